@@ -1,7 +1,15 @@
 package main
 
-import "github.com/dushixiang/uart_sms_forwarder/internal"
+import (
+	"os"
+
+	"github.com/dushixiang/uart_sms_forwarder/internal"
+)
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		internal.Migrate("./config.yaml", os.Args[2:])
+		return
+	}
 	internal.Run("./config.yaml")
 }
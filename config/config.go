@@ -1,10 +1,38 @@
 package config
 
 type AppConfig struct {
-	JWT    JWTConfig         `json:"JWT"`
-	Users  map[string]string `json:"Users"`  // 用户名 -> bcrypt加密的密码
-	Serial SerialConfig      `json:"Serial"` // 串口配置
-	OIDC   *OIDCConfig       `json:"OIDC"`   // OIDC配置（可选）
+	JWT             JWTConfig         `json:"JWT"`
+	Users           map[string]string `json:"Users"`           // 用户名 -> bcrypt加密的密码
+	Serial          SerialConfig      `json:"Serial"`          // 串口配置
+	OIDC            *OIDCConfig       `json:"OIDC"`            // OIDC配置（可选）
+	SecretKey       string            `json:"SecretKey"`       // 配置加密密钥（可选），也可通过环境变量 UART_SMS_SECRET_KEY 设置
+	EncryptMessages bool              `json:"EncryptMessages"` // 加密存储短信内容，密钥由 SecretKey 派生，需同时配置 SecretKey
+	Password        PasswordConfig    `json:"Password"`        // 密码哈希参数
+	Admins          []string          `json:"Admins"`          // 管理员用户名，可查看所有短信并分配会话可见性，为空时所有用户都是管理员
+	Update          UpdateCheckConfig `json:"Update"`          // 新版本检查
+	Spool           SpoolConfig       `json:"Spool"`           // 数据库不可写时的短信暂存
+}
+
+// SpoolConfig 短信暂存配置，数据库暂时不可写（磁盘已满、被锁定）时收到的短信先写入本地文件，恢复后自动入库
+type SpoolConfig struct {
+	Path       string `json:"Path"`       // 暂存文件路径，默认 ./data/spool.jsonl，应与数据库位于不同的磁盘时效果最好
+	MaxEntries int    `json:"MaxEntries"` // 最多暂存的短信条数，超出时丢弃最早的短信，默认 1000
+}
+
+// UpdateCheckConfig 新版本检查配置，定期查询 GitHub Releases 获取最新版本
+type UpdateCheckConfig struct {
+	Disabled      bool   `json:"Disabled"`      // 关闭检查，无法访问外网的环境建议关闭
+	IntervalHours int    `json:"IntervalHours"` // 检查间隔（小时），默认 24
+	Repository    string `json:"Repository"`    // GitHub 仓库，默认 dushixiang/uart_sms_forwarder
+}
+
+// PasswordConfig 用户密码哈希参数，登录成功时按当前参数透明重新计算较弱的哈希
+type PasswordConfig struct {
+	Algorithm         string `json:"Algorithm"`         // bcrypt(默认) 或 argon2id
+	BcryptCost        int    `json:"BcryptCost"`        // bcrypt 计算成本，默认 12
+	Argon2Memory      uint32 `json:"Argon2Memory"`      // argon2id 内存（KiB），默认 65536
+	Argon2Iterations  uint32 `json:"Argon2Iterations"`  // argon2id 迭代次数，默认 3
+	Argon2Parallelism uint8  `json:"Argon2Parallelism"` // argon2id 并行度，默认 2
 }
 
 // JWTConfig JWT配置
@@ -15,7 +43,44 @@ type JWTConfig struct {
 
 // SerialConfig 串口配置
 type SerialConfig struct {
-	Port string `json:"Port"` // 串口路径，为空则自动检测
+	ID            string              `json:"ID"`            // 设备 ID，用于区分多台设备，主设备默认为 default
+	Port          string              `json:"Port"`          // 串口路径，为空则自动检测
+	BaudRate      int                 `json:"BaudRate"`      // 波特率，为 0 时自动探测
+	DataBits      int                 `json:"DataBits"`      // 数据位: 5, 6, 7, 8，默认 8
+	StopBits      float64             `json:"StopBits"`      // 停止位: 1, 1.5, 2，默认 1
+	Parity        string              `json:"Parity"`        // 校验方式: none, odd, even, mark, space，默认 none
+	ReadTimeout   int                 `json:"ReadTimeout"`   // 空闲超时（秒），超过该时间未收到任何数据则重连，默认 120
+	MaxLineLength int                 `json:"MaxLineLength"` // 单行最大长度（字节），超长数据丢弃，默认 65536
+	SendTimeout   int                 `json:"SendTimeout"`   // 等待设备返回发送结果的超时（秒），超时后标记为发送失败，默认 120
+	ConcatTimeout int                 `json:"ConcatTimeout"` // 等待长短信其余分片的超时（秒），超时后按已收到的分片保存，默认 60
+	CapturePath   string              `json:"CapturePath"`   // 串口抓包文件路径，默认 ./data/serial_capture.log
+	Backend       string              `json:"Backend"`       // 设备后端: lua(默认，烧录 main.lua 的模块), at(标准 AT 指令模组), hilink(华为 HiLink 网卡), android(Android 手机), modemmanager(Linux ModemManager)
+	HiLink        *HiLinkConfig       `json:"HiLink"`        // HiLink 后端配置（可选）
+	Android       *AndroidConfig      `json:"Android"`       // Android 后端配置（可选）
+	ModemManager  *ModemManagerConfig `json:"ModemManager"`  // ModemManager 后端配置（可选）
+	Devices       []SerialConfig      `json:"Devices"`       // 同时连接的其他设备（可选），每台设备需配置不同的 ID，发送超时等全局参数使用主设备的配置
+}
+
+// HiLinkConfig 华为 HiLink 网卡配置
+type HiLinkConfig struct {
+	URL             string `json:"URL"`             // 管理地址，默认 http://192.168.8.1
+	Username        string `json:"Username"`        // 登录用户名，默认 admin
+	Password        string `json:"Password"`        // 登录密码，未开启登录可留空
+	PollInterval    int    `json:"PollInterval"`    // 收件箱轮询间隔（秒），默认 10
+	DeleteAfterRead bool   `json:"DeleteAfterRead"` // 转发后删除短信，默认仅标记已读
+}
+
+// AndroidConfig Android 手机配置
+type AndroidConfig struct {
+	Mode         string `json:"Mode"`         // termux(默认，在手机的 Termux 中运行) / adb(在电脑上通过 adb 连接，仅支持接收)
+	ADBPath      string `json:"ADBPath"`      // adb 可执行文件路径，默认 adb
+	Serial       string `json:"Serial"`       // adb 设备序列号，连接多台设备时必填
+	PollInterval int    `json:"PollInterval"` // 收件箱轮询间隔（秒），默认 10
+}
+
+// ModemManagerConfig ModemManager 配置
+type ModemManagerConfig struct {
+	Modem string `json:"Modem"` // 模组 DBus 路径或 IMEI，为空则使用第一个模组
 }
 
 // OIDCConfig OIDC认证配置
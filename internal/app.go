@@ -2,24 +2,25 @@ package internal
 
 import (
 	"context"
+	"errors"
 	"log"
 	"net/http"
+	"os"
 	"strings"
 
 	"github.com/dushixiang/uart_sms_forwarder/config"
 	"github.com/dushixiang/uart_sms_forwarder/internal/handler"
 	"github.com/dushixiang/uart_sms_forwarder/internal/middleware"
-	"github.com/dushixiang/uart_sms_forwarder/internal/models"
+	"github.com/dushixiang/uart_sms_forwarder/internal/migration"
 	"github.com/dushixiang/uart_sms_forwarder/internal/repo"
 	"github.com/dushixiang/uart_sms_forwarder/internal/service"
-	"github.com/dushixiang/uart_sms_forwarder/internal/version"
+	"github.com/dushixiang/uart_sms_forwarder/internal/util"
 	"github.com/dushixiang/uart_sms_forwarder/web"
 	"github.com/go-orz/orz"
 	"github.com/google/uuid"
 	"github.com/labstack/echo/v4"
 	echomiddleware "github.com/labstack/echo/v4/middleware"
 	"go.uber.org/zap"
-	"gorm.io/gorm"
 )
 
 // Handlers 所有Handler的集合
@@ -28,14 +29,51 @@ type Handlers struct {
 	Property      *handler.PropertyHandler
 	TextMessage   *handler.TextMessageHandler
 	Serial        *handler.SerialHandler
+	Version       *handler.VersionHandler
 	ScheduledTask *handler.ScheduledTaskHandler
+	Admin         *handler.AdminHandler
+	Script        *handler.ScriptHandler
+	Extraction    *handler.ExtractionHandler
+	Transaction   *handler.TransactionHandler
+	Spam          *handler.SpamHandler
+	NumberRule    *handler.NumberRuleHandler
+	Push          *handler.PushHandler
+	Backup        *handler.BackupHandler
+	Visibility    *handler.VisibilityHandler
+	Inbound       *handler.InboundHandler
+	Import        *handler.ImportHandler
+	SMSEagle      *handler.SMSEagleHandler
+	Conversation  *handler.ConversationSettingHandler
+	Template      *handler.MessageTemplateHandler
+	Settings      *handler.SettingsHandler
+	DeadLetter    *handler.DeadLetterHandler
+	Alias         *handler.AliasHandler
+	Notification  *handler.NotificationLogHandler
+	Debug         *handler.DebugHandler
+	MessageEvent  *handler.MessageEventHandler
+	SerialCapture *handler.SerialCaptureHandler
+	Standby       *handler.StandbyHandler
+	Recovery      *handler.RegistrationRecoveryHandler
+	MQTT          *handler.MQTTHandler
+	Metrics       *handler.MetricsHandler
+	Audit         *handler.AuditHandler
+	Call          *handler.CallHandler
 }
 
 func Run(configPath string) {
-	err := orz.Quick(configPath, setup)
+	framework, err := orz.NewFramework(
+		orz.WithConfig(configPath),
+		withRotatingLogger(configPath),
+		orz.WithDatabase(),
+		orz.WithHTTP(),
+		orz.WithApplication(orz.NewSimpleApp(setup)),
+	)
 	if err != nil {
 		log.Fatal(err)
 	}
+	if err := framework.Run(); err != nil {
+		log.Fatal(err)
+	}
 }
 
 func setup(app *orz.App) error {
@@ -43,7 +81,7 @@ func setup(app *orz.App) error {
 	db := app.GetDatabase()
 
 	// 1. 数据库迁移
-	if err := autoMigrate(db); err != nil {
+	if err := migration.NewRunner(logger, db).Up(); err != nil {
 		logger.Error("数据库迁移失败", zap.Error(err))
 		return err
 	}
@@ -63,14 +101,56 @@ func setup(app *orz.App) error {
 
 	// 4. 初始化 Repository
 	textMessageRepo := repo.NewTextMessageRepo(db)
+	pushDeviceRepo := repo.NewPushDeviceRepo(db)
 
 	// 5. 初始化 Service
 	propertyService := service.NewPropertyService(logger, db)
 	notifier := service.NewNotifier(logger)
+	notifier.SetPushDeviceStore(pushDeviceRepo)
+	pushService := service.NewPushService(logger, pushDeviceRepo, propertyService)
+	notifier.SetVAPIDKeySource(pushService.VAPIDKeys)
 	textMessageService := service.NewTextMessageService(logger, textMessageRepo)
+	// 会话可见性
+	visibilityService := service.NewVisibilityService(logger, db, appConfig.Admins)
+	textMessageService.SetVisibilityService(visibilityService)
+	// 号码别名（会话合并）
+	aliasService := service.NewAliasService(logger, db)
+	textMessageService.SetAliasService(aliasService)
+
+	// 配置中的敏感字段加密存储
+	ctx := context.Background()
+	if appConfig.SecretKey != "" {
+		box, err := util.NewSecretBox(appConfig.SecretKey)
+		if err != nil {
+			logger.Error("初始化配置加密失败", zap.Error(err))
+			return err
+		}
+		propertyService.SetSecretBox(box)
+		count, err := propertyService.EncryptExisting(ctx)
+		if err != nil {
+			logger.Error("加密已有配置失败", zap.Error(err))
+			return err
+		}
+		if count > 0 {
+			logger.Info("已加密已有配置中的敏感字段", zap.Int("count", count))
+		}
+	}
+
+	// 短信内容加密存储，密钥由 SecretKey 派生，与配置加密的密钥相互独立。
+	// 关闭加密后仍需配置 SecretKey 才能读取以前加密的短信。
+	if appConfig.SecretKey != "" {
+		messageBox, err := util.NewDerivedSecretBox(appConfig.SecretKey, "messages")
+		if err != nil {
+			logger.Error("初始化短信加密失败", zap.Error(err))
+			return err
+		}
+		service.SetMessageEncryption(messageBox, appConfig.EncryptMessages)
+	} else if appConfig.EncryptMessages {
+		logger.Error("启用短信内容加密需要配置 App.SecretKey")
+		return errors.New("启用短信内容加密需要配置 App.SecretKey")
+	}
 
 	// 初始化默认配置
-	ctx := context.Background()
 	if err := propertyService.InitializeDefaultConfigs(ctx); err != nil {
 		logger.Error("初始化默认配置失败", zap.Error(err))
 	}
@@ -92,30 +172,127 @@ func setup(app *orz.App) error {
 	)
 	serialService.SetScheduledTaskStatusUpdater(schedulerService.UpdateLastRunStatusByMsgId)
 
+	// 短信处理脚本
+	scriptService := service.NewScriptService(logger, propertyService)
+	serialService.SetScriptService(scriptService)
+	serialService.SetPluginService(service.NewPluginService(logger, propertyService))
+
+	// 字段提取
+	extractionService := service.NewExtractionService(logger, propertyService)
+	serialService.SetExtractionService(extractionService)
+
+	// 银行交易解析
+	transactionService := service.NewTransactionService(logger, db, textMessageRepo)
+	transactionService.SetVisibilityService(visibilityService)
+	serialService.SetTransactionService(transactionService)
+
+	// 垃圾短信识别
+	spamService := service.NewSpamService(logger, db, propertyService, textMessageRepo)
+	serialService.SetSpamService(spamService)
+
+	// 号码分类规则
+	numberRuleService := service.NewNumberRuleService(logger, db)
+	serialService.SetNumberRuleService(numberRuleService)
+	conversationSettingService := service.NewConversationSettingService(logger, db)
+	serialService.SetConversationSettingService(conversationSettingService)
+	// 来电规则和来电记录
+	callService := service.NewCallService(logger, db, propertyService)
+	callService.SetVisibilityService(visibilityService)
+	serialService.SetCallService(callService)
+
+	// 无法解析的串口帧
+	deadLetterService := service.NewDeadLetterService(logger, db, serialService)
+	serialService.SetDeadLetterService(deadLetterService)
+	notificationLogService := service.NewNotificationLogService(logger, db)
+	serialService.SetNotificationLogService(notificationLogService)
+	// 数据库不可写时暂存收到的短信
+	messageSpool := service.NewMessageSpool(logger, appConfig.Spool)
+	serialService.SetMessageSpool(messageSpool)
+	// 串口抓包，用于排查协议问题
+	serialCapture := service.NewSerialCapture(logger, appConfig.Serial.CapturePath)
+	serialService.SetSerialCapture(serialCapture)
+
+	// 夜间待机
+	standbyService := service.NewStandbyService(logger, propertyService, serialService)
+	// 网络注册自动恢复
+	recoveryService := service.NewRegistrationRecoveryService(logger, db, propertyService, serialService)
+	// MQTT 发布
+	mqttService := service.NewMQTTService(logger, propertyService, serialService)
+	// 外部监控心跳
+	healthcheckService := service.NewHealthcheckService(logger, propertyService, serialService)
+	serialService.SetHealthcheckService(healthcheckService)
+	schedulerService.SetHealthcheckService(healthcheckService)
+
+	// 数据库维护服务
+	maintenanceService := service.NewMaintenanceService(logger, db)
+	systemService := service.NewSystemService(logger, db)
+	storageMonitor := service.NewStorageMonitor(logger, systemService, propertyService, serialService.SendSystemNotification)
+	backupService := service.NewBackupService(logger, db, propertyService, serialService.SendSystemNotification)
+
+	// 新版本检查
+	updateService := service.NewUpdateService(logger, appConfig.Update)
+
 	// 8. 初始化 OIDC 和 Account Service
 	oidcService := service.NewOIDCService(logger, &appConfig)
-	accountService := service.NewAccountService(logger, oidcService, &appConfig)
+	accountService := service.NewAccountService(logger, oidcService, propertyService, &appConfig)
 
 	// 9. 初始化 Handler
 	authHandler := handler.NewAuthHandler(logger, accountService)
 	propertyHandler := handler.NewPropertyHandler(logger, propertyService, notifier)
 	textMessageHandler := handler.NewTextMessageHandler(logger, textMessageService, textMessageRepo)
-	serialHandler := handler.NewSerialHandler(logger, serialService)
+	messageTemplateService := service.NewMessageTemplateService(logger, db)
+	smsEagleService := service.NewSMSEagleService(logger, propertyService, accountService, serialService)
+	smsEagleService.SetVisibilityService(visibilityService)
+
+	serialHandler := handler.NewSerialHandler(logger, serialService, messageTemplateService)
 	scheduledTaskHandler := handler.NewScheduledTaskHandler(logger, schedulerService)
+	adminHandler := handler.NewAdminHandler(logger, maintenanceService, systemService, storageMonitor)
 
 	handlers := &Handlers{
 		Auth:          authHandler,
 		Property:      propertyHandler,
 		TextMessage:   textMessageHandler,
 		Serial:        serialHandler,
+		Version:       handler.NewVersionHandler(logger, serialService, updateService),
 		ScheduledTask: scheduledTaskHandler,
+		Admin:         adminHandler,
+		Script:        handler.NewScriptHandler(logger, scriptService),
+		Extraction:    handler.NewExtractionHandler(logger, extractionService),
+		Transaction:   handler.NewTransactionHandler(logger, transactionService),
+		Spam:          handler.NewSpamHandler(logger, spamService),
+		NumberRule:    handler.NewNumberRuleHandler(logger, numberRuleService),
+		Call:          handler.NewCallHandler(logger, callService),
+		Push:          handler.NewPushHandler(logger, pushService),
+		Backup:        handler.NewBackupHandler(logger, backupService),
+		Visibility:    handler.NewVisibilityHandler(logger, visibilityService),
+		Alias:         handler.NewAliasHandler(logger, aliasService),
+		Notification:  handler.NewNotificationLogHandler(logger, notificationLogService),
+		Debug:         handler.NewDebugHandler(logger, service.NewDebugEchoService()),
+		Inbound:       handler.NewInboundHandler(logger, service.NewInboundService(logger, propertyService, serialService)),
+		Import:        handler.NewImportHandler(logger, service.NewGammuImportService(logger, textMessageService)),
+		SMSEagle:      handler.NewSMSEagleHandler(logger, smsEagleService),
+		Conversation:  handler.NewConversationSettingHandler(logger, conversationSettingService),
+		Template:      handler.NewMessageTemplateHandler(logger, messageTemplateService),
+		Settings:      handler.NewSettingsHandler(logger, service.NewSettingsService(logger, db, propertyService)),
+		DeadLetter:    handler.NewDeadLetterHandler(logger, deadLetterService),
+		MessageEvent:  handler.NewMessageEventHandler(logger, serialService, visibilityService),
+		SerialCapture: handler.NewSerialCaptureHandler(logger, serialCapture),
+		Standby:       handler.NewStandbyHandler(logger, standbyService),
+		Recovery:      handler.NewRegistrationRecoveryHandler(logger, recoveryService),
+		MQTT:          handler.NewMQTTHandler(logger, mqttService),
+		Metrics:       handler.NewMetricsHandler(logger, service.NewMetricsService(propertyService, serialService)),
+		Audit:         handler.NewAuditHandler(logger, service.NewAuditExportService(logger, db, propertyService)),
 	}
 
 	// 10. 设置 API 路由
-	setupApi(app, handlers, &appConfig, logger)
+	setupApi(app, handlers, visibilityService, &appConfig, logger)
 
 	// 11. 启动后台服务
 	background := context.Background()
+	// 上次运行中断时仍在发送中的短信不会再收到发送结果
+	serialService.FailStuckSending(background)
+	// 写回上次运行时暂存的短信，之后定期检查
+	messageSpool.Start(background, serialService.SaveSpooled)
 	// 启动串口服务
 	go serialService.Start()
 
@@ -126,6 +303,43 @@ func setup(app *orz.App) error {
 		logger.Info("定时任务服务启动成功")
 	}
 
+	// 启动数据库定期维护
+	if err := maintenanceService.Start(); err != nil {
+		logger.Error("启动数据库维护服务失败", zap.Error(err))
+	}
+
+	// 启动存储空间监控
+	storageMonitor.Start()
+
+	// 启动夜间待机计划
+	standbyService.Start()
+
+	// 启动网络注册自动恢复
+	recoveryService.Start()
+
+	// 启动 MQTT 发布
+	mqttService.Start()
+
+	// 启动外部监控心跳
+	healthcheckService.Start()
+
+	// 启动新版本检查
+	updateService.Start(background)
+
+	// 启用短信内容加密后，在后台加密以前保存的明文短信
+	if appConfig.EncryptMessages {
+		go func() {
+			if _, err := textMessageService.EncryptExisting(background); err != nil {
+				logger.Error("加密已有短信失败", zap.Error(err))
+			}
+		}()
+	}
+
+	// 启动定时远程备份
+	if err := backupService.Start(background); err != nil {
+		logger.Error("启动远程备份服务失败", zap.Error(err))
+	}
+
 	logger.Info("应用启动完成")
 	return nil
 }
@@ -140,20 +354,18 @@ func setDefaultConfig(appConfig *config.AppConfig, logger *zap.Logger) {
 	if appConfig.JWT.ExpiresHours == 0 {
 		appConfig.JWT.ExpiresHours = 168 // 7天
 	}
-}
-
-// autoMigrate 数据库迁移
-func autoMigrate(db *gorm.DB) error {
-	return db.AutoMigrate(
-		&models.Property{},
-		&models.TextMessage{},
-		&models.ScheduledTask{},
-	)
+	// 环境变量优先，避免密钥与数据库一起出现在配置文件备份中
+	if key := os.Getenv("UART_SMS_SECRET_KEY"); key != "" {
+		appConfig.SecretKey = key
+	}
 }
 
 // setupApi 设置API路由
-func setupApi(app *orz.App, handlers *Handlers, appConfig *config.AppConfig, logger *zap.Logger) {
+func setupApi(app *orz.App, handlers *Handlers, visibilityService *service.VisibilityService, appConfig *config.AppConfig, logger *zap.Logger) {
 	e := app.GetEcho()
+	// 错误统一以 {code, message, requestId} 格式返回
+	e.HTTPErrorHandler = handler.HTTPErrorHandler(logger)
+	e.Use(echomiddleware.RequestID())
 
 	e.Use(echomiddleware.StaticWithConfig(echomiddleware.StaticConfig{
 		Skipper: func(c echo.Context) bool {
@@ -164,6 +376,12 @@ func setupApi(app *orz.App, handlers *Handlers, appConfig *config.AppConfig, log
 			if strings.HasPrefix(c.Request().RequestURI, "/health") {
 				return true
 			}
+			if strings.HasPrefix(c.Request().RequestURI, "/metrics") {
+				return true
+			}
+			if strings.HasPrefix(c.Request().RequestURI, "/http_api") {
+				return true
+			}
 			return false
 		},
 		Index:      "index.html",
@@ -179,43 +397,175 @@ func setupApi(app *orz.App, handlers *Handlers, appConfig *config.AppConfig, log
 	e.GET("/api/auth/oidc/url", handlers.Auth.GetOIDCAuthURL)
 	e.POST("/api/auth/oidc/callback", handlers.Auth.OIDCCallback)
 
+	// 外部设备推送短信（使用推送接口配置中的密钥校验，不需要登录）
+	e.POST("/api/inbound/smsforwarder", handlers.Inbound.SmsForwarder)
+	// 通用消息接入（使用配置 ingest_api 中的密钥校验，不需要登录）
+	e.POST("/api/ingest", handlers.Inbound.Ingest)
+
+	// Webhook 调试接口，记录收到的请求供查看（不需要登录，只保存在内存中）
+	e.Any("/api/debug/echo", handlers.Debug.Echo)
+	e.Any("/api/debug/echo/*", handlers.Debug.Echo)
+
+	// SMSEagle 兼容接口（使用账号密码或 access_token 校验，需在配置 smseagle_api 中启用）
+	e.GET("/http_api/send_sms", handlers.SMSEagle.SendSMS)
+	e.POST("/http_api/send_sms", handlers.SMSEagle.SendSMS)
+
+	// Prometheus 指标（需在配置 metrics 中启用，配置了 token 时使用 Bearer 令牌校验）
+	e.GET("/metrics", handlers.Metrics.GetMetrics)
+
 	// API 路由组（需要认证）
 	api := e.Group("/api")
 	api.Use(middleware.JWTMiddleware(appConfig.JWT.Secret, logger))
+	api.Use(middleware.ViewerMiddleware(visibilityService))
+
+	// 管理接口（仅管理员）：可修改转发渠道、读取密钥或导出数据库，非管理员能借此绕过会话可见性
+	adminApi := api.Group("", middleware.AdminMiddleware())
 
 	// Version
-	api.GET("/version", func(c echo.Context) error {
-		return c.JSON(http.StatusOK, echo.Map{
-			"version": version.GetVersion(),
-		})
-	})
+	api.GET("/version", handlers.Version.GetVersion)
 
 	// Property API
-	api.GET("/properties/:id", handlers.Property.GetProperty)
-	api.PUT("/properties/:id", handlers.Property.SetProperty)
-	api.POST("/notifications/:type/test", handlers.Property.TestNotificationChannel)
+	adminApi.GET("/properties/:id", handlers.Property.GetProperty)
+	adminApi.PUT("/properties/:id", handlers.Property.SetProperty)
+	adminApi.POST("/notifications/:type/test", handlers.Property.TestNotificationChannel)
+	api.GET("/notifications/logs", handlers.Notification.List)
 
 	// TextMessage API
+	api.GET("/messages", handlers.TextMessage.List)
+	api.GET("/ws", handlers.MessageEvent.Stream)
 	api.GET("/messages/stats", handlers.TextMessage.GetStats)
+	api.GET("/messages/stats/daily", handlers.TextMessage.GetDailyStats)
+	api.GET("/messages/stats/heatmap", handlers.TextMessage.GetHeatmapStats)
+	api.GET("/messages/suggest", handlers.TextMessage.Suggest)
+	api.GET("/messages/export", handlers.TextMessage.Export)
 	api.GET("/messages/conversations", handlers.TextMessage.GetConversations)
 	api.GET("/messages/conversations/:peer/messages", handlers.TextMessage.GetConversationMessages)
+	api.GET("/messages/conversations/:peer/stats", handlers.TextMessage.GetConversationStats)
+	api.GET("/messages/conversations/:peer/export", handlers.TextMessage.ExportConversation)
 	api.DELETE("/messages/conversations/:peer", handlers.TextMessage.DeleteConversation)
+	api.POST("/messages/conversations/:peer/merge", handlers.Alias.Merge)
+	api.POST("/messages/conversations/:peer/unmerge", handlers.Alias.Unmerge)
+	api.POST("/messages/batch", handlers.TextMessage.Batch)
+	api.POST("/messages/:id/spam", handlers.Spam.Train)
+	api.POST("/messages/:id/forward", handlers.Serial.ForwardMessage)
+	api.POST("/messages/:id/pin", handlers.TextMessage.Pin)
+	api.DELETE("/messages/:id/pin", handlers.TextMessage.Unpin)
+	api.POST("/ack/:id", handlers.Serial.AckMessage)
 	api.DELETE("/messages/:id", handlers.TextMessage.Delete)
 	api.DELETE("/messages", handlers.TextMessage.Clear)
 
+	// Peer Assignment API（会话可见性，仅管理员）
+	api.GET("/peer-assignments", handlers.Visibility.List)
+	api.PUT("/peer-assignments/:peer", handlers.Visibility.Assign)
+	api.DELETE("/peer-assignments/:peer", handlers.Visibility.Unassign)
+
+	// Number Alias API（会话合并）
+	api.GET("/number-aliases", handlers.Alias.List)
+
 	// Serial API
 	api.POST("/serial/sms", handlers.Serial.SendSMS)
+	api.GET("/serial/sms/usage", handlers.Serial.GetSendUsage)
+	api.GET("/serial/sms/pending", handlers.Serial.ListPendingApproval)
+	api.POST("/serial/sms/:id/approve", handlers.Serial.ApproveSMS)
+	api.POST("/serial/sms/:id/reject", handlers.Serial.RejectSMS)
+	api.GET("/serial/sms/:id/events", handlers.Serial.SendSMSEvents)
 	api.GET("/serial/status", handlers.Serial.GetStatus) // 包含移动网络信息
+	api.GET("/serial/devices", handlers.Serial.GetDevices)
+	api.POST("/serial/status/refresh", handlers.Serial.RefreshStatus)
 	api.POST("/serial/flymode", handlers.Serial.SetFlymode)
-	api.POST("/serial/reboot", handlers.Serial.RebootMcu)
+	api.POST("/serial/ussd", handlers.Serial.SendUSSD)
+	api.GET("/serial/standby", handlers.Standby.GetStatus)
+	api.POST("/serial/standby", handlers.Standby.Override)
+	api.GET("/serial/recovery", handlers.Recovery.GetStatus)
+	api.GET("/serial/recovery/logs", handlers.Recovery.List)
+	api.GET("/mqtt/status", handlers.MQTT.GetStatus)
+	adminApi.POST("/serial/reboot", handlers.Serial.RebootMcu)
 
 	// ScheduledTask API (RESTful)
-	api.GET("/scheduled-tasks", handlers.ScheduledTask.List)
-	api.GET("/scheduled-tasks/:id", handlers.ScheduledTask.Get)
-	api.POST("/scheduled-tasks", handlers.ScheduledTask.Create)
-	api.PUT("/scheduled-tasks/:id", handlers.ScheduledTask.Update)
-	api.DELETE("/scheduled-tasks/:id", handlers.ScheduledTask.Delete)
-	api.POST("/scheduled-tasks/:id/trigger", handlers.ScheduledTask.Trigger)
+	adminApi.GET("/scheduled-tasks", handlers.ScheduledTask.List)
+	adminApi.GET("/scheduled-tasks/:id", handlers.ScheduledTask.Get)
+	adminApi.POST("/scheduled-tasks", handlers.ScheduledTask.Create)
+	adminApi.PUT("/scheduled-tasks/:id", handlers.ScheduledTask.Update)
+	adminApi.DELETE("/scheduled-tasks/:id", handlers.ScheduledTask.Delete)
+	adminApi.POST("/scheduled-tasks/:id/trigger", handlers.ScheduledTask.Trigger)
+	adminApi.POST("/scheduled-tasks/:id/run", handlers.ScheduledTask.Run)
+
+	// Admin API（仅管理员）
+	adminApi.GET("/admin/maintenance", handlers.Admin.GetMaintenance)
+	adminApi.POST("/admin/maintenance", handlers.Admin.RunMaintenance)
+	adminApi.GET("/admin/backup", handlers.Backup.GetBackup)
+	adminApi.PUT("/admin/backup", handlers.Backup.UpdateBackup)
+	adminApi.POST("/admin/backup/run", handlers.Backup.RunBackup)
+	adminApi.POST("/admin/import/gammu", handlers.Import.ImportGammu)
+	adminApi.GET("/admin/settings/export", handlers.Settings.Export)
+	adminApi.POST("/admin/settings/import", handlers.Settings.Import)
+	adminApi.GET("/admin/system", handlers.Admin.GetSystem)
+	adminApi.POST("/admin/messages/encrypt", handlers.TextMessage.EncryptContent)
+	adminApi.POST("/admin/messages/decrypt", handlers.TextMessage.DecryptContent)
+	adminApi.GET("/admin/dead-letters", handlers.DeadLetter.List)
+	adminApi.POST("/admin/dead-letters/reprocess", handlers.DeadLetter.ReprocessPending)
+	adminApi.POST("/admin/dead-letters/:id/reprocess", handlers.DeadLetter.Reprocess)
+	adminApi.DELETE("/admin/dead-letters/:id", handlers.DeadLetter.Delete)
+	adminApi.GET("/admin/serial/capture", handlers.SerialCapture.GetStatus)
+	adminApi.POST("/admin/serial/capture", handlers.SerialCapture.SetCapture)
+	adminApi.DELETE("/admin/serial/capture", handlers.SerialCapture.Clear)
+	adminApi.GET("/admin/serial/capture/download", handlers.SerialCapture.Download)
+	adminApi.GET("/admin/audit/export", handlers.Audit.Export)
+	adminApi.GET("/admin/audit/public-key", handlers.Audit.GetPublicKey)
+
+	// Debug API
+	api.GET("/debug/echo-requests", handlers.Debug.ListEchoRequests)
+	api.DELETE("/debug/echo-requests", handlers.Debug.ClearEchoRequests)
+
+	// Message Script API
+	adminApi.POST("/message-script/test", handlers.Script.TestScript)
+
+	// Extraction Rules API
+	api.POST("/extraction-rules/test", handlers.Extraction.TestRules)
+
+	// Number Rule API
+	adminApi.GET("/number-rules", handlers.NumberRule.List)
+	adminApi.GET("/number-rules/:id", handlers.NumberRule.Get)
+	adminApi.POST("/number-rules", handlers.NumberRule.Create)
+	adminApi.PUT("/number-rules/:id", handlers.NumberRule.Update)
+	adminApi.DELETE("/number-rules/:id", handlers.NumberRule.Delete)
+
+	// Call API
+	api.GET("/calls", handlers.Call.List)
+	adminApi.GET("/call-rules", handlers.Call.ListRules)
+	adminApi.GET("/call-rules/:id", handlers.Call.GetRule)
+	adminApi.POST("/call-rules", handlers.Call.CreateRule)
+	adminApi.PUT("/call-rules/:id", handlers.Call.UpdateRule)
+	adminApi.DELETE("/call-rules/:id", handlers.Call.DeleteRule)
+
+	// Conversation Setting API
+	api.GET("/conversation-settings", handlers.Conversation.List)
+	api.PUT("/conversation-settings/:peer", handlers.Conversation.Save)
+	api.DELETE("/conversation-settings/:peer", handlers.Conversation.Delete)
+
+	// Message Template API
+	api.GET("/message-templates", handlers.Template.List)
+	api.POST("/message-templates", handlers.Template.Create)
+	api.PUT("/message-templates/:id", handlers.Template.Update)
+	api.DELETE("/message-templates/:id", handlers.Template.Delete)
+	api.POST("/message-templates/:id/render", handlers.Template.Render)
+
+	// Push Device API
+	api.GET("/push/devices", handlers.Push.ListDevices)
+	api.POST("/push/devices", handlers.Push.RegisterDevice)
+	api.DELETE("/push/devices/:id", handlers.Push.DeleteDevice)
+	api.GET("/push/webpush/public-key", handlers.Push.GetVAPIDPublicKey)
+	api.POST("/push/webpush/subscriptions", handlers.Push.Subscribe)
+	api.DELETE("/push/webpush/subscriptions", handlers.Push.Unsubscribe)
+
+	// Spam Filter API
+	api.POST("/spam-filter/test", handlers.Spam.Test)
+
+	// Transaction API
+	api.GET("/transactions", handlers.Transaction.List)
+	api.GET("/transactions/summary/monthly", handlers.Transaction.GetMonthlySummary)
+	api.GET("/transactions/summary/merchants", handlers.Transaction.GetMerchantSummary)
+	api.POST("/transactions/rebuild", handlers.Transaction.Rebuild)
 
 	// 健康检查接口（无需认证）
 	e.GET("/health", func(c echo.Context) error {
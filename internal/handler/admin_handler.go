@@ -0,0 +1,63 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/dushixiang/uart_sms_forwarder/internal/service"
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+)
+
+// AdminHandler 系统管理API处理器
+type AdminHandler struct {
+	logger             *zap.Logger
+	maintenanceService *service.MaintenanceService
+	systemService      *service.SystemService
+	storageMonitor     *service.StorageMonitor
+}
+
+// NewAdminHandler 创建系统管理Handler实例
+func NewAdminHandler(logger *zap.Logger, maintenanceService *service.MaintenanceService, systemService *service.SystemService, storageMonitor *service.StorageMonitor) *AdminHandler {
+	return &AdminHandler{
+		logger:             logger,
+		maintenanceService: maintenanceService,
+		systemService:      systemService,
+		storageMonitor:     storageMonitor,
+	}
+}
+
+// GetMaintenance 获取最近一次数据库维护结果
+// GET /api/admin/maintenance
+func (h *AdminHandler) GetMaintenance(c echo.Context) error {
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"schedule": service.MaintenanceCronSpec,
+		"last":     h.maintenanceService.LastResult(),
+	})
+}
+
+// RunMaintenance 立即执行数据库维护（VACUUM / ANALYZE）
+// POST /api/admin/maintenance
+func (h *AdminHandler) RunMaintenance(c echo.Context) error {
+	result, err := h.maintenanceService.Run(c.Request().Context())
+	if err != nil {
+		h.logger.Error("数据库维护失败", zap.Error(err))
+		return Fail(http.StatusInternalServerError, err.Error())
+	}
+
+	return c.JSON(http.StatusOK, result)
+}
+
+// SystemResponse 系统资源信息响应
+type SystemResponse struct {
+	*service.SystemInfo
+	Alerts []service.StorageAlert `json:"alerts"` // 当前存储空间告警
+}
+
+// GetSystem 获取主机 CPU 负载、内存、磁盘剩余空间和进程信息
+// GET /api/admin/system
+func (h *AdminHandler) GetSystem(c echo.Context) error {
+	return c.JSON(http.StatusOK, SystemResponse{
+		SystemInfo: h.systemService.GetSystemInfo(c.Request().Context()),
+		Alerts:     h.storageMonitor.Alerts(),
+	})
+}
@@ -0,0 +1,93 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+
+	"github.com/dushixiang/uart_sms_forwarder/internal/models"
+	"github.com/dushixiang/uart_sms_forwarder/internal/service"
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+)
+
+// AliasHandler 号码别名（会话合并）API处理器
+type AliasHandler struct {
+	logger       *zap.Logger
+	aliasService *service.AliasService
+}
+
+// NewAliasHandler 创建号码别名Handler实例
+func NewAliasHandler(logger *zap.Logger, aliasService *service.AliasService) *AliasHandler {
+	return &AliasHandler{
+		logger:       logger,
+		aliasService: aliasService,
+	}
+}
+
+// List 获取所有号码别名
+// GET /api/number-aliases
+func (h *AliasHandler) List(c echo.Context) error {
+	aliases, err := h.aliasService.List(c.Request().Context())
+	if err != nil {
+		h.logger.Error("获取号码别名失败", zap.Error(err))
+		return Fail(http.StatusInternalServerError, "获取号码别名失败")
+	}
+
+	if aliases == nil {
+		aliases = []models.NumberAlias{}
+	}
+	return c.JSON(http.StatusOK, aliases)
+}
+
+// MergeRequest 合并或拆分会话请求
+type MergeRequest struct {
+	Numbers []string `json:"numbers"` // 别名号码
+}
+
+// Merge 将其他号码合并到会话中，合并后这些号码的短信在同一个会话中显示
+// POST /api/messages/conversations/:peer/merge
+// Body: {"numbers": ["95588", "+8695588"]}
+func (h *AliasHandler) Merge(c echo.Context) error {
+	var req MergeRequest
+	if err := c.Bind(&req); err != nil {
+		return Fail(http.StatusBadRequest, "请求参数错误")
+	}
+
+	peer, err := url.QueryUnescape(c.Param("peer"))
+	if err != nil {
+		peer = c.Param("peer")
+	}
+	group, err := h.aliasService.Merge(c.Request().Context(), peer, req.Numbers)
+	if err != nil {
+		h.logger.Error("合并会话失败", zap.String("peer", peer), zap.Error(err))
+		return failService(http.StatusBadRequest, err)
+	}
+
+	return c.JSON(http.StatusOK, group)
+}
+
+// Unmerge 将别名号码从会话中拆出，恢复为独立的会话
+// POST /api/messages/conversations/:peer/unmerge
+// Body: {"numbers": ["95588"]}
+func (h *AliasHandler) Unmerge(c echo.Context) error {
+	var req MergeRequest
+	if err := c.Bind(&req); err != nil {
+		return Fail(http.StatusBadRequest, "请求参数错误")
+	}
+
+	peer, err := url.QueryUnescape(c.Param("peer"))
+	if err != nil {
+		peer = c.Param("peer")
+	}
+	group, err := h.aliasService.Unmerge(c.Request().Context(), peer, req.Numbers)
+	if err != nil {
+		if errors.Is(err, service.ErrAliasNotFound) {
+			return Fail(http.StatusNotFound, err.Error())
+		}
+		h.logger.Error("拆分会话失败", zap.String("peer", peer), zap.Error(err))
+		return failService(http.StatusInternalServerError, err)
+	}
+
+	return c.JSON(http.StatusOK, group)
+}
@@ -0,0 +1,148 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/dushixiang/uart_sms_forwarder/internal/service"
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+)
+
+// 错误码，API 客户端据此判断错误类型，message 为面向用户的说明
+const (
+	CodeInvalidRequest       = "invalid_request"
+	CodeUnauthorized         = "unauthorized"
+	CodeForbidden            = "forbidden"
+	CodeAdminRequired        = "admin_required"
+	CodeNotFound             = "not_found"
+	CodeConflict             = "conflict"
+	CodeUnprocessable        = "unprocessable"
+	CodeIdempotencyKeyReused = "idempotency_key_reused"
+	CodeSendRejected         = "send_rejected"
+	CodeQuotaExceeded        = "quota_exceeded"
+	CodeTooManyRequests      = "too_many_requests"
+	CodeInternal             = "internal_error"
+	CodeServiceUnavailable   = "service_unavailable"
+	CodeDeviceTimeout        = "device_timeout"
+)
+
+// ErrorResponse 统一的错误响应
+type ErrorResponse struct {
+	Code      string `json:"code"`              // 错误码
+	Message   string `json:"message"`           // 错误说明
+	RequestID string `json:"requestId"`         // 请求 ID，与响应头 X-Request-Id 相同，便于在日志中定位
+	Details   any    `json:"details,omitempty"` // 附加信息
+}
+
+// APIError 处理器返回的错误，由 HTTPErrorHandler 转换为 ErrorResponse
+type APIError struct {
+	Status  int
+	Code    string
+	Message string
+	Details any
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("%d %s: %s", e.Status, e.Code, e.Message)
+}
+
+// Fail 返回指定状态码的错误，错误码按状态码确定
+func Fail(status int, message string) error {
+	return &APIError{Status: status, Code: codeForStatus(status), Message: message}
+}
+
+// FailCode 返回指定状态码和错误码的错误
+func FailCode(status int, code, message string) error {
+	return &APIError{Status: status, Code: code, Message: message}
+}
+
+// failService 将服务返回的错误转换为 API 错误，非管理员访问管理功能时返回 403
+func failService(status int, err error) error {
+	if errors.Is(err, service.ErrAdminRequired) {
+		return FailCode(http.StatusForbidden, CodeAdminRequired, err.Error())
+	}
+	return Fail(status, err.Error())
+}
+
+// codeForStatus 状态码对应的默认错误码
+func codeForStatus(status int) string {
+	switch status {
+	case http.StatusBadRequest:
+		return CodeInvalidRequest
+	case http.StatusUnauthorized:
+		return CodeUnauthorized
+	case http.StatusForbidden:
+		return CodeForbidden
+	case http.StatusNotFound:
+		return CodeNotFound
+	case http.StatusConflict:
+		return CodeConflict
+	case http.StatusUnprocessableEntity:
+		return CodeUnprocessable
+	case http.StatusTooManyRequests:
+		return CodeTooManyRequests
+	case http.StatusServiceUnavailable:
+		return CodeServiceUnavailable
+	case http.StatusGatewayTimeout:
+		return CodeDeviceTimeout
+	}
+	if status >= 500 {
+		return CodeInternal
+	}
+	return CodeInvalidRequest
+}
+
+// HTTPErrorHandler 统一的错误处理器，处理器和中间件返回的错误都以 ErrorResponse 格式响应
+func HTTPErrorHandler(logger *zap.Logger) echo.HTTPErrorHandler {
+	return func(err error, c echo.Context) {
+		if c.Response().Committed {
+			return
+		}
+
+		status := http.StatusInternalServerError
+		resp := ErrorResponse{
+			Code:      CodeInternal,
+			Message:   "服务器内部错误",
+			RequestID: c.Response().Header().Get(echo.HeaderXRequestID),
+		}
+
+		var apiErr *APIError
+		var httpErr *echo.HTTPError
+		switch {
+		case errors.Is(err, service.ErrAdminRequired):
+			status = http.StatusForbidden
+			resp.Code = CodeAdminRequired
+			resp.Message = err.Error()
+		case errors.As(err, &apiErr):
+			status = apiErr.Status
+			resp.Code = apiErr.Code
+			resp.Message = apiErr.Message
+			resp.Details = apiErr.Details
+		case errors.As(err, &httpErr):
+			status = httpErr.Code
+			resp.Code = codeForStatus(status)
+			if message, ok := httpErr.Message.(string); ok {
+				resp.Message = message
+			} else {
+				resp.Message = http.StatusText(status)
+			}
+		default:
+			logger.Error("请求处理失败",
+				zap.String("method", c.Request().Method),
+				zap.String("path", c.Path()),
+				zap.String("request_id", resp.RequestID),
+				zap.Error(err))
+		}
+
+		if c.Request().Method == http.MethodHead {
+			err = c.NoContent(status)
+		} else {
+			err = c.JSON(status, resp)
+		}
+		if err != nil {
+			logger.Error("写入错误响应失败", zap.Error(err))
+		}
+	}
+}
@@ -0,0 +1,124 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/dushixiang/uart_sms_forwarder/internal/middleware"
+	"github.com/dushixiang/uart_sms_forwarder/internal/models"
+	"github.com/dushixiang/uart_sms_forwarder/internal/service"
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+)
+
+func TestHTTPErrorHandler(t *testing.T) {
+	tests := []struct {
+		name    string
+		err     error
+		status  int
+		code    string
+		message string
+	}{
+		{name: "非管理员", err: failService(http.StatusInternalServerError, service.ErrAdminRequired), status: http.StatusForbidden, code: CodeAdminRequired},
+		{name: "中间件返回的非管理员错误", err: service.ErrAdminRequired, status: http.StatusForbidden, code: CodeAdminRequired},
+		{name: "包装的非管理员错误", err: fmt.Errorf("删除失败: %w", service.ErrAdminRequired), status: http.StatusForbidden, code: CodeAdminRequired},
+		{name: "发送被拒绝", err: FailCode(http.StatusForbidden, CodeSendRejected, service.ErrOutgoingRejected.Error()), status: http.StatusForbidden, code: CodeSendRejected},
+		{name: "不存在", err: Fail(http.StatusNotFound, "短信不存在"), status: http.StatusNotFound, code: CodeNotFound, message: "短信不存在"},
+		{name: "服务错误", err: failService(http.StatusBadRequest, errors.New("号码不能为空")), status: http.StatusBadRequest, code: CodeInvalidRequest, message: "号码不能为空"},
+		{name: "echo 错误透传", err: echo.NewHTTPError(http.StatusUnauthorized, "缺少认证信息"), status: http.StatusUnauthorized, code: CodeUnauthorized, message: "缺少认证信息"},
+		{name: "echo 错误非字符串消息", err: echo.NewHTTPError(http.StatusTooManyRequests, map[string]string{"a": "b"}), status: http.StatusTooManyRequests, code: CodeTooManyRequests, message: http.StatusText(http.StatusTooManyRequests)},
+		{name: "未知错误", err: errors.New("database is locked"), status: http.StatusInternalServerError, code: CodeInternal, message: "服务器内部错误"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := echo.New()
+			e.GET("/test", func(c echo.Context) error { return tt.err })
+			rec := serveTestRequest(e, http.MethodGet, "/test")
+			if rec.Code != tt.status {
+				t.Fatalf("状态码 = %d，期望 %d，响应: %s", rec.Code, tt.status, rec.Body.String())
+			}
+			resp := assertErrorCode(t, rec, tt.code)
+			if tt.message != "" && resp.Message != tt.message {
+				t.Errorf("message = %q，期望 %q", resp.Message, tt.message)
+			}
+		})
+	}
+}
+
+// TestHTTPErrorHandlerRouteNotFound 不存在的路由返回 404 not_found
+func TestHTTPErrorHandlerRouteNotFound(t *testing.T) {
+	rec := serveTestRequest(echo.New(), http.MethodGet, "/api/missing")
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("状态码 = %d，期望 404", rec.Code)
+	}
+	assertErrorCode(t, rec, CodeNotFound)
+}
+
+// TestAdminMiddleware 非管理员访问管理接口返回 403 admin_required
+func TestAdminMiddleware(t *testing.T) {
+	tests := []struct {
+		name   string
+		viewer service.Viewer
+		status int
+	}{
+		{name: "管理员", viewer: service.Viewer{Username: "admin", Admin: true}, status: http.StatusOK},
+		{name: "普通用户", viewer: service.Viewer{Username: "alice"}, status: http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := echo.New()
+			e.Use(func(next echo.HandlerFunc) echo.HandlerFunc {
+				return func(c echo.Context) error {
+					c.SetRequest(c.Request().WithContext(service.WithViewer(c.Request().Context(), tt.viewer)))
+					return next(c)
+				}
+			})
+			e.GET("/api/admin/system", func(c echo.Context) error {
+				return c.NoContent(http.StatusOK)
+			}, middleware.AdminMiddleware())
+
+			rec := serveTestRequest(e, http.MethodGet, "/api/admin/system")
+			if rec.Code != tt.status {
+				t.Fatalf("状态码 = %d，期望 %d", rec.Code, tt.status)
+			}
+			if tt.status == http.StatusForbidden {
+				assertErrorCode(t, rec, CodeAdminRequired)
+			}
+		})
+	}
+}
+
+// TestSendSMSRejected 违反发送安全策略时返回 403 send_rejected，不保存发送记录
+func TestSendSMSRejected(t *testing.T) {
+	db := newTestDB(t)
+	policy := models.OutgoingPolicyConfig{Enabled: true, BlockedPatterns: []string{"1900*"}}
+	if err := service.NewPropertyService(zap.NewNop(), db).Set(context.Background(), service.PropertyIDOutgoingPolicy, "发送安全策略", policy); err != nil {
+		t.Fatalf("保存发送安全策略失败: %v", err)
+	}
+
+	e := echo.New()
+	e.HTTPErrorHandler = HTTPErrorHandler(zap.NewNop())
+	e.POST("/api/serial/sms", newTestSerialHandler(t, db).SendSMS)
+	req := httptest.NewRequest(http.MethodPost, "/api/serial/sms", strings.NewReader(`{"to":"19001234","content":"test"}`))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusForbidden {
+		t.Fatalf("状态码 = %d，期望 403，响应: %s", rec.Code, rec.Body.String())
+	}
+	assertErrorCode(t, rec, CodeSendRejected)
+
+	var count int64
+	if err := db.Model(&models.TextMessage{}).Count(&count).Error; err != nil {
+		t.Fatalf("统计短信失败: %v", err)
+	}
+	if count != 0 {
+		t.Errorf("被拒绝的短信不应保存，短信数 = %d", count)
+	}
+}
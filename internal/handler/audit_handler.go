@@ -0,0 +1,75 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/dushixiang/uart_sms_forwarder/internal/service"
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+)
+
+// AuditHandler 审计导出API处理器，仅管理员可用
+type AuditHandler struct {
+	logger       *zap.Logger
+	auditService *service.AuditExportService
+}
+
+// NewAuditHandler 创建审计导出Handler实例
+func NewAuditHandler(logger *zap.Logger, auditService *service.AuditExportService) *AuditHandler {
+	return &AuditHandler{
+		logger:       logger,
+		auditService: auditService,
+	}
+}
+
+// Export 导出时间范围内的短信和通知发送记录，JSONL 格式，带哈希链和签名
+// GET /api/admin/audit/export?since=1704067200000&until=1704153600000
+func (h *AuditHandler) Export(c echo.Context) error {
+	var rng service.AuditRange
+	for name, target := range map[string]*int64{"since": &rng.Since, "until": &rng.Until} {
+		if value := c.QueryParam(name); value != "" {
+			parsed, err := strconv.ParseInt(value, 10, 64)
+			if err != nil {
+				return Fail(http.StatusBadRequest, name+" 必须是时间戳（毫秒）")
+			}
+			*target = parsed
+		}
+	}
+	if rng.Since > 0 && rng.Until > 0 && rng.Since >= rng.Until {
+		return Fail(http.StatusBadRequest, "since 必须早于 until")
+	}
+
+	ctx := c.Request().Context()
+	// 开始输出后无法再返回错误响应，先检查权限和密钥
+	if err := h.auditService.Prepare(ctx); err != nil {
+		h.logger.Error("准备审计导出失败", zap.Error(err))
+		return failService(http.StatusInternalServerError, err)
+	}
+
+	filename := fmt.Sprintf("audit_%s.jsonl", time.Now().Format("20060102_150405"))
+	res := c.Response()
+	res.Header().Set(echo.HeaderContentType, "application/x-ndjson; charset=UTF-8")
+	res.Header().Set(echo.HeaderContentDisposition, fmt.Sprintf(`attachment; filename="%s"`, filename))
+	res.WriteHeader(http.StatusOK)
+	if err := h.auditService.Export(ctx, rng, res); err != nil {
+		h.logger.Warn("审计导出中断", zap.Error(err))
+	}
+	return nil
+}
+
+// GetPublicKey 获取审计导出的签名公钥，用于校验导出文件
+// GET /api/admin/audit/public-key
+func (h *AuditHandler) GetPublicKey(c echo.Context) error {
+	publicKey, err := h.auditService.PublicKey(c.Request().Context())
+	if err != nil {
+		h.logger.Error("获取审计签名公钥失败", zap.Error(err))
+		return failService(http.StatusInternalServerError, err)
+	}
+	return c.JSON(http.StatusOK, map[string]string{
+		"algorithm": "ed25519",
+		"publicKey": publicKey,
+	})
+}
@@ -40,25 +40,19 @@ func (h *AuthHandler) Login(c echo.Context) error {
 	// 获取请求参数
 	var req LoginRequest
 	if err := c.Bind(&req); err != nil {
-		return c.JSON(http.StatusBadRequest, map[string]string{
-			"error": "请求参数错误",
-		})
+		return Fail(http.StatusBadRequest, "请求参数错误")
 	}
 
 	// 验证必填字段
 	if req.Username == "" || req.Password == "" {
-		return c.JSON(http.StatusBadRequest, map[string]string{
-			"error": "用户名和密码不能为空",
-		})
+		return Fail(http.StatusBadRequest, "用户名和密码不能为空")
 	}
 
 	// 使用 AccountService 进行登录
 	ctx := c.Request().Context()
 	loginResp, err := h.accountService.Login(ctx, req.Username, req.Password)
 	if err != nil {
-		return c.JSON(http.StatusBadRequest, map[string]string{
-			"error": "用户名或密码错误",
-		})
+		return Fail(http.StatusBadRequest, "用户名或密码错误")
 	}
 
 	// 返回 token 和用户信息
@@ -79,9 +73,7 @@ func (h *AuthHandler) GetAuthConfig(c echo.Context) error {
 func (h *AuthHandler) GetOIDCAuthURL(c echo.Context) error {
 	authURL, err := h.accountService.GetOIDCAuthURL()
 	if err != nil {
-		return c.JSON(http.StatusBadRequest, map[string]string{
-			"error": err.Error(),
-		})
+		return Fail(http.StatusBadRequest, err.Error())
 	}
 	return c.JSON(http.StatusOK, authURL)
 }
@@ -96,15 +88,11 @@ type OIDCCallbackRequest struct {
 func (h *AuthHandler) OIDCCallback(c echo.Context) error {
 	var req OIDCCallbackRequest
 	if err := c.Bind(&req); err != nil {
-		return c.JSON(http.StatusBadRequest, map[string]string{
-			"error": "请求参数错误",
-		})
+		return Fail(http.StatusBadRequest, "请求参数错误")
 	}
 
 	if req.Code == "" || req.State == "" {
-		return c.JSON(http.StatusBadRequest, map[string]string{
-			"error": "缺少必要参数",
-		})
+		return Fail(http.StatusBadRequest, "缺少必要参数")
 	}
 
 	// 使用 AccountService 处理 OIDC 登录
@@ -112,9 +100,7 @@ func (h *AuthHandler) OIDCCallback(c echo.Context) error {
 	loginResp, err := h.accountService.LoginWithOIDC(ctx, req.Code, req.State)
 	if err != nil {
 		h.logger.Error("OIDC 登录失败", zap.Error(err))
-		return c.JSON(http.StatusUnauthorized, map[string]string{
-			"error": "OIDC 认证失败",
-		})
+		return Fail(http.StatusUnauthorized, "OIDC 认证失败")
 	}
 
 	// 返回 token 和用户信息
@@ -0,0 +1,75 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/dushixiang/uart_sms_forwarder/internal/models"
+	"github.com/dushixiang/uart_sms_forwarder/internal/service"
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+)
+
+// BackupHandler 远程备份API处理器
+type BackupHandler struct {
+	logger        *zap.Logger
+	backupService *service.BackupService
+}
+
+// NewBackupHandler 创建远程备份Handler实例
+func NewBackupHandler(logger *zap.Logger, backupService *service.BackupService) *BackupHandler {
+	return &BackupHandler{
+		logger:        logger,
+		backupService: backupService,
+	}
+}
+
+// GetBackup 获取远程备份配置和最近一次备份结果
+// GET /api/admin/backup
+func (h *BackupHandler) GetBackup(c echo.Context) error {
+	config, err := h.backupService.GetConfig(c.Request().Context())
+	if err != nil {
+		h.logger.Error("获取远程备份配置失败", zap.Error(err))
+		return Fail(http.StatusInternalServerError, "获取远程备份配置失败")
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"config": config,
+		"last":   h.backupService.LastResult(),
+	})
+}
+
+// UpdateBackup 保存远程备份配置
+// PUT /api/admin/backup
+// Body: {"enabled": true, "schedule": "0 3 * * *", "retention": 7, "type": "webdav", "webdav": {"url": "https://...", "username": "u", "password": "p"}}
+func (h *BackupHandler) UpdateBackup(c echo.Context) error {
+	var config models.BackupConfig
+	if err := c.Bind(&config); err != nil {
+		return Fail(http.StatusBadRequest, "请求参数错误")
+	}
+
+	if err := h.backupService.UpdateConfig(c.Request().Context(), config); err != nil {
+		h.logger.Error("保存远程备份配置失败", zap.Error(err))
+		return Fail(http.StatusBadRequest, err.Error())
+	}
+
+	return c.JSON(http.StatusOK, map[string]string{
+		"message": "远程备份配置已保存",
+	})
+}
+
+// RunBackup 立即执行一次远程备份
+// POST /api/admin/backup/run
+func (h *BackupHandler) RunBackup(c echo.Context) error {
+	result, err := h.backupService.Run(c.Request().Context())
+	if err != nil {
+		h.logger.Error("远程备份失败", zap.Error(err))
+		return &APIError{
+			Status:  http.StatusInternalServerError,
+			Code:    CodeInternal,
+			Message: err.Error(),
+			Details: result,
+		}
+	}
+
+	return c.JSON(http.StatusOK, result)
+}
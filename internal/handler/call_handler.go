@@ -0,0 +1,143 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/dushixiang/uart_sms_forwarder/internal/models"
+	"github.com/dushixiang/uart_sms_forwarder/internal/service"
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+)
+
+// CallHandler 来电记录和来电规则API处理器
+type CallHandler struct {
+	logger      *zap.Logger
+	callService *service.CallService
+}
+
+// NewCallHandler 创建来电Handler实例
+func NewCallHandler(logger *zap.Logger, callService *service.CallService) *CallHandler {
+	return &CallHandler{
+		logger:      logger,
+		callService: callService,
+	}
+}
+
+// List 按时间倒序分页获取来电记录（键集分页）
+// GET /api/calls?from=13800138000&action=hangup&deviceId=xxx&since=1704067200000&until=1704153600000&cursor=xxx&limit=50
+func (h *CallHandler) List(c echo.Context) error {
+	filter := service.CallLogFilter{
+		From:     c.QueryParam("from"),
+		Action:   models.CallAction(c.QueryParam("action")),
+		DeviceID: c.QueryParam("deviceId"),
+	}
+	if filter.Action != "" && !filter.Action.Valid() {
+		return Fail(http.StatusBadRequest, "action 只能是 notify、ignore 或 hangup")
+	}
+	for name, target := range map[string]*int64{"since": &filter.Since, "until": &filter.Until} {
+		if value := c.QueryParam(name); value != "" {
+			parsed, err := strconv.ParseInt(value, 10, 64)
+			if err != nil {
+				return Fail(http.StatusBadRequest, name+" 必须是时间戳（毫秒）")
+			}
+			*target = parsed
+		}
+	}
+	limit, _ := strconv.Atoi(c.QueryParam("limit"))
+
+	page, err := h.callService.List(c.Request().Context(), filter, c.QueryParam("cursor"), limit)
+	if err != nil {
+		h.logger.Error("获取来电记录失败", zap.Error(err))
+		return failService(http.StatusBadRequest, err)
+	}
+
+	return c.JSON(http.StatusOK, page)
+}
+
+// ListRules 获取所有来电规则
+// GET /api/call-rules
+func (h *CallHandler) ListRules(c echo.Context) error {
+	rules, err := h.callService.GetAllRules(c.Request().Context())
+	if err != nil {
+		h.logger.Error("获取来电规则失败", zap.Error(err))
+		return Fail(http.StatusInternalServerError, "获取规则列表失败")
+	}
+
+	if rules == nil {
+		rules = []models.CallRule{}
+	}
+	return c.JSON(http.StatusOK, rules)
+}
+
+// GetRule 根据ID获取来电规则
+// GET /api/call-rules/:id
+func (h *CallHandler) GetRule(c echo.Context) error {
+	id := c.Param("id")
+	rule, err := h.callService.GetRuleById(c.Request().Context(), id)
+	if err != nil {
+		h.logger.Error("获取来电规则失败", zap.String("id", id), zap.Error(err))
+		return Fail(http.StatusNotFound, "规则不存在")
+	}
+
+	return c.JSON(http.StatusOK, rule)
+}
+
+// CreateRule 创建来电规则
+// POST /api/call-rules
+// Body: {"pattern": "400*", "action": "hangup", "enabled": true, "remark": "推销电话"}
+func (h *CallHandler) CreateRule(c echo.Context) error {
+	var rule models.CallRule
+	if err := c.Bind(&rule); err != nil {
+		return Fail(http.StatusBadRequest, "请求参数错误")
+	}
+	if err := service.ValidateCallRule(&rule); err != nil {
+		return Fail(http.StatusBadRequest, err.Error())
+	}
+
+	if err := h.callService.CreateRule(c.Request().Context(), &rule); err != nil {
+		h.logger.Error("创建来电规则失败", zap.Error(err))
+		return Fail(http.StatusInternalServerError, "创建规则失败")
+	}
+
+	h.logger.Info("来电规则创建成功", zap.String("id", rule.ID), zap.String("pattern", rule.Pattern))
+	return c.JSON(http.StatusCreated, rule)
+}
+
+// UpdateRule 更新来电规则
+// PUT /api/call-rules/:id
+func (h *CallHandler) UpdateRule(c echo.Context) error {
+	id := c.Param("id")
+
+	var rule models.CallRule
+	if err := c.Bind(&rule); err != nil {
+		return Fail(http.StatusBadRequest, "请求参数错误")
+	}
+	if err := service.ValidateCallRule(&rule); err != nil {
+		return Fail(http.StatusBadRequest, err.Error())
+	}
+
+	rule.ID = id
+	if err := h.callService.UpdateRule(c.Request().Context(), &rule); err != nil {
+		h.logger.Error("更新来电规则失败", zap.String("id", id), zap.Error(err))
+		return Fail(http.StatusInternalServerError, "更新规则失败")
+	}
+
+	h.logger.Info("来电规则更新成功", zap.String("id", id))
+	return c.JSON(http.StatusOK, rule)
+}
+
+// DeleteRule 删除来电规则
+// DELETE /api/call-rules/:id
+func (h *CallHandler) DeleteRule(c echo.Context) error {
+	id := c.Param("id")
+	if err := h.callService.DeleteRule(c.Request().Context(), id); err != nil {
+		h.logger.Error("删除来电规则失败", zap.String("id", id), zap.Error(err))
+		return Fail(http.StatusInternalServerError, "删除规则失败")
+	}
+
+	h.logger.Info("来电规则删除成功", zap.String("id", id))
+	return c.JSON(http.StatusOK, map[string]string{
+		"message": "规则已删除",
+	})
+}
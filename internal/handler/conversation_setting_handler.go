@@ -0,0 +1,70 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/dushixiang/uart_sms_forwarder/internal/models"
+	"github.com/dushixiang/uart_sms_forwarder/internal/service"
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+)
+
+// ConversationSettingHandler 会话设置API处理器
+type ConversationSettingHandler struct {
+	logger                     *zap.Logger
+	conversationSettingService *service.ConversationSettingService
+}
+
+// NewConversationSettingHandler 创建会话设置Handler实例
+func NewConversationSettingHandler(logger *zap.Logger, conversationSettingService *service.ConversationSettingService) *ConversationSettingHandler {
+	return &ConversationSettingHandler{
+		logger:                     logger,
+		conversationSettingService: conversationSettingService,
+	}
+}
+
+// List 获取所有会话设置
+// GET /api/conversation-settings
+func (h *ConversationSettingHandler) List(c echo.Context) error {
+	settings, err := h.conversationSettingService.List(c.Request().Context())
+	if err != nil {
+		h.logger.Error("获取会话设置失败", zap.Error(err))
+		return Fail(http.StatusInternalServerError, "获取会话设置失败")
+	}
+
+	if settings == nil {
+		settings = []models.ConversationSetting{}
+	}
+	return c.JSON(http.StatusOK, settings)
+}
+
+// Save 保存会话的通知路由，优先于号码分类规则
+// PUT /api/conversation-settings/:peer
+// Body: {"mute": false, "channels": ["telegram", "email"], "priority": "critical"}
+func (h *ConversationSettingHandler) Save(c echo.Context) error {
+	var setting models.ConversationSetting
+	if err := c.Bind(&setting); err != nil {
+		return Fail(http.StatusBadRequest, "请求参数错误")
+	}
+	setting.Peer = c.Param("peer")
+
+	if err := h.conversationSettingService.Save(c.Request().Context(), &setting); err != nil {
+		h.logger.Error("保存会话设置失败", zap.Error(err))
+		return Fail(http.StatusBadRequest, err.Error())
+	}
+
+	return c.JSON(http.StatusOK, setting)
+}
+
+// Delete 删除会话设置，恢复按号码分类规则路由
+// DELETE /api/conversation-settings/:peer
+func (h *ConversationSettingHandler) Delete(c echo.Context) error {
+	if err := h.conversationSettingService.Delete(c.Request().Context(), c.Param("peer")); err != nil {
+		h.logger.Error("删除会话设置失败", zap.Error(err))
+		return Fail(http.StatusInternalServerError, "删除会话设置失败")
+	}
+
+	return c.JSON(http.StatusOK, map[string]string{
+		"message": "会话设置已删除",
+	})
+}
@@ -0,0 +1,91 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/dushixiang/uart_sms_forwarder/internal/models"
+	"github.com/dushixiang/uart_sms_forwarder/internal/service"
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// DeadLetterHandler 无法解析的串口帧API处理器，仅管理员可用
+type DeadLetterHandler struct {
+	logger            *zap.Logger
+	deadLetterService *service.DeadLetterService
+}
+
+// NewDeadLetterHandler 创建无法解析帧Handler实例
+func NewDeadLetterHandler(logger *zap.Logger, deadLetterService *service.DeadLetterService) *DeadLetterHandler {
+	return &DeadLetterHandler{
+		logger:            logger,
+		deadLetterService: deadLetterService,
+	}
+}
+
+// List 获取最近无法解析的串口帧
+// GET /api/admin/dead-letters?pending=true
+func (h *DeadLetterHandler) List(c echo.Context) error {
+	frames, err := h.deadLetterService.List(c.Request().Context(), c.QueryParam("pending") == "true")
+	if err != nil {
+		h.logger.Error("获取无法解析的串口帧失败", zap.Error(err))
+		return failService(http.StatusInternalServerError, err)
+	}
+
+	if frames == nil {
+		frames = []models.DeadLetterFrame{}
+	}
+	return c.JSON(http.StatusOK, frames)
+}
+
+// Reprocess 重新解析并处理指定的串口帧，用于修复解析逻辑后补处理
+// POST /api/admin/dead-letters/:id/reprocess
+func (h *DeadLetterHandler) Reprocess(c echo.Context) error {
+	id := c.Param("id")
+	frame, err := h.deadLetterService.Reprocess(c.Request().Context(), id)
+	switch {
+	case err == nil:
+		return c.JSON(http.StatusOK, frame)
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return Fail(http.StatusNotFound, "串口帧不存在")
+	case errors.Is(err, service.ErrDeadLetterReprocessed):
+		return Fail(http.StatusConflict, err.Error())
+	case errors.Is(err, service.ErrDeadLetterInvalid):
+		return &APIError{
+			Status:  http.StatusUnprocessableEntity,
+			Code:    CodeUnprocessable,
+			Message: err.Error(),
+			Details: frame,
+		}
+	default:
+		h.logger.Error("重新处理串口帧失败", zap.String("id", id), zap.Error(err))
+		return failService(http.StatusInternalServerError, err)
+	}
+}
+
+// ReprocessPending 按收到顺序重新处理所有尚未处理成功的串口帧
+// POST /api/admin/dead-letters/reprocess
+func (h *DeadLetterHandler) ReprocessPending(c echo.Context) error {
+	result, err := h.deadLetterService.ReprocessPending(c.Request().Context())
+	if err != nil {
+		h.logger.Error("重新处理串口帧失败", zap.Error(err))
+		return failService(http.StatusInternalServerError, err)
+	}
+
+	return c.JSON(http.StatusOK, result)
+}
+
+// Delete 删除串口帧
+// DELETE /api/admin/dead-letters/:id
+func (h *DeadLetterHandler) Delete(c echo.Context) error {
+	if err := h.deadLetterService.Delete(c.Request().Context(), c.Param("id")); err != nil {
+		h.logger.Error("删除串口帧失败", zap.Error(err))
+		return failService(http.StatusInternalServerError, err)
+	}
+
+	return c.JSON(http.StatusOK, map[string]string{
+		"message": "删除成功",
+	})
+}
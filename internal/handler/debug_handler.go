@@ -0,0 +1,78 @@
+package handler
+
+import (
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/dushixiang/uart_sms_forwarder/internal/service"
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+)
+
+// DebugHandler 调试API处理器
+type DebugHandler struct {
+	logger           *zap.Logger
+	debugEchoService *service.DebugEchoService
+}
+
+// NewDebugHandler 创建调试Handler实例
+func NewDebugHandler(logger *zap.Logger, debugEchoService *service.DebugEchoService) *DebugHandler {
+	return &DebugHandler{
+		logger:           logger,
+		debugEchoService: debugEchoService,
+	}
+}
+
+// Echo 记录收到的请求并原样返回，用于查看自定义 Webhook 实际发送的内容，不需要登录
+// ANY /api/debug/echo
+// ANY /api/debug/echo/*
+func (h *DebugHandler) Echo(c echo.Context) error {
+	r := c.Request()
+	body, err := io.ReadAll(io.LimitReader(r.Body, service.DebugEchoMaxBody+1))
+	if err != nil {
+		return Fail(http.StatusBadRequest, "读取请求体失败")
+	}
+
+	headers := make(map[string]string, len(r.Header))
+	for name, values := range r.Header {
+		headers[name] = strings.Join(values, ", ")
+	}
+	req := service.EchoRequest{
+		Method:   r.Method,
+		Path:     r.URL.Path,
+		Query:    r.URL.RawQuery,
+		Headers:  headers,
+		RemoteIP: c.RealIP(),
+	}
+	if len(body) > service.DebugEchoMaxBody {
+		body = body[:service.DebugEchoMaxBody]
+		req.BodyTruncated = true
+	}
+	req.Body = string(body)
+
+	return c.JSON(http.StatusOK, h.debugEchoService.Record(req))
+}
+
+// ListEchoRequests 获取调试接口最近收到的请求，最新的在前
+// GET /api/debug/echo-requests
+func (h *DebugHandler) ListEchoRequests(c echo.Context) error {
+	requests, err := h.debugEchoService.List(c.Request().Context())
+	if err != nil {
+		return failService(http.StatusInternalServerError, err)
+	}
+
+	return c.JSON(http.StatusOK, requests)
+}
+
+// ClearEchoRequests 清空调试接口收到的请求
+// DELETE /api/debug/echo-requests
+func (h *DebugHandler) ClearEchoRequests(c echo.Context) error {
+	if err := h.debugEchoService.Clear(c.Request().Context()); err != nil {
+		return failService(http.StatusInternalServerError, err)
+	}
+
+	return c.JSON(http.StatusOK, map[string]string{
+		"message": "已清空",
+	})
+}
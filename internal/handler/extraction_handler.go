@@ -0,0 +1,60 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/dushixiang/uart_sms_forwarder/internal/models"
+	"github.com/dushixiang/uart_sms_forwarder/internal/service"
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+)
+
+// ExtractionHandler 字段提取规则API处理器
+type ExtractionHandler struct {
+	logger            *zap.Logger
+	extractionService *service.ExtractionService
+}
+
+// NewExtractionHandler 创建字段提取规则Handler实例
+func NewExtractionHandler(logger *zap.Logger, extractionService *service.ExtractionService) *ExtractionHandler {
+	return &ExtractionHandler{
+		logger:            logger,
+		extractionService: extractionService,
+	}
+}
+
+// TestExtractionRequest 测试字段提取请求
+type TestExtractionRequest struct {
+	Rules   []models.ExtractionRule `json:"rules"` // 为空时使用已保存的规则
+	From    string                  `json:"from"`
+	Content string                  `json:"content"`
+}
+
+// TestRules 使用示例短信测试字段提取规则
+// POST /api/extraction-rules/test
+// Body: {"rules": [{"name": "取件码", "enabled": true, "pattern": "取件码(?P<code>\\d+)"}], "from": "10086", "content": "您的取件码123456"}
+func (h *ExtractionHandler) TestRules(c echo.Context) error {
+	var req TestExtractionRequest
+	if err := c.Bind(&req); err != nil {
+		return Fail(http.StatusBadRequest, "请求参数错误")
+	}
+
+	ctx := c.Request().Context()
+	rules := req.Rules
+	if rules == nil {
+		var err error
+		if rules, err = h.extractionService.GetRules(ctx); err != nil {
+			h.logger.Error("获取字段提取规则失败", zap.Error(err))
+			return Fail(http.StatusInternalServerError, "获取字段提取规则失败")
+		}
+	}
+
+	fields, err := h.extractionService.Test(rules, req.From, req.Content)
+	if err != nil {
+		return Fail(http.StatusBadRequest, err.Error())
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"fields": fields,
+	})
+}
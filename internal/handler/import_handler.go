@@ -0,0 +1,41 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/dushixiang/uart_sms_forwarder/internal/service"
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+)
+
+// ImportHandler 历史短信导入API处理器
+type ImportHandler struct {
+	logger       *zap.Logger
+	gammuService *service.GammuImportService
+}
+
+// NewImportHandler 创建历史短信导入Handler实例
+func NewImportHandler(logger *zap.Logger, gammuService *service.GammuImportService) *ImportHandler {
+	return &ImportHandler{
+		logger:       logger,
+		gammuService: gammuService,
+	}
+}
+
+// ImportGammu 从 gammu-smsd 数据库导入历史短信，重复导入会跳过已导入的记录
+// POST /api/admin/import/gammu
+// Body: {"driver": "sqlite", "dsn": "/var/lib/gammu/smsd.db"} 或 {"driver": "mysql", "dsn": "user:pass@tcp(127.0.0.1:3306)/smsd"}
+func (h *ImportHandler) ImportGammu(c echo.Context) error {
+	var req service.GammuImportRequest
+	if err := c.Bind(&req); err != nil {
+		return Fail(http.StatusBadRequest, "请求参数错误")
+	}
+
+	result, err := h.gammuService.Import(c.Request().Context(), req)
+	if err != nil {
+		h.logger.Error("导入 gammu-smsd 短信失败", zap.Error(err))
+		return failService(http.StatusBadRequest, err)
+	}
+
+	return c.JSON(http.StatusOK, result)
+}
@@ -0,0 +1,113 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/dushixiang/uart_sms_forwarder/internal/service"
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+)
+
+// InboundHandler 外部短信推送API处理器，供其他设备推送短信，不使用 JWT 认证
+type InboundHandler struct {
+	logger         *zap.Logger
+	inboundService *service.InboundService
+}
+
+// NewInboundHandler 创建外部短信推送Handler实例
+func NewInboundHandler(logger *zap.Logger, inboundService *service.InboundService) *InboundHandler {
+	return &InboundHandler{
+		logger:         logger,
+		inboundService: inboundService,
+	}
+}
+
+// SmsForwarder 接收 SmsForwarder（Android 短信转发器）Webhook 推送的短信
+// POST /api/inbound/smsforwarder?token=密钥
+// Body: 表单 from=10086&content=...&org_content=...&device_mark=Pixel&receive_time=2024-01-02 15:04:05&timestamp=1704179045000&sign=xxx
+// 也支持相同字段的 JSON
+func (h *InboundHandler) SmsForwarder(c echo.Context) error {
+	fields, err := readInboundFields(c)
+	if err != nil {
+		return Fail(http.StatusBadRequest, "请求参数错误")
+	}
+
+	err = h.inboundService.ReceiveSmsForwarder(c.Request().Context(), fields, c.QueryParam("token"))
+	switch {
+	case err == nil:
+		return c.JSON(http.StatusOK, map[string]string{
+			"message": "ok",
+		})
+	case errors.Is(err, service.ErrInboundDisabled):
+		return Fail(http.StatusForbidden, err.Error())
+	case errors.Is(err, service.ErrInboundUnauthorized):
+		h.logger.Warn("SmsForwarder 推送校验失败", zap.String("ip", c.RealIP()), zap.Error(err))
+		return Fail(http.StatusUnauthorized, err.Error())
+	default:
+		h.logger.Error("处理 SmsForwarder 推送失败", zap.Error(err))
+		return Fail(http.StatusBadRequest, err.Error())
+	}
+}
+
+// readInboundFields 读取表单或 JSON 请求体中的字段，JSON 中的数字等转换为字符串
+func readInboundFields(c echo.Context) (map[string]string, error) {
+	fields := make(map[string]string)
+	if strings.HasPrefix(c.Request().Header.Get(echo.HeaderContentType), echo.MIMEApplicationJSON) {
+		var body map[string]interface{}
+		decoder := json.NewDecoder(c.Request().Body)
+		decoder.UseNumber()
+		if err := decoder.Decode(&body); err != nil {
+			return nil, err
+		}
+		for key, value := range body {
+			if value != nil {
+				fields[key] = fmt.Sprint(value)
+			}
+		}
+		return fields, nil
+	}
+
+	params, err := c.FormParams()
+	if err != nil {
+		return nil, err
+	}
+	for key := range params {
+		fields[key] = params.Get(key)
+	}
+	return fields, nil
+}
+
+// Ingest 通用消息接入接口，其他网关或脚本推送的消息与本机收到的消息一起保存和通知
+// POST /api/ingest
+// Header: X-API-Key: 密钥 或 Authorization: Bearer 密钥
+// Body: {"from": "10086", "content": "...", "type": "sms", "source": "gateway-a", "timestamp": 1704179045}
+func (h *InboundHandler) Ingest(c echo.Context) error {
+	var msg service.IngestMessage
+	if err := c.Bind(&msg); err != nil {
+		return Fail(http.StatusBadRequest, "请求参数错误")
+	}
+
+	apiKey := c.Request().Header.Get("X-API-Key")
+	if apiKey == "" {
+		apiKey = strings.TrimPrefix(c.Request().Header.Get(echo.HeaderAuthorization), "Bearer ")
+	}
+
+	err := h.inboundService.Ingest(c.Request().Context(), msg, apiKey)
+	switch {
+	case err == nil:
+		return c.JSON(http.StatusOK, map[string]string{
+			"message": "ok",
+		})
+	case errors.Is(err, service.ErrInboundDisabled):
+		return Fail(http.StatusForbidden, err.Error())
+	case errors.Is(err, service.ErrInboundUnauthorized):
+		h.logger.Warn("消息接入接口密钥校验失败", zap.String("ip", c.RealIP()))
+		return Fail(http.StatusUnauthorized, "接口密钥错误")
+	default:
+		return Fail(http.StatusBadRequest, err.Error())
+	}
+}
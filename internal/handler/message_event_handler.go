@@ -0,0 +1,82 @@
+package handler
+
+import (
+	"io"
+	"time"
+
+	"github.com/dushixiang/uart_sms_forwarder/internal/service"
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+	"golang.org/x/net/websocket"
+)
+
+// wsPingInterval WebSocket 心跳间隔
+const wsPingInterval = 30 * time.Second
+
+// MessageEventHandler 新消息推送API处理器
+type MessageEventHandler struct {
+	logger            *zap.Logger
+	serialService     *service.SerialService
+	visibilityService *service.VisibilityService
+}
+
+// NewMessageEventHandler 创建新消息推送Handler实例
+func NewMessageEventHandler(logger *zap.Logger, serialService *service.SerialService, visibilityService *service.VisibilityService) *MessageEventHandler {
+	return &MessageEventHandler{
+		logger:            logger,
+		serialService:     serialService,
+		visibilityService: visibilityService,
+	}
+}
+
+// Stream 通过 WebSocket 实时推送新收到的短信、来电和短信确认，页面无需轮询短信列表
+// GET /api/ws?token=xxx
+// 每条消息为一个 JSON：{"type": "sms", "from": "10086", "message": {...}, "timestamp": 1704179045000}，来电时 type 为 call 且没有 message，
+// 短信被确认时 type 为 ack，message 为确认后的短信（含 ackedBy、ackNote）。
+// 只推送当前用户可见的会话；客户端处理不及时时丢弃事件，重连后应重新拉取列表。
+func (h *MessageEventHandler) Stream(c echo.Context) error {
+	websocket.Handler(func(ws *websocket.Conn) {
+		defer ws.Close()
+		ctx := ws.Request().Context()
+
+		events, cancel := h.serialService.SubscribeMessageEvents()
+		defer cancel()
+
+		// 客户端不发送消息，读取只用于处理控制帧和发现连接断开
+		closed := make(chan struct{})
+		go func() {
+			defer close(closed)
+			_, _ = io.Copy(io.Discard, ws)
+		}()
+
+		ping := time.NewTicker(wsPingInterval)
+		defer ping.Stop()
+		for {
+			select {
+			case event := <-events:
+				visible, err := h.visibilityService.CanView(ctx, event.From)
+				if err != nil {
+					h.logger.Error("检查会话可见性失败", zap.Error(err))
+					continue
+				}
+				if !visible {
+					continue
+				}
+				if err := websocket.JSON.Send(ws, event); err != nil {
+					return
+				}
+			case <-ping.C:
+				// 防止代理因连接空闲而断开
+				ws.PayloadType = websocket.PingFrame
+				if _, err := ws.Write(nil); err != nil {
+					return
+				}
+			case <-closed:
+				return
+			case <-ctx.Done():
+				return
+			}
+		}
+	}).ServeHTTP(c.Response(), c.Request())
+	return nil
+}
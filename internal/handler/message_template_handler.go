@@ -0,0 +1,120 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/dushixiang/uart_sms_forwarder/internal/models"
+	"github.com/dushixiang/uart_sms_forwarder/internal/service"
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+)
+
+// MessageTemplateHandler 短信模板API处理器
+type MessageTemplateHandler struct {
+	logger                 *zap.Logger
+	messageTemplateService *service.MessageTemplateService
+}
+
+// NewMessageTemplateHandler 创建短信模板Handler实例
+func NewMessageTemplateHandler(logger *zap.Logger, messageTemplateService *service.MessageTemplateService) *MessageTemplateHandler {
+	return &MessageTemplateHandler{
+		logger:                 logger,
+		messageTemplateService: messageTemplateService,
+	}
+}
+
+// List 获取所有短信模板
+// GET /api/message-templates
+func (h *MessageTemplateHandler) List(c echo.Context) error {
+	templates, err := h.messageTemplateService.GetAll(c.Request().Context())
+	if err != nil {
+		h.logger.Error("获取短信模板失败", zap.Error(err))
+		return Fail(http.StatusInternalServerError, "获取模板列表失败")
+	}
+
+	if templates == nil {
+		templates = []models.MessageTemplate{}
+	}
+	return c.JSON(http.StatusOK, templates)
+}
+
+// Create 创建短信模板
+// POST /api/message-templates
+// Body: {"name": "抄表", "content": "电表读数：{{reading}}，抄表日期：{{date}}"}
+func (h *MessageTemplateHandler) Create(c echo.Context) error {
+	var template models.MessageTemplate
+	if err := c.Bind(&template); err != nil {
+		return Fail(http.StatusBadRequest, "请求参数错误")
+	}
+	if err := service.ValidateMessageTemplate(&template); err != nil {
+		return Fail(http.StatusBadRequest, err.Error())
+	}
+
+	if err := h.messageTemplateService.Create(c.Request().Context(), &template); err != nil {
+		h.logger.Error("创建短信模板失败", zap.Error(err))
+		return Fail(http.StatusInternalServerError, "创建模板失败")
+	}
+
+	return c.JSON(http.StatusCreated, template)
+}
+
+// Update 更新短信模板
+// PUT /api/message-templates/:id
+func (h *MessageTemplateHandler) Update(c echo.Context) error {
+	id := c.Param("id")
+
+	var template models.MessageTemplate
+	if err := c.Bind(&template); err != nil {
+		return Fail(http.StatusBadRequest, "请求参数错误")
+	}
+	if err := service.ValidateMessageTemplate(&template); err != nil {
+		return Fail(http.StatusBadRequest, err.Error())
+	}
+
+	template.ID = id
+	if err := h.messageTemplateService.Update(c.Request().Context(), &template); err != nil {
+		h.logger.Error("更新短信模板失败", zap.String("id", id), zap.Error(err))
+		return Fail(http.StatusInternalServerError, "更新模板失败")
+	}
+
+	return c.JSON(http.StatusOK, template)
+}
+
+// Delete 删除短信模板
+// DELETE /api/message-templates/:id
+func (h *MessageTemplateHandler) Delete(c echo.Context) error {
+	id := c.Param("id")
+	if err := h.messageTemplateService.Delete(c.Request().Context(), id); err != nil {
+		h.logger.Error("删除短信模板失败", zap.String("id", id), zap.Error(err))
+		return Fail(http.StatusInternalServerError, "删除模板失败")
+	}
+
+	return c.JSON(http.StatusOK, map[string]string{
+		"message": "模板已删除",
+	})
+}
+
+// RenderTemplateRequest 预览模板请求
+type RenderTemplateRequest struct {
+	To        string            `json:"to"`        // 接收号码，用于内置变量 {{to}}
+	Variables map[string]string `json:"variables"` // 变量值
+}
+
+// Render 预览替换变量后的模板内容
+// POST /api/message-templates/:id/render
+// Body: {"to": "13800138000", "variables": {"reading": "1234.5"}}
+func (h *MessageTemplateHandler) Render(c echo.Context) error {
+	var req RenderTemplateRequest
+	if err := c.Bind(&req); err != nil {
+		return Fail(http.StatusBadRequest, "请求参数错误")
+	}
+
+	content, err := h.messageTemplateService.Render(c.Request().Context(), c.Param("id"), req.To, req.Variables)
+	if err != nil {
+		return Fail(http.StatusBadRequest, err.Error())
+	}
+
+	return c.JSON(http.StatusOK, map[string]string{
+		"content": content,
+	})
+}
@@ -0,0 +1,47 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/dushixiang/uart_sms_forwarder/internal/service"
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+)
+
+// MetricsHandler Prometheus 指标接口处理器，不使用 JWT 认证
+type MetricsHandler struct {
+	logger         *zap.Logger
+	metricsService *service.MetricsService
+}
+
+// NewMetricsHandler 创建指标接口Handler实例
+func NewMetricsHandler(logger *zap.Logger, metricsService *service.MetricsService) *MetricsHandler {
+	return &MetricsHandler{
+		logger:         logger,
+		metricsService: metricsService,
+	}
+}
+
+// GetMetrics 以 Prometheus 文本格式输出指标，需在配置 metrics 中启用
+// GET /metrics
+// Header: Authorization: Bearer 令牌（配置了 token 时必填）
+func (h *MetricsHandler) GetMetrics(c echo.Context) error {
+	token := strings.TrimPrefix(c.Request().Header.Get(echo.HeaderAuthorization), "Bearer ")
+	err := h.metricsService.Authorize(c.Request().Context(), token)
+	switch {
+	case err == nil:
+	case errors.Is(err, service.ErrMetricsDisabled):
+		return Fail(http.StatusNotFound, err.Error())
+	case errors.Is(err, service.ErrMetricsUnauthorized):
+		h.logger.Warn("指标接口令牌校验失败", zap.String("ip", c.RealIP()))
+		return Fail(http.StatusUnauthorized, err.Error())
+	default:
+		return Fail(http.StatusInternalServerError, err.Error())
+	}
+
+	c.Response().Header().Set(echo.HeaderContentType, "text/plain; version=0.0.4; charset=utf-8")
+	c.Response().WriteHeader(http.StatusOK)
+	return h.metricsService.Write(c.Response())
+}
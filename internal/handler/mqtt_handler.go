@@ -0,0 +1,29 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/dushixiang/uart_sms_forwarder/internal/service"
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+)
+
+// MQTTHandler MQTT 发布API处理器
+type MQTTHandler struct {
+	logger      *zap.Logger
+	mqttService *service.MQTTService
+}
+
+// NewMQTTHandler 创建 MQTT 发布Handler实例
+func NewMQTTHandler(logger *zap.Logger, mqttService *service.MQTTService) *MQTTHandler {
+	return &MQTTHandler{
+		logger:      logger,
+		mqttService: mqttService,
+	}
+}
+
+// GetStatus 获取 MQTT 连接状态，服务器和主题在配置 mqtt 中设置
+// GET /api/mqtt/status
+func (h *MQTTHandler) GetStatus(c echo.Context) error {
+	return c.JSON(http.StatusOK, h.mqttService.Status(c.Request().Context()))
+}
@@ -0,0 +1,58 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/dushixiang/uart_sms_forwarder/internal/models"
+	"github.com/dushixiang/uart_sms_forwarder/internal/service"
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+)
+
+// NotificationLogHandler 通知发送记录API处理器，仅管理员可用
+type NotificationLogHandler struct {
+	logger                 *zap.Logger
+	notificationLogService *service.NotificationLogService
+}
+
+// NewNotificationLogHandler 创建通知发送记录Handler实例
+func NewNotificationLogHandler(logger *zap.Logger, notificationLogService *service.NotificationLogService) *NotificationLogHandler {
+	return &NotificationLogHandler{
+		logger:                 logger,
+		notificationLogService: notificationLogService,
+	}
+}
+
+// List 按时间倒序分页获取通知发送记录（键集分页）
+// GET /api/notifications/logs?channel=feishu&status=failed&messageId=xxx&since=1704067200000&until=1704153600000&cursor=xxx&limit=50
+func (h *NotificationLogHandler) List(c echo.Context) error {
+	filter := service.NotificationLogFilter{
+		Channel:   c.QueryParam("channel"),
+		Status:    models.NotificationLogStatus(c.QueryParam("status")),
+		MessageID: c.QueryParam("messageId"),
+	}
+	switch filter.Status {
+	case "", models.NotificationLogSuccess, models.NotificationLogFailed:
+	default:
+		return Fail(http.StatusBadRequest, "status 只能是 success 或 failed")
+	}
+	for name, target := range map[string]*int64{"since": &filter.Since, "until": &filter.Until} {
+		if value := c.QueryParam(name); value != "" {
+			parsed, err := strconv.ParseInt(value, 10, 64)
+			if err != nil {
+				return Fail(http.StatusBadRequest, name+" 必须是时间戳（毫秒）")
+			}
+			*target = parsed
+		}
+	}
+	limit, _ := strconv.Atoi(c.QueryParam("limit"))
+
+	page, err := h.notificationLogService.List(c.Request().Context(), filter, c.QueryParam("cursor"), limit)
+	if err != nil {
+		h.logger.Error("获取通知发送记录失败", zap.Error(err))
+		return failService(http.StatusBadRequest, err)
+	}
+
+	return c.JSON(http.StatusOK, page)
+}
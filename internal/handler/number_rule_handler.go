@@ -0,0 +1,111 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/dushixiang/uart_sms_forwarder/internal/models"
+	"github.com/dushixiang/uart_sms_forwarder/internal/service"
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+)
+
+// NumberRuleHandler 号码分类规则API处理器
+type NumberRuleHandler struct {
+	logger            *zap.Logger
+	numberRuleService *service.NumberRuleService
+}
+
+// NewNumberRuleHandler 创建号码分类规则Handler实例
+func NewNumberRuleHandler(logger *zap.Logger, numberRuleService *service.NumberRuleService) *NumberRuleHandler {
+	return &NumberRuleHandler{
+		logger:            logger,
+		numberRuleService: numberRuleService,
+	}
+}
+
+// List 获取所有号码分类规则
+// GET /api/number-rules
+func (h *NumberRuleHandler) List(c echo.Context) error {
+	rules, err := h.numberRuleService.GetAll(c.Request().Context())
+	if err != nil {
+		h.logger.Error("获取号码分类规则失败", zap.Error(err))
+		return Fail(http.StatusInternalServerError, "获取规则列表失败")
+	}
+
+	if rules == nil {
+		rules = []models.NumberRule{}
+	}
+	return c.JSON(http.StatusOK, rules)
+}
+
+// Get 根据ID获取号码分类规则
+// GET /api/number-rules/:id
+func (h *NumberRuleHandler) Get(c echo.Context) error {
+	id := c.Param("id")
+	rule, err := h.numberRuleService.GetById(c.Request().Context(), id)
+	if err != nil {
+		h.logger.Error("获取号码分类规则失败", zap.String("id", id), zap.Error(err))
+		return Fail(http.StatusNotFound, "规则不存在")
+	}
+
+	return c.JSON(http.StatusOK, rule)
+}
+
+// Create 创建号码分类规则
+// POST /api/number-rules
+// Body: {"pattern": "106*", "category": "营销", "enabled": true, "mute": true}
+func (h *NumberRuleHandler) Create(c echo.Context) error {
+	var rule models.NumberRule
+	if err := c.Bind(&rule); err != nil {
+		return Fail(http.StatusBadRequest, "请求参数错误")
+	}
+	if err := service.ValidateNumberRule(&rule); err != nil {
+		return Fail(http.StatusBadRequest, err.Error())
+	}
+
+	if err := h.numberRuleService.Create(c.Request().Context(), &rule); err != nil {
+		h.logger.Error("创建号码分类规则失败", zap.Error(err))
+		return Fail(http.StatusInternalServerError, "创建规则失败")
+	}
+
+	h.logger.Info("号码分类规则创建成功", zap.String("id", rule.ID), zap.String("pattern", rule.Pattern))
+	return c.JSON(http.StatusCreated, rule)
+}
+
+// Update 更新号码分类规则
+// PUT /api/number-rules/:id
+func (h *NumberRuleHandler) Update(c echo.Context) error {
+	id := c.Param("id")
+
+	var rule models.NumberRule
+	if err := c.Bind(&rule); err != nil {
+		return Fail(http.StatusBadRequest, "请求参数错误")
+	}
+	if err := service.ValidateNumberRule(&rule); err != nil {
+		return Fail(http.StatusBadRequest, err.Error())
+	}
+
+	rule.ID = id
+	if err := h.numberRuleService.Update(c.Request().Context(), &rule); err != nil {
+		h.logger.Error("更新号码分类规则失败", zap.String("id", id), zap.Error(err))
+		return Fail(http.StatusInternalServerError, "更新规则失败")
+	}
+
+	h.logger.Info("号码分类规则更新成功", zap.String("id", id))
+	return c.JSON(http.StatusOK, rule)
+}
+
+// Delete 删除号码分类规则
+// DELETE /api/number-rules/:id
+func (h *NumberRuleHandler) Delete(c echo.Context) error {
+	id := c.Param("id")
+	if err := h.numberRuleService.Delete(c.Request().Context(), id); err != nil {
+		h.logger.Error("删除号码分类规则失败", zap.String("id", id), zap.Error(err))
+		return Fail(http.StatusInternalServerError, "删除规则失败")
+	}
+
+	h.logger.Info("号码分类规则删除成功", zap.String("id", id))
+	return c.JSON(http.StatusOK, map[string]string{
+		"message": "规则已删除",
+	})
+}
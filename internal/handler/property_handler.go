@@ -1,6 +1,7 @@
 package handler
 
 import (
+	"context"
 	"encoding/json"
 	"net/http"
 	"time"
@@ -32,9 +33,7 @@ func (h *PropertyHandler) GetProperty(c echo.Context) error {
 	property, err := h.service.Get(c.Request().Context(), id)
 	if err != nil {
 		h.logger.Error("获取属性失败", zap.String("id", id), zap.Error(err))
-		return c.JSON(http.StatusInternalServerError, map[string]string{
-			"error": "获取属性失败",
-		})
+		return Fail(http.StatusInternalServerError, "获取属性失败")
 	}
 
 	// 解析 JSON 值
@@ -42,9 +41,7 @@ func (h *PropertyHandler) GetProperty(c echo.Context) error {
 	if property.Value != "" {
 		if err := json.Unmarshal([]byte(property.Value), &value); err != nil {
 			h.logger.Error("解析属性值失败", zap.String("id", id), zap.Error(err))
-			return c.JSON(http.StatusInternalServerError, map[string]string{
-				"error": "解析属性值失败",
-			})
+			return Fail(http.StatusInternalServerError, "解析属性值失败")
 		}
 	}
 
@@ -65,16 +62,12 @@ func (h *PropertyHandler) SetProperty(c echo.Context) error {
 	}
 
 	if err := c.Bind(&req); err != nil {
-		return c.JSON(http.StatusBadRequest, map[string]string{
-			"error": "无效的请求参数",
-		})
+		return Fail(http.StatusBadRequest, "无效的请求参数")
 	}
 
 	if err := h.service.Set(c.Request().Context(), id, req.Name, req.Value); err != nil {
 		h.logger.Error("设置属性失败", zap.String("id", id), zap.Error(err))
-		return c.JSON(http.StatusInternalServerError, map[string]string{
-			"error": "设置属性失败",
-		})
+		return Fail(http.StatusInternalServerError, "设置属性失败")
 	}
 
 	return c.JSON(http.StatusOK, map[string]string{
@@ -86,9 +79,7 @@ func (h *PropertyHandler) SetProperty(c echo.Context) error {
 func (h *PropertyHandler) TestNotificationChannel(c echo.Context) error {
 	channelType := c.Param("type")
 	if channelType == "" {
-		return c.JSON(http.StatusBadRequest, map[string]string{
-			"error": "缺少渠道类型参数",
-		})
+		return Fail(http.StatusBadRequest, "缺少渠道类型参数")
 	}
 
 	ctx := c.Request().Context()
@@ -96,9 +87,7 @@ func (h *PropertyHandler) TestNotificationChannel(c echo.Context) error {
 	channels, err := h.service.GetNotificationChannelConfigs(c.Request().Context())
 	if err != nil {
 		h.logger.Error("获取通知渠道配置失败", zap.Error(err))
-		return c.JSON(http.StatusInternalServerError, map[string]string{
-			"error": "获取通知渠道配置失败",
-		})
+		return Fail(http.StatusInternalServerError, "获取通知渠道配置失败")
 	}
 
 	// 查找指定类型的渠道
@@ -111,19 +100,26 @@ func (h *PropertyHandler) TestNotificationChannel(c echo.Context) error {
 	}
 
 	if targetChannel == nil {
-		return c.JSON(http.StatusNotFound, map[string]string{
-			"error": "通知渠道不存在，请先配置",
-		})
+		return Fail(http.StatusNotFound, "通知渠道不存在，请先配置")
 	}
 
 	if !targetChannel.Enabled {
-		return c.JSON(http.StatusBadRequest, map[string]string{
-			"error": "通知渠道未启用",
-		})
+		return Fail(http.StatusBadRequest, "通知渠道未启用")
 	}
 
+	// 测试时只发送一次，使用渠道配置的超时
+	policy := service.ChannelPolicyFromConfig(targetChannel.Type, targetChannel.Config)
+	ctx, cancel := context.WithTimeout(ctx, policy.Timeout)
+	defer cancel()
+
 	// 发送测试消息
 	message := "这是一条测试通知消息"
+	testMsg := service.NotificationMessage{
+		Type:      "sms",
+		From:      "13800001234",
+		Content:   message,
+		Timestamp: time.Now().Unix(),
+	}
 
 	var sendErr error
 	switch targetChannel.Type {
@@ -134,28 +130,37 @@ func (h *PropertyHandler) TestNotificationChannel(c echo.Context) error {
 	case "feishu":
 		sendErr = h.notifier.SendFeishuByConfig(ctx, targetChannel.Config, message)
 	case "webhook":
-		sendErr = h.notifier.SendWebhookByConfig(ctx, targetChannel.Config, service.NotificationMessage{
-			Type:      "sms",
-			From:      "13800001234",
-			Content:   message,
-			Timestamp: time.Now().Unix(),
-		})
+		sendErr = h.notifier.SendWebhookByConfig(ctx, targetChannel.Config, testMsg)
 	case "email":
 		sendErr = h.notifier.SendEmailByConfig(ctx, targetChannel.Config, message)
 	case "telegram":
 		sendErr = h.notifier.SendTelegramByConfig(ctx, targetChannel.Config, message)
+	case "syslog":
+		sendErr = h.notifier.SendSyslogByConfig(ctx, targetChannel.Config, testMsg)
+	case "redis":
+		sendErr = h.notifier.SendRedisByConfig(ctx, targetChannel.Config, testMsg)
+	case "amqp":
+		sendErr = h.notifier.SendAMQPByConfig(ctx, targetChannel.Config, testMsg)
+	case "exec":
+		sendErr = h.notifier.SendExecByConfig(ctx, targetChannel.Config, testMsg)
+	case "file":
+		sendErr = h.notifier.SendFileByConfig(ctx, targetChannel.Config, testMsg)
+	case "fcm":
+		sendErr = h.notifier.SendFCMByConfig(ctx, targetChannel.Config, testMsg)
+	case "webpush":
+		sendErr = h.notifier.SendWebPushByConfig(ctx, targetChannel.Config, testMsg)
+	case "bark":
+		sendErr = h.notifier.SendBarkByConfig(ctx, targetChannel.Config, testMsg)
+	case "pushover":
+		sendErr = h.notifier.SendPushoverByConfig(ctx, targetChannel.Config, testMsg)
 
 	default:
-		return c.JSON(http.StatusBadRequest, map[string]string{
-			"error": "不支持的通知渠道类型",
-		})
+		return Fail(http.StatusBadRequest, "不支持的通知渠道类型")
 	}
 
 	if sendErr != nil {
 		h.logger.Error("发送测试通知失败", zap.String("type", channelType), zap.Error(sendErr))
-		return c.JSON(http.StatusInternalServerError, map[string]string{
-			"error": "发送测试通知失败: " + sendErr.Error(),
-		})
+		return Fail(http.StatusInternalServerError, "发送测试通知失败: "+sendErr.Error())
 	}
 
 	return c.JSON(http.StatusOK, map[string]string{
@@ -0,0 +1,154 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/dushixiang/uart_sms_forwarder/internal/middleware"
+	"github.com/dushixiang/uart_sms_forwarder/internal/models"
+	"github.com/dushixiang/uart_sms_forwarder/internal/service"
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+)
+
+// PushHandler 推送设备API处理器
+type PushHandler struct {
+	logger      *zap.Logger
+	pushService *service.PushService
+}
+
+// NewPushHandler 创建推送设备Handler实例
+func NewPushHandler(logger *zap.Logger, pushService *service.PushService) *PushHandler {
+	return &PushHandler{
+		logger:      logger,
+		pushService: pushService,
+	}
+}
+
+// RegisterDeviceRequest 注册推送设备请求
+type RegisterDeviceRequest struct {
+	Kind     string `json:"kind"`     // 推送方式，默认 fcm
+	Token    string `json:"token"`    // FCM 注册 token
+	Platform string `json:"platform"` // android、ios
+	Name     string `json:"name"`     // 设备名称
+}
+
+// RegisterDevice 注册推送设备，App 获取或刷新 token 后调用
+// POST /api/push/devices
+// Body: {"token": "fcm-token", "platform": "android", "name": "Pixel 8"}
+func (h *PushHandler) RegisterDevice(c echo.Context) error {
+	var req RegisterDeviceRequest
+	if err := c.Bind(&req); err != nil {
+		return Fail(http.StatusBadRequest, "请求参数错误")
+	}
+
+	device, err := h.pushService.RegisterDevice(c.Request().Context(), &models.PushDevice{
+		Kind:     req.Kind,
+		Token:    req.Token,
+		Platform: req.Platform,
+		Name:     req.Name,
+		Username: middleware.GetUsername(c),
+	})
+	if err != nil {
+		h.logger.Error("注册推送设备失败", zap.Error(err))
+		return Fail(http.StatusBadRequest, err.Error())
+	}
+
+	return c.JSON(http.StatusOK, device)
+}
+
+// ListDevices 获取所有推送设备
+// GET /api/push/devices
+func (h *PushHandler) ListDevices(c echo.Context) error {
+	devices, err := h.pushService.ListDevices(c.Request().Context())
+	if err != nil {
+		h.logger.Error("获取推送设备失败", zap.Error(err))
+		return Fail(http.StatusInternalServerError, "获取推送设备失败")
+	}
+
+	if devices == nil {
+		devices = []models.PushDevice{}
+	}
+	return c.JSON(http.StatusOK, devices)
+}
+
+// DeleteDevice 删除推送设备
+// DELETE /api/push/devices/:id
+func (h *PushHandler) DeleteDevice(c echo.Context) error {
+	id := c.Param("id")
+	if err := h.pushService.DeleteDevice(c.Request().Context(), id); err != nil {
+		h.logger.Error("删除推送设备失败", zap.String("id", id), zap.Error(err))
+		return Fail(http.StatusInternalServerError, "删除推送设备失败")
+	}
+
+	return c.JSON(http.StatusOK, map[string]string{
+		"message": "设备已删除",
+	})
+}
+
+// GetVAPIDPublicKey 获取 Web Push 服务器公钥，浏览器订阅时作为 applicationServerKey
+// GET /api/push/webpush/public-key
+func (h *PushHandler) GetVAPIDPublicKey(c echo.Context) error {
+	keys, err := h.pushService.VAPIDKeys(c.Request().Context())
+	if err != nil {
+		h.logger.Error("获取 VAPID 密钥失败", zap.Error(err))
+		return Fail(http.StatusInternalServerError, "获取 VAPID 密钥失败")
+	}
+
+	return c.JSON(http.StatusOK, map[string]string{
+		"publicKey": keys.PublicKey,
+	})
+}
+
+// WebPushSubscriptionRequest 浏览器订阅请求，格式与 PushSubscription.toJSON() 一致
+type WebPushSubscriptionRequest struct {
+	Endpoint string `json:"endpoint"`
+	Keys     struct {
+		P256dh string `json:"p256dh"`
+		Auth   string `json:"auth"`
+	} `json:"keys"`
+	Name string `json:"name"` // 可选，浏览器名称
+}
+
+// Subscribe 保存浏览器的 Web Push 订阅
+// POST /api/push/webpush/subscriptions
+// Body: {"endpoint": "https://...", "keys": {"p256dh": "...", "auth": "..."}, "name": "Chrome"}
+func (h *PushHandler) Subscribe(c echo.Context) error {
+	var req WebPushSubscriptionRequest
+	if err := c.Bind(&req); err != nil {
+		return Fail(http.StatusBadRequest, "请求参数错误")
+	}
+
+	device, err := h.pushService.RegisterDevice(c.Request().Context(), &models.PushDevice{
+		Kind:     service.PushKindWebPush,
+		Token:    req.Endpoint,
+		P256dh:   req.Keys.P256dh,
+		Auth:     req.Keys.Auth,
+		Name:     req.Name,
+		Username: middleware.GetUsername(c),
+	})
+	if err != nil {
+		h.logger.Error("保存 Web Push 订阅失败", zap.Error(err))
+		return Fail(http.StatusBadRequest, err.Error())
+	}
+
+	return c.JSON(http.StatusOK, device)
+}
+
+// Unsubscribe 删除浏览器的 Web Push 订阅
+// DELETE /api/push/webpush/subscriptions
+// Body: {"endpoint": "https://..."}
+func (h *PushHandler) Unsubscribe(c echo.Context) error {
+	var req WebPushSubscriptionRequest
+	if err := c.Bind(&req); err != nil || req.Endpoint == "" {
+		return Fail(http.StatusBadRequest, "请求参数错误")
+	}
+
+	if err := h.pushService.DeleteDeviceByToken(c.Request().Context(), req.Endpoint); err != nil {
+		h.logger.Error("删除 Web Push 订阅失败", zap.Error(err))
+		return Fail(http.StatusInternalServerError, "删除 Web Push 订阅失败")
+	}
+
+	return c.JSON(http.StatusOK, map[string]string{
+		"message": "订阅已删除",
+	})
+}
@@ -0,0 +1,43 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/dushixiang/uart_sms_forwarder/internal/service"
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+)
+
+// RegistrationRecoveryHandler 网络注册自动恢复API处理器
+type RegistrationRecoveryHandler struct {
+	logger          *zap.Logger
+	recoveryService *service.RegistrationRecoveryService
+}
+
+// NewRegistrationRecoveryHandler 创建网络注册自动恢复Handler实例
+func NewRegistrationRecoveryHandler(logger *zap.Logger, recoveryService *service.RegistrationRecoveryService) *RegistrationRecoveryHandler {
+	return &RegistrationRecoveryHandler{
+		logger:          logger,
+		recoveryService: recoveryService,
+	}
+}
+
+// GetStatus 获取网络注册自动恢复配置和当前未注册网络的设备的恢复进度，配置在 registration_recovery 中设置
+// GET /api/serial/recovery
+func (h *RegistrationRecoveryHandler) GetStatus(c echo.Context) error {
+	return c.JSON(http.StatusOK, h.recoveryService.Status(c.Request().Context()))
+}
+
+// List 按时间倒序分页获取恢复记录（键集分页），包含执行过的操作和最终生效的操作
+// GET /api/serial/recovery/logs?deviceId=xxx&cursor=xxx&limit=50
+func (h *RegistrationRecoveryHandler) List(c echo.Context) error {
+	limit, _ := strconv.Atoi(c.QueryParam("limit"))
+	page, err := h.recoveryService.List(c.Request().Context(), c.QueryParam("deviceId"), c.QueryParam("cursor"), limit)
+	if err != nil {
+		h.logger.Error("获取网络注册恢复记录失败", zap.Error(err))
+		return Fail(http.StatusBadRequest, err.Error())
+	}
+
+	return c.JSON(http.StatusOK, page)
+}
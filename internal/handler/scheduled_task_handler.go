@@ -1,12 +1,15 @@
 package handler
 
 import (
+	"errors"
 	"net/http"
+	"strings"
 
 	"github.com/dushixiang/uart_sms_forwarder/internal/models"
 	"github.com/dushixiang/uart_sms_forwarder/internal/service"
 	"github.com/labstack/echo/v4"
 	"go.uber.org/zap"
+	"gorm.io/gorm"
 )
 
 type ScheduledTaskHandler struct {
@@ -28,9 +31,7 @@ func (h *ScheduledTaskHandler) List(c echo.Context) error {
 	tasks, err := h.schedulerService.GetAll(ctx)
 	if err != nil {
 		h.logger.Error("获取定时任务列表失败", zap.Error(err))
-		return c.JSON(http.StatusInternalServerError, map[string]string{
-			"error": "获取任务列表失败",
-		})
+		return Fail(http.StatusInternalServerError, "获取任务列表失败")
 	}
 
 	// 如果为空，返回空数组而不是 null
@@ -49,9 +50,7 @@ func (h *ScheduledTaskHandler) Get(c echo.Context) error {
 	task, err := h.schedulerService.GetById(ctx, id)
 	if err != nil {
 		h.logger.Error("获取定时任务失败", zap.String("id", id), zap.Error(err))
-		return c.JSON(http.StatusNotFound, map[string]string{
-			"error": "任务不存在",
-		})
+		return Fail(http.StatusNotFound, "任务不存在")
 	}
 
 	return c.JSON(http.StatusOK, task)
@@ -64,24 +63,18 @@ func (h *ScheduledTaskHandler) Create(c echo.Context) error {
 	var task models.ScheduledTask
 	if err := c.Bind(&task); err != nil {
 		h.logger.Error("解析请求失败", zap.Error(err))
-		return c.JSON(http.StatusBadRequest, map[string]string{
-			"error": "请求参数错误",
-		})
+		return Fail(http.StatusBadRequest, "请求参数错误")
 	}
 
 	// 验证必填字段
 	if err := h.validateTask(&task); err != nil {
-		return c.JSON(http.StatusBadRequest, map[string]string{
-			"error": err.Error(),
-		})
+		return Fail(http.StatusBadRequest, err.Error())
 	}
 
 	// 创建任务
 	if err := h.schedulerService.Create(ctx, &task); err != nil {
 		h.logger.Error("创建定时任务失败", zap.Error(err))
-		return c.JSON(http.StatusInternalServerError, map[string]string{
-			"error": "创建任务失败",
-		})
+		return Fail(http.StatusInternalServerError, "创建任务失败")
 	}
 
 	h.logger.Info("定时任务创建成功", zap.String("id", task.ID), zap.String("name", task.Name))
@@ -97,16 +90,12 @@ func (h *ScheduledTaskHandler) Update(c echo.Context) error {
 	var task models.ScheduledTask
 	if err := c.Bind(&task); err != nil {
 		h.logger.Error("解析请求失败", zap.Error(err))
-		return c.JSON(http.StatusBadRequest, map[string]string{
-			"error": "请求参数错误",
-		})
+		return Fail(http.StatusBadRequest, "请求参数错误")
 	}
 
 	// 验证必填字段
 	if err := h.validateTask(&task); err != nil {
-		return c.JSON(http.StatusBadRequest, map[string]string{
-			"error": err.Error(),
-		})
+		return Fail(http.StatusBadRequest, err.Error())
 	}
 
 	// 确保 ID 一致
@@ -115,9 +104,7 @@ func (h *ScheduledTaskHandler) Update(c echo.Context) error {
 	// 更新任务
 	if err := h.schedulerService.Update(ctx, &task); err != nil {
 		h.logger.Error("更新定时任务失败", zap.String("id", id), zap.Error(err))
-		return c.JSON(http.StatusInternalServerError, map[string]string{
-			"error": "更新任务失败",
-		})
+		return Fail(http.StatusInternalServerError, "更新任务失败")
 	}
 
 	h.logger.Info("定时任务更新成功", zap.String("id", id), zap.String("name", task.Name))
@@ -132,9 +119,7 @@ func (h *ScheduledTaskHandler) Delete(c echo.Context) error {
 
 	if err := h.schedulerService.Delete(ctx, id); err != nil {
 		h.logger.Error("删除定时任务失败", zap.String("id", id), zap.Error(err))
-		return c.JSON(http.StatusInternalServerError, map[string]string{
-			"error": "删除任务失败",
-		})
+		return Fail(http.StatusInternalServerError, "删除任务失败")
 	}
 
 	h.logger.Info("定时任务删除成功", zap.String("id", id))
@@ -151,9 +136,7 @@ func (h *ScheduledTaskHandler) Trigger(c echo.Context) error {
 
 	if err := h.schedulerService.TriggerTask(ctx, id); err != nil {
 		h.logger.Error("触发定时任务失败", zap.String("id", id), zap.Error(err))
-		return c.JSON(http.StatusInternalServerError, map[string]string{
-			"error": "触发任务失败",
-		})
+		return Fail(http.StatusInternalServerError, "触发任务失败")
 	}
 
 	h.logger.Info("定时任务已触发执行", zap.String("id", id))
@@ -163,12 +146,42 @@ func (h *ScheduledTaskHandler) Trigger(c echo.Context) error {
 	})
 }
 
+// Run 立即执行定时任务并返回发送结果，记录执行时间和状态。
+// 设备处于飞行模式时先取消飞行模式并等待 30 秒再发送，请求会阻塞到发送完成；
+// 任务在后台 context 中执行，客户端断开后仍会完成并记录结果
+// POST /api/scheduled-tasks/:id/run
+func (h *ScheduledTaskHandler) Run(c echo.Context) error {
+	ctx := c.Request().Context()
+	id := c.Param("id")
+
+	result, err := h.schedulerService.RunTask(ctx, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return Fail(http.StatusNotFound, "任务不存在")
+		}
+		h.logger.Error("执行定时任务失败", zap.String("id", id), zap.Error(err))
+		return Fail(http.StatusInternalServerError, "执行任务失败")
+	}
+
+	h.logger.Info("定时任务已手动执行",
+		zap.String("id", id),
+		zap.Bool("success", result.Success),
+		zap.String("msgId", result.MsgID))
+
+	return c.JSON(http.StatusOK, result)
+}
+
 // validateTask 验证任务字段
 func (h *ScheduledTaskHandler) validateTask(task *models.ScheduledTask) error {
 	if task.Name == "" {
 		return echo.NewHTTPError(http.StatusBadRequest, "任务名称不能为空")
 	}
-	if task.IntervalDays <= 0 {
+	task.CronSpec = strings.TrimSpace(task.CronSpec)
+	if task.CronSpec != "" {
+		if err := service.ValidateCronSpec(task.CronSpec); err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		}
+	} else if task.IntervalDays <= 0 {
 		return echo.NewHTTPError(http.StatusBadRequest, "执行间隔天数必须大于0")
 	}
 	if task.PhoneNumber == "" {
@@ -177,5 +190,10 @@ func (h *ScheduledTaskHandler) validateTask(task *models.ScheduledTask) error {
 	if task.Content == "" {
 		return echo.NewHTTPError(http.StatusBadRequest, "短信内容不能为空")
 	}
+	switch task.MissedRun {
+	case "", models.MissedRunOnce, models.MissedRunImmediate, models.MissedRunSkip:
+	default:
+		return echo.NewHTTPError(http.StatusBadRequest, "不支持的错过执行处理方式")
+	}
 	return nil
 }
@@ -0,0 +1,57 @@
+package handler
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/dushixiang/uart_sms_forwarder/internal/service"
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+)
+
+// ScriptHandler 短信处理脚本API处理器
+type ScriptHandler struct {
+	logger        *zap.Logger
+	scriptService *service.ScriptService
+}
+
+// NewScriptHandler 创建短信处理脚本Handler实例
+func NewScriptHandler(logger *zap.Logger, scriptService *service.ScriptService) *ScriptHandler {
+	return &ScriptHandler{
+		logger:        logger,
+		scriptService: scriptService,
+	}
+}
+
+// TestScriptRequest 测试脚本请求
+type TestScriptRequest struct {
+	Script  string `json:"script"`
+	Timeout int    `json:"timeout"`
+	From    string `json:"from"`
+	Content string `json:"content"`
+}
+
+// TestScript 使用示例短信测试脚本（不保存配置、不发送通知）
+// POST /api/message-script/test
+// Body: {"script": "function on_message(msg) ... end", "from": "10086", "content": "测试短信"}
+func (h *ScriptHandler) TestScript(c echo.Context) error {
+	var req TestScriptRequest
+	if err := c.Bind(&req); err != nil {
+		return Fail(http.StatusBadRequest, "请求参数错误")
+	}
+
+	if req.Script == "" {
+		return Fail(http.StatusBadRequest, "脚本不能为空")
+	}
+
+	result, err := h.scriptService.Test(c.Request().Context(), req.Script, req.Timeout, service.ScriptMessage{
+		From:      req.From,
+		Content:   req.Content,
+		Timestamp: time.Now().Unix(),
+	})
+	if err != nil {
+		return Fail(http.StatusBadRequest, err.Error())
+	}
+
+	return c.JSON(http.StatusOK, result)
+}
@@ -0,0 +1,104 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/dushixiang/uart_sms_forwarder/internal/service"
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+)
+
+// SerialCaptureHandler 串口抓包API处理器，仅管理员可用
+type SerialCaptureHandler struct {
+	logger  *zap.Logger
+	capture *service.SerialCapture
+}
+
+// NewSerialCaptureHandler 创建串口抓包Handler实例
+func NewSerialCaptureHandler(logger *zap.Logger, capture *service.SerialCapture) *SerialCaptureHandler {
+	return &SerialCaptureHandler{
+		logger:  logger,
+		capture: capture,
+	}
+}
+
+// SetCaptureRequest 开启或关闭串口抓包请求
+type SetCaptureRequest struct {
+	Enabled bool `json:"enabled"`
+	service.CaptureOptions
+}
+
+// GetStatus 获取串口抓包状态
+// GET /api/admin/serial/capture
+func (h *SerialCaptureHandler) GetStatus(c echo.Context) error {
+	status, err := h.capture.Status(c.Request().Context())
+	if err != nil {
+		return failService(http.StatusInternalServerError, err)
+	}
+	return c.JSON(http.StatusOK, status)
+}
+
+// SetCapture 开启或关闭串口抓包
+// POST /api/admin/serial/capture
+// Body: {"enabled": true, "redact": true, "maxSizeMB": 10}
+func (h *SerialCaptureHandler) SetCapture(c echo.Context) error {
+	var req SetCaptureRequest
+	if err := c.Bind(&req); err != nil {
+		return Fail(http.StatusBadRequest, "请求参数错误")
+	}
+
+	ctx := c.Request().Context()
+	var status *service.CaptureStatus
+	var err error
+	if req.Enabled {
+		status, err = h.capture.Start(ctx, req.CaptureOptions)
+	} else {
+		status, err = h.capture.Stop(ctx)
+	}
+	if err != nil {
+		h.logger.Error("设置串口抓包失败", zap.Error(err))
+		return failService(http.StatusInternalServerError, err)
+	}
+	return c.JSON(http.StatusOK, status)
+}
+
+// Download 下载抓包文件，抓包进行中也可下载已记录的部分
+// GET /api/admin/serial/capture/download
+func (h *SerialCaptureHandler) Download(c echo.Context) error {
+	reader, err := h.capture.Open(c.Request().Context())
+	switch {
+	case err == nil:
+	case errors.Is(err, service.ErrCaptureEmpty):
+		return Fail(http.StatusNotFound, err.Error())
+	default:
+		h.logger.Error("读取串口抓包文件失败", zap.Error(err))
+		return failService(http.StatusInternalServerError, err)
+	}
+	defer reader.Close()
+
+	filename := fmt.Sprintf("serial_capture_%s.log", time.Now().Format("20060102_150405"))
+	res := c.Response()
+	res.Header().Set(echo.HeaderContentType, echo.MIMETextPlainCharsetUTF8)
+	res.Header().Set(echo.HeaderContentDisposition, fmt.Sprintf(`attachment; filename="%s"`, filename))
+	res.WriteHeader(http.StatusOK)
+	if _, err := io.Copy(res, reader); err != nil {
+		h.logger.Warn("下载串口抓包文件中断", zap.Error(err))
+	}
+	return nil
+}
+
+// Clear 删除抓包文件
+// DELETE /api/admin/serial/capture
+func (h *SerialCaptureHandler) Clear(c echo.Context) error {
+	if err := h.capture.Clear(c.Request().Context()); err != nil {
+		h.logger.Error("删除串口抓包文件失败", zap.Error(err))
+		return failService(http.StatusInternalServerError, err)
+	}
+	return c.JSON(http.StatusOK, map[string]string{
+		"message": "抓包文件已删除",
+	})
+}
@@ -1,114 +1,422 @@
 package handler
 
 import (
+	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
+	"strings"
+	"time"
 
 	"github.com/dushixiang/uart_sms_forwarder/internal/service"
 	"github.com/labstack/echo/v4"
 	"go.uber.org/zap"
 )
 
+// sseHeartbeatInterval SSE 心跳间隔
+const sseHeartbeatInterval = 15 * time.Second
+
 // SerialHandler 串口控制API处理器
 type SerialHandler struct {
-	logger        *zap.Logger
-	serialService *service.SerialService
+	logger                 *zap.Logger
+	serialService          *service.SerialService
+	messageTemplateService *service.MessageTemplateService
 }
 
 // NewSerialHandler 创建串口Handler实例
-func NewSerialHandler(logger *zap.Logger, serialService *service.SerialService) *SerialHandler {
+func NewSerialHandler(logger *zap.Logger, serialService *service.SerialService, messageTemplateService *service.MessageTemplateService) *SerialHandler {
 	return &SerialHandler{
-		logger:        logger,
-		serialService: serialService,
+		logger:                 logger,
+		serialService:          serialService,
+		messageTemplateService: messageTemplateService,
 	}
 }
 
 // SendSMSRequest 发送短信请求
 type SendSMSRequest struct {
-	To      string `json:"to"`
-	Content string `json:"content"`
+	To         string            `json:"to"`
+	DeviceID   string            `json:"deviceId"` // 发送短信的设备 ID，为空时使用主设备
+	Content    string            `json:"content"`
+	TemplateID string            `json:"templateId"` // 使用短信模板时的模板 ID，此时忽略 content
+	Variables  map[string]string `json:"variables"`  // 模板变量值
 }
 
+// idempotencyKeyMaxLength Idempotency-Key 请求头的最大长度
+const idempotencyKeyMaxLength = 255
+
 // SendSMS 发送短信
 // POST /api/serial/sms
-// Body: {"to": "13800138000", "content": "测试短信"}
+// Body: {"to": "13800138000", "content": "测试短信"} 或 {"to": "13800138000", "templateId": "xxx", "variables": {"reading": "1234.5"}}
+// 连接多台设备时可通过 "deviceId" 指定发送的设备
+// 可选请求头 Idempotency-Key：24 小时内使用相同键的重复请求不会再次发送，直接返回首次发送的短信 ID
 func (h *SerialHandler) SendSMS(c echo.Context) error {
+	idempotencyKey := strings.TrimSpace(c.Request().Header.Get("Idempotency-Key"))
+	if len(idempotencyKey) > idempotencyKeyMaxLength {
+		return Fail(http.StatusBadRequest, "Idempotency-Key 过长")
+	}
+
 	var req SendSMSRequest
 	if err := c.Bind(&req); err != nil {
-		return c.JSON(http.StatusBadRequest, map[string]string{
-			"error": "请求参数错误",
-		})
+		return Fail(http.StatusBadRequest, "请求参数错误")
+	}
+
+	if req.TemplateID != "" {
+		content, err := h.messageTemplateService.Render(c.Request().Context(), req.TemplateID, req.To, req.Variables)
+		if err != nil {
+			return Fail(http.StatusBadRequest, err.Error())
+		}
+		req.Content = content
 	}
 
 	if req.To == "" || req.Content == "" {
-		return c.JSON(http.StatusBadRequest, map[string]string{
-			"error": "手机号和内容不能为空",
-		})
+		return Fail(http.StatusBadRequest, "手机号和内容不能为空")
 	}
 
-	if _, err := h.serialService.SendSMS(req.To, req.Content); err != nil {
+	var id string
+	var err error
+	if idempotencyKey != "" {
+		var replayed bool
+		id, replayed, err = h.serialService.SendSMSIdempotent(c.Request().Context(), idempotencyKey, req.DeviceID, req.To, req.Content)
+		if errors.Is(err, service.ErrIdempotencyKeyReused) {
+			return FailCode(http.StatusUnprocessableEntity, CodeIdempotencyKeyReused, err.Error())
+		}
+		if replayed {
+			c.Response().Header().Set("Idempotent-Replayed", "true")
+		}
+	} else {
+		id, err = h.serialService.SendSMSContext(c.Request().Context(), req.DeviceID, req.To, req.Content)
+	}
+	if errors.Is(err, service.ErrDeviceNotFound) {
+		return Fail(http.StatusBadRequest, err.Error())
+	}
+	if errors.Is(err, service.ErrSendQuotaExceeded) {
+		return FailCode(http.StatusTooManyRequests, CodeQuotaExceeded, err.Error())
+	}
+	if errors.Is(err, service.ErrOutgoingRejected) {
+		return FailCode(http.StatusForbidden, CodeSendRejected, err.Error())
+	}
+	if err != nil {
 		h.logger.Error("发送短信失败", zap.Error(err))
-		return c.JSON(http.StatusInternalServerError, map[string]string{
-			"error": "发送失败",
+		return Fail(http.StatusInternalServerError, "发送失败")
+	}
+
+	if _, required := h.serialService.RequiresApproval(c.Request().Context()); required {
+		return c.JSON(http.StatusAccepted, map[string]string{
+			"message": "已提交，等待管理员审批",
+			"id":      id,
+			"status":  "pending_approval",
 		})
 	}
 
 	return c.JSON(http.StatusOK, map[string]string{
 		"message": "发送成功",
+		"id":      id,
+	})
+}
+
+// ListPendingApproval 获取待审批的短信，仅管理员可用
+// GET /api/serial/sms/pending
+func (h *SerialHandler) ListPendingApproval(c echo.Context) error {
+	items, err := h.serialService.ListPendingApproval(c.Request().Context())
+	if err != nil {
+		return failService(http.StatusInternalServerError, err)
+	}
+	return c.JSON(http.StatusOK, items)
+}
+
+// ApproveSMS 批准待审批的短信并发送，仅管理员可用
+// POST /api/serial/sms/:id/approve
+func (h *SerialHandler) ApproveSMS(c echo.Context) error {
+	err := h.serialService.ApproveSMS(c.Request().Context(), c.Param("id"))
+	if errors.Is(err, service.ErrNotPendingApproval) {
+		return Fail(http.StatusConflict, err.Error())
+	}
+	if err != nil {
+		h.logger.Error("批准发送短信失败", zap.String("id", c.Param("id")), zap.Error(err))
+		return failService(http.StatusInternalServerError, err)
+	}
+	return c.JSON(http.StatusOK, map[string]string{
+		"message": "已批准发送",
+		"id":      c.Param("id"),
 	})
 }
 
+// RejectSMSRequest 拒绝发送请求
+type RejectSMSRequest struct {
+	Reason string `json:"reason"` // 拒绝原因，保存为失败原因
+}
+
+// RejectSMS 拒绝待审批的短信，仅管理员可用
+// POST /api/serial/sms/:id/reject
+// Body: {"reason": "内容不合规"}
+func (h *SerialHandler) RejectSMS(c echo.Context) error {
+	var req RejectSMSRequest
+	if err := c.Bind(&req); err != nil {
+		return Fail(http.StatusBadRequest, "请求参数错误")
+	}
+	err := h.serialService.RejectSMS(c.Request().Context(), c.Param("id"), strings.TrimSpace(req.Reason))
+	if errors.Is(err, service.ErrNotPendingApproval) {
+		return Fail(http.StatusConflict, err.Error())
+	}
+	if err != nil {
+		h.logger.Error("拒绝发送短信失败", zap.String("id", c.Param("id")), zap.Error(err))
+		return failService(http.StatusInternalServerError, err)
+	}
+	return c.JSON(http.StatusOK, map[string]string{
+		"message": "已拒绝发送",
+		"id":      c.Param("id"),
+	})
+}
+
+// GetSendUsage 获取今天和本月的发送条数、额度和估算费用
+// GET /api/serial/sms/usage
+func (h *SerialHandler) GetSendUsage(c echo.Context) error {
+	usage, err := h.serialService.GetSendUsage(c.Request().Context())
+	if err != nil {
+		h.logger.Error("获取发送用量失败", zap.Error(err))
+		return Fail(http.StatusInternalServerError, "获取发送用量失败")
+	}
+
+	return c.JSON(http.StatusOK, usage)
+}
+
+// ForwardMessageRequest 转发短信请求
+type ForwardMessageRequest struct {
+	To string `json:"to"`
+}
+
+// ForwardMessage 将已保存的短信转发到其他号码
+// POST /api/messages/:id/forward
+// Body: {"to": "13800138000"}
+func (h *SerialHandler) ForwardMessage(c echo.Context) error {
+	var req ForwardMessageRequest
+	if err := c.Bind(&req); err != nil {
+		return Fail(http.StatusBadRequest, "请求参数错误")
+	}
+	req.To = strings.TrimSpace(req.To)
+	if req.To == "" {
+		return Fail(http.StatusBadRequest, "手机号不能为空")
+	}
+
+	id, err := h.serialService.ForwardMessage(c.Request().Context(), c.Param("id"), req.To)
+	if err != nil {
+		h.logger.Error("转发短信失败", zap.Error(err))
+		return Fail(http.StatusBadRequest, err.Error())
+	}
+
+	return c.JSON(http.StatusOK, map[string]string{
+		"message": "发送成功",
+		"id":      id,
+	})
+}
+
+// AckMessageRequest 确认短信请求
+type AckMessageRequest struct {
+	Note string `json:"note"` // 可选的备注或回应，如 已转账、👍
+}
+
+// ackNoteMaxLength 确认备注的最大长度（字符数）
+const ackNoteMaxLength = 200
+
+// AckMessage 确认已处理短信，记录确认人和时间，停止号码分类规则配置的升级通知
+// POST /api/ack/:id
+// Body（可选）: {"note": "已转账"}
+// 只有首次确认生效，已被其他人确认时返回原确认记录（ackedBy 为首次确认的用户）
+func (h *SerialHandler) AckMessage(c echo.Context) error {
+	var req AckMessageRequest
+	if c.Request().ContentLength > 0 {
+		if err := c.Bind(&req); err != nil {
+			return Fail(http.StatusBadRequest, "请求参数错误")
+		}
+	}
+	req.Note = strings.TrimSpace(req.Note)
+	if len([]rune(req.Note)) > ackNoteMaxLength {
+		return Fail(http.StatusBadRequest, fmt.Sprintf("备注不能超过 %d 个字符", ackNoteMaxLength))
+	}
+
+	msg, err := h.serialService.AckMessage(c.Request().Context(), c.Param("id"), req.Note)
+	if err != nil {
+		if errors.Is(err, service.ErrMessageNotFound) {
+			return Fail(http.StatusNotFound, "短信不存在")
+		}
+		h.logger.Error("确认短信失败", zap.String("id", c.Param("id")), zap.Error(err))
+		return failService(http.StatusInternalServerError, err)
+	}
+
+	return c.JSON(http.StatusOK, msg)
+}
+
+// SendSMSEvents 以 SSE 推送短信发送进度，先推送当前状态，到达 sent / failed / rejected 后结束
+// GET /api/serial/sms/:id/events
+// 事件：queued（已保存）→ submitted（已提交给设备）→ sent / failed，当前状态为发送中时为 sending
+func (h *SerialHandler) SendSMSEvents(c echo.Context) error {
+	id := c.Param("id")
+	ctx := c.Request().Context()
+
+	// 先订阅再读取当前状态，避免错过两者之间的事件
+	events, cancel := h.serialService.SubscribeSendStatus(id)
+	defer cancel()
+
+	current, err := h.serialService.GetSendStatus(ctx, id)
+	if err != nil {
+		return Fail(http.StatusNotFound, err.Error())
+	}
+
+	w := c.Response()
+	w.Header().Set(echo.HeaderContentType, "text/event-stream")
+	w.Header().Set(echo.HeaderCacheControl, "no-cache")
+	w.Header().Set(echo.HeaderConnection, "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	write := func(event service.SendStatusEvent) error {
+		data, err := json.Marshal(event)
+		if err != nil {
+			return err
+		}
+		if _, err := fmt.Fprintf(w, "event: status\ndata: %s\n\n", data); err != nil {
+			return err
+		}
+		w.Flush()
+		return nil
+	}
+
+	if err := write(current); err != nil || current.Final() {
+		return nil
+	}
+
+	heartbeat := time.NewTicker(sseHeartbeatInterval)
+	defer heartbeat.Stop()
+	for {
+		select {
+		case event := <-events:
+			if err := write(event); err != nil || event.Final() {
+				return nil
+			}
+		case <-heartbeat.C:
+			// 注释行保持连接，防止代理超时断开
+			if _, err := fmt.Fprint(w, ": ping\n\n"); err != nil {
+				return nil
+			}
+			w.Flush()
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
 // GetStatus 获取设备状态（包含移动网络信息）
-// GET /api/serial/status
+// GET /api/serial/status?device=设备ID，不指定设备时为主设备
 func (h *SerialHandler) GetStatus(c echo.Context) error {
-	data, err := h.serialService.GetStatus()
+	data, err := h.serialService.GetStatus(c.QueryParam("device"))
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, map[string]string{
-			"error": err.Error(),
-		})
+		if errors.Is(err, service.ErrDeviceNotFound) {
+			return Fail(http.StatusNotFound, err.Error())
+		}
+		return Fail(http.StatusInternalServerError, err.Error())
 	}
 
 	return c.JSON(http.StatusOK, data)
 }
 
+// GetDevices 获取所有设备的状态，第一个为主设备
+// GET /api/serial/devices
+func (h *SerialHandler) GetDevices(c echo.Context) error {
+	return c.JSON(http.StatusOK, h.serialService.GetAllStatus())
+}
+
+// RefreshStatus 立即查询设备状态并等待设备响应，不使用缓存
+// POST /api/serial/status/refresh?device=设备ID
+func (h *SerialHandler) RefreshStatus(c echo.Context) error {
+	data, err := h.serialService.RefreshStatus(c.Request().Context(), c.QueryParam("device"))
+	if err != nil {
+		if errors.Is(err, service.ErrDeviceNotFound) {
+			return Fail(http.StatusNotFound, err.Error())
+		}
+		if errors.Is(err, service.ErrStatusRefreshTimeout) {
+			return FailCode(http.StatusGatewayTimeout, CodeDeviceTimeout, err.Error())
+		}
+		return Fail(http.StatusServiceUnavailable, err.Error())
+	}
+
+	return c.JSON(http.StatusOK, data)
+}
+
+// SendUSSDRequest 发送 USSD 请求
+type SendUSSDRequest struct {
+	Code string `json:"code"` // USSD 代码，如 *100#
+}
+
+// SendUSSD 发送 USSD 代码并等待运营商回复，用于查询话费、流量余额
+// POST /api/serial/ussd?device=设备ID
+// Body: {"code": "*100#"}
+func (h *SerialHandler) SendUSSD(c echo.Context) error {
+	var req SendUSSDRequest
+	if err := c.Bind(&req); err != nil {
+		return Fail(http.StatusBadRequest, "请求参数错误")
+	}
+
+	text, err := h.serialService.SendUSSD(c.Request().Context(), c.QueryParam("device"), strings.TrimSpace(req.Code))
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidUSSDCode) {
+			return Fail(http.StatusBadRequest, err.Error())
+		}
+		if errors.Is(err, service.ErrDeviceNotFound) {
+			return Fail(http.StatusNotFound, err.Error())
+		}
+		if errors.Is(err, service.ErrUSSDTimeout) {
+			return FailCode(http.StatusGatewayTimeout, CodeDeviceTimeout, err.Error())
+		}
+		h.logger.Error("发送 USSD 失败", zap.Error(err))
+		return Fail(http.StatusServiceUnavailable, err.Error())
+	}
+
+	return c.JSON(http.StatusOK, map[string]any{
+		"text": text,
+	})
+}
+
 // SetFlymodeRequest 设置飞行模式请求
 type SetFlymodeRequest struct {
 	Enabled bool `json:"enabled"`
 }
 
 // SetFlymode 设置飞行模式
-// POST /api/serial/flymode
+// POST /api/serial/flymode?device=设备ID
 // Body: {"enabled": true}
 func (h *SerialHandler) SetFlymode(c echo.Context) error {
 	var req SetFlymodeRequest
 	if err := c.Bind(&req); err != nil {
-		return c.JSON(http.StatusBadRequest, map[string]string{
-			"error": "请求参数错误",
-		})
+		return Fail(http.StatusBadRequest, "请求参数错误")
 	}
 
-	err := h.serialService.SetFlymode(req.Enabled)
+	deviceID := c.QueryParam("device")
+	err := h.serialService.SetFlymode(deviceID, req.Enabled)
 	if err != nil {
+		if errors.Is(err, service.ErrDeviceNotFound) {
+			return Fail(http.StatusNotFound, err.Error())
+		}
 		h.logger.Error("设置飞行模式失败", zap.Error(err))
-		return c.JSON(http.StatusInternalServerError, map[string]string{
-			"error": err.Error(),
-		})
+		return Fail(http.StatusInternalServerError, err.Error())
 	}
-	go h.serialService.RequestCacheUpdate()
+	go h.serialService.RequestCacheUpdate(deviceID)
 
 	return c.JSON(http.StatusOK, map[string]any{})
 }
 
 // RebootMcu 重启模块
-// POST /api/serial/reboot
+// POST /api/serial/reboot?device=设备ID
 func (h *SerialHandler) RebootMcu(c echo.Context) error {
-	err := h.serialService.RebootMcu()
+	deviceID := c.QueryParam("device")
+	err := h.serialService.RebootMcu(deviceID)
 	if err != nil {
+		if errors.Is(err, service.ErrDeviceNotFound) {
+			return Fail(http.StatusNotFound, err.Error())
+		}
 		h.logger.Error("重启模块", zap.Error(err))
-		return c.JSON(http.StatusInternalServerError, map[string]string{
-			"error": err.Error(),
-		})
+		return Fail(http.StatusInternalServerError, err.Error())
 	}
-	go h.serialService.RequestCacheUpdate()
+	go h.serialService.RequestCacheUpdate(deviceID)
 
 	return c.JSON(http.StatusOK, map[string]any{})
 }
@@ -0,0 +1,101 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/dushixiang/uart_sms_forwarder/config"
+	"github.com/dushixiang/uart_sms_forwarder/internal/migration"
+	"github.com/dushixiang/uart_sms_forwarder/internal/models"
+	"github.com/dushixiang/uart_sms_forwarder/internal/repo"
+	"github.com/dushixiang/uart_sms_forwarder/internal/service"
+	"github.com/glebarez/sqlite"
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// newTestDB 创建临时 SQLite 数据库并执行全部迁移
+func newTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "test.db")), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("打开数据库失败: %v", err)
+	}
+	if err := migration.NewRunner(zap.NewNop(), db).Up(); err != nil {
+		t.Fatalf("执行迁移失败: %v", err)
+	}
+	return db
+}
+
+// newTestSerialHandler 创建不连接串口的串口处理器
+func newTestSerialHandler(t *testing.T, db *gorm.DB) *SerialHandler {
+	t.Helper()
+	logger := zap.NewNop()
+	textMsgService := service.NewTextMessageService(logger, repo.NewTextMessageRepo(db))
+	serialService := service.NewSerialService(logger, config.SerialConfig{}, textMsgService,
+		service.NewNotifier(logger), service.NewPropertyService(logger, db))
+	return NewSerialHandler(logger, serialService, nil)
+}
+
+// serveTestRequest 使用统一的错误处理器处理请求
+func serveTestRequest(e *echo.Echo, method, target string) *httptest.ResponseRecorder {
+	e.HTTPErrorHandler = HTTPErrorHandler(zap.NewNop())
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(method, target, nil))
+	return rec
+}
+
+func TestAckMessage(t *testing.T) {
+	db := newTestDB(t)
+	if err := db.Create(&models.TextMessage{
+		ID:        "msg-1",
+		From:      "10086",
+		Content:   "测试",
+		Type:      models.MessageTypeIncoming,
+		CreatedAt: 1,
+	}).Error; err != nil {
+		t.Fatalf("保存短信失败: %v", err)
+	}
+
+	e := echo.New()
+	e.POST("/api/ack/:id", newTestSerialHandler(t, db).AckMessage)
+
+	tests := []struct {
+		name   string
+		id     string
+		status int
+		code   string
+	}{
+		{name: "存在的短信", id: "msg-1", status: http.StatusOK},
+		{name: "已确认的短信", id: "msg-1", status: http.StatusOK},
+		{name: "不存在的短信", id: "missing", status: http.StatusNotFound, code: CodeNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := serveTestRequest(e, http.MethodPost, "/api/ack/"+tt.id)
+			if rec.Code != tt.status {
+				t.Fatalf("状态码 = %d，期望 %d，响应: %s", rec.Code, tt.status, rec.Body.String())
+			}
+			if tt.code != "" {
+				assertErrorCode(t, rec, tt.code)
+			}
+		})
+	}
+}
+
+// assertErrorCode 检查错误响应的 code，返回解析后的响应
+func assertErrorCode(t *testing.T, rec *httptest.ResponseRecorder, code string) ErrorResponse {
+	t.Helper()
+	var resp ErrorResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("解析错误响应失败: %v，响应: %s", err, rec.Body.String())
+	}
+	if resp.Code != code {
+		t.Fatalf("code = %q，期望 %q", resp.Code, code)
+	}
+	return resp
+}
@@ -0,0 +1,59 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/dushixiang/uart_sms_forwarder/internal/service"
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+)
+
+// SettingsHandler 设置导出导入API处理器，仅管理员可用
+type SettingsHandler struct {
+	logger          *zap.Logger
+	settingsService *service.SettingsService
+}
+
+// NewSettingsHandler 创建设置导出导入Handler实例
+func NewSettingsHandler(logger *zap.Logger, settingsService *service.SettingsService) *SettingsHandler {
+	return &SettingsHandler{
+		logger:          logger,
+		settingsService: settingsService,
+	}
+}
+
+// Export 导出完整的应用设置（通知渠道、号码规则、会话设置、短信模板、定时任务、推送设备等），
+// 敏感字段以明文导出，请妥善保管导出文件
+// GET /api/admin/settings/export
+func (h *SettingsHandler) Export(c echo.Context) error {
+	bundle, err := h.settingsService.Export(c.Request().Context())
+	if err != nil {
+		h.logger.Error("导出设置失败", zap.Error(err))
+		return failService(http.StatusInternalServerError, err)
+	}
+
+	filename := fmt.Sprintf("uart_sms_forwarder_settings_%s.json", time.Now().Format("20060102"))
+	c.Response().Header().Set(echo.HeaderContentDisposition, fmt.Sprintf("attachment; filename=%q", filename))
+	return c.JSONPretty(http.StatusOK, bundle, "  ")
+}
+
+// Import 导入设置导出文件，相同 ID 的记录被覆盖
+// POST /api/admin/settings/import?replace=true
+// Body: 导出的 JSON 文件内容；replace=true 时先清空现有设置
+func (h *SettingsHandler) Import(c echo.Context) error {
+	var bundle service.SettingsBundle
+	if err := c.Bind(&bundle); err != nil {
+		return Fail(http.StatusBadRequest, "请求参数错误")
+	}
+	replace := c.QueryParam("replace") == "true"
+
+	result, err := h.settingsService.Import(c.Request().Context(), &bundle, replace)
+	if err != nil {
+		h.logger.Error("导入设置失败", zap.Error(err))
+		return failService(http.StatusBadRequest, err)
+	}
+
+	return c.JSON(http.StatusOK, result)
+}
@@ -0,0 +1,86 @@
+package handler
+
+import (
+	"encoding/xml"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/dushixiang/uart_sms_forwarder/internal/service"
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+)
+
+// SMSEagleHandler SMSEagle 兼容接口处理器，使用 SMSEagle 的参数和响应格式，不使用 JWT 认证
+type SMSEagleHandler struct {
+	logger          *zap.Logger
+	smsEagleService *service.SMSEagleService
+}
+
+// NewSMSEagleHandler 创建 SMSEagle 兼容接口Handler实例
+func NewSMSEagleHandler(logger *zap.Logger, smsEagleService *service.SMSEagleService) *SMSEagleHandler {
+	return &SMSEagleHandler{
+		logger:          logger,
+		smsEagleService: smsEagleService,
+	}
+}
+
+// smsEagleXMLResult responsetype=xml 时的响应
+type smsEagleXMLResult struct {
+	XMLName   xml.Name `xml:"xml"`
+	MessageID []string `xml:"message_id,omitempty"`
+	ErrorText string   `xml:"error_text,omitempty"`
+	Status    string   `xml:"status"`
+}
+
+// SendSMS 按 SMSEagle HTTP API 发送短信
+// GET /http_api/send_sms?login=admin&pass=123456&to=10086,10010&message=hello
+// 也支持 access_token 代替 login/pass、POST 表单和 responsetype=xml，其余参数（date、flash 等）忽略
+func (h *SMSEagleHandler) SendSMS(c echo.Context) error {
+	req := service.SMSEagleSendRequest{
+		Login:       c.FormValue("login"),
+		Pass:        c.FormValue("pass"),
+		AccessToken: c.FormValue("access_token"),
+		To:          c.FormValue("to"),
+		Message:     c.FormValue("message"),
+	}
+	xmlResponse := c.FormValue("responsetype") == "xml"
+
+	ids, err := h.smsEagleService.Send(c.Request().Context(), req)
+	if err != nil {
+		// SMSEagle 出错时同样返回 200，客户端按响应内容判断
+		errorText := smsEagleErrorText(err)
+		if errors.Is(err, service.ErrSMSEagleUnauthorized) {
+			h.logger.Warn("SMSEagle 兼容接口认证失败", zap.String("ip", c.RealIP()), zap.String("login", req.Login))
+		} else {
+			h.logger.Error("SMSEagle 兼容接口发送短信失败", zap.Error(err))
+		}
+		if xmlResponse {
+			return c.XML(http.StatusOK, smsEagleXMLResult{ErrorText: errorText, Status: "error"})
+		}
+		return c.String(http.StatusOK, errorText)
+	}
+
+	if xmlResponse {
+		return c.XML(http.StatusOK, smsEagleXMLResult{MessageID: ids, Status: "OK"})
+	}
+	lines := make([]string, 0, len(ids))
+	for _, id := range ids {
+		lines = append(lines, "OK; ID=["+id+"]")
+	}
+	return c.String(http.StatusOK, strings.Join(lines, "\n"))
+}
+
+// smsEagleErrorText 转换为 SMSEagle 的错误文本，监控设备通常按这些文本判断失败原因
+func smsEagleErrorText(err error) string {
+	switch {
+	case errors.Is(err, service.ErrSMSEagleUnauthorized):
+		return "Invalid login or password"
+	case errors.Is(err, service.ErrSMSEagleNoText):
+		return "No SMS text specified"
+	case errors.Is(err, service.ErrSMSEagleNoRecipient):
+		return "No recipient specified"
+	default:
+		return "Error sending SMS"
+	}
+}
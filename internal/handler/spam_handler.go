@@ -0,0 +1,70 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/dushixiang/uart_sms_forwarder/internal/service"
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+)
+
+// SpamHandler 垃圾短信识别API处理器
+type SpamHandler struct {
+	logger      *zap.Logger
+	spamService *service.SpamService
+}
+
+// NewSpamHandler 创建垃圾短信识别Handler实例
+func NewSpamHandler(logger *zap.Logger, spamService *service.SpamService) *SpamHandler {
+	return &SpamHandler{
+		logger:      logger,
+		spamService: spamService,
+	}
+}
+
+// TrainSpamRequest 训练请求
+type TrainSpamRequest struct {
+	Spam bool `json:"spam"` // true 标记为垃圾短信，false 标记为正常短信
+}
+
+// Train 将短信标记为垃圾或正常短信，同时训练分类模型
+// POST /api/messages/:id/spam
+// Body: {"spam": true}
+func (h *SpamHandler) Train(c echo.Context) error {
+	var req TrainSpamRequest
+	if err := c.Bind(&req); err != nil {
+		return Fail(http.StatusBadRequest, "请求参数错误")
+	}
+
+	msg, err := h.spamService.Train(c.Request().Context(), c.Param("id"), req.Spam)
+	if err != nil {
+		h.logger.Error("训练垃圾短信模型失败", zap.Error(err), zap.String("id", c.Param("id")))
+		return Fail(http.StatusBadRequest, err.Error())
+	}
+
+	return c.JSON(http.StatusOK, msg)
+}
+
+// TestSpamRequest 垃圾短信评分测试请求
+type TestSpamRequest struct {
+	From    string `json:"from"`
+	Content string `json:"content"`
+}
+
+// Test 使用当前规则和模型对示例短信评分
+// POST /api/spam-filter/test
+// Body: {"from": "10690000", "content": "限时优惠，回T退订"}
+func (h *SpamHandler) Test(c echo.Context) error {
+	var req TestSpamRequest
+	if err := c.Bind(&req); err != nil {
+		return Fail(http.StatusBadRequest, "请求参数错误")
+	}
+
+	result, err := h.spamService.Test(c.Request().Context(), req.From, req.Content)
+	if err != nil {
+		h.logger.Error("垃圾短信评分失败", zap.Error(err))
+		return Fail(http.StatusInternalServerError, "垃圾短信评分失败")
+	}
+
+	return c.JSON(http.StatusOK, result)
+}
@@ -0,0 +1,60 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/dushixiang/uart_sms_forwarder/internal/service"
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+)
+
+// StandbyHandler 夜间待机API处理器
+type StandbyHandler struct {
+	logger         *zap.Logger
+	standbyService *service.StandbyService
+}
+
+// NewStandbyHandler 创建夜间待机Handler实例
+func NewStandbyHandler(logger *zap.Logger, standbyService *service.StandbyService) *StandbyHandler {
+	return &StandbyHandler{
+		logger:         logger,
+		standbyService: standbyService,
+	}
+}
+
+// GetStatus 获取夜间待机状态，待机时段在配置 standby 中设置
+// GET /api/serial/standby
+func (h *StandbyHandler) GetStatus(c echo.Context) error {
+	return c.JSON(http.StatusOK, h.standbyService.Status(c.Request().Context()))
+}
+
+// OverrideRequest 手动设置待机请求
+type OverrideRequest struct {
+	Action  string `json:"action"`  // sleep, wake, auto
+	Minutes int    `json:"minutes"` // 持续分钟数，为 0 时持续到下一次计划切换
+}
+
+// Override 手动进入或退出待机，auto 恢复按计划执行
+// POST /api/serial/standby
+// Body: {"action": "wake", "minutes": 60}
+func (h *StandbyHandler) Override(c echo.Context) error {
+	var req OverrideRequest
+	if err := c.Bind(&req); err != nil {
+		return Fail(http.StatusBadRequest, "请求参数错误")
+	}
+	if req.Minutes < 0 {
+		return Fail(http.StatusBadRequest, "持续时间不能小于 0")
+	}
+
+	status, err := h.standbyService.Override(c.Request().Context(), req.Action, req.Minutes)
+	switch {
+	case err == nil:
+		return c.JSON(http.StatusOK, status)
+	case errors.Is(err, service.ErrInvalidStandbyAction):
+		return Fail(http.StatusBadRequest, err.Error())
+	default:
+		h.logger.Error("设置待机失败", zap.String("action", req.Action), zap.Error(err))
+		return Fail(http.StatusServiceUnavailable, err.Error())
+	}
+}
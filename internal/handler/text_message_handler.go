@@ -1,9 +1,17 @@
 package handler
 
 import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
 	"net/http"
 	"net/url"
+	"strconv"
+	"strings"
+	"time"
 
+	"github.com/dushixiang/uart_sms_forwarder/internal/models"
 	"github.com/dushixiang/uart_sms_forwarder/internal/repo"
 	"github.com/dushixiang/uart_sms_forwarder/internal/service"
 
@@ -27,6 +35,144 @@ func NewTextMessageHandler(logger *zap.Logger, service *service.TextMessageServi
 	}
 }
 
+// List 按时间倒序分页获取短信，folder=junk 时查询垃圾箱，category 按号码分类筛选
+// GET /api/messages?cursor=xxx&limit=50&folder=junk&category=营销
+// GET /api/messages?page=2&size=20&q=验证码&from=10086&type=incoming&status=received&since=1704067200000&until=1704153600000
+// 默认使用 cursor 键集分页；传入 page 时按页码分页，并返回符合条件的总数 total。
+// q 搜索短信内容（启用短信内容加密时不支持），from 为发送方号码，device 为收发短信的设备 ID，since/until 为时间戳（毫秒）。
+func (h *TextMessageHandler) List(c echo.Context) error {
+	filter, err := parseMessageFilter(c)
+	if err != nil {
+		return err
+	}
+
+	var page *service.MessagePage
+	if c.QueryParam("page") != "" {
+		pageNum, convErr := strconv.Atoi(c.QueryParam("page"))
+		if convErr != nil || pageNum < 1 {
+			return Fail(http.StatusBadRequest, "page 必须是大于 0 的整数")
+		}
+		size, _ := strconv.Atoi(c.QueryParam("size"))
+		page, err = h.service.ListMessagesByPage(c.Request().Context(), filter, pageNum, size)
+	} else {
+		limit, _ := strconv.Atoi(c.QueryParam("limit"))
+		page, err = h.service.ListMessages(c.Request().Context(), filter, c.QueryParam("cursor"), limit)
+	}
+	if err != nil {
+		h.logger.Error("获取短信列表失败", zap.Error(err))
+		return Fail(http.StatusBadRequest, err.Error())
+	}
+
+	return c.JSON(http.StatusOK, page)
+}
+
+// parseMessageFilter 解析短信列表和导出共用的筛选参数
+func parseMessageFilter(c echo.Context) (service.MessageFilter, error) {
+	filter := service.MessageFilter{
+		Junk:     c.QueryParam("folder") == "junk",
+		Category: c.QueryParam("category"),
+		Query:    strings.TrimSpace(c.QueryParam("q")),
+		From:     c.QueryParam("from"),
+		Type:     models.MessageType(c.QueryParam("type")),
+		Status:   models.MessageStatus(c.QueryParam("status")),
+		DeviceID: c.QueryParam("device"),
+	}
+	switch filter.Type {
+	case "", models.MessageTypeIncoming, models.MessageTypeOutgoing:
+	default:
+		return filter, Fail(http.StatusBadRequest, "type 只能是 incoming 或 outgoing")
+	}
+	switch filter.Status {
+	case "", models.MessageStatusReceived, models.MessageStatusSending, models.MessageStatusSent, models.MessageStatusFailed,
+		models.MessageStatusDelivered, models.MessageStatusDeliveryFailed, models.MessageStatusPendingApproval, models.MessageStatusRejected:
+	default:
+		return filter, Fail(http.StatusBadRequest, "status 只能是 received、sending、sent、failed、delivered、delivery_failed、pending_approval 或 rejected")
+	}
+	for name, target := range map[string]*int64{"since": &filter.Since, "until": &filter.Until} {
+		if value := c.QueryParam(name); value != "" {
+			parsed, err := strconv.ParseInt(value, 10, 64)
+			if err != nil {
+				return filter, Fail(http.StatusBadRequest, name+" 必须是时间戳（毫秒）")
+			}
+			*target = parsed
+		}
+	}
+	return filter, nil
+}
+
+// Export 以 CSV 或 JSON 文件下载符合条件的所有短信，筛选参数与短信列表相同，边查询边输出
+// GET /api/messages/export?format=csv&q=验证码&from=10086&type=incoming&since=1704067200000
+// format 为 csv（默认，带 UTF-8 BOM，可直接用 Excel 打开）或 json（短信记录数组）
+func (h *TextMessageHandler) Export(c echo.Context) error {
+	filter, err := parseMessageFilter(c)
+	if err != nil {
+		return err
+	}
+	format := c.QueryParam("format")
+	if format == "" {
+		format = "csv"
+	}
+	if format != "csv" && format != "json" {
+		return Fail(http.StatusBadRequest, "format 只能是 csv 或 json")
+	}
+	// 开始输出后无法再返回错误响应，先检查筛选条件
+	if err := filter.Validate(); err != nil {
+		return Fail(http.StatusBadRequest, err.Error())
+	}
+
+	w := c.Response()
+	filename := fmt.Sprintf("sms_%s.%s", time.Now().Format("20060102_150405"), format)
+	w.Header().Set(echo.HeaderContentDisposition,
+		fmt.Sprintf("attachment; filename=%q; filename*=UTF-8''%s", filename, url.PathEscape(filename)))
+
+	ctx := c.Request().Context()
+	if format == "json" {
+		w.Header().Set(echo.HeaderContentType, echo.MIMEApplicationJSONCharsetUTF8)
+		w.WriteHeader(http.StatusOK)
+		encoder := json.NewEncoder(w)
+		first := true
+		_, _ = io.WriteString(w, "[")
+		err = h.service.ExportMessages(ctx, filter, func(msg *models.TextMessage) error {
+			if !first {
+				if _, err := io.WriteString(w, ","); err != nil {
+					return err
+				}
+			}
+			first = false
+			return encoder.Encode(msg)
+		})
+		_, _ = io.WriteString(w, "]\n")
+	} else {
+		w.Header().Set(echo.HeaderContentType, "text/csv; charset=utf-8")
+		w.WriteHeader(http.StatusOK)
+		_, _ = io.WriteString(w, "\uFEFF")
+		writer := csv.NewWriter(w)
+		_ = writer.Write([]string{"id", "time", "type", "from", "to", "content", "status", "category", "tags", "spam", "source", "failure_reason"})
+		err = h.service.ExportMessages(ctx, filter, func(msg *models.TextMessage) error {
+			return writer.Write([]string{
+				msg.ID,
+				time.UnixMilli(msg.CreatedAt).Format(time.DateTime),
+				string(msg.Type),
+				msg.From,
+				msg.To,
+				msg.Content,
+				string(msg.Status),
+				msg.Category,
+				strings.Join(msg.Tags, ","),
+				strconv.FormatBool(msg.Spam),
+				msg.Source,
+				msg.FailureReason,
+			})
+		})
+		writer.Flush()
+	}
+	if err != nil {
+		// 响应已开始输出，只能记录日志，下载的文件不完整
+		h.logger.Error("导出短信失败", zap.Error(err))
+	}
+	return nil
+}
+
 // Delete 删除单条短信
 // DELETE /api/messages/:id
 func (h *TextMessageHandler) Delete(c echo.Context) error {
@@ -36,9 +182,7 @@ func (h *TextMessageHandler) Delete(c echo.Context) error {
 
 	if err := h.service.Delete(c.Request().Context(), id); err != nil {
 		h.logger.Error("删除短信失败", zap.Error(err), zap.String("id", id))
-		return c.JSON(http.StatusInternalServerError, map[string]string{
-			"error": "删除失败",
-		})
+		return Fail(http.StatusInternalServerError, "删除失败")
 	}
 
 	return c.JSON(http.StatusOK, map[string]string{
@@ -46,14 +190,55 @@ func (h *TextMessageHandler) Delete(c echo.Context) error {
 	})
 }
 
+// Pin 在会话中置顶短信
+// POST /api/messages/:id/pin
+func (h *TextMessageHandler) Pin(c echo.Context) error {
+	return h.setPinned(c, true)
+}
+
+// Unpin 取消置顶短信
+// DELETE /api/messages/:id/pin
+func (h *TextMessageHandler) Unpin(c echo.Context) error {
+	return h.setPinned(c, false)
+}
+
+func (h *TextMessageHandler) setPinned(c echo.Context, pinned bool) error {
+	id := c.Param("id")
+	msg, err := h.service.SetPinned(c.Request().Context(), id, pinned)
+	if err != nil {
+		h.logger.Error("更新置顶状态失败", zap.Error(err), zap.String("id", id))
+		return Fail(http.StatusBadRequest, err.Error())
+	}
+
+	return c.JSON(http.StatusOK, msg)
+}
+
+// Batch 批量操作短信（删除、标记已读、添加标签），在一个事务中执行
+// POST /api/messages/batch
+// Body: {"ids": ["id1", "id2"], "operation": "delete|markRead|tag", "tags": ["验证码"]}
+func (h *TextMessageHandler) Batch(c echo.Context) error {
+	var req service.BatchRequest
+	if err := c.Bind(&req); err != nil {
+		return Fail(http.StatusBadRequest, "请求参数错误")
+	}
+
+	affected, err := h.service.Batch(c.Request().Context(), req)
+	if err != nil {
+		return Fail(http.StatusBadRequest, err.Error())
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"message":  "操作成功",
+		"affected": affected,
+	})
+}
+
 // Clear 清空所有短信
 // DELETE /api/messages
 func (h *TextMessageHandler) Clear(c echo.Context) error {
 	if err := h.service.Clear(c.Request().Context()); err != nil {
 		h.logger.Error("清空短信失败", zap.Error(err))
-		return c.JSON(http.StatusInternalServerError, map[string]string{
-			"error": "清空失败",
-		})
+		return Fail(http.StatusInternalServerError, "清空失败")
 	}
 
 	return c.JSON(http.StatusOK, map[string]string{
@@ -67,9 +252,45 @@ func (h *TextMessageHandler) GetStats(c echo.Context) error {
 	stats, err := h.service.GetStats(c.Request().Context())
 	if err != nil {
 		h.logger.Error("获取统计信息失败", zap.Error(err))
-		return c.JSON(http.StatusInternalServerError, map[string]string{
-			"error": "获取统计信息失败",
-		})
+		return Fail(http.StatusInternalServerError, "获取统计信息失败")
+	}
+
+	return c.JSON(http.StatusOK, stats)
+}
+
+// Suggest 搜索建议，返回匹配的发送方、联系人和标签
+// GET /api/messages/suggest?q=138
+func (h *TextMessageHandler) Suggest(c echo.Context) error {
+	suggestions, err := h.service.Suggest(c.Request().Context(), c.QueryParam("q"))
+	if err != nil {
+		h.logger.Error("获取搜索建议失败", zap.Error(err))
+		return Fail(http.StatusInternalServerError, "获取搜索建议失败")
+	}
+
+	return c.JSON(http.StatusOK, suggestions)
+}
+
+// GetDailyStats 获取每日收发统计
+// GET /api/messages/stats/daily?days=30
+func (h *TextMessageHandler) GetDailyStats(c echo.Context) error {
+	days, _ := strconv.Atoi(c.QueryParam("days"))
+	stats, err := h.service.GetDailyStats(c.Request().Context(), days)
+	if err != nil {
+		h.logger.Error("获取每日统计失败", zap.Error(err))
+		return Fail(http.StatusInternalServerError, "获取每日统计失败")
+	}
+
+	return c.JSON(http.StatusOK, stats)
+}
+
+// GetHeatmapStats 获取按星期和小时分组的收发统计
+// GET /api/messages/stats/heatmap?days=90
+func (h *TextMessageHandler) GetHeatmapStats(c echo.Context) error {
+	days, _ := strconv.Atoi(c.QueryParam("days"))
+	stats, err := h.service.GetHeatmapStats(c.Request().Context(), days)
+	if err != nil {
+		h.logger.Error("获取时段统计失败", zap.Error(err))
+		return Fail(http.StatusInternalServerError, "获取时段统计失败")
 	}
 
 	return c.JSON(http.StatusOK, stats)
@@ -81,22 +302,20 @@ func (h *TextMessageHandler) GetConversations(c echo.Context) error {
 	conversations, err := h.service.GetConversations(c.Request().Context())
 	if err != nil {
 		h.logger.Error("获取会话列表失败", zap.Error(err))
-		return c.JSON(http.StatusInternalServerError, map[string]string{
-			"error": "获取会话列表失败",
-		})
+		return Fail(http.StatusInternalServerError, "获取会话列表失败")
 	}
 
 	return c.JSON(http.StatusOK, conversations)
 }
 
-// GetConversationMessages 获取指定会话的所有消息
+// GetConversationMessages 获取指定会话的消息
 // GET /api/messages/conversations/:peer/messages
+// 未指定 cursor 和 limit 时返回全部消息，置顶的消息排在最前；指定时按键集分页返回 MessagePage，从最新一页开始向前翻页，
+// 第一页的 pinned 为置顶的消息
 func (h *TextMessageHandler) GetConversationMessages(c echo.Context) error {
 	peer := c.Param("peer")
 	if peer == "" {
-		return c.JSON(http.StatusBadRequest, map[string]string{
-			"error": "peer 参数不能为空",
-		})
+		return Fail(http.StatusBadRequest, "peer 参数不能为空")
 	}
 
 	// 手动 URL 解码以处理特殊字符（如 + 号）
@@ -111,25 +330,94 @@ func (h *TextMessageHandler) GetConversationMessages(c echo.Context) error {
 		zap.String("peer_raw", peer),
 		zap.String("peer_decoded", decodedPeer))
 
-	messages, err := h.service.GetConversationMessages(c.Request().Context(), decodedPeer)
+	cursor := c.QueryParam("cursor")
+	limitParam := c.QueryParam("limit")
+	if cursor != "" || limitParam != "" {
+		limit, _ := strconv.Atoi(limitParam)
+		page, err := h.service.ListConversationMessages(c.Request().Context(), decodedPeer, cursor, limit)
+		if err != nil {
+			h.logger.Error("获取会话消息失败", zap.Error(err), zap.String("peer", decodedPeer))
+			return Fail(http.StatusBadRequest, err.Error())
+		}
+		return c.JSON(http.StatusOK, page)
+	}
+
+	messages, err := h.service.GetConversationMessagesPinnedFirst(c.Request().Context(), decodedPeer)
 	if err != nil {
 		h.logger.Error("获取会话消息失败", zap.Error(err), zap.String("peer", decodedPeer))
-		return c.JSON(http.StatusInternalServerError, map[string]string{
-			"error": "获取会话消息失败",
-		})
+		return Fail(http.StatusInternalServerError, "获取会话消息失败")
 	}
 
 	return c.JSON(http.StatusOK, messages)
 }
 
+// GetConversationStats 获取会话统计：收发数量、首次和最近联系时间、月均数量和最活跃的时段
+// GET /api/messages/conversations/:peer/stats
+func (h *TextMessageHandler) GetConversationStats(c echo.Context) error {
+	peer := c.Param("peer")
+	if peer == "" {
+		return Fail(http.StatusBadRequest, "peer 参数不能为空")
+	}
+
+	// 手动 URL 解码以处理特殊字符（如 + 号）
+	decodedPeer, err := url.QueryUnescape(peer)
+	if err != nil {
+		decodedPeer = peer
+	}
+
+	stats, err := h.service.GetConversationStats(c.Request().Context(), decodedPeer)
+	if err != nil {
+		h.logger.Error("获取会话统计失败", zap.Error(err), zap.String("peer", decodedPeer))
+		return Fail(http.StatusInternalServerError, "获取会话统计失败")
+	}
+
+	return c.JSON(http.StatusOK, stats)
+}
+
+// ExportConversation 导出会话为文本文件
+// GET /api/messages/conversations/:peer/export
+func (h *TextMessageHandler) ExportConversation(c echo.Context) error {
+	peer := c.Param("peer")
+	if peer == "" {
+		return Fail(http.StatusBadRequest, "peer 参数不能为空")
+	}
+
+	// 手动 URL 解码以处理特殊字符（如 + 号）
+	decodedPeer, err := url.QueryUnescape(peer)
+	if err != nil {
+		decodedPeer = peer
+	}
+
+	transcript, err := h.service.ExportConversation(c.Request().Context(), decodedPeer)
+	if err != nil {
+		h.logger.Error("导出会话失败", zap.Error(err), zap.String("peer", decodedPeer))
+		return Fail(http.StatusInternalServerError, "导出会话失败")
+	}
+
+	filename := fmt.Sprintf("sms_%s_%s.txt", safeFilename(decodedPeer), time.Now().Format("20060102"))
+	c.Response().Header().Set(echo.HeaderContentDisposition,
+		fmt.Sprintf("attachment; filename=%q; filename*=UTF-8''%s", filename, url.PathEscape(filename)))
+	return c.Blob(http.StatusOK, "text/plain; charset=utf-8", []byte(transcript))
+}
+
+// safeFilename 替换文件名中不安全的字符
+func safeFilename(name string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= '0' && r <= '9', r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r == '+', r == '-', r == '_':
+			return r
+		default:
+			return '_'
+		}
+	}, name)
+}
+
 // DeleteConversation 删除整个会话（与某个联系人的所有消息）
 // DELETE /api/messages/conversations/:peer
 func (h *TextMessageHandler) DeleteConversation(c echo.Context) error {
 	peer := c.Param("peer")
 	if peer == "" {
-		return c.JSON(http.StatusBadRequest, map[string]string{
-			"error": "peer 参数不能为空",
-		})
+		return Fail(http.StatusBadRequest, "peer 参数不能为空")
 	}
 
 	// 手动 URL 解码以处理特殊字符（如 + 号）
@@ -146,12 +434,38 @@ func (h *TextMessageHandler) DeleteConversation(c echo.Context) error {
 
 	if err := h.service.DeleteConversation(c.Request().Context(), decodedPeer); err != nil {
 		h.logger.Error("删除会话失败", zap.Error(err), zap.String("peer", decodedPeer))
-		return c.JSON(http.StatusInternalServerError, map[string]string{
-			"error": "删除会话失败",
-		})
+		return Fail(http.StatusInternalServerError, "删除会话失败")
 	}
 
 	return c.JSON(http.StatusOK, map[string]string{
 		"message": "删除成功",
 	})
 }
+
+// EncryptContent 加密已有的明文短信内容，需在配置中启用 App.EncryptMessages
+// POST /api/admin/messages/encrypt
+func (h *TextMessageHandler) EncryptContent(c echo.Context) error {
+	count, err := h.service.EncryptExisting(c.Request().Context())
+	if err != nil {
+		h.logger.Error("加密已有短信失败", zap.Error(err))
+		return failService(http.StatusBadRequest, err)
+	}
+
+	return c.JSON(http.StatusOK, map[string]int64{
+		"count": count,
+	})
+}
+
+// DecryptContent 将已加密的短信内容恢复为明文，需先在配置中关闭 App.EncryptMessages
+// POST /api/admin/messages/decrypt
+func (h *TextMessageHandler) DecryptContent(c echo.Context) error {
+	count, err := h.service.DecryptExisting(c.Request().Context())
+	if err != nil {
+		h.logger.Error("解密已有短信失败", zap.Error(err))
+		return failService(http.StatusBadRequest, err)
+	}
+
+	return c.JSON(http.StatusOK, map[string]int64{
+		"count": count,
+	})
+}
@@ -0,0 +1,93 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/dushixiang/uart_sms_forwarder/internal/models"
+	"github.com/dushixiang/uart_sms_forwarder/internal/service"
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+)
+
+// TransactionHandler 银行交易API处理器
+type TransactionHandler struct {
+	logger             *zap.Logger
+	transactionService *service.TransactionService
+}
+
+// NewTransactionHandler 创建银行交易Handler实例
+func NewTransactionHandler(logger *zap.Logger, transactionService *service.TransactionService) *TransactionHandler {
+	return &TransactionHandler{
+		logger:             logger,
+		transactionService: transactionService,
+	}
+}
+
+// monthParam 读取 month 参数，默认本月
+func monthParam(c echo.Context) string {
+	if month := c.QueryParam("month"); month != "" {
+		return month
+	}
+	return time.Now().Format("2006-01")
+}
+
+// List 获取指定月份的交易记录
+// GET /api/transactions?month=2024-01
+func (h *TransactionHandler) List(c echo.Context) error {
+	transactions, err := h.transactionService.ListByMonth(c.Request().Context(), monthParam(c))
+	if err != nil {
+		return Fail(http.StatusBadRequest, err.Error())
+	}
+
+	if transactions == nil {
+		transactions = []models.Transaction{}
+	}
+	return c.JSON(http.StatusOK, transactions)
+}
+
+// GetMonthlySummary 获取最近几个月的收支汇总
+// GET /api/transactions/summary/monthly?months=12
+func (h *TransactionHandler) GetMonthlySummary(c echo.Context) error {
+	months := 12
+	if v := c.QueryParam("months"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			return Fail(http.StatusBadRequest, "months 参数无效")
+		}
+		months = n
+	}
+
+	summaries, err := h.transactionService.MonthlySummaries(c.Request().Context(), months)
+	if err != nil {
+		h.logger.Error("获取月度收支汇总失败", zap.Error(err))
+		return Fail(http.StatusInternalServerError, "获取月度收支汇总失败")
+	}
+	return c.JSON(http.StatusOK, summaries)
+}
+
+// GetMerchantSummary 获取指定月份按商户汇总的支出
+// GET /api/transactions/summary/merchants?month=2024-01
+func (h *TransactionHandler) GetMerchantSummary(c echo.Context) error {
+	summaries, err := h.transactionService.MerchantSummaries(c.Request().Context(), monthParam(c))
+	if err != nil {
+		return Fail(http.StatusBadRequest, err.Error())
+	}
+	return c.JSON(http.StatusOK, summaries)
+}
+
+// Rebuild 重新解析所有收到的短信生成交易记录
+// POST /api/transactions/rebuild
+func (h *TransactionHandler) Rebuild(c echo.Context) error {
+	count, err := h.transactionService.Rebuild(c.Request().Context())
+	if err != nil {
+		h.logger.Error("重新解析交易记录失败", zap.Error(err))
+		return Fail(http.StatusInternalServerError, "重新解析交易记录失败")
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"message": "解析完成",
+		"count":   count,
+	})
+}
@@ -0,0 +1,61 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/dushixiang/uart_sms_forwarder/internal/service"
+	"github.com/dushixiang/uart_sms_forwarder/internal/version"
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+)
+
+// VersionHandler 版本信息API处理器
+type VersionHandler struct {
+	logger        *zap.Logger
+	serialService *service.SerialService
+	updateService *service.UpdateService
+}
+
+// NewVersionHandler 创建版本信息Handler实例
+func NewVersionHandler(logger *zap.Logger, serialService *service.SerialService, updateService *service.UpdateService) *VersionHandler {
+	return &VersionHandler{
+		logger:        logger,
+		serialService: serialService,
+		updateService: updateService,
+	}
+}
+
+// DeviceVersion 主设备的版本信息
+type DeviceVersion struct {
+	Backend   string `json:"backend"`   // 设备后端：lua、at、hilink、android、modemmanager
+	Version   string `json:"version"`   // Lua 脚本版本，设备未上报状态时为空
+	PortName  string `json:"portName"`  // 串口名称
+	Connected bool   `json:"connected"` // 连接状态
+}
+
+// VersionResponse 版本信息响应
+type VersionResponse struct {
+	version.Info
+	Device DeviceVersion       `json:"device"`
+	Update *service.UpdateInfo `json:"update,omitempty"` // 最新版本信息，关闭检查或尚未检查成功时为空
+}
+
+// GetVersion 获取服务端版本、运行信息、设备固件版本和最新发布版本，用于问题排查和更新提示
+// GET /api/version
+func (h *VersionHandler) GetVersion(c echo.Context) error {
+	resp := VersionResponse{
+		Info: version.GetInfo(),
+		Device: DeviceVersion{
+			Backend: h.serialService.BackendName(""),
+		},
+		Update: h.updateService.Latest(),
+	}
+
+	if status, err := h.serialService.GetStatus(""); err == nil && status != nil {
+		resp.Device.Version = status.Version
+		resp.Device.PortName = status.PortName
+		resp.Device.Connected = status.Connected
+	}
+
+	return c.JSON(http.StatusOK, resp)
+}
@@ -0,0 +1,84 @@
+package handler
+
+import (
+	"net/http"
+	"net/url"
+
+	"github.com/dushixiang/uart_sms_forwarder/internal/models"
+	"github.com/dushixiang/uart_sms_forwarder/internal/service"
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+)
+
+// VisibilityHandler 会话可见性API处理器，仅管理员可用
+type VisibilityHandler struct {
+	logger            *zap.Logger
+	visibilityService *service.VisibilityService
+}
+
+// NewVisibilityHandler 创建会话可见性Handler实例
+func NewVisibilityHandler(logger *zap.Logger, visibilityService *service.VisibilityService) *VisibilityHandler {
+	return &VisibilityHandler{
+		logger:            logger,
+		visibilityService: visibilityService,
+	}
+}
+
+// List 获取所有会话分配
+// GET /api/peer-assignments
+func (h *VisibilityHandler) List(c echo.Context) error {
+	assignments, err := h.visibilityService.List(c.Request().Context())
+	if err != nil {
+		h.logger.Error("获取会话分配失败", zap.Error(err))
+		return failService(http.StatusInternalServerError, err)
+	}
+
+	if assignments == nil {
+		assignments = []models.PeerAssignment{}
+	}
+	return c.JSON(http.StatusOK, assignments)
+}
+
+// AssignRequest 分配会话请求
+type AssignRequest struct {
+	Users []string `json:"users"` // 可查看该会话的用户
+}
+
+// Assign 将会话分配给指定用户，其他非管理员用户将看不到该会话
+// PUT /api/peer-assignments/:peer
+// Body: {"users": ["alice"]}
+func (h *VisibilityHandler) Assign(c echo.Context) error {
+	var req AssignRequest
+	if err := c.Bind(&req); err != nil {
+		return Fail(http.StatusBadRequest, "请求参数错误")
+	}
+
+	peer, err := url.QueryUnescape(c.Param("peer"))
+	if err != nil {
+		peer = c.Param("peer")
+	}
+	assignment, err := h.visibilityService.Assign(c.Request().Context(), peer, req.Users)
+	if err != nil {
+		h.logger.Error("分配会话失败", zap.String("peer", peer), zap.Error(err))
+		return failService(http.StatusBadRequest, err)
+	}
+
+	return c.JSON(http.StatusOK, assignment)
+}
+
+// Unassign 取消会话分配，会话恢复为所有用户可见
+// DELETE /api/peer-assignments/:peer
+func (h *VisibilityHandler) Unassign(c echo.Context) error {
+	peer, err := url.QueryUnescape(c.Param("peer"))
+	if err != nil {
+		peer = c.Param("peer")
+	}
+	if err := h.visibilityService.Unassign(c.Request().Context(), peer); err != nil {
+		h.logger.Error("取消会话分配失败", zap.String("peer", peer), zap.Error(err))
+		return failService(http.StatusInternalServerError, err)
+	}
+
+	return c.JSON(http.StatusOK, map[string]string{
+		"message": "已取消分配",
+	})
+}
@@ -0,0 +1,124 @@
+package internal
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/go-orz/orz"
+	"github.com/spf13/viper"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"gopkg.in/natefinch/lumberjack.v2"
+)
+
+const (
+	// DefaultLogMaxSize 单个日志文件最大大小（MB）
+	DefaultLogMaxSize = 100
+	// DefaultLogMaxAge 历史日志保留天数
+	DefaultLogMaxAge = 7
+	// DefaultLogMaxBackups 保留的历史日志文件数
+	DefaultLogMaxBackups = 10
+)
+
+// logConfig 日志配置，在 orz 日志配置基础上增加历史文件数量限制
+type logConfig struct {
+	orz.LogConfig
+	MaxBackups int // 保留的历史日志文件数，0 使用默认值，-1 表示不限制
+}
+
+// withRotatingLogger 读取配置文件的 log 段并初始化按大小轮转的日志器，
+// 长期运行的设备无需依赖外部 logrotate
+func withRotatingLogger(configPath string) orz.Option {
+	return func(f *orz.Framework) error {
+		app := f.App()
+		if app.GetConfig() == nil {
+			return fmt.Errorf("config not loaded")
+		}
+
+		cfg := logConfig{LogConfig: app.GetConfig().Log}
+		// orz 的日志配置不包含 max_backups，单独读取
+		v := viper.New()
+		v.SetConfigFile(configPath)
+		v.AutomaticEnv()
+		v.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
+		if err := v.ReadInConfig(); err == nil {
+			cfg.MaxBackups = v.GetInt("log.max_backups")
+		}
+
+		logger, err := newLogger(cfg)
+		if err != nil {
+			return err
+		}
+		app.SetLogger(logger)
+		return nil
+	}
+}
+
+// newLogger 根据配置创建日志器，文件输出按大小轮转，encode 为 json 时文件和控制台均输出 JSON
+func newLogger(cfg logConfig) (*zap.Logger, error) {
+	level, err := zapcore.ParseLevel(strings.ToLower(cfg.Level))
+	if err != nil {
+		level = zapcore.InfoLevel
+	}
+	jsonEncode := strings.EqualFold(cfg.Encode, "json")
+
+	encoderConfig := zap.NewProductionEncoderConfig()
+	encoderConfig.TimeKey = "time"
+	encoderConfig.EncodeTime = zapcore.TimeEncoderOfLayout("2006-01-02 15:04:05.000")
+	encoderConfig.EncodeLevel = zapcore.CapitalLevelEncoder
+
+	var cores []zapcore.Core
+
+	if cfg.Filename != "" {
+		if dir := filepath.Dir(cfg.Filename); dir != "." {
+			if err := os.MkdirAll(dir, 0755); err != nil {
+				return nil, fmt.Errorf("创建日志目录失败: %w", err)
+			}
+		}
+
+		writer := &lumberjack.Logger{
+			Filename:   cfg.Filename,
+			MaxSize:    orDefault(cfg.MaxSize, DefaultLogMaxSize),
+			MaxAge:     orDefault(cfg.MaxAge, DefaultLogMaxAge),
+			MaxBackups: orDefault(cfg.MaxBackups, DefaultLogMaxBackups),
+			Compress:   cfg.Compress,
+			LocalTime:  true,
+		}
+
+		var encoder zapcore.Encoder
+		if jsonEncode {
+			encoder = zapcore.NewJSONEncoder(encoderConfig)
+		} else {
+			encoder = zapcore.NewConsoleEncoder(encoderConfig)
+		}
+		cores = append(cores, zapcore.NewCore(encoder, zapcore.AddSync(writer), level))
+	}
+
+	if cfg.Console || len(cores) == 0 {
+		var encoder zapcore.Encoder
+		if jsonEncode {
+			encoder = zapcore.NewJSONEncoder(encoderConfig)
+		} else {
+			consoleConfig := encoderConfig
+			consoleConfig.EncodeLevel = zapcore.CapitalColorLevelEncoder
+			encoder = zapcore.NewConsoleEncoder(consoleConfig)
+		}
+		cores = append(cores, zapcore.NewCore(encoder, zapcore.AddSync(os.Stdout), level))
+	}
+
+	return zap.New(zapcore.NewTee(cores...), zap.AddCaller(), zap.AddStacktrace(zapcore.ErrorLevel)), nil
+}
+
+// orDefault 未配置（0）时使用默认值，负数表示不限制
+func orDefault(value, def int) int {
+	switch {
+	case value == 0:
+		return def
+	case value < 0:
+		return 0
+	default:
+		return value
+	}
+}
@@ -20,20 +20,20 @@ func JWTMiddleware(secret string, logger *zap.Logger) echo.MiddlewareFunc {
 		return func(c echo.Context) error {
 			// 获取 Authorization header
 			authHeader := c.Request().Header.Get("Authorization")
+			// 浏览器 EventSource 和 WebSocket 无法设置请求头，SSE 和 WebSocket 请求允许通过 token 查询参数传递
+			if authHeader == "" && (isEventStream(c) || isWebSocket(c)) && c.QueryParam("token") != "" {
+				authHeader = "Bearer " + c.QueryParam("token")
+			}
 			if authHeader == "" {
 				logger.Warn("缺少 Authorization header")
-				return c.JSON(http.StatusUnauthorized, map[string]string{
-					"error": "缺少认证信息",
-				})
+				return echo.NewHTTPError(http.StatusUnauthorized, "缺少认证信息")
 			}
 
 			// 提取 Bearer token
 			parts := strings.SplitN(authHeader, " ", 2)
 			if len(parts) != 2 || parts[0] != "Bearer" {
 				logger.Warn("Authorization header 格式错误", zap.String("header", authHeader))
-				return c.JSON(http.StatusUnauthorized, map[string]string{
-					"error": "认证信息格式错误",
-				})
+				return echo.NewHTTPError(http.StatusUnauthorized, "认证信息格式错误")
 			}
 
 			tokenString := parts[1]
@@ -42,9 +42,7 @@ func JWTMiddleware(secret string, logger *zap.Logger) echo.MiddlewareFunc {
 			claims, err := util.VerifyToken(tokenString, secret)
 			if err != nil {
 				logger.Warn("token 验证失败", zap.Error(err))
-				return c.JSON(http.StatusUnauthorized, map[string]string{
-					"error": "认证失败：" + err.Error(),
-				})
+				return echo.NewHTTPError(http.StatusUnauthorized, "认证失败："+err.Error())
 			}
 
 			// 将用户名存入 context
@@ -56,6 +54,18 @@ func JWTMiddleware(secret string, logger *zap.Logger) echo.MiddlewareFunc {
 	}
 }
 
+// isEventStream 判断是否为 SSE 请求
+func isEventStream(c echo.Context) bool {
+	return c.Request().Method == http.MethodGet &&
+		strings.Contains(c.Request().Header.Get(echo.HeaderAccept), "text/event-stream")
+}
+
+// isWebSocket 判断是否为 WebSocket 握手请求
+func isWebSocket(c echo.Context) bool {
+	return c.Request().Method == http.MethodGet &&
+		strings.EqualFold(c.Request().Header.Get(echo.HeaderUpgrade), "websocket")
+}
+
 // GetUsername 从 context 中获取用户名
 func GetUsername(c echo.Context) string {
 	if username, ok := c.Get(ContextKeyUsername).(string); ok {
@@ -0,0 +1,36 @@
+package middleware
+
+import (
+	"github.com/dushixiang/uart_sms_forwarder/internal/service"
+	"github.com/labstack/echo/v4"
+)
+
+// ViewerMiddleware 将当前用户及其是否为管理员存入请求 context，
+// 短信服务据此过滤用户不可见的会话。需在 JWTMiddleware 之后使用。
+func ViewerMiddleware(visibilityService *service.VisibilityService) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			username := GetUsername(c)
+			ctx := service.WithViewer(c.Request().Context(), service.Viewer{
+				Username: username,
+				Admin:    visibilityService.IsAdmin(username),
+			})
+			c.SetRequest(c.Request().WithContext(ctx))
+			return next(c)
+		}
+	}
+}
+
+// AdminMiddleware 只允许管理员访问，非管理员返回 403 admin_required。
+// 配置、备份、维护、规则和定时任务等接口可以绕过会话可见性（如添加转发渠道、上传数据库），需使用该中间件。
+// 需在 ViewerMiddleware 之后使用。
+func AdminMiddleware() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if viewer, ok := service.ViewerFrom(c.Request().Context()); ok && !viewer.Admin {
+				return service.ErrAdminRequired
+			}
+			return next(c)
+		}
+	}
+}
@@ -0,0 +1,70 @@
+package internal
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/dushixiang/uart_sms_forwarder/internal/migration"
+	"github.com/go-orz/orz"
+)
+
+// Migrate 命令行执行数据库迁移，不启动服务：
+//
+//	migrate status          查看各版本的执行状态
+//	migrate up              执行所有未执行的迁移
+//	migrate down <version>  回滚到指定版本（0 表示全部回滚），降级程序前使用
+func Migrate(configPath string, args []string) {
+	framework, err := orz.NewFramework(
+		orz.WithConfig(configPath),
+		withRotatingLogger(configPath),
+		orz.WithDatabase(),
+	)
+	if err != nil {
+		log.Fatal(err)
+	}
+	runner := migration.NewRunner(framework.App().Logger(), framework.GetDB())
+
+	command := "status"
+	if len(args) > 0 {
+		command = args[0]
+	}
+	switch command {
+	case "status":
+		statuses, err := runner.Status()
+		if err != nil {
+			log.Fatal(err)
+		}
+		for _, status := range statuses {
+			applied := "-"
+			if status.AppliedAt > 0 {
+				applied = time.UnixMilli(status.AppliedAt).Format(time.DateTime)
+			}
+			reversible := ""
+			if !status.Reversible {
+				reversible = "（不可回滚）"
+			}
+			fmt.Printf("%04d  %-19s  %s%s\n", status.Version, applied, status.Name, reversible)
+		}
+	case "up":
+		if err := runner.Up(); err != nil {
+			log.Fatal(err)
+		}
+	case "down":
+		if len(args) < 2 {
+			log.Fatal("请指定回滚到的版本号，如 migrate down 2")
+		}
+		target, err := strconv.Atoi(args[1])
+		if err != nil || target < 0 {
+			log.Fatalf("无效的版本号: %s", args[1])
+		}
+		if err := runner.DownTo(target); err != nil {
+			log.Fatal(err)
+		}
+	default:
+		fmt.Fprintf(os.Stderr, "未知的迁移命令: %s，可用命令: status、up、down <version>\n", command)
+		os.Exit(2)
+	}
+}
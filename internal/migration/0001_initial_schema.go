@@ -0,0 +1,44 @@
+package migration
+
+import (
+	"github.com/dushixiang/uart_sms_forwarder/internal/models"
+	"gorm.io/gorm"
+)
+
+// 引入版本化迁移之前的全部表结构。已有安装上 AutoMigrate 只会补齐缺少的列和索引，
+// 不会删除数据，因此已有数据库也从这里开始记录版本
+func init() {
+	register(Migration{
+		Version: 1,
+		Name:    "initial schema",
+		Up: func(tx *gorm.DB) error {
+			// 旧版本没有已读状态，升级时将已有短信视为已读
+			backfillReadAt := tx.Migrator().HasTable(&models.TextMessage{}) &&
+				!tx.Migrator().HasColumn(&models.TextMessage{}, "read_at")
+
+			if err := tx.AutoMigrate(
+				&models.Property{},
+				&models.TextMessage{},
+				&models.ScheduledTask{},
+				&models.Transaction{},
+				&models.SpamToken{},
+				&models.NumberRule{},
+				&models.ConversationSetting{},
+				&models.MessageTemplate{},
+				&models.PushDevice{},
+				&models.PeerAssignment{},
+				&models.DeadLetterFrame{},
+				&models.NumberAlias{},
+				&models.NotificationLog{},
+			); err != nil {
+				return err
+			}
+
+			if backfillReadAt {
+				return tx.Model(&models.TextMessage{}).Where("1 = 1").
+					UpdateColumn("read_at", gorm.Expr("created_at")).Error
+			}
+			return nil
+		},
+	})
+}
@@ -0,0 +1,26 @@
+package migration
+
+import (
+	"github.com/dushixiang/uart_sms_forwarder/internal/models"
+	"gorm.io/gorm"
+)
+
+// type 单列索引已被 (type, from, created_at) 复合索引覆盖
+func init() {
+	register(Migration{
+		Version: 2,
+		Name:    "drop idx_text_messages_type",
+		Up: func(tx *gorm.DB) error {
+			if !tx.Migrator().HasIndex(&models.TextMessage{}, "idx_text_messages_type") {
+				return nil
+			}
+			return tx.Migrator().DropIndex(&models.TextMessage{}, "idx_text_messages_type")
+		},
+		Down: func(tx *gorm.DB) error {
+			if tx.Migrator().HasIndex(&models.TextMessage{}, "idx_text_messages_type") {
+				return nil
+			}
+			return tx.Exec("CREATE INDEX idx_text_messages_type ON text_messages (type)").Error
+		},
+	})
+}
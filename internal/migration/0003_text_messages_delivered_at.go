@@ -0,0 +1,26 @@
+package migration
+
+import (
+	"github.com/dushixiang/uart_sms_forwarder/internal/models"
+	"gorm.io/gorm"
+)
+
+// 发送短信的送达报告时间
+func init() {
+	register(Migration{
+		Version: 3,
+		Name:    "add text_messages.delivered_at",
+		Up: func(tx *gorm.DB) error {
+			if tx.Migrator().HasColumn(&models.TextMessage{}, "delivered_at") {
+				return nil
+			}
+			return tx.Migrator().AddColumn(&models.TextMessage{}, "DeliveredAt")
+		},
+		Down: func(tx *gorm.DB) error {
+			if !tx.Migrator().HasColumn(&models.TextMessage{}, "delivered_at") {
+				return nil
+			}
+			return tx.Migrator().DropColumn(&models.TextMessage{}, "delivered_at")
+		},
+	})
+}
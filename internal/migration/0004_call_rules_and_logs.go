@@ -0,0 +1,20 @@
+package migration
+
+import (
+	"github.com/dushixiang/uart_sms_forwarder/internal/models"
+	"gorm.io/gorm"
+)
+
+// 来电规则和来电记录
+func init() {
+	register(Migration{
+		Version: 4,
+		Name:    "create call_rules and call_logs",
+		Up: func(tx *gorm.DB) error {
+			return tx.AutoMigrate(&models.CallRule{}, &models.CallLog{})
+		},
+		Down: func(tx *gorm.DB) error {
+			return tx.Migrator().DropTable(&models.CallRule{}, &models.CallLog{})
+		},
+	})
+}
@@ -0,0 +1,49 @@
+package migration
+
+import (
+	"github.com/dushixiang/uart_sms_forwarder/internal/models"
+	"gorm.io/gorm"
+)
+
+// textMessageApprovalColumns 发送审批使用的列，字段名 -> 列名
+var textMessageApprovalColumns = [][2]string{
+	{"SubmittedBy", "submitted_by"},
+	{"ReviewedBy", "reviewed_by"},
+	{"ReviewedAt", "reviewed_at"},
+}
+
+// 发送审批的提交者和审批记录
+func init() {
+	register(Migration{
+		Version: 5,
+		Name:    "add text_messages approval columns",
+		Up: func(tx *gorm.DB) error {
+			for _, column := range textMessageApprovalColumns {
+				if tx.Migrator().HasColumn(&models.TextMessage{}, column[1]) {
+					continue
+				}
+				if err := tx.Migrator().AddColumn(&models.TextMessage{}, column[0]); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+		Down: func(tx *gorm.DB) error {
+			// 待审批和已拒绝的短信从未发送，回滚后旧版本无法识别这些状态
+			if err := tx.Model(&models.TextMessage{}).
+				Where("status IN ?", []models.MessageStatus{models.MessageStatusPendingApproval, models.MessageStatusRejected}).
+				UpdateColumn("status", models.MessageStatusFailed).Error; err != nil {
+				return err
+			}
+			for _, column := range textMessageApprovalColumns {
+				if !tx.Migrator().HasColumn(&models.TextMessage{}, column[1]) {
+					continue
+				}
+				if err := tx.Migrator().DropColumn(&models.TextMessage{}, column[1]); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	})
+}
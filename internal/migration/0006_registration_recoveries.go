@@ -0,0 +1,20 @@
+package migration
+
+import (
+	"github.com/dushixiang/uart_sms_forwarder/internal/models"
+	"gorm.io/gorm"
+)
+
+// 网络注册自动恢复记录
+func init() {
+	register(Migration{
+		Version: 6,
+		Name:    "create registration_recoveries",
+		Up: func(tx *gorm.DB) error {
+			return tx.AutoMigrate(&models.RegistrationRecovery{})
+		},
+		Down: func(tx *gorm.DB) error {
+			return tx.Migrator().DropTable(&models.RegistrationRecovery{})
+		},
+	})
+}
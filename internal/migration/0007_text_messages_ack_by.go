@@ -0,0 +1,42 @@
+package migration
+
+import (
+	"github.com/dushixiang/uart_sms_forwarder/internal/models"
+	"gorm.io/gorm"
+)
+
+// textMessageAckColumns 确认记录使用的列，字段名 -> 列名
+var textMessageAckColumns = [][2]string{
+	{"AckedBy", "acked_by"},
+	{"AckNote", "ack_note"},
+}
+
+// 短信的确认人和确认备注
+func init() {
+	register(Migration{
+		Version: 7,
+		Name:    "add text_messages acked_by and ack_note",
+		Up: func(tx *gorm.DB) error {
+			for _, column := range textMessageAckColumns {
+				if tx.Migrator().HasColumn(&models.TextMessage{}, column[1]) {
+					continue
+				}
+				if err := tx.Migrator().AddColumn(&models.TextMessage{}, column[0]); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+		Down: func(tx *gorm.DB) error {
+			for _, column := range textMessageAckColumns {
+				if !tx.Migrator().HasColumn(&models.TextMessage{}, column[1]) {
+					continue
+				}
+				if err := tx.Migrator().DropColumn(&models.TextMessage{}, column[1]); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	})
+}
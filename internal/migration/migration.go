@@ -0,0 +1,185 @@
+package migration
+
+import (
+	"fmt"
+	"slices"
+	"time"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// Migration 一个版本的数据库变更。
+// 0001 按当前模型创建所有表，新安装时会直接得到最新的结构，因此之后的迁移需要先检查列、索引是否已存在。
+type Migration struct {
+	Version int    // 版本号，按顺序递增，不能修改已发布的版本号
+	Name    string // 变更说明
+	Up      func(tx *gorm.DB) error
+	Down    func(tx *gorm.DB) error // 回滚，为 nil 时该版本不可回滚
+}
+
+// SchemaMigration 已执行的迁移记录
+type SchemaMigration struct {
+	Version   int    `gorm:"primaryKey;autoIncrement:false" json:"version"`
+	Name      string `json:"name"`
+	AppliedAt int64  `json:"appliedAt"` // 执行时间（时间戳毫秒）
+}
+
+func (SchemaMigration) TableName() string {
+	return "schema_migrations"
+}
+
+// migrations 按版本号排序的全部迁移，由各迁移文件在 init 中注册
+var migrations []Migration
+
+// register 注册迁移，版本号重复时 panic，避免两个迁移文件使用同一版本号
+func register(m Migration) {
+	for _, existing := range migrations {
+		if existing.Version == m.Version {
+			panic(fmt.Sprintf("迁移版本号重复: %d", m.Version))
+		}
+	}
+	migrations = append(migrations, m)
+	slices.SortFunc(migrations, func(a, b Migration) int {
+		return a.Version - b.Version
+	})
+}
+
+// Status 迁移的执行状态
+type Status struct {
+	Version    int    `json:"version"`
+	Name       string `json:"name"`
+	AppliedAt  int64  `json:"appliedAt"`  // 执行时间（时间戳毫秒），0 表示未执行
+	Reversible bool   `json:"reversible"` // 是否可以回滚
+}
+
+// Runner 按版本顺序执行和回滚迁移
+type Runner struct {
+	logger *zap.Logger
+	db     *gorm.DB
+}
+
+// NewRunner 创建迁移执行器
+func NewRunner(logger *zap.Logger, db *gorm.DB) *Runner {
+	return &Runner{logger: logger, db: db}
+}
+
+// applied 读取已执行的迁移，按版本号索引
+func (r *Runner) applied() (map[int]SchemaMigration, error) {
+	if err := r.db.AutoMigrate(&SchemaMigration{}); err != nil {
+		return nil, fmt.Errorf("创建迁移记录表失败: %w", err)
+	}
+	var records []SchemaMigration
+	if err := r.db.Find(&records).Error; err != nil {
+		return nil, fmt.Errorf("读取迁移记录失败: %w", err)
+	}
+	applied := make(map[int]SchemaMigration, len(records))
+	for _, record := range records {
+		applied[record.Version] = record
+	}
+	return applied, nil
+}
+
+// Up 依次执行所有未执行的迁移，每个迁移和它的执行记录在同一事务中提交。
+// 数据库中存在本程序不认识的版本时返回错误，说明数据库已被更新的版本升级过，需要先用新版本回滚
+func (r *Runner) Up() error {
+	applied, err := r.applied()
+	if err != nil {
+		return err
+	}
+	if latest := Latest(); len(applied) > 0 {
+		for version := range applied {
+			if version > latest {
+				return fmt.Errorf("数据库版本 %d 高于程序支持的版本 %d，请使用新版本程序执行 migrate down %d 后再降级", version, latest, latest)
+			}
+		}
+	}
+
+	for _, m := range migrations {
+		if _, ok := applied[m.Version]; ok {
+			continue
+		}
+		start := time.Now()
+		err := r.db.Transaction(func(tx *gorm.DB) error {
+			if err := m.Up(tx); err != nil {
+				return err
+			}
+			return tx.Create(&SchemaMigration{
+				Version:   m.Version,
+				Name:      m.Name,
+				AppliedAt: time.Now().UnixMilli(),
+			}).Error
+		})
+		if err != nil {
+			return fmt.Errorf("执行迁移 %04d %s 失败: %w", m.Version, m.Name, err)
+		}
+		r.logger.Info("已执行数据库迁移",
+			zap.Int("version", m.Version),
+			zap.String("name", m.Name),
+			zap.Duration("elapsed", time.Since(start)))
+	}
+	return nil
+}
+
+// DownTo 按版本倒序回滚 target 之后已执行的迁移，target 为 0 时回滚全部。
+// 先检查所有待回滚的迁移都可回滚，避免回滚到一半才发现无法继续
+func (r *Runner) DownTo(target int) error {
+	applied, err := r.applied()
+	if err != nil {
+		return err
+	}
+
+	var pending []Migration
+	for _, m := range slices.Backward(migrations) {
+		if m.Version <= target {
+			break
+		}
+		if _, ok := applied[m.Version]; !ok {
+			continue
+		}
+		if m.Down == nil {
+			return fmt.Errorf("迁移 %04d %s 不可回滚", m.Version, m.Name)
+		}
+		pending = append(pending, m)
+	}
+
+	for _, m := range pending {
+		err := r.db.Transaction(func(tx *gorm.DB) error {
+			if err := m.Down(tx); err != nil {
+				return err
+			}
+			return tx.Delete(&SchemaMigration{}, m.Version).Error
+		})
+		if err != nil {
+			return fmt.Errorf("回滚迁移 %04d %s 失败: %w", m.Version, m.Name, err)
+		}
+		r.logger.Info("已回滚数据库迁移", zap.Int("version", m.Version), zap.String("name", m.Name))
+	}
+	return nil
+}
+
+// Status 返回所有迁移的执行状态，按版本号排序
+func (r *Runner) Status() ([]Status, error) {
+	applied, err := r.applied()
+	if err != nil {
+		return nil, err
+	}
+	statuses := make([]Status, 0, len(migrations))
+	for _, m := range migrations {
+		statuses = append(statuses, Status{
+			Version:    m.Version,
+			Name:       m.Name,
+			AppliedAt:  applied[m.Version].AppliedAt,
+			Reversible: m.Down != nil,
+		})
+	}
+	return statuses, nil
+}
+
+// Latest 返回最新的迁移版本号
+func Latest() int {
+	if len(migrations) == 0 {
+		return 0
+	}
+	return migrations[len(migrations)-1].Version
+}
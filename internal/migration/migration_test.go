@@ -0,0 +1,196 @@
+package migration
+
+import (
+	"testing"
+
+	"github.com/dushixiang/uart_sms_forwarder/internal/models"
+	_ "github.com/dushixiang/uart_sms_forwarder/internal/service" // 注册短信内容使用的 msgcrypt 序列化器
+	"github.com/glebarez/sqlite"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// newTestRunner 创建使用内存 SQLite 的迁移执行器
+func newTestRunner(t *testing.T) (*Runner, *gorm.DB) {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatalf("打开数据库失败: %v", err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatalf("获取连接失败: %v", err)
+	}
+	// 内存数据库每个连接相互独立，只使用一个连接
+	sqlDB.SetMaxOpenConns(1)
+	t.Cleanup(func() { _ = sqlDB.Close() })
+	return NewRunner(zap.NewNop(), db), db
+}
+
+// appliedVersions 已执行的迁移版本号
+func appliedVersions(t *testing.T, db *gorm.DB) []int {
+	t.Helper()
+	var versions []int
+	if err := db.Model(&SchemaMigration{}).Order("version").Pluck("version", &versions).Error; err != nil {
+		t.Fatalf("读取迁移记录失败: %v", err)
+	}
+	return versions
+}
+
+func TestUpFreshInstall(t *testing.T) {
+	runner, db := newTestRunner(t)
+	if err := runner.Up(); err != nil {
+		t.Fatalf("执行迁移失败: %v", err)
+	}
+
+	versions := appliedVersions(t, db)
+	if len(versions) != len(migrations) || versions[len(versions)-1] != Latest() {
+		t.Fatalf("已执行版本 = %v，期望 1..%d", versions, Latest())
+	}
+	for _, column := range []string{"read_at", "delivered_at", "submitted_by", "reviewed_at", "acked_by", "ack_note"} {
+		if !db.Migrator().HasColumn(&models.TextMessage{}, column) {
+			t.Errorf("缺少列 text_messages.%s", column)
+		}
+	}
+	if !db.Migrator().HasTable(&models.RegistrationRecovery{}) {
+		t.Error("缺少表 registration_recoveries")
+	}
+
+	// 再次执行不做任何变更
+	if err := runner.Up(); err != nil {
+		t.Fatalf("重复执行迁移失败: %v", err)
+	}
+	if got := appliedVersions(t, db); len(got) != len(versions) {
+		t.Errorf("重复执行后版本 = %v，期望 %v", got, versions)
+	}
+}
+
+func TestUpFromBaseline(t *testing.T) {
+	runner, db := newTestRunner(t)
+
+	// 引入版本化迁移之前的表结构：没有 read_at 等后来增加的列，也没有迁移记录
+	if err := db.Exec(`CREATE TABLE text_messages (
+		id TEXT PRIMARY KEY,
+		"from" TEXT,
+		"to" TEXT,
+		content TEXT,
+		type TEXT,
+		status TEXT,
+		created_at INTEGER,
+		updated_at INTEGER
+	)`).Error; err != nil {
+		t.Fatalf("创建旧表失败: %v", err)
+	}
+	if err := db.Exec(`INSERT INTO text_messages (id, "from", "to", content, type, status, created_at, updated_at)
+		VALUES ('m1', '10086', '', '余额', 'incoming', 'received', 1700000000000, 1700000000000),
+		       ('m2', '', '10086', 'CXYE', 'outgoing', 'sent', 1700000001000, 1700000001000)`).Error; err != nil {
+		t.Fatalf("写入旧数据失败: %v", err)
+	}
+
+	if err := runner.Up(); err != nil {
+		t.Fatalf("执行迁移失败: %v", err)
+	}
+
+	// 升级前的短信视为已读，已读时间为创建时间
+	var messages []models.TextMessage
+	if err := db.Order("id").Find(&messages).Error; err != nil {
+		t.Fatalf("读取短信失败: %v", err)
+	}
+	if len(messages) != 2 {
+		t.Fatalf("短信数 = %d，期望 2", len(messages))
+	}
+	for _, msg := range messages {
+		if msg.ReadAt != msg.CreatedAt {
+			t.Errorf("短信 %s read_at = %d，期望 %d", msg.ID, msg.ReadAt, msg.CreatedAt)
+		}
+	}
+	if versions := appliedVersions(t, db); len(versions) != len(migrations) {
+		t.Errorf("已执行版本 = %v", versions)
+	}
+}
+
+func TestUpRefusesNewerDatabase(t *testing.T) {
+	runner, db := newTestRunner(t)
+	if err := runner.Up(); err != nil {
+		t.Fatalf("执行迁移失败: %v", err)
+	}
+	newer := SchemaMigration{Version: Latest() + 1, Name: "from a newer release", AppliedAt: 1}
+	if err := db.Create(&newer).Error; err != nil {
+		t.Fatalf("写入迁移记录失败: %v", err)
+	}
+
+	if err := runner.Up(); err == nil {
+		t.Fatal("数据库版本高于程序时应返回错误")
+	}
+}
+
+func TestDownTo(t *testing.T) {
+	runner, db := newTestRunner(t)
+	if err := runner.Up(); err != nil {
+		t.Fatalf("执行迁移失败: %v", err)
+	}
+	messages := []models.TextMessage{
+		{ID: "pending", To: "10086", Type: models.MessageTypeOutgoing, Status: models.MessageStatusPendingApproval, SubmittedBy: "alice"},
+		{ID: "rejected", To: "10086", Type: models.MessageTypeOutgoing, Status: models.MessageStatusRejected, ReviewedBy: "admin"},
+		{ID: "sent", To: "10086", Type: models.MessageTypeOutgoing, Status: models.MessageStatusSent},
+	}
+	if err := db.Create(&messages).Error; err != nil {
+		t.Fatalf("写入短信失败: %v", err)
+	}
+
+	if err := runner.DownTo(4); err != nil {
+		t.Fatalf("回滚失败: %v", err)
+	}
+
+	if versions := appliedVersions(t, db); versions[len(versions)-1] != 4 {
+		t.Errorf("回滚后版本 = %v，期望最高为 4", versions)
+	}
+	for _, column := range []string{"submitted_by", "reviewed_by", "reviewed_at", "acked_by", "ack_note"} {
+		if db.Migrator().HasColumn(&models.TextMessage{}, column) {
+			t.Errorf("回滚后仍有列 text_messages.%s", column)
+		}
+	}
+	if db.Migrator().HasTable("registration_recoveries") {
+		t.Error("回滚后仍有表 registration_recoveries")
+	}
+
+	// 待审批和已拒绝的短信改为发送失败，其他状态不变
+	want := map[string]models.MessageStatus{
+		"pending":  models.MessageStatusFailed,
+		"rejected": models.MessageStatusFailed,
+		"sent":     models.MessageStatusSent,
+	}
+	for id, status := range want {
+		var got string
+		if err := db.Table("text_messages").Where("id = ?", id).Pluck("status", &got).Error; err != nil {
+			t.Fatalf("读取短信 %s 失败: %v", id, err)
+		}
+		if models.MessageStatus(got) != status {
+			t.Errorf("短信 %s 状态 = %s，期望 %s", id, got, status)
+		}
+	}
+
+	// 可以重新升级到最新版本
+	if err := runner.Up(); err != nil {
+		t.Fatalf("重新执行迁移失败: %v", err)
+	}
+	if !db.Migrator().HasColumn(&models.TextMessage{}, "submitted_by") {
+		t.Error("重新升级后缺少列 text_messages.submitted_by")
+	}
+}
+
+func TestDownToIrreversible(t *testing.T) {
+	runner, db := newTestRunner(t)
+	if err := runner.Up(); err != nil {
+		t.Fatalf("执行迁移失败: %v", err)
+	}
+
+	// 0001 不可回滚，应在回滚任何版本之前返回错误
+	if err := runner.DownTo(0); err == nil {
+		t.Fatal("包含不可回滚的迁移时应返回错误")
+	}
+	if versions := appliedVersions(t, db); len(versions) != len(migrations) {
+		t.Errorf("检查失败后不应回滚任何版本，已执行版本 = %v", versions)
+	}
+}
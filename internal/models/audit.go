@@ -0,0 +1,7 @@
+package models
+
+// AuditSigningKey 审计导出签名密钥对（Ed25519，base64 编码），首次导出时生成
+type AuditSigningKey struct {
+	PublicKey  string `json:"publicKey"`  // 公钥，提供给校验方
+	PrivateKey string `json:"privateKey"` // 私钥
+}
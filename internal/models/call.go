@@ -0,0 +1,55 @@
+package models
+
+// CallAction 来电处理方式
+type CallAction string
+
+const (
+	CallActionNotify CallAction = "notify" // 发送来电通知（默认）
+	CallActionIgnore CallAction = "ignore" // 只记录，不通知也不挂断
+	CallActionHangup CallAction = "hangup" // 自动挂断，只记录不通知
+)
+
+// Valid 是否为有效的来电处理方式
+func (a CallAction) Valid() bool {
+	switch a {
+	case CallActionNotify, CallActionIgnore, CallActionHangup:
+		return true
+	}
+	return false
+}
+
+// CallRule 来电处理规则，按来电号码匹配，白名单使用 notify，黑名单使用 hangup 或 ignore
+type CallRule struct {
+	ID        string     `gorm:"primaryKey" json:"id"`                  // UUID
+	Pattern   string     `json:"pattern"`                               // 号码模式，支持 * 和 ? 通配符，如 400*、+1800*、95588
+	Action    CallAction `json:"action"`                                // 处理方式: notify, ignore, hangup
+	Enabled   bool       `json:"enabled"`                               // 是否启用
+	Remark    string     `json:"remark"`                                // 备注，如 骚扰电话
+	CreatedAt int64      `json:"createdAt" gorm:"autoCreateTime:milli"` // 创建时间（时间戳毫秒）
+	UpdatedAt int64      `json:"updatedAt" gorm:"autoUpdateTime:milli"` // 更新时间（时间戳毫秒）
+}
+
+func (CallRule) TableName() string {
+	return "call_rules"
+}
+
+// CallLog 来电记录
+type CallLog struct {
+	ID        string     `gorm:"primaryKey" json:"id"`                        // UUID
+	From      string     `gorm:"index" json:"from"`                           // 来电号码
+	DeviceID  string     `gorm:"index" json:"deviceId"`                       // 接听的设备 ID，其他来源推送的来电为空
+	Source    string     `json:"source,omitempty"`                            // 其他设备推送的来电来源，如 smsforwarder，本机来电为空
+	Action    CallAction `gorm:"index" json:"action"`                         // 实际的处理方式
+	RuleID    string     `json:"ruleId,omitempty"`                            // 匹配的来电规则 ID，使用默认处理方式时为空
+	EndedAt   int64      `json:"endedAt"`                                     // 通话结束时间（时间戳毫秒），0 表示未收到结束事件
+	CreatedAt int64      `json:"createdAt" gorm:"autoCreateTime:milli;index"` // 来电时间（时间戳毫秒）
+}
+
+func (CallLog) TableName() string {
+	return "call_logs"
+}
+
+// CallPolicyConfig 来电处理配置（存储在 Property 中）
+type CallPolicyConfig struct {
+	DefaultAction CallAction `json:"defaultAction"` // 未匹配任何规则时的处理方式，为空时为 notify；设为 hangup 即只接受白名单来电
+}
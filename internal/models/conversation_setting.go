@@ -0,0 +1,15 @@
+package models
+
+// ConversationSetting 会话设置，覆盖号码分类规则对该号码的通知路由
+type ConversationSetting struct {
+	Peer      string               `gorm:"primaryKey" json:"peer"`                // 对方号码（去掉 +86 等格式）
+	Mute      bool                 `json:"mute"`                                  // 是否静默：保存但不发送通知
+	Channels  []string             `gorm:"serializer:json" json:"channels"`       // 只通知指定类型的渠道或 "@组名" 引用的渠道组，为空时发送到所有启用的渠道
+	Priority  NotificationPriority `json:"priority"`                              // 通知优先级: low, normal, high, critical，为空时使用号码分类规则的优先级
+	CreatedAt int64                `json:"createdAt" gorm:"autoCreateTime:milli"` // 创建时间（时间戳毫秒）
+	UpdatedAt int64                `json:"updatedAt" gorm:"autoUpdateTime:milli"` // 更新时间（时间戳毫秒）
+}
+
+func (ConversationSetting) TableName() string {
+	return "conversation_settings"
+}
@@ -0,0 +1,15 @@
+package models
+
+// DeadLetterFrame 无法解析的串口帧，保留原始数据，修复解析后可重新处理
+type DeadLetterFrame struct {
+	ID            string `gorm:"primaryKey" json:"id"`                        // UUID
+	Data          string `gorm:"type:text" json:"data"`                       // 原始帧数据
+	Error         string `json:"error"`                                       // 最近一次解析失败的原因
+	Attempts      int    `json:"attempts"`                                    // 重新处理次数
+	ReprocessedAt int64  `gorm:"index" json:"reprocessedAt"`                  // 重新处理成功的时间（时间戳毫秒），为 0 时尚未处理
+	CreatedAt     int64  `json:"createdAt" gorm:"autoCreateTime:milli;index"` // 收到时间（时间戳毫秒）
+}
+
+func (DeadLetterFrame) TableName() string {
+	return "dead_letter_frames"
+}
@@ -0,0 +1,15 @@
+package models
+
+// MessageTemplate 短信模板，内容中可使用 {{变量名}} 占位，发送时替换
+type MessageTemplate struct {
+	ID        string   `gorm:"primaryKey" json:"id"`                  // UUID
+	Name      string   `json:"name"`                                  // 模板名称
+	Content   string   `gorm:"type:text" json:"content"`              // 模板内容，如 "电表读数：{{reading}}，抄表日期：{{date}}"
+	Variables []string `gorm:"-" json:"variables"`                    // 内容中的变量名，不含内置变量
+	CreatedAt int64    `json:"createdAt" gorm:"autoCreateTime:milli"` // 创建时间（时间戳毫秒）
+	UpdatedAt int64    `json:"updatedAt" gorm:"autoUpdateTime:milli"` // 更新时间（时间戳毫秒）
+}
+
+func (MessageTemplate) TableName() string {
+	return "message_templates"
+}
@@ -0,0 +1,25 @@
+package models
+
+type NotificationLogStatus string
+
+const (
+	NotificationLogSuccess NotificationLogStatus = "success"
+	NotificationLogFailed  NotificationLogStatus = "failed"
+)
+
+// NotificationLog 通知发送记录，每次发送尝试（含重试）记录一条
+type NotificationLog struct {
+	ID        string                `gorm:"primaryKey" json:"id"`                        // UUID
+	Channel   string                `gorm:"index" json:"channel"`                        // 渠道类型，如 feishu
+	MessageID string                `gorm:"index" json:"messageId"`                      // 短信记录 ID，系统通知为空
+	Event     string                `json:"event"`                                       // 事件类型，如 sms、device-offline
+	Attempt   int                   `json:"attempt"`                                     // 第几次尝试，从 1 开始
+	Status    NotificationLogStatus `gorm:"index" json:"status"`                         // 发送结果: success, failed
+	LatencyMs int64                 `json:"latencyMs"`                                   // 本次尝试耗时（毫秒）
+	Error     string                `gorm:"type:text" json:"error,omitempty"`            // 失败原因
+	CreatedAt int64                 `json:"createdAt" gorm:"autoCreateTime:milli;index"` // 发送时间（时间戳毫秒）
+}
+
+func (NotificationLog) TableName() string {
+	return "notification_logs"
+}
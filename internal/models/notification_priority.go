@@ -0,0 +1,22 @@
+package models
+
+// NotificationPriority 通知优先级，由各渠道映射为自身的紧急程度（如 Bark 的 critical 级别、Telegram 的静默消息）
+type NotificationPriority string
+
+const (
+	NotificationPriorityDefault  NotificationPriority = ""         // 未设置，按渠道配置发送
+	NotificationPriorityLow      NotificationPriority = "low"      // 静默送达，不响铃（如营销短信）
+	NotificationPriorityNormal   NotificationPriority = "normal"   // 普通通知
+	NotificationPriorityHigh     NotificationPriority = "high"     // 重要通知，可突破专注模式
+	NotificationPriorityCritical NotificationPriority = "critical" // 紧急通知，静音和勿扰时也响铃（如银行验证码）
+)
+
+// Valid 是否为有效的优先级
+func (p NotificationPriority) Valid() bool {
+	switch p {
+	case NotificationPriorityDefault, NotificationPriorityLow, NotificationPriorityNormal,
+		NotificationPriorityHigh, NotificationPriorityCritical:
+		return true
+	}
+	return false
+}
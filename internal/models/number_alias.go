@@ -0,0 +1,12 @@
+package models
+
+// NumberAlias 号码别名，别名号码的短信合并到主号码的会话中显示
+type NumberAlias struct {
+	Alias     string `gorm:"primaryKey" json:"alias"`               // 别名号码
+	Peer      string `gorm:"index" json:"peer"`                     // 主号码
+	CreatedAt int64  `json:"createdAt" gorm:"autoCreateTime:milli"` // 创建时间（时间戳毫秒）
+}
+
+func (NumberAlias) TableName() string {
+	return "number_aliases"
+}
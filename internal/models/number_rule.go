@@ -0,0 +1,20 @@
+package models
+
+// NumberRule 号码分类规则，按发送方号码前缀或短号归类，用于通知路由和列表筛选
+type NumberRule struct {
+	ID            string               `gorm:"primaryKey" json:"id"`                  // UUID
+	Pattern       string               `json:"pattern"`                               // 号码模式，支持 * 和 ? 通配符，如 106*、+1800*、95588
+	Category      string               `gorm:"index" json:"category"`                 // 分类名称，如 营销、银行、快递
+	Enabled       bool                 `json:"enabled"`                               // 是否启用
+	Mute          bool                 `json:"mute"`                                  // 是否静默：保存但不发送通知
+	Channels      []string             `gorm:"serializer:json" json:"channels"`       // 只通知指定类型的渠道或 "@组名" 引用的渠道组，为空时发送到所有启用的渠道
+	Escalation    []string             `gorm:"serializer:json" json:"escalation"`     // 升级通知链：通知后未确认时依次改发的渠道类型或渠道组，为空表示不升级
+	EscalateAfter int                  `json:"escalateAfter"`                         // 未确认多少分钟后升级到下一个渠道，默认 10
+	Priority      NotificationPriority `json:"priority"`                              // 通知优先级: low, normal, high, critical，为空时按渠道配置发送
+	CreatedAt     int64                `json:"createdAt" gorm:"autoCreateTime:milli"` // 创建时间（时间戳毫秒）
+	UpdatedAt     int64                `json:"updatedAt" gorm:"autoUpdateTime:milli"` // 更新时间（时间戳毫秒）
+}
+
+func (NumberRule) TableName() string {
+	return "number_rules"
+}
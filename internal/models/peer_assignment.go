@@ -0,0 +1,13 @@
+package models
+
+// PeerAssignment 会话可见性分配，分配后该号码的会话只有指定用户和管理员可见
+type PeerAssignment struct {
+	Peer      string   `gorm:"primaryKey" json:"peer"`                // 对方号码
+	Users     []string `gorm:"serializer:json" json:"users"`          // 可查看该会话的用户
+	CreatedAt int64    `json:"createdAt" gorm:"autoCreateTime:milli"` // 创建时间（时间戳毫秒）
+	UpdatedAt int64    `json:"updatedAt" gorm:"autoUpdateTime:milli"` // 更新时间（时间戳毫秒）
+}
+
+func (PeerAssignment) TableName() string {
+	return "peer_assignments"
+}
@@ -0,0 +1,25 @@
+package models
+
+// PushDevice 推送设备（伴侣 App 注册的 FCM token、浏览器的 Web Push 订阅）
+type PushDevice struct {
+	ID        string `gorm:"primaryKey" json:"id"`                  // UUID
+	Kind      string `gorm:"index" json:"kind"`                     // 推送方式：fcm、webpush
+	Token     string `gorm:"uniqueIndex;size:512" json:"token"`     // FCM 注册 token，Web Push 为订阅 endpoint
+	P256dh    string `json:"-"`                                     // Web Push 订阅的浏览器公钥（base64url）
+	Auth      string `json:"-"`                                     // Web Push 订阅的认证密钥（base64url）
+	Platform  string `json:"platform"`                              // 设备平台：android、ios、browser
+	Name      string `json:"name"`                                  // 设备名称
+	Username  string `json:"username"`                              // 注册设备的用户
+	CreatedAt int64  `json:"createdAt" gorm:"autoCreateTime:milli"` // 创建时间（时间戳毫秒）
+	UpdatedAt int64  `json:"updatedAt" gorm:"autoUpdateTime:milli"` // 更新时间（时间戳毫秒），每次重新注册时刷新
+}
+
+func (PushDevice) TableName() string {
+	return "push_devices"
+}
+
+// VAPIDKeys Web Push 服务器密钥对（P-256，base64url 编码），首次使用时生成
+type VAPIDKeys struct {
+	PublicKey  string `json:"publicKey"`  // 未压缩格式公钥，浏览器订阅时作为 applicationServerKey
+	PrivateKey string `json:"privateKey"` // 私钥标量
+}
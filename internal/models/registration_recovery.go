@@ -0,0 +1,31 @@
+package models
+
+// RegistrationRecoveryResult 网络注册恢复结果
+type RegistrationRecoveryResult string
+
+const (
+	RegistrationRecoveryRunning   RegistrationRecoveryResult = "running"   // 正在恢复
+	RegistrationRecoveryRecovered RegistrationRecoveryResult = "recovered" // 已恢复注册
+	RegistrationRecoveryFailed    RegistrationRecoveryResult = "failed"    // 所有恢复操作执行后仍未注册
+	RegistrationRecoveryCancelled RegistrationRecoveryResult = "cancelled" // 恢复期间开启了飞行模式或 SIM 卡被移除，已停止
+)
+
+// RegistrationRecovery SIM 卡就绪但未注册网络时的一次自动恢复记录
+type RegistrationRecovery struct {
+	ID                string                     `gorm:"primaryKey" json:"id"`                        // UUID
+	DeviceID          string                     `gorm:"index" json:"deviceId"`                       // 设备 ID
+	Iccid             string                     `json:"iccid"`                                       // SIM 卡 ICCID
+	Operator          string                     `json:"operator"`                                    // 运营商
+	UnregisteredSince int64                      `json:"unregisteredSince"`                           // 开始未注册的时间（时间戳毫秒）
+	Steps             string                     `json:"steps"`                                       // 已执行的恢复操作，逗号分隔，如 reset_stack,flymode
+	RecoveredBy       string                     `json:"recoveredBy"`                                 // 恢复注册前最后执行的操作，恢复后为空表示自行恢复
+	Result            RegistrationRecoveryResult `gorm:"index" json:"result"`                         // 结果: running, recovered, failed, cancelled
+	LastError         string                     `json:"lastError,omitempty"`                         // 最近一次执行失败的恢复操作的错误
+	EndedAt           int64                      `json:"endedAt"`                                     // 恢复注册或停止的时间（时间戳毫秒），0 表示未结束
+	CreatedAt         int64                      `json:"createdAt" gorm:"autoCreateTime:milli;index"` // 开始恢复的时间（时间戳毫秒）
+	UpdatedAt         int64                      `json:"updatedAt" gorm:"autoUpdateTime:milli"`       // 更新时间（时间戳毫秒）
+}
+
+func (RegistrationRecovery) TableName() string {
+	return "registration_recoveries"
+}
@@ -8,16 +8,29 @@ const (
 	LastRunStatusFailed  LastRunStatus = "failed"
 )
 
+// MissedRunPolicy 服务停止期间错过执行时间的处理方式
+type MissedRunPolicy string
+
+const (
+	MissedRunOnce      MissedRunPolicy = "once"      // 在下一次每日检查（按 Cron 表达式执行的任务为下一次计划执行）时补执行一次（默认）
+	MissedRunImmediate MissedRunPolicy = "immediate" // 服务启动后设备连接时立即补执行
+	MissedRunSkip      MissedRunPolicy = "skip"      // 跳过错过的执行，从启动时起重新计算间隔
+)
+
 // ScheduledTask 定时任务
 type ScheduledTask struct {
-	ID           string `gorm:"primaryKey" json:"id"`                  // UUID
-	Name         string `json:"name"`                                  // 任务名称
-	Enabled      bool   `json:"enabled"`                               // 是否启用
-	IntervalDays int    `json:"intervalDays"`                          // 执行间隔天数，例如 90 表示每90天执行一次
-	PhoneNumber  string `json:"phoneNumber"`                           // 目标手机号
-	Content      string `gorm:"type:text" json:"content"`              // 短信内容
-	CreatedAt    int64  `json:"createdAt" gorm:"autoCreateTime:milli"` // 创建时间（时间戳毫秒）
-	UpdatedAt    int64  `json:"updatedAt" gorm:"autoUpdateTime:milli"` // 更新时间（时间戳毫秒）
+	ID           string          `gorm:"primaryKey" json:"id"`                  // UUID
+	Name         string          `json:"name"`                                  // 任务名称
+	Enabled      bool            `json:"enabled"`                               // 是否启用
+	IntervalDays int             `json:"intervalDays"`                          // 执行间隔天数，例如 90 表示每90天执行一次
+	CronSpec     string          `json:"cronSpec"`                              // Cron 表达式（分 时 日 月 周），如 "0 10 1 * *" 表示每月 1 日 10 点，设置后按表达式执行并忽略 IntervalDays
+	PhoneNumber  string          `json:"phoneNumber"`                           // 目标手机号
+	DeviceID     string          `json:"deviceId"`                              // 发送短信的设备 ID，为空时使用主设备，多张 SIM 卡保号时每张卡各建一个任务
+	Content      string          `gorm:"type:text" json:"content"`              // 短信内容
+	MissedRun    MissedRunPolicy `json:"missedRun"`                             // 错过执行时间的处理方式: once, immediate, skip，为空时按 once 处理
+	Channels     []string        `gorm:"serializer:json" json:"channels"`       // 执行失败通知的渠道类型或 "@组名" 引用的渠道组，为空时发送到所有接收 task-failure 的渠道
+	CreatedAt    int64           `json:"createdAt" gorm:"autoCreateTime:milli"` // 创建时间（时间戳毫秒）
+	UpdatedAt    int64           `json:"updatedAt" gorm:"autoUpdateTime:milli"` // 更新时间（时间戳毫秒）
 
 	LastMsgId     string        `json:"lastMsgId"`     // 上次发送的短信ID
 	LastRunAt     int64         `json:"lastRunAt"`     // 上次执行时间（时间戳毫秒）
@@ -0,0 +1,12 @@
+package models
+
+// SpamToken 垃圾短信贝叶斯分类的词频统计
+type SpamToken struct {
+	Token string `gorm:"primaryKey;size:64" json:"token"` // 分词结果
+	Spam  int64  `json:"spam"`                            // 在训练为垃圾短信的短信中出现的次数
+	Ham   int64  `json:"ham"`                             // 在训练为正常短信的短信中出现的次数
+}
+
+func (SpamToken) TableName() string {
+	return "spam_tokens"
+}
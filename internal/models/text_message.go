@@ -14,18 +14,56 @@ const (
 	MessageStatusSending  MessageStatus = "sending"  // 发送中
 	MessageStatusSent     MessageStatus = "sent"     // 发送成功
 	MessageStatusFailed   MessageStatus = "failed"   // 发送失败
+
+	MessageStatusDelivered      MessageStatus = "delivered"       // 运营商送达报告确认已送达
+	MessageStatusDeliveryFailed MessageStatus = "delivery_failed" // 运营商送达报告未能送达
+
+	MessageStatusPendingApproval MessageStatus = "pending_approval" // 等待管理员审批后发送
+	MessageStatusRejected        MessageStatus = "rejected"         // 管理员拒绝发送
 )
 
+// DeliveryReported 是否已收到送达报告，之后的发送结果和对账不再覆盖该状态
+func (s MessageStatus) DeliveryReported() bool {
+	return s == MessageStatusDelivered || s == MessageStatusDeliveryFailed
+}
+
 // TextMessage 短信记录
+// 索引说明：
+//   - (type, from, created_at) / (type, to, created_at)：会话查询
+//   - (created_at, id)：键集分页、今日统计
+//   - status：按状态筛选
+//   - spam：区分收件箱和垃圾箱
+//   - category：按号码分类筛选
+//   - device_id：按设备筛选
 type TextMessage struct {
-	ID        string        `gorm:"primaryKey" json:"id"`                  // UUID
-	From      string        `gorm:"index" json:"from"`                     // 发送方号码
-	To        string        `gorm:"index" json:"to"`                       // 接收方号码
-	Content   string        `gorm:"type:text" json:"content"`              // 短信内容
-	Type      MessageType   `gorm:"index" json:"type"`                     // 消息类型：incoming（收到）、outgoing（发送）
-	Status    MessageStatus `gorm:"index" json:"status"`                   // 状态：received、sent、failed
-	CreatedAt int64         `json:"createdAt" gorm:"autoCreateTime:milli"` // 创建时间
-	UpdatedAt int64         `json:"updatedAt" gorm:"autoUpdateTime:milli"` // 更新时间
+	ID             string            `gorm:"primaryKey;index:idx_text_messages_created_id,priority:2" json:"id"`                                                                                                          // UUID
+	From           string            `gorm:"index;index:idx_text_messages_type_from,priority:2" json:"from"`                                                                                                              // 发送方号码
+	To             string            `gorm:"index;index:idx_text_messages_type_to,priority:2" json:"to"`                                                                                                                  // 接收方号码
+	Content        string            `gorm:"type:text;serializer:msgcrypt" json:"content"`                                                                                                                                // 短信内容，启用 App.EncryptMessages 后加密存储
+	Type           MessageType       `gorm:"index:idx_text_messages_type_from,priority:1;index:idx_text_messages_type_to,priority:1" json:"type"`                                                                         // 消息类型：incoming（收到）、outgoing（发送）
+	Status         MessageStatus     `gorm:"index" json:"status"`                                                                                                                                                         // 状态：received、sending、sent、failed、delivered、delivery_failed、pending_approval、rejected
+	ReadAt         int64             `json:"readAt"`                                                                                                                                                                      // 已读时间（时间戳毫秒），0 表示未读
+	AckedAt        int64             `json:"ackedAt"`                                                                                                                                                                     // 确认处理时间（时间戳毫秒），0 表示未确认，确认后停止升级通知
+	AckedBy        string            `json:"ackedBy"`                                                                                                                                                                     // 确认的用户名，通过 SMSEagle 等接口确认时为接口名称
+	AckNote        string            `json:"ackNote"`                                                                                                                                                                     // 确认时的备注或回应，如 已转账、👍
+	PinnedAt       int64             `json:"pinnedAt"`                                                                                                                                                                    // 在会话中置顶的时间（时间戳毫秒），0 表示未置顶
+	Tags           []string          `gorm:"serializer:json" json:"tags"`                                                                                                                                                 // 标签
+	Fields         map[string]string `gorm:"serializer:json" json:"fields"`                                                                                                                                               // 规则提取的结构化字段
+	Spam           bool              `gorm:"not null;default:false;index" json:"spam"`                                                                                                                                    // 是否为垃圾短信
+	SpamScore      float64           `json:"spamScore"`                                                                                                                                                                   // 垃圾短信评分（0-1）
+	SpamLabel      string            `json:"spamLabel"`                                                                                                                                                                   // 人工训练的标签：spam、ham，为空表示未训练
+	Category       string            `gorm:"index" json:"category"`                                                                                                                                                       // 号码分类规则匹配的分类
+	Source         string            `json:"source"`                                                                                                                                                                      // 来源，为空表示本机收到，外部设备推送时如 smsforwarder:设备名
+	DeviceID       string            `gorm:"index" json:"deviceId"`                                                                                                                                                       // 收发短信的设备 ID，为空表示外部推送或多设备支持之前的记录
+	IdempotencyKey string            `gorm:"index" json:"-"`                                                                                                                                                              // 发送请求的幂等键（Idempotency-Key 请求头）
+	FailureCode    string            `json:"failureCode"`                                                                                                                                                                 // 发送失败时模组返回的错误码，如 CMS 21
+	FailureReason  string            `json:"failureReason"`                                                                                                                                                               // 发送失败原因
+	DeliveredAt    int64             `json:"deliveredAt"`                                                                                                                                                                 // 送达报告的时间（时间戳毫秒），0 表示未收到送达报告
+	SubmittedBy    string            `json:"submittedBy"`                                                                                                                                                                 // 需要审批的发送请求的提交者，如用户名或 smseagle:access_token
+	ReviewedBy     string            `json:"reviewedBy"`                                                                                                                                                                  // 审批的管理员
+	ReviewedAt     int64             `json:"reviewedAt"`                                                                                                                                                                  // 审批时间（时间戳毫秒），0 表示未审批
+	CreatedAt      int64             `json:"createdAt" gorm:"autoCreateTime:milli;index:idx_text_messages_created_id,priority:1;index:idx_text_messages_type_from,priority:3;index:idx_text_messages_type_to,priority:3"` // 创建时间
+	UpdatedAt      int64             `json:"updatedAt" gorm:"autoUpdateTime:milli"`                                                                                                                                       // 更新时间
 }
 
 // TableName 指定表名
@@ -0,0 +1,26 @@
+package models
+
+type TransactionDirection string
+
+const (
+	TransactionExpense TransactionDirection = "expense" // 支出
+	TransactionIncome  TransactionDirection = "income"  // 收入
+)
+
+// Transaction 从银行通知短信解析出的交易记录
+type Transaction struct {
+	ID         string               `gorm:"primaryKey" json:"id"`                              // UUID
+	MessageID  string               `gorm:"uniqueIndex" json:"messageId"`                      // 来源短信ID
+	Bank       string               `json:"bank"`                                              // 银行名称
+	Account    string               `json:"account"`                                           // 卡号尾号
+	Direction  TransactionDirection `gorm:"index:idx_transactions_direction" json:"direction"` // expense（支出）、income（收入）
+	Amount     int64                `json:"amount"`                                            // 金额（分）
+	Balance    *int64               `json:"balance"`                                           // 余额（分），短信未包含时为空
+	Merchant   string               `gorm:"index" json:"merchant"`                             // 商户或交易对方
+	OccurredAt int64                `gorm:"index" json:"occurredAt"`                           // 交易时间（时间戳毫秒），取短信接收时间
+	CreatedAt  int64                `json:"createdAt" gorm:"autoCreateTime:milli"`             // 创建时间
+}
+
+func (Transaction) TableName() string {
+	return "transactions"
+}
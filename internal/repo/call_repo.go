@@ -0,0 +1,72 @@
+package repo
+
+import (
+	"context"
+
+	"github.com/dushixiang/uart_sms_forwarder/internal/models"
+	"github.com/go-orz/orz"
+	"gorm.io/gorm"
+)
+
+type CallRuleRepo struct {
+	orz.Repository[models.CallRule, string]
+	db *gorm.DB
+}
+
+func NewCallRuleRepo(db *gorm.DB) *CallRuleRepo {
+	return &CallRuleRepo{
+		Repository: orz.NewRepository[models.CallRule, string](db),
+		db:         db,
+	}
+}
+
+// FindAll 查询所有规则，按创建时间排序
+func (r *CallRuleRepo) FindAll(ctx context.Context) ([]models.CallRule, error) {
+	var rules []models.CallRule
+	err := r.GetDB(ctx).Order("created_at").Find(&rules).Error
+	return rules, err
+}
+
+// FindAllEnabled 查询所有启用的规则
+func (r *CallRuleRepo) FindAllEnabled(ctx context.Context) ([]models.CallRule, error) {
+	var rules []models.CallRule
+	err := r.GetDB(ctx).Where("enabled = ?", true).Order("created_at").Find(&rules).Error
+	return rules, err
+}
+
+type CallLogRepo struct {
+	orz.Repository[models.CallLog, string]
+	db *gorm.DB
+}
+
+func NewCallLogRepo(db *gorm.DB) *CallLogRepo {
+	return &CallLogRepo{
+		Repository: orz.NewRepository[models.CallLog, string](db),
+		db:         db,
+	}
+}
+
+// FindBefore 按时间倒序查询游标之前的记录，cursor 为空时从最新开始
+func (r *CallLogRepo) FindBefore(ctx context.Context, scope func(db *gorm.DB) *gorm.DB, cursor *MessageCursor, limit int) ([]models.CallLog, error) {
+	db := r.GetDB(ctx).Model(&models.CallLog{}).Scopes(scope)
+	if cursor != nil {
+		db = db.Where("(created_at < ? OR (created_at = ? AND id < ?))", cursor.CreatedAt, cursor.CreatedAt, cursor.ID)
+	}
+
+	var logs []models.CallLog
+	err := db.Order("created_at DESC").Order("id DESC").Limit(limit).Find(&logs).Error
+	return logs, err
+}
+
+// EndLatest 将设备最近一条未结束的来电标记为已结束
+func (r *CallLogRepo) EndLatest(ctx context.Context, deviceID string, endedAt int64) error {
+	var log models.CallLog
+	err := r.GetDB(ctx).
+		Where("device_id = ? AND ended_at = 0", deviceID).
+		Order("created_at DESC").
+		Take(&log).Error
+	if err != nil {
+		return err
+	}
+	return r.GetDB(ctx).Model(&log).UpdateColumn("ended_at", endedAt).Error
+}
@@ -0,0 +1,28 @@
+package repo
+
+import (
+	"context"
+
+	"github.com/dushixiang/uart_sms_forwarder/internal/models"
+	"github.com/go-orz/orz"
+	"gorm.io/gorm"
+)
+
+type ConversationSettingRepo struct {
+	orz.Repository[models.ConversationSetting, string]
+	db *gorm.DB
+}
+
+func NewConversationSettingRepo(db *gorm.DB) *ConversationSettingRepo {
+	return &ConversationSettingRepo{
+		Repository: orz.NewRepository[models.ConversationSetting, string](db),
+		db:         db,
+	}
+}
+
+// FindAll 查询所有会话设置，按号码排序
+func (r *ConversationSettingRepo) FindAll(ctx context.Context) ([]models.ConversationSetting, error) {
+	var settings []models.ConversationSetting
+	err := r.GetDB(ctx).Order("peer").Find(&settings).Error
+	return settings, err
+}
@@ -0,0 +1,46 @@
+package repo
+
+import (
+	"context"
+
+	"github.com/dushixiang/uart_sms_forwarder/internal/models"
+	"github.com/go-orz/orz"
+	"gorm.io/gorm"
+)
+
+type DeadLetterRepo struct {
+	orz.Repository[models.DeadLetterFrame, string]
+	db *gorm.DB
+}
+
+func NewDeadLetterRepo(db *gorm.DB) *DeadLetterRepo {
+	return &DeadLetterRepo{
+		Repository: orz.NewRepository[models.DeadLetterFrame, string](db),
+		db:         db,
+	}
+}
+
+// FindRecent 查询最近的帧，pendingOnly 为 true 时只返回尚未处理成功的帧
+func (r *DeadLetterRepo) FindRecent(ctx context.Context, pendingOnly bool, limit int) ([]models.DeadLetterFrame, error) {
+	var frames []models.DeadLetterFrame
+	db := r.GetDB(ctx)
+	if pendingOnly {
+		db = db.Where("reprocessed_at = 0")
+	}
+	err := db.Order("created_at DESC").Limit(limit).Find(&frames).Error
+	return frames, err
+}
+
+// FindPending 按收到顺序查询所有尚未处理成功的帧
+func (r *DeadLetterRepo) FindPending(ctx context.Context) ([]models.DeadLetterFrame, error) {
+	var frames []models.DeadLetterFrame
+	err := r.GetDB(ctx).Where("reprocessed_at = 0").Order("created_at").Find(&frames).Error
+	return frames, err
+}
+
+// DeleteBeyond 只保留最近的 keep 条记录
+func (r *DeadLetterRepo) DeleteBeyond(ctx context.Context, keep int) error {
+	return r.GetDB(ctx).
+		Where("id NOT IN (?)", r.GetDB(ctx).Model(&models.DeadLetterFrame{}).Select("id").Order("created_at DESC").Limit(keep)).
+		Delete(&models.DeadLetterFrame{}).Error
+}
@@ -0,0 +1,28 @@
+package repo
+
+import (
+	"context"
+
+	"github.com/dushixiang/uart_sms_forwarder/internal/models"
+	"github.com/go-orz/orz"
+	"gorm.io/gorm"
+)
+
+type MessageTemplateRepo struct {
+	orz.Repository[models.MessageTemplate, string]
+	db *gorm.DB
+}
+
+func NewMessageTemplateRepo(db *gorm.DB) *MessageTemplateRepo {
+	return &MessageTemplateRepo{
+		Repository: orz.NewRepository[models.MessageTemplate, string](db),
+		db:         db,
+	}
+}
+
+// FindAll 查询所有模板，按名称排序
+func (r *MessageTemplateRepo) FindAll(ctx context.Context) ([]models.MessageTemplate, error) {
+	var templates []models.MessageTemplate
+	err := r.GetDB(ctx).Order("name").Find(&templates).Error
+	return templates, err
+}
@@ -0,0 +1,51 @@
+package repo
+
+import (
+	"context"
+
+	"github.com/dushixiang/uart_sms_forwarder/internal/models"
+	"github.com/go-orz/orz"
+	"gorm.io/gorm"
+)
+
+type NotificationLogRepo struct {
+	orz.Repository[models.NotificationLog, string]
+	db *gorm.DB
+}
+
+func NewNotificationLogRepo(db *gorm.DB) *NotificationLogRepo {
+	return &NotificationLogRepo{
+		Repository: orz.NewRepository[models.NotificationLog, string](db),
+		db:         db,
+	}
+}
+
+// FindBefore 按时间倒序查询游标之前的记录，cursor 为空时从最新开始
+func (r *NotificationLogRepo) FindBefore(ctx context.Context, scope func(db *gorm.DB) *gorm.DB, cursor *MessageCursor, limit int) ([]models.NotificationLog, error) {
+	db := r.GetDB(ctx).Model(&models.NotificationLog{}).Scopes(scope)
+	if cursor != nil {
+		db = db.Where("(created_at < ? OR (created_at = ? AND id < ?))", cursor.CreatedAt, cursor.CreatedAt, cursor.ID)
+	}
+
+	var logs []models.NotificationLog
+	err := db.Order("created_at DESC").Order("id DESC").Limit(limit).Find(&logs).Error
+	return logs, err
+}
+
+// FindAfter 按时间正序查询游标之后的记录，cursor 为空时从最早开始，用于按时间顺序导出
+func (r *NotificationLogRepo) FindAfter(ctx context.Context, scope func(db *gorm.DB) *gorm.DB, cursor *MessageCursor, limit int) ([]models.NotificationLog, error) {
+	db := r.GetDB(ctx).Model(&models.NotificationLog{}).Scopes(scope)
+	if cursor != nil {
+		db = db.Where("(created_at > ? OR (created_at = ? AND id > ?))", cursor.CreatedAt, cursor.CreatedAt, cursor.ID)
+	}
+
+	var logs []models.NotificationLog
+	err := db.Order("created_at ASC").Order("id ASC").Limit(limit).Find(&logs).Error
+	return logs, err
+}
+
+// DeleteBefore 删除 before（时间戳毫秒）之前的记录
+func (r *NotificationLogRepo) DeleteBefore(ctx context.Context, before int64) (int64, error) {
+	result := r.GetDB(ctx).Where("created_at < ?", before).Delete(&models.NotificationLog{})
+	return result.RowsAffected, result.Error
+}
@@ -0,0 +1,38 @@
+package repo
+
+import (
+	"context"
+
+	"github.com/dushixiang/uart_sms_forwarder/internal/models"
+	"github.com/go-orz/orz"
+	"gorm.io/gorm"
+)
+
+type NumberAliasRepo struct {
+	orz.Repository[models.NumberAlias, string]
+	db *gorm.DB
+}
+
+func NewNumberAliasRepo(db *gorm.DB) *NumberAliasRepo {
+	return &NumberAliasRepo{
+		Repository: orz.NewRepository[models.NumberAlias, string](db),
+		db:         db,
+	}
+}
+
+// FindAll 查询所有别名，按主号码和别名排序
+func (r *NumberAliasRepo) FindAll(ctx context.Context) ([]models.NumberAlias, error) {
+	var aliases []models.NumberAlias
+	err := r.GetDB(ctx).Order("peer").Order("alias").Find(&aliases).Error
+	return aliases, err
+}
+
+// UpdatePeer 将主号码为 from 的别名改为指向 to
+func (r *NumberAliasRepo) UpdatePeer(ctx context.Context, from, to string) error {
+	return r.GetDB(ctx).Model(&models.NumberAlias{}).Where("peer = ?", from).Update("peer", to).Error
+}
+
+// DeleteByAlias 删除别名
+func (r *NumberAliasRepo) DeleteByAlias(ctx context.Context, alias string) error {
+	return r.GetDB(ctx).Where("alias = ?", alias).Delete(&models.NumberAlias{}).Error
+}
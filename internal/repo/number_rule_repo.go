@@ -0,0 +1,35 @@
+package repo
+
+import (
+	"context"
+
+	"github.com/dushixiang/uart_sms_forwarder/internal/models"
+	"github.com/go-orz/orz"
+	"gorm.io/gorm"
+)
+
+type NumberRuleRepo struct {
+	orz.Repository[models.NumberRule, string]
+	db *gorm.DB
+}
+
+func NewNumberRuleRepo(db *gorm.DB) *NumberRuleRepo {
+	return &NumberRuleRepo{
+		Repository: orz.NewRepository[models.NumberRule, string](db),
+		db:         db,
+	}
+}
+
+// FindAll 查询所有规则，按创建时间排序
+func (r *NumberRuleRepo) FindAll(ctx context.Context) ([]models.NumberRule, error) {
+	var rules []models.NumberRule
+	err := r.GetDB(ctx).Order("created_at").Find(&rules).Error
+	return rules, err
+}
+
+// FindAllEnabled 查询所有启用的规则
+func (r *NumberRuleRepo) FindAllEnabled(ctx context.Context) ([]models.NumberRule, error) {
+	var rules []models.NumberRule
+	err := r.GetDB(ctx).Where("enabled = ?", true).Order("created_at").Find(&rules).Error
+	return rules, err
+}
@@ -0,0 +1,28 @@
+package repo
+
+import (
+	"context"
+
+	"github.com/dushixiang/uart_sms_forwarder/internal/models"
+	"github.com/go-orz/orz"
+	"gorm.io/gorm"
+)
+
+type PeerAssignmentRepo struct {
+	orz.Repository[models.PeerAssignment, string]
+	db *gorm.DB
+}
+
+func NewPeerAssignmentRepo(db *gorm.DB) *PeerAssignmentRepo {
+	return &PeerAssignmentRepo{
+		Repository: orz.NewRepository[models.PeerAssignment, string](db),
+		db:         db,
+	}
+}
+
+// FindAll 查询所有分配，按号码排序
+func (r *PeerAssignmentRepo) FindAll(ctx context.Context) ([]models.PeerAssignment, error) {
+	var assignments []models.PeerAssignment
+	err := r.GetDB(ctx).Order("peer").Find(&assignments).Error
+	return assignments, err
+}
@@ -0,0 +1,58 @@
+package repo
+
+import (
+	"context"
+
+	"github.com/dushixiang/uart_sms_forwarder/internal/models"
+	"github.com/go-orz/orz"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type PushDeviceRepo struct {
+	orz.Repository[models.PushDevice, string]
+	db *gorm.DB
+}
+
+func NewPushDeviceRepo(db *gorm.DB) *PushDeviceRepo {
+	return &PushDeviceRepo{
+		Repository: orz.NewRepository[models.PushDevice, string](db),
+		db:         db,
+	}
+}
+
+// FindByKind 查询指定推送方式的设备
+func (r *PushDeviceRepo) FindByKind(ctx context.Context, kind string) ([]models.PushDevice, error) {
+	var devices []models.PushDevice
+	err := r.GetDB(ctx).Where("kind = ?", kind).Order("created_at").Find(&devices).Error
+	return devices, err
+}
+
+// FindAll 查询所有设备
+func (r *PushDeviceRepo) FindAll(ctx context.Context) ([]models.PushDevice, error) {
+	var devices []models.PushDevice
+	err := r.GetDB(ctx).Order("created_at").Find(&devices).Error
+	return devices, err
+}
+
+// Upsert 按 token 保存设备，已注册时更新密钥、名称、平台和用户
+func (r *PushDeviceRepo) Upsert(ctx context.Context, device *models.PushDevice) error {
+	return r.GetDB(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "token"}},
+		DoUpdates: clause.AssignmentColumns([]string{"kind", "p256dh", "auth", "platform", "name", "username", "updated_at"}),
+	}).Create(device).Error
+}
+
+// DeleteByToken 按 token 删除设备
+func (r *PushDeviceRepo) DeleteByToken(ctx context.Context, token string) error {
+	return r.GetDB(ctx).Where("token = ?", token).Delete(&models.PushDevice{}).Error
+}
+
+// FindByToken 按 token 查询设备
+func (r *PushDeviceRepo) FindByToken(ctx context.Context, token string) (*models.PushDevice, error) {
+	var device models.PushDevice
+	if err := r.GetDB(ctx).Where("token = ?", token).First(&device).Error; err != nil {
+		return nil, err
+	}
+	return &device, nil
+}
@@ -0,0 +1,43 @@
+package repo
+
+import (
+	"context"
+
+	"github.com/dushixiang/uart_sms_forwarder/internal/models"
+	"github.com/go-orz/orz"
+	"gorm.io/gorm"
+)
+
+type RegistrationRecoveryRepo struct {
+	orz.Repository[models.RegistrationRecovery, string]
+	db *gorm.DB
+}
+
+func NewRegistrationRecoveryRepo(db *gorm.DB) *RegistrationRecoveryRepo {
+	return &RegistrationRecoveryRepo{
+		Repository: orz.NewRepository[models.RegistrationRecovery, string](db),
+		db:         db,
+	}
+}
+
+// FindBefore 按时间倒序查询游标之前的记录，cursor 为空时从最新开始
+func (r *RegistrationRecoveryRepo) FindBefore(ctx context.Context, deviceID string, cursor *MessageCursor, limit int) ([]models.RegistrationRecovery, error) {
+	db := r.GetDB(ctx).Model(&models.RegistrationRecovery{})
+	if deviceID != "" {
+		db = db.Where("device_id = ?", deviceID)
+	}
+	if cursor != nil {
+		db = db.Where("(created_at < ? OR (created_at = ? AND id < ?))", cursor.CreatedAt, cursor.CreatedAt, cursor.ID)
+	}
+
+	var records []models.RegistrationRecovery
+	err := db.Order("created_at DESC").Order("id DESC").Limit(limit).Find(&records).Error
+	return records, err
+}
+
+// CancelRunning 将服务重启前未结束的恢复记录标记为已停止
+func (r *RegistrationRecoveryRepo) CancelRunning(ctx context.Context, endedAt int64) error {
+	return r.GetDB(ctx).Model(&models.RegistrationRecovery{}).
+		Where("result = ?", models.RegistrationRecoveryRunning).
+		Updates(map[string]any{"result": models.RegistrationRecoveryCancelled, "ended_at": endedAt}).Error
+}
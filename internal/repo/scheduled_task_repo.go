@@ -34,6 +34,13 @@ func (r *ScheduledTaskRepo) FindAll(ctx context.Context) ([]models.ScheduledTask
 	return tasks, err
 }
 
+// FindByLastMsgId 查询最近一次执行发送了该短信的任务
+func (r *ScheduledTaskRepo) FindByLastMsgId(ctx context.Context, msgId string) ([]models.ScheduledTask, error) {
+	var tasks []models.ScheduledTask
+	err := r.db.WithContext(ctx).Where("last_msg_id = ?", msgId).Find(&tasks).Error
+	return tasks, err
+}
+
 func (r *ScheduledTaskRepo) UpdateLastRunStatusByMsgId(ctx context.Context, msgId string, status models.LastRunStatus) error {
 	return r.db.WithContext(ctx).Model(&models.ScheduledTask{}).
 		Where("last_msg_id = ?", msgId).
@@ -0,0 +1,53 @@
+package repo
+
+import (
+	"context"
+
+	"github.com/dushixiang/uart_sms_forwarder/internal/models"
+	"github.com/go-orz/orz"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type SpamTokenRepo struct {
+	orz.Repository[models.SpamToken, string]
+	db *gorm.DB
+}
+
+func NewSpamTokenRepo(db *gorm.DB) *SpamTokenRepo {
+	return &SpamTokenRepo{
+		Repository: orz.NewRepository[models.SpamToken, string](db),
+		db:         db,
+	}
+}
+
+// FindByTokens 查询指定分词的统计，未出现过的分词不在结果中
+func (r *SpamTokenRepo) FindByTokens(ctx context.Context, tokens []string) (map[string]models.SpamToken, error) {
+	var rows []models.SpamToken
+	if err := r.GetDB(ctx).Where("token IN ?", tokens).Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	result := make(map[string]models.SpamToken, len(rows))
+	for _, row := range rows {
+		result[row.Token] = row
+	}
+	return result, nil
+}
+
+// Increment 累加分词的垃圾 / 正常计数，不存在时创建
+func (r *SpamTokenRepo) Increment(ctx context.Context, tokens []string, spam, ham int64) error {
+	if len(tokens) == 0 {
+		return nil
+	}
+	rows := make([]models.SpamToken, 0, len(tokens))
+	for _, token := range tokens {
+		rows = append(rows, models.SpamToken{Token: token, Spam: spam, Ham: ham})
+	}
+	return r.GetDB(ctx).Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "token"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{
+			"spam": gorm.Expr("spam + ?", spam),
+			"ham":  gorm.Expr("ham + ?", ham),
+		}),
+	}).Create(&rows).Error
+}
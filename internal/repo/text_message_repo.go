@@ -1,9 +1,14 @@
 package repo
 
 import (
+	"context"
+	"strings"
+	"time"
+
 	"github.com/dushixiang/uart_sms_forwarder/internal/models"
 	"github.com/go-orz/orz"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 func NewTextMessageRepo(db *gorm.DB) *TextMessageRepo {
@@ -17,3 +22,203 @@ type TextMessageRepo struct {
 	orz.Repository[models.TextMessage, string]
 	db *gorm.DB
 }
+
+// MessageCursor 键集分页游标，指向上一页最后一条记录
+type MessageCursor struct {
+	CreatedAt int64
+	ID        string
+}
+
+// FindBefore 按 (created_at, id) 倒序查询游标之前的记录，cursor 为空时从最新开始。
+// 利用 idx_text_messages_created_id 索引，翻页深度不影响查询耗时。
+func (r *TextMessageRepo) FindBefore(ctx context.Context, scope func(db *gorm.DB) *gorm.DB, cursor *MessageCursor, limit int) ([]models.TextMessage, error) {
+	db := r.GetDB(ctx).Model(&models.TextMessage{})
+	if scope != nil {
+		db = scope(db)
+	}
+	if cursor != nil {
+		db = db.Where("(created_at < ? OR (created_at = ? AND id < ?))", cursor.CreatedAt, cursor.CreatedAt, cursor.ID)
+	}
+
+	var messages []models.TextMessage
+	err := db.Order("created_at DESC").Order("id DESC").Limit(limit).Find(&messages).Error
+	return messages, err
+}
+
+// FindPage 按 (created_at, id) 倒序查询第 offset 条起的记录，同时返回符合条件的总数。
+// 用于按页码翻页，深度翻页比 FindBefore 慢。
+func (r *TextMessageRepo) FindPage(ctx context.Context, scope func(db *gorm.DB) *gorm.DB, offset, limit int) ([]models.TextMessage, int64, error) {
+	db := r.GetDB(ctx).Model(&models.TextMessage{})
+	if scope != nil {
+		db = scope(db)
+	}
+
+	var total int64
+	if err := db.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	messages := make([]models.TextMessage, 0)
+	if total > int64(offset) {
+		if err := db.Order("created_at DESC").Order("id DESC").Offset(offset).Limit(limit).Find(&messages).Error; err != nil {
+			return nil, 0, err
+		}
+	}
+	return messages, total, nil
+}
+
+// ContentContains 内容包含 keyword 的查询条件，keyword 中的 % 和 _ 按普通字符匹配
+func ContentContains(keyword string) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where(`content LIKE ? ESCAPE '\'`, "%"+escapeLike(keyword)+"%")
+	}
+}
+
+// DayCount 按天分组的数量
+type DayCount struct {
+	Day   int64  // 自 1970-01-01 起的天数（按 loc 时区划分）
+	Type  string // 消息类型
+	Count int64
+}
+
+// CountByDay 统计 since（时间戳毫秒）之后每天每种类型的数量。
+// 按 now 所在时区的当前 UTC 偏移划分日期，夏令时切换当天可能有一小时偏差。
+func (r *TextMessageRepo) CountByDay(ctx context.Context, scope func(db *gorm.DB) *gorm.DB, since int64, now time.Time) ([]DayCount, error) {
+	_, offset := now.Zone()
+	offsetMs := int64(offset) * 1000
+
+	db := r.GetDB(ctx)
+	div := "/"
+	if db.Dialector.Name() == "mysql" {
+		div = "DIV"
+	}
+	dayExpr := "(created_at + ?) " + div + " 86400000"
+
+	var rows []DayCount
+	err := db.Model(&models.TextMessage{}).
+		Scopes(scope).
+		Select(dayExpr+" AS day, type, COUNT(*) AS count", offsetMs).
+		Where("created_at >= ?", since).
+		Group("day").Group("type").
+		Scan(&rows).Error
+	return rows, err
+}
+
+// HourCount 按小时分组的数量
+type HourCount struct {
+	Hour  int64  // 自 1970-01-01 00:00 起的小时数（按 loc 时区划分）
+	Type  string // 消息类型
+	Count int64
+}
+
+// CountByHour 统计 since（时间戳毫秒）之后每小时每种类型的数量，时区处理与 CountByDay 相同
+func (r *TextMessageRepo) CountByHour(ctx context.Context, scope func(db *gorm.DB) *gorm.DB, since int64, now time.Time) ([]HourCount, error) {
+	_, offset := now.Zone()
+	offsetMs := int64(offset) * 1000
+
+	db := r.GetDB(ctx)
+	div := "/"
+	if db.Dialector.Name() == "mysql" {
+		div = "DIV"
+	}
+	hourExpr := "(created_at + ?) " + div + " 3600000"
+
+	var rows []HourCount
+	err := db.Model(&models.TextMessage{}).
+		Scopes(scope).
+		Select(hourExpr+" AS hour, type, COUNT(*) AS count", offsetMs).
+		Where("created_at >= ?", since).
+		Group("hour").Group("type").
+		Scan(&rows).Error
+	return rows, err
+}
+
+// TypeSummary 按类型分组的数量和时间范围
+type TypeSummary struct {
+	Type    string // 消息类型
+	Count   int64
+	FirstAt int64 // 最早一条的时间（时间戳毫秒）
+	LastAt  int64 // 最近一条的时间（时间戳毫秒）
+}
+
+// SummarizeByType 统计每种类型的数量和最早、最近的时间
+func (r *TextMessageRepo) SummarizeByType(ctx context.Context, scope func(db *gorm.DB) *gorm.DB) ([]TypeSummary, error) {
+	var rows []TypeSummary
+	err := r.GetDB(ctx).Model(&models.TextMessage{}).
+		Scopes(scope).
+		Select("type, COUNT(*) AS count, MIN(created_at) AS first_at, MAX(created_at) AS last_at").
+		Group("type").
+		Scan(&rows).Error
+	return rows, err
+}
+
+// escapeLike 转义 LIKE 通配符
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
+
+// FindDistinctPeers 查询包含 keyword 的不重复号码，incoming 取发送方，outgoing 取接收方，按最近联系时间排序
+func (r *TextMessageRepo) FindDistinctPeers(ctx context.Context, scope func(db *gorm.DB) *gorm.DB, msgType models.MessageType, keyword string, limit int) ([]string, error) {
+	column := `"from"`
+	if msgType == models.MessageTypeOutgoing {
+		column = `"to"`
+	}
+
+	var peers []string
+	err := r.GetDB(ctx).Model(&models.TextMessage{}).
+		Scopes(scope).
+		Select(column+" AS peer").
+		Where("type = ? AND "+column+" <> '' AND "+column+` LIKE ? ESCAPE '\'`, msgType, "%"+escapeLike(keyword)+"%").
+		Group("peer").
+		Order("MAX(created_at) DESC").
+		Limit(limit).
+		Pluck("peer", &peers).Error
+	return peers, err
+}
+
+// FindTagsLike 查询包含 keyword 的标签所在记录的标签列表（最多扫描 scanLimit 条记录）
+func (r *TextMessageRepo) FindTagsLike(ctx context.Context, scope func(db *gorm.DB) *gorm.DB, keyword string, scanLimit int) ([][]string, error) {
+	var messages []models.TextMessage
+	err := r.GetDB(ctx).
+		Scopes(scope).
+		Select("tags").
+		Where(`tags LIKE ? ESCAPE '\'`, "%"+escapeLike(keyword)+"%").
+		Order("created_at DESC").
+		Limit(scanLimit).
+		Find(&messages).Error
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([][]string, 0, len(messages))
+	for _, msg := range messages {
+		result = append(result, msg.Tags)
+	}
+	return result, nil
+}
+
+// CreateIgnoreDuplicates 批量保存短信，ID 已存在的记录忽略，返回实际新增的数量
+func (r *TextMessageRepo) CreateIgnoreDuplicates(ctx context.Context, messages []models.TextMessage, batchSize int) (int64, error) {
+	if len(messages) == 0 {
+		return 0, nil
+	}
+	result := r.GetDB(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "id"}},
+		DoNothing: true,
+	}).CreateInBatches(messages, batchSize)
+	return result.RowsAffected, result.Error
+}
+
+// FindAfter 按 (created_at, id) 正序查询游标之后的记录，cursor 为空时从最早开始，用于按时间顺序导出
+func (r *TextMessageRepo) FindAfter(ctx context.Context, scope func(db *gorm.DB) *gorm.DB, cursor *MessageCursor, limit int) ([]models.TextMessage, error) {
+	db := r.GetDB(ctx).Model(&models.TextMessage{})
+	if scope != nil {
+		db = scope(db)
+	}
+	if cursor != nil {
+		db = db.Where("(created_at > ? OR (created_at = ? AND id > ?))", cursor.CreatedAt, cursor.CreatedAt, cursor.ID)
+	}
+
+	var messages []models.TextMessage
+	err := db.Order("created_at ASC").Order("id ASC").Limit(limit).Find(&messages).Error
+	return messages, err
+}
@@ -0,0 +1,46 @@
+package repo
+
+import (
+	"context"
+
+	"github.com/dushixiang/uart_sms_forwarder/internal/models"
+	"github.com/go-orz/orz"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type TransactionRepo struct {
+	orz.Repository[models.Transaction, string]
+	db *gorm.DB
+}
+
+func NewTransactionRepo(db *gorm.DB) *TransactionRepo {
+	return &TransactionRepo{
+		Repository: orz.NewRepository[models.Transaction, string](db),
+		db:         db,
+	}
+}
+
+// CreateIgnoreDuplicate 保存交易记录，同一短信已解析过时忽略
+func (r *TransactionRepo) CreateIgnoreDuplicate(ctx context.Context, tx *models.Transaction) error {
+	return r.GetDB(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "message_id"}},
+		DoNothing: true,
+	}).Create(tx).Error
+}
+
+// FindBetween 查询 [start, end) 时间范围内的交易，按交易时间倒序
+func (r *TransactionRepo) FindBetween(ctx context.Context, scope func(db *gorm.DB) *gorm.DB, start, end int64) ([]models.Transaction, error) {
+	var transactions []models.Transaction
+	err := r.GetDB(ctx).
+		Scopes(scope).
+		Where("occurred_at >= ? AND occurred_at < ?", start, end).
+		Order("occurred_at DESC").
+		Find(&transactions).Error
+	return transactions, err
+}
+
+// DeleteAll 删除所有交易记录
+func (r *TransactionRepo) DeleteAll(ctx context.Context) error {
+	return r.GetDB(ctx).Where("1 = 1").Delete(&models.Transaction{}).Error
+}
@@ -0,0 +1,64 @@
+package service
+
+import (
+	"context"
+
+	"go.uber.org/zap"
+)
+
+// PropertyIDUserPasswordHashes 登录时按当前参数重新计算的用户密码哈希
+const PropertyIDUserPasswordHashes = "user_password_hashes"
+
+// storedPasswordHash 重新计算的密码哈希。
+// Source 记录计算时配置文件中的哈希，配置文件中的密码被修改后此记录自动失效。
+type storedPasswordHash struct {
+	Source string `json:"source"`
+	Hash   string `json:"hash"`
+}
+
+// effectiveHash 获取用户当前有效的密码哈希
+func (s *AccountService) effectiveHash(ctx context.Context, username, configHash string) string {
+	if s.propertyService == nil {
+		return configHash
+	}
+	var stored map[string]storedPasswordHash
+	if err := s.propertyService.GetValue(ctx, PropertyIDUserPasswordHashes, &stored); err != nil {
+		return configHash
+	}
+	if entry, ok := stored[username]; ok && entry.Source == configHash && entry.Hash != "" {
+		return entry.Hash
+	}
+	return configHash
+}
+
+// rehash 密码验证通过后按当前算法和参数重新计算哈希并保存，失败不影响登录
+func (s *AccountService) rehash(ctx context.Context, username, configHash, password string) {
+	if s.propertyService == nil {
+		return
+	}
+	hash, err := s.hasher.Hash(password)
+	if err != nil {
+		s.logger.Error("重新计算密码哈希失败", zap.String("username", username), zap.Error(err))
+		return
+	}
+
+	s.rehashMu.Lock()
+	defer s.rehashMu.Unlock()
+
+	stored := make(map[string]storedPasswordHash)
+	_ = s.propertyService.GetValue(ctx, PropertyIDUserPasswordHashes, &stored)
+	// 清理已删除用户或配置已修改的记录
+	for name, entry := range stored {
+		if s.users[name] != entry.Source {
+			delete(stored, name)
+		}
+	}
+	stored[username] = storedPasswordHash{Source: configHash, Hash: hash}
+	if err := s.propertyService.Set(ctx, PropertyIDUserPasswordHashes, "用户密码哈希", stored); err != nil {
+		s.logger.Error("保存密码哈希失败", zap.String("username", username), zap.Error(err))
+		return
+	}
+	s.logger.Info("已按当前参数重新计算密码哈希",
+		zap.String("username", username),
+		zap.String("algorithm", s.hasher.Algorithm))
+}
@@ -2,16 +2,17 @@ package service
 
 import (
 	"context"
+	"sync"
 	"time"
 
 	"github.com/dushixiang/uart_sms_forwarder/config"
+	"github.com/dushixiang/uart_sms_forwarder/internal/util"
 	"github.com/go-errors/errors"
 	"github.com/golang-jwt/jwt/v5"
 	"go.uber.org/zap"
-	"golang.org/x/crypto/bcrypt"
 )
 
-func NewAccountService(logger *zap.Logger, oidcService *OIDCService, appConfig *config.AppConfig) *AccountService {
+func NewAccountService(logger *zap.Logger, oidcService *OIDCService, propertyService *PropertyService, appConfig *config.AppConfig) *AccountService {
 	jwtSecret := appConfig.JWT.Secret
 	tokenExpireHours := appConfig.JWT.ExpiresHours
 
@@ -25,12 +26,25 @@ func NewAccountService(logger *zap.Logger, oidcService *OIDCService, appConfig *
 		tokenExpireHours = 168 // 默认7天
 	}
 
+	hasher := &util.PasswordHasher{
+		Algorithm:         appConfig.Password.Algorithm,
+		BcryptCost:        appConfig.Password.BcryptCost,
+		Argon2Memory:      appConfig.Password.Argon2Memory,
+		Argon2Iterations:  appConfig.Password.Argon2Iterations,
+		Argon2Parallelism: appConfig.Password.Argon2Parallelism,
+	}
+	if err := hasher.Normalize(); err != nil {
+		logger.Fatal("密码哈希配置错误", zap.Error(err))
+	}
+
 	service := &AccountService{
 		logger:           logger,
 		oidcService:      oidcService,
+		propertyService:  propertyService,
 		jwtSecret:        jwtSecret,
 		tokenExpireHours: tokenExpireHours,
 		users:            appConfig.Users,
+		hasher:           hasher,
 	}
 	return service
 }
@@ -38,10 +52,14 @@ func NewAccountService(logger *zap.Logger, oidcService *OIDCService, appConfig *
 type AccountService struct {
 	logger           *zap.Logger
 	oidcService      *OIDCService
+	propertyService  *PropertyService
 	jwtSecret        string
 	tokenExpireHours int
 
-	users map[string]string
+	users  map[string]string
+	hasher *util.PasswordHasher
+	// 重新计算哈希时串行化读写，避免并发登录互相覆盖
+	rehashMu sync.Mutex
 }
 
 // JWTClaims JWT 声明
@@ -146,19 +164,24 @@ func (s *AccountService) Logout(ctx context.Context, userID string) error {
 
 // ValidateCredentials 验证用户名和密码
 func (s *AccountService) ValidateCredentials(ctx context.Context, username, password string) error {
-	// 从配置中获取用户的bcrypt密码哈希
-	hashedPassword, exists := s.users[username]
+	// 从配置中获取用户的密码哈希
+	configHash, exists := s.users[username]
 	if !exists {
 		s.logger.Debug("用户不存在", zap.String("username", username))
 		return errors.New("用户名或密码错误")
 	}
 
-	// 验证密码
-	if err := bcrypt.CompareHashAndPassword([]byte(hashedPassword), []byte(password)); err != nil {
-		s.logger.Debug("密码验证失败", zap.String("username", username), zap.Error(err))
+	// 验证密码，优先使用登录时重新计算过的哈希
+	hashedPassword := s.effectiveHash(ctx, username, configHash)
+	if !s.hasher.Verify(hashedPassword, password) {
+		s.logger.Debug("密码验证失败", zap.String("username", username))
 		return errors.New("用户名或密码错误")
 	}
 
+	if s.hasher.NeedsRehash(hashedPassword) {
+		s.rehash(ctx, username, configHash, password)
+	}
+
 	s.logger.Info("User 认证成功", zap.String("username", username))
 	return nil
 }
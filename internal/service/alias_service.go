@@ -0,0 +1,158 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/dushixiang/uart_sms_forwarder/internal/models"
+	"github.com/dushixiang/uart_sms_forwarder/internal/repo"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// ErrAliasNotFound 号码不是该会话的别名
+var ErrAliasNotFound = errors.New("号码不是该会话的别名")
+
+// AliasGroup 合并后的会话，主号码及其所有别名
+type AliasGroup struct {
+	Peer    string   `json:"peer"`    // 主号码
+	Aliases []string `json:"aliases"` // 别名号码
+}
+
+// AliasService 号码别名服务。
+// 同一联系人的多个号码（如银行的多个短号、带和不带 +86 的号码）可合并为一个会话，
+// 会话列表、会话消息和搜索建议中别名号码都按主号码显示。
+type AliasService struct {
+	logger *zap.Logger
+	repo   *repo.NumberAliasRepo
+}
+
+// NewAliasService 创建号码别名服务实例
+func NewAliasService(logger *zap.Logger, db *gorm.DB) *AliasService {
+	return &AliasService{
+		logger: logger,
+		repo:   repo.NewNumberAliasRepo(db),
+	}
+}
+
+// List 获取所有别名
+func (s *AliasService) List(ctx context.Context) ([]models.NumberAlias, error) {
+	return s.repo.FindAll(ctx)
+}
+
+// Merge 将号码合并到 peer 的会话中，peer 本身是别名时合并到它的主号码。
+// 合并的号码如果已有自己的别名，这些别名一起合并过来。
+func (s *AliasService) Merge(ctx context.Context, peer string, numbers []string) (*AliasGroup, error) {
+	if err := requireAdmin(ctx); err != nil {
+		return nil, err
+	}
+	peer = strings.TrimSpace(peer)
+	if peer == "" {
+		return nil, fmt.Errorf("号码不能为空")
+	}
+	numbers = normalizeTags(numbers)
+	if len(numbers) == 0 {
+		return nil, fmt.Errorf("合并的号码不能为空")
+	}
+
+	aliases, err := s.aliasMap(ctx)
+	if err != nil {
+		return nil, err
+	}
+	primary := resolveAlias(aliases, peer)
+	for _, number := range numbers {
+		if number == primary {
+			continue
+		}
+		if err := s.repo.UpdatePeer(ctx, number, primary); err != nil {
+			return nil, fmt.Errorf("合并号码失败: %w", err)
+		}
+		if err := s.repo.Save(ctx, &models.NumberAlias{Alias: number, Peer: primary}); err != nil {
+			return nil, fmt.Errorf("合并号码失败: %w", err)
+		}
+	}
+	s.logger.Info("会话已合并", zap.String("peer", primary), zap.Strings("numbers", numbers))
+	return s.Group(ctx, primary)
+}
+
+// Unmerge 将别名号码从 peer 的会话中拆出，恢复为独立的会话
+func (s *AliasService) Unmerge(ctx context.Context, peer string, numbers []string) (*AliasGroup, error) {
+	if err := requireAdmin(ctx); err != nil {
+		return nil, err
+	}
+	aliases, err := s.aliasMap(ctx)
+	if err != nil {
+		return nil, err
+	}
+	primary := resolveAlias(aliases, strings.TrimSpace(peer))
+	for _, number := range normalizeTags(numbers) {
+		if aliases[number] != primary {
+			return nil, fmt.Errorf("%w: %s", ErrAliasNotFound, number)
+		}
+	}
+	for _, number := range normalizeTags(numbers) {
+		if err := s.repo.DeleteByAlias(ctx, number); err != nil {
+			return nil, fmt.Errorf("拆分会话失败: %w", err)
+		}
+	}
+	s.logger.Info("会话已拆分", zap.String("peer", primary), zap.Strings("numbers", numbers))
+	return s.Group(ctx, primary)
+}
+
+// Group 获取号码所在的会话，号码没有别名时 Aliases 为空
+func (s *AliasService) Group(ctx context.Context, peer string) (*AliasGroup, error) {
+	aliases, err := s.aliasMap(ctx)
+	if err != nil {
+		return nil, err
+	}
+	primary := resolveAlias(aliases, peer)
+	return &AliasGroup{Peer: primary, Aliases: aliasesOf(aliases, primary)}, nil
+}
+
+// aliasMap 别名到主号码的映射
+func (s *AliasService) aliasMap(ctx context.Context) (map[string]string, error) {
+	records, err := s.repo.FindAll(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("获取号码别名失败: %w", err)
+	}
+	aliases := make(map[string]string, len(records))
+	for _, record := range records {
+		aliases[record.Alias] = record.Peer
+	}
+	return aliases, nil
+}
+
+// resolveAlias 号码对应的主号码，不是别名时返回号码本身
+func resolveAlias(aliases map[string]string, number string) string {
+	if peer, ok := aliases[number]; ok {
+		return peer
+	}
+	return number
+}
+
+// aliasesOf 主号码的所有别名，按号码排序
+func aliasesOf(aliases map[string]string, peer string) []string {
+	result := []string{}
+	for alias, primary := range aliases {
+		if primary == peer {
+			result = append(result, alias)
+		}
+	}
+	slices.Sort(result)
+	return result
+}
+
+// resolvePeers 将号码列表中的别名替换为主号码并去重，保持原有顺序
+func resolvePeers(aliases map[string]string, peers []string) []string {
+	result := make([]string, 0, len(peers))
+	for _, peer := range peers {
+		peer = resolveAlias(aliases, peer)
+		if !slices.Contains(result, peer) {
+			result = append(result, peer)
+		}
+	}
+	return result
+}
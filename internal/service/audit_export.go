@@ -0,0 +1,323 @@
+package service
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/dushixiang/uart_sms_forwarder/internal/models"
+	"github.com/dushixiang/uart_sms_forwarder/internal/repo"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// PropertyIDAuditSigningKey 审计导出签名密钥
+const PropertyIDAuditSigningKey = "audit_signing_key"
+
+const (
+	// auditExportVersion 审计导出格式版本
+	auditExportVersion = 1
+	// auditBatchSize 导出时每批读取的条数
+	auditBatchSize = 500
+)
+
+// 审计导出每行的类型
+const (
+	AuditKindHeader       = "header"       // 第一行：导出范围、生成时间和公钥
+	AuditKindMessage      = "message"      // 短信收发记录
+	AuditKindNotification = "notification" // 通知发送记录
+	AuditKindSignature    = "signature"    // 最后一行：对哈希链末端的签名
+)
+
+// AuditRange 审计导出的时间范围
+type AuditRange struct {
+	Since int64 `json:"since"` // 起始时间（时间戳毫秒，包含），0 表示不限制
+	Until int64 `json:"until"` // 截止时间（时间戳毫秒，不包含），0 表示不限制
+}
+
+// auditLine 审计导出的一行。prev 为上一行（不含换行符）的 SHA-256，
+// 第一行为空，形成哈希链；最后一行的 prev 即整个文件的链尾，由签名覆盖。
+type auditLine struct {
+	Seq  int64  `json:"seq"`
+	Kind string `json:"kind"`
+	At   int64  `json:"at,omitempty"` // 记录时间（时间戳毫秒）
+	Prev string `json:"prev"`
+	Data any    `json:"data"`
+}
+
+// auditMessage 短信记录，只导出内容的 SHA-256，校验方可据此证明某条短信的内容
+type auditMessage struct {
+	ID            string               `json:"id"`
+	Type          models.MessageType   `json:"type"`
+	Status        models.MessageStatus `json:"status"`
+	From          string               `json:"from"`
+	To            string               `json:"to"`
+	DeviceID      string               `json:"deviceId,omitempty"`
+	Source        string               `json:"source,omitempty"`
+	ContentSHA256 string               `json:"contentSha256"`
+}
+
+// auditNotification 通知发送记录
+type auditNotification struct {
+	ID        string                       `json:"id"`
+	MessageID string                       `json:"messageId,omitempty"`
+	Channel   string                       `json:"channel"`
+	Event     string                       `json:"event"`
+	Attempt   int                          `json:"attempt"`
+	Status    models.NotificationLogStatus `json:"status"`
+	LatencyMs int64                        `json:"latencyMs"`
+	Error     string                       `json:"error,omitempty"`
+}
+
+// AuditExportService 审计导出服务，将短信收发记录和通知发送记录按时间顺序导出为带签名的 JSONL，
+// 用于证明某条验证码或告警何时收到、何时转发到哪个渠道。
+// 每行记录上一行的哈希，最后一行为 Ed25519 签名，删除、插入、修改任意一行或截断文件都会导致校验失败。
+type AuditExportService struct {
+	logger          *zap.Logger
+	propertyService *PropertyService
+	messageRepo     *repo.TextMessageRepo
+	logRepo         *repo.NotificationLogRepo
+	keyMu           sync.Mutex
+}
+
+// NewAuditExportService 创建审计导出服务实例
+func NewAuditExportService(logger *zap.Logger, db *gorm.DB, propertyService *PropertyService) *AuditExportService {
+	return &AuditExportService{
+		logger:          logger,
+		propertyService: propertyService,
+		messageRepo:     repo.NewTextMessageRepo(db),
+		logRepo:         repo.NewNotificationLogRepo(db),
+	}
+}
+
+// signingKey 获取签名私钥，不存在时生成并保存。
+// 密钥变更后以前导出的文件需用旧公钥校验，因此只生成一次。
+func (s *AuditExportService) signingKey(ctx context.Context) (ed25519.PrivateKey, error) {
+	s.keyMu.Lock()
+	defer s.keyMu.Unlock()
+
+	var keys models.AuditSigningKey
+	err := s.propertyService.GetValue(ctx, PropertyIDAuditSigningKey, &keys)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+	if keys.PrivateKey != "" {
+		seed, err := base64.StdEncoding.DecodeString(keys.PrivateKey)
+		if err != nil || len(seed) != ed25519.SeedSize {
+			return nil, fmt.Errorf("审计签名密钥格式错误")
+		}
+		return ed25519.NewKeyFromSeed(seed), nil
+	}
+
+	publicKey, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	keys = models.AuditSigningKey{
+		PublicKey:  base64.StdEncoding.EncodeToString(publicKey),
+		PrivateKey: base64.StdEncoding.EncodeToString(privateKey.Seed()),
+	}
+	if err := s.propertyService.Set(ctx, PropertyIDAuditSigningKey, "审计导出签名密钥", keys); err != nil {
+		return nil, fmt.Errorf("保存审计签名密钥失败: %w", err)
+	}
+	s.logger.Info("已生成审计导出签名密钥")
+	return privateKey, nil
+}
+
+// PublicKey 获取审计导出的签名公钥（base64），校验方用它验证导出文件
+func (s *AuditExportService) PublicKey(ctx context.Context) (string, error) {
+	if err := requireAdmin(ctx); err != nil {
+		return "", err
+	}
+	key, err := s.signingKey(ctx)
+	if err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(key.Public().(ed25519.PublicKey)), nil
+}
+
+// Prepare 检查权限并准备签名密钥，开始输出前调用，以便在出错时仍能返回错误响应
+func (s *AuditExportService) Prepare(ctx context.Context) error {
+	if err := requireAdmin(ctx); err != nil {
+		return err
+	}
+	_, err := s.signingKey(ctx)
+	return err
+}
+
+// Export 将时间范围内的短信和通知发送记录按时间正序写入 w，分批查询，不会一次加载全部记录。
+// 写入失败或查询失败时停止，文件缺少签名行，校验时会被识别为不完整。
+func (s *AuditExportService) Export(ctx context.Context, rng AuditRange, w io.Writer) error {
+	if err := requireAdmin(ctx); err != nil {
+		return err
+	}
+	key, err := s.signingKey(ctx)
+	if err != nil {
+		return err
+	}
+	publicKey := base64.StdEncoding.EncodeToString(key.Public().(ed25519.PublicKey))
+
+	chain := &auditChain{w: w}
+	err = chain.write(AuditKindHeader, 0, map[string]any{
+		"version":     auditExportVersion,
+		"since":       rng.Since,
+		"until":       rng.Until,
+		"generatedAt": time.Now().UnixMilli(),
+		"algorithm":   "ed25519",
+		"publicKey":   publicKey,
+	})
+	if err != nil {
+		return err
+	}
+
+	scope := func(db *gorm.DB) *gorm.DB {
+		if rng.Since > 0 {
+			db = db.Where("created_at >= ?", rng.Since)
+		}
+		if rng.Until > 0 {
+			db = db.Where("created_at < ?", rng.Until)
+		}
+		return db
+	}
+	messages := &auditSource[models.TextMessage]{
+		fetch: func(cursor *repo.MessageCursor) ([]models.TextMessage, error) {
+			return s.messageRepo.FindAfter(ctx, scope, cursor, auditBatchSize)
+		},
+		cursorOf: func(msg models.TextMessage) repo.MessageCursor {
+			return repo.MessageCursor{CreatedAt: msg.CreatedAt, ID: msg.ID}
+		},
+	}
+	logs := &auditSource[models.NotificationLog]{
+		fetch: func(cursor *repo.MessageCursor) ([]models.NotificationLog, error) {
+			return s.logRepo.FindAfter(ctx, scope, cursor, auditBatchSize)
+		},
+		cursorOf: func(log models.NotificationLog) repo.MessageCursor {
+			return repo.MessageCursor{CreatedAt: log.CreatedAt, ID: log.ID}
+		},
+	}
+
+	// 合并两类记录，同一时间的短信排在通知之前
+	for {
+		msg, err := messages.peek()
+		if err != nil {
+			return fmt.Errorf("查询短信失败: %w", err)
+		}
+		log, err := logs.peek()
+		if err != nil {
+			return fmt.Errorf("查询通知发送记录失败: %w", err)
+		}
+		switch {
+		case msg == nil && log == nil:
+			return chain.sign(key)
+		case msg != nil && (log == nil || msg.CreatedAt <= log.CreatedAt):
+			sum := sha256.Sum256([]byte(msg.Content))
+			err = chain.write(AuditKindMessage, msg.CreatedAt, auditMessage{
+				ID:            msg.ID,
+				Type:          msg.Type,
+				Status:        msg.Status,
+				From:          msg.From,
+				To:            msg.To,
+				DeviceID:      msg.DeviceID,
+				Source:        msg.Source,
+				ContentSHA256: hex.EncodeToString(sum[:]),
+			})
+			messages.pop()
+		default:
+			err = chain.write(AuditKindNotification, log.CreatedAt, auditNotification{
+				ID:        log.ID,
+				MessageID: log.MessageID,
+				Channel:   log.Channel,
+				Event:     log.Event,
+				Attempt:   log.Attempt,
+				Status:    log.Status,
+				LatencyMs: log.LatencyMs,
+				Error:     log.Error,
+			})
+			logs.pop()
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// auditChain 逐行写入并维护哈希链
+type auditChain struct {
+	w     io.Writer
+	seq   int64
+	prev  string
+	count int64 // 已写入的记录数（不含首行）
+}
+
+// write 写入一行，记录这一行的哈希作为下一行的 prev
+func (c *auditChain) write(kind string, at int64, data any) error {
+	line, err := json.Marshal(auditLine{Seq: c.seq, Kind: kind, At: at, Prev: c.prev, Data: data})
+	if err != nil {
+		return fmt.Errorf("JSON编码失败: %w", err)
+	}
+	if _, err := c.w.Write(append(line, '\n')); err != nil {
+		return err
+	}
+	sum := sha256.Sum256(line)
+	c.prev = hex.EncodeToString(sum[:])
+	if c.seq > 0 {
+		c.count++
+	}
+	c.seq++
+	return nil
+}
+
+// sign 写入签名行，签名内容为链尾哈希（十六进制字符串）
+func (c *auditChain) sign(key ed25519.PrivateKey) error {
+	signature := ed25519.Sign(key, []byte(c.prev))
+	return c.write(AuditKindSignature, 0, map[string]any{
+		"count":     c.count,
+		"head":      c.prev,
+		"signature": base64.StdEncoding.EncodeToString(signature),
+	})
+}
+
+// auditSource 按时间正序分批读取的记录源
+type auditSource[T any] struct {
+	fetch    func(cursor *repo.MessageCursor) ([]T, error)
+	cursorOf func(T) repo.MessageCursor
+	batch    []T
+	cursor   *repo.MessageCursor
+	done     bool
+}
+
+// peek 返回下一条记录，没有更多记录时返回 nil
+func (src *auditSource[T]) peek() (*T, error) {
+	if len(src.batch) == 0 && !src.done {
+		batch, err := src.fetch(src.cursor)
+		if err != nil {
+			return nil, err
+		}
+		if len(batch) < auditBatchSize {
+			src.done = true
+		}
+		if len(batch) > 0 {
+			cursor := src.cursorOf(batch[len(batch)-1])
+			src.cursor = &cursor
+		}
+		src.batch = batch
+	}
+	if len(src.batch) == 0 {
+		return nil, nil
+	}
+	return &src.batch[0], nil
+}
+
+// pop 移除 peek 返回的记录
+func (src *auditSource[T]) pop() {
+	src.batch = src.batch[1:]
+}
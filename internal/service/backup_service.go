@@ -0,0 +1,335 @@
+package service
+
+import (
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dushixiang/uart_sms_forwarder/internal/models"
+	"github.com/robfig/cron/v3"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+const (
+	// PropertyIDBackup 远程备份配置
+	PropertyIDBackup = "backup"
+	// DefaultBackupSchedule 默认备份时间（每天凌晨 3 点）
+	DefaultBackupSchedule = "0 3 * * *"
+
+	backupNamePrefix = "uart_sms_forwarder-"
+	backupNameSuffix = ".db.gz"
+	backupTimeLayout = "20060102-150405"
+	backupTimeout    = 30 * time.Minute
+)
+
+// DefaultBackupConfig 默认远程备份配置
+var DefaultBackupConfig = models.BackupConfig{
+	Schedule:  DefaultBackupSchedule,
+	Retention: 7,
+	Type:      "webdav",
+	S3:        models.S3BackupConfig{Region: "us-east-1"},
+}
+
+// BackupResult 备份结果
+type BackupResult struct {
+	Name       string   `json:"name"`       // 备份文件名
+	Target     string   `json:"target"`     // 备份目标类型
+	StartedAt  int64    `json:"startedAt"`  // 开始时间（时间戳毫秒）
+	DurationMs int64    `json:"durationMs"` // 耗时（毫秒）
+	Size       int64    `json:"size"`       // 压缩后大小（字节）
+	Deleted    []string `json:"deleted"`    // 按保留数量删除的旧备份
+	Error      string   `json:"error,omitempty"`
+}
+
+// backupTarget 备份目标存储
+type backupTarget interface {
+	// Upload 上传备份文件
+	Upload(ctx context.Context, name string, file *os.File, size int64) error
+	// List 列出目标中的所有文件名
+	List(ctx context.Context) ([]string, error)
+	// Delete 删除备份文件
+	Delete(ctx context.Context, name string) error
+}
+
+// BackupService 远程备份服务。
+// 定时将 SQLite 数据库快照压缩后上传到 S3 兼容存储或 WebDAV，
+// 避免存储卡损坏导致短信记录丢失，并按保留数量清理旧备份。
+type BackupService struct {
+	logger          *zap.Logger
+	db              *gorm.DB
+	propertyService *PropertyService
+	notify          func(ctx context.Context, content string)
+
+	cron    *cron.Cron
+	entryID cron.EntryID
+
+	running sync.Mutex
+	mu      sync.RWMutex
+	last    *BackupResult
+}
+
+// NewBackupService 创建远程备份服务实例，notify 用于推送备份失败通知
+func NewBackupService(logger *zap.Logger, db *gorm.DB, propertyService *PropertyService, notify func(ctx context.Context, content string)) *BackupService {
+	return &BackupService{
+		logger:          logger,
+		db:              db,
+		propertyService: propertyService,
+		notify:          notify,
+	}
+}
+
+// Start 按配置启动定时备份
+func (s *BackupService) Start(ctx context.Context) error {
+	s.cron = cron.New()
+	s.cron.Start()
+
+	config, err := s.GetConfig(ctx)
+	if err != nil {
+		return err
+	}
+	return s.schedule(config)
+}
+
+// schedule 重新设置定时备份任务
+func (s *BackupService) schedule(config models.BackupConfig) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.cron == nil {
+		return nil
+	}
+	if s.entryID != 0 {
+		s.cron.Remove(s.entryID)
+		s.entryID = 0
+	}
+	if !config.Enabled {
+		return nil
+	}
+
+	entryID, err := s.cron.AddFunc(config.Schedule, func() {
+		if _, err := s.Run(context.Background()); err != nil {
+			s.logger.Error("定时备份失败", zap.Error(err))
+			if s.notify != nil {
+				s.notify(context.Background(), fmt.Sprintf("数据库远程备份失败: %v", err))
+			}
+		}
+	})
+	if err != nil {
+		return fmt.Errorf("添加备份任务失败: %w", err)
+	}
+	s.entryID = entryID
+	s.logger.Info("定时备份已启用", zap.String("schedule", config.Schedule), zap.String("type", config.Type))
+	return nil
+}
+
+// GetConfig 获取远程备份配置，未配置时返回默认值
+func (s *BackupService) GetConfig(ctx context.Context) (models.BackupConfig, error) {
+	config := DefaultBackupConfig
+	if err := s.propertyService.GetValue(ctx, PropertyIDBackup, &config); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return DefaultBackupConfig, nil
+		}
+		return config, fmt.Errorf("获取远程备份配置失败: %w", err)
+	}
+	return config, nil
+}
+
+// UpdateConfig 校验并保存远程备份配置，立即按新配置重新设置定时任务
+func (s *BackupService) UpdateConfig(ctx context.Context, config models.BackupConfig) error {
+	if config.Schedule == "" {
+		config.Schedule = DefaultBackupSchedule
+	}
+	if config.Retention < 0 {
+		return fmt.Errorf("保留数量不能小于 0")
+	}
+	if _, err := cron.ParseStandard(config.Schedule); err != nil {
+		return fmt.Errorf("cron 表达式无效: %w", err)
+	}
+	if config.Enabled {
+		if _, err := newBackupTarget(config); err != nil {
+			return err
+		}
+	}
+
+	if err := s.propertyService.Set(ctx, PropertyIDBackup, "远程备份配置", config); err != nil {
+		return err
+	}
+	return s.schedule(config)
+}
+
+// LastResult 获取最近一次备份结果，从未执行时返回 nil
+func (s *BackupService) LastResult() *BackupResult {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.last
+}
+
+// Run 立即执行一次备份，同一时间只允许一个备份任务
+func (s *BackupService) Run(ctx context.Context) (*BackupResult, error) {
+	if !s.running.TryLock() {
+		return nil, fmt.Errorf("备份正在执行")
+	}
+	defer s.running.Unlock()
+
+	ctx, cancel := context.WithTimeout(ctx, backupTimeout)
+	defer cancel()
+
+	start := time.Now()
+	result := &BackupResult{
+		Name:      backupNamePrefix + start.Format(backupTimeLayout) + backupNameSuffix,
+		StartedAt: start.UnixMilli(),
+	}
+	err := s.run(ctx, result)
+	result.DurationMs = time.Since(start).Milliseconds()
+	if err != nil {
+		result.Error = err.Error()
+	}
+
+	s.mu.Lock()
+	s.last = result
+	s.mu.Unlock()
+
+	if err != nil {
+		return result, fmt.Errorf("备份失败: %w", err)
+	}
+	s.logger.Info("远程备份完成",
+		zap.String("name", result.Name),
+		zap.String("target", result.Target),
+		zap.Int64("size", result.Size),
+		zap.Int64("duration_ms", result.DurationMs))
+	return result, nil
+}
+
+func (s *BackupService) run(ctx context.Context, result *BackupResult) error {
+	config, err := s.GetConfig(ctx)
+	if err != nil {
+		return err
+	}
+	result.Target = config.Type
+	target, err := newBackupTarget(config)
+	if err != nil {
+		return err
+	}
+
+	dir, err := os.MkdirTemp("", "uart-sms-backup-")
+	if err != nil {
+		return fmt.Errorf("创建临时目录失败: %w", err)
+	}
+	defer os.RemoveAll(dir)
+
+	archive, err := s.createArchive(ctx, dir)
+	if err != nil {
+		return err
+	}
+	defer archive.Close()
+	stat, err := archive.Stat()
+	if err != nil {
+		return err
+	}
+	result.Size = stat.Size()
+
+	if err := target.Upload(ctx, result.Name, archive, result.Size); err != nil {
+		return fmt.Errorf("上传备份失败: %w", err)
+	}
+
+	// 清理失败不影响本次备份结果
+	deleted, err := s.prune(ctx, target, config.Retention)
+	result.Deleted = deleted
+	if err != nil {
+		s.logger.Warn("清理旧备份失败", zap.Error(err))
+	}
+	return nil
+}
+
+// createArchive 生成数据库快照并 gzip 压缩，返回定位到开头的压缩文件
+func (s *BackupService) createArchive(ctx context.Context, dir string) (*os.File, error) {
+	db := s.db.WithContext(ctx)
+	if dialect := db.Dialector.Name(); dialect != "sqlite" {
+		return nil, fmt.Errorf("远程备份仅支持 SQLite，当前数据库: %s", dialect)
+	}
+
+	// VACUUM INTO 在不阻塞写入的情况下生成一致的数据库快照
+	snapshot := filepath.Join(dir, "snapshot.db")
+	if err := db.Exec("VACUUM INTO ?", snapshot).Error; err != nil {
+		return nil, fmt.Errorf("生成数据库快照失败: %w", err)
+	}
+
+	src, err := os.Open(snapshot)
+	if err != nil {
+		return nil, err
+	}
+	defer src.Close()
+
+	archive, err := os.Create(filepath.Join(dir, "snapshot.db.gz"))
+	if err != nil {
+		return nil, err
+	}
+	zw := gzip.NewWriter(archive)
+	if _, err := io.Copy(zw, src); err != nil {
+		archive.Close()
+		return nil, fmt.Errorf("压缩数据库快照失败: %w", err)
+	}
+	if err := zw.Close(); err != nil {
+		archive.Close()
+		return nil, fmt.Errorf("压缩数据库快照失败: %w", err)
+	}
+	if _, err := archive.Seek(0, io.SeekStart); err != nil {
+		archive.Close()
+		return nil, err
+	}
+	return archive, nil
+}
+
+// prune 保留最新的 retention 个备份，删除更旧的
+func (s *BackupService) prune(ctx context.Context, target backupTarget, retention int) ([]string, error) {
+	if retention <= 0 {
+		return nil, nil
+	}
+	names, err := target.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var backups []string
+	for _, name := range names {
+		if strings.HasPrefix(name, backupNamePrefix) && strings.HasSuffix(name, backupNameSuffix) {
+			backups = append(backups, name)
+		}
+	}
+	if len(backups) <= retention {
+		return nil, nil
+	}
+	// 文件名中的时间可按字典序排序
+	sort.Strings(backups)
+
+	var deleted []string
+	for _, name := range backups[:len(backups)-retention] {
+		if err := target.Delete(ctx, name); err != nil {
+			return deleted, fmt.Errorf("删除旧备份 %s 失败: %w", name, err)
+		}
+		deleted = append(deleted, name)
+		s.logger.Info("已删除旧备份", zap.String("name", name))
+	}
+	return deleted, nil
+}
+
+// newBackupTarget 按配置创建备份目标
+func newBackupTarget(config models.BackupConfig) (backupTarget, error) {
+	switch config.Type {
+	case "s3":
+		return newS3BackupTarget(config.S3)
+	case "webdav":
+		return newWebDAVBackupTarget(config.WebDAV)
+	default:
+		return nil, fmt.Errorf("不支持的备份目标类型: %s", config.Type)
+	}
+}
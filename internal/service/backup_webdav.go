@@ -0,0 +1,123 @@
+package service
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/dushixiang/uart_sms_forwarder/internal/models"
+)
+
+// webdavBackupTarget WebDAV 存储，备份文件直接放在配置的目录下
+type webdavBackupTarget struct {
+	config models.WebDAVBackupConfig
+	dir    *url.URL
+}
+
+func newWebDAVBackupTarget(config models.WebDAVBackupConfig) (*webdavBackupTarget, error) {
+	dir, err := url.Parse(config.URL)
+	if err != nil || dir.Host == "" || (dir.Scheme != "http" && dir.Scheme != "https") {
+		return nil, fmt.Errorf("WebDAV 地址无效: %s", config.URL)
+	}
+	if !strings.HasSuffix(dir.Path, "/") {
+		dir.Path += "/"
+		dir.RawPath = ""
+	}
+	return &webdavBackupTarget{config: config, dir: dir}, nil
+}
+
+func (t *webdavBackupTarget) fileURL(name string) string {
+	return t.dir.JoinPath(name).String()
+}
+
+func (t *webdavBackupTarget) Upload(ctx context.Context, name string, file *os.File, size int64) error {
+	// 目录不存在时创建，已存在时服务端返回 405，忽略错误
+	if req, err := http.NewRequestWithContext(ctx, "MKCOL", t.dir.String(), nil); err == nil {
+		if resp, err := t.send(req); err == nil {
+			resp.Body.Close()
+		}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, t.fileURL(name), file)
+	if err != nil {
+		return err
+	}
+	req.ContentLength = size
+	req.Header.Set("Content-Type", "application/gzip")
+	_, err = t.do(req)
+	return err
+}
+
+// webdavMultistatus PROPFIND 响应
+type webdavMultistatus struct {
+	Responses []struct {
+		Href string `xml:"href"`
+	} `xml:"response"`
+}
+
+func (t *webdavBackupTarget) List(ctx context.Context) ([]string, error) {
+	body := `<?xml version="1.0" encoding="utf-8"?><d:propfind xmlns:d="DAV:"><d:prop><d:resourcetype/></d:prop></d:propfind>`
+	req, err := http.NewRequestWithContext(ctx, "PROPFIND", t.dir.String(), strings.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Depth", "1")
+	req.Header.Set("Content-Type", "application/xml; charset=utf-8")
+	respBody, err := t.do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	var result webdavMultistatus
+	if err := xml.Unmarshal(respBody, &result); err != nil {
+		return nil, fmt.Errorf("解析 WebDAV 列表失败: %w", err)
+	}
+	var names []string
+	for _, response := range result.Responses {
+		href, err := url.Parse(response.Href)
+		if err != nil || strings.HasSuffix(href.Path, "/") {
+			continue
+		}
+		names = append(names, path.Base(href.Path))
+	}
+	return names, nil
+}
+
+func (t *webdavBackupTarget) Delete(ctx context.Context, name string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodDelete, t.fileURL(name), nil)
+	if err != nil {
+		return err
+	}
+	_, err = t.do(req)
+	return err
+}
+
+func (t *webdavBackupTarget) send(req *http.Request) (*http.Response, error) {
+	if t.config.Username != "" {
+		req.SetBasicAuth(t.config.Username, t.config.Password)
+	}
+	return http.DefaultClient.Do(req)
+}
+
+// do 发送请求，返回响应内容
+func (t *webdavBackupTarget) do(req *http.Request) ([]byte, error) {
+	resp, err := t.send(req)
+	if err != nil {
+		return nil, fmt.Errorf("发送请求失败: %w", err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 10*1024*1024))
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("请求失败，状态码: %d, 响应: %s", resp.StatusCode, string(body))
+	}
+	return body, nil
+}
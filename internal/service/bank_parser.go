@@ -0,0 +1,147 @@
+package service
+
+import (
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/dushixiang/uart_sms_forwarder/internal/models"
+)
+
+// bankSenders 银行客服号码 -> 银行名称
+var bankSenders = map[string]string{
+	"95588": "工商银行",
+	"95533": "建设银行",
+	"95599": "农业银行",
+	"95566": "中国银行",
+	"95555": "招商银行",
+	"95559": "交通银行",
+	"95580": "邮储银行",
+	"95561": "兴业银行",
+	"95568": "民生银行",
+	"95595": "光大银行",
+	"95528": "浦发银行",
+	"95558": "中信银行",
+	"95511": "平安银行",
+	"95508": "广发银行",
+	"95577": "华夏银行",
+}
+
+var (
+	// 短信签名，如【招商银行】
+	bankSignatureRe = regexp.MustCompile(`【([^】]{2,12}银行)[^】]*】`)
+	// 卡号尾号，如 尾号1234、*1234
+	bankAccountRe = regexp.MustCompile(`(?:尾号|末四位|账户|账号|卡号|卡)\s*\**(\d{4})(?:\D|$)`)
+	// 金额，关键字与金额之间允许少量修饰文字
+	bankExpenseRe = regexp.MustCompile(`(?:支出|消费|支取|转出|扣款|扣费|付款|支付|取款)[^0-9余]{0,20}?(?:人民币|RMB|CNY)?\s*([0-9][0-9,]*(?:\.\d{1,2})?)\s*元?`)
+	bankIncomeRe  = regexp.MustCompile(`(?:收入|存入|转入|入账|到账|汇入|退款|退货|工资)[^0-9余]{0,20}?(?:人民币|RMB|CNY)?\s*([0-9][0-9,]*(?:\.\d{1,2})?)\s*元?`)
+	bankBalanceRe = regexp.MustCompile(`(?:可用)?余额[为是:：]?\s*(?:人民币|RMB|CNY)?\s*([0-9][0-9,]*(?:\.\d{1,2})?)`)
+	// 商户，如 在星巴克消费、商户：美团
+	bankMerchantRes = []*regexp.Regexp{
+		regexp.MustCompile(`(?:商户|对方户名|对方|交易对手)[:：]?\s*([^，,。;；\s()（）]{2,30})`),
+		regexp.MustCompile(`在\s*([^，,。;；\s]{2,30}?)\s*(?:消费|支付|交易|付款|快捷支付|网上支付)`),
+		regexp.MustCompile(`[（(]([^）)]{2,30})[）)]`),
+	}
+)
+
+// parseBankSMS 解析银行通知短信，无法识别为交易时返回 nil
+func parseBankSMS(from, content string) *models.Transaction {
+	bank := detectBank(from, content)
+	if bank == "" {
+		return nil
+	}
+
+	// 验证码、营销短信中也可能出现金额，只处理带余额或账户尾号的动账通知
+	account := ""
+	if m := bankAccountRe.FindStringSubmatch(content); m != nil {
+		account = m[1]
+	}
+	balance := parseBalance(content)
+	if account == "" && balance == nil {
+		return nil
+	}
+	if strings.Contains(content, "验证码") || strings.Contains(content, "校验码") {
+		return nil
+	}
+
+	tx := &models.Transaction{
+		Bank:    bank,
+		Account: account,
+		Balance: balance,
+	}
+
+	// 以先出现的关键字判断收支方向
+	expense := bankExpenseRe.FindStringSubmatchIndex(content)
+	income := bankIncomeRe.FindStringSubmatchIndex(content)
+	var amountText string
+	switch {
+	case expense != nil && (income == nil || expense[0] < income[0]):
+		tx.Direction = models.TransactionExpense
+		amountText = content[expense[2]:expense[3]]
+	case income != nil:
+		tx.Direction = models.TransactionIncome
+		amountText = content[income[2]:income[3]]
+	default:
+		return nil
+	}
+
+	amount, ok := parseAmount(amountText)
+	if !ok || amount == 0 {
+		return nil
+	}
+	tx.Amount = amount
+	tx.Merchant = parseMerchant(content)
+	return tx
+}
+
+// detectBank 根据发送方号码或短信签名识别银行
+func detectBank(from, content string) string {
+	number := strings.TrimPrefix(strings.TrimPrefix(from, "+86"), "106")
+	for prefix, bank := range bankSenders {
+		if strings.HasPrefix(number, prefix) || strings.HasPrefix(from, prefix) {
+			return bank
+		}
+	}
+	if m := bankSignatureRe.FindStringSubmatch(content); m != nil {
+		return m[1]
+	}
+	return ""
+}
+
+// parseBalance 解析余额
+func parseBalance(content string) *int64 {
+	m := bankBalanceRe.FindStringSubmatch(content)
+	if m == nil {
+		return nil
+	}
+	balance, ok := parseAmount(m[1])
+	if !ok {
+		return nil
+	}
+	return &balance
+}
+
+// parseMerchant 解析商户或交易对方
+func parseMerchant(content string) string {
+	// 去掉短信签名，避免把【xx银行】识别为商户
+	content = bankSignatureRe.ReplaceAllString(content, "")
+	for _, re := range bankMerchantRes {
+		if m := re.FindStringSubmatch(content); m != nil {
+			merchant := strings.TrimSpace(m[1])
+			if merchant != "" && !strings.Contains(merchant, "尾号") {
+				return merchant
+			}
+		}
+	}
+	return ""
+}
+
+// parseAmount 将金额文本转换为分
+func parseAmount(text string) (int64, bool) {
+	v, err := strconv.ParseFloat(strings.ReplaceAll(text, ",", ""), 64)
+	if err != nil || v < 0 {
+		return 0, false
+	}
+	return int64(math.Round(v * 100)), true
+}
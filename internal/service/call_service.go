@@ -0,0 +1,227 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/dushixiang/uart_sms_forwarder/internal/models"
+	"github.com/dushixiang/uart_sms_forwarder/internal/repo"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// PropertyIDCallPolicy 来电处理配置
+const PropertyIDCallPolicy = "call_policy"
+
+// CallLogFilter 来电记录筛选条件
+type CallLogFilter struct {
+	From     string            // 来电号码，为空时不筛选
+	Action   models.CallAction // 处理方式，为空时不筛选
+	DeviceID string            // 设备 ID，为空时不筛选
+	Since    int64             // 起始时间（时间戳毫秒），为 0 时不限制
+	Until    int64             // 截止时间（时间戳毫秒），为 0 时不限制
+}
+
+// CallLogPage 来电记录键集分页结果
+type CallLogPage struct {
+	Items      []models.CallLog `json:"items"`
+	NextCursor string           `json:"nextCursor"` // 下一页（更早的记录）游标，没有更多时为空
+	HasMore    bool             `json:"hasMore"`
+}
+
+// CallService 来电处理服务：按来电规则决定通知、忽略或挂断，并保存来电记录
+type CallService struct {
+	logger          *zap.Logger
+	propertyService *PropertyService
+	ruleRepo        *repo.CallRuleRepo
+	logRepo         *repo.CallLogRepo
+	visibility      *VisibilityService
+}
+
+// NewCallService 创建来电处理服务实例
+func NewCallService(logger *zap.Logger, db *gorm.DB, propertyService *PropertyService) *CallService {
+	return &CallService{
+		logger:          logger,
+		propertyService: propertyService,
+		ruleRepo:        repo.NewCallRuleRepo(db),
+		logRepo:         repo.NewCallLogRepo(db),
+	}
+}
+
+// SetVisibilityService 设置会话可见性服务，设置后普通用户看不到隐藏会话号码的来电记录
+func (s *CallService) SetVisibilityService(visibility *VisibilityService) {
+	s.visibility = visibility
+}
+
+// GetAllRules 获取所有来电规则
+func (s *CallService) GetAllRules(ctx context.Context) ([]models.CallRule, error) {
+	return s.ruleRepo.FindAll(ctx)
+}
+
+// GetRuleById 根据ID获取来电规则
+func (s *CallService) GetRuleById(ctx context.Context, id string) (*models.CallRule, error) {
+	rule, err := s.ruleRepo.FindById(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return &rule, nil
+}
+
+// CreateRule 创建来电规则
+func (s *CallService) CreateRule(ctx context.Context, rule *models.CallRule) error {
+	now := time.Now().UnixMilli()
+	rule.ID = uuid.NewString()
+	rule.CreatedAt = now
+	rule.UpdatedAt = now
+	return s.ruleRepo.Create(ctx, rule)
+}
+
+// UpdateRule 更新来电规则
+func (s *CallService) UpdateRule(ctx context.Context, rule *models.CallRule) error {
+	existing, err := s.GetRuleById(ctx, rule.ID)
+	if err != nil {
+		return err
+	}
+	existing.Pattern = rule.Pattern
+	existing.Action = rule.Action
+	existing.Enabled = rule.Enabled
+	existing.Remark = rule.Remark
+	existing.UpdatedAt = time.Now().UnixMilli()
+	if err := s.ruleRepo.Save(ctx, existing); err != nil {
+		return err
+	}
+	*rule = *existing
+	return nil
+}
+
+// DeleteRule 删除来电规则
+func (s *CallService) DeleteRule(ctx context.Context, id string) error {
+	return s.ruleRepo.DeleteById(ctx, id)
+}
+
+// ValidateCallRule 校验来电规则字段
+func ValidateCallRule(rule *models.CallRule) error {
+	rule.Pattern = strings.ReplaceAll(strings.TrimSpace(rule.Pattern), " ", "")
+	rule.Remark = strings.TrimSpace(rule.Remark)
+	if rule.Pattern == "" {
+		return fmt.Errorf("号码模式不能为空")
+	}
+	if _, err := path.Match(rule.Pattern, ""); err != nil {
+		return fmt.Errorf("号码模式格式错误: %s", rule.Pattern)
+	}
+	if !rule.Action.Valid() {
+		return fmt.Errorf("处理方式只能是 notify、ignore 或 hangup")
+	}
+	return nil
+}
+
+// Decide 决定来电的处理方式：匹配模式最长的启用规则，未匹配时使用配置的默认处理方式。
+// 读取规则失败时按 notify 处理，避免漏掉来电
+func (s *CallService) Decide(ctx context.Context, from string) (models.CallAction, *models.CallRule) {
+	rules, err := s.ruleRepo.FindAllEnabled(ctx)
+	if err != nil {
+		s.logger.Error("获取来电规则失败", zap.Error(err))
+		return models.CallActionNotify, nil
+	}
+	if rule := matchCallRule(rules, from); rule != nil {
+		return rule.Action, rule
+	}
+
+	var policy models.CallPolicyConfig
+	if err := s.propertyService.GetValue(ctx, PropertyIDCallPolicy, &policy); err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		s.logger.Error("获取来电处理配置失败", zap.Error(err))
+	}
+	if policy.DefaultAction.Valid() {
+		return policy.DefaultAction, nil
+	}
+	return models.CallActionNotify, nil
+}
+
+// Record 保存来电记录，保存失败只记录日志，不影响来电通知
+func (s *CallService) Record(ctx context.Context, log *models.CallLog) {
+	log.ID = uuid.NewString()
+	if err := s.logRepo.Create(ctx, log); err != nil {
+		s.logger.Error("保存来电记录失败", zap.String("from", log.From), zap.Error(err))
+	}
+}
+
+// End 记录设备最近一次来电的结束时间
+func (s *CallService) End(ctx context.Context, deviceID string, endedAt int64) {
+	if err := s.logRepo.EndLatest(ctx, deviceID, endedAt); err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		s.logger.Error("更新来电结束时间失败", zap.String("device", deviceID), zap.Error(err))
+	}
+}
+
+// List 按时间倒序分页查询来电记录
+func (s *CallService) List(ctx context.Context, filter CallLogFilter, cursor string, limit int) (*CallLogPage, error) {
+	c, err := decodeCursor(cursor)
+	if err != nil {
+		return nil, err
+	}
+	limit = normalizeLimit(limit)
+	visible := noScope
+	if s.visibility != nil {
+		if visible, err = s.visibility.CallScope(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	logs, err := s.logRepo.FindBefore(ctx, func(db *gorm.DB) *gorm.DB {
+		db = db.Scopes(visible)
+		if filter.From != "" {
+			db = db.Where(`"from" = ?`, filter.From)
+		}
+		if filter.Action != "" {
+			db = db.Where("action = ?", filter.Action)
+		}
+		if filter.DeviceID != "" {
+			db = db.Where("device_id = ?", filter.DeviceID)
+		}
+		if filter.Since > 0 {
+			db = db.Where("created_at >= ?", filter.Since)
+		}
+		if filter.Until > 0 {
+			db = db.Where("created_at < ?", filter.Until)
+		}
+		return db
+	}, c, limit+1)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &CallLogPage{Items: logs}
+	if len(logs) > limit {
+		page.Items = logs[:limit]
+		page.HasMore = true
+		last := page.Items[limit-1]
+		page.NextCursor = encodeCursorAt(last.CreatedAt, last.ID)
+	}
+	if page.Items == nil {
+		page.Items = []models.CallLog{}
+	}
+	return page, nil
+}
+
+// matchCallRule 号码同时以原始格式和去掉 +86 的格式匹配，多条匹配时取模式最长（最具体）的一条
+func matchCallRule(rules []models.CallRule, number string) *models.CallRule {
+	raw := strings.NewReplacer(" ", "", "-", "").Replace(number)
+	normalized := normalizePhone(number)
+
+	var best *models.CallRule
+	for i := range rules {
+		rule := &rules[i]
+		ok, _ := path.Match(rule.Pattern, raw)
+		if !ok {
+			ok, _ = path.Match(rule.Pattern, normalized)
+		}
+		if ok && (best == nil || len(rule.Pattern) > len(best.Pattern)) {
+			best = rule
+		}
+	}
+	return best
+}
@@ -0,0 +1,60 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+
+	"github.com/dushixiang/uart_sms_forwarder/internal/models"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// PropertyIDChannelGroups 通知渠道组配置
+const PropertyIDChannelGroups = "channel_groups"
+
+// channelGroupPrefix 渠道列表中以该前缀引用渠道组，如 "@critical"
+const channelGroupPrefix = "@"
+
+// expandChannelGroups 将渠道列表中的 "@组名" 展开为组内的渠道类型并去重，不存在的渠道组忽略并记录日志
+func (s *SerialService) expandChannelGroups(ctx context.Context, channels []string) []string {
+	if !slices.ContainsFunc(channels, isChannelGroupRef) {
+		return channels
+	}
+
+	var groups []models.ChannelGroup
+	if err := s.propertyService.GetValue(ctx, PropertyIDChannelGroups, &groups); err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		s.logger.Error("获取通知渠道组失败", zap.Error(err))
+	}
+
+	expanded := make([]string, 0, len(channels))
+	add := func(channel string) {
+		if !slices.Contains(expanded, channel) {
+			expanded = append(expanded, channel)
+		}
+	}
+	for _, channel := range channels {
+		if !isChannelGroupRef(channel) {
+			add(channel)
+			continue
+		}
+		name := strings.TrimPrefix(channel, channelGroupPrefix)
+		index := slices.IndexFunc(groups, func(group models.ChannelGroup) bool {
+			return group.Name == name
+		})
+		if index < 0 {
+			s.logger.Warn("通知渠道组不存在", zap.String("group", name))
+			continue
+		}
+		for _, member := range groups[index].Channels {
+			add(member)
+		}
+	}
+	return expanded
+}
+
+// isChannelGroupRef 是否为渠道组引用
+func isChannelGroupRef(channel string) bool {
+	return strings.HasPrefix(channel, channelGroupPrefix)
+}
@@ -0,0 +1,71 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/dushixiang/uart_sms_forwarder/internal/models"
+	"github.com/dushixiang/uart_sms_forwarder/internal/repo"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// ConversationSettingService 会话设置服务。
+// 号码分类规则按号码模式批量路由，会话设置针对单个号码，优先于规则生效。
+type ConversationSettingService struct {
+	logger *zap.Logger
+	repo   *repo.ConversationSettingRepo
+}
+
+// NewConversationSettingService 创建会话设置服务实例
+func NewConversationSettingService(logger *zap.Logger, db *gorm.DB) *ConversationSettingService {
+	return &ConversationSettingService{
+		logger: logger,
+		repo:   repo.NewConversationSettingRepo(db),
+	}
+}
+
+// List 获取所有会话设置
+func (s *ConversationSettingService) List(ctx context.Context) ([]models.ConversationSetting, error) {
+	return s.repo.FindAll(ctx)
+}
+
+// Save 保存会话设置，覆盖原有设置
+func (s *ConversationSettingService) Save(ctx context.Context, setting *models.ConversationSetting) error {
+	setting.Peer = normalizePhone(strings.TrimSpace(setting.Peer))
+	if setting.Peer == "" {
+		return fmt.Errorf("号码不能为空")
+	}
+	setting.Channels = normalizeTags(setting.Channels)
+	if !setting.Priority.Valid() {
+		return fmt.Errorf("通知优先级无效: %s", setting.Priority)
+	}
+
+	now := time.Now().UnixMilli()
+	setting.CreatedAt = now
+	setting.UpdatedAt = now
+	if existing, err := s.repo.FindById(ctx, setting.Peer); err == nil {
+		setting.CreatedAt = existing.CreatedAt
+	}
+	return s.repo.Save(ctx, setting)
+}
+
+// Delete 删除会话设置，恢复按号码分类规则路由
+func (s *ConversationSettingService) Delete(ctx context.Context, peer string) error {
+	return s.repo.DeleteById(ctx, normalizePhone(peer))
+}
+
+// Match 获取号码的会话设置，未设置时返回 nil
+func (s *ConversationSettingService) Match(ctx context.Context, number string) *models.ConversationSetting {
+	setting, err := s.repo.FindById(ctx, normalizePhone(number))
+	if err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			s.logger.Error("获取会话设置失败", zap.String("peer", number), zap.Error(err))
+		}
+		return nil
+	}
+	return &setting
+}
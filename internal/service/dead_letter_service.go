@@ -0,0 +1,149 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/dushixiang/uart_sms_forwarder/internal/models"
+	"github.com/dushixiang/uart_sms_forwarder/internal/repo"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+const (
+	// deadLetterKeep 最多保留的无法解析帧数量，设备持续输出异常数据时避免数据库无限增长
+	deadLetterKeep = 1000
+	// deadLetterListLimit 列表接口默认返回的数量
+	deadLetterListLimit = 200
+)
+
+var (
+	// ErrDeadLetterReprocessed 帧已重新处理成功，不再重复处理，避免重复保存短信和发送通知
+	ErrDeadLetterReprocessed = errors.New("该帧已处理成功")
+	// ErrDeadLetterInvalid 重新解析帧仍然失败
+	ErrDeadLetterInvalid = errors.New("解析仍然失败")
+)
+
+// DeadLetterService 保存串口上无法解析的消息帧，修复解析逻辑后可重新处理
+type DeadLetterService struct {
+	logger        *zap.Logger
+	repo          *repo.DeadLetterRepo
+	serialService *SerialService
+}
+
+// ReprocessResult 批量重新处理结果
+type ReprocessResult struct {
+	Processed int                      `json:"processed"` // 处理成功的数量
+	Failed    []models.DeadLetterFrame `json:"failed"`    // 仍然无法解析的帧
+}
+
+// NewDeadLetterService 创建无法解析帧服务实例
+func NewDeadLetterService(logger *zap.Logger, db *gorm.DB, serialService *SerialService) *DeadLetterService {
+	return &DeadLetterService{
+		logger:        logger,
+		repo:          repo.NewDeadLetterRepo(db),
+		serialService: serialService,
+	}
+}
+
+// Record 保存解析失败的帧
+func (s *DeadLetterService) Record(ctx context.Context, data string, parseErr error) {
+	frame := &models.DeadLetterFrame{
+		ID:        uuid.NewString(),
+		Data:      data,
+		Error:     parseErr.Error(),
+		CreatedAt: time.Now().UnixMilli(),
+	}
+	if err := s.repo.Create(ctx, frame); err != nil {
+		s.logger.Error("保存无法解析的串口帧失败", zap.Error(err))
+		return
+	}
+	if err := s.repo.DeleteBeyond(ctx, deadLetterKeep); err != nil {
+		s.logger.Warn("清理无法解析的串口帧失败", zap.Error(err))
+	}
+}
+
+// List 获取最近的无法解析帧，仅管理员可用
+func (s *DeadLetterService) List(ctx context.Context, pendingOnly bool) ([]models.DeadLetterFrame, error) {
+	if err := requireAdmin(ctx); err != nil {
+		return nil, err
+	}
+	return s.repo.FindRecent(ctx, pendingOnly, deadLetterListLimit)
+}
+
+// Reprocess 重新解析指定帧，解析成功后按正常消息处理，仅管理员可用。
+// 解析仍然失败时返回更新了失败原因的帧和错误
+func (s *DeadLetterService) Reprocess(ctx context.Context, id string) (*models.DeadLetterFrame, error) {
+	if err := requireAdmin(ctx); err != nil {
+		return nil, err
+	}
+	frame, err := s.repo.FindById(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if frame.ReprocessedAt != 0 {
+		return &frame, ErrDeadLetterReprocessed
+	}
+	return &frame, s.reprocess(ctx, &frame)
+}
+
+// ReprocessPending 按收到顺序重新处理所有尚未处理成功的帧，仅管理员可用
+func (s *DeadLetterService) ReprocessPending(ctx context.Context) (*ReprocessResult, error) {
+	if err := requireAdmin(ctx); err != nil {
+		return nil, err
+	}
+	frames, err := s.repo.FindPending(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &ReprocessResult{Failed: []models.DeadLetterFrame{}}
+	for i := range frames {
+		if err := s.reprocess(ctx, &frames[i]); err != nil {
+			result.Failed = append(result.Failed, frames[i])
+			continue
+		}
+		result.Processed++
+	}
+	s.logger.Info("重新处理无法解析的串口帧",
+		zap.Int("processed", result.Processed),
+		zap.Int("failed", len(result.Failed)))
+	return result, nil
+}
+
+// Delete 删除帧，仅管理员可用
+func (s *DeadLetterService) Delete(ctx context.Context, id string) error {
+	if err := requireAdmin(ctx); err != nil {
+		return err
+	}
+	return s.repo.DeleteById(ctx, id)
+}
+
+// reprocess 重新解析帧并更新记录，解析成功时交给串口消息路由处理
+func (s *DeadLetterService) reprocess(ctx context.Context, frame *models.DeadLetterFrame) error {
+	frame.Attempts++
+	msg, parseErr := parseSMSFrame(frame.Data)
+	if parseErr != nil {
+		frame.Error = parseErr.Error()
+	} else {
+		frame.ReprocessedAt = time.Now().UnixMilli()
+	}
+
+	if err := s.repo.UpdateColumnsById(ctx, frame.ID, map[string]interface{}{
+		"attempts":       frame.Attempts,
+		"error":          frame.Error,
+		"reprocessed_at": frame.ReprocessedAt,
+	}); err != nil {
+		return fmt.Errorf("更新串口帧记录失败: %w", err)
+	}
+	if parseErr != nil {
+		return fmt.Errorf("%w: %v", ErrDeadLetterInvalid, parseErr)
+	}
+
+	s.logger.Info("重新处理串口帧", zap.String("id", frame.ID), zap.String("type", msg.Type))
+	s.serialService.routeMessage(msg)
+	return nil
+}
@@ -0,0 +1,82 @@
+package service
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// debugEchoKeep 最多保留的请求数量
+	debugEchoKeep = 50
+	// DebugEchoMaxBody 每个请求最多保存的请求体字节数
+	DebugEchoMaxBody = 64 * 1024
+)
+
+// EchoRequest 调试接口收到的请求
+type EchoRequest struct {
+	ID            string            `json:"id"`
+	Method        string            `json:"method"`
+	Path          string            `json:"path"`
+	Query         string            `json:"query,omitempty"`
+	Headers       map[string]string `json:"headers"`
+	Body          string            `json:"body"`
+	BodyTruncated bool              `json:"bodyTruncated,omitempty"` // 请求体超过 64 KB 时只保存前 64 KB
+	RemoteIP      string            `json:"remoteIp"`
+	ReceivedAt    int64             `json:"receivedAt"` // 收到时间（时间戳毫秒）
+}
+
+// DebugEchoService 保存最近收到的调试请求，只保存在内存中，重启后清空。
+// 配置自定义 Webhook 时可将地址指向本机的 /api/debug/echo，查看模板实际生成的请求。
+type DebugEchoService struct {
+	mu       sync.Mutex
+	requests []EchoRequest
+}
+
+// NewDebugEchoService 创建调试请求记录服务实例
+func NewDebugEchoService() *DebugEchoService {
+	return &DebugEchoService{}
+}
+
+// Record 保存一个请求，超过保留数量时丢弃最早的请求
+func (s *DebugEchoService) Record(req EchoRequest) EchoRequest {
+	req.ID = uuid.NewString()
+	req.ReceivedAt = time.Now().UnixMilli()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.requests = append(s.requests, req)
+	if len(s.requests) > debugEchoKeep {
+		s.requests = slices.Delete(s.requests, 0, len(s.requests)-debugEchoKeep)
+	}
+	return req
+}
+
+// List 获取最近收到的请求，最新的在前
+func (s *DebugEchoService) List(ctx context.Context) ([]EchoRequest, error) {
+	if err := requireAdmin(ctx); err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	result := slices.Clone(s.requests)
+	slices.Reverse(result)
+	if result == nil {
+		result = []EchoRequest{}
+	}
+	return result, nil
+}
+
+// Clear 清空已保存的请求
+func (s *DebugEchoService) Clear(ctx context.Context) error {
+	if err := requireAdmin(ctx); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.requests = nil
+	return nil
+}
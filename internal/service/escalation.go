@@ -0,0 +1,119 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/dushixiang/uart_sms_forwarder/internal/models"
+	"go.uber.org/zap"
+)
+
+// defaultEscalateAfter 号码分类规则未设置时，未确认通知升级前的等待时间
+const defaultEscalateAfter = 10 * time.Minute
+
+// escalateAfter 将规则中的分钟数转换为等待时间
+func escalateAfter(minutes int) time.Duration {
+	if minutes <= 0 {
+		return defaultEscalateAfter
+	}
+	return time.Duration(minutes) * time.Minute
+}
+
+// escalatedChannels 记录每条短信已发送过升级提醒的渠道，确认后通知这些渠道短信已有人处理。
+// 与升级状态一样只保存在内存中
+type escalatedChannels struct {
+	mu       sync.Mutex
+	channels map[string][]string
+}
+
+// add 记录已发送升级提醒的渠道
+func (e *escalatedChannels) add(id, channel string) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.channels == nil {
+		e.channels = make(map[string][]string)
+	}
+	e.channels[id] = append(e.channels[id], channel)
+}
+
+// take 取出并清除已发送升级提醒的渠道
+func (e *escalatedChannels) take(id string) []string {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	channels := e.channels[id]
+	delete(e.channels, id)
+	return channels
+}
+
+// scheduleEscalation 等待短信被确认，超时未确认时改发升级链中的下一个渠道。
+// 升级状态只保存在内存中，程序重启后不再继续升级。
+func (s *SerialService) scheduleEscalation(msg NotificationMessage, chain []string, after time.Duration) {
+	if len(chain) == 0 || msg.ID == "" {
+		return
+	}
+	s.escalations.track(msg.ID, after, func() {
+		s.escalate(msg, chain, after)
+	})
+}
+
+// escalate 短信仍未确认时发送到升级链的第一个渠道，并继续等待剩余的渠道
+func (s *SerialService) escalate(msg NotificationMessage, chain []string, after time.Duration) {
+	ctx := context.Background()
+	record, err := s.textMsgService.Get(ctx, msg.ID)
+	if err != nil {
+		// 短信已删除时不再升级
+		s.logger.Info("短信不存在，停止升级通知", zap.String("id", msg.ID), zap.Error(err))
+		return
+	}
+	if record.AckedAt > 0 {
+		return
+	}
+
+	channel := chain[0]
+	s.logger.Warn("短信未确认，升级通知",
+		zap.String("id", msg.ID),
+		zap.String("from", msg.From),
+		zap.String("channel", channel))
+
+	escalated := msg
+	escalated.Channels = []string{channel}
+	escalated.Content = fmt.Sprintf("【未确认提醒】以下短信 %d 分钟内无人确认，确认后停止提醒：POST /api/ack/%s\n%s",
+		int(time.Since(time.UnixMilli(record.CreatedAt)).Minutes()), msg.ID, msg.Content)
+	s.sendNotificationMessage(ctx, escalated)
+	s.escalated.add(msg.ID, channel)
+
+	s.scheduleEscalation(msg, chain[1:], after)
+}
+
+// AckMessage 确认已处理短信，记录当前用户为确认人，停止后续的升级通知。
+// 已发送过升级提醒时通知这些渠道短信已有人处理；已被其他人确认时返回原确认记录
+func (s *SerialService) AckMessage(ctx context.Context, id, note string) (*models.TextMessage, error) {
+	by := "api"
+	if viewer, ok := ViewerFrom(ctx); ok && viewer.Username != "" {
+		by = viewer.Username
+	}
+	msg, acked, err := s.textMsgService.Ack(ctx, id, by, note)
+	if err != nil {
+		return nil, err
+	}
+	if !acked {
+		return msg, nil
+	}
+
+	s.logger.Info("短信已确认", zap.String("id", id), zap.String("acked_by", by))
+	if s.escalations.resolve(id) {
+		s.logger.Info("短信已确认，取消升级通知", zap.String("id", id))
+	}
+	s.publishAckEvent(msg)
+
+	if channels := s.escalated.take(id); len(channels) > 0 {
+		content := fmt.Sprintf("✓ %s 已确认处理来自 %s 的短信，停止提醒", by, msg.From)
+		if note != "" {
+			content += "\n备注: " + note
+		}
+		go s.SendEventNotificationTo(context.Background(), EventSMS, content, channels)
+	}
+	return msg, nil
+}
@@ -0,0 +1,170 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"sync"
+
+	"github.com/dushixiang/uart_sms_forwarder/internal/models"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// PropertyIDExtractionRules 字段提取规则
+const PropertyIDExtractionRules = "extraction_rules"
+
+// DefaultExtractionRules 默认字段提取规则
+var DefaultExtractionRules = []models.ExtractionRule{
+	{
+		Name:    "验证码",
+		Enabled: true,
+		Pattern: `(?:验证码|校验码|动态码|code)[^0-9A-Za-z]{0,10}(?P<code>[0-9]{4,8})`,
+	},
+}
+
+// compiledRule 编译后的提取规则
+type compiledRule struct {
+	name    string
+	sender  *regexp.Regexp
+	pattern *regexp.Regexp
+}
+
+// ExtractionService 字段提取服务。
+// 按发送方匹配规则，用正则命名分组将快递取件码、验证码、水电表读数等短信转换为结构化字段，
+// 字段随短信保存，并可在 Webhook 模板中通过 {{fields.字段名}} 引用。
+type ExtractionService struct {
+	logger          *zap.Logger
+	propertyService *PropertyService
+
+	mu     sync.Mutex
+	source []models.ExtractionRule
+	rules  []compiledRule
+}
+
+// NewExtractionService 创建字段提取服务实例
+func NewExtractionService(logger *zap.Logger, propertyService *PropertyService) *ExtractionService {
+	return &ExtractionService{
+		logger:          logger,
+		propertyService: propertyService,
+	}
+}
+
+// GetRules 获取字段提取规则，未配置时返回默认规则
+func (s *ExtractionService) GetRules(ctx context.Context) ([]models.ExtractionRule, error) {
+	var rules []models.ExtractionRule
+	if err := s.propertyService.GetValue(ctx, PropertyIDExtractionRules, &rules); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return DefaultExtractionRules, nil
+		}
+		return nil, err
+	}
+	return rules, nil
+}
+
+// Extract 按已保存的规则提取字段，没有匹配时返回 nil
+func (s *ExtractionService) Extract(ctx context.Context, from, content string) map[string]string {
+	rules, err := s.GetRules(ctx)
+	if err != nil {
+		s.logger.Error("获取字段提取规则失败", zap.Error(err))
+		return nil
+	}
+	return extractFields(s.compile(rules), from, content)
+}
+
+// Test 使用指定规则提取字段，规则有误时返回错误
+func (s *ExtractionService) Test(rules []models.ExtractionRule, from, content string) (map[string]string, error) {
+	compiled, err := compileRules(rules)
+	if err != nil {
+		return nil, err
+	}
+	return extractFields(compiled, from, content), nil
+}
+
+// compile 编译规则，规则未变化时复用上次结果，有误的规则跳过
+func (s *ExtractionService) compile(rules []models.ExtractionRule) []compiledRule {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.rules != nil && slices.Equal(s.source, rules) {
+		return s.rules
+	}
+
+	compiled := make([]compiledRule, 0, len(rules))
+	for _, rule := range rules {
+		c, err := compileRule(rule)
+		if err != nil {
+			s.logger.Warn("字段提取规则无效，已跳过", zap.String("rule", rule.Name), zap.Error(err))
+			continue
+		}
+		if c != nil {
+			compiled = append(compiled, *c)
+		}
+	}
+	s.source = rules
+	s.rules = compiled
+	return compiled
+}
+
+// compileRules 编译全部规则，任一规则有误时返回错误
+func compileRules(rules []models.ExtractionRule) ([]compiledRule, error) {
+	compiled := make([]compiledRule, 0, len(rules))
+	for _, rule := range rules {
+		c, err := compileRule(rule)
+		if err != nil {
+			return nil, fmt.Errorf("规则 %s: %w", rule.Name, err)
+		}
+		if c != nil {
+			compiled = append(compiled, *c)
+		}
+	}
+	return compiled, nil
+}
+
+// compileRule 编译单条规则，未启用时返回 nil
+func compileRule(rule models.ExtractionRule) (*compiledRule, error) {
+	if !rule.Enabled || rule.Pattern == "" {
+		return nil, nil
+	}
+	c := &compiledRule{name: rule.Name}
+	var err error
+	if rule.Sender != "" {
+		if c.sender, err = regexp.Compile(rule.Sender); err != nil {
+			return nil, fmt.Errorf("发送方正则有误: %w", err)
+		}
+	}
+	if c.pattern, err = regexp.Compile(rule.Pattern); err != nil {
+		return nil, fmt.Errorf("内容正则有误: %w", err)
+	}
+	if len(c.pattern.SubexpNames()) <= 1 {
+		return nil, fmt.Errorf("内容正则缺少命名分组 (?P<name>...)")
+	}
+	return c, nil
+}
+
+// extractFields 依次匹配规则，同名字段以先匹配的规则为准
+func extractFields(rules []compiledRule, from, content string) map[string]string {
+	var fields map[string]string
+	for _, rule := range rules {
+		if rule.sender != nil && !rule.sender.MatchString(from) {
+			continue
+		}
+		match := rule.pattern.FindStringSubmatch(content)
+		if match == nil {
+			continue
+		}
+		for i, name := range rule.pattern.SubexpNames() {
+			if name == "" || match[i] == "" {
+				continue
+			}
+			if fields == nil {
+				fields = make(map[string]string)
+			}
+			if _, ok := fields[name]; !ok {
+				fields[name] = match[i]
+			}
+		}
+	}
+	return fields
+}
@@ -0,0 +1,454 @@
+package service
+
+import (
+	"context"
+	"database/sql/driver"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dushixiang/uart_sms_forwarder/internal/models"
+	"github.com/glebarez/sqlite"
+	"go.uber.org/zap"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// SourceGammu 从 gammu-smsd 数据库导入的短信来源
+const SourceGammu = "gammu"
+
+// GammuImportRequest gammu-smsd 数据库连接参数
+type GammuImportRequest struct {
+	Driver string `json:"driver"` // 数据库类型：sqlite、mysql
+	DSN    string `json:"dsn"`    // SQLite 文件路径或 MySQL DSN，如 user:pass@tcp(127.0.0.1:3306)/smsd
+}
+
+// GammuImportResult 导入结果
+type GammuImportResult struct {
+	Inbox      int   `json:"inbox"`      // 读取的收件（长短信合并后）
+	SentItems  int   `json:"sentItems"`  // 读取的已发送
+	Outbox     int   `json:"outbox"`     // 读取的待发送
+	Imported   int64 `json:"imported"`   // 实际导入的数量
+	Skipped    int64 `json:"skipped"`    // 已导入过而跳过的数量
+	DurationMs int64 `json:"durationMs"` // 耗时（毫秒）
+}
+
+// GammuImportService gammu-smsd 历史短信导入服务。
+// 读取 gammu-smsd 数据库的 inbox、sentitems、outbox 表，转换为短信记录，
+// 记录 ID 由 gammu 的表名和 ID 生成，重复导入不会产生重复记录。
+type GammuImportService struct {
+	logger             *zap.Logger
+	textMessageService *TextMessageService
+}
+
+// NewGammuImportService 创建 gammu-smsd 导入服务实例
+func NewGammuImportService(logger *zap.Logger, textMessageService *TextMessageService) *GammuImportService {
+	return &GammuImportService{
+		logger:             logger,
+		textMessageService: textMessageService,
+	}
+}
+
+// gammuTime gammu 数据库中的时间，兼容 SQLite 文本和 MySQL datetime，按本地时间解析
+type gammuTime struct {
+	time.Time
+}
+
+var gammuTimeLayouts = []string{
+	time.DateTime,
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05.999999999",
+	time.RFC3339,
+}
+
+func (t *gammuTime) Scan(value any) error {
+	switch v := value.(type) {
+	case nil:
+		t.Time = time.Time{}
+	case time.Time:
+		t.Time = v
+	case int64:
+		t.Time = time.Unix(v, 0)
+	case []byte:
+		return t.parse(string(v))
+	case string:
+		return t.parse(v)
+	default:
+		return fmt.Errorf("无法解析时间: %v", value)
+	}
+	return nil
+}
+
+func (t *gammuTime) parse(s string) error {
+	s = strings.TrimSpace(s)
+	if s == "" || strings.HasPrefix(s, "0000-00-00") {
+		t.Time = time.Time{}
+		return nil
+	}
+	for _, layout := range gammuTimeLayouts {
+		if parsed, err := time.ParseInLocation(layout, s, time.Local); err == nil {
+			t.Time = parsed
+			return nil
+		}
+	}
+	return fmt.Errorf("无法解析时间: %s", s)
+}
+
+func (t gammuTime) Value() (driver.Value, error) {
+	return t.Time, nil
+}
+
+// millis 时间戳毫秒，时间为空时使用 fallback
+func (t gammuTime) millis(fallback ...gammuTime) int64 {
+	if !t.IsZero() {
+		return t.UnixMilli()
+	}
+	for _, f := range fallback {
+		if !f.IsZero() {
+			return f.UnixMilli()
+		}
+	}
+	return time.Now().UnixMilli()
+}
+
+// gammuText gammu 各表共有的短信内容字段
+type gammuText struct {
+	Text        string `gorm:"column:Text"`
+	Coding      string `gorm:"column:Coding"`
+	UDH         string `gorm:"column:UDH"`
+	TextDecoded string `gorm:"column:TextDecoded"`
+}
+
+// decode 获取短信文本，TextDecoded 为空时解码十六进制的 Text
+func (t gammuText) decode() string {
+	if t.TextDecoded != "" {
+		return t.TextDecoded
+	}
+	data, err := hex.DecodeString(strings.TrimSpace(t.Text))
+	if err != nil {
+		return ""
+	}
+	// gammu 将 7bit 和 UCS-2 短信统一保存为 UCS-2，8bit 短信保存原始数据
+	if strings.Contains(t.Coding, "8bit") {
+		return string(data)
+	}
+	return decodeUCS2(data)
+}
+
+// concat 长短信分片信息
+func (t gammuText) concat() (ref, total, seq int) {
+	udh, err := hex.DecodeString(strings.TrimSpace(t.UDH))
+	if err != nil || len(udh) < 2 {
+		return 0, 0, 0
+	}
+	var pdu SMSPDU
+	// UDH 第一个字节为头长度
+	parseUDH(udh[1:], &pdu)
+	return pdu.ConcatRef, pdu.ConcatTotal, pdu.ConcatSeq
+}
+
+type gammuInbox struct {
+	Body              gammuText `gorm:"embedded"`
+	ID                int64     `gorm:"column:ID"`
+	SenderNumber      string    `gorm:"column:SenderNumber"`
+	ReceivingDateTime gammuTime `gorm:"column:ReceivingDateTime"`
+}
+
+type gammuSentItem struct {
+	Body              gammuText `gorm:"embedded"`
+	ID                int64     `gorm:"column:ID"`
+	SequencePosition  int       `gorm:"column:SequencePosition"`
+	DestinationNumber string    `gorm:"column:DestinationNumber"`
+	Status            string    `gorm:"column:Status"`
+	InsertIntoDB      gammuTime `gorm:"column:InsertIntoDB"`
+	SendingDateTime   gammuTime `gorm:"column:SendingDateTime"`
+}
+
+type gammuOutbox struct {
+	Body              gammuText `gorm:"embedded"`
+	ID                int64     `gorm:"column:ID"`
+	DestinationNumber string    `gorm:"column:DestinationNumber"`
+	InsertIntoDB      gammuTime `gorm:"column:InsertIntoDB"`
+}
+
+type gammuOutboxPart struct {
+	Body             gammuText `gorm:"embedded"`
+	ID               int64     `gorm:"column:ID"`
+	SequencePosition int       `gorm:"column:SequencePosition"`
+}
+
+// gammuSentFailed 表示发送失败的 sentitems 状态，其余状态（SendingOK、DeliveryOK 等）视为已发送
+var gammuSentFailed = map[string]bool{
+	"SendingError":   true,
+	"DeliveryFailed": true,
+	"Error":          true,
+}
+
+// Import 从 gammu-smsd 数据库导入历史短信
+func (s *GammuImportService) Import(ctx context.Context, req GammuImportRequest) (*GammuImportResult, error) {
+	if err := requireAdmin(ctx); err != nil {
+		return nil, err
+	}
+	start := time.Now()
+
+	source, err := openGammuDatabase(req)
+	if err != nil {
+		return nil, err
+	}
+	if sqlDB, err := source.DB(); err == nil {
+		defer sqlDB.Close()
+	}
+	source = source.WithContext(ctx)
+
+	result := &GammuImportResult{}
+	var messages []models.TextMessage
+
+	inbox, err := s.readInbox(source)
+	if err != nil {
+		return nil, err
+	}
+	result.Inbox = len(inbox)
+	messages = append(messages, inbox...)
+
+	sent, err := s.readSentItems(source)
+	if err != nil {
+		return nil, err
+	}
+	result.SentItems = len(sent)
+	messages = append(messages, sent...)
+
+	outbox, err := s.readOutbox(source)
+	if err != nil {
+		return nil, err
+	}
+	result.Outbox = len(outbox)
+	messages = append(messages, outbox...)
+
+	imported, err := s.textMessageService.Import(ctx, messages)
+	if err != nil {
+		return nil, err
+	}
+	result.Imported = imported
+	result.Skipped = int64(len(messages)) - imported
+	result.DurationMs = time.Since(start).Milliseconds()
+
+	s.logger.Info("gammu-smsd 历史短信导入完成",
+		zap.String("driver", req.Driver),
+		zap.Int("inbox", result.Inbox),
+		zap.Int("sent_items", result.SentItems),
+		zap.Int("outbox", result.Outbox),
+		zap.Int64("imported", result.Imported),
+		zap.Int64("skipped", result.Skipped))
+	return result, nil
+}
+
+// openGammuDatabase 连接 gammu-smsd 数据库
+func openGammuDatabase(req GammuImportRequest) (*gorm.DB, error) {
+	dsn := strings.TrimSpace(req.DSN)
+	if dsn == "" {
+		return nil, fmt.Errorf("数据库连接不能为空")
+	}
+
+	var dialector gorm.Dialector
+	switch req.Driver {
+	case "sqlite":
+		// 文件不存在时 SQLite 会创建空数据库，提前检查
+		if _, err := os.Stat(dsn); err != nil {
+			return nil, fmt.Errorf("SQLite 文件不可用: %w", err)
+		}
+		dialector = sqlite.Open(dsn)
+	case "mysql":
+		dialector = mysql.Open(dsn)
+	default:
+		return nil, fmt.Errorf("不支持的数据库类型: %s", req.Driver)
+	}
+
+	db, err := gorm.Open(dialector, &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		return nil, fmt.Errorf("连接 gammu-smsd 数据库失败: %w", err)
+	}
+	if !db.Migrator().HasTable("inbox") {
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+		return nil, fmt.Errorf("未找到 inbox 表，请确认是 gammu-smsd 数据库")
+	}
+	return db, nil
+}
+
+// readInbox 读取收件箱，按 UDH 合并长短信
+func (s *GammuImportService) readInbox(source *gorm.DB) ([]models.TextMessage, error) {
+	var rows []gammuInbox
+	if err := source.Table("inbox").Order("ID").Find(&rows).Error; err != nil {
+		return nil, fmt.Errorf("读取 inbox 失败: %w", err)
+	}
+
+	type concatKey struct {
+		sender     string
+		ref, total int
+	}
+	type concatGroup struct {
+		first gammuInbox
+		parts map[int]string
+	}
+	groups := make(map[concatKey]*concatGroup)
+	var keys []concatKey
+	var messages []models.TextMessage
+
+	for _, row := range rows {
+		ref, total, seq := row.Body.concat()
+		if total <= 1 {
+			messages = append(messages, gammuInboxMessage(row, row.Body.decode()))
+			continue
+		}
+		key := concatKey{sender: row.SenderNumber, ref: ref, total: total}
+		group, ok := groups[key]
+		// 同一引用号可能被重复使用，分片序号重复时视为新的长短信
+		if ok {
+			if _, dup := group.parts[seq]; dup {
+				messages = append(messages, gammuConcatMessage(group.first, group.parts))
+				delete(groups, key)
+				ok = false
+			}
+		}
+		if !ok {
+			group = &concatGroup{first: row, parts: make(map[int]string)}
+			groups[key] = group
+			keys = append(keys, key)
+		}
+		group.parts[seq] = row.Body.decode()
+		if len(group.parts) == total {
+			messages = append(messages, gammuConcatMessage(group.first, group.parts))
+			delete(groups, key)
+		}
+	}
+	// 缺少分片的长短信按已有分片导入
+	for _, key := range keys {
+		if group, ok := groups[key]; ok {
+			messages = append(messages, gammuConcatMessage(group.first, group.parts))
+			delete(groups, key)
+		}
+	}
+	return messages, nil
+}
+
+func gammuInboxMessage(row gammuInbox, content string) models.TextMessage {
+	receivedAt := row.ReceivingDateTime.millis()
+	return models.TextMessage{
+		ID:        fmt.Sprintf("gammu-inbox-%d", row.ID),
+		From:      row.SenderNumber,
+		To:        "", // 接收方是本机
+		Content:   content,
+		Type:      models.MessageTypeIncoming,
+		Status:    models.MessageStatusReceived,
+		ReadAt:    receivedAt,
+		Source:    SourceGammu,
+		CreatedAt: receivedAt,
+	}
+}
+
+func gammuConcatMessage(first gammuInbox, parts map[int]string) models.TextMessage {
+	return gammuInboxMessage(first, joinParts(parts))
+}
+
+// joinParts 按序号拼接分片
+func joinParts(parts map[int]string) string {
+	seqs := make([]int, 0, len(parts))
+	for seq := range parts {
+		seqs = append(seqs, seq)
+	}
+	sort.Ints(seqs)
+	var b strings.Builder
+	for _, seq := range seqs {
+		b.WriteString(parts[seq])
+	}
+	return b.String()
+}
+
+// readSentItems 读取已发送，同一 ID 的多条记录为长短信的各个分片
+func (s *GammuImportService) readSentItems(source *gorm.DB) ([]models.TextMessage, error) {
+	if !source.Migrator().HasTable("sentitems") {
+		return nil, nil
+	}
+	var rows []gammuSentItem
+	if err := source.Table("sentitems").Order("ID").Order("SequencePosition").Find(&rows).Error; err != nil {
+		return nil, fmt.Errorf("读取 sentitems 失败: %w", err)
+	}
+
+	var messages []models.TextMessage
+	for i := 0; i < len(rows); {
+		first := rows[i]
+		parts := make(map[int]string)
+		failed := false
+		for ; i < len(rows) && rows[i].ID == first.ID; i++ {
+			parts[rows[i].SequencePosition] = rows[i].Body.decode()
+			failed = failed || gammuSentFailed[rows[i].Status]
+		}
+
+		status := models.MessageStatusSent
+		if failed {
+			status = models.MessageStatusFailed
+		}
+		messages = append(messages, models.TextMessage{
+			ID:        "gammu-sent-" + strconv.FormatInt(first.ID, 10),
+			From:      "", // 发送方是本机
+			To:        first.DestinationNumber,
+			Content:   joinParts(parts),
+			Type:      models.MessageTypeOutgoing,
+			Status:    status,
+			Source:    SourceGammu,
+			CreatedAt: first.SendingDateTime.millis(first.InsertIntoDB),
+		})
+	}
+	return messages, nil
+}
+
+// readOutbox 读取待发送，合并 outbox_multipart 中的后续分片。
+// 迁移后这些短信不会再由 gammu 发送，标记为发送失败以便确认后重新发送。
+func (s *GammuImportService) readOutbox(source *gorm.DB) ([]models.TextMessage, error) {
+	if !source.Migrator().HasTable("outbox") {
+		return nil, nil
+	}
+	var rows []gammuOutbox
+	if err := source.Table("outbox").Order("ID").Find(&rows).Error; err != nil {
+		return nil, fmt.Errorf("读取 outbox 失败: %w", err)
+	}
+
+	extra := make(map[int64]map[int]string)
+	if source.Migrator().HasTable("outbox_multipart") {
+		var parts []gammuOutboxPart
+		if err := source.Table("outbox_multipart").Find(&parts).Error; err != nil {
+			return nil, fmt.Errorf("读取 outbox_multipart 失败: %w", err)
+		}
+		for _, part := range parts {
+			if extra[part.ID] == nil {
+				extra[part.ID] = make(map[int]string)
+			}
+			extra[part.ID][part.SequencePosition] = part.Body.decode()
+		}
+	}
+
+	messages := make([]models.TextMessage, 0, len(rows))
+	for _, row := range rows {
+		parts := map[int]string{1: row.Body.decode()}
+		for seq, text := range extra[row.ID] {
+			parts[seq] = text
+		}
+		messages = append(messages, models.TextMessage{
+			ID:        "gammu-outbox-" + strconv.FormatInt(row.ID, 10),
+			From:      "", // 发送方是本机
+			To:        row.DestinationNumber,
+			Content:   joinParts(parts),
+			Type:      models.MessageTypeOutgoing,
+			Status:    models.MessageStatusFailed,
+			Source:    SourceGammu,
+			CreatedAt: row.InsertIntoDB.millis(),
+		})
+	}
+	return messages, nil
+}
@@ -0,0 +1,194 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dushixiang/uart_sms_forwarder/internal/models"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// PropertyIDHealthcheck 外部监控心跳配置
+const PropertyIDHealthcheck = "healthcheck"
+
+const (
+	// HealthcheckKindHealthchecks healthchecks.io（及自建实例），失败时请求 URL/fail
+	HealthcheckKindHealthchecks = "healthchecks"
+	// HealthcheckKindUptimeKuma Uptime Kuma Push 监控，通过 status=up/down 查询参数上报
+	HealthcheckKindUptimeKuma = "uptimekuma"
+
+	// DefaultHealthcheckInterval 默认定时心跳间隔（秒）
+	DefaultHealthcheckInterval = 60
+	// healthcheckTick 检查是否到达心跳时间的间隔，修改配置后无需重启即可生效
+	healthcheckTick = 10 * time.Second
+	// healthcheckTimeout 单次心跳请求的超时
+	healthcheckTimeout = 10 * time.Second
+)
+
+// HealthcheckService 定时向 healthchecks.io / Uptime Kuma 等外部监控发送心跳。
+// 转发器停止运行或卡死时心跳中断，由外部监控发出告警；设备断开时心跳上报为失败。
+// 设备连接、定时任务执行等关键事件也会额外发送一次心跳，便于在监控面板上查看。
+type HealthcheckService struct {
+	logger          *zap.Logger
+	propertyService *PropertyService
+	serialService   *SerialService
+
+	mu       sync.Mutex
+	lastPing time.Time // 上次定时心跳的时间
+	stopChan chan struct{}
+}
+
+// NewHealthcheckService 创建外部监控心跳实例
+func NewHealthcheckService(logger *zap.Logger, propertyService *PropertyService, serialService *SerialService) *HealthcheckService {
+	return &HealthcheckService{
+		logger:          logger,
+		propertyService: propertyService,
+		serialService:   serialService,
+		stopChan:        make(chan struct{}),
+	}
+}
+
+// Start 启动定时心跳
+func (s *HealthcheckService) Start() {
+	go func() {
+		ticker := time.NewTicker(healthcheckTick)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				s.Check(context.Background())
+			case <-s.stopChan:
+				return
+			}
+		}
+	}()
+}
+
+// Stop 停止定时心跳
+func (s *HealthcheckService) Stop() {
+	close(s.stopChan)
+}
+
+// Check 到达心跳间隔时发送一次心跳，所有设备都已连接时上报成功，否则上报失败
+func (s *HealthcheckService) Check(ctx context.Context) {
+	config, ok := s.getConfig(ctx)
+	if !ok {
+		return
+	}
+	interval := time.Duration(config.IntervalSeconds) * time.Second
+	if interval <= 0 {
+		interval = DefaultHealthcheckInterval * time.Second
+	}
+
+	s.mu.Lock()
+	if time.Since(s.lastPing) < interval {
+		s.mu.Unlock()
+		return
+	}
+	s.lastPing = time.Now()
+	s.mu.Unlock()
+
+	var disconnected []string
+	for _, deviceID := range s.serialService.DeviceIDs() {
+		if !s.serialService.connected(deviceID) {
+			disconnected = append(disconnected, deviceID)
+		}
+	}
+	if len(disconnected) > 0 {
+		s.ping(ctx, config, false, "设备未连接: "+strings.Join(disconnected, ", "))
+		return
+	}
+	s.ping(ctx, config, true, "OK")
+}
+
+// Event 关键事件发生时额外发送一次心跳，success 为 false 时上报失败。未启用时不发送，可在 nil 上调用
+func (s *HealthcheckService) Event(success bool, message string) {
+	if s == nil {
+		return
+	}
+	go func() {
+		ctx := context.Background()
+		if config, ok := s.getConfig(ctx); ok {
+			s.ping(ctx, config, success, message)
+		}
+	}()
+}
+
+// getConfig 获取心跳配置，未启用或未配置 URL 时返回 false
+func (s *HealthcheckService) getConfig(ctx context.Context) (models.HealthcheckConfig, bool) {
+	var config models.HealthcheckConfig
+	if err := s.propertyService.GetValue(ctx, PropertyIDHealthcheck, &config); err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			s.logger.Error("获取外部监控心跳配置失败", zap.Error(err))
+		}
+		return config, false
+	}
+	return config, config.Enabled && config.URL != ""
+}
+
+// ping 按监控类型发送心跳，失败只记录日志
+func (s *HealthcheckService) ping(ctx context.Context, config models.HealthcheckConfig, success bool, message string) {
+	ctx, cancel := context.WithTimeout(ctx, healthcheckTimeout)
+	defer cancel()
+
+	req, err := newHealthcheckRequest(ctx, config, success, message)
+	if err != nil {
+		s.logger.Error("外部监控心跳地址无效", zap.Error(err))
+		return
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		s.logger.Warn("发送外部监控心跳失败", zap.Error(err))
+		return
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		s.logger.Warn("外部监控心跳返回错误状态码", zap.Int("status", resp.StatusCode))
+		return
+	}
+	s.logger.Debug("已发送外部监控心跳", zap.Bool("success", success), zap.String("message", message))
+}
+
+// newHealthcheckRequest 构造心跳请求。
+// healthchecks.io 成功时 POST 到 URL、失败时 POST 到 URL/fail，消息作为请求体显示在监控日志中；
+// Uptime Kuma 在 Push URL 上设置 status 和 msg 查询参数后 GET。
+func newHealthcheckRequest(ctx context.Context, config models.HealthcheckConfig, success bool, message string) (*http.Request, error) {
+	switch strings.ToLower(config.Kind) {
+	case "", HealthcheckKindHealthchecks:
+		target := strings.TrimRight(config.URL, "/")
+		if !success {
+			target += "/fail"
+		}
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, target, strings.NewReader(message))
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Content-Type", "text/plain; charset=utf-8")
+		return req, nil
+	case HealthcheckKindUptimeKuma:
+		u, err := url.Parse(config.URL)
+		if err != nil {
+			return nil, err
+		}
+		query := u.Query()
+		query.Set("status", "up")
+		if !success {
+			query.Set("status", "down")
+		}
+		query.Set("msg", message)
+		query.Del("ping")
+		u.RawQuery = query.Encode()
+		return http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	default:
+		return nil, fmt.Errorf("不支持的监控类型: %s", config.Kind)
+	}
+}
@@ -0,0 +1,138 @@
+package service
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dushixiang/uart_sms_forwarder/internal/models"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+const (
+	// PropertyIDSmsForwarderInbound SmsForwarder 推送接口配置
+	PropertyIDSmsForwarderInbound = "smsforwarder_inbound"
+	// SourceSmsForwarder SmsForwarder 推送的短信来源前缀
+	SourceSmsForwarder = "smsforwarder"
+
+	// inboundSignMaxAge 签名时间戳允许的最大偏差
+	inboundSignMaxAge = time.Hour
+)
+
+var (
+	// ErrInboundDisabled 推送接口未启用
+	ErrInboundDisabled = errors.New("推送接口未启用")
+	// ErrInboundUnauthorized 签名或 token 校验失败
+	ErrInboundUnauthorized = errors.New("签名校验失败")
+)
+
+// InboundService 接收其他设备推送的短信，汇总到同一个短信记录和通知流程
+type InboundService struct {
+	logger          *zap.Logger
+	propertyService *PropertyService
+	serialService   *SerialService
+}
+
+// NewInboundService 创建外部短信推送服务实例
+func NewInboundService(logger *zap.Logger, propertyService *PropertyService, serialService *SerialService) *InboundService {
+	return &InboundService{
+		logger:          logger,
+		propertyService: propertyService,
+		serialService:   serialService,
+	}
+}
+
+// getConfig 获取推送接口配置，未配置时视为未启用
+func (s *InboundService) getConfig(ctx context.Context, id string) (models.InboundWebhookConfig, error) {
+	var config models.InboundWebhookConfig
+	if err := s.propertyService.GetValue(ctx, id, &config); err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return config, err
+	}
+	return config, nil
+}
+
+// ReceiveSmsForwarder 处理 SmsForwarder（Android 短信转发器）Webhook 推送的短信。
+// fields 为推送的表单或 JSON 字段：from、content、org_content、device_mark、receive_time、timestamp、sign。
+// 推送方配置了密钥时按 timestamp + "\n" + secret 的 HmacSHA256 签名校验，否则需通过 token 传递密钥。
+func (s *InboundService) ReceiveSmsForwarder(ctx context.Context, fields map[string]string, token string) error {
+	config, err := s.getConfig(ctx, PropertyIDSmsForwarderInbound)
+	if err != nil {
+		return fmt.Errorf("获取推送接口配置失败: %w", err)
+	}
+	if !config.Enabled || config.Secret == "" {
+		return ErrInboundDisabled
+	}
+	if err := verifySmsForwarderSign(config.Secret, fields["timestamp"], fields["sign"], token); err != nil {
+		return err
+	}
+
+	from := strings.TrimSpace(fields["from"])
+	// content 是 SmsForwarder 按模板拼接后的内容，org_content 才是短信原文
+	content := fields["org_content"]
+	if content == "" {
+		content = fields["content"]
+	}
+	if from == "" || content == "" {
+		return fmt.Errorf("from 和 content 不能为空")
+	}
+
+	receivedAt := time.Now().UnixMilli()
+	if t, err := time.ParseInLocation(time.DateTime, fields["receive_time"], time.Local); err == nil {
+		receivedAt = t.UnixMilli()
+	} else if ts, err := strconv.ParseInt(fields["timestamp"], 10, 64); err == nil && ts > 0 {
+		receivedAt = ts
+	}
+
+	source := SourceSmsForwarder
+	if device := strings.TrimSpace(fields["device_mark"]); device != "" {
+		source += ":" + device
+	}
+
+	s.serialService.ReceiveExternalSMS(ctx, IncomingSMS{
+		Timestamp: receivedAt / 1000,
+		From:      from,
+		Content:   content,
+		Type:      "sms",
+	}, source, receivedAt)
+	return nil
+}
+
+// verifySmsForwarderSign 校验 SmsForwarder 的签名，未签名时校验 token
+func verifySmsForwarderSign(secret, timestamp, sign, token string) error {
+	if sign == "" {
+		if token != "" && subtle.ConstantTimeCompare([]byte(token), []byte(secret)) == 1 {
+			return nil
+		}
+		return ErrInboundUnauthorized
+	}
+
+	ts, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return ErrInboundUnauthorized
+	}
+	if age := time.Since(time.UnixMilli(ts)); age > inboundSignMaxAge || age < -inboundSignMaxAge {
+		return fmt.Errorf("%w: 时间戳已过期", ErrInboundUnauthorized)
+	}
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp + "\n" + secret))
+	expected := base64.StdEncoding.EncodeToString(mac.Sum(nil))
+
+	// SmsForwarder 对签名做了 URL 编码，表单解码后可能仍是编码后的形式
+	if unescaped, err := url.QueryUnescape(sign); err == nil && strings.Contains(sign, "%") {
+		sign = unescaped
+	}
+	if !hmac.Equal([]byte(sign), []byte(expected)) {
+		return ErrInboundUnauthorized
+	}
+	return nil
+}
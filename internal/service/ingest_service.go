@@ -0,0 +1,79 @@
+package service
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/dushixiang/uart_sms_forwarder/internal/models"
+	"gorm.io/gorm"
+)
+
+const (
+	// PropertyIDIngestAPI 通用消息接入接口配置
+	PropertyIDIngestAPI = "ingest_api"
+	// SourceIngest 通用消息接入接口的短信来源前缀
+	SourceIngest = "ingest"
+)
+
+// IngestMessage 通用消息接入接口接收的消息
+type IngestMessage struct {
+	From      string `json:"from"`      // 发送方号码
+	Content   string `json:"content"`   // 内容，来电时可为空
+	Type      string `json:"type"`      // sms（默认）或 call
+	Source    string `json:"source"`    // 来源名称，如网关或脚本名，记录为 ingest:来源
+	Timestamp int64  `json:"timestamp"` // 收到时间，秒或毫秒时间戳，为空时使用当前时间
+}
+
+// Ingest 接收其他网关或脚本推送的消息，短信与本机收到的短信走相同的存储和通知流程，来电只发送通知
+func (s *InboundService) Ingest(ctx context.Context, msg IngestMessage, apiKey string) error {
+	var config models.IngestAPIConfig
+	if err := s.propertyService.GetValue(ctx, PropertyIDIngestAPI, &config); err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return fmt.Errorf("获取接入接口配置失败: %w", err)
+	}
+	if !config.Enabled || config.APIKey == "" {
+		return ErrInboundDisabled
+	}
+	if subtle.ConstantTimeCompare([]byte(apiKey), []byte(config.APIKey)) != 1 {
+		return ErrInboundUnauthorized
+	}
+
+	msg.From = strings.TrimSpace(msg.From)
+	if msg.From == "" {
+		return fmt.Errorf("from 不能为空")
+	}
+
+	receivedAt := time.Now().UnixMilli()
+	switch {
+	case msg.Timestamp > 1e12:
+		receivedAt = msg.Timestamp
+	case msg.Timestamp > 0:
+		receivedAt = msg.Timestamp * 1000
+	}
+
+	source := SourceIngest
+	if name := strings.TrimSpace(msg.Source); name != "" {
+		source += ":" + name
+	}
+
+	switch strings.ToLower(strings.TrimSpace(msg.Type)) {
+	case "", "sms":
+		if msg.Content == "" {
+			return fmt.Errorf("content 不能为空")
+		}
+		s.serialService.ReceiveExternalSMS(ctx, IncomingSMS{
+			Timestamp: receivedAt / 1000,
+			From:      msg.From,
+			Content:   msg.Content,
+			Type:      "sms",
+		}, source, receivedAt)
+	case "call":
+		s.serialService.ReceiveExternalCall(ctx, msg.From, receivedAt/1000, source)
+	default:
+		return fmt.Errorf("不支持的消息类型: %s", msg.Type)
+	}
+	return nil
+}
@@ -0,0 +1,131 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/robfig/cron/v3"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// MaintenanceCronSpec 数据库维护执行时间（每周日凌晨 4 点）
+const MaintenanceCronSpec = "0 4 * * 0"
+
+// MaintenanceResult 数据库维护结果
+type MaintenanceResult struct {
+	Dialect    string `json:"dialect"`
+	StartedAt  int64  `json:"startedAt"`  // 开始时间（时间戳毫秒）
+	DurationMs int64  `json:"durationMs"` // 耗时（毫秒）
+	SizeBefore int64  `json:"sizeBefore"` // 维护前数据库大小（字节），仅 SQLite
+	SizeAfter  int64  `json:"sizeAfter"`  // 维护后数据库大小（字节），仅 SQLite
+	Error      string `json:"error,omitempty"`
+}
+
+// MaintenanceService 数据库维护服务。
+// SQLite 删除数据后文件不会自动缩小，定期 VACUUM 回收空间，ANALYZE 更新查询计划统计信息。
+type MaintenanceService struct {
+	logger *zap.Logger
+	db     *gorm.DB
+	cron   *cron.Cron
+
+	running sync.Mutex
+	mu      sync.RWMutex
+	last    *MaintenanceResult
+}
+
+// NewMaintenanceService 创建数据库维护服务实例
+func NewMaintenanceService(logger *zap.Logger, db *gorm.DB) *MaintenanceService {
+	return &MaintenanceService{
+		logger: logger,
+		db:     db,
+	}
+}
+
+// Start 启动定期维护
+func (s *MaintenanceService) Start() error {
+	s.cron = cron.New()
+	_, err := s.cron.AddFunc(MaintenanceCronSpec, func() {
+		if _, err := s.Run(context.Background()); err != nil {
+			s.logger.Error("定期数据库维护失败", zap.Error(err))
+		}
+	})
+	if err != nil {
+		return fmt.Errorf("添加数据库维护任务失败: %w", err)
+	}
+	s.cron.Start()
+	return nil
+}
+
+// LastResult 获取最近一次维护结果，从未执行时返回 nil
+func (s *MaintenanceService) LastResult() *MaintenanceResult {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.last
+}
+
+// Run 执行一次数据库维护，同一时间只允许一个维护任务
+func (s *MaintenanceService) Run(ctx context.Context) (*MaintenanceResult, error) {
+	if !s.running.TryLock() {
+		return nil, fmt.Errorf("数据库维护正在执行")
+	}
+	defer s.running.Unlock()
+
+	db := s.db.WithContext(ctx)
+	start := time.Now()
+	result := &MaintenanceResult{
+		Dialect:   db.Dialector.Name(),
+		StartedAt: start.UnixMilli(),
+	}
+
+	s.logger.Info("开始数据库维护", zap.String("dialect", result.Dialect))
+
+	var err error
+	switch result.Dialect {
+	case "sqlite":
+		result.SizeBefore = s.sqliteSize(db)
+		if err = db.Exec("VACUUM").Error; err == nil {
+			err = db.Exec("ANALYZE").Error
+		}
+		result.SizeAfter = s.sqliteSize(db)
+	case "postgres":
+		err = db.Exec("VACUUM ANALYZE").Error
+	case "mysql":
+		err = db.Exec("ANALYZE TABLE properties, text_messages, scheduled_tasks").Error
+	default:
+		err = fmt.Errorf("不支持的数据库类型: %s", result.Dialect)
+	}
+
+	result.DurationMs = time.Since(start).Milliseconds()
+	if err != nil {
+		result.Error = err.Error()
+	}
+
+	s.mu.Lock()
+	s.last = result
+	s.mu.Unlock()
+
+	if err != nil {
+		return result, fmt.Errorf("数据库维护失败: %w", err)
+	}
+
+	s.logger.Info("数据库维护完成",
+		zap.Int64("duration_ms", result.DurationMs),
+		zap.Int64("size_before", result.SizeBefore),
+		zap.Int64("size_after", result.SizeAfter))
+	return result, nil
+}
+
+// sqliteSize 计算 SQLite 数据库大小
+func (s *MaintenanceService) sqliteSize(db *gorm.DB) int64 {
+	var pageCount, pageSize int64
+	if err := db.Raw("PRAGMA page_count").Scan(&pageCount).Error; err != nil {
+		return 0
+	}
+	if err := db.Raw("PRAGMA page_size").Scan(&pageSize).Error; err != nil {
+		return 0
+	}
+	return pageCount * pageSize
+}
@@ -0,0 +1,162 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"sync/atomic"
+
+	"github.com/dushixiang/uart_sms_forwarder/internal/models"
+	"github.com/dushixiang/uart_sms_forwarder/internal/util"
+	"go.uber.org/zap"
+	"gorm.io/gorm/schema"
+)
+
+// messageCryptoBatch 迁移已有短信时每批处理的条数
+const messageCryptoBatch = 500
+
+// ErrMessageKeyMissing 短信内容已加密，但未配置密钥
+var ErrMessageKeyMissing = errors.New("短信内容已加密，但未配置密钥 App.SecretKey")
+
+// messageCipher 短信内容加解密配置。gorm 序列化器全局注册，配置也只能全局保存。
+type messageCipher struct {
+	box     *util.SecretBox
+	encrypt bool // 为 false 时只解密已加密的内容，新短信明文保存
+}
+
+var currentMessageCipher atomic.Pointer[messageCipher]
+
+func init() {
+	schema.RegisterSerializer("msgcrypt", messageContentSerializer{})
+}
+
+// SetMessageEncryption 设置短信内容加密。box 为空时不加密，已加密的内容无法读取；
+// encrypt 为 false 时仍使用 box 解密以前加密的内容。
+func SetMessageEncryption(box *util.SecretBox, encrypt bool) {
+	currentMessageCipher.Store(&messageCipher{box: box, encrypt: encrypt && box != nil})
+}
+
+// messageEncryptionEnabled 新短信是否加密保存
+func messageEncryptionEnabled() bool {
+	c := currentMessageCipher.Load()
+	return c != nil && c.encrypt
+}
+
+// messageContentSerializer 短信内容序列化器，写入时加密，读取时透明解密，未加密的内容原样读取
+type messageContentSerializer struct{}
+
+// Scan 读取数据库中的内容并解密
+func (messageContentSerializer) Scan(ctx context.Context, field *schema.Field, dst reflect.Value, dbValue interface{}) error {
+	var value string
+	switch v := dbValue.(type) {
+	case string:
+		value = v
+	case []byte:
+		value = string(v)
+	case nil:
+	default:
+		return fmt.Errorf("短信内容类型错误: %T", dbValue)
+	}
+
+	plaintext, err := openMessageContent(value)
+	if err != nil {
+		return err
+	}
+	return field.Set(ctx, dst, plaintext)
+}
+
+// Value 写入前加密内容，未启用加密或内容为空（来电记录）时原样写入
+func (messageContentSerializer) Value(ctx context.Context, field *schema.Field, dst reflect.Value, fieldValue interface{}) (interface{}, error) {
+	value, _ := fieldValue.(string)
+	return sealMessageContent(value)
+}
+
+// sealMessageContent 按当前配置加密短信内容，未启用加密或内容为空时原样返回
+func sealMessageContent(value string) (string, error) {
+	c := currentMessageCipher.Load()
+	if c == nil || !c.encrypt || value == "" {
+		return value, nil
+	}
+	return c.box.Encrypt(value)
+}
+
+// openMessageContent 解密短信内容，未加密的内容原样返回
+func openMessageContent(value string) (string, error) {
+	if !util.IsEncrypted(value) {
+		return value, nil
+	}
+	c := currentMessageCipher.Load()
+	if c == nil || c.box == nil {
+		return "", ErrMessageKeyMissing
+	}
+	plaintext, err := c.box.Decrypt(value)
+	if err != nil {
+		return "", fmt.Errorf("解密短信内容失败: %w", err)
+	}
+	return plaintext, nil
+}
+
+// EncryptExisting 加密已有的明文短信内容，返回处理的条数。启用加密后首次启动时自动执行。
+func (s *TextMessageService) EncryptExisting(ctx context.Context) (int64, error) {
+	if err := requireAdmin(ctx); err != nil {
+		return 0, err
+	}
+	c := currentMessageCipher.Load()
+	if c == nil || !c.encrypt {
+		return 0, fmt.Errorf("未启用短信内容加密，请在配置中设置 App.SecretKey 和 App.EncryptMessages")
+	}
+	return s.migrateContent(ctx, "content <> '' AND content NOT LIKE ?", c.box.Encrypt)
+}
+
+// DecryptExisting 将已加密的短信内容恢复为明文，返回处理的条数。需先在配置中关闭加密，否则新短信仍会加密保存。
+func (s *TextMessageService) DecryptExisting(ctx context.Context) (int64, error) {
+	if err := requireAdmin(ctx); err != nil {
+		return 0, err
+	}
+	if messageEncryptionEnabled() {
+		return 0, fmt.Errorf("请先在配置中关闭 App.EncryptMessages")
+	}
+	// 读取时已由序列化器解密，直接写回明文
+	return s.migrateContent(ctx, "content LIKE ?", func(plaintext string) (string, error) {
+		return plaintext, nil
+	})
+}
+
+// migrateContent 分批读取符合条件的短信，将 convert 转换后的内容直接写回数据库
+func (s *TextMessageService) migrateContent(ctx context.Context, condition string, convert func(string) (string, error)) (int64, error) {
+	var total int64
+	for {
+		var messages []models.TextMessage
+		err := s.repo.GetDB(ctx).
+			Select("id", "content").
+			Where(condition, util.EncryptedPrefix+"%").
+			Order("id").
+			Limit(messageCryptoBatch).
+			Find(&messages).Error
+		if err != nil {
+			return total, fmt.Errorf("读取短信失败: %w", err)
+		}
+		if len(messages) == 0 {
+			break
+		}
+		for _, msg := range messages {
+			content, err := convert(msg.Content)
+			if err != nil {
+				return total, err
+			}
+			// 即使写入时经过序列化器，Encrypt 对已加密的值也会原样返回，不会重复加密
+			err = s.repo.GetDB(ctx).Model(&models.TextMessage{}).
+				Where("id = ?", msg.ID).
+				UpdateColumn("content", content).Error
+			if err != nil {
+				return total, fmt.Errorf("更新短信内容失败: %w", err)
+			}
+			total++
+		}
+	}
+	if total > 0 {
+		s.logger.Info("短信内容迁移完成", zap.Int64("count", total))
+	}
+	return total, nil
+}
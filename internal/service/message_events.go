@@ -0,0 +1,92 @@
+package service
+
+import (
+	"sync"
+	"time"
+
+	"github.com/dushixiang/uart_sms_forwarder/internal/models"
+)
+
+// 新消息事件类型
+const (
+	MessageEventSMS  = "sms"
+	MessageEventCall = "call"
+	MessageEventAck  = "ack" // 短信被确认处理
+)
+
+// MessageEvent 新收到的短信、来电或短信被确认，推送给通过 WebSocket 连接的页面
+type MessageEvent struct {
+	Type      string              `json:"type"`              // sms, call, ack
+	From      string              `json:"from"`              // 发送方号码
+	Message   *models.TextMessage `json:"message,omitempty"` // 短信记录，来电时为空
+	Timestamp int64               `json:"timestamp"`         // 时间戳（毫秒）
+}
+
+// messageEventHub 向所有订阅方分发新消息事件
+type messageEventHub struct {
+	mu   sync.Mutex
+	subs map[chan MessageEvent]struct{}
+}
+
+// subscribe 订阅新消息事件，返回的取消函数必须调用
+func (h *messageEventHub) subscribe() (<-chan MessageEvent, func()) {
+	ch := make(chan MessageEvent, 32)
+	h.mu.Lock()
+	if h.subs == nil {
+		h.subs = make(map[chan MessageEvent]struct{})
+	}
+	h.subs[ch] = struct{}{}
+	h.mu.Unlock()
+
+	return ch, func() {
+		h.mu.Lock()
+		defer h.mu.Unlock()
+		delete(h.subs, ch)
+	}
+}
+
+// publish 推送事件，订阅方处理不及时时丢弃，不阻塞收信流程
+func (h *messageEventHub) publish(event MessageEvent) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for ch := range h.subs {
+		select {
+		case ch <- event:
+		default:
+		}
+	}
+}
+
+// SubscribeMessageEvents 订阅新收到的短信和来电
+func (s *SerialService) SubscribeMessageEvents() (<-chan MessageEvent, func()) {
+	return s.messageEvents.subscribe()
+}
+
+// publishSMSEvent 推送已保存的短信
+func (s *SerialService) publishSMSEvent(record *models.TextMessage) {
+	s.messageEvents.publish(MessageEvent{
+		Type:      MessageEventSMS,
+		From:      record.From,
+		Message:   record,
+		Timestamp: record.CreatedAt,
+	})
+}
+
+// publishCallEvent 推送来电
+func (s *SerialService) publishCallEvent(from string) {
+	s.messageEvents.publish(MessageEvent{
+		Type:      MessageEventCall,
+		From:      from,
+		Timestamp: time.Now().UnixMilli(),
+	})
+}
+
+// publishAckEvent 推送短信被确认，其他打开页面的用户可以看到已有人处理
+func (s *SerialService) publishAckEvent(record *models.TextMessage) {
+	s.messageEvents.publish(MessageEvent{
+		Type:      MessageEventAck,
+		From:      record.From,
+		Message:   record,
+		Timestamp: record.AckedAt,
+	})
+}
@@ -0,0 +1,286 @@
+package service
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/dushixiang/uart_sms_forwarder/config"
+	"github.com/dushixiang/uart_sms_forwarder/internal/models"
+	"go.uber.org/zap"
+)
+
+const (
+	// DefaultSpoolPath 默认暂存文件路径
+	DefaultSpoolPath = "./data/spool.jsonl"
+	// DefaultSpoolMaxEntries 默认最多暂存的短信条数
+	DefaultSpoolMaxEntries = 1000
+	// SpoolReplayInterval 重新写入数据库的检查间隔
+	SpoolReplayInterval = 30 * time.Second
+)
+
+// spoolEntry 暂存文件中的一行
+type spoolEntry struct {
+	Message   models.TextMessage `json:"message"`   // 短信记录，启用短信内容加密时内容为密文
+	SpooledAt int64              `json:"spooledAt"` // 暂存时间（时间戳毫秒）
+}
+
+// MessageSpool 短信暂存。数据库暂时不可写时，收到的短信以 JSON 行追加到本地文件，
+// 定期尝试写回数据库，写入成功的条目从文件中移除。文件条数有上限，超出时丢弃最早的短信。
+type MessageSpool struct {
+	logger     *zap.Logger
+	path       string
+	maxEntries int
+
+	mu    sync.Mutex
+	count int // 文件中的条数，-1 表示尚未读取
+}
+
+// NewMessageSpool 创建短信暂存实例
+func NewMessageSpool(logger *zap.Logger, config config.SpoolConfig) *MessageSpool {
+	spool := &MessageSpool{
+		logger:     logger,
+		path:       config.Path,
+		maxEntries: config.MaxEntries,
+		count:      -1,
+	}
+	if spool.path == "" {
+		spool.path = DefaultSpoolPath
+	}
+	if spool.maxEntries <= 0 {
+		spool.maxEntries = DefaultSpoolMaxEntries
+	}
+	return spool
+}
+
+// Append 暂存一条短信，文件已满时丢弃最早的短信
+func (s *MessageSpool) Append(msg *models.TextMessage) error {
+	entry := spoolEntry{Message: *msg, SpooledAt: time.Now().UnixMilli()}
+	// 暂存文件与数据库一样按配置加密短信内容
+	content, err := sealMessageContent(msg.Content)
+	if err != nil {
+		return fmt.Errorf("加密短信内容失败: %w", err)
+	}
+	entry.Message.Content = content
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.loadCountLocked(); err != nil {
+		return err
+	}
+	if s.count >= s.maxEntries {
+		entries, err := s.readLocked()
+		if err != nil {
+			return err
+		}
+		dropped := len(entries) - s.maxEntries + 1
+		s.logger.Warn("短信暂存已满，丢弃最早的短信", zap.Int("dropped", dropped))
+		return s.writeLocked(append(entries[dropped:], entry))
+	}
+
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o755); err != nil {
+		return fmt.Errorf("创建暂存目录失败: %w", err)
+	}
+	file, err := os.OpenFile(s.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return fmt.Errorf("打开暂存文件失败: %w", err)
+	}
+	defer file.Close()
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	if _, err := file.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("写入暂存文件失败: %w", err)
+	}
+	s.count++
+	return nil
+}
+
+// Pending 暂存中等待写入数据库的短信条数
+func (s *MessageSpool) Pending() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.loadCountLocked(); err != nil {
+		s.logger.Error("读取暂存文件失败", zap.Error(err))
+		return 0
+	}
+	return s.count
+}
+
+// Replay 按暂存顺序将短信写回数据库，遇到写入失败时停止，已写入的短信从文件中移除。返回写入的条数。
+func (s *MessageSpool) Replay(ctx context.Context, save func(context.Context, *models.TextMessage) error) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.loadCountLocked(); err != nil || s.count == 0 {
+		return 0, err
+	}
+	entries, err := s.readLocked()
+	if err != nil {
+		return 0, err
+	}
+
+	saved := 0
+	var saveErr error
+	for _, entry := range entries {
+		msg := entry.Message
+		if msg.Content, saveErr = openMessageContent(msg.Content); saveErr != nil {
+			break
+		}
+		if saveErr = save(ctx, &msg); saveErr != nil {
+			break
+		}
+		saved++
+	}
+	if saved > 0 {
+		if err := s.writeLocked(entries[saved:]); err != nil {
+			// 已写入的短信下次会以相同 ID 覆盖写入，不会重复
+			return saved, err
+		}
+	}
+	return saved, saveErr
+}
+
+// Start 定期将暂存的短信写回数据库
+func (s *MessageSpool) Start(ctx context.Context, save func(context.Context, *models.TextMessage) error) {
+	go func() {
+		ticker := time.NewTicker(SpoolReplayInterval)
+		defer ticker.Stop()
+		for {
+			s.replayOnce(ctx, save)
+			select {
+			case <-ticker.C:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+}
+
+// replayOnce 写回暂存的短信并记录结果
+func (s *MessageSpool) replayOnce(ctx context.Context, save func(context.Context, *models.TextMessage) error) {
+	saved, err := s.Replay(ctx, save)
+	if saved > 0 {
+		s.logger.Info("暂存的短信已写入数据库", zap.Int("count", saved), zap.Int("pending", s.Pending()))
+	}
+	if err != nil {
+		s.logger.Warn("暂存的短信写入数据库失败，稍后重试", zap.Int("pending", s.Pending()), zap.Error(err))
+	}
+}
+
+// loadCountLocked 首次使用时统计文件中的条数，调用方需持有锁
+func (s *MessageSpool) loadCountLocked() error {
+	if s.count >= 0 {
+		return nil
+	}
+	entries, err := s.readLocked()
+	if err != nil {
+		return err
+	}
+	s.count = len(entries)
+	return nil
+}
+
+// readLocked 读取文件中的所有条目，损坏的行跳过，调用方需持有锁
+func (s *MessageSpool) readLocked() ([]spoolEntry, error) {
+	file, err := os.Open(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("打开暂存文件失败: %w", err)
+	}
+	defer file.Close()
+
+	var entries []spoolEntry
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var entry spoolEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			// 写入时断电可能留下不完整的最后一行
+			s.logger.Warn("跳过损坏的暂存记录", zap.Error(err))
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("读取暂存文件失败: %w", err)
+	}
+	return entries, nil
+}
+
+// writeLocked 以临时文件替换的方式重写暂存文件，没有条目时删除文件，调用方需持有锁
+func (s *MessageSpool) writeLocked(entries []spoolEntry) error {
+	if len(entries) == 0 {
+		if err := os.Remove(s.path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("删除暂存文件失败: %w", err)
+		}
+		s.count = 0
+		return nil
+	}
+
+	tmp := s.path + ".tmp"
+	file, err := os.OpenFile(tmp, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0o600)
+	if err != nil {
+		return fmt.Errorf("创建暂存文件失败: %w", err)
+	}
+	writer := bufio.NewWriter(file)
+	encoder := json.NewEncoder(writer)
+	for _, entry := range entries {
+		if err := encoder.Encode(entry); err != nil {
+			file.Close()
+			os.Remove(tmp)
+			return fmt.Errorf("写入暂存文件失败: %w", err)
+		}
+	}
+	if err := writer.Flush(); err != nil {
+		file.Close()
+		os.Remove(tmp)
+		return fmt.Errorf("写入暂存文件失败: %w", err)
+	}
+	if err := file.Close(); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("写入暂存文件失败: %w", err)
+	}
+	if err := os.Rename(tmp, s.path); err != nil {
+		return fmt.Errorf("替换暂存文件失败: %w", err)
+	}
+	s.count = len(entries)
+	return nil
+}
+
+// spoolMessage 数据库写入失败时暂存短信，稍后由 SaveSpooled 写回
+func (s *SerialService) spoolMessage(record *models.TextMessage) {
+	if s.spool == nil {
+		return
+	}
+	if err := s.spool.Append(record); err != nil {
+		s.logger.Error("暂存短信失败，短信已丢失", zap.String("id", record.ID), zap.Error(err))
+		return
+	}
+	s.logger.Warn("数据库不可写，短信已暂存到本地文件", zap.String("id", record.ID), zap.String("path", s.spool.path))
+}
+
+// SaveSpooled 将暂存的短信写入数据库，并补上保存后的处理
+func (s *SerialService) SaveSpooled(ctx context.Context, record *models.TextMessage) error {
+	if err := s.textMsgService.Save(ctx, record); err != nil {
+		return err
+	}
+	s.afterMessageSaved(ctx, record)
+	return nil
+}
+
+// afterMessageSaved 短信入库后推送到页面并解析银行交易
+func (s *SerialService) afterMessageSaved(ctx context.Context, record *models.TextMessage) {
+	s.publishSMSEvent(record)
+	if s.transactionService != nil {
+		s.transactionService.Record(ctx, record)
+	}
+}
@@ -0,0 +1,163 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/dushixiang/uart_sms_forwarder/internal/models"
+	"github.com/dushixiang/uart_sms_forwarder/internal/repo"
+	"github.com/google/uuid"
+	"github.com/valyala/fasttemplate"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// templateBuiltinVariables 发送时自动填充的内置变量
+var templateBuiltinVariables = []string{"to", "date", "time", "datetime"}
+
+// templateVariablePattern 模板中的 {{变量名}}
+var templateVariablePattern = regexp.MustCompile(`\{\{\s*([^{}\s]+)\s*}}`)
+
+// MessageTemplateService 短信模板服务
+type MessageTemplateService struct {
+	logger *zap.Logger
+	repo   *repo.MessageTemplateRepo
+}
+
+// NewMessageTemplateService 创建短信模板服务实例
+func NewMessageTemplateService(logger *zap.Logger, db *gorm.DB) *MessageTemplateService {
+	return &MessageTemplateService{
+		logger: logger,
+		repo:   repo.NewMessageTemplateRepo(db),
+	}
+}
+
+// GetAll 获取所有模板
+func (s *MessageTemplateService) GetAll(ctx context.Context) ([]models.MessageTemplate, error) {
+	templates, err := s.repo.FindAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for i := range templates {
+		templates[i].Variables = templateVariables(templates[i].Content)
+	}
+	return templates, nil
+}
+
+// GetById 根据ID获取模板
+func (s *MessageTemplateService) GetById(ctx context.Context, id string) (*models.MessageTemplate, error) {
+	template, err := s.repo.FindById(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	template.Variables = templateVariables(template.Content)
+	return &template, nil
+}
+
+// Create 创建模板
+func (s *MessageTemplateService) Create(ctx context.Context, template *models.MessageTemplate) error {
+	now := time.Now().UnixMilli()
+	template.ID = uuid.NewString()
+	template.CreatedAt = now
+	template.UpdatedAt = now
+	if err := s.repo.Create(ctx, template); err != nil {
+		return err
+	}
+	template.Variables = templateVariables(template.Content)
+	return nil
+}
+
+// Update 更新模板
+func (s *MessageTemplateService) Update(ctx context.Context, template *models.MessageTemplate) error {
+	existing, err := s.GetById(ctx, template.ID)
+	if err != nil {
+		return err
+	}
+	existing.Name = template.Name
+	existing.Content = template.Content
+	existing.UpdatedAt = time.Now().UnixMilli()
+	if err := s.repo.Save(ctx, existing); err != nil {
+		return err
+	}
+	existing.Variables = templateVariables(existing.Content)
+	*template = *existing
+	return nil
+}
+
+// Delete 删除模板
+func (s *MessageTemplateService) Delete(ctx context.Context, id string) error {
+	return s.repo.DeleteById(ctx, id)
+}
+
+// Render 用变量替换模板内容，to 为接收号码，用于内置变量 {{to}}
+func (s *MessageTemplateService) Render(ctx context.Context, id, to string, variables map[string]string) (string, error) {
+	template, err := s.GetById(ctx, id)
+	if err != nil {
+		return "", fmt.Errorf("模板不存在")
+	}
+	return RenderMessageTemplate(template.Content, to, variables)
+}
+
+// RenderMessageTemplate 替换模板中的变量，内置变量 to、date、time、datetime 自动填充，
+// 其余变量未提供时返回错误，避免发出带占位符的短信
+func RenderMessageTemplate(content, to string, variables map[string]string) (string, error) {
+	var missing []string
+	for _, name := range templateVariables(content) {
+		if _, ok := variables[name]; !ok {
+			missing = append(missing, name)
+		}
+	}
+	if len(missing) > 0 {
+		return "", fmt.Errorf("缺少模板变量: %s", strings.Join(missing, ", "))
+	}
+
+	now := time.Now()
+	t := fasttemplate.New(content, "{{", "}}")
+	return t.ExecuteFuncString(func(w io.Writer, tag string) (int, error) {
+		tag = strings.TrimSpace(tag)
+		if v, ok := variables[tag]; ok {
+			return w.Write([]byte(v))
+		}
+		switch tag {
+		case "to":
+			return w.Write([]byte(to))
+		case "date":
+			return w.Write([]byte(now.Format(time.DateOnly)))
+		case "time":
+			return w.Write([]byte(now.Format("15:04")))
+		case "datetime":
+			return w.Write([]byte(now.Format("2006-01-02 15:04")))
+		}
+		return w.Write([]byte("{{" + tag + "}}"))
+	}), nil
+}
+
+// templateVariables 提取模板中需要填写的变量名，不含内置变量
+func templateVariables(content string) []string {
+	variables := []string{}
+	for _, match := range templateVariablePattern.FindAllStringSubmatch(content, -1) {
+		name := match[1]
+		if slices.Contains(templateBuiltinVariables, name) || slices.Contains(variables, name) {
+			continue
+		}
+		variables = append(variables, name)
+	}
+	return variables
+}
+
+// ValidateMessageTemplate 校验模板字段
+func ValidateMessageTemplate(template *models.MessageTemplate) error {
+	template.Name = strings.TrimSpace(template.Name)
+	if template.Name == "" {
+		return fmt.Errorf("模板名称不能为空")
+	}
+	if strings.TrimSpace(template.Content) == "" {
+		return fmt.Errorf("模板内容不能为空")
+	}
+	return nil
+}
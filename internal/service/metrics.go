@@ -0,0 +1,196 @@
+package service
+
+import (
+	"bufio"
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/dushixiang/uart_sms_forwarder/internal/models"
+	"gorm.io/gorm"
+)
+
+// PropertyIDMetrics Prometheus 指标接口配置
+const PropertyIDMetrics = "metrics"
+
+var (
+	// ErrMetricsDisabled 指标接口未启用
+	ErrMetricsDisabled = errors.New("指标接口未启用")
+	// ErrMetricsUnauthorized 指标接口令牌错误
+	ErrMetricsUnauthorized = errors.New("指标接口令牌错误")
+)
+
+// metricsCounters 串口服务运行期间累计的计数，服务重启后从 0 开始（Prometheus 会自动处理计数器重置）
+type metricsCounters struct {
+	smsReceived atomic.Int64
+	smsSent     atomic.Int64
+	smsFailed   atomic.Int64
+
+	mu            sync.Mutex
+	notifications map[notificationCounterKey]int64
+}
+
+// notificationCounterKey 通知发送结果按渠道类型和是否成功计数
+type notificationCounterKey struct {
+	channel string
+	success bool
+}
+
+// countNotification 记录一次渠道通知的最终结果（含重试）
+func (m *metricsCounters) countNotification(channel string, success bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.notifications == nil {
+		m.notifications = make(map[notificationCounterKey]int64)
+	}
+	m.notifications[notificationCounterKey{channel: channel, success: success}]++
+}
+
+// countSendResult 记录短信的发送结果，对账修正状态时会再次计数
+func (m *metricsCounters) countSendResult(status models.MessageStatus) {
+	switch status {
+	case models.MessageStatusSent:
+		m.smsSent.Add(1)
+	case models.MessageStatusFailed:
+		m.smsFailed.Add(1)
+	}
+}
+
+// MetricsService 以 Prometheus 文本格式输出短信收发、通知和设备信号指标
+type MetricsService struct {
+	propertyService *PropertyService
+	serialService   *SerialService
+}
+
+// NewMetricsService 创建指标服务实例
+func NewMetricsService(propertyService *PropertyService, serialService *SerialService) *MetricsService {
+	return &MetricsService{
+		propertyService: propertyService,
+		serialService:   serialService,
+	}
+}
+
+// Authorize 检查指标接口是否启用，配置了令牌时校验 Authorization: Bearer 令牌
+func (s *MetricsService) Authorize(ctx context.Context, token string) error {
+	var config models.MetricsConfig
+	if err := s.propertyService.GetValue(ctx, PropertyIDMetrics, &config); err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return fmt.Errorf("获取指标接口配置失败: %w", err)
+	}
+	if !config.Enabled {
+		return ErrMetricsDisabled
+	}
+	if config.Token != "" && subtle.ConstantTimeCompare([]byte(token), []byte(config.Token)) != 1 {
+		return ErrMetricsUnauthorized
+	}
+	return nil
+}
+
+// Write 输出所有指标
+func (s *MetricsService) Write(w io.Writer) error {
+	b := bufio.NewWriter(w)
+	serial := s.serialService
+	counters := &serial.metrics
+
+	writeHeader(b, "uart_sms_received_total", "counter", "收到的短信数量（含外部推送）")
+	fmt.Fprintf(b, "uart_sms_received_total %d\n", counters.smsReceived.Load())
+	writeHeader(b, "uart_sms_sent_total", "counter", "设备确认发送成功的短信数量")
+	fmt.Fprintf(b, "uart_sms_sent_total %d\n", counters.smsSent.Load())
+	writeHeader(b, "uart_sms_send_failed_total", "counter", "发送失败的短信数量（含超时）")
+	fmt.Fprintf(b, "uart_sms_send_failed_total %d\n", counters.smsFailed.Load())
+
+	counters.mu.Lock()
+	keys := make([]notificationCounterKey, 0, len(counters.notifications))
+	for key := range counters.notifications {
+		keys = append(keys, key)
+	}
+	slices.SortFunc(keys, func(a, b notificationCounterKey) int {
+		if c := strings.Compare(a.channel, b.channel); c != 0 {
+			return c
+		}
+		if a.success == b.success {
+			return 0
+		}
+		if a.success {
+			return 1
+		}
+		return -1
+	})
+	writeHeader(b, "uart_sms_notifications_total", "counter", "各通知渠道的发送结果（重试后的最终结果）")
+	for _, key := range keys {
+		result := "failure"
+		if key.success {
+			result = "success"
+		}
+		fmt.Fprintf(b, "uart_sms_notifications_total{channel=%s,result=%q} %d\n",
+			quoteLabel(key.channel), result, counters.notifications[key])
+	}
+	counters.mu.Unlock()
+
+	devices := serial.devices
+	writeHeader(b, "uart_sms_serial_reconnects_total", "counter", "设备断开后重新连接的次数")
+	for _, device := range devices {
+		fmt.Fprintf(b, "uart_sms_serial_reconnects_total{device=%s} %d\n", quoteLabel(device.id), device.reconnects.Load())
+	}
+
+	statuses := serial.GetAllStatus()
+	writeHeader(b, "uart_sms_device_connected", "gauge", "设备是否已连接（1 为已连接）")
+	for _, status := range statuses {
+		fmt.Fprintf(b, "uart_sms_device_connected{device=%s} %d\n", quoteLabel(status.DeviceID), boolGauge(status.Connected))
+	}
+	writeHeader(b, "uart_sms_device_flymode", "gauge", "设备是否处于飞行模式（1 为飞行模式）")
+	for _, status := range statuses {
+		fmt.Fprintf(b, "uart_sms_device_flymode{device=%s} %d\n", quoteLabel(status.DeviceID), boolGauge(status.Flymode))
+	}
+
+	// 信号指标只输出当前连接中收到的状态，旧状态会让图表误以为信号一直不变
+	var live []*StatusData
+	for _, status := range statuses {
+		if status.Connected && status.UpdatedAt > 0 && !status.Stale {
+			live = append(live, status)
+		}
+	}
+	signals := []struct {
+		name  string
+		help  string
+		value func(*StatusData) int
+	}{
+		{"uart_sms_signal_rssi", "信号强度 RSSI（dBm）", func(s *StatusData) int { return s.Mobile.Rssi }},
+		{"uart_sms_signal_rsrp", "参考信号接收功率 RSRP（dBm）", func(s *StatusData) int { return s.Mobile.Rsrp }},
+		{"uart_sms_signal_csq", "CSQ 信号强度（0-31，99 为未知）", func(s *StatusData) int { return s.Mobile.Csq }},
+	}
+	for _, signal := range signals {
+		writeHeader(b, signal.name, "gauge", signal.help)
+		for _, status := range live {
+			fmt.Fprintf(b, "%s{device=%s} %d\n", signal.name, quoteLabel(status.DeviceID), signal.value(status))
+		}
+	}
+	writeHeader(b, "uart_sms_network_registered", "gauge", "SIM 卡是否已注册到网络（1 为已注册）")
+	for _, status := range live {
+		fmt.Fprintf(b, "uart_sms_network_registered{device=%s} %d\n", quoteLabel(status.DeviceID), boolGauge(status.Mobile.IsRegistered))
+	}
+
+	return b.Flush()
+}
+
+// writeHeader 输出指标的 HELP 和 TYPE 行
+func writeHeader(w io.Writer, name, metricType, help string) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, metricType)
+}
+
+// quoteLabel 按 Prometheus 文本格式转义标签值
+func quoteLabel(value string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(value) + `"`
+}
+
+func boolGauge(value bool) int {
+	if value {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,142 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/dushixiang/uart_sms_forwarder/config"
+	"go.uber.org/zap"
+)
+
+const (
+	// BackendLua 默认后端：烧录 main.lua 的 Air780 系列模块
+	BackendLua = "lua"
+	// BackendAT 标准 AT 指令（PDU 模式）的 GSM/LTE 模组，如 SIM800、EC200、Quectel
+	BackendAT = "at"
+	// BackendHiLink 华为 HiLink 网卡（E3372h 等），通过本地 HTTP 接口收发短信
+	BackendHiLink = "hilink"
+	// BackendAndroid Android 手机，通过 Termux:API 或 adb 收发短信
+	BackendAndroid = "android"
+	// BackendModemManager Linux 上由 ModemManager 管理的模组，通过 DBus 收发短信
+	BackendModemManager = "modemmanager"
+)
+
+// ModemAdapter 设备适配器。
+// SerialService 只处理统一的消息帧（与 Lua 固件协议一致的 type/action），
+// 不同硬件通过适配器完成连接、命令翻译和事件上报，存储和通知逻辑无需关心底层设备。
+type ModemAdapter interface {
+	// Name 适配器名称，用于日志
+	Name() string
+	// Run 连接设备并阻塞处理数据，直到连接断开或 ctx 取消。
+	// 连接建立后调用 ready(设备名)，设备事件转换为消息帧后通过 emit 上报。
+	Run(ctx context.Context, ready func(device string), emit func(*ParsedMessage)) error
+	// SendCommand 发送统一格式的命令（action + 参数），结果以消息帧形式异步上报
+	SendCommand(cmd map[string]any) error
+}
+
+// newModemAdapter 根据配置创建设备适配器，Lua 后端返回 nil，使用内置串口协议
+func newModemAdapter(logger *zap.Logger, cfg config.SerialConfig) (ModemAdapter, error) {
+	switch strings.ToLower(cfg.Backend) {
+	case "", BackendLua:
+		return nil, nil
+	case BackendAT:
+		return newATModem(logger, cfg), nil
+	case BackendHiLink:
+		return newHiLinkModem(logger, cfg.HiLink), nil
+	case BackendAndroid:
+		return newAndroidModem(logger, cfg.Android), nil
+	case BackendModemManager, "mm":
+		return newMMModem(logger, cfg.ModemManager)
+	default:
+		return nil, fmt.Errorf("不支持的设备后端: %s", cfg.Backend)
+	}
+}
+
+// runAdapter 通过适配器执行一次连接
+func (d *serialDevice) runAdapter(resetBackoff func()) error {
+	connCtx, connCancel := context.WithCancel(context.Background())
+	defer connCancel()
+
+	ready := func(device string) {
+		d.setPortName(device)
+		d.setConnected(true)
+		resetBackoff()
+		d.logger.Info("设备连接成功", zap.String("backend", d.adapter.Name()), zap.String("device", device))
+
+		// 启动定时更新缓存的 goroutine
+		d.wg.Add(1)
+		go d.periodicCacheUpdate(connCtx)
+
+		// 首次立即发送缓存更新请求
+		go d.RequestCacheUpdate()
+	}
+
+	err := d.adapter.Run(connCtx, ready, d.emit)
+
+	// 通知其他 goroutine 连接已断开，并等待退出
+	connCancel()
+	d.wg.Wait()
+	d.setConnected(false)
+
+	if err != nil {
+		return fmt.Errorf("%s 后端运行失败: %w", d.adapter.Name(), err)
+	}
+	return nil
+}
+
+// emitFrame 将 payload 构造成消息帧并上报，供适配器使用
+func emitFrame(emit func(*ParsedMessage), payload map[string]any) {
+	if _, ok := payload["timestamp"]; !ok {
+		payload["timestamp"] = time.Now().Unix()
+	}
+	msg, err := newParsedMessage(payload)
+	if err != nil {
+		return
+	}
+	emit(msg)
+}
+
+// sendResultFrame 构造短信发送结果消息帧，失败时附带原因
+func sendResultFrame(requestID, to string, err error) map[string]any {
+	frame := map[string]any{
+		"type":       "sms_send_result",
+		"success":    err == nil,
+		"request_id": requestID,
+		"to":         to,
+	}
+	if err != nil {
+		frame["error"] = err.Error()
+	}
+	return frame
+}
+
+// deliveryReportFrame 构造短信送达报告消息帧，未送达时附带状态码和原因
+func deliveryReportFrame(requestID, to string, delivered bool, code, reason string) map[string]any {
+	frame := map[string]any{
+		"type":       "sms_delivery_report",
+		"delivered":  delivered,
+		"request_id": requestID,
+		"to":         to,
+	}
+	if !delivered {
+		frame["code"] = code
+		frame["error"] = reason
+	}
+	return frame
+}
+
+// ussdResultFrame 构造 USSD 结果消息帧，失败时附带原因
+func ussdResultFrame(requestID, text string, err error) map[string]any {
+	frame := map[string]any{
+		"type":       "ussd_response",
+		"success":    err == nil,
+		"request_id": requestID,
+		"text":       text,
+	}
+	if err != nil {
+		frame["error"] = err.Error()
+	}
+	return frame
+}
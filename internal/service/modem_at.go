@@ -0,0 +1,805 @@
+package service
+
+import (
+	"bufio"
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/dushixiang/uart_sms_forwarder/config"
+	"go.bug.st/serial"
+	"go.uber.org/zap"
+)
+
+const (
+	atCommandTimeout = 5 * time.Second
+	atSendTimeout    = 60 * time.Second
+)
+
+var (
+	errATNotConnected = errors.New("串口未连接")
+
+	atCSQPattern  = regexp.MustCompile(`\+CSQ:\s*(\d+),`)
+	atRegPattern  = regexp.MustCompile(`\+C(?:E|G)?REG:\s*(?:\d+,)?(\d+)`)
+	atCFUNPattern = regexp.MustCompile(`\+CFUN:\s*(\d+)`)
+	atCESQPattern = regexp.MustCompile(`\+CESQ:\s*\d+,\d+,\d+,\d+,(\d+),(\d+)`)
+	atCNUMPattern = regexp.MustCompile(`\+CNUM:\s*"[^"]*","([^"]*)"`)
+	atCLIPPattern = regexp.MustCompile(`\+CLIP:\s*"([^"]*)"`)
+	atCMTIPattern = regexp.MustCompile(`\+CMTI:\s*"[^"]*",\s*(\d+)`)
+	atCMGLPattern = regexp.MustCompile(`\+CMGL:\s*(\d+),`)
+	atCMGSPattern = regexp.MustCompile(`\+CMGS:\s*(\d+)`)
+	atCUSDPattern = regexp.MustCompile(`(?s)^\+CUSD:\s*(\d+)(?:,\s*"(.*)"(?:,\s*(\d+))?)?$`)
+	atDigits      = regexp.MustCompile(`[0-9A-Fa-f]{10,}`)
+)
+
+// atError 模组返回的错误（ERROR / +CMS ERROR / +CME ERROR）
+type atError struct {
+	Response string
+}
+
+func (e *atError) Error() string {
+	return "模组返回错误: " + e.Response
+}
+
+// atModem 标准 AT 指令（PDU 模式）适配器
+type atModem struct {
+	logger *zap.Logger
+	config config.SerialConfig
+
+	mu   sync.RWMutex
+	port serial.Port
+	emit func(*ParsedMessage)
+
+	// 同一时间只允许一条 AT 命令在执行
+	cmdMu    sync.Mutex
+	pending  atomic.Bool
+	respCh   chan string
+	promptCh chan struct{}
+
+	concatRef atomic.Uint32
+	ringing   atomic.Bool
+	capture   *SerialCapture
+
+	// 同一时间只有一个 USSD 会话，+CUSD 上报不带请求标识，按最近一次请求关联
+	ussdMu        sync.Mutex
+	ussdRequestID string
+	ussdBuf       string // 跨多行的 +CUSD 文本，仅在 readLoop 中访问
+
+	// 等待送达报告的短信：+CMGS 返回的 TP-MR -> 发送请求，长短信的每个分片各有一个 TP-MR
+	deliveryMu sync.Mutex
+	deliveries map[int]*atDelivery
+}
+
+// atDeliveryTTL 等待送达报告的最长时间，超过后不再关联，避免 TP-MR 循环使用后误关联
+const atDeliveryTTL = 72 * time.Hour
+
+// atDelivery 等待送达报告的发送请求
+type atDelivery struct {
+	requestID string
+	to        string
+	sentAt    time.Time
+	pending   int  // 尚未收到最终送达报告的分片数
+	done      bool // 已上报结果（全部送达或有分片失败）
+}
+
+func newATModem(logger *zap.Logger, cfg config.SerialConfig) *atModem {
+	return &atModem{
+		logger:   logger.Named("at"),
+		config:   cfg,
+		respCh:   make(chan string, 64),
+		promptCh: make(chan struct{}, 1),
+	}
+}
+
+// setCapture 设置串口抓包，在连接前调用
+func (m *atModem) setCapture(capture *SerialCapture) {
+	m.capture = capture
+}
+
+func (m *atModem) Name() string {
+	return BackendAT
+}
+
+// Run 打开串口、初始化模组并阻塞读取数据
+func (m *atModem) Run(ctx context.Context, ready func(device string), emit func(*ParsedMessage)) error {
+	portName, err := m.selectPort()
+	if err != nil {
+		return err
+	}
+
+	port, err := serial.Open(portName, m.mode())
+	if err != nil {
+		return fmt.Errorf("连接串口失败: %w", err)
+	}
+
+	m.mu.Lock()
+	m.port = port
+	m.emit = emit
+	m.mu.Unlock()
+
+	readErr := make(chan error, 1)
+	go func() {
+		readErr <- m.readLoop(port)
+	}()
+
+	closePort := func() {
+		m.mu.Lock()
+		m.port = nil
+		m.mu.Unlock()
+		_ = port.Close()
+	}
+
+	if err := m.initModem(); err != nil {
+		closePort()
+		<-readErr
+		return fmt.Errorf("初始化模组失败: %w", err)
+	}
+
+	ready(portName)
+
+	// 读取连接前已存储在 SIM 卡中的未读短信
+	go m.readStoredMessages()
+
+	select {
+	case err := <-readErr:
+		closePort()
+		return err
+	case <-ctx.Done():
+		closePort()
+		<-readErr
+		return nil
+	}
+}
+
+// selectPort 确定使用的串口：优先使用配置，否则发送 AT 探测
+func (m *atModem) selectPort() (string, error) {
+	if m.config.Port != "" {
+		return m.config.Port, nil
+	}
+
+	ports, err := serial.GetPortsList()
+	if err != nil {
+		return "", fmt.Errorf("获取串口列表失败: %w", err)
+	}
+
+	for _, portName := range ports {
+		if m.probePort(portName) {
+			m.logger.Info("自动检测到 AT 模组", zap.String("port", portName))
+			return portName, nil
+		}
+	}
+	return "", fmt.Errorf("未检测到响应 AT 指令的串口")
+}
+
+// mode 串口参数，未配置波特率时使用 115200
+func (m *atModem) mode() *serial.Mode {
+	baudRate := m.config.BaudRate
+	if baudRate == 0 {
+		baudRate = DefaultBaudRate
+	}
+	return serialMode(m.config, baudRate)
+}
+
+// probePort 发送 AT 并检查是否返回 OK
+func (m *atModem) probePort(portName string) bool {
+	port, err := serial.Open(portName, m.mode())
+	if err != nil {
+		return false
+	}
+	defer port.Close()
+
+	_ = port.SetReadTimeout(1 * time.Second)
+	if _, err := port.Write([]byte("AT\r")); err != nil {
+		return false
+	}
+	time.Sleep(300 * time.Millisecond)
+
+	buffer := make([]byte, 256)
+	n, err := port.Read(buffer)
+	return err == nil && strings.Contains(string(buffer[:n]), "OK")
+}
+
+// initModem 初始化模组：关闭回显、PDU 模式、新短信和来电上报
+func (m *atModem) initModem() error {
+	// 部分模组上电后第一条命令会丢失，先发送一次 AT 唤醒
+	_, _ = m.command("AT", atCommandTimeout)
+
+	commands := []string{
+		"ATE0",              // 关闭回显
+		"AT+CMEE=1",         // 数字错误码
+		"AT+CMGF=0",         // PDU 模式
+		"AT+CNMI=2,1,0,1,0", // 新短信以 +CMTI 上报，送达报告以 +CDS 上报
+		"AT+CLIP=1",         // 来电显示
+	}
+	for _, cmd := range commands {
+		if _, err := m.command(cmd, atCommandTimeout); err != nil {
+			// CNMI/CLIP 在部分模组上参数不同，不影响基本收发
+			if cmd == "AT+CMGF=0" {
+				return err
+			}
+			m.logger.Warn("AT 初始化命令失败", zap.String("cmd", cmd), zap.Error(err))
+		}
+	}
+	return nil
+}
+
+// readLoop 逐字节读取串口，识别行和 CMGS 的 "> " 提示符
+func (m *atModem) readLoop(port serial.Port) error {
+	reader := bufio.NewReader(port)
+	var line []byte
+	expectPDU := false
+
+	for {
+		b, err := reader.ReadByte()
+		if err != nil {
+			return fmt.Errorf("读取串口失败: %w", err)
+		}
+
+		switch b {
+		case '\r':
+			continue
+		case '\n':
+			text := strings.TrimSpace(string(line))
+			line = line[:0]
+			if text == "" {
+				continue
+			}
+			m.capture.Record(CaptureInbound, text)
+			if expectPDU {
+				expectPDU = false
+				m.handleIncomingPDU(text, -1)
+				continue
+			}
+			expectPDU = m.handleLine(text)
+		default:
+			line = append(line, b)
+			if len(line) == 2 && line[0] == '>' && line[1] == ' ' {
+				line = line[:0]
+				select {
+				case m.promptCh <- struct{}{}:
+				default:
+				}
+			}
+		}
+	}
+}
+
+// handleLine 处理一行数据，返回 true 表示下一行为 PDU（+CMT / +CDS 直接上报）
+func (m *atModem) handleLine(line string) bool {
+	m.logger.Debug("AT <-", zap.String("line", line))
+
+	switch {
+	case strings.HasPrefix(line, "+CMTI:"):
+		if match := atCMTIPattern.FindStringSubmatch(line); match != nil {
+			index, _ := strconv.Atoi(match[1])
+			go m.readMessage(index)
+		}
+		return false
+	case strings.HasPrefix(line, "+CMT:"), strings.HasPrefix(line, "+CDS:"):
+		return true
+	case line == "RING":
+		return false
+	case strings.HasPrefix(line, "+CLIP:"):
+		if m.ringing.CompareAndSwap(false, true) {
+			from := "unknown"
+			if match := atCLIPPattern.FindStringSubmatch(line); match != nil && match[1] != "" {
+				from = match[1]
+			}
+			m.emitFrame(map[string]any{"type": "incoming_call", "from": from})
+		}
+		return false
+	case m.ussdBuf != "" || strings.HasPrefix(line, "+CUSD:"):
+		m.handleUSSDLine(line)
+		return false
+	case line == "NO CARRIER":
+		if m.ringing.CompareAndSwap(true, false) {
+			m.emitFrame(map[string]any{"type": "call_disconnected"})
+		}
+		return false
+	}
+
+	if m.pending.Load() {
+		m.respCh <- line
+	}
+	return false
+}
+
+// emitFrame 上报消息帧
+func (m *atModem) emitFrame(payload map[string]any) {
+	m.mu.RLock()
+	emit := m.emit
+	m.mu.RUnlock()
+	if emit != nil {
+		emitFrame(emit, payload)
+	}
+}
+
+// writeRaw 写入串口
+func (m *atModem) writeRaw(data string) error {
+	m.mu.RLock()
+	port := m.port
+	m.mu.RUnlock()
+	if port == nil {
+		return errATNotConnected
+	}
+	m.capture.Record(CaptureOutbound, data)
+	if _, err := port.Write([]byte(data)); err != nil {
+		return fmt.Errorf("串口写入失败: %w", err)
+	}
+	return nil
+}
+
+// command 执行一条 AT 命令，返回最终结果之前的响应行
+func (m *atModem) command(cmd string, timeout time.Duration) ([]string, error) {
+	return m.commandWithData(cmd, "", timeout)
+}
+
+// commandWithData 执行 AT 命令；data 非空时在收到 "> " 提示符后写入 data 并以 Ctrl-Z 结束
+func (m *atModem) commandWithData(cmd, data string, timeout time.Duration) ([]string, error) {
+	m.cmdMu.Lock()
+	defer m.cmdMu.Unlock()
+
+	// 清空上一条命令的残留
+	for len(m.respCh) > 0 {
+		<-m.respCh
+	}
+	select {
+	case <-m.promptCh:
+	default:
+	}
+
+	m.pending.Store(true)
+	defer m.pending.Store(false)
+
+	m.logger.Debug("AT ->", zap.String("cmd", cmd))
+	if err := m.writeRaw(cmd + "\r"); err != nil {
+		return nil, err
+	}
+
+	deadline := time.NewTimer(timeout)
+	defer deadline.Stop()
+
+	if data != "" {
+		select {
+		case <-m.promptCh:
+			if err := m.writeRaw(data + "\x1a"); err != nil {
+				return nil, err
+			}
+		case line := <-m.respCh:
+			return nil, &atError{Response: line}
+		case <-deadline.C:
+			// 取消输入，避免模组一直等待
+			_ = m.writeRaw("\x1b")
+			return nil, fmt.Errorf("等待输入提示符超时: %s", cmd)
+		}
+	}
+
+	var lines []string
+	for {
+		select {
+		case line := <-m.respCh:
+			if line == cmd {
+				continue // 回显
+			}
+			switch {
+			case line == "OK":
+				return lines, nil
+			case line == "ERROR", strings.HasPrefix(line, "+CMS ERROR"), strings.HasPrefix(line, "+CME ERROR"):
+				return lines, &atError{Response: line}
+			}
+			lines = append(lines, line)
+		case <-deadline.C:
+			return lines, fmt.Errorf("AT 命令超时: %s", cmd)
+		}
+	}
+}
+
+// readMessage 读取并删除指定位置的短信
+func (m *atModem) readMessage(index int) {
+	lines, err := m.command(fmt.Sprintf("AT+CMGR=%d", index), atCommandTimeout)
+	if err != nil {
+		m.logger.Error("读取短信失败", zap.Int("index", index), zap.Error(err))
+		return
+	}
+	// 响应格式：+CMGR: <stat>,[<alpha>],<length> 后跟 PDU
+	for i, line := range lines {
+		if strings.HasPrefix(line, "+CMGR:") && i+1 < len(lines) {
+			m.handleIncomingPDU(lines[i+1], index)
+			return
+		}
+	}
+}
+
+// readStoredMessages 读取所有存储的短信（连接前收到的）
+func (m *atModem) readStoredMessages() {
+	// 4 = ALL（PDU 模式）
+	lines, err := m.command("AT+CMGL=4", atSendTimeout)
+	if err != nil {
+		m.logger.Warn("读取存储短信失败", zap.Error(err))
+		return
+	}
+	for i, line := range lines {
+		match := atCMGLPattern.FindStringSubmatch(line)
+		if match == nil || i+1 >= len(lines) {
+			continue
+		}
+		index, _ := strconv.Atoi(match[1])
+		m.handleIncomingPDU(lines[i+1], index)
+	}
+}
+
+// handleIncomingPDU 解码 PDU 并上报；index >= 0 时处理完成后从存储中删除
+func (m *atModem) handleIncomingPDU(hexPDU string, index int) {
+	pdu, err := DecodeSMSPDU(hexPDU)
+	if err != nil {
+		m.logger.Error("PDU 解码失败", zap.String("pdu", hexPDU), zap.Error(err))
+		return
+	}
+
+	switch pdu.Type {
+	case pduTypeDeliver:
+		payload := map[string]any{
+			"type":      "incoming_sms",
+			"from":      pdu.From,
+			"content":   pdu.Content,
+			"timestamp": pdu.Timestamp.Unix(),
+		}
+		if pdu.ConcatTotal > 1 {
+			payload["concat_ref"] = pdu.ConcatRef
+			payload["concat_total"] = pdu.ConcatTotal
+			payload["concat_seq"] = pdu.ConcatSeq
+		}
+		m.emitFrame(payload)
+	case pduTypeStatusReport:
+		m.logger.Debug("收到短信状态报告",
+			zap.Int("message_ref", pdu.MessageRef),
+			zap.Int("status", pdu.Status))
+		m.handleStatusReport(pdu.MessageRef, pdu.Status)
+	}
+
+	if index >= 0 {
+		go func() {
+			if _, err := m.command(fmt.Sprintf("AT+CMGD=%d", index), atCommandTimeout); err != nil {
+				m.logger.Warn("删除已读短信失败", zap.Int("index", index), zap.Error(err))
+			}
+		}()
+	}
+}
+
+// SendCommand 将统一命令翻译为 AT 指令，结果异步上报
+func (m *atModem) SendCommand(cmd map[string]any) error {
+	m.mu.RLock()
+	connected := m.port != nil
+	m.mu.RUnlock()
+	if !connected {
+		return errATNotConnected
+	}
+
+	action, _ := cmd["action"].(string)
+	switch action {
+	case "send_sms":
+		to, _ := cmd["to"].(string)
+		content, _ := cmd["content"].(string)
+		requestID, _ := cmd["request_id"].(string)
+		go m.sendSMS(to, content, requestID)
+	case "send_ussd":
+		code, _ := cmd["code"].(string)
+		requestID, _ := cmd["request_id"].(string)
+		go m.sendUSSD(code, requestID)
+	case "get_status":
+		go m.reportStatus()
+	case "set_flymode":
+		enabled, _ := cmd["enabled"].(bool)
+		fun := "1"
+		if enabled {
+			fun = "4"
+		}
+		go m.simpleCommand("set_flymode", "AT+CFUN="+fun)
+	case "reset_stack":
+		go m.simpleCommand("reset_stack", "AT+CFUN=0", "AT+CFUN=1")
+	case "reboot_mcu":
+		go m.simpleCommand("reboot_mcu", "AT+CFUN=1,1")
+	case "hangup_call":
+		go m.hangupCall()
+	default:
+		return fmt.Errorf("AT 后端不支持的命令: %s", action)
+	}
+	return nil
+}
+
+// simpleCommand 依次执行 AT 命令并上报 cmd_response
+func (m *atModem) simpleCommand(action string, commands ...string) {
+	result := "ok"
+	for _, c := range commands {
+		if _, err := m.command(c, atSendTimeout); err != nil {
+			m.logger.Error("执行命令失败", zap.String("cmd", c), zap.Error(err))
+			result = err.Error()
+			break
+		}
+	}
+	m.emitFrame(map[string]any{"type": "cmd_response", "action": action, "result": result})
+}
+
+// hangupCall 挂断来电。本地挂断时模组不会上报 NO CARRIER，挂断成功后直接上报通话结束
+func (m *atModem) hangupCall() {
+	result := "ok"
+	if _, err := m.command("AT+CHUP", atCommandTimeout); err != nil {
+		// 部分模组不支持 AT+CHUP
+		if _, err = m.command("ATH", atCommandTimeout); err != nil {
+			m.logger.Error("挂断来电失败", zap.Error(err))
+			result = err.Error()
+		}
+	}
+	m.emitFrame(map[string]any{"type": "cmd_response", "action": "hangup_call", "result": result})
+	if result == "ok" && m.ringing.CompareAndSwap(true, false) {
+		m.emitFrame(map[string]any{"type": "call_disconnected"})
+	}
+}
+
+// sendSMS 以 PDU 模式发送短信，超长短信自动分片
+func (m *atModem) sendSMS(to, content, requestID string) {
+	ref := byte(m.concatRef.Add(1))
+	parts, err := EncodeSubmitPDUs(to, content, ref, true)
+
+	var refs []int
+	if err == nil {
+		for _, part := range parts {
+			var lines []string
+			if lines, err = m.commandWithData(fmt.Sprintf("AT+CMGS=%d", part.Length), part.Hex, atSendTimeout); err != nil {
+				break
+			}
+			for _, line := range lines {
+				if match := atCMGSPattern.FindStringSubmatch(line); match != nil {
+					mr, _ := strconv.Atoi(match[1])
+					refs = append(refs, mr)
+				}
+			}
+		}
+	}
+
+	if err != nil {
+		m.logger.Error("AT 发送短信失败", zap.String("to", to), zap.Error(err))
+	} else if requestID != "" && len(refs) == len(parts) {
+		m.awaitDelivery(requestID, to, refs)
+	}
+	m.emitFrame(sendResultFrame(requestID, to, err))
+}
+
+// awaitDelivery 登记等待送达报告的分片，同时清理过期的记录
+func (m *atModem) awaitDelivery(requestID, to string, refs []int) {
+	m.deliveryMu.Lock()
+	defer m.deliveryMu.Unlock()
+	if m.deliveries == nil {
+		m.deliveries = make(map[int]*atDelivery)
+	}
+	now := time.Now()
+	for mr, d := range m.deliveries {
+		if now.Sub(d.sentAt) > atDeliveryTTL {
+			delete(m.deliveries, mr)
+		}
+	}
+	d := &atDelivery{requestID: requestID, to: to, sentAt: now, pending: len(refs)}
+	for _, mr := range refs {
+		m.deliveries[mr] = d
+	}
+}
+
+// handleStatusReport 处理 SMS-STATUS-REPORT。TP-ST 0x00-0x1F 为已送达，0x20-0x3F 为短信中心仍在重试，
+// 0x40 及以上为最终失败。长短信全部分片送达后上报已送达，任一分片失败时上报未送达
+func (m *atModem) handleStatusReport(mr, status int) {
+	if status >= 0x20 && status < 0x40 {
+		return
+	}
+
+	m.deliveryMu.Lock()
+	d, ok := m.deliveries[mr]
+	if !ok {
+		m.deliveryMu.Unlock()
+		return
+	}
+	delete(m.deliveries, mr)
+	if d.done {
+		m.deliveryMu.Unlock()
+		return
+	}
+	delivered := status < 0x20
+	if delivered {
+		d.pending--
+	}
+	report := !delivered || d.pending == 0
+	if report {
+		d.done = true
+	}
+	m.deliveryMu.Unlock()
+
+	if report {
+		m.emitFrame(deliveryReportFrame(d.requestID, d.to, delivered, fmt.Sprintf("TP-ST %02X", status), statusReportReason(status)))
+	}
+}
+
+// sendUSSD 发起 USSD 会话，运营商的回复以 +CUSD 异步上报
+func (m *atModem) sendUSSD(code, requestID string) {
+	m.ussdMu.Lock()
+	m.ussdRequestID = requestID
+	m.ussdMu.Unlock()
+
+	// 15 = GSM 7bit 默认字母表
+	if _, err := m.command(fmt.Sprintf(`AT+CUSD=1,"%s",15`, code), atSendTimeout); err != nil {
+		m.logger.Error("AT 发送 USSD 失败", zap.String("code", code), zap.Error(err))
+		if m.takeUSSDRequest() == requestID {
+			m.emitFrame(ussdResultFrame(requestID, "", err))
+		}
+	}
+}
+
+// takeUSSDRequest 取出等待 +CUSD 上报的请求
+func (m *atModem) takeUSSDRequest() string {
+	m.ussdMu.Lock()
+	defer m.ussdMu.Unlock()
+	requestID := m.ussdRequestID
+	m.ussdRequestID = ""
+	return requestID
+}
+
+// handleUSSDLine 处理 +CUSD: <m>[,"<str>"[,<dcs>]] 上报，文本含换行时拼接到引号闭合
+func (m *atModem) handleUSSDLine(line string) {
+	text := line
+	if m.ussdBuf != "" {
+		text = m.ussdBuf + "\n" + line
+	}
+	if strings.Count(text, `"`)%2 == 1 && len(text) < 4096 {
+		m.ussdBuf = text
+		return
+	}
+	m.ussdBuf = ""
+
+	requestID := m.takeUSSDRequest()
+	if requestID == "" {
+		m.logger.Debug("收到无请求的 USSD 上报", zap.String("line", text))
+		return
+	}
+
+	match := atCUSDPattern.FindStringSubmatch(text)
+	if match == nil {
+		m.emitFrame(ussdResultFrame(requestID, "", fmt.Errorf("无法解析 USSD 上报: %s", text)))
+		return
+	}
+	var err error
+	switch match[1] {
+	case "0":
+	case "1":
+		// 运营商等待进一步输入（菜单），结束会话避免占用
+		go func() { _, _ = m.command("AT+CUSD=2", atCommandTimeout) }()
+	case "2":
+		err = errors.New("会话已被网络终止")
+	case "4":
+		err = errors.New("运营商不支持该 USSD 代码")
+	case "5":
+		err = errors.New("网络响应超时")
+	default:
+		err = fmt.Errorf("USSD 状态 %s", match[1])
+	}
+	if err != nil {
+		m.emitFrame(ussdResultFrame(requestID, "", err))
+		return
+	}
+	m.emitFrame(ussdResultFrame(requestID, decodeUSSDText(match[2], match[3]), nil))
+}
+
+// decodeUSSDText 按 dcs 解码 USSD 文本，UCS2 编码时模组上报的是十六进制串
+func decodeUSSDText(text, dcs string) string {
+	code, err := strconv.Atoi(dcs)
+	if err != nil || dcsEncoding(byte(code)) != pduEncodingUCS2 {
+		return text
+	}
+	data, err := hex.DecodeString(text)
+	if err != nil {
+		return text
+	}
+	return decodeUCS2(data)
+}
+
+// reportStatus 查询模组状态并以 status_response 上报
+func (m *atModem) reportStatus() {
+	mobile := map[string]any{}
+
+	query := func(cmd string) string {
+		lines, err := m.command(cmd, atCommandTimeout)
+		if err != nil {
+			return ""
+		}
+		return strings.Join(lines, "\n")
+	}
+
+	csq := 0
+	if match := atCSQPattern.FindStringSubmatch(query("AT+CSQ")); match != nil {
+		csq, _ = strconv.Atoi(match[1])
+	}
+	mobile["csq"] = csq
+	if csq == 0 || csq == 99 {
+		mobile["signal_level"] = 0
+		mobile["signal_desc"] = "无信号"
+		mobile["rssi"] = -113
+	} else {
+		mobile["signal_level"] = csq
+		mobile["rssi"] = -113 + 2*csq
+		switch {
+		case csq >= 20:
+			mobile["signal_desc"] = "强"
+		case csq >= 10:
+			mobile["signal_desc"] = "中"
+		default:
+			mobile["signal_desc"] = "弱"
+		}
+	}
+
+	// LTE 信号质量（部分 2G 模组不支持）
+	mobile["rsrp"] = -140
+	mobile["rsrq"] = -20
+	if match := atCESQPattern.FindStringSubmatch(query("AT+CESQ")); match != nil {
+		rsrq, _ := strconv.Atoi(match[1])
+		rsrp, _ := strconv.Atoi(match[2])
+		if rsrq != 255 {
+			mobile["rsrq"] = -20 + float64(rsrq)*0.5
+		}
+		if rsrp != 255 {
+			mobile["rsrp"] = -141 + rsrp
+		}
+	}
+
+	registered, roaming := false, false
+	for _, cmd := range []string{"AT+CEREG?", "AT+CREG?"} {
+		if match := atRegPattern.FindStringSubmatch(query(cmd)); match != nil {
+			stat, _ := strconv.Atoi(match[1])
+			if stat == 1 || stat == 5 {
+				registered, roaming = true, stat == 5
+				break
+			}
+		}
+	}
+	mobile["is_registered"] = registered
+	mobile["is_roaming"] = roaming
+
+	mobile["sim_ready"] = strings.Contains(query("AT+CPIN?"), "READY")
+
+	iccid := "unknown"
+	for _, cmd := range []string{"AT+CCID", "AT+QCCID", "AT+ICCID"} {
+		if match := atDigits.FindString(query(cmd)); match != "" {
+			iccid = match
+			break
+		}
+	}
+	mobile["iccid"] = iccid
+
+	imsi := "unknown"
+	if match := atDigits.FindString(query("AT+CIMI")); match != "" {
+		imsi = match
+	}
+	mobile["imsi"] = imsi
+
+	number := ""
+	if match := atCNUMPattern.FindStringSubmatch(query("AT+CNUM")); match != nil {
+		number = match[1]
+	}
+	mobile["number"] = number
+	mobile["uptime"] = 0
+
+	flymode := false
+	if match := atCFUNPattern.FindStringSubmatch(query("AT+CFUN?")); match != nil {
+		flymode = match[1] == "4" || match[1] == "0"
+	}
+
+	m.emitFrame(map[string]any{
+		"type":    "status_response",
+		"version": "AT " + strings.TrimSpace(query("AT+CGMR")),
+		"flymode": flymode,
+		"mobile":  mobile,
+	})
+}
@@ -0,0 +1,506 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/cookiejar"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dushixiang/uart_sms_forwarder/config"
+	"go.uber.org/zap"
+)
+
+const (
+	hilinkDefaultURL          = "http://192.168.8.1"
+	hilinkDefaultPollInterval = 10 * time.Second
+	// hilinkMaxFailures 连续请求失败次数，超过后视为断开
+	hilinkMaxFailures = 3
+)
+
+// hilinkError HiLink 接口返回的错误
+type hilinkError struct {
+	XMLName xml.Name `xml:"error"`
+	Code    int      `xml:"code"`
+	Message string   `xml:"message"`
+}
+
+func (e *hilinkError) Error() string {
+	return fmt.Sprintf("HiLink 错误码 %d %s", e.Code, e.Message)
+}
+
+type hilinkSesTokInfo struct {
+	SesInfo string `xml:"SesInfo"`
+	TokInfo string `xml:"TokInfo"`
+}
+
+type hilinkMessage struct {
+	Smstat  int    `xml:"Smstat"` // 0 未读，1 已读
+	Index   int    `xml:"Index"`
+	Phone   string `xml:"Phone"`
+	Content string `xml:"Content"`
+	Date    string `xml:"Date"`
+}
+
+type hilinkSMSList struct {
+	Count    int             `xml:"Count"`
+	Messages []hilinkMessage `xml:"Messages>Message"`
+}
+
+type hilinkMonitoringStatus struct {
+	SignalIcon     int `xml:"SignalIcon"`
+	SimStatus      int `xml:"SimStatus"`
+	ServiceStatus  int `xml:"ServiceStatus"`
+	RoamingStatus  int `xml:"RoamingStatus"`
+	ConnectionStat int `xml:"ConnectionStatus"`
+}
+
+type hilinkSignal struct {
+	RSSI string `xml:"rssi"`
+	RSRP string `xml:"rsrp"`
+	RSRQ string `xml:"rsrq"`
+}
+
+type hilinkDeviceInfo struct {
+	Iccid           string `xml:"Iccid"`
+	Imsi            string `xml:"Imsi"`
+	Msisdn          string `xml:"Msisdn"`
+	SoftwareVersion string `xml:"SoftwareVersion"`
+	DeviceName      string `xml:"DeviceName"`
+}
+
+type hilinkUSSDStatus struct {
+	Result int `xml:"result"` // 1 等待运营商回复，0 已完成
+}
+
+type hilinkUSSDContent struct {
+	Content string `xml:"content"`
+}
+
+type hilinkPLMN struct {
+	FullName  string `xml:"FullName"`
+	ShortName string `xml:"ShortName"`
+}
+
+// hilinkModem 华为 HiLink 网卡适配器，通过本地 HTTP 接口收发短信
+type hilinkModem struct {
+	logger *zap.Logger
+	config config.HiLinkConfig
+	client *http.Client
+
+	mu        sync.RWMutex
+	connected bool
+	emit      func(*ParsedMessage)
+}
+
+func newHiLinkModem(logger *zap.Logger, cfg *config.HiLinkConfig) *hilinkModem {
+	var c config.HiLinkConfig
+	if cfg != nil {
+		c = *cfg
+	}
+	if c.URL == "" {
+		c.URL = hilinkDefaultURL
+	}
+	c.URL = strings.TrimRight(c.URL, "/")
+
+	jar, _ := cookiejar.New(nil)
+	return &hilinkModem{
+		logger: logger.Named("hilink"),
+		config: c,
+		client: &http.Client{
+			Timeout: 10 * time.Second,
+			Jar:     jar,
+		},
+	}
+}
+
+func (m *hilinkModem) Name() string {
+	return BackendHiLink
+}
+
+// Run 登录并轮询收件箱
+func (m *hilinkModem) Run(ctx context.Context, ready func(device string), emit func(*ParsedMessage)) error {
+	if m.config.Password != "" {
+		if err := m.login(ctx); err != nil {
+			return fmt.Errorf("HiLink 登录失败: %w", err)
+		}
+	} else if _, err := m.sessionToken(ctx); err != nil {
+		return fmt.Errorf("连接 HiLink 失败: %w", err)
+	}
+
+	m.mu.Lock()
+	m.connected = true
+	m.emit = emit
+	m.mu.Unlock()
+	defer func() {
+		m.mu.Lock()
+		m.connected = false
+		m.mu.Unlock()
+	}()
+
+	ready(m.config.URL)
+
+	interval := hilinkDefaultPollInterval
+	if m.config.PollInterval > 0 {
+		interval = time.Duration(m.config.PollInterval) * time.Second
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	failures := 0
+	for {
+		if err := m.pollInbox(ctx); err != nil {
+			failures++
+			m.logger.Warn("轮询收件箱失败", zap.Error(err), zap.Int("failures", failures))
+			if failures >= hilinkMaxFailures {
+				return err
+			}
+		} else {
+			failures = 0
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// sessionToken 获取会话 Cookie 和请求校验令牌
+func (m *hilinkModem) sessionToken(ctx context.Context) (*hilinkSesTokInfo, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, m.config.URL+"/api/webserver/SesTokInfo", nil)
+	if err != nil {
+		return nil, err
+	}
+	body, err := m.do(req)
+	if err != nil {
+		return nil, err
+	}
+	var info hilinkSesTokInfo
+	if err := xml.Unmarshal(body, &info); err != nil {
+		return nil, fmt.Errorf("解析会话令牌失败: %w", err)
+	}
+	return &info, nil
+}
+
+// login 使用 password_type 4（SHA256）登录
+func (m *hilinkModem) login(ctx context.Context) error {
+	token, err := m.sessionToken(ctx)
+	if err != nil {
+		return err
+	}
+
+	username := m.config.Username
+	if username == "" {
+		username = "admin"
+	}
+	sha := func(s string) string {
+		sum := sha256.Sum256([]byte(s))
+		return hex.EncodeToString(sum[:])
+	}
+	passwordHash := base64.StdEncoding.EncodeToString([]byte(sha(m.config.Password)))
+	password := base64.StdEncoding.EncodeToString([]byte(sha(username + passwordHash + token.TokInfo)))
+
+	request := fmt.Sprintf(`<?xml version="1.0" encoding="UTF-8"?><request><Username>%s</Username><Password>%s</Password><password_type>4</password_type></request>`,
+		xmlEscape(username), password)
+	_, err = m.postWithToken(ctx, token, "/api/user/login", request)
+	return err
+}
+
+// post 获取新令牌后发送 XML 请求（部分固件的令牌只能使用一次）
+func (m *hilinkModem) post(ctx context.Context, path, request string) ([]byte, error) {
+	token, err := m.sessionToken(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return m.postWithToken(ctx, token, path, request)
+}
+
+func (m *hilinkModem) postWithToken(ctx context.Context, token *hilinkSesTokInfo, path, request string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, m.config.URL+path, bytes.NewReader([]byte(request)))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded; charset=UTF-8")
+	req.Header.Set("__RequestVerificationToken", token.TokInfo)
+	if token.SesInfo != "" {
+		req.Header.Set("Cookie", token.SesInfo)
+	}
+	return m.do(req)
+}
+
+// get 发送 GET 请求
+func (m *hilinkModem) get(ctx context.Context, path string, target any) error {
+	token, err := m.sessionToken(ctx)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, m.config.URL+path, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("__RequestVerificationToken", token.TokInfo)
+	if token.SesInfo != "" {
+		req.Header.Set("Cookie", token.SesInfo)
+	}
+	body, err := m.do(req)
+	if err != nil {
+		return err
+	}
+	return xml.Unmarshal(body, target)
+}
+
+// do 执行请求并检查 HiLink 的 <error> 响应
+func (m *hilinkModem) do(req *http.Request) ([]byte, error) {
+	resp, err := m.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("请求 HiLink 失败: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("读取响应失败: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("请求失败，状态码: %d", resp.StatusCode)
+	}
+
+	if bytes.Contains(body, []byte("<error>")) {
+		var hErr hilinkError
+		if err := xml.Unmarshal(body, &hErr); err == nil {
+			return nil, &hErr
+		}
+	}
+	return body, nil
+}
+
+// pollInbox 拉取未读短信并上报，处理后标记为已读或删除
+func (m *hilinkModem) pollInbox(ctx context.Context) error {
+	request := `<?xml version="1.0" encoding="UTF-8"?><request><PageIndex>1</PageIndex><ReadCount>20</ReadCount><BoxType>1</BoxType><SortType>0</SortType><Ascending>0</Ascending><UnreadPreferred>1</UnreadPreferred></request>`
+	body, err := m.post(ctx, "/api/sms/sms-list", request)
+	if err != nil {
+		return err
+	}
+
+	var list hilinkSMSList
+	if err := xml.Unmarshal(body, &list); err != nil {
+		return fmt.Errorf("解析短信列表失败: %w", err)
+	}
+
+	for _, msg := range list.Messages {
+		if msg.Smstat != 0 {
+			continue
+		}
+
+		timestamp := time.Now()
+		if t, err := time.ParseInLocation(time.DateTime, msg.Date, time.Local); err == nil {
+			timestamp = t
+		}
+		m.emitFrame(map[string]any{
+			"type":      "incoming_sms",
+			"from":      msg.Phone,
+			"content":   msg.Content,
+			"timestamp": timestamp.Unix(),
+		})
+
+		indexRequest := fmt.Sprintf(`<?xml version="1.0" encoding="UTF-8"?><request><Index>%d</Index></request>`, msg.Index)
+		path := "/api/sms/set-read"
+		if m.config.DeleteAfterRead {
+			path = "/api/sms/delete-sms"
+		}
+		if _, err := m.post(ctx, path, indexRequest); err != nil {
+			m.logger.Warn("更新短信状态失败", zap.Int("index", msg.Index), zap.Error(err))
+		}
+	}
+	return nil
+}
+
+func (m *hilinkModem) emitFrame(payload map[string]any) {
+	m.mu.RLock()
+	emit := m.emit
+	m.mu.RUnlock()
+	if emit != nil {
+		emitFrame(emit, payload)
+	}
+}
+
+// SendCommand 将统一命令翻译为 HiLink 接口调用，结果异步上报
+func (m *hilinkModem) SendCommand(cmd map[string]any) error {
+	m.mu.RLock()
+	connected := m.connected
+	m.mu.RUnlock()
+	if !connected {
+		return fmt.Errorf("HiLink 设备未连接")
+	}
+
+	action, _ := cmd["action"].(string)
+	switch action {
+	case "send_sms":
+		to, _ := cmd["to"].(string)
+		content, _ := cmd["content"].(string)
+		requestID, _ := cmd["request_id"].(string)
+		go m.sendSMS(to, content, requestID)
+	case "send_ussd":
+		code, _ := cmd["code"].(string)
+		requestID, _ := cmd["request_id"].(string)
+		go m.sendUSSD(code, requestID)
+	case "get_status":
+		go m.reportStatus()
+	case "reboot_mcu":
+		go func() {
+			ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+			defer cancel()
+			result := "ok"
+			request := `<?xml version="1.0" encoding="UTF-8"?><request><Control>1</Control></request>`
+			if _, err := m.post(ctx, "/api/device/control", request); err != nil {
+				result = err.Error()
+			}
+			m.emitFrame(map[string]any{"type": "cmd_response", "action": "reboot_mcu", "result": result})
+		}()
+	default:
+		return fmt.Errorf("HiLink 后端不支持的命令: %s", action)
+	}
+	return nil
+}
+
+// sendSMS 调用 send-sms 接口发送短信
+func (m *hilinkModem) sendSMS(to, content, requestID string) {
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	request := fmt.Sprintf(`<?xml version="1.0" encoding="UTF-8"?><request><Index>-1</Index><Phones><Phone>%s</Phone></Phones><Sca></Sca><Content>%s</Content><Length>%d</Length><Reserved>1</Reserved><Date>%s</Date></request>`,
+		xmlEscape(to),
+		xmlEscape(content),
+		len([]rune(content)),
+		time.Now().Format(time.DateTime),
+	)
+	_, err := m.post(ctx, "/api/sms/send-sms", request)
+	if err != nil {
+		m.logger.Error("HiLink 发送短信失败", zap.String("to", to), zap.Error(err))
+	}
+
+	m.emitFrame(sendResultFrame(requestID, to, err))
+}
+
+// sendUSSD 调用 ussd/send 接口，轮询 ussd/status 直到运营商回复后读取 ussd/get
+func (m *hilinkModem) sendUSSD(code, requestID string) {
+	ctx, cancel := context.WithTimeout(context.Background(), ussdTimeout)
+	defer cancel()
+
+	text, err := func() (string, error) {
+		request := fmt.Sprintf(`<?xml version="1.0" encoding="UTF-8"?><request><content>%s</content><codeType>CodeType</codeType><timeout></timeout></request>`,
+			xmlEscape(code))
+		if _, err := m.post(ctx, "/api/ussd/send", request); err != nil {
+			return "", err
+		}
+		for {
+			select {
+			case <-ctx.Done():
+				return "", ctx.Err()
+			case <-time.After(time.Second):
+			}
+			var status hilinkUSSDStatus
+			if err := m.get(ctx, "/api/ussd/status", &status); err != nil {
+				return "", err
+			}
+			if status.Result == 0 {
+				break
+			}
+		}
+		var content hilinkUSSDContent
+		if err := m.get(ctx, "/api/ussd/get", &content); err != nil {
+			return "", err
+		}
+		return content.Content, nil
+	}()
+	if err != nil {
+		m.logger.Error("HiLink 发送 USSD 失败", zap.String("code", code), zap.Error(err))
+	}
+
+	m.emitFrame(ussdResultFrame(requestID, text, err))
+}
+
+// reportStatus 查询设备状态并以 status_response 上报
+func (m *hilinkModem) reportStatus() {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	var status hilinkMonitoringStatus
+	if err := m.get(ctx, "/api/monitoring/status", &status); err != nil {
+		m.logger.Warn("获取设备状态失败", zap.Error(err))
+		return
+	}
+	var signal hilinkSignal
+	_ = m.get(ctx, "/api/device/signal", &signal)
+	var info hilinkDeviceInfo
+	_ = m.get(ctx, "/api/device/information", &info)
+	var plmn hilinkPLMN
+	_ = m.get(ctx, "/api/net/current-plmn", &plmn)
+
+	// SignalIcon 为 0-5 格，折算为近似的 CSQ
+	csq := status.SignalIcon * 6
+	signalDesc := "无信号"
+	switch {
+	case status.SignalIcon >= 4:
+		signalDesc = "强"
+	case status.SignalIcon >= 2:
+		signalDesc = "中"
+	case status.SignalIcon >= 1:
+		signalDesc = "弱"
+	}
+
+	mobile := map[string]any{
+		"is_registered": status.ServiceStatus == 2,
+		"is_roaming":    status.RoamingStatus == 1,
+		"iccid":         info.Iccid,
+		"imsi":          info.Imsi,
+		"number":        info.Msisdn,
+		"sim_ready":     status.SimStatus == 1,
+		"signal_level":  csq,
+		"signal_desc":   signalDesc,
+		"csq":           csq,
+		"rssi":          parseDBm(signal.RSSI, -113),
+		"rsrp":          parseDBm(signal.RSRP, -140),
+		"rsrq":          parseDBm(signal.RSRQ, -20),
+		"uptime":        0,
+	}
+	if plmn.FullName != "" {
+		mobile["operator"] = plmn.FullName
+	}
+
+	m.emitFrame(map[string]any{
+		"type":    "status_response",
+		"version": strings.TrimSpace("HiLink " + info.DeviceName + " " + info.SoftwareVersion),
+		"mobile":  mobile,
+	})
+}
+
+// parseDBm 解析 "-85dBm" 格式的信号值
+func parseDBm(value string, fallback int) int {
+	value = strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(value), "dBm"))
+	value = strings.TrimPrefix(value, ">=")
+	value = strings.TrimPrefix(value, "<=")
+	v, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return fallback
+	}
+	return int(v)
+}
+
+// xmlEscape 转义 XML 文本
+func xmlEscape(s string) string {
+	var buf bytes.Buffer
+	_ = xml.EscapeText(&buf, []byte(s))
+	return buf.String()
+}
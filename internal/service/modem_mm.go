@@ -0,0 +1,565 @@
+//go:build linux
+
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dushixiang/uart_sms_forwarder/config"
+	"github.com/godbus/dbus/v5"
+	"go.uber.org/zap"
+)
+
+const (
+	mmService        = "org.freedesktop.ModemManager1"
+	mmPath           = "/org/freedesktop/ModemManager1"
+	mmModemIface     = "org.freedesktop.ModemManager1.Modem"
+	mmModem3gppIface = "org.freedesktop.ModemManager1.Modem.Modem3gpp"
+	mmUssdIface      = "org.freedesktop.ModemManager1.Modem.Modem3gpp.Ussd"
+	mmMessagingIface = "org.freedesktop.ModemManager1.Modem.Messaging"
+	mmVoiceIface     = "org.freedesktop.ModemManager1.Modem.Voice"
+	mmSmsIface       = "org.freedesktop.ModemManager1.Sms"
+	mmCallIface      = "org.freedesktop.ModemManager1.Call"
+	mmSimIface       = "org.freedesktop.ModemManager1.Sim"
+	mmObjectManager  = "org.freedesktop.DBus.ObjectManager"
+
+	// MMSmsState
+	mmSmsStateReceiving = 2
+	mmSmsStateReceived  = 3
+
+	// MMModem3gppRegistrationState
+	mm3gppRegHome    = 1
+	mm3gppRegRoaming = 5
+
+	// MMCallDirection
+	mmCallDirectionIncoming = 1
+
+	// mmReceiveTimeout 长短信分段接收的最长等待时间
+	mmReceiveTimeout = 60 * time.Second
+)
+
+// mmModem 通过 ModemManager 的 DBus 接口驱动模组，
+// 适用于已由 ModemManager 管理的 Linux 主机，无需独占串口。
+type mmModem struct {
+	logger *zap.Logger
+	config config.ModemManagerConfig
+
+	mu        sync.RWMutex
+	conn      *dbus.Conn
+	modem     dbus.ObjectPath
+	emit      func(*ParsedMessage)
+	ringing   map[dbus.ObjectPath]bool
+	connected bool
+}
+
+func newMMModem(logger *zap.Logger, cfg *config.ModemManagerConfig) (ModemAdapter, error) {
+	var c config.ModemManagerConfig
+	if cfg != nil {
+		c = *cfg
+	}
+	return &mmModem{
+		logger:  logger.Named("modemmanager"),
+		config:  c,
+		ringing: make(map[dbus.ObjectPath]bool),
+	}, nil
+}
+
+func (m *mmModem) Name() string {
+	return BackendModemManager
+}
+
+// Run 连接系统总线，定位模组后监听短信和来电信号
+func (m *mmModem) Run(ctx context.Context, ready func(device string), emit func(*ParsedMessage)) error {
+	conn, err := dbus.ConnectSystemBus()
+	if err != nil {
+		return fmt.Errorf("连接系统 DBus 失败: %w", err)
+	}
+	defer conn.Close()
+
+	modem, err := m.findModem(conn)
+	if err != nil {
+		return err
+	}
+
+	signals := make(chan *dbus.Signal, 32)
+	conn.Signal(signals)
+	matches := [][]dbus.MatchOption{
+		{dbus.WithMatchObjectPath(modem), dbus.WithMatchInterface(mmMessagingIface), dbus.WithMatchMember("Added")},
+		{dbus.WithMatchObjectPath(modem), dbus.WithMatchInterface(mmVoiceIface), dbus.WithMatchMember("CallAdded")},
+		{dbus.WithMatchObjectPath(modem), dbus.WithMatchInterface(mmVoiceIface), dbus.WithMatchMember("CallDeleted")},
+		{dbus.WithMatchObjectPath(mmPath), dbus.WithMatchInterface(mmObjectManager), dbus.WithMatchMember("InterfacesRemoved")},
+	}
+	for _, match := range matches {
+		if err := conn.AddMatchSignal(match...); err != nil {
+			return fmt.Errorf("订阅 DBus 信号失败: %w", err)
+		}
+	}
+
+	m.mu.Lock()
+	m.conn = conn
+	m.modem = modem
+	m.emit = emit
+	m.connected = true
+	m.mu.Unlock()
+	defer func() {
+		m.mu.Lock()
+		m.conn = nil
+		m.connected = false
+		m.mu.Unlock()
+	}()
+
+	device := string(modem)
+	if model, err := m.property(modem, mmModemIface, "Model"); err == nil {
+		if s, ok := model.(string); ok && s != "" {
+			device = s + " (" + string(modem) + ")"
+		}
+	}
+	ready(device)
+
+	// 处理已存储的短信
+	m.readStoredMessages(ctx)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case sig, ok := <-signals:
+			if !ok {
+				return fmt.Errorf("DBus 连接已关闭")
+			}
+			if err := m.handleSignal(ctx, sig); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// findModem 按配置查找模组，未配置时使用第一个
+func (m *mmModem) findModem(conn *dbus.Conn) (dbus.ObjectPath, error) {
+	var objects map[dbus.ObjectPath]map[string]map[string]dbus.Variant
+	err := conn.Object(mmService, mmPath).Call(mmObjectManager+".GetManagedObjects", 0).Store(&objects)
+	if err != nil {
+		return "", fmt.Errorf("查询 ModemManager 失败: %w", err)
+	}
+
+	for path, ifaces := range objects {
+		props, ok := ifaces[mmModemIface]
+		if !ok {
+			continue
+		}
+		if m.config.Modem == "" || string(path) == m.config.Modem {
+			return path, nil
+		}
+		if v, ok := props["EquipmentIdentifier"]; ok {
+			if id, _ := v.Value().(string); id == m.config.Modem {
+				return path, nil
+			}
+		}
+	}
+
+	if m.config.Modem != "" {
+		return "", fmt.Errorf("未找到模组: %s", m.config.Modem)
+	}
+	return "", fmt.Errorf("ModemManager 中没有可用的模组")
+}
+
+// handleSignal 处理 DBus 信号，模组被移除时返回错误以触发重连
+func (m *mmModem) handleSignal(ctx context.Context, sig *dbus.Signal) error {
+	m.mu.RLock()
+	modem := m.modem
+	m.mu.RUnlock()
+
+	switch sig.Name {
+	case mmMessagingIface + ".Added":
+		if len(sig.Body) < 2 {
+			return nil
+		}
+		path, _ := sig.Body[0].(dbus.ObjectPath)
+		received, _ := sig.Body[1].(bool)
+		if received {
+			go m.handleReceivedSMS(ctx, path)
+		}
+	case mmVoiceIface + ".CallAdded":
+		if len(sig.Body) < 1 {
+			return nil
+		}
+		path, _ := sig.Body[0].(dbus.ObjectPath)
+		m.handleCallAdded(path)
+	case mmVoiceIface + ".CallDeleted":
+		if len(sig.Body) < 1 {
+			return nil
+		}
+		path, _ := sig.Body[0].(dbus.ObjectPath)
+		m.mu.Lock()
+		wasRinging := m.ringing[path]
+		delete(m.ringing, path)
+		m.mu.Unlock()
+		if wasRinging {
+			m.emitFrame(map[string]any{"type": "call_disconnected"})
+		}
+	case mmObjectManager + ".InterfacesRemoved":
+		if len(sig.Body) < 1 {
+			return nil
+		}
+		if path, _ := sig.Body[0].(dbus.ObjectPath); path == modem {
+			return fmt.Errorf("模组已移除: %s", modem)
+		}
+	}
+	return nil
+}
+
+// readStoredMessages 上报并删除已存储的短信
+func (m *mmModem) readStoredMessages(ctx context.Context) {
+	m.mu.RLock()
+	conn, modem := m.conn, m.modem
+	m.mu.RUnlock()
+
+	var paths []dbus.ObjectPath
+	if err := conn.Object(mmService, modem).Call(mmMessagingIface+".List", 0).Store(&paths); err != nil {
+		m.logger.Warn("读取已存储短信失败", zap.Error(err))
+		return
+	}
+	for _, path := range paths {
+		state, err := m.property(path, mmSmsIface, "State")
+		if err != nil {
+			continue
+		}
+		if s, _ := state.(uint32); s == mmSmsStateReceived || s == mmSmsStateReceiving {
+			m.handleReceivedSMS(ctx, path)
+		}
+	}
+}
+
+// handleReceivedSMS 等待短信接收完成（长短信由 ModemManager 合并）后上报并删除
+func (m *mmModem) handleReceivedSMS(ctx context.Context, path dbus.ObjectPath) {
+	deadline := time.Now().Add(mmReceiveTimeout)
+	for {
+		state, err := m.property(path, mmSmsIface, "State")
+		if err != nil {
+			m.logger.Warn("读取短信状态失败", zap.String("path", string(path)), zap.Error(err))
+			return
+		}
+		if s, _ := state.(uint32); s == mmSmsStateReceived {
+			break
+		}
+		if time.Now().After(deadline) {
+			m.logger.Warn("等待短信接收完成超时", zap.String("path", string(path)))
+			break
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(time.Second):
+		}
+	}
+
+	number, _ := m.property(path, mmSmsIface, "Number")
+	text, _ := m.property(path, mmSmsIface, "Text")
+	from, _ := number.(string)
+	content, _ := text.(string)
+
+	timestamp := time.Now().Unix()
+	if v, err := m.property(path, mmSmsIface, "Timestamp"); err == nil {
+		if s, ok := v.(string); ok {
+			if t, err := parseMMTimestamp(s); err == nil {
+				timestamp = t.Unix()
+			}
+		}
+	}
+
+	m.emitFrame(map[string]any{
+		"type":      "incoming_sms",
+		"from":      from,
+		"content":   content,
+		"timestamp": timestamp,
+	})
+
+	m.deleteSMS(path)
+}
+
+// parseMMTimestamp 解析 ModemManager 的时间格式，如 2024-01-02T15:04:05+08:00 或 2024-01-02T15:04:05+08
+func parseMMTimestamp(s string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	return time.Parse("2006-01-02T15:04:05-07", s)
+}
+
+// deleteSMS 从模组中删除短信
+func (m *mmModem) deleteSMS(path dbus.ObjectPath) {
+	m.mu.RLock()
+	conn, modem := m.conn, m.modem
+	m.mu.RUnlock()
+	if conn == nil {
+		return
+	}
+	if err := conn.Object(mmService, modem).Call(mmMessagingIface+".Delete", 0, path).Err; err != nil {
+		m.logger.Warn("删除短信失败", zap.String("path", string(path)), zap.Error(err))
+	}
+}
+
+// handleCallAdded 来电时上报 incoming_call
+func (m *mmModem) handleCallAdded(path dbus.ObjectPath) {
+	direction, err := m.property(path, mmCallIface, "Direction")
+	if err != nil {
+		return
+	}
+	if d, _ := direction.(uint32); d != mmCallDirectionIncoming {
+		return
+	}
+	number, _ := m.property(path, mmCallIface, "Number")
+	from, _ := number.(string)
+
+	m.mu.Lock()
+	m.ringing[path] = true
+	m.mu.Unlock()
+
+	m.emitFrame(map[string]any{
+		"type": "incoming_call",
+		"from": from,
+	})
+}
+
+// hangupCalls 挂断所有正在响铃的来电，CallDeleted 信号到达后上报通话结束
+func (m *mmModem) hangupCalls() {
+	m.mu.RLock()
+	conn := m.conn
+	paths := make([]dbus.ObjectPath, 0, len(m.ringing))
+	for path := range m.ringing {
+		paths = append(paths, path)
+	}
+	m.mu.RUnlock()
+
+	result := "ok"
+	if conn == nil {
+		result = "DBus 未连接"
+	}
+	for _, path := range paths {
+		if conn == nil {
+			break
+		}
+		if err := conn.Object(mmService, path).Call(mmCallIface+".Hangup", 0).Err; err != nil {
+			m.logger.Error("挂断来电失败", zap.String("path", string(path)), zap.Error(err))
+			result = err.Error()
+		}
+	}
+	m.emitFrame(map[string]any{"type": "cmd_response", "action": "hangup_call", "result": result})
+}
+
+// property 读取 DBus 对象属性
+func (m *mmModem) property(path dbus.ObjectPath, iface, name string) (any, error) {
+	m.mu.RLock()
+	conn := m.conn
+	m.mu.RUnlock()
+	if conn == nil {
+		return nil, fmt.Errorf("DBus 未连接")
+	}
+	v, err := conn.Object(mmService, path).GetProperty(iface + "." + name)
+	if err != nil {
+		return nil, err
+	}
+	return v.Value(), nil
+}
+
+func (m *mmModem) emitFrame(payload map[string]any) {
+	m.mu.RLock()
+	emit := m.emit
+	m.mu.RUnlock()
+	if emit != nil {
+		emitFrame(emit, payload)
+	}
+}
+
+// SendCommand 将统一命令翻译为 ModemManager 方法调用，结果异步上报
+func (m *mmModem) SendCommand(cmd map[string]any) error {
+	m.mu.RLock()
+	connected := m.connected
+	m.mu.RUnlock()
+	if !connected {
+		return fmt.Errorf("ModemManager 模组未连接")
+	}
+
+	action, _ := cmd["action"].(string)
+	switch action {
+	case "send_sms":
+		to, _ := cmd["to"].(string)
+		content, _ := cmd["content"].(string)
+		requestID, _ := cmd["request_id"].(string)
+		go m.sendSMS(to, content, requestID)
+	case "send_ussd":
+		code, _ := cmd["code"].(string)
+		requestID, _ := cmd["request_id"].(string)
+		go m.sendUSSD(code, requestID)
+	case "get_status":
+		go m.reportStatus()
+	case "set_flymode":
+		enabled, _ := cmd["enabled"].(bool)
+		go m.control(action, func(modem dbus.BusObject) error {
+			return modem.Call(mmModemIface+".Enable", 0, !enabled).Err
+		})
+	case "reset_stack":
+		go m.control(action, func(modem dbus.BusObject) error {
+			if err := modem.Call(mmModemIface+".Enable", 0, false).Err; err != nil {
+				return err
+			}
+			return modem.Call(mmModemIface+".Enable", 0, true).Err
+		})
+	case "reboot_mcu":
+		go m.control(action, func(modem dbus.BusObject) error {
+			return modem.Call(mmModemIface+".Reset", 0).Err
+		})
+	case "hangup_call":
+		go m.hangupCalls()
+	default:
+		return fmt.Errorf("ModemManager 后端不支持的命令: %s", action)
+	}
+	return nil
+}
+
+// control 执行控制类命令并上报 cmd_response
+func (m *mmModem) control(action string, f func(modem dbus.BusObject) error) {
+	m.mu.RLock()
+	conn, modem := m.conn, m.modem
+	m.mu.RUnlock()
+
+	result := "ok"
+	if conn == nil {
+		result = "DBus 未连接"
+	} else if err := f(conn.Object(mmService, modem)); err != nil {
+		m.logger.Error("执行命令失败", zap.String("action", action), zap.Error(err))
+		result = err.Error()
+	}
+	m.emitFrame(map[string]any{"type": "cmd_response", "action": action, "result": result})
+}
+
+// sendSMS 创建短信对象并发送，发送后删除
+func (m *mmModem) sendSMS(to, content, requestID string) {
+	m.mu.RLock()
+	conn, modem := m.conn, m.modem
+	m.mu.RUnlock()
+
+	err := func() error {
+		if conn == nil {
+			return fmt.Errorf("DBus 未连接")
+		}
+		props := map[string]dbus.Variant{
+			"number": dbus.MakeVariant(to),
+			"text":   dbus.MakeVariant(content),
+		}
+		var path dbus.ObjectPath
+		if err := conn.Object(mmService, modem).Call(mmMessagingIface+".Create", 0, props).Store(&path); err != nil {
+			return fmt.Errorf("创建短信失败: %w", err)
+		}
+		defer m.deleteSMS(path)
+
+		if err := conn.Object(mmService, path).Call(mmSmsIface+".Send", 0).Err; err != nil {
+			return fmt.Errorf("发送短信失败: %w", err)
+		}
+		return nil
+	}()
+	if err != nil {
+		m.logger.Error("ModemManager 发送短信失败", zap.String("to", to), zap.Error(err))
+	}
+
+	m.emitFrame(sendResultFrame(requestID, to, err))
+}
+
+// sendUSSD 发起 USSD 会话，Initiate 在运营商回复后返回
+func (m *mmModem) sendUSSD(code, requestID string) {
+	m.mu.RLock()
+	conn, modem := m.conn, m.modem
+	m.mu.RUnlock()
+
+	var reply string
+	err := func() error {
+		if conn == nil {
+			return fmt.Errorf("DBus 未连接")
+		}
+		ussd := conn.Object(mmService, modem)
+		if err := ussd.Call(mmUssdIface+".Initiate", 0, code).Store(&reply); err != nil {
+			return err
+		}
+		// 运营商等待进一步输入（菜单）时结束会话，避免占用
+		_ = ussd.Call(mmUssdIface+".Cancel", 0).Err
+		return nil
+	}()
+	if err != nil {
+		m.logger.Error("ModemManager 发送 USSD 失败", zap.String("code", code), zap.Error(err))
+	}
+
+	m.emitFrame(ussdResultFrame(requestID, reply, err))
+}
+
+// reportStatus 查询模组和 SIM 卡信息并以 status_response 上报
+func (m *mmModem) reportStatus() {
+	m.mu.RLock()
+	modem := m.modem
+	m.mu.RUnlock()
+
+	status := map[string]any{"uptime": 0}
+
+	// SignalQuality 为 (百分比, 是否最新)
+	if v, err := m.property(modem, mmModemIface, "SignalQuality"); err == nil {
+		if sq, ok := v.([]any); ok && len(sq) > 0 {
+			if percent, ok := sq[0].(uint32); ok {
+				csq := int(percent) * 31 / 100
+				status["signal_level"] = csq
+				status["csq"] = csq
+				status["rssi"] = -113 + csq*2
+				status["signal_desc"] = fmt.Sprintf("%d%%", percent)
+			}
+		}
+	}
+	if v, err := m.property(modem, mmModemIface, "OwnNumbers"); err == nil {
+		if numbers, ok := v.([]string); ok && len(numbers) > 0 {
+			status["number"] = numbers[0]
+		}
+	}
+	if v, err := m.property(modem, mmModem3gppIface, "RegistrationState"); err == nil {
+		state, _ := v.(uint32)
+		status["is_registered"] = state == mm3gppRegHome || state == mm3gppRegRoaming
+		status["is_roaming"] = state == mm3gppRegRoaming
+	}
+	if v, err := m.property(modem, mmModem3gppIface, "OperatorName"); err == nil {
+		if s, _ := v.(string); s != "" {
+			status["operator"] = s
+		}
+	}
+
+	if v, err := m.property(modem, mmModemIface, "Sim"); err == nil {
+		if sim, ok := v.(dbus.ObjectPath); ok && sim != "/" {
+			status["sim_ready"] = true
+			if v, err := m.property(sim, mmSimIface, "SimIdentifier"); err == nil {
+				status["iccid"] = v
+			}
+			if v, err := m.property(sim, mmSimIface, "Imsi"); err == nil {
+				status["imsi"] = v
+			}
+		} else {
+			status["sim_ready"] = false
+		}
+	}
+
+	version := "ModemManager"
+	var parts []string
+	for _, name := range []string{"Manufacturer", "Model", "Revision"} {
+		if v, err := m.property(modem, mmModemIface, name); err == nil {
+			if s, _ := v.(string); s != "" {
+				parts = append(parts, s)
+			}
+		}
+	}
+	if len(parts) > 0 {
+		version += " " + strings.Join(parts, " ")
+	}
+
+	m.emitFrame(map[string]any{
+		"type":    "status_response",
+		"version": version,
+		"mobile":  status,
+	})
+}
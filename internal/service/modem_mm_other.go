@@ -0,0 +1,15 @@
+//go:build !linux
+
+package service
+
+import (
+	"fmt"
+
+	"github.com/dushixiang/uart_sms_forwarder/config"
+	"go.uber.org/zap"
+)
+
+// newMMModem ModemManager 依赖 Linux 系统总线，其他平台不支持
+func newMMModem(logger *zap.Logger, cfg *config.ModemManagerConfig) (ModemAdapter, error) {
+	return nil, fmt.Errorf("ModemManager 后端仅支持 Linux")
+}
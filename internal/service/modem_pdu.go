@@ -0,0 +1,526 @@
+package service
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf16"
+)
+
+// GSM 03.38 默认字母表
+var gsm7Alphabet = []rune("@£$¥èéùìòÇ\nØø\rÅåΔ_ΦΓΛΩΠΨΣΘΞ\x1bÆæßÉ !\"#¤%&'()*+,-./0123456789:;<=>?" +
+	"¡ABCDEFGHIJKLMNOPQRSTUVWXYZÄÖÑÜ§¿abcdefghijklmnopqrstuvwxyzäöñüà")
+
+// GSM 03.38 扩展字母表（前缀 0x1B）
+var gsm7Extension = map[byte]rune{
+	0x0A: '\f',
+	0x14: '^',
+	0x28: '{',
+	0x29: '}',
+	0x2F: '\\',
+	0x3C: '[',
+	0x3D: '~',
+	0x3E: ']',
+	0x40: '|',
+	0x65: '€',
+}
+
+const gsm7Escape = 0x1B
+
+var (
+	gsm7Reverse    = map[rune]byte{}
+	gsm7ExtReverse = map[rune]byte{}
+
+	errPDUTooShort = errors.New("PDU 长度不足")
+)
+
+func init() {
+	for i, r := range gsm7Alphabet {
+		if i == gsm7Escape {
+			continue
+		}
+		gsm7Reverse[r] = byte(i)
+	}
+	for b, r := range gsm7Extension {
+		gsm7ExtReverse[r] = b
+	}
+}
+
+// PDU 编码方式
+const (
+	pduEncodingGSM7 = iota
+	pduEncoding8Bit
+	pduEncodingUCS2
+)
+
+// PDU 消息类型（first octet 低两位）
+const (
+	pduTypeDeliver      = 0x00
+	pduTypeSubmit       = 0x01
+	pduTypeStatusReport = 0x02
+)
+
+// SMSPDU 解码后的短信 PDU
+type SMSPDU struct {
+	Type      int       // pduTypeDeliver / pduTypeStatusReport
+	From      string    // 发送方号码
+	Content   string    // 短信内容
+	Timestamp time.Time // 短信中心时间戳
+	// 长短信分片信息，ConcatTotal 为 0 表示非长短信
+	ConcatRef   int
+	ConcatTotal int
+	ConcatSeq   int
+
+	// 状态报告字段
+	MessageRef int // 对应发送时的 TP-MR
+	Status     int // TP-ST，0x00-0x1F 表示已送达
+}
+
+// encodeGSM7 将文本转换为 GSM7 septet 序列，ok=false 表示包含无法用 GSM7 表示的字符
+func encodeGSM7(text string) (septets []byte, ok bool) {
+	for _, r := range text {
+		if b, exists := gsm7Reverse[r]; exists {
+			septets = append(septets, b)
+			continue
+		}
+		if b, exists := gsm7ExtReverse[r]; exists {
+			septets = append(septets, gsm7Escape, b)
+			continue
+		}
+		return nil, false
+	}
+	return septets, true
+}
+
+// decodeGSM7 将 septet 序列转换为文本
+func decodeGSM7(septets []byte) string {
+	var sb strings.Builder
+	for i := 0; i < len(septets); i++ {
+		s := septets[i] & 0x7F
+		if s == gsm7Escape && i+1 < len(septets) {
+			i++
+			if r, ok := gsm7Extension[septets[i]&0x7F]; ok {
+				sb.WriteRune(r)
+			} else {
+				sb.WriteRune(' ')
+			}
+			continue
+		}
+		sb.WriteRune(gsm7Alphabet[s])
+	}
+	return sb.String()
+}
+
+// packSeptets 将 septet 打包为字节，padding 为打包前预留的 septet 数（用于 UDH 对齐）
+func packSeptets(septets []byte, padding int) []byte {
+	totalBits := (padding + len(septets)) * 7
+	out := make([]byte, (totalBits+7)/8)
+	bit := padding * 7
+	for _, s := range septets {
+		for i := 0; i < 7; i++ {
+			if s&(1<<i) != 0 {
+				out[bit/8] |= 1 << (bit % 8)
+			}
+			bit++
+		}
+	}
+	return out
+}
+
+// unpackSeptets 从字节中解出 count 个 septet
+func unpackSeptets(data []byte, count int) []byte {
+	septets := make([]byte, 0, count)
+	for n := 0; n < count; n++ {
+		var s byte
+		for i := 0; i < 7; i++ {
+			bit := n*7 + i
+			if bit/8 >= len(data) {
+				return septets
+			}
+			if data[bit/8]&(1<<(bit%8)) != 0 {
+				s |= 1 << i
+			}
+		}
+		septets = append(septets, s)
+	}
+	return septets
+}
+
+// swapSemiOctets 按半字节交换编码数字串，奇数长度补 F
+func swapSemiOctets(digits string) []byte {
+	if len(digits)%2 == 1 {
+		digits += "F"
+	}
+	out := make([]byte, len(digits)/2)
+	for i := 0; i < len(digits); i += 2 {
+		out[i/2] = hexNibble(digits[i+1])<<4 | hexNibble(digits[i])
+	}
+	return out
+}
+
+func hexNibble(c byte) byte {
+	switch {
+	case c >= '0' && c <= '9':
+		return c - '0'
+	case c >= 'A' && c <= 'F':
+		return c - 'A' + 10
+	case c >= 'a' && c <= 'f':
+		return c - 'a' + 10
+	}
+	return 0x0F
+}
+
+// decodeSemiOctets 解码半字节交换的数字串
+func decodeSemiOctets(data []byte, digits int) string {
+	const table = "0123456789*#abc"
+	var sb strings.Builder
+	for _, b := range data {
+		for _, n := range []byte{b & 0x0F, b >> 4} {
+			if sb.Len() >= digits || n == 0x0F {
+				return sb.String()
+			}
+			sb.WriteByte(table[n])
+		}
+	}
+	return sb.String()
+}
+
+// decodeBCD 解码时间戳中的一个字节
+func decodeBCD(b byte) int {
+	return int(b&0x0F)*10 + int(b>>4)
+}
+
+// encodeAddress 编码目标地址（长度 + 类型 + 号码）
+func encodeAddress(number string) []byte {
+	toa := byte(0x81) // 未知类型，ISDN
+	if strings.HasPrefix(number, "+") {
+		toa = 0x91 // 国际号码
+		number = number[1:]
+	}
+	out := []byte{byte(len(number)), toa}
+	return append(out, swapSemiOctets(number)...)
+}
+
+// decodeAddress 解码地址，返回号码和消耗的字节数
+func decodeAddress(data []byte) (string, int, error) {
+	if len(data) < 2 {
+		return "", 0, errPDUTooShort
+	}
+	digits := int(data[0])
+	toa := data[1]
+	octets := (digits + 1) / 2
+	if len(data) < 2+octets {
+		return "", 0, errPDUTooShort
+	}
+	body := data[2 : 2+octets]
+
+	// 字母数字地址（如银行、运营商短号名称）
+	if toa&0x70 == 0x50 {
+		return decodeGSM7(unpackSeptets(body, digits*4/7)), 2 + octets, nil
+	}
+
+	number := decodeSemiOctets(body, digits)
+	if toa&0x70 == 0x10 {
+		number = "+" + number
+	}
+	return number, 2 + octets, nil
+}
+
+// decodeSCTS 解码短信中心时间戳
+func decodeSCTS(data []byte) time.Time {
+	if len(data) < 7 {
+		return time.Now()
+	}
+	year := 2000 + decodeBCD(data[0])
+	tzQuarters := int(data[6]&0x07)*10 + int(data[6]>>4)
+	if data[6]&0x08 != 0 {
+		tzQuarters = -tzQuarters
+	}
+	loc := time.FixedZone("", tzQuarters*15*60)
+	return time.Date(year, time.Month(decodeBCD(data[1])), decodeBCD(data[2]),
+		decodeBCD(data[3]), decodeBCD(data[4]), decodeBCD(data[5]), 0, loc)
+}
+
+// dcsEncoding 根据 TP-DCS 判断编码方式
+func dcsEncoding(dcs byte) int {
+	switch dcs & 0xF0 {
+	case 0xF0:
+		if dcs&0x04 != 0 {
+			return pduEncoding8Bit
+		}
+		return pduEncodingGSM7
+	case 0xC0, 0xD0:
+		return pduEncodingGSM7
+	case 0xE0:
+		return pduEncodingUCS2
+	}
+	if dcs&0xC0 == 0x00 || dcs&0xC0 == 0x40 {
+		switch (dcs >> 2) & 0x03 {
+		case 0x01:
+			return pduEncoding8Bit
+		case 0x02:
+			return pduEncodingUCS2
+		}
+	}
+	return pduEncodingGSM7
+}
+
+// decodeUCS2 解码 UTF-16BE 文本
+func decodeUCS2(data []byte) string {
+	units := make([]uint16, 0, len(data)/2)
+	for i := 0; i+1 < len(data); i += 2 {
+		units = append(units, uint16(data[i])<<8|uint16(data[i+1]))
+	}
+	return string(utf16.Decode(units))
+}
+
+// encodeUCS2 编码为 UTF-16BE
+func encodeUCS2(units []uint16) []byte {
+	out := make([]byte, 0, len(units)*2)
+	for _, u := range units {
+		out = append(out, byte(u>>8), byte(u))
+	}
+	return out
+}
+
+// parseUDH 解析用户数据头中的长短信分片信息
+func parseUDH(udh []byte, pdu *SMSPDU) {
+	for i := 0; i+1 < len(udh); {
+		iei := udh[i]
+		length := int(udh[i+1])
+		value := udh[i+2:]
+		if len(value) < length {
+			return
+		}
+		value = value[:length]
+		switch {
+		case iei == 0x00 && length == 3:
+			pdu.ConcatRef = int(value[0])
+			pdu.ConcatTotal = int(value[1])
+			pdu.ConcatSeq = int(value[2])
+		case iei == 0x08 && length == 4:
+			pdu.ConcatRef = int(value[0])<<8 | int(value[1])
+			pdu.ConcatTotal = int(value[2])
+			pdu.ConcatSeq = int(value[3])
+		}
+		i += 2 + length
+	}
+}
+
+// decodeUserData 解码 TP-UD
+func decodeUserData(data []byte, udl int, encoding int, hasUDH bool, pdu *SMSPDU) string {
+	udhLen := 0
+	if hasUDH && len(data) > 0 {
+		udhLen = int(data[0]) + 1
+		if udhLen > len(data) {
+			udhLen = len(data)
+		}
+		parseUDH(data[1:udhLen], pdu)
+	}
+
+	switch encoding {
+	case pduEncodingGSM7:
+		septets := unpackSeptets(data, udl)
+		skip := (udhLen*8 + 6) / 7
+		if skip > len(septets) {
+			skip = len(septets)
+		}
+		return decodeGSM7(septets[skip:])
+	case pduEncodingUCS2:
+		end := udl
+		if end > len(data) {
+			end = len(data)
+		}
+		return decodeUCS2(data[udhLen:end])
+	default:
+		end := udl
+		if end > len(data) {
+			end = len(data)
+		}
+		return string(data[udhLen:end])
+	}
+}
+
+// DecodeSMSPDU 解码模组上报的十六进制 PDU（包含 SMSC 地址）
+func DecodeSMSPDU(hexPDU string) (*SMSPDU, error) {
+	data, err := hex.DecodeString(strings.TrimSpace(hexPDU))
+	if err != nil {
+		return nil, fmt.Errorf("PDU 不是合法的十六进制: %w", err)
+	}
+	if len(data) < 1 {
+		return nil, errPDUTooShort
+	}
+
+	// 跳过 SMSC 地址
+	smscLen := int(data[0])
+	pos := 1 + smscLen
+	if len(data) <= pos {
+		return nil, errPDUTooShort
+	}
+
+	firstOctet := data[pos]
+	pos++
+	pdu := &SMSPDU{Type: int(firstOctet & 0x03)}
+
+	switch pdu.Type {
+	case pduTypeDeliver:
+		from, n, err := decodeAddress(data[pos:])
+		if err != nil {
+			return nil, err
+		}
+		pdu.From = from
+		pos += n
+
+		// TP-PID, TP-DCS, TP-SCTS(7), TP-UDL
+		if len(data) < pos+10 {
+			return nil, errPDUTooShort
+		}
+		dcs := data[pos+1]
+		pdu.Timestamp = decodeSCTS(data[pos+2 : pos+9])
+		udl := int(data[pos+9])
+		pos += 10
+
+		hasUDH := firstOctet&0x40 != 0
+		pdu.Content = decodeUserData(data[pos:], udl, dcsEncoding(dcs), hasUDH, pdu)
+		return pdu, nil
+
+	case pduTypeStatusReport:
+		// TP-MR, TP-RA, TP-SCTS(7), TP-DT(7), TP-ST
+		if len(data) < pos+1 {
+			return nil, errPDUTooShort
+		}
+		pdu.MessageRef = int(data[pos])
+		pos++
+		to, n, err := decodeAddress(data[pos:])
+		if err != nil {
+			return nil, err
+		}
+		pdu.From = to
+		pos += n
+		if len(data) < pos+15 {
+			return nil, errPDUTooShort
+		}
+		pdu.Timestamp = decodeSCTS(data[pos+7 : pos+14])
+		pdu.Status = int(data[pos+14])
+		return pdu, nil
+
+	default:
+		return nil, fmt.Errorf("不支持的 PDU 类型: %d", pdu.Type)
+	}
+}
+
+// SubmitPDU 待发送的 PDU 分片
+type SubmitPDU struct {
+	Hex    string // 完整 PDU（包含 SMSC 占位 00）
+	Length int    // AT+CMGS 使用的长度（不含 SMSC 部分）
+}
+
+// EncodeSubmitPDUs 将短信编码为一个或多个 SMS-SUBMIT PDU，超长时自动分片
+// ref 为长短信参考号，statusReport 表示是否请求送达报告
+func EncodeSubmitPDUs(to, content string, ref byte, statusReport bool) ([]SubmitPDU, error) {
+	if to == "" {
+		return nil, fmt.Errorf("目标号码不能为空")
+	}
+
+	type part struct {
+		ud  []byte
+		udl int
+	}
+	var parts []part
+	var dcs byte
+
+	if septets, ok := encodeGSM7(content); ok {
+		dcs = 0x00
+		if len(septets) <= 160 {
+			parts = append(parts, part{ud: packSeptets(septets, 0), udl: len(septets)})
+		} else {
+			chunks := splitSeptets(septets, 153)
+			for i, chunk := range chunks {
+				udh := concatUDH(ref, len(chunks), i+1)
+				// UDH 占 6 字节，即 7 个 septet（含 1 位填充）
+				ud := packSeptets(chunk, 7)
+				copy(ud, udh)
+				parts = append(parts, part{ud: ud, udl: 7 + len(chunk)})
+			}
+		}
+	} else {
+		dcs = 0x08
+		units := utf16.Encode([]rune(content))
+		if len(units) <= 70 {
+			ud := encodeUCS2(units)
+			parts = append(parts, part{ud: ud, udl: len(ud)})
+		} else {
+			chunks := splitUTF16(units, 67)
+			for i, chunk := range chunks {
+				ud := append(concatUDH(ref, len(chunks), i+1), encodeUCS2(chunk)...)
+				parts = append(parts, part{ud: ud, udl: len(ud)})
+			}
+		}
+	}
+
+	if len(parts) > 255 {
+		return nil, fmt.Errorf("短信过长")
+	}
+
+	address := encodeAddress(to)
+	result := make([]SubmitPDU, 0, len(parts))
+	for _, p := range parts {
+		firstOctet := byte(pduTypeSubmit)
+		if len(parts) > 1 {
+			firstOctet |= 0x40 // UDHI
+		}
+		if statusReport {
+			firstOctet |= 0x20 // SRR
+		}
+
+		tpdu := []byte{firstOctet, 0x00} // TP-MR 由模组分配
+		tpdu = append(tpdu, address...)
+		tpdu = append(tpdu, 0x00, dcs, byte(p.udl))
+		tpdu = append(tpdu, p.ud...)
+
+		result = append(result, SubmitPDU{
+			Hex:    strings.ToUpper("00" + hex.EncodeToString(tpdu)),
+			Length: len(tpdu),
+		})
+	}
+	return result, nil
+}
+
+// concatUDH 构造 8 位参考号的长短信 UDH
+func concatUDH(ref byte, total, seq int) []byte {
+	return []byte{0x05, 0x00, 0x03, ref, byte(total), byte(seq)}
+}
+
+// splitSeptets 按最大长度分割 septet，避免把扩展字符的转义前缀与字符拆开
+func splitSeptets(septets []byte, size int) [][]byte {
+	var chunks [][]byte
+	for len(septets) > 0 {
+		n := size
+		if n > len(septets) {
+			n = len(septets)
+		} else if septets[n-1] == gsm7Escape {
+			n--
+		}
+		chunks = append(chunks, septets[:n])
+		septets = septets[n:]
+	}
+	return chunks
+}
+
+// splitUTF16 按最大长度分割 UTF-16 码元，避免拆开代理对
+func splitUTF16(units []uint16, size int) [][]uint16 {
+	var chunks [][]uint16
+	for len(units) > 0 {
+		n := size
+		if n > len(units) {
+			n = len(units)
+		} else if utf16.IsSurrogate(rune(units[n-1])) && units[n-1] < 0xDC00 {
+			n--
+		}
+		chunks = append(chunks, units[:n])
+		units = units[n:]
+	}
+	return chunks
+}
@@ -0,0 +1,261 @@
+package service
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// 测试向量：hellohello 来自 GSM 03.40 常用的公开示例（dreamfabric SMS PDU 说明、Wikipedia GSM 03.38 条目），
+// 其余按 GSM 03.40 / 03.38 逐字段构造，SMSC 为 +8613800210500，时间戳为 2024-10-12 13:20:53 +08:00
+
+func TestDecodeSMSPDU(t *testing.T) {
+	cst := time.FixedZone("", 8*3600)
+	tests := []struct {
+		name string
+		pdu  string
+		want SMSPDU
+	}{
+		{
+			name: "GSM7 公开示例",
+			pdu:  "07917283010010F5040BC87238880900F10000993092516195800AE8329BFD4697D9EC37",
+			want: SMSPDU{
+				Type:    pduTypeDeliver,
+				From:    "27838890001",
+				Content: "hellohello",
+				// 两位年份按 20xx 解码，原示例中的 99 年解码为 2099 年
+				Timestamp: time.Date(2099, 3, 29, 15, 16, 59, 0, time.FixedZone("", 2*3600)),
+			},
+		},
+		{
+			name: "GSM7 扩展字符",
+			pdu:  "0891683108200105F0040D91683110325476F80000420121310235231050797A5CD6816A9B3268C3C36F7C",
+			want: SMSPDU{
+				Type:      pduTypeDeliver,
+				From:      "+8613012345678",
+				Content:   "Price: 5€ [x]",
+				Timestamp: time.Date(2024, 10, 12, 13, 20, 53, 0, cst),
+			},
+		},
+		{
+			name: "UCS2 emoji",
+			pdu:  "0891683108200105F0040D91683110325476F8000842012131023523084F60597DD83DDE00",
+			want: SMSPDU{
+				Type:      pduTypeDeliver,
+				From:      "+8613012345678",
+				Content:   "你好😀",
+				Timestamp: time.Date(2024, 10, 12, 13, 20, 53, 0, cst),
+			},
+		},
+		{
+			name: "长短信 8 位参考号 UCS2",
+			pdu:  "0891683108200105F0440D91683110325476F80008420121310235230C050003A702017B2C4E006BB5",
+			want: SMSPDU{
+				Type:        pduTypeDeliver,
+				From:        "+8613012345678",
+				Content:     "第一段",
+				Timestamp:   time.Date(2024, 10, 12, 13, 20, 53, 0, cst),
+				ConcatRef:   0xA7,
+				ConcatTotal: 2,
+				ConcatSeq:   1,
+			},
+		},
+		{
+			name: "长短信 8 位参考号 GSM7 填充位",
+			pdu:  "0891683108200105F0440D91683110325476F80000420121310235230C050003420302D06536FB0D",
+			want: SMSPDU{
+				Type:        pduTypeDeliver,
+				From:        "+8613012345678",
+				Content:     "hello",
+				Timestamp:   time.Date(2024, 10, 12, 13, 20, 53, 0, cst),
+				ConcatRef:   0x42,
+				ConcatTotal: 3,
+				ConcatSeq:   2,
+			},
+		},
+		{
+			name: "长短信 16 位参考号",
+			pdu:  "0891683108200105F0440D91683110325476F80000420121310235231006080412340302F0B09C0EA2DFDF",
+			want: SMSPDU{
+				Type:        pduTypeDeliver,
+				From:        "+8613012345678",
+				Content:     "part two",
+				Timestamp:   time.Date(2024, 10, 12, 13, 20, 53, 0, cst),
+				ConcatRef:   0x1234,
+				ConcatTotal: 3,
+				ConcatSeq:   2,
+			},
+		},
+		{
+			name: "字母数字发送方",
+			pdu:  "0891683108200105F0040BD0C7F7FBCC2E0300004201213102352308C7564C36A3D56C",
+			want: SMSPDU{
+				Type:      pduTypeDeliver,
+				From:      "Google",
+				Content:   "G-123456",
+				Timestamp: time.Date(2024, 10, 12, 13, 20, 53, 0, cst),
+			},
+		},
+		{
+			name: "负时区",
+			pdu:  "0891683108200105F0040D91683110325476F800004210203040500A02E834",
+			want: SMSPDU{
+				Type:      pduTypeDeliver,
+				From:      "+8613012345678",
+				Content:   "hi",
+				Timestamp: time.Date(2024, 1, 2, 3, 4, 5, 0, time.FixedZone("", -5*3600)),
+			},
+		},
+		{
+			name: "状态报告",
+			pdu:  "0891683108200105F0062A0D91683110325476F8420121310235234201213112302300",
+			want: SMSPDU{
+				Type:       pduTypeStatusReport,
+				From:       "+8613012345678",
+				Timestamp:  time.Date(2024, 10, 12, 13, 21, 3, 0, cst),
+				MessageRef: 0x2A,
+				Status:     0x00,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := DecodeSMSPDU(tt.pdu)
+			if err != nil {
+				t.Fatalf("解码失败: %v", err)
+			}
+			if !got.Timestamp.Equal(tt.want.Timestamp) {
+				t.Errorf("Timestamp = %v，期望 %v", got.Timestamp, tt.want.Timestamp)
+			}
+			_, gotOffset := got.Timestamp.Zone()
+			_, wantOffset := tt.want.Timestamp.Zone()
+			if gotOffset != wantOffset {
+				t.Errorf("时区偏移 = %d，期望 %d", gotOffset, wantOffset)
+			}
+			got.Timestamp, tt.want.Timestamp = time.Time{}, time.Time{}
+			if *got != tt.want {
+				t.Errorf("解码结果 = %+v，期望 %+v", *got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDecodeSMSPDUInvalid(t *testing.T) {
+	for _, pdu := range []string{"", "ZZ", "0891683108200105F0", "0891683108200105F0040D9168"} {
+		if _, err := DecodeSMSPDU(pdu); err == nil {
+			t.Errorf("DecodeSMSPDU(%q) 应返回错误", pdu)
+		}
+	}
+}
+
+func TestEncodeSubmitPDUs(t *testing.T) {
+	tests := []struct {
+		name         string
+		to           string
+		content      string
+		ref          byte
+		statusReport bool
+		want         []SubmitPDU
+	}{
+		{
+			name:    "GSM7 公开示例",
+			to:      "+46708251358",
+			content: "hellohello",
+			want:    []SubmitPDU{{Hex: "0001000B916407281553F800000AE8329BFD4697D9EC37", Length: 22}},
+		},
+		{
+			name:    "GSM7 扩展字符",
+			to:      "+8613012345678",
+			content: "Price: 5€ [x]",
+			want:    []SubmitPDU{{Hex: "0001000D91683110325476F800001050797A5CD6816A9B3268C3C36F7C", Length: 28}},
+		},
+		{
+			name:    "UCS2 emoji",
+			to:      "10086",
+			content: "你好😀",
+			want:    []SubmitPDU{{Hex: "00010005810180F60008084F60597DD83DDE00", Length: 18}},
+		},
+		{
+			name:    "长短信 GSM7",
+			to:      "10086",
+			content: strings.Repeat("a", 200),
+			ref:     0x11,
+			want: []SubmitPDU{
+				{Hex: "00410005810180F60000A0050003110201C2" + strings.Repeat("E170381C0E87C3", 19), Length: 150},
+				{Hex: "00410005810180F6000036050003110202C2" + strings.Repeat("E170381C0E87C3", 5) + "E170381C0E03", Length: 58},
+			},
+		},
+		{
+			name:         "请求送达报告",
+			to:           "+46708251358",
+			content:      "hellohello",
+			statusReport: true,
+			want:         []SubmitPDU{{Hex: "0021000B916407281553F800000AE8329BFD4697D9EC37", Length: 22}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := EncodeSubmitPDUs(tt.to, tt.content, tt.ref, tt.statusReport)
+			if err != nil {
+				t.Fatalf("编码失败: %v", err)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("分片数 = %d，期望 %d", len(got), len(tt.want))
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("第 %d 段 = %+v，期望 %+v", i+1, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+// TestEncodeSubmitPDUsSplit 分片不拆开扩展字符的转义前缀和 UTF-16 代理对
+func TestEncodeSubmitPDUsSplit(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		parts   int
+	}{
+		// 第 153 个 septet 是 € 的转义前缀，应整体放到下一段
+		{name: "GSM7 扩展字符位于分片边界", content: strings.Repeat("a", 152) + "€" + strings.Repeat("a", 10), parts: 2},
+		// 第 67 个码元是 emoji 的高位代理，应整体放到下一段
+		{name: "UCS2 代理对位于分片边界", content: strings.Repeat("中", 66) + "😀" + strings.Repeat("中", 10), parts: 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := EncodeSubmitPDUs("10086", tt.content, 1, false)
+			if err != nil {
+				t.Fatalf("编码失败: %v", err)
+			}
+			if len(got) != tt.parts {
+				t.Fatalf("分片数 = %d，期望 %d", len(got), tt.parts)
+			}
+
+			// 将每段转换为 SMS-DELIVER 解码，拼接后应与原文相同
+			var content strings.Builder
+			for i, part := range got {
+				pdu, err := DecodeSMSPDU(submitToDeliver(part.Hex))
+				if err != nil {
+					t.Fatalf("解码第 %d 段失败: %v", i+1, err)
+				}
+				if pdu.ConcatSeq != i+1 || pdu.ConcatTotal != tt.parts {
+					t.Errorf("第 %d 段分片信息 = %d/%d", i+1, pdu.ConcatSeq, pdu.ConcatTotal)
+				}
+				content.WriteString(pdu.Content)
+			}
+			if content.String() != tt.content {
+				t.Errorf("拼接结果 = %q，期望 %q", content.String(), tt.content)
+			}
+		})
+	}
+}
+
+// submitToDeliver 将发往 10086 的长短信 SMS-SUBMIT 分片转换为 SMS-DELIVER，
+// 保留 TP-DCS 和 TP-UD，用于解码验证编码结果
+func submitToDeliver(submit string) string {
+	// 00 | 41 | 00 | 05810180F6 | 00 | DCS | UDL UD
+	dcs, ud := submit[18:20], submit[20:]
+	return "00" + "44" + "05810180F6" + "00" + dcs + "42012131023523" + ud
+}
@@ -0,0 +1,446 @@
+package service
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dushixiang/uart_sms_forwarder/config"
+	"go.uber.org/zap"
+)
+
+const (
+	androidModeTermux = "termux"
+	androidModeADB    = "adb"
+
+	androidDefaultPollInterval = 10 * time.Second
+	androidCommandTimeout      = 30 * time.Second
+	// androidMaxFailures 连续执行失败次数，超过后视为断开
+	androidMaxFailures = 3
+)
+
+// androidSMS 统一的短信记录
+type androidSMS struct {
+	ID        int64
+	From      string
+	Content   string
+	Timestamp int64
+}
+
+// termuxSMS termux-sms-list 输出的单条记录
+type termuxSMS struct {
+	ID       int64  `json:"_id"`
+	Number   string `json:"number"`
+	Body     string `json:"body"`
+	Received string `json:"received"`
+}
+
+// termuxDeviceInfo termux-telephony-deviceinfo 输出
+type termuxDeviceInfo struct {
+	NetworkOperatorName string `json:"network_operator_name"`
+	NetworkRoaming      bool   `json:"network_roaming"`
+	SimState            string `json:"sim_state"`
+	SimOperatorName     string `json:"sim_operator_name"`
+	SimSerialNumber     string `json:"sim_serial_number"`
+	SubscriberID        string `json:"subscriber_id"`
+	PhoneNumber         string `json:"line1_number"`
+}
+
+// termuxCellInfo termux-telephony-cellinfo 输出的单个小区
+type termuxCellInfo struct {
+	Registered bool `json:"registered"`
+	ASU        int  `json:"asu"`
+	DBM        int  `json:"dbm"`
+	RSRP       *int `json:"rsrp"`
+	RSRQ       *int `json:"rsrq"`
+}
+
+// androidModem 使用 Android 手机作为短信模块。
+// termux 模式在手机上的 Termux 中运行，调用 termux-api 命令；
+// adb 模式在电脑上运行，通过 adb shell 读取短信库（仅支持接收）。
+type androidModem struct {
+	logger *zap.Logger
+	config config.AndroidConfig
+
+	mu        sync.RWMutex
+	connected bool
+	emit      func(*ParsedMessage)
+	// lastID 已处理的最大短信 ID，启动时初始化为当前最大值，避免重复转发历史短信
+	lastID int64
+}
+
+func newAndroidModem(logger *zap.Logger, cfg *config.AndroidConfig) *androidModem {
+	var c config.AndroidConfig
+	if cfg != nil {
+		c = *cfg
+	}
+	c.Mode = strings.ToLower(c.Mode)
+	if c.Mode == "" {
+		c.Mode = androidModeTermux
+	}
+	if c.ADBPath == "" {
+		c.ADBPath = "adb"
+	}
+	return &androidModem{
+		logger: logger.Named("android"),
+		config: c,
+	}
+}
+
+func (m *androidModem) Name() string {
+	return BackendAndroid
+}
+
+// Run 记录当前最新短信位置后轮询收件箱
+func (m *androidModem) Run(ctx context.Context, ready func(device string), emit func(*ParsedMessage)) error {
+	if m.config.Mode != androidModeTermux && m.config.Mode != androidModeADB {
+		return fmt.Errorf("不支持的 Android 模式: %s", m.config.Mode)
+	}
+
+	messages, err := m.listInbox(ctx)
+	if err != nil {
+		return fmt.Errorf("读取收件箱失败: %w", err)
+	}
+	var lastID int64
+	for _, msg := range messages {
+		lastID = max(lastID, msg.ID)
+	}
+
+	m.mu.Lock()
+	m.connected = true
+	m.emit = emit
+	m.lastID = lastID
+	m.mu.Unlock()
+	defer func() {
+		m.mu.Lock()
+		m.connected = false
+		m.mu.Unlock()
+	}()
+
+	device := m.config.Mode
+	if m.config.Mode == androidModeADB && m.config.Serial != "" {
+		device = "adb:" + m.config.Serial
+	}
+	ready(device)
+
+	interval := androidDefaultPollInterval
+	if m.config.PollInterval > 0 {
+		interval = time.Duration(m.config.PollInterval) * time.Second
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	failures := 0
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+
+		if err := m.pollInbox(ctx); err != nil {
+			failures++
+			m.logger.Warn("轮询收件箱失败", zap.Error(err), zap.Int("failures", failures))
+			if failures >= androidMaxFailures {
+				return err
+			}
+		} else {
+			failures = 0
+		}
+	}
+}
+
+// pollInbox 上报 ID 大于 lastID 的新短信
+func (m *androidModem) pollInbox(ctx context.Context) error {
+	messages, err := m.listInbox(ctx)
+	if err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	lastID := m.lastID
+	m.mu.Unlock()
+
+	// 按 ID 升序上报，保证顺序
+	var fresh []androidSMS
+	for _, msg := range messages {
+		if msg.ID > lastID {
+			fresh = append(fresh, msg)
+		}
+	}
+	slices.SortFunc(fresh, func(a, b androidSMS) int {
+		return cmp.Compare(a.ID, b.ID)
+	})
+
+	for _, msg := range fresh {
+		m.emitFrame(map[string]any{
+			"type":      "incoming_sms",
+			"from":      msg.From,
+			"content":   msg.Content,
+			"timestamp": msg.Timestamp,
+		})
+		lastID = msg.ID
+	}
+
+	m.mu.Lock()
+	m.lastID = lastID
+	m.mu.Unlock()
+	return nil
+}
+
+// listInbox 读取收件箱最近的短信
+func (m *androidModem) listInbox(ctx context.Context) ([]androidSMS, error) {
+	if m.config.Mode == androidModeADB {
+		output, err := m.run(ctx, m.config.ADBPath, m.adbArgs(
+			"content", "query",
+			"--uri", "content://sms/inbox",
+			"--projection", "_id:address:date:body",
+			"--sort", "'_id DESC LIMIT 50'",
+		)...)
+		if err != nil {
+			return nil, err
+		}
+		return parseContentQuery(output), nil
+	}
+
+	output, err := m.run(ctx, "termux-sms-list", "-t", "inbox", "-l", "50")
+	if err != nil {
+		return nil, err
+	}
+	var list []termuxSMS
+	if err := json.Unmarshal(output, &list); err != nil {
+		return nil, fmt.Errorf("解析 termux-sms-list 输出失败: %w", err)
+	}
+
+	messages := make([]androidSMS, 0, len(list))
+	for _, item := range list {
+		timestamp := time.Now().Unix()
+		if t, err := time.ParseInLocation(time.DateTime, item.Received, time.Local); err == nil {
+			timestamp = t.Unix()
+		}
+		messages = append(messages, androidSMS{
+			ID:        item.ID,
+			From:      item.Number,
+			Content:   item.Body,
+			Timestamp: timestamp,
+		})
+	}
+	return messages, nil
+}
+
+// parseContentQuery 解析 content query 的输出，body 位于每行最后以容纳逗号和换行
+//
+//	Row: 0 _id=12, address=10086, date=1700000000000, body=hello, world
+func parseContentQuery(output []byte) []androidSMS {
+	text := "\n" + strings.ReplaceAll(string(output), "\r\n", "\n")
+	rows := strings.Split(text, "\nRow: ")
+
+	var messages []androidSMS
+	for _, row := range rows {
+		idIdx := strings.Index(row, "_id=")
+		addrIdx := strings.Index(row, ", address=")
+		dateIdx := strings.Index(row, ", date=")
+		bodyIdx := strings.Index(row, ", body=")
+		if idIdx < 0 || addrIdx < idIdx || dateIdx < addrIdx || bodyIdx < dateIdx {
+			continue
+		}
+
+		id, err := strconv.ParseInt(row[idIdx+len("_id="):addrIdx], 10, 64)
+		if err != nil {
+			continue
+		}
+		timestamp := time.Now().Unix()
+		if ms, err := strconv.ParseInt(row[dateIdx+len(", date="):bodyIdx], 10, 64); err == nil {
+			timestamp = ms / 1000
+		}
+
+		messages = append(messages, androidSMS{
+			ID:        id,
+			From:      row[addrIdx+len(", address=") : dateIdx],
+			Content:   strings.TrimRight(row[bodyIdx+len(", body="):], "\n"),
+			Timestamp: timestamp,
+		})
+	}
+	return messages
+}
+
+// adbArgs 构造 adb shell 参数
+func (m *androidModem) adbArgs(args ...string) []string {
+	var result []string
+	if m.config.Serial != "" {
+		result = append(result, "-s", m.config.Serial)
+	}
+	result = append(result, "shell")
+	return append(result, args...)
+}
+
+// run 执行命令并返回标准输出
+func (m *androidModem) run(ctx context.Context, name string, args ...string) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(ctx, androidCommandTimeout)
+	defer cancel()
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("执行 %s 失败: %w, 输出: %s", name, err, strings.TrimSpace(stderr.String()))
+	}
+	return stdout.Bytes(), nil
+}
+
+func (m *androidModem) emitFrame(payload map[string]any) {
+	m.mu.RLock()
+	emit := m.emit
+	m.mu.RUnlock()
+	if emit != nil {
+		emitFrame(emit, payload)
+	}
+}
+
+// SendCommand 将统一命令翻译为 termux-api / adb 调用，结果异步上报
+func (m *androidModem) SendCommand(cmd map[string]any) error {
+	m.mu.RLock()
+	connected := m.connected
+	m.mu.RUnlock()
+	if !connected {
+		return fmt.Errorf("Android 设备未连接")
+	}
+
+	action, _ := cmd["action"].(string)
+	switch action {
+	case "send_sms":
+		if m.config.Mode != androidModeTermux {
+			return fmt.Errorf("adb 模式不支持发送短信，请使用 termux 模式")
+		}
+		to, _ := cmd["to"].(string)
+		content, _ := cmd["content"].(string)
+		requestID, _ := cmd["request_id"].(string)
+		go m.sendSMS(to, content, requestID)
+	case "get_status":
+		go m.reportStatus()
+	default:
+		return fmt.Errorf("Android 后端不支持的命令: %s", action)
+	}
+	return nil
+}
+
+// sendSMS 通过 termux-sms-send 发送短信，内容从标准输入传入
+func (m *androidModem) sendSMS(to, content, requestID string) {
+	ctx, cancel := context.WithTimeout(context.Background(), androidCommandTimeout)
+	defer cancel()
+
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, "termux-sms-send", "-n", to)
+	cmd.Stdin = strings.NewReader(content)
+	cmd.Stderr = &stderr
+	err := cmd.Run()
+	if err != nil {
+		m.logger.Error("termux 发送短信失败", zap.String("to", to), zap.Error(err), zap.String("stderr", stderr.String()))
+	}
+
+	m.emitFrame(sendResultFrame(requestID, to, err))
+}
+
+// reportStatus 查询手机的网络和 SIM 状态并以 status_response 上报
+func (m *androidModem) reportStatus() {
+	ctx, cancel := context.WithTimeout(context.Background(), androidCommandTimeout)
+	defer cancel()
+
+	var status map[string]any
+	var err error
+	if m.config.Mode == androidModeADB {
+		status, err = m.adbStatus(ctx)
+	} else {
+		status, err = m.termuxStatus(ctx)
+	}
+	if err != nil {
+		m.logger.Warn("获取设备状态失败", zap.Error(err))
+		return
+	}
+
+	m.emitFrame(map[string]any{
+		"type":    "status_response",
+		"version": "Android " + m.config.Mode,
+		"mobile":  status,
+	})
+}
+
+func (m *androidModem) termuxStatus(ctx context.Context) (map[string]any, error) {
+	output, err := m.run(ctx, "termux-telephony-deviceinfo")
+	if err != nil {
+		return nil, err
+	}
+	var info termuxDeviceInfo
+	if err := json.Unmarshal(output, &info); err != nil {
+		return nil, fmt.Errorf("解析设备信息失败: %w", err)
+	}
+
+	status := map[string]any{
+		"is_registered": info.NetworkOperatorName != "",
+		"is_roaming":    info.NetworkRoaming,
+		"iccid":         info.SimSerialNumber,
+		"imsi":          info.SubscriberID,
+		"number":        info.PhoneNumber,
+		"sim_ready":     strings.EqualFold(info.SimState, "ready"),
+		"operator":      info.NetworkOperatorName,
+		"uptime":        0,
+	}
+
+	// 信号信息取已注册小区
+	if output, err := m.run(ctx, "termux-telephony-cellinfo"); err == nil {
+		var cells []termuxCellInfo
+		if err := json.Unmarshal(output, &cells); err == nil {
+			for _, cell := range cells {
+				if !cell.Registered {
+					continue
+				}
+				status["rssi"] = cell.DBM
+				status["csq"] = min(cell.ASU, 31)
+				status["signal_level"] = min(cell.ASU, 31)
+				if cell.RSRP != nil {
+					status["rsrp"] = *cell.RSRP
+				}
+				if cell.RSRQ != nil {
+					status["rsrq"] = *cell.RSRQ
+				}
+				break
+			}
+		}
+	}
+	return status, nil
+}
+
+func (m *androidModem) adbStatus(ctx context.Context) (map[string]any, error) {
+	getprop := func(name string) string {
+		output, err := m.run(ctx, m.config.ADBPath, m.adbArgs("getprop", name)...)
+		if err != nil {
+			return ""
+		}
+		// 双卡设备返回逗号分隔的值，取第一张卡
+		value, _, _ := strings.Cut(strings.TrimSpace(string(output)), ",")
+		return value
+	}
+
+	if _, err := m.run(ctx, m.config.ADBPath, m.adbArgs("true")...); err != nil {
+		return nil, err
+	}
+
+	operator := getprop("gsm.operator.alpha")
+	simState := strings.ToUpper(getprop("gsm.sim.state"))
+	return map[string]any{
+		"is_registered": operator != "",
+		"is_roaming":    getprop("gsm.operator.isroaming") == "true",
+		"sim_ready":     simState == "READY" || simState == "LOADED",
+		"operator":      operator,
+		"uptime":        0,
+	}, nil
+}
@@ -0,0 +1,341 @@
+package service
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dushixiang/uart_sms_forwarder/internal/models"
+	mqtt "github.com/eclipse/paho.mqtt.golang"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// PropertyIDMQTT MQTT 发布配置
+const PropertyIDMQTT = "mqtt"
+
+const (
+	// DefaultMQTTClientID 默认客户端 ID
+	DefaultMQTTClientID = "uart_sms_forwarder"
+	// DefaultMQTTTopicPrefix 默认主题前缀
+	DefaultMQTTTopicPrefix = "uart_sms_forwarder"
+	// DefaultMQTTStatusInterval 默认发布设备状态的间隔（秒）
+	DefaultMQTTStatusInterval = 60
+	// mqttTick 检查配置和发布设备状态的间隔，修改配置后无需重启即可生效
+	mqttTick = 10 * time.Second
+	// mqttTimeout 连接和发布的超时
+	mqttTimeout = 10 * time.Second
+)
+
+// mqttSMS 发布到 <前缀>/sms 的短信
+type mqttSMS struct {
+	ID        string `json:"id"`
+	From      string `json:"from"`
+	To        string `json:"to"`
+	Content   string `json:"content"`
+	Category  string `json:"category,omitempty"`
+	DeviceID  string `json:"deviceId,omitempty"`
+	Timestamp int64  `json:"timestamp"` // 时间戳（毫秒）
+}
+
+// mqttAck 发布到 <前缀>/ack 的短信确认
+type mqttAck struct {
+	ID        string `json:"id"`
+	From      string `json:"from"`
+	AckedBy   string `json:"ackedBy"`
+	AckNote   string `json:"ackNote,omitempty"`
+	Timestamp int64  `json:"timestamp"` // 确认时间（毫秒）
+}
+
+// mqttCall 发布到 <前缀>/call 的来电
+type mqttCall struct {
+	From      string `json:"from"`
+	Timestamp int64  `json:"timestamp"` // 时间戳（毫秒）
+}
+
+// MQTTStatus MQTT 连接状态
+type MQTTStatus struct {
+	Enabled     bool   `json:"enabled"`
+	Connected   bool   `json:"connected"`
+	Broker      string `json:"broker"`
+	TopicPrefix string `json:"topicPrefix"`
+	LastError   string `json:"lastError,omitempty"` // 最近一次连接或发布失败的原因
+}
+
+// MQTTService 将收到的短信、来电和设备状态发布到 MQTT 服务器。
+// 主题（前缀默认为 uart_sms_forwarder）：
+//   - <前缀>/availability：在线状态 online / offline，保留消息，异常断开时由遗嘱消息设为 offline
+//   - <前缀>/sms：收到的短信
+//   - <前缀>/call：需要通知的来电
+//   - <前缀>/ack：短信被确认处理（确认人、备注）
+//   - <前缀>/device/<设备ID>/status：设备状态（信号、运营商、注册状态等），保留消息，定时发布
+//
+// 保持一个长连接，断开后自动重连；配置变化时断开旧连接并使用新配置重新连接。
+type MQTTService struct {
+	logger          *zap.Logger
+	propertyService *PropertyService
+	serialService   *SerialService
+
+	mu         sync.Mutex
+	client     mqtt.Client
+	config     models.MQTTConfig // 当前连接使用的配置
+	lastStatus time.Time         // 上次发布设备状态的时间
+	lastError  string
+	stopChan   chan struct{}
+	done       chan struct{}
+}
+
+// NewMQTTService 创建 MQTT 发布实例
+func NewMQTTService(logger *zap.Logger, propertyService *PropertyService, serialService *SerialService) *MQTTService {
+	return &MQTTService{
+		logger:          logger,
+		propertyService: propertyService,
+		serialService:   serialService,
+		stopChan:        make(chan struct{}),
+		done:            make(chan struct{}),
+	}
+}
+
+// Start 订阅新消息事件并定时检查配置、发布设备状态
+func (s *MQTTService) Start() {
+	events, cancel := s.serialService.SubscribeMessageEvents()
+	go func() {
+		defer close(s.done)
+		defer cancel()
+		ticker := time.NewTicker(mqttTick)
+		defer ticker.Stop()
+
+		s.sync(context.Background())
+		for {
+			select {
+			case event := <-events:
+				s.publishEvent(event)
+			case <-ticker.C:
+				s.sync(context.Background())
+			case <-s.stopChan:
+				return
+			}
+		}
+	}()
+}
+
+// Stop 停止发布，发布离线状态后断开连接
+func (s *MQTTService) Stop() {
+	close(s.stopChan)
+	<-s.done
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.disconnectLocked()
+}
+
+// Status 获取 MQTT 连接状态
+func (s *MQTTService) Status(ctx context.Context) *MQTTStatus {
+	config := s.getConfig(ctx)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return &MQTTStatus{
+		Enabled:     config.Enabled,
+		Connected:   s.client != nil && s.client.IsConnectionOpen(),
+		Broker:      config.Broker,
+		TopicPrefix: mqttTopicPrefix(config),
+		LastError:   s.lastError,
+	}
+}
+
+// sync 配置变化时重新连接，到达间隔时发布设备状态
+func (s *MQTTService) sync(ctx context.Context) {
+	config := s.getConfig(ctx)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.client == nil || !reflect.DeepEqual(config, s.config) {
+		s.disconnectLocked()
+		if !config.Enabled || config.Broker == "" {
+			s.lastError = ""
+			return
+		}
+		client, err := s.connect(config)
+		if err != nil {
+			// 下一次检查时重试，相同的错误只记录一次
+			if err.Error() != s.lastError {
+				s.logger.Error("连接 MQTT 服务器失败", zap.String("broker", config.Broker), zap.Error(err))
+			}
+			s.lastError = err.Error()
+			return
+		}
+		s.logger.Info("已连接 MQTT 服务器", zap.String("broker", config.Broker))
+		s.client, s.config, s.lastError = client, config, ""
+		s.lastStatus = time.Time{}
+	}
+
+	interval := time.Duration(config.StatusIntervalSeconds) * time.Second
+	if interval <= 0 {
+		interval = DefaultMQTTStatusInterval * time.Second
+	}
+	if time.Since(s.lastStatus) >= interval {
+		s.lastStatus = time.Now()
+		s.publishStatusLocked()
+	}
+}
+
+// connect 按配置连接 MQTT 服务器，设置遗嘱消息，连接（含自动重连）成功后发布在线状态
+func (s *MQTTService) connect(config models.MQTTConfig) (mqtt.Client, error) {
+	tlsConfig := &tls.Config{InsecureSkipVerify: config.InsecureSkipVerify}
+	if config.CACert != "" {
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM([]byte(config.CACert)) {
+			return nil, fmt.Errorf("CA 证书无效")
+		}
+		tlsConfig.RootCAs = pool
+	}
+	clientID := config.ClientID
+	if clientID == "" {
+		clientID = DefaultMQTTClientID
+	}
+	availability := mqttTopicPrefix(config) + "/availability"
+
+	options := mqtt.NewClientOptions().
+		AddBroker(config.Broker).
+		SetClientID(clientID).
+		SetUsername(config.Username).
+		SetPassword(config.Password).
+		SetTLSConfig(tlsConfig). // 只在 ssl://、tls://、mqtts://、wss:// 地址时使用
+		SetConnectTimeout(mqttTimeout).
+		SetWriteTimeout(mqttTimeout).
+		SetAutoReconnect(true).
+		SetWill(availability, "offline", 1, true).
+		SetOnConnectHandler(func(client mqtt.Client) {
+			client.Publish(availability, 1, true, "online")
+		}).
+		SetConnectionLostHandler(func(_ mqtt.Client, err error) {
+			s.logger.Warn("MQTT 连接断开，正在重连", zap.Error(err))
+		})
+
+	client := mqtt.NewClient(options)
+	if err := waitMQTT(client.Connect()); err != nil {
+		return nil, err
+	}
+	return client, nil
+}
+
+// disconnectLocked 发布离线状态并断开连接，调用方需持有锁
+func (s *MQTTService) disconnectLocked() {
+	if s.client == nil {
+		return
+	}
+	if s.client.IsConnectionOpen() {
+		_ = waitMQTT(s.client.Publish(mqttTopicPrefix(s.config)+"/availability", 1, true, "offline"))
+	}
+	s.client.Disconnect(uint(mqttTimeout / time.Millisecond))
+	s.client, s.config = nil, models.MQTTConfig{}
+}
+
+// publishEvent 发布收到的短信、来电或短信确认
+func (s *MQTTService) publishEvent(event MessageEvent) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.client == nil {
+		return
+	}
+
+	switch event.Type {
+	case MessageEventSMS:
+		if event.Message == nil {
+			return
+		}
+		msg := event.Message
+		s.publishLocked("sms", s.config.Retain, mqttSMS{
+			ID:        msg.ID,
+			From:      msg.From,
+			To:        msg.To,
+			Content:   msg.Content,
+			Category:  msg.Category,
+			DeviceID:  msg.DeviceID,
+			Timestamp: event.Timestamp,
+		})
+	case MessageEventCall:
+		s.publishLocked("call", s.config.Retain, mqttCall{From: event.From, Timestamp: event.Timestamp})
+	case MessageEventAck:
+		if event.Message == nil {
+			return
+		}
+		msg := event.Message
+		s.publishLocked("ack", s.config.Retain, mqttAck{
+			ID:        msg.ID,
+			From:      msg.From,
+			AckedBy:   msg.AckedBy,
+			AckNote:   msg.AckNote,
+			Timestamp: event.Timestamp,
+		})
+	}
+}
+
+// publishStatusLocked 发布所有设备的状态，调用方需持有锁
+func (s *MQTTService) publishStatusLocked() {
+	for _, deviceID := range s.serialService.DeviceIDs() {
+		status, err := s.serialService.GetStatus(deviceID)
+		if err != nil {
+			continue
+		}
+		s.publishLocked("device/"+mqttTopicLevel(deviceID)+"/status", true, status)
+	}
+}
+
+// publishLocked 发布 JSON 消息到 <前缀>/<topic>，调用方需持有锁
+func (s *MQTTService) publishLocked(topic string, retained bool, payload any) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		s.logger.Error("序列化 MQTT 消息失败", zap.String("topic", topic), zap.Error(err))
+		return
+	}
+	topic = mqttTopicPrefix(s.config) + "/" + topic
+	qos := byte(0)
+	if s.config.QoS > 0 && s.config.QoS <= 2 {
+		qos = byte(s.config.QoS)
+	}
+	if err := waitMQTT(s.client.Publish(topic, qos, retained, data)); err != nil {
+		s.logger.Error("MQTT 发布失败", zap.String("topic", topic), zap.Error(err))
+		s.lastError = err.Error()
+		return
+	}
+	s.logger.Debug("MQTT 发布成功", zap.String("topic", topic))
+}
+
+// getConfig 获取 MQTT 发布配置，未配置或读取失败时视为未启用
+func (s *MQTTService) getConfig(ctx context.Context) models.MQTTConfig {
+	var config models.MQTTConfig
+	if err := s.propertyService.GetValue(ctx, PropertyIDMQTT, &config); err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		s.logger.Error("获取 MQTT 发布配置失败", zap.Error(err))
+	}
+	return config
+}
+
+// waitMQTT 等待操作完成，超时返回错误
+func waitMQTT(token mqtt.Token) error {
+	if !token.WaitTimeout(mqttTimeout) {
+		return fmt.Errorf("MQTT 操作超时")
+	}
+	return token.Error()
+}
+
+// mqttTopicPrefix 主题前缀，去掉末尾的 /
+func mqttTopicPrefix(config models.MQTTConfig) string {
+	prefix := strings.TrimRight(config.TopicPrefix, "/")
+	if prefix == "" {
+		return DefaultMQTTTopicPrefix
+	}
+	return prefix
+}
+
+// mqttTopicLevel 将设备 ID 转换为单个主题层级，替换 / 和通配符
+func mqttTopicLevel(value string) string {
+	return strings.NewReplacer("/", "_", "+", "_", "#", "_").Replace(value)
+}
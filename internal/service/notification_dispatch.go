@@ -0,0 +1,189 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/dushixiang/uart_sms_forwarder/internal/models"
+	"go.uber.org/zap"
+)
+
+// ChannelResult 单个通知渠道的发送结果
+type ChannelResult struct {
+	Type     string        `json:"type"`
+	Success  bool          `json:"success"`
+	Error    string        `json:"error,omitempty"`
+	Attempts int           `json:"attempts"`
+	Duration time.Duration `json:"duration"`
+}
+
+// DispatchResult 一次通知分发的汇总结果
+type DispatchResult struct {
+	Results []ChannelResult `json:"results"`
+}
+
+// Succeeded 发送成功的渠道
+func (r DispatchResult) Succeeded() []string {
+	var types []string
+	for _, result := range r.Results {
+		if result.Success {
+			types = append(types, result.Type)
+		}
+	}
+	return types
+}
+
+// Failed 发送失败的渠道
+func (r DispatchResult) Failed() []string {
+	var types []string
+	for _, result := range r.Results {
+		if !result.Success {
+			types = append(types, result.Type)
+		}
+	}
+	return types
+}
+
+// sendNotificationMessage 并发发送通用通知消息到所有匹配的渠道，返回各渠道的发送结果
+func (s *SerialService) sendNotificationMessage(ctx context.Context, msg NotificationMessage) DispatchResult {
+	// 获取通知渠道配置
+	channels, err := s.propertyService.GetNotificationChannelConfigs(ctx)
+	if err != nil {
+		s.logger.Error("获取通知渠道配置失败", zap.Error(err))
+		return DispatchResult{}
+	}
+
+	privacy, err := s.getPrivacyConfig(ctx)
+	if err != nil {
+		s.logger.Error("获取通知隐私配置失败", zap.Error(err))
+	}
+	masked := applyPrivacy(privacy, msg)
+
+	// 指定了渠道时只发送到这些渠道，渠道组展开为其中的渠道；引用的渠道组不存在时不回退到全部渠道
+	targets := s.expandChannelGroups(ctx, msg.Channels)
+	event := msg.event()
+	now := time.Now()
+	var matched []models.NotificationChannelConfig
+	for _, channel := range channels {
+		if !channel.Enabled || !channelAcceptsEvent(channel, event) {
+			continue
+		}
+		if len(msg.Channels) > 0 && !slices.Contains(targets, channel.Type) {
+			continue
+		}
+		if !channelActiveAt(channel, now) {
+			s.logger.Debug("通知渠道不在工作时间，跳过", zap.String("type", channel.Type))
+			continue
+		}
+		matched = append(matched, channel)
+	}
+
+	// 每个渠道按各自的超时和重试策略发送，结果按渠道配置顺序保存
+	results := make([]ChannelResult, len(matched))
+	var wg sync.WaitGroup
+	for i, channel := range matched {
+		// 发往外部平台的通知按隐私配置脱敏
+		channelMsg := msg
+		if privacyApplies(privacy, msg, channel.Type) {
+			channelMsg = masked
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i] = s.sendToChannel(ctx, channel, channelMsg)
+		}()
+	}
+	wg.Wait()
+
+	result := DispatchResult{Results: results}
+	if failed := result.Failed(); len(failed) > 0 {
+		s.logger.Warn("部分通知渠道发送失败",
+			zap.Strings("succeeded", result.Succeeded()),
+			zap.Strings("failed", failed))
+	}
+	return result
+}
+
+// sendToChannel 按渠道的超时和重试策略发送通知到单个渠道
+func (s *SerialService) sendToChannel(ctx context.Context, channel models.NotificationChannelConfig, msg NotificationMessage) ChannelResult {
+	policy := ChannelPolicyFromConfig(channel.Type, channel.Config)
+	start := time.Now()
+
+	attempt := 0
+	attempts, sendErr := policy.Do(ctx, func(ctx context.Context) error {
+		attempt++
+		attemptStart := time.Now()
+		err := s.sendChannelOnce(ctx, channel, msg)
+		if err != nil && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			err = fmt.Errorf("发送超时（%s）: %w", policy.Timeout, err)
+		}
+		if s.notificationLogService != nil {
+			s.notificationLogService.Record(ctx, channel.Type, msg, attempt, time.Since(attemptStart), err)
+		}
+		if err != nil && policy.Retries > 0 {
+			s.logger.Warn("发送通知失败，稍后重试", zap.String("type", channel.Type), zap.Error(err))
+		}
+		return err
+	})
+
+	result := ChannelResult{Type: channel.Type, Success: sendErr == nil, Attempts: attempts, Duration: time.Since(start)}
+	s.metrics.countNotification(channel.Type, result.Success)
+	if sendErr != nil {
+		result.Error = sendErr.Error()
+		s.logger.Error("发送通知失败",
+			zap.String("type", channel.Type),
+			zap.Int("attempts", attempts),
+			zap.Duration("duration", result.Duration),
+			zap.Error(sendErr))
+	} else {
+		s.logger.Info("通知发送成功",
+			zap.String("type", channel.Type),
+			zap.Int("attempts", attempts),
+			zap.Duration("duration", result.Duration))
+	}
+	return result
+}
+
+// sendChannelOnce 发送一次通知到单个渠道
+func (s *SerialService) sendChannelOnce(ctx context.Context, channel models.NotificationChannelConfig, msg NotificationMessage) error {
+	message := msg.String()
+	switch channel.Type {
+	case "dingtalk":
+		return s.notifier.SendDingTalkByConfig(ctx, channel.Config, message)
+	case "wecom":
+		return s.notifier.SendWeComByConfig(ctx, channel.Config, message)
+	case "feishu":
+		return s.notifier.SendFeishuByConfig(ctx, channel.Config, message)
+	case "webhook":
+		return s.notifier.SendWebhookByConfig(ctx, channel.Config, msg)
+	case "email":
+		return s.notifier.SendEmail(ctx, channel.Config, msg)
+	case "telegram":
+		return s.notifier.sendTelegramByConfig(ctx, channel.Config, message, msg.Priority == models.NotificationPriorityLow)
+	case "syslog":
+		return s.notifier.SendSyslogByConfig(ctx, channel.Config, msg)
+	case "redis":
+		return s.notifier.SendRedisByConfig(ctx, channel.Config, msg)
+	case "amqp":
+		return s.notifier.SendAMQPByConfig(ctx, channel.Config, msg)
+	case "exec":
+		return s.notifier.SendExecByConfig(ctx, channel.Config, msg)
+	case "file":
+		return s.notifier.SendFileByConfig(ctx, channel.Config, msg)
+	case "fcm":
+		return s.notifier.SendFCMByConfig(ctx, channel.Config, msg)
+	case "webpush":
+		return s.notifier.SendWebPushByConfig(ctx, channel.Config, msg)
+	case "bark":
+		return s.notifier.SendBarkByConfig(ctx, channel.Config, msg)
+	case "pushover":
+		return s.notifier.SendPushoverByConfig(ctx, channel.Config, msg)
+	default:
+		return fmt.Errorf("不支持的通知渠道: %s", channel.Type)
+	}
+}
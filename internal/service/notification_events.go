@@ -0,0 +1,62 @@
+package service
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	"github.com/dushixiang/uart_sms_forwarder/internal/models"
+)
+
+// 通知事件类型，渠道可通过 events 配置只接收其中部分类型
+const (
+	EventSMS           = "sms"            // 收到短信
+	EventCall          = "call"           // 来电
+	EventDeviceOffline = "device-offline" // 设备断开连接
+	EventLowSignal     = "low-signal"     // 信号弱或无信号
+	EventTaskFailure   = "task-failure"   // 定时任务执行失败
+	EventSystem        = "system"         // 其他系统通知，如发送失败、存储告警、备份失败
+)
+
+// NotificationEvents 所有通知事件类型
+var NotificationEvents = []string{EventSMS, EventCall, EventDeviceOffline, EventLowSignal, EventTaskFailure, EventSystem}
+
+// event 通知的事件类型，未指定时系统通知为 system，其余按消息类型
+func (m NotificationMessage) event() string {
+	if m.Event != "" {
+		return m.Event
+	}
+	if m.System {
+		return EventSystem
+	}
+	return m.Type
+}
+
+// channelAcceptsEvent 渠道是否接收该类型的事件，未配置 events 时接收全部
+func channelAcceptsEvent(channel models.NotificationChannelConfig, event string) bool {
+	return len(channel.Events) == 0 || slices.Contains(channel.Events, event)
+}
+
+// SendEventNotification 以转发器自身的名义推送指定类型的事件通知
+func (s *SerialService) SendEventNotification(ctx context.Context, event, content string) {
+	s.SendEventNotificationTo(ctx, event, content, nil)
+}
+
+// SendEventNotificationTo 推送事件通知到指定的渠道或渠道组，channels 为空时发送到所有接收该事件的渠道
+func (s *SerialService) SendEventNotificationTo(ctx context.Context, event, content string, channels []string) {
+	s.SendPriorityEventNotificationTo(ctx, event, content, models.NotificationPriorityDefault, channels)
+}
+
+// SendPriorityEventNotificationTo 以指定优先级推送事件通知，用于逐级升级的告警
+func (s *SerialService) SendPriorityEventNotificationTo(ctx context.Context, event, content string, priority models.NotificationPriority, channels []string) {
+	s.sendNotificationMessage(ctx, NotificationMessage{
+		Type:      "sms",
+		Event:     event,
+		From:      "UART 短信转发器",
+		Content:   content,
+		Timestamp: time.Now().Unix(),
+		Priority:  priority,
+		System:    true,
+		Channels:  channels,
+	})
+}
@@ -0,0 +1,45 @@
+package service
+
+import (
+	"slices"
+	"time"
+
+	"github.com/dushixiang/uart_sms_forwarder/internal/models"
+)
+
+// channelActiveAt 渠道在指定时间是否处于工作时间，未配置 activeHours 时总是处于工作时间
+func channelActiveAt(channel models.NotificationChannelConfig, now time.Time) bool {
+	if len(channel.ActiveHours) == 0 {
+		return true
+	}
+	for _, hours := range channel.ActiveHours {
+		if inActiveHours(hours, now) {
+			return true
+		}
+	}
+	return false
+}
+
+// inActiveHours 指定时间是否处于工作时段，时段配置无效（时间格式错误或开始等于结束）时视为全天，避免误配置导致收不到通知
+func inActiveHours(hours models.ChannelActiveHours, now time.Time) bool {
+	start, okStart := parseClock(hours.Start)
+	end, okEnd := parseClock(hours.End)
+	if !okStart || !okEnd || start == end {
+		return activeOnDay(hours, now.Weekday())
+	}
+
+	minute := now.Hour()*60 + now.Minute()
+	if start < end {
+		return minute >= start && minute < end && activeOnDay(hours, now.Weekday())
+	}
+	// 跨过午夜的时段：午夜之后的部分属于前一天开始的时段
+	if minute >= start {
+		return activeOnDay(hours, now.Weekday())
+	}
+	return minute < end && activeOnDay(hours, now.AddDate(0, 0, -1).Weekday())
+}
+
+// activeOnDay 时段是否包含星期几，未配置 days 时每天都包含
+func activeOnDay(hours models.ChannelActiveHours, day time.Weekday) bool {
+	return len(hours.Days) == 0 || slices.Contains(hours.Days, int(day))
+}
@@ -0,0 +1,140 @@
+package service
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"github.com/dushixiang/uart_sms_forwarder/internal/models"
+	"github.com/dushixiang/uart_sms_forwarder/internal/repo"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+const (
+	// notificationLogRetention 通知发送记录保留时间
+	notificationLogRetention = 30 * 24 * time.Hour
+	// notificationLogPruneInterval 清理过期记录的最小间隔
+	notificationLogPruneInterval = time.Hour
+)
+
+// NotificationLogFilter 通知发送记录筛选条件
+type NotificationLogFilter struct {
+	Channel   string                       // 渠道类型，为空时不筛选
+	Status    models.NotificationLogStatus // 发送结果，为空时不筛选
+	MessageID string                       // 短信记录 ID，为空时不筛选
+	Since     int64                        // 起始时间（时间戳毫秒），为 0 时不限制
+	Until     int64                        // 截止时间（时间戳毫秒），为 0 时不限制
+}
+
+// NotificationLogPage 通知发送记录键集分页结果
+type NotificationLogPage struct {
+	Items      []models.NotificationLog `json:"items"`
+	NextCursor string                   `json:"nextCursor"` // 下一页（更早的记录）游标，没有更多时为空
+	HasMore    bool                     `json:"hasMore"`
+}
+
+// NotificationLogService 通知发送记录服务，记录每个渠道每次发送尝试的结果和耗时，
+// 用于排查某个渠道收不到通知的原因。记录保留 30 天。
+type NotificationLogService struct {
+	logger    *zap.Logger
+	repo      *repo.NotificationLogRepo
+	lastPrune atomic.Int64
+}
+
+// NewNotificationLogService 创建通知发送记录服务实例
+func NewNotificationLogService(logger *zap.Logger, db *gorm.DB) *NotificationLogService {
+	return &NotificationLogService{
+		logger: logger,
+		repo:   repo.NewNotificationLogRepo(db),
+	}
+}
+
+// Record 保存一次发送尝试，保存失败只记录日志，不影响通知发送
+func (s *NotificationLogService) Record(ctx context.Context, channel string, msg NotificationMessage, attempt int, latency time.Duration, sendErr error) {
+	// 渠道超时取消 ctx 时仍需保存记录
+	ctx = context.WithoutCancel(ctx)
+	entry := &models.NotificationLog{
+		ID:        uuid.NewString(),
+		Channel:   channel,
+		MessageID: msg.ID,
+		Event:     msg.event(),
+		Attempt:   attempt,
+		Status:    models.NotificationLogSuccess,
+		LatencyMs: latency.Milliseconds(),
+	}
+	if sendErr != nil {
+		entry.Status = models.NotificationLogFailed
+		entry.Error = sendErr.Error()
+	}
+	if err := s.repo.Create(ctx, entry); err != nil {
+		s.logger.Error("保存通知发送记录失败", zap.String("channel", channel), zap.Error(err))
+		return
+	}
+	s.prune(ctx)
+}
+
+// prune 删除过期记录，最多每小时执行一次
+func (s *NotificationLogService) prune(ctx context.Context) {
+	now := time.Now()
+	last := s.lastPrune.Load()
+	if now.UnixMilli()-last < notificationLogPruneInterval.Milliseconds() ||
+		!s.lastPrune.CompareAndSwap(last, now.UnixMilli()) {
+		return
+	}
+	deleted, err := s.repo.DeleteBefore(ctx, now.Add(-notificationLogRetention).UnixMilli())
+	if err != nil {
+		s.logger.Error("清理过期通知发送记录失败", zap.Error(err))
+		return
+	}
+	if deleted > 0 {
+		s.logger.Info("已清理过期通知发送记录", zap.Int64("deleted", deleted))
+	}
+}
+
+// List 按时间倒序分页查询发送记录
+func (s *NotificationLogService) List(ctx context.Context, filter NotificationLogFilter, cursor string, limit int) (*NotificationLogPage, error) {
+	if err := requireAdmin(ctx); err != nil {
+		return nil, err
+	}
+	c, err := decodeCursor(cursor)
+	if err != nil {
+		return nil, err
+	}
+	limit = normalizeLimit(limit)
+
+	logs, err := s.repo.FindBefore(ctx, func(db *gorm.DB) *gorm.DB {
+		if filter.Channel != "" {
+			db = db.Where("channel = ?", filter.Channel)
+		}
+		if filter.Status != "" {
+			db = db.Where("status = ?", filter.Status)
+		}
+		if filter.MessageID != "" {
+			db = db.Where("message_id = ?", filter.MessageID)
+		}
+		if filter.Since > 0 {
+			db = db.Where("created_at >= ?", filter.Since)
+		}
+		if filter.Until > 0 {
+			db = db.Where("created_at < ?", filter.Until)
+		}
+		return db
+	}, c, limit+1)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &NotificationLogPage{Items: logs}
+	if len(logs) > limit {
+		page.Items = logs[:limit]
+		page.HasMore = true
+		last := page.Items[limit-1]
+		page.NextCursor = encodeCursorAt(last.CreatedAt, last.ID)
+	}
+	if page.Items == nil {
+		page.Items = []models.NotificationLog{}
+	}
+	return page, nil
+}
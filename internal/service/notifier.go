@@ -7,6 +7,7 @@ import (
 	"crypto/sha256"
 	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
@@ -15,6 +16,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/dushixiang/uart_sms_forwarder/internal/models"
 	"github.com/valyala/fasttemplate"
 	"go.uber.org/zap"
 	"gopkg.in/gomail.v2"
@@ -23,6 +25,12 @@ import (
 // Notifier 告警通知服务
 type Notifier struct {
 	logger *zap.Logger
+	files  fileSinks // 文件渠道的输出目标
+	fcm    fcmTokenSources
+	// 推送渠道读取已注册设备
+	pushDevices PushDeviceStore
+	// Web Push 的 VAPID 密钥来源
+	vapidKeys VAPIDKeySource
 }
 
 func NewNotifier(logger *zap.Logger) *Notifier {
@@ -33,10 +41,22 @@ func NewNotifier(logger *zap.Logger) *Notifier {
 
 // NotificationMessage 通用通知消息（支持短信、来电等）
 type NotificationMessage struct {
-	Type      string // "sms" 或 "call"
-	From      string
-	Content   string // 短信内容（来电时为空）
-	Timestamp int64
+	ID        string `json:"id,omitempty"`    // 短信记录 ID，用于确认（POST /api/ack/:id）
+	Type      string `json:"type"`            // "sms" 或 "call"
+	Event     string `json:"event,omitempty"` // 事件类型，如 device-offline，为空时按 Type 判断
+	From      string `json:"from"`
+	Content   string `json:"content"` // 短信内容（来电时为空）
+	Timestamp int64  `json:"timestamp"`
+	// Fields 规则提取的结构化字段
+	Fields map[string]string `json:"fields,omitempty"`
+	// Priority 通知优先级，由号码分类规则或会话设置指定，为空时按渠道配置发送
+	Priority models.NotificationPriority `json:"priority,omitempty"`
+	// DeviceID 收到短信或来电的设备，外部推送时为空
+	DeviceID string `json:"deviceId,omitempty"`
+	// Channels 限定发送的渠道类型，为空时发送到所有启用的渠道
+	Channels []string `json:"-"`
+	// System 系统通知（如存储空间告警），不做隐私处理
+	System bool `json:"-"`
 }
 
 func (m NotificationMessage) String() string {
@@ -158,47 +178,85 @@ func (n *Notifier) sendFeishu(ctx context.Context, webhook, signSecret, message
 	return nil
 }
 
-// 导出方法
+// SendTelegramByConfig 导出方法供外部调用
 func (n *Notifier) SendTelegramByConfig(ctx context.Context, config map[string]interface{}, message string) error {
-	return n.sendTelegramByConfig(ctx, config, message)
+	return n.sendTelegramByConfig(ctx, config, message, false)
 }
 
-func (n *Notifier) sendTelegramByConfig(ctx context.Context, config map[string]interface{}, message string) error {
-	n.logger.Info("config:", zap.Any("config", config))
-	apitoken := config["apiToken"].(string)
-	userid := config["userid"].(string)
-	proxyEnabled := config["proxyEnabled"].(bool)
-	proxyUrl := config["proxyUrl"].(string)
-	proxyUsername := config["proxyUsername"].(string)
-	proxyPassword := config["proxyPassword"].(string)
-
-	// 构建发送消息的URL
-	baseURL := fmt.Sprintf("https://api.telegram.org/bot%s/sendMessage", apitoken)
+// telegramResult Telegram Bot API 响应
+type telegramResult struct {
+	OK          bool   `json:"ok"`
+	Description string `json:"description"`
+}
+
+// sendTelegramByConfig 通过 Telegram Bot 发送通知。
+// 配置 botToken 和 chatId（兼容旧配置中的 apiToken 和 userid），
+// 设备所在网络无法直连 Telegram 时可启用代理（支持 http、https、socks5）。
+// silent 为 true 时发送静默消息，客户端收到后不响铃。
+func (n *Notifier) sendTelegramByConfig(ctx context.Context, config map[string]interface{}, message string, silent bool) error {
+	botToken := telegramConfigString(config, "botToken", "apiToken")
+	chatID := telegramConfigString(config, "chatId", "userid")
+	if botToken == "" || chatID == "" {
+		return fmt.Errorf("Telegram 配置缺少 botToken 或 chatId")
+	}
+	proxyEnabled, _ := config["proxyEnabled"].(bool)
+	proxyUrl, _ := config["proxyUrl"].(string)
+	proxyUsername, _ := config["proxyUsername"].(string)
+	proxyPassword, _ := config["proxyPassword"].(string)
+
+	baseURL := fmt.Sprintf("https://api.telegram.org/bot%s/sendMessage", botToken)
 	body := map[string]interface{}{
-		"chat_id": userid,
+		"chat_id": chatID,
 		"text":    message,
-		//"parse_mode": "markdown",
+	}
+	if silent {
+		body["disable_notification"] = true
 	}
 
-	if proxyEnabled {
+	var result []byte
+	var err error
+	if proxyEnabled && proxyUrl != "" {
 		proxyFullUrl, err := buildProxyURL(proxyUrl, proxyUsername, proxyPassword)
 		if err != nil {
 			n.logger.Error("代理配置错误", zap.Error(err))
 			return err
 		}
-		_, err = n.sendJSONRequestWithProxy(ctx, baseURL, proxyFullUrl, body)
+		result, err = n.sendJSONRequestWithProxy(ctx, baseURL, proxyFullUrl, body)
 		if err != nil {
 			return err
 		}
 	} else {
-		_, err := n.sendJSONRequest(ctx, baseURL, body)
+		result, err = n.sendJSONRequest(ctx, baseURL, body)
 		if err != nil {
 			return err
 		}
 	}
+
+	var telegram telegramResult
+	if err := json.Unmarshal(result, &telegram); err != nil {
+		return err
+	}
+	if !telegram.OK {
+		return fmt.Errorf("Telegram 发送失败: %s", telegram.Description)
+	}
 	return nil
 }
 
+// telegramConfigString 按顺序读取第一个非空的字符串配置，chatId 也可以是数字
+func telegramConfigString(config map[string]interface{}, keys ...string) string {
+	for _, key := range keys {
+		switch v := config[key].(type) {
+		case string:
+			if v = strings.TrimSpace(v); v != "" {
+				return v
+			}
+		case float64:
+			return strconv.FormatInt(int64(v), 10)
+		}
+	}
+	return ""
+}
+
 // sendCustomWebhook 发送自定义Webhook
 func (n *Notifier) sendCustomWebhook(ctx context.Context, config map[string]interface{}, msg NotificationMessage) error {
 	// 解析配置
@@ -247,10 +305,17 @@ func (n *Notifier) sendCustomWebhook(ctx context.Context, config map[string]inte
 			v = msg.Content
 		case "type":
 			v = msg.Type
+		case "priority":
+			v = string(msg.Priority)
 		case "timestamp":
 			timestamp := time.Unix(msg.Timestamp, 0).Format(time.DateTime)
 			v = timestamp
 		default:
+			// {{fields.code}} 引用提取的字段，未提取到时为空
+			if name, ok := strings.CutPrefix(tag, "fields."); ok {
+				v = msg.Fields[name]
+				break
+			}
 			return w.Write([]byte("{{" + tag + "}}"))
 		}
 
@@ -275,10 +340,8 @@ func (n *Notifier) sendCustomWebhook(ctx context.Context, config map[string]inte
 		req.Header.Set(k, v)
 	}
 
-	// 发送请求
-	client := &http.Client{
-		Timeout: 10 * time.Second,
-	}
+	// 发送请求，超时由渠道策略通过 ctx 控制
+	client := &http.Client{}
 
 	resp, err := client.Do(req)
 	if err != nil {
@@ -316,13 +379,12 @@ func (n *Notifier) sendJSONRequest(ctx context.Context, url string, body interfa
 
 	req.Header.Set("Content-Type", "application/json")
 
-	client := &http.Client{
-		Timeout: 10 * time.Second,
-	}
+	// 超时由渠道策略通过 ctx 控制
+	client := &http.Client{}
 
 	resp, err := client.Do(req)
 	if err != nil {
-		return nil, fmt.Errorf("发送请求失败: %w", err)
+		return nil, fmt.Errorf("发送请求失败: %w", withoutURL(err))
 	}
 	defer resp.Body.Close()
 
@@ -333,10 +395,29 @@ func (n *Notifier) sendJSONRequest(ctx context.Context, url string, body interfa
 		return nil, fmt.Errorf("请求失败，状态码: %d, 响应: %s", resp.StatusCode, string(respBody))
 	}
 
-	n.logger.Info("通知发送成功", zap.String("url", url), zap.String("response", string(respBody)))
+	n.logger.Info("通知发送成功", zap.String("host", requestHost(url)), zap.String("response", string(respBody)))
 	return respBody, nil
 }
 
+// requestHost 请求地址的主机名，用于日志。
+// 钉钉、企业微信、飞书和 Telegram 的地址中包含令牌（如 /bot<token>/），不记录完整地址
+func requestHost(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return ""
+	}
+	return u.Host
+}
+
+// withoutURL 去掉请求错误中的完整地址，避免令牌随错误写入日志和通知记录
+func withoutURL(err error) error {
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		return urlErr.Err
+	}
+	return err
+}
+
 func (n *Notifier) sendJSONRequestWithProxy(ctx context.Context, url string, proxyUrl *url.URL, body interface{}) ([]byte, error) {
 	data, err := json.Marshal(body)
 	if err != nil {
@@ -353,14 +434,14 @@ func (n *Notifier) sendJSONRequestWithProxy(ctx context.Context, url string, pro
 	transport := &http.Transport{}
 	transport.Proxy = http.ProxyURL(proxyUrl)
 
+	// 超时由渠道策略通过 ctx 控制
 	client := &http.Client{
-		Timeout:   10 * time.Second,
 		Transport: transport,
 	}
 
 	resp, err := client.Do(req)
 	if err != nil {
-		return nil, fmt.Errorf("发送请求失败: %w", err)
+		return nil, fmt.Errorf("发送请求失败: %w", withoutURL(err))
 	}
 	defer resp.Body.Close()
 
@@ -371,7 +452,7 @@ func (n *Notifier) sendJSONRequestWithProxy(ctx context.Context, url string, pro
 		return nil, fmt.Errorf("请求失败，状态码: %d, 响应: %s", resp.StatusCode, string(respBody))
 	}
 
-	n.logger.Info("通知发送成功", zap.String("url", url), zap.String("response", string(respBody)))
+	n.logger.Info("通知发送成功", zap.String("host", requestHost(url)), zap.String("response", string(respBody)))
 	return respBody, nil
 }
 
@@ -500,9 +581,15 @@ func (n *Notifier) sendEmail(ctx context.Context, config map[string]interface{},
 				v = msg.Content
 			case "type":
 				v = msg.Type
+			case "priority":
+				v = string(msg.Priority)
 			case "timestamp":
 				v = time.Unix(msg.Timestamp, 0).Format(time.DateTime)
 			default:
+				if name, ok := strings.CutPrefix(tag, "fields."); ok {
+					v = msg.Fields[name]
+					break
+				}
 				return w.Write([]byte("{{" + tag + "}}"))
 			}
 			return w.Write([]byte(v))
@@ -526,6 +613,15 @@ func (n *Notifier) sendEmail(ctx context.Context, config map[string]interface{},
 	m.SetHeader("From", from)
 	m.SetHeader("To", toList...)
 	m.SetHeader("Subject", subject)
+	// 邮件客户端按 X-Priority 标记重要邮件：1 最高，5 最低
+	switch msg.Priority {
+	case models.NotificationPriorityHigh, models.NotificationPriorityCritical:
+		m.SetHeader("X-Priority", "1")
+		m.SetHeader("Importance", "high")
+	case models.NotificationPriorityLow:
+		m.SetHeader("X-Priority", "5")
+		m.SetHeader("Importance", "low")
+	}
 	m.SetBody("text/plain", body)
 
 	// 创建 SMTP 拨号器
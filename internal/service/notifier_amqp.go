@@ -0,0 +1,84 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+	"go.uber.org/zap"
+)
+
+// sendAMQP 发布消息到 AMQP（RabbitMQ）交换机，使用持久化投递并等待 broker 确认
+func (n *Notifier) sendAMQP(ctx context.Context, config map[string]interface{}, msg NotificationMessage) error {
+	amqpURL, ok := config["url"].(string)
+	if !ok || amqpURL == "" {
+		return fmt.Errorf("AMQP配置缺少 url")
+	}
+
+	// exchange 为空时使用默认交换机，此时 routingKey 即队列名
+	exchange, _ := config["exchange"].(string)
+	routingKey, _ := config["routingKey"].(string)
+	if exchange == "" && routingKey == "" {
+		return fmt.Errorf("AMQP配置缺少 exchange 或 routingKey")
+	}
+
+	conn, err := amqp.DialConfig(amqpURL, amqp.Config{
+		Dial: amqp.DefaultDial(10 * time.Second),
+	})
+	if err != nil {
+		return fmt.Errorf("连接 AMQP 服务器失败: %w", err)
+	}
+	defer conn.Close()
+
+	ch, err := conn.Channel()
+	if err != nil {
+		return fmt.Errorf("打开 AMQP 通道失败: %w", err)
+	}
+	defer ch.Close()
+
+	// 开启 publisher confirm，确保消息被 broker 接收
+	if err := ch.Confirm(false); err != nil {
+		return fmt.Errorf("开启 AMQP 确认模式失败: %w", err)
+	}
+
+	body, err := json.Marshal(msg)
+	if err != nil {
+		return fmt.Errorf("序列化消息失败: %w", err)
+	}
+
+	publishCtx, cancel := withDefaultTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	confirmation, err := ch.PublishWithDeferredConfirmWithContext(publishCtx, exchange, routingKey, true, false, amqp.Publishing{
+		ContentType:  "application/json",
+		DeliveryMode: amqp.Persistent,
+		Timestamp:    time.Unix(msg.Timestamp, 0),
+		Type:         msg.Type,
+		AppId:        "uart_sms_forwarder",
+		Body:         body,
+	})
+	if err != nil {
+		return fmt.Errorf("发布 AMQP 消息失败: %w", err)
+	}
+
+	acked, err := confirmation.WaitContext(publishCtx)
+	if err != nil {
+		return fmt.Errorf("等待 AMQP 确认失败: %w", err)
+	}
+	if !acked {
+		return fmt.Errorf("AMQP 消息被 broker 拒绝")
+	}
+
+	n.logger.Info("AMQP发布成功",
+		zap.String("exchange", exchange),
+		zap.String("routingKey", routingKey),
+	)
+	return nil
+}
+
+// SendAMQPByConfig 导出方法供外部调用
+func (n *Notifier) SendAMQPByConfig(ctx context.Context, config map[string]interface{}, msg NotificationMessage) error {
+	return n.sendAMQP(ctx, config, msg)
+}
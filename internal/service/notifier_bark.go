@@ -0,0 +1,110 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/dushixiang/uart_sms_forwarder/internal/models"
+)
+
+const (
+	// barkDefaultServer Bark 官方服务器，自建服务器时在渠道配置 serverUrl 中指定
+	barkDefaultServer = "https://api.day.app"
+	// barkMaxBodyLength 通知正文最大字数，APNs 限制推送大小
+	barkMaxBodyLength = 1000
+)
+
+// barkLevels Bark 支持的通知级别
+var barkLevels = []string{"active", "timeSensitive", "passive", "critical"}
+
+// barkPriorityLevels 通知优先级对应的 Bark 通知级别
+var barkPriorityLevels = map[models.NotificationPriority]string{
+	models.NotificationPriorityLow:      "passive",
+	models.NotificationPriorityNormal:   "active",
+	models.NotificationPriorityHigh:     "timeSensitive",
+	models.NotificationPriorityCritical: "critical",
+}
+
+// barkResult Bark 服务器响应
+type barkResult struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// SendBarkByConfig 通过 Bark 推送到 iPhone。
+// 配置 serverUrl（默认官方服务器）、deviceKey，以及可选的 group（分组）、sound（铃声）、
+// level（active、timeSensitive、passive、critical）和 icon（图标地址）。
+// 通知指定了优先级时覆盖配置的 level，critical 优先级可通过 criticalSound 单独指定铃声。
+func (n *Notifier) SendBarkByConfig(ctx context.Context, config map[string]interface{}, msg NotificationMessage) error {
+	deviceKey, _ := config["deviceKey"].(string)
+	deviceKey = strings.TrimSpace(deviceKey)
+	if deviceKey == "" {
+		return errors.New("Bark 配置缺少 deviceKey")
+	}
+	serverURL, _ := config["serverUrl"].(string)
+	serverURL = strings.TrimRight(strings.TrimSpace(serverURL), "/")
+	if serverURL == "" {
+		serverURL = barkDefaultServer
+	}
+
+	title := "来自 " + msg.From
+	body := msg.Content
+	switch {
+	case msg.System:
+		title = "系统通知"
+	case msg.Type == "call":
+		title = "来电 " + msg.From
+		body = "来电号码: " + msg.From
+	}
+	if runes := []rune(body); len(runes) > barkMaxBodyLength {
+		body = string(runes[:barkMaxBodyLength]) + "…"
+	}
+
+	payload := map[string]interface{}{
+		"device_key": deviceKey,
+		"title":      title,
+		"body":       body,
+	}
+	if msg.Timestamp > 0 {
+		payload["subtitle"] = time.Unix(msg.Timestamp, 0).Format(time.DateTime)
+	}
+	for _, key := range []string{"group", "sound", "icon"} {
+		if value, _ := config[key].(string); value != "" {
+			payload[key] = value
+		}
+	}
+	if level, _ := config["level"].(string); level != "" {
+		if !slices.Contains(barkLevels, level) {
+			return fmt.Errorf("Bark 通知级别无效: %s", level)
+		}
+		payload["level"] = level
+	}
+	if level, ok := barkPriorityLevels[msg.Priority]; ok {
+		payload["level"] = level
+	}
+	if msg.Priority == models.NotificationPriorityCritical {
+		// 重要警告忽略静音和勿扰模式，音量最大
+		payload["volume"] = 10
+		if sound, _ := config["criticalSound"].(string); sound != "" {
+			payload["sound"] = sound
+		}
+	}
+
+	respBody, err := n.sendJSONRequest(ctx, serverURL+"/push", payload)
+	if err != nil {
+		return err
+	}
+	var result barkResult
+	if err := json.Unmarshal(respBody, &result); err != nil {
+		return fmt.Errorf("解析 Bark 响应失败: %w", err)
+	}
+	if result.Code != 200 {
+		return fmt.Errorf("Bark 推送失败: %s（%d）", result.Message, result.Code)
+	}
+	return nil
+}
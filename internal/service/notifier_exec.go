@@ -0,0 +1,115 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+const (
+	// execDefaultTimeout 本地命令默认超时时间
+	execDefaultTimeout = 30 * time.Second
+	// execMaxOutput 记录命令输出的最大字节数
+	execMaxOutput = 4096
+)
+
+// limitedBuffer 只保留前 limit 字节的输出，防止脚本输出过多占用内存
+type limitedBuffer struct {
+	buf   bytes.Buffer
+	limit int
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	if remain := b.limit - b.buf.Len(); remain > 0 {
+		if len(p) > remain {
+			b.buf.Write(p[:remain])
+		} else {
+			b.buf.Write(p)
+		}
+	}
+	// 始终返回完整长度，避免子进程因写入失败而退出
+	return len(p), nil
+}
+
+func (b *limitedBuffer) String() string {
+	return b.buf.String()
+}
+
+// sendExec 执行本地命令，消息通过环境变量和标准输入（JSON）传递
+func (n *Notifier) sendExec(ctx context.Context, config map[string]interface{}, msg NotificationMessage) error {
+	command, ok := config["command"].(string)
+	if !ok || command == "" {
+		return fmt.Errorf("本地命令配置缺少 command")
+	}
+
+	var args []string
+	if a, ok := config["args"].([]interface{}); ok {
+		for _, v := range a {
+			if s, ok := v.(string); ok {
+				args = append(args, s)
+			}
+		}
+	}
+
+	timeout := execDefaultTimeout
+	if t, ok := config["timeout"].(float64); ok && t > 0 {
+		timeout = time.Duration(t) * time.Second
+	}
+
+	execCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	input, err := json.Marshal(msg)
+	if err != nil {
+		return fmt.Errorf("序列化消息失败: %w", err)
+	}
+
+	cmd := exec.CommandContext(execCtx, command, args...)
+	if workDir, ok := config["workDir"].(string); ok && workDir != "" {
+		cmd.Dir = workDir
+	}
+	cmd.Env = append(os.Environ(),
+		"SMS_TYPE="+msg.Type,
+		"SMS_FROM="+msg.From,
+		"SMS_CONTENT="+msg.Content,
+		"SMS_TIMESTAMP="+strconv.FormatInt(msg.Timestamp, 10),
+	)
+	cmd.Stdin = bytes.NewReader(input)
+
+	stdout := &limitedBuffer{limit: execMaxOutput}
+	stderr := &limitedBuffer{limit: execMaxOutput}
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+
+	start := time.Now()
+	err = cmd.Run()
+	elapsed := time.Since(start)
+
+	if err != nil {
+		if errors.Is(execCtx.Err(), context.DeadlineExceeded) {
+			return fmt.Errorf("命令执行超时（%s）, stderr: %s", timeout, stderr.String())
+		}
+		return fmt.Errorf("命令执行失败: %w, stdout: %s, stderr: %s", err, stdout.String(), stderr.String())
+	}
+
+	n.logger.Info("本地命令执行成功",
+		zap.String("command", command),
+		zap.Duration("elapsed", elapsed),
+		zap.String("stdout", stdout.String()),
+		zap.String("stderr", stderr.String()),
+	)
+	return nil
+}
+
+// SendExecByConfig 导出方法供外部调用
+func (n *Notifier) SendExecByConfig(ctx context.Context, config map[string]interface{}, msg NotificationMessage) error {
+	return n.sendExec(ctx, config, msg)
+}
@@ -0,0 +1,232 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dushixiang/uart_sms_forwarder/internal/models"
+	"go.uber.org/zap"
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/jwt"
+)
+
+const (
+	// PushKindFCM Firebase Cloud Messaging 推送
+	PushKindFCM = "fcm"
+
+	fcmScope         = "https://www.googleapis.com/auth/firebase.messaging"
+	fcmEndpoint      = "https://fcm.googleapis.com/v1/projects/%s/messages:send"
+	googleTokenURL   = "https://oauth2.googleapis.com/token"
+	fcmSendTimeout   = 10 * time.Second // 未设置截止时间时的默认超时
+	fcmMaxBodyLength = 1000             // 通知正文最大字数，完整内容在 data.content 中
+)
+
+// PushDeviceStore 推送设备存储，推送渠道从中读取已注册的设备
+type PushDeviceStore interface {
+	FindByKind(ctx context.Context, kind string) ([]models.PushDevice, error)
+	DeleteByToken(ctx context.Context, token string) error
+}
+
+// SetPushDeviceStore 设置推送设备存储
+func (n *Notifier) SetPushDeviceStore(store PushDeviceStore) {
+	n.pushDevices = store
+}
+
+// serviceAccount Firebase 服务账号密钥文件中用到的字段
+type serviceAccount struct {
+	ProjectID   string `json:"project_id"`
+	ClientEmail string `json:"client_email"`
+	PrivateKey  string `json:"private_key"`
+	TokenURI    string `json:"token_uri"`
+}
+
+// fcmTokenSources 按服务账号缓存 OAuth2 访问令牌，令牌过期前复用
+type fcmTokenSources struct {
+	mu      sync.Mutex
+	sources map[[32]byte]oauth2.TokenSource
+}
+
+func (f *fcmTokenSources) get(account serviceAccount) oauth2.TokenSource {
+	key := sha256.Sum256([]byte(account.ClientEmail + "\x00" + account.PrivateKey))
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.sources == nil {
+		f.sources = make(map[[32]byte]oauth2.TokenSource)
+	}
+	if source, ok := f.sources[key]; ok {
+		return source
+	}
+	tokenURL := account.TokenURI
+	if tokenURL == "" {
+		tokenURL = googleTokenURL
+	}
+	conf := &jwt.Config{
+		Email:      account.ClientEmail,
+		PrivateKey: []byte(account.PrivateKey),
+		Scopes:     []string{fcmScope},
+		TokenURL:   tokenURL,
+	}
+	source := oauth2.ReuseTokenSource(nil, conf.TokenSource(context.Background()))
+	f.sources[key] = source
+	return source
+}
+
+// SendFCMByConfig 通过 FCM HTTP v1 接口推送到所有已注册的设备，
+// iOS 设备由 FCM 经 APNs 投递。已失效的设备 token 自动删除。
+func (n *Notifier) SendFCMByConfig(ctx context.Context, config map[string]interface{}, msg NotificationMessage) error {
+	if n.pushDevices == nil {
+		return errors.New("推送设备存储未初始化")
+	}
+
+	raw, _ := config["serviceAccount"].(string)
+	if strings.TrimSpace(raw) == "" {
+		return errors.New("FCM 服务账号密钥不能为空")
+	}
+	var account serviceAccount
+	if err := json.Unmarshal([]byte(raw), &account); err != nil {
+		return fmt.Errorf("FCM 服务账号密钥格式错误: %w", err)
+	}
+	projectID, _ := config["projectId"].(string)
+	if projectID == "" {
+		projectID = account.ProjectID
+	}
+	if projectID == "" || account.ClientEmail == "" || account.PrivateKey == "" {
+		return errors.New("FCM 服务账号密钥缺少 project_id、client_email 或 private_key")
+	}
+
+	devices, err := n.pushDevices.FindByKind(ctx, PushKindFCM)
+	if err != nil {
+		return fmt.Errorf("获取推送设备失败: %w", err)
+	}
+	if len(devices) == 0 {
+		return errors.New("没有已注册的推送设备")
+	}
+
+	token, err := n.fcm.get(account).Token()
+	if err != nil {
+		return fmt.Errorf("获取 FCM 访问令牌失败: %w", err)
+	}
+
+	endpoint := fmt.Sprintf(fcmEndpoint, projectID)
+	var errs []error
+	for _, device := range devices {
+		err := n.sendFCM(ctx, endpoint, token.AccessToken, device.Token, msg)
+		if err == nil {
+			continue
+		}
+		if errors.Is(err, errFCMUnregistered) {
+			n.logger.Info("FCM 设备 token 已失效，删除设备", zap.String("device", device.Name))
+			if err := n.pushDevices.DeleteByToken(ctx, device.Token); err != nil {
+				n.logger.Error("删除失效的推送设备失败", zap.Error(err))
+			}
+			continue
+		}
+		errs = append(errs, fmt.Errorf("%s: %w", device.Name, err))
+	}
+	return errors.Join(errs...)
+}
+
+// errFCMUnregistered 设备 token 已失效（App 卸载或 token 轮换）
+var errFCMUnregistered = errors.New("FCM 设备 token 已失效")
+
+// sendFCM 推送到单个设备
+func (n *Notifier) sendFCM(ctx context.Context, endpoint, accessToken, deviceToken string, msg NotificationMessage) error {
+	title := "来自 " + msg.From
+	body := msg.Content
+	if msg.Type == "call" {
+		title = "来电 " + msg.From
+		body = "来电号码: " + msg.From
+	}
+	if runes := []rune(body); len(runes) > fcmMaxBodyLength {
+		body = string(runes[:fcmMaxBodyLength]) + "…"
+	}
+
+	data := map[string]string{
+		"type":      msg.Type,
+		"from":      msg.From,
+		"content":   msg.Content,
+		"timestamp": strconv.FormatInt(msg.Timestamp, 10),
+	}
+	if len(msg.Fields) > 0 {
+		fields, _ := json.Marshal(msg.Fields)
+		data["fields"] = string(fields)
+	}
+
+	if msg.Priority != "" {
+		data["priority"] = string(msg.Priority)
+	}
+
+	// 低优先级通知不立即唤醒设备，也不响铃
+	androidPriority, apnsPriority := "HIGH", "10"
+	aps := map[string]interface{}{"sound": "default"}
+	switch msg.Priority {
+	case models.NotificationPriorityLow:
+		androidPriority, apnsPriority = "NORMAL", "5"
+		aps = map[string]interface{}{"interruption-level": "passive"}
+	case models.NotificationPriorityHigh:
+		aps["interruption-level"] = "time-sensitive"
+	case models.NotificationPriorityCritical:
+		// 需要 App 具有 critical alerts 权限，否则按普通通知处理
+		aps["interruption-level"] = "critical"
+		aps["sound"] = map[string]interface{}{"critical": 1, "name": "default", "volume": 1.0}
+	}
+
+	payload := map[string]interface{}{
+		"message": map[string]interface{}{
+			"token": deviceToken,
+			"notification": map[string]string{
+				"title": title,
+				"body":  body,
+			},
+			"data": data,
+			"android": map[string]interface{}{
+				"priority": androidPriority,
+			},
+			"apns": map[string]interface{}{
+				"headers": map[string]string{"apns-priority": apnsPriority},
+				"payload": map[string]interface{}{
+					"aps": aps,
+				},
+			},
+		},
+	}
+	reqBody, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := withDefaultTimeout(ctx, fcmSendTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(reqBody))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("发送请求失败: %w", err)
+	}
+	defer resp.Body.Close()
+	respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+
+	if resp.StatusCode == http.StatusNotFound || bytes.Contains(respBody, []byte("UNREGISTERED")) {
+		return errFCMUnregistered
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("请求失败，状态码: %d, 响应: %s", resp.StatusCode, string(respBody))
+	}
+	return nil
+}
@@ -0,0 +1,122 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"gopkg.in/natefinch/lumberjack.v2"
+)
+
+// fileSink 文件输出目标，按路径复用，避免每条消息重新打开文件
+type fileSink struct {
+	mu     sync.Mutex
+	writer *lumberjack.Logger
+}
+
+// fileSinks 按文件路径缓存的输出目标
+type fileSinks struct {
+	mu    sync.Mutex
+	sinks map[string]*fileSink
+}
+
+// get 获取（或创建）指定路径的输出目标；轮转参数变化时重新创建
+func (f *fileSinks) get(path string, maxSize, maxBackups, maxAge int, compress bool) *fileSink {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.sinks == nil {
+		f.sinks = make(map[string]*fileSink)
+	}
+
+	if sink, ok := f.sinks[path]; ok {
+		w := sink.writer
+		if w.MaxSize == maxSize && w.MaxBackups == maxBackups && w.MaxAge == maxAge && w.Compress == compress {
+			return sink
+		}
+		sink.mu.Lock()
+		_ = w.Close()
+		sink.mu.Unlock()
+	}
+
+	sink := &fileSink{
+		writer: &lumberjack.Logger{
+			Filename:   path,
+			MaxSize:    maxSize,
+			MaxBackups: maxBackups,
+			MaxAge:     maxAge,
+			Compress:   compress,
+			LocalTime:  true,
+		},
+	}
+	f.sinks[path] = sink
+	return sink
+}
+
+// formatFileLine 将消息格式化为一行，jsonl 或纯文本
+func formatFileLine(format string, msg NotificationMessage) (string, error) {
+	switch format {
+	case "", "jsonl":
+		data, err := json.Marshal(msg)
+		if err != nil {
+			return "", fmt.Errorf("序列化消息失败: %w", err)
+		}
+		return string(data) + "\n", nil
+	case "text":
+		content := strings.ReplaceAll(msg.Content, "\n", "\\n")
+		return fmt.Sprintf("%s\t%s\t%s\t%s\n",
+			time.Unix(msg.Timestamp, 0).Format(time.DateTime),
+			msg.Type,
+			msg.From,
+			content,
+		), nil
+	default:
+		return "", fmt.Errorf("不支持的文件格式: %s", format)
+	}
+}
+
+// sendFile 将消息追加写入本地文件，按大小自动轮转
+func (n *Notifier) sendFile(ctx context.Context, config map[string]interface{}, msg NotificationMessage) error {
+	path, ok := config["path"].(string)
+	if !ok || path == "" {
+		return fmt.Errorf("文件配置缺少 path")
+	}
+	path = filepath.Clean(path)
+
+	format, _ := config["format"].(string)
+	line, err := formatFileLine(strings.ToLower(format), msg)
+	if err != nil {
+		return err
+	}
+
+	maxSize := 100 // MB
+	if v, ok := config["maxSize"].(float64); ok && v > 0 {
+		maxSize = int(v)
+	}
+	maxBackups, _ := config["maxBackups"].(float64)
+	maxAge, _ := config["maxAge"].(float64)
+	compress, _ := config["compress"].(bool)
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("创建目录失败: %w", err)
+	}
+
+	sink := n.files.get(path, maxSize, int(maxBackups), int(maxAge), compress)
+	sink.mu.Lock()
+	defer sink.mu.Unlock()
+
+	if _, err := sink.writer.Write([]byte(line)); err != nil {
+		return fmt.Errorf("写入文件失败: %w", err)
+	}
+	return nil
+}
+
+// SendFileByConfig 导出方法供外部调用
+func (n *Notifier) SendFileByConfig(ctx context.Context, config map[string]interface{}, msg NotificationMessage) error {
+	return n.sendFile(ctx, config, msg)
+}
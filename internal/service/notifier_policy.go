@@ -0,0 +1,73 @@
+package service
+
+import (
+	"context"
+	"time"
+)
+
+// 通知渠道默认的超时和重试策略，可在渠道配置中通过 timeout、retries、retryBackoff 覆盖
+const (
+	defaultChannelTimeout      = 10 * time.Second
+	defaultChannelRetryBackoff = 2 * time.Second
+	maxChannelRetries          = 5
+)
+
+// ChannelPolicy 通知渠道的超时和重试策略
+type ChannelPolicy struct {
+	Timeout time.Duration // 单次发送超时
+	Retries int           // 失败后的重试次数，0 表示不重试
+	Backoff time.Duration // 首次重试前的等待时间，之后每次翻倍
+}
+
+// ChannelPolicyFromConfig 从渠道配置中读取超时和重试策略：
+// timeout 单次发送超时（秒），retries 重试次数，retryBackoff 首次重试间隔（秒）
+func ChannelPolicyFromConfig(channelType string, config map[string]interface{}) ChannelPolicy {
+	policy := ChannelPolicy{
+		Timeout: defaultChannelTimeout,
+		Backoff: defaultChannelRetryBackoff,
+	}
+	if channelType == "exec" {
+		policy.Timeout = execDefaultTimeout
+	}
+
+	if t, ok := config["timeout"].(float64); ok && t > 0 {
+		policy.Timeout = time.Duration(t * float64(time.Second))
+	}
+	if r, ok := config["retries"].(float64); ok && r > 0 {
+		policy.Retries = min(int(r), maxChannelRetries)
+	}
+	if b, ok := config["retryBackoff"].(float64); ok && b >= 0 {
+		policy.Backoff = time.Duration(b * float64(time.Second))
+	}
+	return policy
+}
+
+// Do 按策略执行发送，每次尝试使用独立的超时，失败后按指数退避重试，返回最后一次的错误和尝试次数
+func (p ChannelPolicy) Do(ctx context.Context, send func(ctx context.Context) error) (int, error) {
+	var err error
+	backoff := p.Backoff
+	for attempt := 1; ; attempt++ {
+		attemptCtx, cancel := context.WithTimeout(ctx, p.Timeout)
+		err = send(attemptCtx)
+		cancel()
+		if err == nil || attempt > p.Retries {
+			return attempt, err
+		}
+
+		select {
+		case <-ctx.Done():
+			return attempt, err
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+}
+
+// withDefaultTimeout ctx 没有截止时间时设置默认超时；
+// 通过 ChannelPolicy.Do 发送时已按渠道配置的 timeout 设置了截止时间，不再限制
+func withDefaultTimeout(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if _, ok := ctx.Deadline(); ok {
+		return ctx, func() {}
+	}
+	return context.WithTimeout(ctx, timeout)
+}
@@ -0,0 +1,110 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/dushixiang/uart_sms_forwarder/internal/models"
+)
+
+const (
+	// pushoverAPI Pushover 消息接口
+	pushoverAPI = "https://api.pushover.net/1/messages.json"
+	// pushoverMaxMessageLength 消息正文最大字数
+	pushoverMaxMessageLength = 1024
+	// pushoverEmergencyRetry 紧急通知未确认时重复提醒的间隔（秒），Pushover 要求不小于 30
+	pushoverEmergencyRetry = 60
+	// pushoverEmergencyExpire 紧急通知停止重复提醒的时间（秒）
+	pushoverEmergencyExpire = 3600
+)
+
+// pushoverPriorities 通知优先级对应的 Pushover 优先级：-1 静默，0 普通，1 高（忽略免打扰），2 紧急（重复提醒直到确认）
+var pushoverPriorities = map[models.NotificationPriority]int{
+	models.NotificationPriorityLow:      -1,
+	models.NotificationPriorityNormal:   0,
+	models.NotificationPriorityHigh:     1,
+	models.NotificationPriorityCritical: 2,
+}
+
+// pushoverResult Pushover 接口响应
+type pushoverResult struct {
+	Status int      `json:"status"`
+	Errors []string `json:"errors"`
+}
+
+// SendPushoverByConfig 通过 Pushover 推送通知。
+// 配置 appToken（应用的 API Token）、userKey（用户或群组 Key），以及可选的 device（指定设备）和 sound（铃声）。
+// 通知指定了优先级时映射为 Pushover 的优先级，critical 为紧急通知，每 60 秒重复提醒直到确认（最长 1 小时）。
+func (n *Notifier) SendPushoverByConfig(ctx context.Context, config map[string]interface{}, msg NotificationMessage) error {
+	appToken, _ := config["appToken"].(string)
+	userKey, _ := config["userKey"].(string)
+	appToken, userKey = strings.TrimSpace(appToken), strings.TrimSpace(userKey)
+	if appToken == "" || userKey == "" {
+		return errors.New("Pushover 配置缺少 appToken 或 userKey")
+	}
+
+	title := "来自 " + msg.From
+	message := msg.Content
+	switch {
+	case msg.System:
+		title = "系统通知"
+	case msg.Type == "call":
+		title = "来电 " + msg.From
+		message = "来电号码: " + msg.From
+	}
+	if runes := []rune(message); len(runes) > pushoverMaxMessageLength {
+		message = string(runes[:pushoverMaxMessageLength-1]) + "…"
+	}
+	if message == "" {
+		message = "（无内容）"
+	}
+
+	form := url.Values{}
+	form.Set("token", appToken)
+	form.Set("user", userKey)
+	form.Set("title", title)
+	form.Set("message", message)
+	if msg.Timestamp > 0 {
+		form.Set("timestamp", strconv.FormatInt(msg.Timestamp, 10))
+	}
+	for _, key := range []string{"device", "sound"} {
+		if value, _ := config[key].(string); value != "" {
+			form.Set(key, value)
+		}
+	}
+	if priority, ok := pushoverPriorities[msg.Priority]; ok {
+		form.Set("priority", strconv.Itoa(priority))
+		if priority == 2 {
+			form.Set("retry", strconv.Itoa(pushoverEmergencyRetry))
+			form.Set("expire", strconv.Itoa(pushoverEmergencyExpire))
+		}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, pushoverAPI, strings.NewReader(form.Encode()))
+	if err != nil {
+		return fmt.Errorf("创建请求失败: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	// 超时由渠道策略通过 ctx 控制
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("发送请求失败: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var result pushoverResult
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return fmt.Errorf("解析 Pushover 响应失败（状态码 %d）: %w", resp.StatusCode, err)
+	}
+	if result.Status != 1 {
+		return fmt.Errorf("Pushover 推送失败: %s", strings.Join(result.Errors, "; "))
+	}
+	return nil
+}
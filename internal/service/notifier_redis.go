@@ -0,0 +1,97 @@
+package service
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+)
+
+// sendRedis 将消息 JSON 发布到 Redis，支持 PUBLISH（pubsub）和 XADD（stream）两种模式
+func (n *Notifier) sendRedis(ctx context.Context, config map[string]interface{}, msg NotificationMessage) error {
+	addr, ok := config["addr"].(string)
+	if !ok || addr == "" {
+		return fmt.Errorf("Redis配置缺少 addr")
+	}
+
+	key, ok := config["key"].(string)
+	if !ok || key == "" {
+		return fmt.Errorf("Redis配置缺少 key")
+	}
+
+	mode, _ := config["mode"].(string)
+	if mode == "" {
+		mode = "pubsub"
+	}
+	mode = strings.ToLower(mode)
+
+	username, _ := config["username"].(string)
+	password, _ := config["password"].(string)
+	// JSON 数字反序列化为 float64
+	db, _ := config["db"].(float64)
+
+	options := &redis.Options{
+		Addr:         addr,
+		Username:     username,
+		Password:     password,
+		DB:           int(db),
+		DialTimeout:  10 * time.Second,
+		ReadTimeout:  10 * time.Second,
+		WriteTimeout: 10 * time.Second,
+	}
+	if useTLS, _ := config["tls"].(bool); useTLS {
+		options.TLSConfig = &tls.Config{}
+	}
+
+	client := redis.NewClient(options)
+	defer client.Close()
+
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return fmt.Errorf("序列化消息失败: %w", err)
+	}
+
+	switch mode {
+	case "pubsub":
+		receivers, err := client.Publish(ctx, key, data).Result()
+		if err != nil {
+			return fmt.Errorf("Redis PUBLISH 失败: %w", err)
+		}
+		n.logger.Info("Redis发布成功", zap.String("channel", key), zap.Int64("receivers", receivers))
+	case "stream":
+		args := &redis.XAddArgs{
+			Stream: key,
+			Values: map[string]interface{}{
+				"type":      msg.Type,
+				"from":      msg.From,
+				"content":   msg.Content,
+				"timestamp": msg.Timestamp,
+				"json":      string(data),
+			},
+		}
+		// 可选：限制 stream 长度，避免无限增长
+		if maxLen, _ := config["maxLen"].(float64); maxLen > 0 {
+			args.MaxLen = int64(maxLen)
+			args.Approx = true
+		}
+		id, err := client.XAdd(ctx, args).Result()
+		if err != nil {
+			return fmt.Errorf("Redis XADD 失败: %w", err)
+		}
+		n.logger.Info("Redis Stream写入成功", zap.String("stream", key), zap.String("id", id))
+	default:
+		return fmt.Errorf("不支持的 Redis 模式: %s", mode)
+	}
+
+	return nil
+}
+
+// SendRedisByConfig 导出方法供外部调用
+func (n *Notifier) SendRedisByConfig(ctx context.Context, config map[string]interface{}, msg NotificationMessage) error {
+	return n.sendRedis(ctx, config, msg)
+}
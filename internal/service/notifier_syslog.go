@@ -0,0 +1,181 @@
+package service
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// syslogFacilities RFC5424 facility 名称与编号映射
+var syslogFacilities = map[string]int{
+	"kern":     0,
+	"user":     1,
+	"mail":     2,
+	"daemon":   3,
+	"auth":     4,
+	"syslog":   5,
+	"lpr":      6,
+	"news":     7,
+	"uucp":     8,
+	"cron":     9,
+	"authpriv": 10,
+	"ftp":      11,
+	"local0":   16,
+	"local1":   17,
+	"local2":   18,
+	"local3":   19,
+	"local4":   20,
+	"local5":   21,
+	"local6":   22,
+	"local7":   23,
+}
+
+const (
+	syslogSeverityNotice = 5 // 来电
+	syslogSeverityInfo   = 6 // 短信
+
+	// syslogSDID 结构化数据 ID（使用 IANA 示例企业号）
+	syslogSDID = "sms@32473"
+)
+
+// parseSyslogFacility 解析 facility，支持名称（local0）或数字（16）
+func parseSyslogFacility(value string) (int, error) {
+	if value == "" {
+		return syslogFacilities["user"], nil
+	}
+	if f, ok := syslogFacilities[strings.ToLower(value)]; ok {
+		return f, nil
+	}
+	f, err := strconv.Atoi(value)
+	if err != nil || f < 0 || f > 23 {
+		return 0, fmt.Errorf("无效的 syslog facility: %s", value)
+	}
+	return f, nil
+}
+
+// escapeSyslogParam 转义结构化数据参数值中的 '"', '\' 和 ']'
+func escapeSyslogParam(s string) string {
+	replacer := strings.NewReplacer(`\`, `\\`, `"`, `\"`, `]`, `\]`)
+	return replacer.Replace(s)
+}
+
+// buildSyslogMessage 构造 RFC5424 格式的 syslog 消息
+func buildSyslogMessage(facility int, hostname, appName string, msg NotificationMessage) string {
+	severity := syslogSeverityInfo
+	if msg.Type == "call" {
+		severity = syslogSeverityNotice
+	}
+	pri := facility*8 + severity
+
+	timestamp := time.Unix(msg.Timestamp, 0).Format(time.RFC3339)
+	structuredData := fmt.Sprintf(`[%s type="%s" from="%s"]`,
+		syslogSDID,
+		escapeSyslogParam(msg.Type),
+		escapeSyslogParam(msg.From),
+	)
+
+	content := msg.Content
+	if msg.Type == "call" {
+		content = fmt.Sprintf("来电号码: %s", msg.From)
+	}
+	// 换行会破坏大多数采集器的按行解析
+	content = strings.ReplaceAll(content, "\r", " ")
+	content = strings.ReplaceAll(content, "\n", " ")
+
+	// <PRI>VERSION TIMESTAMP HOSTNAME APP-NAME PROCID MSGID SD MSG，MSG 以 UTF-8 BOM 开头
+	return fmt.Sprintf("<%d>1 %s %s %s %d %s %s \xEF\xBB\xBF%s",
+		pri,
+		timestamp,
+		hostname,
+		appName,
+		os.Getpid(),
+		msg.Type,
+		structuredData,
+		content,
+	)
+}
+
+// sendSyslog 发送 syslog 消息，支持 udp、tcp、tls
+func (n *Notifier) sendSyslog(ctx context.Context, config map[string]interface{}, msg NotificationMessage) error {
+	address, ok := config["address"].(string)
+	if !ok || address == "" {
+		return fmt.Errorf("syslog配置缺少 address")
+	}
+
+	network, _ := config["network"].(string)
+	if network == "" {
+		network = "udp"
+	}
+	network = strings.ToLower(network)
+
+	facilityStr, _ := config["facility"].(string)
+	facility, err := parseSyslogFacility(facilityStr)
+	if err != nil {
+		return err
+	}
+
+	appName, _ := config["appName"].(string)
+	if appName == "" {
+		appName = "uart_sms_forwarder"
+	}
+
+	hostname, _ := config["hostname"].(string)
+	if hostname == "" {
+		hostname, _ = os.Hostname()
+		if hostname == "" {
+			hostname = "-"
+		}
+	}
+
+	message := buildSyslogMessage(facility, hostname, appName, msg)
+
+	dialer := &net.Dialer{Timeout: 10 * time.Second}
+	var conn net.Conn
+	switch network {
+	case "udp", "tcp":
+		conn, err = dialer.DialContext(ctx, network, address)
+	case "tls":
+		insecure, _ := config["insecureSkipVerify"].(bool)
+		tlsDialer := &tls.Dialer{
+			NetDialer: dialer,
+			Config:    &tls.Config{InsecureSkipVerify: insecure},
+		}
+		conn, err = tlsDialer.DialContext(ctx, "tcp", address)
+	default:
+		return fmt.Errorf("不支持的 syslog 传输协议: %s", network)
+	}
+	if err != nil {
+		return fmt.Errorf("连接 syslog 服务器失败: %w", err)
+	}
+	defer conn.Close()
+
+	_ = conn.SetWriteDeadline(time.Now().Add(10 * time.Second))
+
+	// TCP/TLS 使用 RFC6587 octet-counting 分帧
+	payload := message
+	if network != "udp" {
+		payload = fmt.Sprintf("%d %s", len(message), message)
+	}
+
+	if _, err := conn.Write([]byte(payload)); err != nil {
+		return fmt.Errorf("发送 syslog 消息失败: %w", err)
+	}
+
+	n.logger.Info("syslog发送成功",
+		zap.String("network", network),
+		zap.String("address", address),
+	)
+	return nil
+}
+
+// SendSyslogByConfig 导出方法供外部调用
+func (n *Notifier) SendSyslogByConfig(ctx context.Context, config map[string]interface{}, msg NotificationMessage) error {
+	return n.sendSyslog(ctx, config, msg)
+}
@@ -0,0 +1,291 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hkdf"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/dushixiang/uart_sms_forwarder/internal/models"
+	"go.uber.org/zap"
+)
+
+const (
+	// PushKindWebPush 浏览器 Web Push 订阅
+	PushKindWebPush = "webpush"
+
+	webPushSendTimeout   = 10 * time.Second // 未设置截止时间时的默认超时
+	webPushTTL           = 24 * time.Hour
+	webPushRecordSize    = 4096
+	webPushMaxBodyLength = 1000 // 通知正文最大字数，推送服务限制加密后的消息不超过 4KB
+	// webPushDefaultSubject VAPID 联系方式，未配置时使用项目地址
+	webPushDefaultSubject = "https://github.com/dushixiang/uart_sms_forwarder"
+)
+
+// VAPIDKeySource 获取 Web Push 服务器密钥对
+type VAPIDKeySource func(ctx context.Context) (models.VAPIDKeys, error)
+
+// SetVAPIDKeySource 设置 Web Push 服务器密钥来源
+func (n *Notifier) SetVAPIDKeySource(source VAPIDKeySource) {
+	n.vapidKeys = source
+}
+
+// webPushPayload 推送给浏览器 Service Worker 的消息内容
+type webPushPayload struct {
+	Title     string `json:"title"`
+	Body      string `json:"body"`
+	Type      string `json:"type"`
+	From      string `json:"from"`
+	Timestamp int64  `json:"timestamp"`
+	URL       string `json:"url"`                // 点击通知后打开的页面
+	Priority  string `json:"priority,omitempty"` // 通知优先级，low 时静默显示，high 和 critical 时保持显示直到用户处理
+}
+
+// errWebPushExpired 订阅已过期或被用户取消
+var errWebPushExpired = errors.New("Web Push 订阅已失效")
+
+// SendWebPushByConfig 推送到所有已订阅的浏览器，已失效的订阅自动删除
+func (n *Notifier) SendWebPushByConfig(ctx context.Context, config map[string]interface{}, msg NotificationMessage) error {
+	if n.pushDevices == nil || n.vapidKeys == nil {
+		return errors.New("Web Push 未初始化")
+	}
+
+	subject, _ := config["subject"].(string)
+	if subject == "" {
+		subject = webPushDefaultSubject
+	}
+
+	keys, err := n.vapidKeys(ctx)
+	if err != nil {
+		return fmt.Errorf("获取 VAPID 密钥失败: %w", err)
+	}
+	d, err := decodeBase64URL(keys.PrivateKey)
+	if err != nil {
+		return fmt.Errorf("VAPID 私钥格式错误: %w", err)
+	}
+	privateKey, err := ecdsa.ParseRawPrivateKey(elliptic.P256(), d)
+	if err != nil {
+		return fmt.Errorf("VAPID 私钥格式错误: %w", err)
+	}
+
+	devices, err := n.pushDevices.FindByKind(ctx, PushKindWebPush)
+	if err != nil {
+		return fmt.Errorf("获取浏览器订阅失败: %w", err)
+	}
+	if len(devices) == 0 {
+		return errors.New("没有已订阅的浏览器")
+	}
+
+	payload, err := json.Marshal(newWebPushPayload(msg))
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	for _, device := range devices {
+		err := n.sendWebPush(ctx, device, payload, webPushUrgency(msg.Priority), privateKey, keys.PublicKey, subject)
+		if err == nil {
+			continue
+		}
+		if errors.Is(err, errWebPushExpired) {
+			n.logger.Info("Web Push 订阅已失效，删除订阅", zap.String("device", device.Name))
+			if err := n.pushDevices.DeleteByToken(ctx, device.Token); err != nil {
+				n.logger.Error("删除失效的推送设备失败", zap.Error(err))
+			}
+			continue
+		}
+		errs = append(errs, fmt.Errorf("%s: %w", device.Name, err))
+	}
+	return errors.Join(errs...)
+}
+
+func newWebPushPayload(msg NotificationMessage) webPushPayload {
+	title := "来自 " + msg.From
+	body := msg.Content
+	if msg.Type == "call" {
+		title = "来电 " + msg.From
+		body = "来电号码: " + msg.From
+	}
+	if runes := []rune(body); len(runes) > webPushMaxBodyLength {
+		body = string(runes[:webPushMaxBodyLength]) + "…"
+	}
+	return webPushPayload{
+		Title:     title,
+		Body:      body,
+		Type:      msg.Type,
+		From:      msg.From,
+		Timestamp: msg.Timestamp,
+		URL:       "/messages",
+		Priority:  string(msg.Priority),
+	}
+}
+
+// webPushUrgency 通知优先级对应的 Urgency 请求头，低优先级通知由推送服务择机投递
+func webPushUrgency(priority models.NotificationPriority) string {
+	if priority == models.NotificationPriorityLow {
+		return "low"
+	}
+	return "high"
+}
+
+// sendWebPush 加密消息并发送到单个浏览器订阅的推送服务
+func (n *Notifier) sendWebPush(ctx context.Context, device models.PushDevice, payload []byte, urgency string, privateKey *ecdsa.PrivateKey, publicKey, subject string) error {
+	endpoint, err := url.Parse(device.Token)
+	if err != nil || endpoint.Scheme != "https" || endpoint.Host == "" {
+		return fmt.Errorf("订阅地址无效: %s", device.Token)
+	}
+
+	body, err := encryptWebPush(device.P256dh, device.Auth, payload)
+	if err != nil {
+		return err
+	}
+	token, err := vapidToken(privateKey, endpoint.Scheme+"://"+endpoint.Host, subject)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := withDefaultTimeout(ctx, webPushSendTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, device.Token, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/octet-stream")
+	req.Header.Set("Content-Encoding", "aes128gcm")
+	req.Header.Set("TTL", fmt.Sprintf("%d", int(webPushTTL.Seconds())))
+	req.Header.Set("Urgency", urgency)
+	req.Header.Set("Authorization", fmt.Sprintf("vapid t=%s, k=%s", token, publicKey))
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("发送请求失败: %w", err)
+	}
+	defer resp.Body.Close()
+	respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+
+	if resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusGone {
+		return errWebPushExpired
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("请求失败，状态码: %d, 响应: %s", resp.StatusCode, string(respBody))
+	}
+	return nil
+}
+
+// encryptWebPush 按 RFC 8291 使用 aes128gcm 加密消息，消息只有一条记录
+func encryptWebPush(p256dh, auth string, plaintext []byte) ([]byte, error) {
+	uaPublic, err := decodeBase64URL(p256dh)
+	if err != nil {
+		return nil, fmt.Errorf("订阅公钥格式错误: %w", err)
+	}
+	authSecret, err := decodeBase64URL(auth)
+	if err != nil {
+		return nil, fmt.Errorf("订阅认证密钥格式错误: %w", err)
+	}
+
+	curve := ecdh.P256()
+	uaKey, err := curve.NewPublicKey(uaPublic)
+	if err != nil {
+		return nil, fmt.Errorf("订阅公钥格式错误: %w", err)
+	}
+	asKey, err := curve.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	sharedSecret, err := asKey.ECDH(uaKey)
+	if err != nil {
+		return nil, err
+	}
+	asPublic := asKey.PublicKey().Bytes()
+
+	keyInfo := "WebPush: info\x00" + string(uaPublic) + string(asPublic)
+	ikm, err := hkdf.Key(sha256.New, sharedSecret, authSecret, keyInfo, 32)
+	if err != nil {
+		return nil, err
+	}
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+	prk, err := hkdf.Extract(sha256.New, ikm, salt)
+	if err != nil {
+		return nil, err
+	}
+	cek, err := hkdf.Expand(sha256.New, prk, "Content-Encoding: aes128gcm\x00", 16)
+	if err != nil {
+		return nil, err
+	}
+	nonce, err := hkdf.Expand(sha256.New, prk, "Content-Encoding: nonce\x00", 12)
+	if err != nil {
+		return nil, err
+	}
+
+	block, err := aes.NewCipher(cek)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	// 0x02 为最后一条记录的分隔符
+	record := append(append([]byte{}, plaintext...), 0x02)
+	if len(record)+gcm.Overhead() > webPushRecordSize {
+		return nil, errors.New("推送消息过长")
+	}
+
+	// 头部：salt(16) | rs(4) | idlen(1) | keyid(发送方公钥)
+	header := make([]byte, 0, 21+len(asPublic))
+	header = append(header, salt...)
+	header = binary.BigEndian.AppendUint32(header, webPushRecordSize)
+	header = append(header, byte(len(asPublic)))
+	header = append(header, asPublic...)
+	return gcm.Seal(header, nonce, record, nil), nil
+}
+
+// vapidToken 生成 RFC 8292 VAPID 认证使用的 ES256 JWT
+func vapidToken(privateKey *ecdsa.PrivateKey, audience, subject string) (string, error) {
+	header := base64.RawURLEncoding.EncodeToString([]byte(`{"typ":"JWT","alg":"ES256"}`))
+	claims, err := json.Marshal(map[string]interface{}{
+		"aud": audience,
+		"exp": time.Now().Add(12 * time.Hour).Unix(),
+		"sub": subject,
+	})
+	if err != nil {
+		return "", err
+	}
+	signingInput := header + "." + base64.RawURLEncoding.EncodeToString(claims)
+
+	digest := sha256.Sum256([]byte(signingInput))
+	r, s, err := ecdsa.Sign(rand.Reader, privateKey, digest[:])
+	if err != nil {
+		return "", err
+	}
+	signature := make([]byte, 64)
+	r.FillBytes(signature[:32])
+	s.FillBytes(signature[32:])
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
+
+// decodeBase64URL 解码 base64url，兼容带填充和标准 base64 字符的输入
+func decodeBase64URL(value string) ([]byte, error) {
+	value = strings.TrimRight(strings.TrimSpace(value), "=")
+	value = strings.NewReplacer("+", "-", "/", "_").Replace(value)
+	return base64.RawURLEncoding.DecodeString(value)
+}
@@ -0,0 +1,130 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/dushixiang/uart_sms_forwarder/internal/models"
+	"github.com/dushixiang/uart_sms_forwarder/internal/repo"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// NumberRuleService 号码分类规则服务
+type NumberRuleService struct {
+	logger *zap.Logger
+	repo   *repo.NumberRuleRepo
+}
+
+// NewNumberRuleService 创建号码分类规则服务实例
+func NewNumberRuleService(logger *zap.Logger, db *gorm.DB) *NumberRuleService {
+	return &NumberRuleService{
+		logger: logger,
+		repo:   repo.NewNumberRuleRepo(db),
+	}
+}
+
+// GetAll 获取所有规则
+func (s *NumberRuleService) GetAll(ctx context.Context) ([]models.NumberRule, error) {
+	return s.repo.FindAll(ctx)
+}
+
+// GetById 根据ID获取规则
+func (s *NumberRuleService) GetById(ctx context.Context, id string) (*models.NumberRule, error) {
+	rule, err := s.repo.FindById(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return &rule, nil
+}
+
+// Create 创建规则
+func (s *NumberRuleService) Create(ctx context.Context, rule *models.NumberRule) error {
+	now := time.Now().UnixMilli()
+	rule.ID = uuid.NewString()
+	rule.CreatedAt = now
+	rule.UpdatedAt = now
+	return s.repo.Create(ctx, rule)
+}
+
+// Update 更新规则
+func (s *NumberRuleService) Update(ctx context.Context, rule *models.NumberRule) error {
+	existing, err := s.GetById(ctx, rule.ID)
+	if err != nil {
+		return err
+	}
+	existing.Pattern = rule.Pattern
+	existing.Category = rule.Category
+	existing.Enabled = rule.Enabled
+	existing.Mute = rule.Mute
+	existing.Channels = rule.Channels
+	existing.Escalation = rule.Escalation
+	existing.EscalateAfter = rule.EscalateAfter
+	existing.Priority = rule.Priority
+	existing.UpdatedAt = time.Now().UnixMilli()
+	if err := s.repo.Save(ctx, existing); err != nil {
+		return err
+	}
+	*rule = *existing
+	return nil
+}
+
+// Delete 删除规则
+func (s *NumberRuleService) Delete(ctx context.Context, id string) error {
+	return s.repo.DeleteById(ctx, id)
+}
+
+// Match 查找与号码匹配的启用规则，多条匹配时取模式最长（最具体）的一条，未匹配返回 nil
+func (s *NumberRuleService) Match(ctx context.Context, number string) *models.NumberRule {
+	rules, err := s.repo.FindAllEnabled(ctx)
+	if err != nil {
+		s.logger.Error("获取号码分类规则失败", zap.Error(err))
+		return nil
+	}
+	return matchNumberRule(rules, number)
+}
+
+// ValidateNumberRule 校验规则字段
+func ValidateNumberRule(rule *models.NumberRule) error {
+	rule.Pattern = strings.ReplaceAll(strings.TrimSpace(rule.Pattern), " ", "")
+	rule.Category = strings.TrimSpace(rule.Category)
+	if rule.Pattern == "" {
+		return fmt.Errorf("号码模式不能为空")
+	}
+	if rule.Category == "" {
+		return fmt.Errorf("分类名称不能为空")
+	}
+	if _, err := path.Match(rule.Pattern, ""); err != nil {
+		return fmt.Errorf("号码模式格式错误: %s", rule.Pattern)
+	}
+	if rule.EscalateAfter < 0 {
+		return fmt.Errorf("升级等待时间不能小于 0")
+	}
+	if !rule.Priority.Valid() {
+		return fmt.Errorf("通知优先级无效: %s", rule.Priority)
+	}
+	return nil
+}
+
+// matchNumberRule 号码同时以原始格式和去掉 +86 的格式匹配
+func matchNumberRule(rules []models.NumberRule, number string) *models.NumberRule {
+	raw := strings.NewReplacer(" ", "", "-", "").Replace(number)
+	normalized := normalizePhone(number)
+
+	var best *models.NumberRule
+	for i := range rules {
+		rule := &rules[i]
+		ok, _ := path.Match(rule.Pattern, raw)
+		if !ok {
+			ok, _ = path.Match(rule.Pattern, normalized)
+		}
+		if ok && (best == nil || len(rule.Pattern) > len(best.Pattern)) {
+			best = rule
+		}
+	}
+	return best
+}
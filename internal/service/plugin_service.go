@@ -0,0 +1,339 @@
+package service
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dushixiang/uart_sms_forwarder/internal/models"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+const (
+	// PropertyIDMessagePlugins 外部插件配置
+	PropertyIDMessagePlugins = "message_plugins"
+	// DefaultPluginTimeout 插件默认处理超时（毫秒）
+	DefaultPluginTimeout = 3000
+	// pluginMaxLine 插件单行输出最大长度
+	pluginMaxLine = 1024 * 1024
+)
+
+// 插件处理结果
+const (
+	PluginActionForward = "forward"
+	PluginActionDrop    = "drop"
+	PluginActionModify  = "modify"
+)
+
+// pluginRequest 发送给插件的请求
+type pluginRequest struct {
+	ID string `json:"id"`
+	ScriptMessage
+}
+
+// pluginVerdict 插件返回的处理结果，modify 时未返回的字段保持不变
+type pluginVerdict struct {
+	ID       string    `json:"id"`
+	Action   string    `json:"action"`
+	From     *string   `json:"from"`
+	Content  *string   `json:"content"`
+	Tags     *[]string `json:"tags"`
+	Channels *[]string `json:"channels"`
+	Notify   *bool     `json:"notify"`
+}
+
+// PluginService 外部插件服务。
+// 收到的短信以 JSON 行的形式写入常驻插件进程的标准输入，读取标准输出返回的处理结果，
+// 任何语言都可以编写插件，无需重新编译本程序。插件出错或超时时放行原短信。
+type PluginService struct {
+	logger          *zap.Logger
+	propertyService *PropertyService
+
+	mu        sync.Mutex
+	processes map[string]*pluginProcess // 插件名 -> 进程
+}
+
+// NewPluginService 创建外部插件服务实例
+func NewPluginService(logger *zap.Logger, propertyService *PropertyService) *PluginService {
+	return &PluginService{
+		logger:          logger.Named("plugin"),
+		propertyService: propertyService,
+		processes:       make(map[string]*pluginProcess),
+	}
+}
+
+// GetConfigs 获取插件配置
+func (s *PluginService) GetConfigs(ctx context.Context) ([]models.MessagePluginConfig, error) {
+	var configs []models.MessagePluginConfig
+	if err := s.propertyService.GetValue(ctx, PropertyIDMessagePlugins, &configs); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return configs, nil
+}
+
+// Process 依次交给启用的插件处理，任一插件丢弃后不再继续
+func (s *PluginService) Process(ctx context.Context, msg ScriptMessage) ScriptMessage {
+	configs, err := s.GetConfigs(ctx)
+	if err != nil {
+		s.logger.Error("获取插件配置失败", zap.Error(err))
+		return msg
+	}
+
+	s.cleanup(configs)
+
+	for _, config := range configs {
+		if !config.Enabled || config.Command == "" {
+			continue
+		}
+		result, err := s.call(ctx, config, msg)
+		if err != nil {
+			s.logger.Error("插件处理失败，放行原短信", zap.String("plugin", config.Name), zap.Error(err))
+			continue
+		}
+		msg = result
+		if msg.Drop {
+			s.logger.Info("短信已被插件丢弃", zap.String("plugin", config.Name), zap.String("from", msg.From))
+			break
+		}
+	}
+	return msg
+}
+
+// Stop 停止所有插件进程
+func (s *PluginService) Stop() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for name, p := range s.processes {
+		p.stop()
+		delete(s.processes, name)
+	}
+}
+
+// cleanup 停止已删除、已禁用或配置变化的插件进程
+func (s *PluginService) cleanup(configs []models.MessagePluginConfig) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for name, p := range s.processes {
+		idx := slices.IndexFunc(configs, func(c models.MessagePluginConfig) bool {
+			return c.Name == name && c.Enabled
+		})
+		if idx < 0 || !p.sameConfig(configs[idx]) {
+			s.logger.Info("停止插件进程", zap.String("plugin", name))
+			p.stop()
+			delete(s.processes, name)
+		}
+	}
+}
+
+// call 调用插件处理一条短信，超时或进程退出时停止进程，下次调用时重新启动
+func (s *PluginService) call(ctx context.Context, config models.MessagePluginConfig, msg ScriptMessage) (ScriptMessage, error) {
+	s.mu.Lock()
+	p, ok := s.processes[config.Name]
+	if !ok || p.exited() {
+		var err error
+		p, err = startPlugin(s.logger, config)
+		if err != nil {
+			s.mu.Unlock()
+			return msg, err
+		}
+		s.processes[config.Name] = p
+		s.logger.Info("插件进程已启动", zap.String("plugin", config.Name), zap.String("command", config.Command))
+	}
+	s.mu.Unlock()
+
+	timeout := config.Timeout
+	if timeout <= 0 {
+		timeout = DefaultPluginTimeout
+	}
+	callCtx, cancel := context.WithTimeout(ctx, time.Duration(timeout)*time.Millisecond)
+	defer cancel()
+
+	verdict, err := p.call(callCtx, pluginRequest{ID: uuid.NewString(), ScriptMessage: msg})
+	if err != nil {
+		// 超时后进程的输出可能与请求错位，直接重启
+		s.mu.Lock()
+		if s.processes[config.Name] == p {
+			delete(s.processes, config.Name)
+		}
+		s.mu.Unlock()
+		p.stop()
+		return msg, err
+	}
+	return applyVerdict(msg, verdict)
+}
+
+// applyVerdict 应用插件处理结果
+func applyVerdict(msg ScriptMessage, verdict *pluginVerdict) (ScriptMessage, error) {
+	switch strings.ToLower(verdict.Action) {
+	case "", PluginActionForward:
+		return msg, nil
+	case PluginActionDrop:
+		msg.Drop = true
+		return msg, nil
+	case PluginActionModify:
+		if verdict.From != nil {
+			msg.From = *verdict.From
+		}
+		if verdict.Content != nil {
+			msg.Content = *verdict.Content
+		}
+		if verdict.Tags != nil {
+			msg.Tags = *verdict.Tags
+		}
+		if verdict.Channels != nil {
+			msg.Channels = *verdict.Channels
+		}
+		if verdict.Notify != nil {
+			msg.Notify = *verdict.Notify
+		}
+		return msg, nil
+	default:
+		return msg, fmt.Errorf("未知的插件处理结果: %s", verdict.Action)
+	}
+}
+
+// pluginProcess 常驻插件进程
+type pluginProcess struct {
+	config models.MessagePluginConfig
+	cmd    *exec.Cmd
+	stdin  io.WriteCloser
+	lines  chan []byte
+	done   chan struct{}
+
+	mu sync.Mutex // 同一插件同一时间只处理一条短信
+}
+
+// startPlugin 启动插件进程
+func startPlugin(logger *zap.Logger, config models.MessagePluginConfig) (*pluginProcess, error) {
+	cmd := exec.Command(config.Command, config.Args...)
+	cmd.Dir = config.WorkDir
+
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	stderr, err := cmd.StderrPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("启动插件失败: %w", err)
+	}
+
+	p := &pluginProcess{
+		config: config,
+		cmd:    cmd,
+		stdin:  stdin,
+		lines:  make(chan []byte, 16),
+		done:   make(chan struct{}),
+	}
+
+	pluginLogger := logger.With(zap.String("plugin", config.Name))
+	go func() {
+		scanner := bufio.NewScanner(stderr)
+		for scanner.Scan() {
+			pluginLogger.Info("插件输出", zap.String("stderr", scanner.Text()))
+		}
+	}()
+	go func() {
+		defer close(p.done)
+		scanner := bufio.NewScanner(stdout)
+		scanner.Buffer(make([]byte, 64*1024), pluginMaxLine)
+		for scanner.Scan() {
+			line := slices.Clone(scanner.Bytes())
+			select {
+			case p.lines <- line:
+			default:
+				// 无人等待的多余输出直接丢弃
+			}
+		}
+		err := cmd.Wait()
+		pluginLogger.Info("插件进程已退出", zap.Error(err))
+	}()
+	return p, nil
+}
+
+// sameConfig 判断配置是否变化
+func (p *pluginProcess) sameConfig(config models.MessagePluginConfig) bool {
+	return p.config.Command == config.Command &&
+		slices.Equal(p.config.Args, config.Args) &&
+		p.config.WorkDir == config.WorkDir
+}
+
+// exited 进程是否已退出
+func (p *pluginProcess) exited() bool {
+	select {
+	case <-p.done:
+		return true
+	default:
+		return false
+	}
+}
+
+// call 写入请求并等待 id 匹配的响应
+func (p *pluginProcess) call(ctx context.Context, req pluginRequest) (*pluginVerdict, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	// 丢弃之前残留的输出
+	for len(p.lines) > 0 {
+		<-p.lines
+	}
+
+	data, err := json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := p.stdin.Write(append(data, '\n')); err != nil {
+		return nil, fmt.Errorf("写入插件失败: %w", err)
+	}
+
+	for {
+		select {
+		case line := <-p.lines:
+			var verdict pluginVerdict
+			if err := json.Unmarshal(line, &verdict); err != nil {
+				return nil, fmt.Errorf("插件返回格式错误: %w, output: %s", err, line)
+			}
+			if verdict.ID != req.ID {
+				// 上一次超时请求的迟到响应
+				continue
+			}
+			return &verdict, nil
+		case <-p.done:
+			return nil, fmt.Errorf("插件进程已退出")
+		case <-ctx.Done():
+			return nil, fmt.Errorf("插件处理超时: %w", ctx.Err())
+		}
+	}
+}
+
+// stop 关闭标准输入并结束进程
+func (p *pluginProcess) stop() {
+	_ = p.stdin.Close()
+	select {
+	case <-p.done:
+		return
+	case <-time.After(time.Second):
+	}
+	if p.cmd.Process != nil {
+		_ = p.cmd.Process.Kill()
+	}
+}
@@ -0,0 +1,92 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/dushixiang/uart_sms_forwarder/internal/models"
+	"gorm.io/gorm"
+)
+
+// PropertyIDPrivacy 通知隐私配置
+const PropertyIDPrivacy = "privacy"
+
+// DefaultPrivacyConfig 默认通知隐私配置（未启用，本地渠道不做处理）
+var DefaultPrivacyConfig = models.PrivacyConfig{
+	Enabled:          false,
+	MaskNumbers:      true,
+	MaxContentLength: 0,
+	LocalChannels:    []string{"syslog", "redis", "amqp", "exec", "file"},
+}
+
+// mobileInContent 内容中的手机号，前后不能紧挨数字，避免误伤验证码、金额等长数字
+var mobileInContent = regexp.MustCompile(`(^|\D)(1[3-9]\d)(\d{4})(\d{4})(\D|$)`)
+
+// getPrivacyConfig 获取通知隐私配置，未配置时返回禁用状态
+func (s *SerialService) getPrivacyConfig(ctx context.Context) (models.PrivacyConfig, error) {
+	var config models.PrivacyConfig
+	if err := s.propertyService.GetValue(ctx, PropertyIDPrivacy, &config); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return config, nil
+		}
+		return config, err
+	}
+	return config, nil
+}
+
+// privacyApplies 判断渠道是否需要隐私处理
+func privacyApplies(config models.PrivacyConfig, msg NotificationMessage, channelType string) bool {
+	return config.Enabled && !msg.System && !slices.Contains(config.LocalChannels, channelType)
+}
+
+// applyPrivacy 返回脱敏、截断后的通知消息
+func applyPrivacy(config models.PrivacyConfig, msg NotificationMessage) NotificationMessage {
+	if config.MaskNumbers {
+		msg.From = maskPhone(msg.From)
+		msg.Content = maskPhonesInText(msg.Content)
+		if len(msg.Fields) > 0 {
+			fields := make(map[string]string, len(msg.Fields))
+			for k, v := range msg.Fields {
+				fields[k] = maskPhonesInText(v)
+			}
+			msg.Fields = fields
+		}
+	}
+	if config.MaxContentLength > 0 {
+		if runes := []rune(msg.Content); len(runes) > config.MaxContentLength {
+			msg.Content = string(runes[:config.MaxContentLength]) + "…"
+		}
+	}
+	return msg
+}
+
+// maskPhone 号码脱敏：保留前 3 位和后 4 位（国际前缀如 +86 不计入），
+// 少于 7 位的号码（如 10086、95588）为服务号码，不做处理
+func maskPhone(number string) string {
+	prefix := ""
+	digits := number
+	if strings.HasPrefix(digits, "+86") {
+		prefix, digits = "+86", digits[3:]
+	} else if strings.HasPrefix(digits, "+") {
+		prefix, digits = "+", digits[1:]
+	}
+	if len(digits) < 7 || strings.IndexFunc(digits, func(r rune) bool { return r < '0' || r > '9' }) >= 0 {
+		return number
+	}
+	return prefix + digits[:3] + strings.Repeat("*", len(digits)-7) + digits[len(digits)-4:]
+}
+
+// maskPhonesInText 将文本中的手机号脱敏为 138****8000
+func maskPhonesInText(text string) string {
+	// 匹配的前后界字符会被消耗，循环替换以处理相邻的号码
+	for {
+		masked := mobileInContent.ReplaceAllString(text, "${1}${2}****${4}${5}")
+		if masked == text {
+			return text
+		}
+		text = masked
+	}
+}
@@ -3,11 +3,13 @@ package service
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"time"
 
 	"github.com/dushixiang/uart_sms_forwarder/internal/models"
 	"github.com/dushixiang/uart_sms_forwarder/internal/repo"
+	"github.com/dushixiang/uart_sms_forwarder/internal/util"
 	"github.com/go-orz/cache"
 	"go.uber.org/zap"
 	"gorm.io/gorm"
@@ -16,6 +18,10 @@ import (
 const (
 	// PropertyIDNotificationChannels 通知渠道配置的固定 ID
 	PropertyIDNotificationChannels = "notification_channels"
+	// PropertyIDSerialBaudRates 各串口探测成功的波特率
+	PropertyIDSerialBaudRates = "serial_baud_rates"
+	// PropertyIDStorageAlert 存储空间告警配置
+	PropertyIDStorageAlert = "storage_alert"
 )
 
 type PropertyService struct {
@@ -23,6 +29,8 @@ type PropertyService struct {
 	logger *zap.Logger
 	// 内存缓存，使用 go-orz/cache，永不过期
 	cache cache.Cache[string, *models.Property]
+	// 敏感字段加密，为空时明文存储
+	secrets *util.SecretBox
 }
 
 func NewPropertyService(logger *zap.Logger, db *gorm.DB) *PropertyService {
@@ -33,7 +41,7 @@ func NewPropertyService(logger *zap.Logger, db *gorm.DB) *PropertyService {
 	}
 }
 
-// Get 获取属性（返回原始 JSON 字符串）
+// Get 获取属性（返回解密后的原始 JSON 字符串）
 func (s *PropertyService) Get(ctx context.Context, id string) (*models.Property, error) {
 	// 先尝试从缓存读取
 	if property, ok := s.cache.Get(id); ok {
@@ -45,6 +53,9 @@ func (s *PropertyService) Get(ctx context.Context, id string) (*models.Property,
 	if err != nil {
 		return nil, err
 	}
+	if property.Value, err = s.openValue(property.Value); err != nil {
+		return nil, fmt.Errorf("解密配置 %s 失败: %w", id, err)
+	}
 
 	// 更新缓存
 	s.cache.Set(id, &property, time.Hour)
@@ -73,11 +84,16 @@ func (s *PropertyService) Set(ctx context.Context, id string, name string, value
 	if err != nil {
 		return err
 	}
+	// 敏感字段加密后存储
+	sealed, err := s.sealValue(string(jsonValue))
+	if err != nil {
+		return fmt.Errorf("加密配置失败: %w", err)
+	}
 
 	property := &models.Property{
 		ID:        id,
 		Name:      name,
-		Value:     string(jsonValue),
+		Value:     sealed,
 		CreatedAt: time.Now().UnixMilli(),
 		UpdatedAt: time.Now().UnixMilli(),
 	}
@@ -102,6 +118,18 @@ func (s *PropertyService) GetNotificationChannelConfigs(ctx context.Context) ([]
 	return allChannels, nil
 }
 
+// GetStorageAlertConfig 获取存储空间告警配置，未配置时返回默认值
+func (s *PropertyService) GetStorageAlertConfig(ctx context.Context) (models.StorageAlertConfig, error) {
+	config := DefaultStorageAlertConfig
+	if err := s.GetValue(ctx, PropertyIDStorageAlert, &config); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return DefaultStorageAlertConfig, nil
+		}
+		return config, fmt.Errorf("获取存储空间告警配置失败: %w", err)
+	}
+	return config, nil
+}
+
 // defaultPropertyConfig 默认配置项定义
 type defaultPropertyConfig struct {
 	ID    string
@@ -118,6 +146,119 @@ func (s *PropertyService) InitializeDefaultConfigs(ctx context.Context) error {
 			Name:  "通知渠道配置",
 			Value: []models.NotificationChannelConfig{},
 		},
+		{
+			ID:    PropertyIDStorageAlert,
+			Name:  "存储空间告警配置",
+			Value: DefaultStorageAlertConfig,
+		},
+		{
+			ID:    PropertyIDMessageScript,
+			Name:  "短信处理脚本",
+			Value: DefaultMessageScriptConfig,
+		},
+		{
+			ID:    PropertyIDMessagePlugins,
+			Name:  "外部插件配置",
+			Value: []models.MessagePluginConfig{},
+		},
+		{
+			ID:    PropertyIDExtractionRules,
+			Name:  "字段提取规则",
+			Value: DefaultExtractionRules,
+		},
+		{
+			ID:    PropertyIDSMSCommand,
+			Name:  "短信指令配置",
+			Value: models.SMSCommandConfig{AllowedNumbers: []string{}},
+		},
+		{
+			ID:    PropertyIDSpamFilter,
+			Name:  "垃圾短信识别配置",
+			Value: DefaultSpamFilterConfig,
+		},
+		{
+			ID:    PropertyIDPrivacy,
+			Name:  "通知隐私配置",
+			Value: DefaultPrivacyConfig,
+		},
+		{
+			ID:    PropertyIDBackup,
+			Name:  "远程备份配置",
+			Value: DefaultBackupConfig,
+		},
+		{
+			ID:    PropertyIDSmsForwarderInbound,
+			Name:  "SmsForwarder 推送接口配置",
+			Value: models.InboundWebhookConfig{},
+		},
+		{
+			ID:    PropertyIDOutgoingPolicy,
+			Name:  "发送短信安全策略",
+			Value: models.OutgoingPolicyConfig{},
+		},
+		{
+			ID:    PropertyIDSendQuota,
+			Name:  "发送额度",
+			Value: models.SendQuotaConfig{},
+		},
+		{
+			ID:    PropertyIDChannelGroups,
+			Name:  "通知渠道组",
+			Value: []models.ChannelGroup{},
+		},
+		{
+			ID:    PropertyIDTranslation,
+			Name:  "短信翻译",
+			Value: models.TranslationConfig{},
+		},
+		{
+			ID:    PropertyIDStandby,
+			Name:  "夜间待机",
+			Value: DefaultStandbyConfig,
+		},
+		{
+			ID:    PropertyIDIngestAPI,
+			Name:  "通用消息接入接口配置",
+			Value: models.IngestAPIConfig{},
+		},
+		{
+			ID:    PropertyIDMetrics,
+			Name:  "Prometheus 指标接口配置",
+			Value: models.MetricsConfig{},
+		},
+		{
+			ID:    PropertyIDHealthcheck,
+			Name:  "外部监控心跳配置",
+			Value: models.HealthcheckConfig{IntervalSeconds: DefaultHealthcheckInterval},
+		},
+		{
+			ID:    PropertyIDSMSEagleAPI,
+			Name:  "SMSEagle 兼容接口配置",
+			Value: models.SMSEagleAPIConfig{},
+		},
+		{
+			ID:    PropertyIDCallPolicy,
+			Name:  "来电处理配置",
+			Value: models.CallPolicyConfig{DefaultAction: models.CallActionNotify},
+		},
+		{
+			ID:   PropertyIDRegistrationRecovery,
+			Name: "网络注册自动恢复配置",
+			Value: models.RegistrationRecoveryConfig{
+				UnregisteredMinutes: DefaultRegistrationUnregisteredMinutes,
+				StepMinutes:         DefaultRegistrationStepMinutes,
+			},
+		},
+		{
+			ID:    PropertyIDSendApproval,
+			Name:  "发送审批配置",
+			Value: models.SendApprovalConfig{},
+		},
+		{
+			ID:    PropertyIDUserPasswordHashes,
+			Name:  "用户密码哈希",
+			Value: map[string]storedPasswordHash{},
+		},
 	}
 
 	// 遍历并初始化每个配置
@@ -0,0 +1,142 @@
+package service
+
+import (
+	"context"
+	"crypto/ecdh"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dushixiang/uart_sms_forwarder/internal/models"
+	"github.com/dushixiang/uart_sms_forwarder/internal/repo"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// PropertyIDWebPushVAPID Web Push 服务器密钥对
+const PropertyIDWebPushVAPID = "web_push_vapid"
+
+// PushService 推送设备管理服务，伴侣 App 启动时注册设备 token，浏览器订阅 Web Push
+type PushService struct {
+	logger          *zap.Logger
+	repo            *repo.PushDeviceRepo
+	propertyService *PropertyService
+
+	// 防止并发生成多个 VAPID 密钥对
+	vapidMu sync.Mutex
+}
+
+// NewPushService 创建推送设备管理服务实例
+func NewPushService(logger *zap.Logger, pushDeviceRepo *repo.PushDeviceRepo, propertyService *PropertyService) *PushService {
+	return &PushService{
+		logger:          logger,
+		repo:            pushDeviceRepo,
+		propertyService: propertyService,
+	}
+}
+
+// RegisterDevice 注册推送设备，同一 token 重复注册时更新设备信息
+func (s *PushService) RegisterDevice(ctx context.Context, device *models.PushDevice) (*models.PushDevice, error) {
+	device.Token = strings.TrimSpace(device.Token)
+	if device.Token == "" {
+		return nil, fmt.Errorf("设备 token 不能为空")
+	}
+	if device.Kind == "" {
+		device.Kind = PushKindFCM
+	}
+	switch device.Kind {
+	case PushKindFCM:
+	case PushKindWebPush:
+		if err := validateWebPushSubscription(device); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("不支持的推送方式: %s", device.Kind)
+	}
+
+	now := time.Now().UnixMilli()
+	device.ID = uuid.NewString()
+	device.CreatedAt = now
+	device.UpdatedAt = now
+	if err := s.repo.Upsert(ctx, device); err != nil {
+		return nil, err
+	}
+
+	s.logger.Info("推送设备已注册",
+		zap.String("kind", device.Kind),
+		zap.String("platform", device.Platform),
+		zap.String("name", device.Name))
+	return s.repo.FindByToken(ctx, device.Token)
+}
+
+// ListDevices 获取所有推送设备
+func (s *PushService) ListDevices(ctx context.Context) ([]models.PushDevice, error) {
+	return s.repo.FindAll(ctx)
+}
+
+// DeleteDevice 删除推送设备
+func (s *PushService) DeleteDevice(ctx context.Context, id string) error {
+	return s.repo.DeleteById(ctx, id)
+}
+
+// DeleteDeviceByToken 按 token 删除推送设备，浏览器取消订阅时使用
+func (s *PushService) DeleteDeviceByToken(ctx context.Context, token string) error {
+	return s.repo.DeleteByToken(ctx, token)
+}
+
+// VAPIDKeys 获取 Web Push 服务器密钥对，不存在时生成并保存。
+// 密钥对变更后浏览器需要重新订阅，因此只生成一次。
+func (s *PushService) VAPIDKeys(ctx context.Context) (models.VAPIDKeys, error) {
+	s.vapidMu.Lock()
+	defer s.vapidMu.Unlock()
+
+	var keys models.VAPIDKeys
+	err := s.propertyService.GetValue(ctx, PropertyIDWebPushVAPID, &keys)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return keys, err
+	}
+	if keys.PublicKey != "" && keys.PrivateKey != "" {
+		return keys, nil
+	}
+
+	key, err := ecdh.P256().GenerateKey(rand.Reader)
+	if err != nil {
+		return keys, err
+	}
+	keys = models.VAPIDKeys{
+		PublicKey:  base64.RawURLEncoding.EncodeToString(key.PublicKey().Bytes()),
+		PrivateKey: base64.RawURLEncoding.EncodeToString(key.Bytes()),
+	}
+	if err := s.propertyService.Set(ctx, PropertyIDWebPushVAPID, "Web Push 密钥", keys); err != nil {
+		return keys, fmt.Errorf("保存 VAPID 密钥失败: %w", err)
+	}
+	s.logger.Info("已生成 Web Push VAPID 密钥")
+	return keys, nil
+}
+
+// validateWebPushSubscription 校验浏览器订阅的 endpoint 和加密密钥
+func validateWebPushSubscription(device *models.PushDevice) error {
+	if !strings.HasPrefix(device.Token, "https://") {
+		return fmt.Errorf("订阅地址必须是 https 地址")
+	}
+	p256dh, err := decodeBase64URL(device.P256dh)
+	if err != nil {
+		return fmt.Errorf("订阅公钥格式错误")
+	}
+	if _, err := ecdh.P256().NewPublicKey(p256dh); err != nil {
+		return fmt.Errorf("订阅公钥格式错误")
+	}
+	auth, err := decodeBase64URL(device.Auth)
+	if err != nil || len(auth) != 16 {
+		return fmt.Errorf("订阅认证密钥格式错误")
+	}
+	if device.Platform == "" {
+		device.Platform = "browser"
+	}
+	return nil
+}
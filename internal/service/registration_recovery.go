@@ -0,0 +1,452 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dushixiang/uart_sms_forwarder/internal/models"
+	"github.com/dushixiang/uart_sms_forwarder/internal/repo"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// PropertyIDRegistrationRecovery 网络注册自动恢复配置
+const PropertyIDRegistrationRecovery = "registration_recovery"
+
+const (
+	// DefaultRegistrationUnregisteredMinutes 默认未注册持续多少分钟后开始恢复
+	DefaultRegistrationUnregisteredMinutes = 10
+	// DefaultRegistrationStepMinutes 默认每一步恢复操作后等待注册的分钟数
+	DefaultRegistrationStepMinutes = 5
+	// registrationRecoveryTick 检查设备注册状态的间隔，与设备状态缓存的刷新间隔相近
+	registrationRecoveryTick = 30 * time.Second
+	// flymodeToggleDelay 切换飞行模式时关闭蜂窝网络的时长
+	flymodeToggleDelay = 5 * time.Second
+)
+
+// 网络注册恢复操作
+const (
+	RecoveryActionResetStack = "reset_stack" // 重启协议栈
+	RecoveryActionFlymode    = "flymode"     // 开启再关闭飞行模式
+	RecoveryActionReboot     = "reboot_mcu"  // 重启模块
+)
+
+// registrationRecoveryStep 一步恢复操作，影响从小到大依次执行，通知优先级逐步升级
+type registrationRecoveryStep struct {
+	action   string
+	label    string
+	priority models.NotificationPriority
+}
+
+var registrationRecoverySteps = []registrationRecoveryStep{
+	{action: RecoveryActionResetStack, label: "重启协议栈", priority: models.NotificationPriorityNormal},
+	{action: RecoveryActionFlymode, label: "切换飞行模式", priority: models.NotificationPriorityHigh},
+	{action: RecoveryActionReboot, label: "重启模块", priority: models.NotificationPriorityHigh},
+}
+
+// recoveryActionLabel 恢复操作的中文名称
+func recoveryActionLabel(action string) string {
+	for _, step := range registrationRecoverySteps {
+		if step.action == action {
+			return step.label
+		}
+	}
+	return action
+}
+
+// registrationState 设备未注册网络期间的恢复进度
+type registrationState struct {
+	unregisteredSince time.Time
+	step              int       // 已执行的恢复操作数
+	lastStepAt        time.Time // 上一步恢复操作的执行时间，执行失败时为零值，下一次检查立即执行下一步
+	record            *models.RegistrationRecovery
+}
+
+// RegistrationRecoveryDeviceStatus 设备当前的恢复进度
+type RegistrationRecoveryDeviceStatus struct {
+	DeviceID          string `json:"deviceId"`
+	UnregisteredSince int64  `json:"unregisteredSince"`    // 开始未注册的时间（时间戳毫秒）
+	Step              int    `json:"step"`                 // 已执行的恢复操作数
+	NextAction        string `json:"nextAction,omitempty"` // 下一步恢复操作，所有操作都已执行时为空
+	RecoveryID        string `json:"recoveryId,omitempty"` // 恢复记录 ID，尚未开始恢复时为空
+	LastStepAt        int64  `json:"lastStepAt,omitempty"` // 上一步恢复操作的执行时间（时间戳毫秒）
+}
+
+// RegistrationRecoveryStatus 网络注册自动恢复状态
+type RegistrationRecoveryStatus struct {
+	models.RegistrationRecoveryConfig
+	Devices []RegistrationRecoveryDeviceStatus `json:"devices"` // 当前未注册网络的设备
+}
+
+// RegistrationRecoveryPage 恢复记录键集分页结果
+type RegistrationRecoveryPage struct {
+	Items      []models.RegistrationRecovery `json:"items"`
+	NextCursor string                        `json:"nextCursor"` // 下一页（更早的记录）游标，没有更多时为空
+	HasMore    bool                          `json:"hasMore"`
+}
+
+// RegistrationRecoveryService 网络注册自动恢复。
+// 设备状态显示 SIM 卡就绪但未注册网络超过配置的时间后，依次执行 重启协议栈 → 切换飞行模式 → 重启模块，
+// 每一步后等待一段时间，仍未注册时执行下一步并发送优先级更高的通知。
+// 每次恢复保存一条记录，包含执行过的操作和最终生效的操作，便于判断哪种方式对该模组和 SIM 卡有效。
+// 飞行模式（含夜间待机）期间不检查。
+type RegistrationRecoveryService struct {
+	logger          *zap.Logger
+	propertyService *PropertyService
+	serialService   *SerialService
+	repo            *repo.RegistrationRecoveryRepo
+
+	mu       sync.Mutex
+	states   map[string]*registrationState // 按设备 ID
+	stopChan chan struct{}
+}
+
+// NewRegistrationRecoveryService 创建网络注册自动恢复实例
+func NewRegistrationRecoveryService(logger *zap.Logger, db *gorm.DB, propertyService *PropertyService, serialService *SerialService) *RegistrationRecoveryService {
+	return &RegistrationRecoveryService{
+		logger:          logger,
+		propertyService: propertyService,
+		serialService:   serialService,
+		repo:            repo.NewRegistrationRecoveryRepo(db),
+		states:          make(map[string]*registrationState),
+		stopChan:        make(chan struct{}),
+	}
+}
+
+// Start 启动定期检查，服务重启前未结束的恢复记录标记为已停止
+func (s *RegistrationRecoveryService) Start() {
+	if err := s.repo.CancelRunning(context.Background(), time.Now().UnixMilli()); err != nil {
+		s.logger.Error("更新未结束的网络注册恢复记录失败", zap.Error(err))
+	}
+	go func() {
+		ticker := time.NewTicker(registrationRecoveryTick)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				s.Check(context.Background())
+			case <-s.stopChan:
+				return
+			}
+		}
+	}()
+}
+
+// Stop 停止检查
+func (s *RegistrationRecoveryService) Stop() {
+	close(s.stopChan)
+}
+
+// Check 检查所有设备的注册状态，按需执行下一步恢复操作
+func (s *RegistrationRecoveryService) Check(ctx context.Context) {
+	config := s.getConfig(ctx)
+	for _, deviceID := range s.serialService.DeviceIDs() {
+		s.checkDevice(ctx, config, deviceID)
+	}
+}
+
+// checkDevice 检查单个设备的注册状态
+func (s *RegistrationRecoveryService) checkDevice(ctx context.Context, config models.RegistrationRecoveryConfig, deviceID string) {
+	if !config.Enabled {
+		s.cancel(ctx, deviceID)
+		return
+	}
+	// 重启模块期间设备可能断开，等待重新连接后继续
+	if !s.serialService.connected(deviceID) {
+		return
+	}
+	status, err := s.serialService.GetStatus(deviceID)
+	if err != nil || status.Stale || status.UpdatedAt == 0 {
+		return
+	}
+
+	switch {
+	case status.Mobile.IsRegistered:
+		s.recovered(ctx, config, deviceID)
+	case status.Flymode:
+		s.cancel(ctx, deviceID)
+	case !status.Mobile.SimReady:
+		// 重启协议栈或模块后 SIM 卡需要一段时间才能就绪，等待期内不视为 SIM 卡被移除
+		if !s.waitingForStep(config, deviceID) {
+			s.cancel(ctx, deviceID)
+		}
+	default:
+		s.unregistered(ctx, config, deviceID, status)
+	}
+}
+
+// waitingForStep 设备是否处于上一步恢复操作后的等待期
+func (s *RegistrationRecoveryService) waitingForStep(config models.RegistrationRecoveryConfig, deviceID string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	state := s.states[deviceID]
+	return state != nil && state.step > 0 && time.Since(state.lastStepAt) < stepInterval(config)
+}
+
+// unregistered SIM 卡就绪但未注册网络，到达等待时间后执行下一步恢复操作
+func (s *RegistrationRecoveryService) unregistered(ctx context.Context, config models.RegistrationRecoveryConfig, deviceID string, status *StatusData) {
+	now := time.Now()
+
+	s.mu.Lock()
+	state := s.states[deviceID]
+	if state == nil {
+		state = &registrationState{unregisteredSince: now}
+		s.states[deviceID] = state
+		s.logger.Warn("SIM 卡已就绪但未注册网络", zap.String("device", deviceID), zap.String("operator", status.Mobile.Operator))
+	}
+	wait, since := unregisteredThreshold(config), state.unregisteredSince
+	if state.step > 0 {
+		wait, since = stepInterval(config), state.lastStepAt
+	}
+	if now.Sub(since) < wait {
+		s.mu.Unlock()
+		return
+	}
+	if state.step >= len(registrationRecoverySteps) {
+		record := state.record
+		s.mu.Unlock()
+		s.exhausted(ctx, config, deviceID, record, now)
+		return
+	}
+	step := registrationRecoverySteps[state.step]
+	state.step++
+	state.lastStepAt = now
+	if state.record == nil {
+		state.record = &models.RegistrationRecovery{
+			ID:                uuid.NewString(),
+			DeviceID:          deviceID,
+			Iccid:             status.Mobile.Iccid,
+			Operator:          status.Mobile.Operator,
+			UnregisteredSince: state.unregisteredSince.UnixMilli(),
+			Result:            models.RegistrationRecoveryRunning,
+		}
+		if err := s.repo.Create(ctx, state.record); err != nil {
+			s.logger.Error("保存网络注册恢复记录失败", zap.String("device", deviceID), zap.Error(err))
+		}
+	}
+	record, number := state.record, state.step
+	s.mu.Unlock()
+
+	s.logger.Warn("执行网络注册恢复操作",
+		zap.String("device", deviceID),
+		zap.String("action", step.action),
+		zap.Int("step", number))
+	err := s.runStep(deviceID, step.action)
+
+	steps := []string{step.action}
+	if record.Steps != "" {
+		steps = append(strings.Split(record.Steps, ","), step.action)
+	}
+	record.Steps = strings.Join(steps, ",")
+	result := "已执行"
+	if err != nil {
+		s.logger.Error("网络注册恢复操作失败", zap.String("device", deviceID), zap.String("action", step.action), zap.Error(err))
+		record.LastError = fmt.Sprintf("%s: %v", step.label, err)
+		result = "执行失败: " + err.Error()
+		// 不支持或执行失败时不等待，下一次检查直接执行下一步
+		s.mu.Lock()
+		state.lastStepAt = time.Time{}
+		s.mu.Unlock()
+	}
+	if err := s.repo.Save(ctx, record); err != nil {
+		s.logger.Error("更新网络注册恢复记录失败", zap.String("device", deviceID), zap.Error(err))
+	}
+
+	content := fmt.Sprintf("%s 的 SIM 卡已 %d 分钟未注册网络（%s），第 %d/%d 步恢复：%s，%s",
+		s.deviceLabel(deviceID), int(now.Sub(state.unregisteredSince).Minutes()), operatorLabel(record.Operator),
+		number, len(registrationRecoverySteps), step.label, result)
+	go s.serialService.SendPriorityEventNotificationTo(context.Background(), EventSystem, content, step.priority, config.Channels)
+}
+
+// exhausted 所有恢复操作执行后仍未注册，记录失败并发送紧急通知，只通知一次
+func (s *RegistrationRecoveryService) exhausted(ctx context.Context, config models.RegistrationRecoveryConfig, deviceID string, record *models.RegistrationRecovery, now time.Time) {
+	if record == nil || record.Result != models.RegistrationRecoveryRunning {
+		return
+	}
+	record.Result = models.RegistrationRecoveryFailed
+	record.EndedAt = now.UnixMilli()
+	if err := s.repo.Save(ctx, record); err != nil {
+		s.logger.Error("更新网络注册恢复记录失败", zap.String("device", deviceID), zap.Error(err))
+	}
+
+	s.logger.Error("网络注册恢复失败，所有恢复操作都未能恢复注册", zap.String("device", deviceID))
+	content := fmt.Sprintf("%s 执行所有恢复操作后仍未注册网络（%s），请检查 SIM 卡是否欠费停机、天线和信号",
+		s.deviceLabel(deviceID), operatorLabel(record.Operator))
+	go s.serialService.SendPriorityEventNotificationTo(context.Background(), EventSystem, content, models.NotificationPriorityCritical, config.Channels)
+}
+
+// recovered 设备已注册网络，结束恢复并记录最终生效的操作
+func (s *RegistrationRecoveryService) recovered(ctx context.Context, config models.RegistrationRecoveryConfig, deviceID string) {
+	s.mu.Lock()
+	state := s.states[deviceID]
+	delete(s.states, deviceID)
+	s.mu.Unlock()
+	if state == nil {
+		return
+	}
+	if state.record == nil {
+		s.logger.Info("设备已自行恢复网络注册", zap.String("device", deviceID))
+		return
+	}
+
+	record := state.record
+	if record.Result == models.RegistrationRecoveryRunning {
+		steps := strings.Split(record.Steps, ",")
+		record.RecoveredBy = steps[len(steps)-1]
+	}
+	record.Result = models.RegistrationRecoveryRecovered
+	record.EndedAt = time.Now().UnixMilli()
+	if err := s.repo.Save(ctx, record); err != nil {
+		s.logger.Error("更新网络注册恢复记录失败", zap.String("device", deviceID), zap.Error(err))
+	}
+
+	by := "自行恢复"
+	if record.RecoveredBy != "" {
+		by = "生效的操作：" + recoveryActionLabel(record.RecoveredBy)
+	}
+	s.logger.Info("设备已恢复网络注册", zap.String("device", deviceID), zap.String("recovered_by", record.RecoveredBy))
+	content := fmt.Sprintf("%s 已恢复网络注册，未注册 %d 分钟，%s",
+		s.deviceLabel(deviceID), int(time.Since(state.unregisteredSince).Minutes()), by)
+	go s.serialService.SendEventNotificationTo(context.Background(), EventSystem, content, config.Channels)
+}
+
+// cancel 开启飞行模式、移除 SIM 卡或关闭自动恢复时停止跟踪，未结束的恢复记录标记为已停止
+func (s *RegistrationRecoveryService) cancel(ctx context.Context, deviceID string) {
+	s.mu.Lock()
+	state := s.states[deviceID]
+	delete(s.states, deviceID)
+	s.mu.Unlock()
+	if state == nil || state.record == nil || state.record.Result != models.RegistrationRecoveryRunning {
+		return
+	}
+
+	s.logger.Info("停止网络注册恢复", zap.String("device", deviceID))
+	state.record.Result = models.RegistrationRecoveryCancelled
+	state.record.EndedAt = time.Now().UnixMilli()
+	if err := s.repo.Save(ctx, state.record); err != nil {
+		s.logger.Error("更新网络注册恢复记录失败", zap.String("device", deviceID), zap.Error(err))
+	}
+}
+
+// runStep 执行恢复操作
+func (s *RegistrationRecoveryService) runStep(deviceID, action string) error {
+	defer func() { go s.serialService.RequestCacheUpdate(deviceID) }()
+	switch action {
+	case RecoveryActionResetStack:
+		return s.serialService.ResetStack(deviceID)
+	case RecoveryActionFlymode:
+		if err := s.serialService.SetFlymode(deviceID, true); err != nil {
+			return err
+		}
+		time.Sleep(flymodeToggleDelay)
+		return s.serialService.SetFlymode(deviceID, false)
+	case RecoveryActionReboot:
+		return s.serialService.RebootMcu(deviceID)
+	}
+	return fmt.Errorf("未知的恢复操作: %s", action)
+}
+
+// Status 获取配置和当前未注册网络的设备的恢复进度
+func (s *RegistrationRecoveryService) Status(ctx context.Context) *RegistrationRecoveryStatus {
+	status := &RegistrationRecoveryStatus{
+		RegistrationRecoveryConfig: s.getConfig(ctx),
+		Devices:                    []RegistrationRecoveryDeviceStatus{},
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, deviceID := range s.serialService.DeviceIDs() {
+		state := s.states[deviceID]
+		if state == nil {
+			continue
+		}
+		device := RegistrationRecoveryDeviceStatus{
+			DeviceID:          deviceID,
+			UnregisteredSince: state.unregisteredSince.UnixMilli(),
+			Step:              state.step,
+		}
+		if state.step < len(registrationRecoverySteps) {
+			device.NextAction = registrationRecoverySteps[state.step].action
+		}
+		if state.record != nil {
+			device.RecoveryID = state.record.ID
+		}
+		if !state.lastStepAt.IsZero() {
+			device.LastStepAt = state.lastStepAt.UnixMilli()
+		}
+		status.Devices = append(status.Devices, device)
+	}
+	return status
+}
+
+// List 按时间倒序分页获取恢复记录（键集分页），deviceID 为空时返回所有设备的记录
+func (s *RegistrationRecoveryService) List(ctx context.Context, deviceID, cursor string, limit int) (*RegistrationRecoveryPage, error) {
+	c, err := decodeCursor(cursor)
+	if err != nil {
+		return nil, err
+	}
+	limit = normalizeLimit(limit)
+
+	records, err := s.repo.FindBefore(ctx, deviceID, c, limit+1)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &RegistrationRecoveryPage{Items: records}
+	if len(records) > limit {
+		page.Items = records[:limit]
+		page.HasMore = true
+		last := page.Items[limit-1]
+		page.NextCursor = encodeCursorAt(last.CreatedAt, last.ID)
+	}
+	if page.Items == nil {
+		page.Items = []models.RegistrationRecovery{}
+	}
+	return page, nil
+}
+
+// getConfig 获取网络注册自动恢复配置，未配置或读取失败时视为未启用
+func (s *RegistrationRecoveryService) getConfig(ctx context.Context) models.RegistrationRecoveryConfig {
+	var config models.RegistrationRecoveryConfig
+	if err := s.propertyService.GetValue(ctx, PropertyIDRegistrationRecovery, &config); err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		s.logger.Error("获取网络注册自动恢复配置失败", zap.Error(err))
+	}
+	return config
+}
+
+// deviceLabel 通知中显示的设备名称
+func (s *RegistrationRecoveryService) deviceLabel(deviceID string) string {
+	if len(s.serialService.DeviceIDs()) > 1 {
+		return "设备 " + deviceID
+	}
+	return "设备"
+}
+
+// unregisteredThreshold 未注册持续多久后开始恢复
+func unregisteredThreshold(config models.RegistrationRecoveryConfig) time.Duration {
+	if config.UnregisteredMinutes <= 0 {
+		return DefaultRegistrationUnregisteredMinutes * time.Minute
+	}
+	return time.Duration(config.UnregisteredMinutes) * time.Minute
+}
+
+// stepInterval 每一步恢复操作后等待注册的时长
+func stepInterval(config models.RegistrationRecoveryConfig) time.Duration {
+	if config.StepMinutes <= 0 {
+		return DefaultRegistrationStepMinutes * time.Minute
+	}
+	return time.Duration(config.StepMinutes) * time.Minute
+}
+
+// operatorLabel 通知中显示的运营商，未知时显示未知运营商
+func operatorLabel(operator string) string {
+	if operator == "" {
+		return "未知运营商"
+	}
+	return operator
+}
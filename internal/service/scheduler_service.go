@@ -3,6 +3,7 @@ package service
 import (
 	"context"
 	"fmt"
+	"sync"
 	"time"
 
 	"github.com/dushixiang/uart_sms_forwarder/internal/models"
@@ -15,12 +16,24 @@ import (
 	"gorm.io/gorm"
 )
 
+const (
+	// taskCheckSpec 按间隔天数执行的任务每天检查一次是否到期（早上 8 点）
+	taskCheckSpec = "0 8 * * *"
+	// catchUpConnectTimeout 启动时立即补执行的任务等待设备连接的最长时间
+	catchUpConnectTimeout = 5 * time.Minute
+)
+
 // SchedulerService 定时任务调度服务（包含任务管理功能）
 type SchedulerService struct {
 	logger        *zap.Logger
 	cron          *cron.Cron
 	repo          *repo.ScheduledTaskRepo
 	serialService *SerialService
+
+	healthcheckService *HealthcheckService
+
+	mu      sync.Mutex
+	entries map[string]cron.EntryID // 任务 ID -> cron 条目
 }
 
 // NewSchedulerService 创建定时任务服务实例
@@ -33,9 +46,23 @@ func NewSchedulerService(
 		logger:        logger,
 		repo:          repo.NewScheduledTaskRepo(db),
 		serialService: serialService,
+		entries:       make(map[string]cron.EntryID),
 	}
 }
 
+// ValidateCronSpec 校验定时任务的 Cron 表达式（分 时 日 月 周，支持 @monthly 等描述符和 CRON_TZ= 前缀）
+func ValidateCronSpec(spec string) error {
+	if _, err := cron.ParseStandard(spec); err != nil {
+		return fmt.Errorf("Cron 表达式无效: %w", err)
+	}
+	return nil
+}
+
+// SetHealthcheckService 设置外部监控心跳服务，设置后每次执行定时任务都会发送一次心跳
+func (s *SchedulerService) SetHealthcheckService(healthcheckService *HealthcheckService) {
+	s.healthcheckService = healthcheckService
+}
+
 // ==================== 任务管理方法 ====================
 
 // GetAll 获取所有定时任务
@@ -63,7 +90,11 @@ func (s *SchedulerService) Create(ctx context.Context, task *models.ScheduledTas
 	task.ID = uuid.New().String()
 	task.CreatedAt = now
 	task.UpdatedAt = now
-	return s.repo.Create(ctx, task)
+	if err := s.repo.Create(ctx, task); err != nil {
+		return err
+	}
+	s.schedule(*task)
+	return nil
 }
 
 // Update 更新定时任务
@@ -75,15 +106,27 @@ func (s *SchedulerService) Update(ctx context.Context, task *models.ScheduledTas
 	existingTask.Name = task.Name
 	existingTask.Enabled = task.Enabled
 	existingTask.IntervalDays = task.IntervalDays
+	existingTask.CronSpec = task.CronSpec
 	existingTask.PhoneNumber = task.PhoneNumber
+	existingTask.DeviceID = task.DeviceID
 	existingTask.Content = task.Content
+	existingTask.MissedRun = task.MissedRun
+	existingTask.Channels = task.Channels
 
-	return s.repo.Save(ctx, existingTask)
+	if err := s.repo.Save(ctx, existingTask); err != nil {
+		return err
+	}
+	s.schedule(*existingTask)
+	return nil
 }
 
 // Delete 删除定时任务
 func (s *SchedulerService) Delete(ctx context.Context, id string) error {
-	return s.repo.DeleteById(ctx, id)
+	if err := s.repo.DeleteById(ctx, id); err != nil {
+		return err
+	}
+	s.unschedule(id)
+	return nil
 }
 
 // TriggerTask 立即触发执行指定的任务
@@ -95,70 +138,251 @@ func (s *SchedulerService) TriggerTask(ctx context.Context, id string) error {
 	}
 
 	// 执行任务
-	if err := s.executeTask(*task); err != nil {
+	if _, err := s.executeTask(*task); err != nil {
 		return fmt.Errorf("执行任务失败: %w", err)
 	}
 
 	return nil
 }
 
+// TaskRunResult 手动执行任务的结果
+type TaskRunResult struct {
+	Success bool                  `json:"success"`
+	MsgID   string                `json:"msgId,omitempty"` // 发送的短信 ID，发送前失败时为空
+	Error   string                `json:"error,omitempty"` // 失败原因
+	Task    *models.ScheduledTask `json:"task"`            // 执行后的任务，包含 lastRunAt、lastRunStatus
+}
+
+// RunTask 立即执行任务并等待发送结果，用于验证新建的任务能否正常发送。
+// 与计划执行相同，记录 LastRunAt 和 LastRunStatus，失败时发送任务失败通知；未启用的任务也可执行
+func (s *SchedulerService) RunTask(ctx context.Context, id string) (*TaskRunResult, error) {
+	task, err := s.GetById(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &TaskRunResult{Success: true}
+	result.MsgID, err = s.executeTask(*task)
+	if err != nil {
+		result.Success = false
+		result.Error = err.Error()
+	}
+
+	// 重新读取以返回更新后的执行时间和状态
+	if updated, err := s.GetById(ctx, id); err == nil {
+		task = updated
+	}
+	result.Task = task
+	return result, nil
+}
+
 // ==================== 调度相关方法 ====================
 
-// Start 启动定时任务服务
+// Start 启动定时任务服务，每个启用的任务注册为单独的 cron 条目
 func (s *SchedulerService) Start(ctx context.Context) error {
+	tasks, err := s.GetAllEnabled(ctx)
+	if err != nil {
+		return fmt.Errorf("获取启用的定时任务失败: %w", err)
+	}
+
+	s.mu.Lock()
 	s.cron = cron.New()
+	s.mu.Unlock()
 
-	// 添加每天执行一次的检查任务（每天早上8点执行）
-	_, err := s.cron.AddFunc("0 8 * * *", func() {
-		s.logger.Info("开始检查定时任务")
-		if err := s.checkAndExecuteTasks(); err != nil {
-			s.logger.Error("检查并执行定时任务失败", zap.Error(err))
-		}
-	})
-	if err != nil {
-		return fmt.Errorf("添加检查任务失败: %w", err)
+	// 服务停止期间错过的任务按各自的策略处理
+	s.handleMissedRuns(ctx, time.Now())
+
+	for _, task := range tasks {
+		s.schedule(task)
 	}
 
 	// 启动 cron
 	s.cron.Start()
 
-	s.logger.Info("定时任务服务启动成功")
+	s.logger.Info("定时任务服务启动成功", zap.Int("tasks", len(tasks)))
 	return nil
 }
 
-// checkAndExecuteTasks 检查并执行满足条件的任务
-func (s *SchedulerService) checkAndExecuteTasks() error {
-	ctx := context.Background()
+// taskSpec 任务的 cron 表达式，按间隔天数执行的任务每天检查一次
+func taskSpec(task models.ScheduledTask) string {
+	if task.CronSpec != "" {
+		return task.CronSpec
+	}
+	return taskCheckSpec
+}
+
+// schedule 按任务当前配置重新注册 cron 条目，未启用的任务只移除条目。服务启动前调用时不做处理
+func (s *SchedulerService) schedule(task models.ScheduledTask) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.cron == nil {
+		return
+	}
+	if entryID, ok := s.entries[task.ID]; ok {
+		s.cron.Remove(entryID)
+		delete(s.entries, task.ID)
+	}
+	if !task.Enabled {
+		return
+	}
 
-	// 获取所有启用的任务
+	taskID := task.ID
+	entryID, err := s.cron.AddFunc(taskSpec(task), func() {
+		s.runScheduled(taskID)
+	})
+	if err != nil {
+		s.logger.Error("注册定时任务失败",
+			zap.String("id", task.ID),
+			zap.String("name", task.Name),
+			zap.String("cronSpec", task.CronSpec),
+			zap.Error(err))
+		return
+	}
+	s.entries[task.ID] = entryID
+}
+
+// unschedule 移除任务的 cron 条目
+func (s *SchedulerService) unschedule(id string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if entryID, ok := s.entries[id]; ok {
+		s.cron.Remove(entryID)
+		delete(s.entries, id)
+	}
+}
+
+// runScheduled cron 条目触发时重新读取任务，按 Cron 表达式执行的任务直接执行，按间隔天数执行的任务到期后执行
+func (s *SchedulerService) runScheduled(id string) {
+	task, err := s.GetById(context.Background(), id)
+	if err != nil {
+		s.logger.Error("获取定时任务失败", zap.String("id", id), zap.Error(err))
+		return
+	}
+	if !task.Enabled {
+		return
+	}
+	if task.CronSpec == "" && !s.shouldExecuteTask(*task, time.Now()) {
+		return
+	}
+
+	s.logger.Info("任务满足执行条件",
+		zap.String("id", task.ID),
+		zap.String("name", task.Name),
+		zap.String("cronSpec", task.CronSpec),
+		zap.Int("intervalDays", task.IntervalDays))
+	if _, err := s.executeTask(*task); err != nil {
+		s.logger.Error("执行定时任务失败",
+			zap.String("id", task.ID),
+			zap.String("name", task.Name),
+			zap.Error(err))
+	}
+}
+
+// handleMissedRuns 处理服务停止期间错过执行时间的任务：
+// immediate 在设备连接后立即执行，skip 从现在起重新计算间隔，once 留给下一次检查或计划执行
+func (s *SchedulerService) handleMissedRuns(ctx context.Context, now time.Time) {
 	tasks, err := s.GetAllEnabled(ctx)
 	if err != nil {
 		s.logger.Error("获取启用的定时任务失败", zap.Error(err))
-		return err
+		return
 	}
 
-	now := time.Now()
+	var immediate []models.ScheduledTask
 	for _, task := range tasks {
-		// 检查是否需要执行
-		if s.shouldExecuteTask(task, now) {
-			s.logger.Info("任务满足执行条件",
+		if !s.missedRun(task, now) {
+			continue
+		}
+		switch task.MissedRun {
+		case models.MissedRunImmediate:
+			immediate = append(immediate, task)
+		case models.MissedRunSkip:
+			s.logger.Info("跳过服务停止期间错过的定时任务",
 				zap.String("id", task.ID),
-				zap.String("name", task.Name),
-				zap.Int("intervalDays", task.IntervalDays))
-
-			if err := s.executeTask(task); err != nil {
-				s.logger.Error("执行定时任务失败",
-					zap.String("id", task.ID),
-					zap.String("name", task.Name),
-					zap.Error(err))
+				zap.String("name", task.Name))
+			if err := s.repo.UpdateColumnsById(ctx, task.ID, orz.Map{"last_run_at": now.UnixMilli()}); err != nil {
+				s.logger.Error("更新定时任务执行时间失败", zap.String("id", task.ID), zap.Error(err))
 			}
+		default:
+			s.logger.Info("定时任务错过执行时间，将在下一次检查时执行",
+				zap.String("id", task.ID),
+				zap.String("name", task.Name))
 		}
 	}
 
-	return nil
+	if len(immediate) > 0 {
+		go s.runMissed(immediate)
+	}
+}
+
+// missedRun 任务到期后是否已错过执行时间，即到期时服务未运行。
+// 按 Cron 表达式执行的任务在上次执行后、现在之前还有计划执行时间即为错过
+func (s *SchedulerService) missedRun(task models.ScheduledTask, now time.Time) bool {
+	// 从未执行过的任务不算错过
+	if task.LastRunAt <= 0 {
+		return false
+	}
+	if task.CronSpec != "" {
+		schedule, err := cron.ParseStandard(task.CronSpec)
+		if err != nil {
+			return false
+		}
+		return !schedule.Next(time.UnixMilli(task.LastRunAt)).After(now)
+	}
+	if !s.shouldExecuteTask(task, now) {
+		return false
+	}
+
+	interval := task.IntervalDays
+	if task.LastRunStatus == models.LastRunStatusFailed {
+		interval = 1
+	}
+	dueAt := time.UnixMilli(task.LastRunAt).AddDate(0, 0, interval)
+
+	schedule, err := cron.ParseStandard(taskCheckSpec)
+	if err != nil {
+		return false
+	}
+	return !schedule.Next(dueAt.Add(-time.Second)).After(now)
+}
+
+// runMissed 等待设备连接后依次执行错过的任务，超时未连接时留给下一次检查或计划执行
+func (s *SchedulerService) runMissed(tasks []models.ScheduledTask) {
+	deadline := time.Now().Add(catchUpConnectTimeout)
+	for {
+		if s.allConnected(tasks) {
+			break
+		}
+		if time.Now().After(deadline) {
+			s.logger.Warn("设备未连接，错过的定时任务将在下一次检查或计划执行时执行", zap.Int("count", len(tasks)))
+			return
+		}
+		time.Sleep(5 * time.Second)
+	}
+
+	for _, task := range tasks {
+		s.logger.Info("补执行服务停止期间错过的定时任务",
+			zap.String("id", task.ID),
+			zap.String("name", task.Name))
+		if _, err := s.executeTask(task); err != nil {
+			s.logger.Error("执行定时任务失败",
+				zap.String("id", task.ID),
+				zap.String("name", task.Name),
+				zap.Error(err))
+		}
+	}
+}
+
+// allConnected 任务使用的设备是否都已连接
+func (s *SchedulerService) allConnected(tasks []models.ScheduledTask) bool {
+	for _, task := range tasks {
+		if !s.serialService.connected(task.DeviceID) {
+			return false
+		}
+	}
+	return true
 }
 
-// shouldExecuteTask 判断任务是否应该执行
+// shouldExecuteTask 判断按间隔天数执行的任务是否已到期
 func (s *SchedulerService) shouldExecuteTask(task models.ScheduledTask, now time.Time) bool {
 	// 如果从未执行过，则执行
 	if task.LastRunAt <= 0 {
@@ -178,8 +402,8 @@ func (s *SchedulerService) shouldExecuteTask(task models.ScheduledTask, now time
 	return daysSinceLastRun >= task.IntervalDays
 }
 
-// executeTask 执行任务
-func (s *SchedulerService) executeTask(task models.ScheduledTask) error {
+// executeTask 执行任务，返回发送的短信 ID
+func (s *SchedulerService) executeTask(task models.ScheduledTask) (string, error) {
 	s.logger.Info("执行定时任务",
 		zap.String("id", task.ID),
 		zap.String("name", task.Name),
@@ -188,14 +412,16 @@ func (s *SchedulerService) executeTask(task models.ScheduledTask) error {
 
 	ctx := context.Background()
 
-	flyMode := s.serialService.FlyMode()
+	flyMode := s.serialService.FlyMode(task.DeviceID)
 	// 如果是飞行模式，取消飞行模式，再等待 30 秒后发送短信
 	if flyMode {
 		s.logger.Info("当前为飞行模式，取消飞行模式后等待 30 秒")
 		// 取消飞行模式
-		if err := s.serialService.SetFlymode(false); err != nil {
+		if err := s.serialService.SetFlymode(task.DeviceID, false); err != nil {
 			s.logger.Error("取消飞行模式失败", zap.Error(err))
-			return err
+			err = fmt.Errorf("取消飞行模式失败: %w", err)
+			s.taskFailed(ctx, task, "", err)
+			return "", err
 		}
 		s.logger.Info("取消飞行模式成功")
 		// 等待 30 秒
@@ -204,14 +430,14 @@ func (s *SchedulerService) executeTask(task models.ScheduledTask) error {
 	}
 
 	// 发送短信
-	msgId, err := s.serialService.SendSMS(task.PhoneNumber, task.Content)
+	msgId, err := s.serialService.SendSMS(task.DeviceID, task.PhoneNumber, task.Content)
 	if err != nil {
 		s.logger.Error("定时任务发送短信失败",
 			zap.String("id", task.ID),
 			zap.String("name", task.Name),
 			zap.Error(err))
-		_ = s.UpdateLastRun(ctx, task.ID, msgId, models.LastRunStatusFailed)
-		return err
+		s.taskFailed(ctx, task, msgId, err)
+		return msgId, err
 	}
 	s.logger.Info("定时任务执行成功",
 		zap.String("id", task.ID),
@@ -220,8 +446,16 @@ func (s *SchedulerService) executeTask(task models.ScheduledTask) error {
 	// 更新任务的 LastRunAt 字段到数据库
 
 	_ = s.UpdateLastRun(ctx, task.ID, msgId, models.LastRunStatusUnknown)
+	s.healthcheckService.Event(true, "定时任务已执行: "+task.Name)
 
-	return nil
+	return msgId, nil
+}
+
+// taskFailed 记录任务执行失败，发送任务失败通知和健康检查事件
+func (s *SchedulerService) taskFailed(ctx context.Context, task models.ScheduledTask, msgId string, err error) {
+	_ = s.UpdateLastRun(ctx, task.ID, msgId, models.LastRunStatusFailed)
+	go s.notifyTaskFailure(task, err.Error())
+	s.healthcheckService.Event(false, fmt.Sprintf("定时任务执行失败: %s: %v", task.Name, err))
 }
 
 func (s *SchedulerService) UpdateLastRun(ctx context.Context, id, msgId string, status models.LastRunStatus) error {
@@ -233,5 +467,25 @@ func (s *SchedulerService) UpdateLastRun(ctx context.Context, id, msgId string,
 }
 
 func (s *SchedulerService) UpdateLastRunStatusByMsgId(ctx context.Context, msgId string, status models.LastRunStatus) error {
-	return s.repo.UpdateLastRunStatusByMsgId(ctx, msgId, status)
+	if err := s.repo.UpdateLastRunStatusByMsgId(ctx, msgId, status); err != nil {
+		return err
+	}
+	if status != models.LastRunStatusFailed {
+		return nil
+	}
+
+	tasks, err := s.repo.FindByLastMsgId(ctx, msgId)
+	if err != nil {
+		return err
+	}
+	for _, task := range tasks {
+		go s.notifyTaskFailure(task, "短信发送失败")
+	}
+	return nil
+}
+
+// notifyTaskFailure 发送定时任务执行失败通知
+func (s *SchedulerService) notifyTaskFailure(task models.ScheduledTask, reason string) {
+	s.serialService.SendEventNotificationTo(context.Background(), EventTaskFailure,
+		fmt.Sprintf("定时任务执行失败: %s\n号码: %s\n原因: %s", task.Name, task.PhoneNumber, reason), task.Channels)
 }
@@ -0,0 +1,269 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dushixiang/uart_sms_forwarder/internal/models"
+	lua "github.com/yuin/gopher-lua"
+	"github.com/yuin/gopher-lua/parse"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+const (
+	// PropertyIDMessageScript 短信处理脚本配置
+	PropertyIDMessageScript = "message_script"
+	// DefaultScriptTimeout 脚本默认执行超时（毫秒）
+	DefaultScriptTimeout = 1000
+	// scriptEntry 脚本入口函数名
+	scriptEntry = "on_message"
+)
+
+// DefaultMessageScriptConfig 默认短信处理脚本配置（未启用，附带示例脚本）
+var DefaultMessageScriptConfig = models.MessageScriptConfig{
+	Enabled: false,
+	Timeout: DefaultScriptTimeout,
+	Script: `-- 每条收到的短信在保存和通知前调用 on_message(msg)
+-- msg.from / msg.content / msg.timestamp：可修改
+-- msg.tags = {"验证码"}：为短信记录添加标签
+-- msg.channels = {"telegram"}：只通知指定类型的渠道
+-- msg.notify = false：保存但不通知
+-- msg.drop = true 或 return false：丢弃，不保存也不通知
+function on_message(msg)
+  if string.find(msg.content, "验证码") then
+    msg.tags = {"验证码"}
+  end
+end
+`,
+}
+
+// ScriptMessage 传入脚本的短信，脚本可修改字段
+type ScriptMessage struct {
+	From      string   `json:"from"`
+	Content   string   `json:"content"`
+	Timestamp int64    `json:"timestamp"` // 时间戳（秒）
+	Tags      []string `json:"tags"`      // 附加到短信记录的标签
+	Channels  []string `json:"channels"`  // 限定通知渠道类型，为空时发送到所有启用的渠道
+	Drop      bool     `json:"drop"`      // 丢弃：不保存也不通知
+	Notify    bool     `json:"notify"`    // 是否发送通知，默认 true
+}
+
+// ScriptService 短信处理脚本服务。
+// 每条收到的短信在保存和通知前调用脚本中的 on_message(msg)，
+// 脚本可以修改 msg.content / msg.from、设置 msg.tags、msg.channels、msg.notify、msg.drop，
+// 用于规则无法表达的复杂逻辑。
+type ScriptService struct {
+	logger          *zap.Logger
+	propertyService *PropertyService
+
+	mu       sync.Mutex
+	source   string
+	compiled *lua.FunctionProto
+}
+
+// NewScriptService 创建脚本服务实例
+func NewScriptService(logger *zap.Logger, propertyService *PropertyService) *ScriptService {
+	return &ScriptService{
+		logger:          logger,
+		propertyService: propertyService,
+	}
+}
+
+// GetConfig 获取脚本配置，未配置时返回禁用状态
+func (s *ScriptService) GetConfig(ctx context.Context) (models.MessageScriptConfig, error) {
+	var config models.MessageScriptConfig
+	if err := s.propertyService.GetValue(ctx, PropertyIDMessageScript, &config); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return config, nil
+		}
+		return config, err
+	}
+	return config, nil
+}
+
+// Process 对收到的短信执行脚本，未启用时原样返回。
+// 脚本出错时记录日志并返回原始短信，避免因脚本问题丢失短信。
+func (s *ScriptService) Process(ctx context.Context, msg ScriptMessage) ScriptMessage {
+	msg.Notify = true
+
+	config, err := s.GetConfig(ctx)
+	if err != nil {
+		s.logger.Error("获取短信处理脚本配置失败", zap.Error(err))
+		return msg
+	}
+	if !config.Enabled || strings.TrimSpace(config.Script) == "" {
+		return msg
+	}
+
+	proto, err := s.compile(config.Script)
+	if err != nil {
+		s.logger.Error("短信处理脚本编译失败", zap.Error(err))
+		return msg
+	}
+
+	result, err := s.run(ctx, proto, config.Timeout, msg)
+	if err != nil {
+		s.logger.Error("短信处理脚本执行失败", zap.Error(err), zap.String("from", msg.From))
+		return msg
+	}
+	return result
+}
+
+// Test 使用指定脚本处理一条短信，返回处理结果，用于保存前调试
+func (s *ScriptService) Test(ctx context.Context, script string, timeout int, msg ScriptMessage) (ScriptMessage, error) {
+	msg.Notify = true
+	proto, err := compileScript(script)
+	if err != nil {
+		return msg, err
+	}
+	return s.run(ctx, proto, timeout, msg)
+}
+
+// compile 编译脚本，脚本内容未变化时复用上次结果
+func (s *ScriptService) compile(source string) (*lua.FunctionProto, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.compiled != nil && s.source == source {
+		return s.compiled, nil
+	}
+	proto, err := compileScript(source)
+	if err != nil {
+		return nil, err
+	}
+	s.source = source
+	s.compiled = proto
+	return proto, nil
+}
+
+// run 在独立的 Lua 虚拟机中执行脚本
+func (s *ScriptService) run(ctx context.Context, proto *lua.FunctionProto, timeout int, msg ScriptMessage) (ScriptMessage, error) {
+	if timeout <= 0 {
+		timeout = DefaultScriptTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(timeout)*time.Millisecond)
+	defer cancel()
+
+	L := newSandboxState(s.logger)
+	defer L.Close()
+	L.SetContext(ctx)
+
+	L.Push(L.NewFunctionFromProto(proto))
+	if err := L.PCall(0, lua.MultRet, nil); err != nil {
+		return msg, fmt.Errorf("加载脚本失败: %w", err)
+	}
+
+	entry := L.GetGlobal(scriptEntry)
+	if entry.Type() != lua.LTFunction {
+		return msg, fmt.Errorf("脚本未定义 %s(msg) 函数", scriptEntry)
+	}
+
+	table := messageToTable(L, msg)
+	if err := L.CallByParam(lua.P{Fn: entry, NRet: 1, Protect: true}, table); err != nil {
+		return msg, err
+	}
+	// 返回 false 等同于 msg.drop = true
+	if ret := L.Get(-1); ret == lua.LFalse {
+		table.RawSetString("drop", lua.LTrue)
+	}
+	L.Pop(1)
+
+	return tableToMessage(table, msg), nil
+}
+
+// compileScript 编译 Lua 脚本
+func compileScript(source string) (*lua.FunctionProto, error) {
+	chunk, err := parse.Parse(strings.NewReader(source), "message_script")
+	if err != nil {
+		return nil, err
+	}
+	return lua.Compile(chunk, "message_script")
+}
+
+// newSandboxState 创建只包含基础库的 Lua 虚拟机，不开放 io / os 等系统访问
+func newSandboxState(logger *zap.Logger) *lua.LState {
+	L := lua.NewState(lua.Options{SkipOpenLibs: true})
+	for _, lib := range []struct {
+		name string
+		fn   lua.LGFunction
+	}{
+		{lua.BaseLibName, lua.OpenBase},
+		{lua.TabLibName, lua.OpenTable},
+		{lua.StringLibName, lua.OpenString},
+		{lua.MathLibName, lua.OpenMath},
+	} {
+		L.Push(L.NewFunction(lib.fn))
+		L.Push(lua.LString(lib.name))
+		L.Call(1, 0)
+	}
+	// 移除可以加载外部代码的函数
+	for _, name := range []string{"dofile", "loadfile", "load", "loadstring", "require"} {
+		L.SetGlobal(name, lua.LNil)
+	}
+	// print 输出到应用日志
+	L.SetGlobal("print", L.NewFunction(func(L *lua.LState) int {
+		parts := make([]string, 0, L.GetTop())
+		for i := 1; i <= L.GetTop(); i++ {
+			parts = append(parts, L.ToStringMeta(L.Get(i)).String())
+		}
+		logger.Info("短信处理脚本输出", zap.String("message", strings.Join(parts, "\t")))
+		return 0
+	}))
+	return L
+}
+
+// messageToTable 将短信转换为 Lua table
+func messageToTable(L *lua.LState, msg ScriptMessage) *lua.LTable {
+	table := L.NewTable()
+	table.RawSetString("from", lua.LString(msg.From))
+	table.RawSetString("content", lua.LString(msg.Content))
+	table.RawSetString("timestamp", lua.LNumber(msg.Timestamp))
+	table.RawSetString("tags", stringsToTable(L, msg.Tags))
+	table.RawSetString("channels", stringsToTable(L, msg.Channels))
+	table.RawSetString("drop", lua.LBool(msg.Drop))
+	table.RawSetString("notify", lua.LBool(msg.Notify))
+	return table
+}
+
+// tableToMessage 读取脚本修改后的字段，类型不符的字段保持原值
+func tableToMessage(table *lua.LTable, msg ScriptMessage) ScriptMessage {
+	if v, ok := table.RawGetString("from").(lua.LString); ok {
+		msg.From = string(v)
+	}
+	if v, ok := table.RawGetString("content").(lua.LString); ok {
+		msg.Content = string(v)
+	}
+	if v, ok := table.RawGetString("tags").(*lua.LTable); ok {
+		msg.Tags = tableToStrings(v)
+	}
+	if v, ok := table.RawGetString("channels").(*lua.LTable); ok {
+		msg.Channels = tableToStrings(v)
+	}
+	msg.Drop = lua.LVAsBool(table.RawGetString("drop"))
+	if v, ok := table.RawGetString("notify").(lua.LBool); ok {
+		msg.Notify = bool(v)
+	}
+	return msg
+}
+
+func stringsToTable(L *lua.LState, values []string) *lua.LTable {
+	table := L.CreateTable(len(values), 0)
+	for _, v := range values {
+		table.Append(lua.LString(v))
+	}
+	return table
+}
+
+func tableToStrings(table *lua.LTable) []string {
+	var values []string
+	table.ForEach(func(_, v lua.LValue) {
+		if s, ok := v.(lua.LString); ok && s != "" {
+			values = append(values, string(s))
+		}
+	})
+	return values
+}
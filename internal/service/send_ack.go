@@ -0,0 +1,88 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/dushixiang/uart_sms_forwarder/internal/models"
+	"go.uber.org/zap"
+)
+
+// DefaultSendTimeout 默认等待设备返回发送结果的超时（秒）
+const DefaultSendTimeout = 120
+
+// sendAckTracker 跟踪已提交给设备、尚未收到发送结果的短信
+type sendAckTracker struct {
+	mu     sync.Mutex
+	timers map[string]*time.Timer
+}
+
+// track 开始等待发送结果，超时后调用 onTimeout
+func (t *sendAckTracker) track(id string, timeout time.Duration, onTimeout func()) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.timers == nil {
+		t.timers = make(map[string]*time.Timer)
+	}
+	if timer, ok := t.timers[id]; ok {
+		timer.Stop()
+	}
+	t.timers[id] = time.AfterFunc(timeout, func() {
+		if t.resolve(id) {
+			onTimeout()
+		}
+	})
+}
+
+// resolve 结束等待，返回该短信是否仍在等待中。
+// 发送结果和超时同时到达时只有一方返回 true。
+func (t *sendAckTracker) resolve(id string) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	timer, ok := t.timers[id]
+	if !ok {
+		return false
+	}
+	timer.Stop()
+	delete(t.timers, id)
+	return true
+}
+
+// sendTimeout 等待发送结果的超时
+func (s *SerialService) sendTimeout() time.Duration {
+	if s.config.SendTimeout > 0 {
+		return time.Duration(s.config.SendTimeout) * time.Second
+	}
+	return DefaultSendTimeout * time.Second
+}
+
+// awaitSendResult 等待设备返回发送结果，超时未返回时标记为发送失败
+func (s *SerialService) awaitSendResult(id, to string) {
+	timeout := s.sendTimeout()
+	s.sendAcks.track(id, timeout, func() {
+		s.logger.Warn("等待设备返回发送结果超时，设备可能未处理该指令",
+			zap.String("to", to),
+			zap.String("request_id", id),
+			zap.Duration("timeout", timeout))
+
+		ctx := context.Background()
+		s.updateSendStatus(ctx, id, models.MessageStatusFailed, sendFailure{Reason: "等待设备返回发送结果超时"})
+		s.updateScheduledTaskStatus(ctx, id, models.LastRunStatusFailed)
+		s.SendSystemNotification(ctx, fmt.Sprintf("短信发送超时: %s", to))
+	})
+}
+
+// FailStuckSending 将上次运行时仍在发送中的短信标记为发送失败。
+// 进程重启后不会再收到这些短信的发送结果，应在串口服务启动前调用。
+func (s *SerialService) FailStuckSending(ctx context.Context) {
+	count, err := s.textMsgService.FailStuckSending(ctx, time.Now().UnixMilli())
+	if err != nil {
+		s.logger.Error("清理发送中的短信失败", zap.Error(err))
+		return
+	}
+	if count > 0 {
+		s.logger.Warn("上次运行时未收到发送结果的短信已标记为发送失败", zap.Int64("count", count))
+	}
+}
@@ -0,0 +1,135 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/dushixiang/uart_sms_forwarder/internal/models"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// PropertyIDSendApproval 发送审批配置
+const PropertyIDSendApproval = "send_approval"
+
+// ErrNotPendingApproval 短信不在待审批队列中（已审批或不存在）
+var ErrNotPendingApproval = errors.New("短信不在待审批队列中")
+
+// approvalPreviewLength 待审批通知中短信内容的最大长度
+const approvalPreviewLength = 70
+
+// getSendApprovalConfig 获取发送审批配置，未配置时不启用
+func (s *SerialService) getSendApprovalConfig(ctx context.Context) (models.SendApprovalConfig, error) {
+	var config models.SendApprovalConfig
+	if err := s.propertyService.GetValue(ctx, PropertyIDSendApproval, &config); err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return config, fmt.Errorf("获取发送审批配置失败: %w", err)
+	}
+	return config, nil
+}
+
+// RequiresApproval 当前请求提交的短信是否需要审批，返回提交者。
+// 只有开启审批后非管理员用户（含 SMSEagle 接口令牌）的请求需要审批，定时任务、短信指令等后台发送不需要
+func (s *SerialService) RequiresApproval(ctx context.Context) (submitter string, required bool) {
+	viewer, ok := ViewerFrom(ctx)
+	if !ok || viewer.Admin {
+		return "", false
+	}
+	config, err := s.getSendApprovalConfig(ctx)
+	if err != nil {
+		// 无法确认是否需要审批时按需要审批处理，避免绕过审批
+		s.logger.Error("读取发送审批配置失败", zap.Error(err))
+		return viewer.Username, true
+	}
+	return viewer.Username, config.Enabled
+}
+
+// holdForApproval 需要审批时将发送记录标记为待审批
+func (s *SerialService) holdForApproval(ctx context.Context, msg *models.TextMessage) {
+	if submitter, required := s.RequiresApproval(ctx); required {
+		msg.Status = models.MessageStatusPendingApproval
+		msg.SubmittedBy = submitter
+	}
+}
+
+// notifyPendingApproval 通知管理员有待审批的短信
+func (s *SerialService) notifyPendingApproval(msg *models.TextMessage) {
+	ctx := context.Background()
+	config, err := s.getSendApprovalConfig(ctx)
+	if err != nil {
+		s.logger.Error("读取发送审批配置失败", zap.Error(err))
+	}
+	preview := []rune(msg.Content)
+	if len(preview) > approvalPreviewLength {
+		preview = append(preview[:approvalPreviewLength], '…')
+	}
+	content := fmt.Sprintf("%s 提交了待审批的短信\n收件人: %s\n内容: %s\n审批: POST /api/serial/sms/%s/approve",
+		msg.SubmittedBy, msg.To, string(preview), msg.ID)
+	go s.SendEventNotificationTo(ctx, EventSystem, content, config.Channels)
+}
+
+// ListPendingApproval 获取待审批的短信，按提交时间排序，仅管理员可用
+func (s *SerialService) ListPendingApproval(ctx context.Context) ([]models.TextMessage, error) {
+	if err := requireAdmin(ctx); err != nil {
+		return nil, err
+	}
+	return s.textMsgService.FindPendingApproval(ctx)
+}
+
+// ApproveSMS 批准待审批的短信并提交给设备发送，仅管理员可用
+func (s *SerialService) ApproveSMS(ctx context.Context, id string) error {
+	if err := requireAdmin(ctx); err != nil {
+		return err
+	}
+	viewer, _ := ViewerFrom(ctx)
+	// 客户端断开时仍需完成发送状态的更新
+	ctx = context.WithoutCancel(ctx)
+	msg, err := s.textMsgService.Get(ctx, id)
+	if err != nil {
+		return ErrNotPendingApproval
+	}
+	reviewed, err := s.textMsgService.ReviewPending(ctx, id, models.MessageStatusSending, viewer.Username, "")
+	if err != nil {
+		return err
+	}
+	if !reviewed {
+		return ErrNotPendingApproval
+	}
+
+	s.logger.Info("已批准发送短信",
+		zap.String("id", id),
+		zap.String("to", msg.To),
+		zap.String("submitted_by", msg.SubmittedBy),
+		zap.String("reviewed_by", viewer.Username))
+	msg.Status = models.MessageStatusSending
+	_, err = s.submitSMS(ctx, msg)
+	return err
+}
+
+// RejectSMS 拒绝待审批的短信，reason 保存为失败原因，仅管理员可用
+func (s *SerialService) RejectSMS(ctx context.Context, id, reason string) error {
+	if err := requireAdmin(ctx); err != nil {
+		return err
+	}
+	viewer, _ := ViewerFrom(ctx)
+	if reason == "" {
+		reason = "管理员拒绝发送"
+	}
+	reviewed, err := s.textMsgService.ReviewPending(ctx, id, models.MessageStatusRejected, viewer.Username, reason)
+	if err != nil {
+		return err
+	}
+	if !reviewed {
+		return ErrNotPendingApproval
+	}
+
+	s.logger.Info("已拒绝发送短信", zap.String("id", id), zap.String("reviewed_by", viewer.Username))
+	s.sendStatus.publish(SendStatusEvent{
+		MessageID: id,
+		Status:    string(models.MessageStatusRejected),
+		Error:     reason,
+		Timestamp: time.Now().UnixMilli(),
+	})
+	return nil
+}
@@ -0,0 +1,133 @@
+package service
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// cmsErrorPattern 模组返回的 +CMS ERROR / +CME ERROR 错误码
+var cmsErrorPattern = regexp.MustCompile(`\+(CMS|CME) ERROR:\s*(\d+)`)
+
+// cmsErrorReasons 常见 CMS 错误码（3GPP TS 27.005 / 24.011）的说明
+var cmsErrorReasons = map[int]string{
+	1:   "号码未分配（空号）",
+	8:   "运营商禁止发送，可能已欠费停机",
+	10:  "呼叫受限",
+	21:  "短信被拒绝，可能已欠费或被运营商拦截",
+	27:  "目标号码无法送达",
+	28:  "无法识别的用户",
+	29:  "业务被拒绝",
+	30:  "未知用户",
+	38:  "网络故障",
+	41:  "网络临时故障",
+	42:  "网络拥塞",
+	47:  "网络资源不可用",
+	50:  "未开通短信业务，可能已欠费",
+	69:  "网络不支持该业务",
+	81:  "短信引用号无效",
+	95:  "消息格式错误",
+	96:  "号码格式错误",
+	111: "网络协议错误",
+	127: "网络互通错误",
+	300: "模组故障",
+	301: "模组短信服务被占用",
+	302: "不允许的操作",
+	303: "不支持的操作",
+	304: "PDU 参数无效",
+	305: "文本参数无效",
+	310: "未插入 SIM 卡",
+	311: "需要 SIM 卡 PIN 码",
+	313: "SIM 卡故障",
+	314: "SIM 卡忙",
+	316: "需要 SIM 卡 PUK 码",
+	320: "存储器故障",
+	322: "存储已满",
+	330: "短信中心号码未知",
+	331: "无网络服务",
+	332: "网络超时",
+	500: "未知错误",
+}
+
+// cmeErrorReasons 发送短信时常见的 CME 错误码（3GPP TS 27.007）的说明
+var cmeErrorReasons = map[int]string{
+	3:  "不允许的操作",
+	4:  "不支持的操作",
+	10: "未插入 SIM 卡",
+	11: "需要 SIM 卡 PIN 码",
+	13: "SIM 卡故障",
+	14: "SIM 卡忙",
+	30: "无网络服务",
+	31: "网络超时",
+}
+
+// statusReportReasons 状态报告中常见的最终失败 TP-ST（3GPP TS 23.040）的说明
+var statusReportReasons = map[int]string{
+	0x40: "短信中心拒绝，远程过程错误",
+	0x41: "号码不兼容",
+	0x42: "连接被拒绝",
+	0x43: "未获得服务",
+	0x44: "服务质量不可用",
+	0x45: "短信中心内部错误",
+	0x46: "短信已过期",
+	0x47: "短信被发送方删除",
+	0x48: "短信被短信中心删除",
+	0x49: "短信不存在",
+	0x60: "拥塞，短信中心已放弃重试",
+	0x61: "对方忙，短信中心已放弃重试",
+	0x62: "对方无响应，短信中心已放弃重试",
+	0x63: "服务被拒绝，短信中心已放弃重试",
+	0x64: "服务质量不可用，短信中心已放弃重试",
+	0x65: "对方错误，短信中心已放弃重试",
+}
+
+// statusReportReason 返回状态报告 TP-ST 对应的失败原因，未知状态码返回空
+func statusReportReason(status int) string {
+	return statusReportReasons[status]
+}
+
+// sendFailure 短信发送失败的错误码和原因
+type sendFailure struct {
+	Code   string // 错误码，如 CMS 21，无法识别时为空
+	Reason string // 可读的失败原因
+}
+
+// parseSendFailure 解析设备上报的失败信息。
+// code 为设备直接上报的错误码（数字或字符串），为空时从错误文本中识别 +CMS ERROR / +CME ERROR
+func parseSendFailure(code any, errText string) sendFailure {
+	failure := sendFailure{Reason: strings.TrimSpace(errText)}
+
+	kind, number := "CMS", -1
+	switch v := code.(type) {
+	case float64:
+		number = int(v)
+	case string:
+		if n, err := strconv.Atoi(strings.TrimSpace(v)); err == nil {
+			number = n
+		} else if v != "" {
+			failure.Code = v
+		}
+	}
+	if number < 0 && failure.Code == "" {
+		if match := cmsErrorPattern.FindStringSubmatch(errText); match != nil {
+			kind = match[1]
+			number, _ = strconv.Atoi(match[2])
+		}
+	}
+	if number < 0 {
+		return failure
+	}
+
+	failure.Code = fmt.Sprintf("%s %d", kind, number)
+	reasons := cmsErrorReasons
+	if kind == "CME" {
+		reasons = cmeErrorReasons
+	}
+	if reason, ok := reasons[number]; ok {
+		failure.Reason = reason
+	} else if failure.Reason == "" {
+		failure.Reason = "模组返回错误 " + failure.Code
+	}
+	return failure
+}
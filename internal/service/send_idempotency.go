@@ -0,0 +1,60 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// idempotencyKeyTTL 幂等键的有效期，超过后相同的键视为新请求
+const idempotencyKeyTTL = 24 * time.Hour
+
+// ErrIdempotencyKeyReused 幂等键已用于发往其他号码的请求
+var ErrIdempotencyKeyReused = errors.New("Idempotency-Key 已用于发往其他号码的请求")
+
+// SendSMSIdempotent 按幂等键通过指定设备发送短信，有效期内重复的键直接返回首次发送的短信 ID，replayed 为 true。
+// 提交给设备失败时清除幂等键，客户端可使用同一个键重试。
+func (s *SerialService) SendSMSIdempotent(ctx context.Context, key, deviceID, to, content string) (id string, replayed bool, err error) {
+	device, err := s.device(deviceID)
+	if err != nil {
+		return "", false, err
+	}
+	// 客户端断开时仍需完成发送状态的更新
+	ctx = context.WithoutCancel(ctx)
+	s.idempotencyMu.Lock()
+	existing, err := s.textMsgService.FindByIdempotencyKey(ctx, key, time.Now().Add(-idempotencyKeyTTL).UnixMilli())
+	if err != nil {
+		s.idempotencyMu.Unlock()
+		return "", false, err
+	}
+	if existing != nil {
+		s.idempotencyMu.Unlock()
+		// 内容可能包含模板的时间变量，重试时不要求一致
+		if existing.To != to {
+			return "", false, ErrIdempotencyKeyReused
+		}
+		s.logger.Info("重复的发送请求，返回首次发送结果",
+			zap.String("idempotency_key", key),
+			zap.String("id", existing.ID))
+		return existing.ID, true, nil
+	}
+
+	msg := newOutgoingMessage(device.id, to, content)
+	msg.IdempotencyKey = key
+	err = s.admitOutgoing(ctx, msg)
+	s.idempotencyMu.Unlock()
+	if err != nil {
+		return "", false, err
+	}
+
+	id, err = s.submitSMS(ctx, msg)
+	if err != nil {
+		if clearErr := s.textMsgService.ClearIdempotencyKey(ctx, msg.ID); clearErr != nil {
+			s.logger.Error("清除幂等键失败", zap.String("id", msg.ID), zap.Error(clearErr))
+		}
+		return "", false, err
+	}
+	return id, false, nil
+}
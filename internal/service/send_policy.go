@@ -0,0 +1,118 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/dushixiang/uart_sms_forwarder/internal/models"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// PropertyIDOutgoingPolicy 发送短信安全策略配置
+const PropertyIDOutgoingPolicy = "outgoing_policy"
+
+// ErrOutgoingRejected 发送短信违反安全策略
+var ErrOutgoingRejected = errors.New("发送被安全策略拒绝")
+
+// admitOutgoing 按安全策略和发送额度检查后保存发送记录，需要审批的短信保存为待审批。
+// 检查和保存串行执行，并发请求不会同时通过每日号码数量和额度限制
+func (s *SerialService) admitOutgoing(ctx context.Context, msg *models.TextMessage) error {
+	s.policyMu.Lock()
+	defer s.policyMu.Unlock()
+
+	if err := s.checkOutgoingPolicy(ctx, msg.To); err != nil {
+		s.logger.Warn("发送短信被安全策略拒绝", zap.String("to", msg.To), zap.Error(err))
+		return err
+	}
+	warning, err := s.checkSendQuota(ctx, msg.Content)
+	if err != nil {
+		s.logger.Warn("发送短信超出额度", zap.String("to", msg.To), zap.Error(err))
+		return err
+	}
+	s.holdForApproval(ctx, msg)
+	if err := s.saveOutgoing(ctx, msg); err != nil {
+		return err
+	}
+	if warning != "" {
+		s.notifyQuotaWarning(warning)
+	}
+	if msg.Status == models.MessageStatusPendingApproval {
+		s.notifyPendingApproval(msg)
+	}
+	return nil
+}
+
+// checkOutgoingPolicy 检查号码是否允许发送，违反策略时返回 ErrOutgoingRejected
+func (s *SerialService) checkOutgoingPolicy(ctx context.Context, to string) error {
+	var policy models.OutgoingPolicyConfig
+	if err := s.propertyService.GetValue(ctx, PropertyIDOutgoingPolicy, &policy); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		return fmt.Errorf("获取发送安全策略失败: %w", err)
+	}
+	if !policy.Enabled {
+		return nil
+	}
+
+	raw := strings.NewReplacer(" ", "", "-", "").Replace(to)
+	normalized := normalizePhone(to)
+
+	// 国际号码须匹配允许的国家/地区前缀，00 与 + 等价
+	international := strings.HasPrefix(raw, "+") || strings.HasPrefix(raw, "00")
+	if len(policy.AllowedPrefixes) > 0 && international {
+		number := "+" + strings.TrimPrefix(strings.TrimPrefix(raw, "+"), "00")
+		allowed := false
+		for _, prefix := range policy.AllowedPrefixes {
+			prefix = strings.TrimSpace(prefix)
+			if prefix == "" {
+				continue
+			}
+			prefix = "+" + strings.TrimPrefix(strings.TrimPrefix(prefix, "+"), "00")
+			if strings.HasPrefix(number, prefix) {
+				allowed = true
+				break
+			}
+		}
+		if !allowed {
+			return fmt.Errorf("%w: 号码 %s 不在允许的国家/地区前缀内", ErrOutgoingRejected, to)
+		}
+	}
+
+	for _, pattern := range policy.BlockedPatterns {
+		pattern = strings.ReplaceAll(strings.TrimSpace(pattern), " ", "")
+		if pattern == "" {
+			continue
+		}
+		matched, _ := path.Match(pattern, raw)
+		if !matched {
+			matched, _ = path.Match(pattern, normalized)
+		}
+		if matched {
+			return fmt.Errorf("%w: 号码 %s 匹配禁止发送的号码 %s", ErrOutgoingRejected, to, pattern)
+		}
+	}
+
+	if policy.MaxRecipientsPerDay > 0 {
+		now := time.Now()
+		todayStart := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+		recipients, err := s.textMsgService.FindRecipientsSince(ctx, todayStart.UnixMilli())
+		if err != nil {
+			return err
+		}
+		seen := make(map[string]bool, len(recipients))
+		for _, recipient := range recipients {
+			seen[normalizePhone(recipient)] = true
+		}
+		// 今天已发送过的号码不受数量限制
+		if !seen[normalized] && len(seen) >= policy.MaxRecipientsPerDay {
+			return fmt.Errorf("%w: 今天已向 %d 个号码发送短信，达到每日上限", ErrOutgoingRejected, len(seen))
+		}
+	}
+	return nil
+}
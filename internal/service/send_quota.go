@@ -0,0 +1,169 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+	"unicode/utf16"
+
+	"github.com/dushixiang/uart_sms_forwarder/internal/models"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// PropertyIDSendQuota 发送额度配置
+const PropertyIDSendQuota = "send_quota"
+
+// defaultQuotaWarnPercent 未配置时用量达到额度的 80% 发送提醒
+const defaultQuotaWarnPercent = 80
+
+// ErrSendQuotaExceeded 发送额度已用完
+var ErrSendQuotaExceeded = errors.New("发送额度已用完")
+
+// SendUsagePeriod 一个周期（天或月）的发送用量
+type SendUsagePeriod struct {
+	Count int     `json:"count"` // 已发送条数，长短信按拆分后的条数计算
+	Limit int     `json:"limit"` // 额度，0 表示不限制
+	Cost  float64 `json:"cost"`  // 估算费用
+}
+
+// exceeds 再发送 segments 条是否超出额度
+func (p SendUsagePeriod) exceeds(segments int) bool {
+	return p.Limit > 0 && p.Count+segments > p.Limit
+}
+
+// SendUsage 发送用量
+type SendUsage struct {
+	Enabled        bool            `json:"enabled"`
+	Day            SendUsagePeriod `json:"day"`   // 今天
+	Month          SendUsagePeriod `json:"month"` // 本月
+	CostPerMessage float64         `json:"costPerMessage"`
+	Currency       string          `json:"currency"`
+	Block          bool            `json:"block"`
+}
+
+// smsSegments 短信按运营商计费拆分后的条数：GSM 7 位编码每条 160 字符（拆分后 153），否则每条 70 字符（拆分后 67）
+func smsSegments(content string) int {
+	if septets, ok := encodeGSM7(content); ok {
+		if len(septets) <= 160 {
+			return 1
+		}
+		return len(splitSeptets(septets, 153))
+	}
+	units := utf16.Encode([]rune(content))
+	if len(units) <= 70 {
+		return 1
+	}
+	return len(splitUTF16(units, 67))
+}
+
+// estimateCost 估算费用，保留 4 位小数避免浮点误差
+func estimateCost(count int, costPerMessage float64) float64 {
+	return math.Round(float64(count)*costPerMessage*10000) / 10000
+}
+
+// getSendQuotaConfig 获取发送额度配置，未配置时返回零值
+func (s *SerialService) getSendQuotaConfig(ctx context.Context) (models.SendQuotaConfig, error) {
+	var config models.SendQuotaConfig
+	if err := s.propertyService.GetValue(ctx, PropertyIDSendQuota, &config); err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return config, fmt.Errorf("获取发送额度配置失败: %w", err)
+	}
+	return config, nil
+}
+
+// GetSendUsage 获取今天和本月的发送用量
+func (s *SerialService) GetSendUsage(ctx context.Context) (*SendUsage, error) {
+	config, err := s.getSendQuotaConfig(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return s.sendUsage(ctx, config, time.Now())
+}
+
+// sendUsage 统计 now 所在的天和月的发送用量
+func (s *SerialService) sendUsage(ctx context.Context, config models.SendQuotaConfig, now time.Time) (*SendUsage, error) {
+	dayStart := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location()).UnixMilli()
+	monthStart := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location()).UnixMilli()
+	messages, err := s.textMsgService.FindOutgoingSince(ctx, monthStart)
+	if err != nil {
+		return nil, err
+	}
+
+	usage := &SendUsage{
+		Enabled:        config.Enabled,
+		Day:            SendUsagePeriod{Limit: config.DailyLimit},
+		Month:          SendUsagePeriod{Limit: config.MonthlyLimit},
+		CostPerMessage: config.CostPerMessage,
+		Currency:       config.Currency,
+		Block:          config.Block,
+	}
+	for _, msg := range messages {
+		segments := smsSegments(msg.Content)
+		usage.Month.Count += segments
+		if msg.CreatedAt >= dayStart {
+			usage.Day.Count += segments
+		}
+	}
+	usage.Day.Cost = estimateCost(usage.Day.Count, config.CostPerMessage)
+	usage.Month.Cost = estimateCost(usage.Month.Count, config.CostPerMessage)
+	return usage, nil
+}
+
+// checkSendQuota 检查发送 content 是否超出额度。
+// 启用拦截时超出额度返回 ErrSendQuotaExceeded，否则返回发送后用量跨过提醒线或额度时的提醒内容。
+func (s *SerialService) checkSendQuota(ctx context.Context, content string) (warning string, err error) {
+	config, err := s.getSendQuotaConfig(ctx)
+	if err != nil || !config.Enabled || (config.DailyLimit <= 0 && config.MonthlyLimit <= 0) {
+		return "", err
+	}
+	usage, err := s.sendUsage(ctx, config, time.Now())
+	if err != nil {
+		return "", err
+	}
+
+	segments := smsSegments(content)
+	if config.Block {
+		if usage.Day.exceeds(segments) {
+			return "", fmt.Errorf("%w: %w，今天已发送 %d 条，每日额度 %d 条", ErrOutgoingRejected, ErrSendQuotaExceeded, usage.Day.Count, usage.Day.Limit)
+		}
+		if usage.Month.exceeds(segments) {
+			return "", fmt.Errorf("%w: %w，本月已发送 %d 条，每月额度 %d 条", ErrOutgoingRejected, ErrSendQuotaExceeded, usage.Month.Count, usage.Month.Limit)
+		}
+	}
+
+	warnPercent := config.WarnPercent
+	if warnPercent <= 0 {
+		warnPercent = defaultQuotaWarnPercent
+	}
+	for _, period := range []struct {
+		name  string
+		usage SendUsagePeriod
+	}{
+		{"今天", usage.Day},
+		{"本月", usage.Month},
+	} {
+		if period.usage.Limit <= 0 {
+			continue
+		}
+		before, after := period.usage.Count, period.usage.Count+segments
+		// 只在跨过提醒线或额度的那一条发送时提醒，每个周期最多各提醒一次
+		warnAt := (period.usage.Limit*warnPercent + 99) / 100
+		switch {
+		case before < period.usage.Limit && after >= period.usage.Limit:
+			return fmt.Sprintf("%s发送短信已达到额度：%d/%d 条，估算费用 %.2f%s", period.name, after, period.usage.Limit,
+				estimateCost(after, config.CostPerMessage), config.Currency), nil
+		case before < warnAt && after >= warnAt:
+			return fmt.Sprintf("%s发送短信已用额度的 %d%%：%d/%d 条，估算费用 %.2f%s", period.name, after*100/period.usage.Limit,
+				after, period.usage.Limit, estimateCost(after, config.CostPerMessage), config.Currency), nil
+		}
+	}
+	return "", nil
+}
+
+// notifyQuotaWarning 发送额度提醒
+func (s *SerialService) notifyQuotaWarning(warning string) {
+	s.logger.Warn("发送额度提醒", zap.String("warning", warning))
+	go s.SendEventNotification(context.Background(), EventSystem, warning)
+}
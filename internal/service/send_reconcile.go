@@ -0,0 +1,103 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/dushixiang/uart_sms_forwarder/internal/models"
+	"go.uber.org/zap"
+)
+
+const (
+	// outboxReconcileWindow 重连后对账的时间范围，更早的短信设备也不再保留结果
+	outboxReconcileWindow = time.Hour
+	// outboxReconcileLimit 单次对账查询的短信数量，与设备保留的发送结果数量一致
+	outboxReconcileLimit = 50
+)
+
+// reconcileOutbox 重连后向设备查询最近提交的短信的发送结果。
+// 断开期间设备上报的发送结果会丢失，这些短信会一直处于发送中或被超时标记为失败。
+func (d *serialDevice) reconcileOutbox() {
+	ctx := context.Background()
+	since := time.Now().Add(-outboxReconcileWindow).UnixMilli()
+	deviceIDs := []string{d.id}
+	if d.primary {
+		deviceIDs = append(deviceIDs, "")
+	}
+	messages, err := d.service.textMsgService.FindUnconfirmedOutgoing(ctx, deviceIDs, since, outboxReconcileLimit)
+	if err != nil {
+		d.logger.Error("获取待对账的短信失败", zap.Error(err))
+		return
+	}
+	if len(messages) == 0 {
+		return
+	}
+
+	requestIDs := make([]string, 0, len(messages))
+	for _, msg := range messages {
+		requestIDs = append(requestIDs, msg.ID)
+	}
+	cmd := map[string]any{
+		"action":      "query_send_results",
+		"request_ids": requestIDs,
+	}
+	if err := d.sendJSONCommand(cmd); err != nil {
+		d.logger.Warn("查询设备发送结果失败", zap.Error(err))
+		return
+	}
+	d.logger.Info("已向设备查询最近短信的发送结果", zap.Int("count", len(requestIDs)))
+}
+
+// handleSendResults 处理设备返回的发送结果，设备未保留结果的短信不会出现在列表中
+func (s *SerialService) handleSendResults(msg *ParsedMessage) {
+	results, _ := msg.Payload["results"].([]any)
+	ctx := context.Background()
+	for _, item := range results {
+		result, _ := item.(map[string]any)
+		requestID, _ := result["request_id"].(string)
+		status, _ := result["status"].(string)
+		if requestID == "" {
+			continue
+		}
+		s.reconcileSendResult(ctx, requestID, models.MessageStatus(status))
+	}
+}
+
+// reconcileSendResult 按设备记录的状态修正短信发送状态
+func (s *SerialService) reconcileSendResult(ctx context.Context, requestID string, status models.MessageStatus) {
+	switch status {
+	case models.MessageStatusSending, models.MessageStatusSent, models.MessageStatusFailed:
+	default:
+		s.logger.Warn("设备返回未知的发送状态", zap.String("request_id", requestID), zap.String("status", string(status)))
+		return
+	}
+
+	msg, err := s.textMsgService.Get(ctx, requestID)
+	if err != nil {
+		s.logger.Warn("对账的短信不存在", zap.String("request_id", requestID), zap.Error(err))
+		return
+	}
+	if msg.Type != models.MessageTypeOutgoing || msg.Status == status || msg.Status.DeliveryReported() {
+		return
+	}
+
+	s.logger.Info("对账修正短信发送状态",
+		zap.String("request_id", requestID),
+		zap.String("from", string(msg.Status)),
+		zap.String("to", string(status)))
+
+	if status == models.MessageStatusSending {
+		// 设备仍在发送，重新等待发送结果
+		s.updateSendStatus(ctx, requestID, status, sendFailure{})
+		s.awaitSendResult(requestID, msg.To)
+		return
+	}
+
+	s.sendAcks.resolve(requestID)
+	s.updateSendStatus(ctx, requestID, status, sendFailure{})
+	lastRunStatus := models.LastRunStatusSuccess
+	if status == models.MessageStatusFailed {
+		lastRunStatus = models.LastRunStatusFailed
+	}
+	s.updateScheduledTaskStatus(ctx, requestID, lastRunStatus)
+}
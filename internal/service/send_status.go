@@ -0,0 +1,118 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/dushixiang/uart_sms_forwarder/internal/models"
+	"go.uber.org/zap"
+)
+
+// 发送进度中的中间状态，只推送不入库，最终状态为 sent / failed，之后可能还会推送 delivered / delivery_failed 送达报告
+const (
+	SendStageQueued    = "queued"    // 已保存发送记录
+	SendStageSubmitted = "submitted" // 已提交给设备
+)
+
+// SendStatusEvent 短信发送进度事件
+type SendStatusEvent struct {
+	MessageID string `json:"messageId"`
+	Status    string `json:"status"`          // queued / submitted / sent / failed / delivered / delivery_failed
+	Error     string `json:"error,omitempty"` // 失败原因
+	Code      string `json:"code,omitempty"`  // 模组返回的错误码，如 CMS 21
+	Timestamp int64  `json:"timestamp"`       // 时间戳（毫秒）
+}
+
+// Final 是否为最终状态，之后不会再有事件
+func (e SendStatusEvent) Final() bool {
+	return e.Status == string(models.MessageStatusSent) || e.Status == string(models.MessageStatusFailed) ||
+		e.Status == string(models.MessageStatusRejected)
+}
+
+// sendStatusHub 按短信 ID 分发发送进度事件
+type sendStatusHub struct {
+	mu   sync.Mutex
+	subs map[string]map[chan SendStatusEvent]struct{}
+}
+
+// subscribe 订阅指定短信的进度，返回的取消函数必须调用
+func (h *sendStatusHub) subscribe(id string) (<-chan SendStatusEvent, func()) {
+	ch := make(chan SendStatusEvent, 8)
+	h.mu.Lock()
+	if h.subs == nil {
+		h.subs = make(map[string]map[chan SendStatusEvent]struct{})
+	}
+	if h.subs[id] == nil {
+		h.subs[id] = make(map[chan SendStatusEvent]struct{})
+	}
+	h.subs[id][ch] = struct{}{}
+	h.mu.Unlock()
+
+	return ch, func() {
+		h.mu.Lock()
+		defer h.mu.Unlock()
+		delete(h.subs[id], ch)
+		if len(h.subs[id]) == 0 {
+			delete(h.subs, id)
+		}
+	}
+}
+
+// publish 推送事件，订阅方处理不及时时丢弃，不阻塞发送流程
+func (h *sendStatusHub) publish(event SendStatusEvent) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for ch := range h.subs[event.MessageID] {
+		select {
+		case ch <- event:
+		default:
+		}
+	}
+}
+
+// SubscribeSendStatus 订阅短信发送进度
+func (s *SerialService) SubscribeSendStatus(id string) (<-chan SendStatusEvent, func()) {
+	return s.sendStatus.subscribe(id)
+}
+
+// GetSendStatus 获取短信当前的发送状态
+func (s *SerialService) GetSendStatus(ctx context.Context, id string) (SendStatusEvent, error) {
+	msg, err := s.textMsgService.Get(ctx, id)
+	if err != nil {
+		return SendStatusEvent{}, err
+	}
+	if msg.Type != models.MessageTypeOutgoing {
+		return SendStatusEvent{}, fmt.Errorf("不是发送的短信")
+	}
+	return SendStatusEvent{
+		MessageID: msg.ID,
+		Status:    string(msg.Status),
+		Error:     msg.FailureReason,
+		Code:      msg.FailureCode,
+		Timestamp: msg.UpdatedAt,
+	}, nil
+}
+
+// publishSendStage 推送不入库的中间进度
+func (s *SerialService) publishSendStage(id, stage string) {
+	s.sendStatus.publish(SendStatusEvent{MessageID: id, Status: stage, Timestamp: time.Now().UnixMilli()})
+}
+
+// updateSendStatus 更新短信发送状态及失败信息并推送进度
+func (s *SerialService) updateSendStatus(ctx context.Context, id string, status models.MessageStatus, failure sendFailure) {
+	s.metrics.countSendResult(status)
+	if err := s.textMsgService.UpdateSendResultById(ctx, id, status, failure.Code, failure.Reason); err != nil {
+		s.logger.Error("更新短信状态失败",
+			zap.String("request_id", id),
+			zap.Error(err))
+	}
+	s.sendStatus.publish(SendStatusEvent{
+		MessageID: id,
+		Status:    string(status),
+		Error:     failure.Reason,
+		Code:      failure.Code,
+		Timestamp: time.Now().UnixMilli(),
+	})
+}
@@ -0,0 +1,341 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+const (
+	// DefaultCapturePath 默认串口抓包文件路径
+	DefaultCapturePath = "./data/serial_capture.log"
+	// DefaultCaptureMaxSizeMB 默认抓包文件最大大小（MB）
+	DefaultCaptureMaxSizeMB = 10
+	// MaxCaptureMaxSizeMB 抓包文件大小上限（MB）
+	MaxCaptureMaxSizeMB = 100
+)
+
+// 抓包记录的数据方向
+const (
+	CaptureInbound  = "<-" // 设备 -> 本程序
+	CaptureOutbound = "->" // 本程序 -> 设备
+)
+
+// ErrCaptureEmpty 没有抓包数据
+var ErrCaptureEmpty = errors.New("没有抓包数据")
+
+// CaptureOptions 串口抓包选项
+type CaptureOptions struct {
+	Redact    bool `json:"redact"`    // 脱敏号码、短信内容和 PDU，便于附在问题反馈中
+	MaxSizeMB int  `json:"maxSizeMB"` // 单个文件最大大小（MB），超过后轮转，只保留上一个文件，默认 10
+}
+
+// CaptureStatus 串口抓包状态
+type CaptureStatus struct {
+	Enabled   bool   `json:"enabled"`
+	Redact    bool   `json:"redact"`
+	MaxSizeMB int    `json:"maxSizeMB"`
+	StartedAt int64  `json:"startedAt,omitempty"` // 开始时间（时间戳毫秒）
+	Frames    int64  `json:"frames"`              // 本次记录的帧数
+	Size      int64  `json:"size"`                // 抓包文件总大小（字节，含轮转的文件）
+	Path      string `json:"path"`
+}
+
+// SerialCapture 串口抓包，开启后将收发的每一帧原始数据按时间和方向逐行写入文件，用于排查协议问题。
+// 每行格式：2024-01-02T15:04:05.000+08:00 <- 数据，<- 为设备发来的数据，-> 为发给设备的数据。
+// 开关只保存在内存中，程序重启后关闭。
+type SerialCapture struct {
+	logger *zap.Logger
+	path   string
+
+	mu        sync.Mutex
+	file      *os.File
+	options   CaptureOptions
+	size      int64 // 当前文件大小
+	frames    int64
+	startedAt time.Time
+}
+
+// NewSerialCapture 创建串口抓包实例，path 为空时使用默认路径
+func NewSerialCapture(logger *zap.Logger, path string) *SerialCapture {
+	if path == "" {
+		path = DefaultCapturePath
+	}
+	return &SerialCapture{logger: logger, path: path}
+}
+
+// Start 开始抓包，已在抓包时按新选项继续写入
+func (c *SerialCapture) Start(ctx context.Context, options CaptureOptions) (*CaptureStatus, error) {
+	if err := requireAdmin(ctx); err != nil {
+		return nil, err
+	}
+	if options.MaxSizeMB <= 0 {
+		options.MaxSizeMB = DefaultCaptureMaxSizeMB
+	}
+	options.MaxSizeMB = min(options.MaxSizeMB, MaxCaptureMaxSizeMB)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.file == nil {
+		if err := c.openLocked(); err != nil {
+			return nil, err
+		}
+		c.frames = 0
+		c.startedAt = time.Now()
+	}
+	c.options = options
+	c.writeLocked(fmt.Sprintf("# 开始抓包 redact=%t maxSizeMB=%d", options.Redact, options.MaxSizeMB))
+	c.logger.Info("串口抓包已开启", zap.String("path", c.path), zap.Bool("redact", options.Redact))
+	return c.statusLocked(), nil
+}
+
+// Stop 停止抓包，已记录的文件保留供下载
+func (c *SerialCapture) Stop(ctx context.Context) (*CaptureStatus, error) {
+	if err := requireAdmin(ctx); err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.file != nil {
+		c.writeLocked("# 停止抓包")
+		c.closeLocked()
+		c.logger.Info("串口抓包已关闭", zap.Int64("frames", c.frames))
+	}
+	return c.statusLocked(), nil
+}
+
+// Status 获取抓包状态
+func (c *SerialCapture) Status(ctx context.Context) (*CaptureStatus, error) {
+	if err := requireAdmin(ctx); err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.statusLocked(), nil
+}
+
+// Open 打开抓包文件用于下载，轮转前的文件在前。抓包进行中也可下载已写入的部分。
+func (c *SerialCapture) Open(ctx context.Context) (io.ReadCloser, error) {
+	if err := requireAdmin(ctx); err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var files []*os.File
+	var readers []io.Reader
+	for _, path := range []string{c.rotatedPath(), c.path} {
+		file, err := os.Open(path)
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			closeAll(files)
+			return nil, fmt.Errorf("打开抓包文件失败: %w", err)
+		}
+		files = append(files, file)
+		readers = append(readers, file)
+	}
+	if len(files) == 0 {
+		return nil, ErrCaptureEmpty
+	}
+	return &multiReadCloser{Reader: io.MultiReader(readers...), files: files}, nil
+}
+
+// Clear 删除抓包文件，抓包进行中时从空文件重新开始
+func (c *SerialCapture) Clear(ctx context.Context) error {
+	if err := requireAdmin(ctx); err != nil {
+		return err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	capturing := c.file != nil
+	c.closeLocked()
+	for _, path := range []string{c.rotatedPath(), c.path} {
+		if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("删除抓包文件失败: %w", err)
+		}
+	}
+	if capturing {
+		return c.openLocked()
+	}
+	return nil
+}
+
+// Record 记录一帧数据，未开启抓包时直接返回
+func (c *SerialCapture) Record(direction, data string) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.file == nil {
+		return
+	}
+	data = strings.TrimRight(data, "\r\n")
+	if c.options.Redact {
+		data = redactFrame(data)
+	}
+	// 帧内的换行和 AT 控制字符转义，保证一帧一行
+	data = strings.NewReplacer("\r", `\r`, "\n", `\n`, "\x1a", "<Ctrl-Z>", "\x1b", "<ESC>").Replace(data)
+	c.writeLocked(direction + " " + data)
+	c.frames++
+}
+
+// writeLocked 写入一行，超过大小上限时轮转，调用方需持有锁
+func (c *SerialCapture) writeLocked(line string) {
+	line = time.Now().Format("2006-01-02T15:04:05.000Z07:00") + " " + line + "\n"
+	if c.size+int64(len(line)) > int64(c.options.MaxSizeMB)<<20 && c.size > 0 {
+		c.rotateLocked()
+		if c.file == nil {
+			return
+		}
+	}
+	n, err := c.file.WriteString(line)
+	c.size += int64(n)
+	if err != nil {
+		c.logger.Error("写入串口抓包文件失败，停止抓包", zap.Error(err))
+		c.closeLocked()
+	}
+}
+
+// rotateLocked 将当前文件改名为 .1 并新建文件，调用方需持有锁
+func (c *SerialCapture) rotateLocked() {
+	c.closeLocked()
+	if err := os.Rename(c.path, c.rotatedPath()); err != nil {
+		c.logger.Error("轮转串口抓包文件失败，停止抓包", zap.Error(err))
+		return
+	}
+	if err := c.openLocked(); err != nil {
+		c.logger.Error("创建串口抓包文件失败，停止抓包", zap.Error(err))
+	}
+}
+
+// openLocked 以追加方式打开抓包文件，调用方需持有锁
+func (c *SerialCapture) openLocked() error {
+	if err := os.MkdirAll(filepath.Dir(c.path), 0o755); err != nil {
+		return fmt.Errorf("创建抓包目录失败: %w", err)
+	}
+	file, err := os.OpenFile(c.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return fmt.Errorf("打开抓包文件失败: %w", err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return fmt.Errorf("打开抓包文件失败: %w", err)
+	}
+	c.file = file
+	c.size = info.Size()
+	return nil
+}
+
+// closeLocked 关闭抓包文件，调用方需持有锁
+func (c *SerialCapture) closeLocked() {
+	if c.file != nil {
+		c.file.Close()
+		c.file = nil
+	}
+}
+
+// statusLocked 当前抓包状态，调用方需持有锁
+func (c *SerialCapture) statusLocked() *CaptureStatus {
+	status := &CaptureStatus{
+		Enabled:   c.file != nil,
+		Redact:    c.options.Redact,
+		MaxSizeMB: c.options.MaxSizeMB,
+		Frames:    c.frames,
+		Path:      c.path,
+	}
+	if !c.startedAt.IsZero() {
+		status.StartedAt = c.startedAt.UnixMilli()
+	}
+	for _, path := range []string{c.rotatedPath(), c.path} {
+		if info, err := os.Stat(path); err == nil {
+			status.Size += info.Size()
+		}
+	}
+	return status
+}
+
+func (c *SerialCapture) rotatedPath() string {
+	return c.path + ".1"
+}
+
+// multiReadCloser 依次读取多个文件，关闭时关闭全部文件
+type multiReadCloser struct {
+	io.Reader
+	files []*os.File
+}
+
+func (m *multiReadCloser) Close() error {
+	closeAll(m.files)
+	return nil
+}
+
+func closeAll(files []*os.File) {
+	for _, file := range files {
+		file.Close()
+	}
+}
+
+var (
+	// capturePhonePattern 5 位以上的号码，保留前 3 位
+	capturePhonePattern = regexp.MustCompile(`\+?\d{3}\d{2,}`)
+	// capturePDUPattern AT 模组上报的 PDU（十六进制），包含号码和内容
+	capturePDUPattern = regexp.MustCompile(`\b[0-9A-Fa-f]{24,}\b`)
+	// captureSensitiveKeys JSON 帧中需要脱敏的字段
+	captureSensitiveKeys = []string{"from", "to", "phone", "number", "content", "content_b64", "iccid", "imsi", "imei"}
+)
+
+// redactFrame 脱敏一帧数据：JSON 帧隐藏号码、内容和 SIM 卡标识字段，其他数据隐藏 PDU 和号码
+func redactFrame(data string) string {
+	if start := strings.Index(data, "{"); start >= 0 {
+		end := strings.LastIndex(data, "}")
+		var payload map[string]any
+		if end > start && json.Unmarshal([]byte(data[start:end+1]), &payload) == nil {
+			redactPayload(payload)
+			var redacted strings.Builder
+			encoder := json.NewEncoder(&redacted)
+			encoder.SetEscapeHTML(false)
+			if err := encoder.Encode(payload); err == nil {
+				return data[:start] + strings.TrimSuffix(redacted.String(), "\n") + data[end+1:]
+			}
+		}
+	}
+	data = capturePDUPattern.ReplaceAllStringFunc(data, func(pdu string) string {
+		return fmt.Sprintf("<PDU %d 字节>", len(pdu)/2)
+	})
+	return capturePhonePattern.ReplaceAllStringFunc(data, func(number string) string {
+		prefix := 3
+		if strings.HasPrefix(number, "+") {
+			prefix = 4
+		}
+		return number[:prefix] + strings.Repeat("*", len(number)-prefix)
+	})
+}
+
+// redactPayload 递归隐藏 JSON 中的敏感字段
+func redactPayload(payload map[string]any) {
+	for key, value := range payload {
+		switch v := value.(type) {
+		case map[string]any:
+			redactPayload(v)
+		case string:
+			if v != "" && slices.ContainsFunc(captureSensitiveKeys, func(k string) bool { return strings.EqualFold(k, key) }) {
+				payload[key] = fmt.Sprintf("<已脱敏 %d 字符>", len([]rune(v)))
+			}
+		}
+	}
+}
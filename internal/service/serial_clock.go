@@ -0,0 +1,65 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+const (
+	// clockSyncInterval 校准设备时钟的间隔
+	clockSyncInterval = 24 * time.Hour
+	// clockDriftWarnThreshold 设备时钟偏差超过该值时记录警告
+	clockDriftWarnThreshold = time.Minute
+)
+
+// SyncClock 用服务器时间设置设备 RTC，避免设备上报帧中的时间戳与入库时间偏差越来越大
+func (d *serialDevice) SyncClock() {
+	cmd := map[string]any{
+		"action":    "set_time",
+		"timestamp": time.Now().Unix(),
+	}
+	if err := d.sendJSONCommand(cmd); err != nil {
+		d.logger.Error("发送校时命令失败", zap.Error(err))
+	}
+}
+
+// periodicClockSync 连接后立即校时，之后每天校时一次
+func (d *serialDevice) periodicClockSync(connCtx context.Context) {
+	defer d.wg.Done()
+	defer func() {
+		if r := recover(); r != nil {
+			d.logger.Error("定时校时 goroutine panic", zap.Any("recover", r))
+		}
+	}()
+
+	d.SyncClock()
+
+	ticker := time.NewTicker(clockSyncInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-connCtx.Done():
+			return
+		case <-ticker.C:
+			d.SyncClock()
+		}
+	}
+}
+
+// handleClockSyncResponse 记录校时前的设备时钟偏差（秒，设备时间减服务器时间）
+func (s *SerialService) handleClockSyncResponse(msg *ParsedMessage) {
+	logger := s.deviceOf(msg.DeviceID).logger
+	driftSeconds, ok := msg.Payload["drift"].(float64)
+	if !ok {
+		return
+	}
+	drift := time.Duration(driftSeconds) * time.Second
+	if drift.Abs() >= clockDriftWarnThreshold {
+		logger.Warn("设备时钟偏差较大，已校准", zap.Duration("drift", drift))
+		return
+	}
+	logger.Debug("设备时钟已校准", zap.Duration("drift", drift))
+}
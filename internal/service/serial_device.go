@@ -0,0 +1,673 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/dushixiang/uart_sms_forwarder/config"
+	"github.com/go-orz/cache"
+	"github.com/jpillora/backoff"
+	"go.bug.st/serial"
+	"go.uber.org/zap"
+)
+
+// DefaultDeviceID 未配置设备 ID 时主设备使用的 ID
+const DefaultDeviceID = "default"
+
+// ErrDeviceNotFound 指定的设备不存在
+var ErrDeviceNotFound = errors.New("设备不存在")
+
+// commonBaudRates 自动探测时依次尝试的波特率
+var commonBaudRates = []int{115200, 9600, 57600, 921600}
+
+const (
+	// probeFailureThreshold 串口连续探测失败达到该次数后暂时跳过
+	probeFailureThreshold = 3
+	// probeFailureCooldown 跳过探测的冷却时间
+	probeFailureCooldown = 10 * time.Minute
+)
+
+// probeFailure 串口探测失败记录
+type probeFailure struct {
+	count     int       // 连续失败次数
+	skipUntil time.Time // 在此时间之前跳过探测
+}
+
+// serialDevice 一个串口设备（一张 SIM 卡），拥有独立的连接循环、状态缓存和飞行模式状态
+type serialDevice struct {
+	id      string
+	primary bool // 主设备使用不带设备 ID 的属性键，与单设备时的数据兼容
+	service *SerialService
+	logger  *zap.Logger
+	config  config.SerialConfig
+	port    serial.Port
+	adapter ModemAdapter // 非 Lua 后端的设备适配器，为空时使用内置串口协议
+	wg      sync.WaitGroup
+	// 设备信息缓存
+	deviceCache cache.Cache[string, *StatusData]
+	// 连接状态管理
+	mu        sync.RWMutex
+	portName  string // 当前使用的串口名称
+	connected bool   // 连接状态
+
+	// 设备的飞行模式查询永远返回 false，无奈只能在应用层处理
+	flyMode atomic.Bool
+	// 上次设备状态是否为信号弱，只在变为信号弱时通知一次
+	lowSignal atomic.Bool
+	// 断开后重新连接的次数，everConnected 由 mu 保护
+	reconnects    atomic.Int64
+	everConnected bool
+
+	concat        concatBuffer   // 等待其余分片的长短信
+	statusUpdates statusNotifier // 等待设备状态响应的请求
+	lastStatus    statusStore    // 最后一次已知的设备状态
+
+	// 自动检测时的串口失败记录，仅在 run 主循环中访问
+	probeFailures map[string]*probeFailure
+}
+
+// newSerialDevice 创建串口设备，配置已通过 ValidateSerialConfig 校验
+func newSerialDevice(service *SerialService, cfg config.SerialConfig, primary bool) (*serialDevice, error) {
+	logger := service.logger.With(zap.String("device", cfg.ID))
+	adapter, err := newModemAdapter(logger, cfg)
+	if err != nil {
+		return nil, err
+	}
+	return &serialDevice{
+		id:            cfg.ID,
+		primary:       primary,
+		service:       service,
+		logger:        logger,
+		config:        cfg,
+		adapter:       adapter,
+		deviceCache:   cache.New[string, *StatusData](CacheTTL),
+		probeFailures: make(map[string]*probeFailure),
+		concat:        concatBuffer{logger: logger},
+	}, nil
+}
+
+// propertyKey 设备专属的属性键，主设备沿用原有的键
+func (d *serialDevice) propertyKey(id string) string {
+	if d.primary {
+		return id
+	}
+	return id + ":" + d.id
+}
+
+// label 通知中使用的设备描述，只有一台设备时不显示设备 ID
+func (d *serialDevice) label(portName string) string {
+	if len(d.service.devices) > 1 {
+		return fmt.Sprintf("%s（%s）", d.id, portName)
+	}
+	return portName
+}
+
+// emit 标记消息来源设备后交给串口服务分发
+func (d *serialDevice) emit(msg *ParsedMessage) {
+	msg.DeviceID = d.id
+	d.service.routeMessage(msg)
+}
+
+// run 设备连接主循环（使用 backoff 重连机制）
+func (d *serialDevice) run() {
+	// 启动主循环
+	b := &backoff.Backoff{
+		Min:    5 * time.Second,
+		Max:    1 * time.Minute,
+		Factor: 2,
+		Jitter: true,
+	}
+
+	for {
+		var err error
+		if d.adapter != nil {
+			err = d.runAdapter(b.Reset)
+		} else {
+			err = d.runOnce(b.Reset)
+		}
+
+		// 连接失败或断开，使用 backoff 重试
+		if err != nil {
+			d.setConnected(false)
+			retryAfter := b.Duration()
+			d.logger.Warn("串口连接异常，将重试",
+				zap.Error(err),
+				zap.Duration("retry_after", retryAfter))
+			d.deviceCache.Delete(CacheKeyDeviceStatus)
+
+			time.Sleep(retryAfter)
+		}
+	}
+}
+
+// setConnected 设置连接状态，已连接的设备断开时发送设备离线通知
+func (d *serialDevice) setConnected(connected bool) {
+	d.mu.Lock()
+	wasConnected := d.connected
+	d.connected = connected
+	portName := d.portName
+	if connected && !wasConnected {
+		if d.everConnected {
+			d.reconnects.Add(1)
+		}
+		d.everConnected = true
+	}
+	d.mu.Unlock()
+
+	if connected && !wasConnected {
+		d.service.healthcheckService.Event(true, "设备已连接: "+d.label(portName))
+	}
+	if wasConnected && !connected {
+		go d.service.SendEventNotification(context.Background(), EventDeviceOffline,
+			fmt.Sprintf("设备已断开连接: %s，正在尝试重连", d.label(portName)))
+	}
+}
+
+// setPortName 设置串口名称
+func (d *serialDevice) setPortName(portName string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.portName = portName
+}
+
+// getConnectionInfo 获取连接信息
+func (d *serialDevice) getConnectionInfo() (portName string, connected bool) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return d.portName, d.connected
+}
+
+// runOnce 执行一次连接尝试
+func (d *serialDevice) runOnce(resetBackoff func()) error {
+	// 获取串口列表
+	ports, err := serial.GetPortsList()
+	if err != nil {
+		return fmt.Errorf("获取串口列表失败: %w", err)
+	}
+
+	if len(ports) == 0 {
+		return fmt.Errorf("未发现可用串口")
+	}
+
+	d.logger.Debug("发现可用串口", zap.Strings("ports", ports))
+
+	// 确定使用的串口
+	var selectedPort string
+	var baudRate int
+	if d.config.Port != "" {
+		// 使用配置的串口
+		selectedPort = d.config.Port
+		d.logger.Info("使用配置的串口", zap.String("port", selectedPort))
+		if !slices.Contains(ports, selectedPort) {
+			// 串口名已变化（如重启后 ttyUSB0 变为 ttyUSB1），按设备身份查找
+			if port, rate := d.resolveBoundPort(); port != "" {
+				d.logger.Info("配置的串口不存在，已按设备身份找到新串口",
+					zap.String("configured", selectedPort), zap.String("port", port))
+				selectedPort, baudRate = port, rate
+			}
+		}
+		if baudRate == 0 {
+			baudRate, err = d.detectBaudRate(selectedPort)
+			if err != nil {
+				// 探测失败时仍按记住的（或默认）波特率连接，设备可能暂时没有响应
+				baudRate = d.rememberedBaudRate(selectedPort)
+				d.logger.Warn("未能探测到波特率，使用默认值", zap.String("port", selectedPort), zap.Int("baud_rate", baudRate))
+			}
+		}
+	} else {
+		// 自动检测，优先尝试上次绑定的设备
+		d.logger.Info("开始自动检测串口...")
+		selectedPort, baudRate, err = d.autoDetectPort(d.orderPortsByBinding(d.service.unclaimedPorts(d, ports)))
+		if err != nil {
+			return fmt.Errorf("自动检测串口失败: %w", err)
+		}
+		d.logger.Info("自动检测到可用串口", zap.String("port", selectedPort), zap.Int("baud_rate", baudRate))
+	}
+
+	// 连接串口
+	if err := d.connectSerial(selectedPort, baudRate); err != nil {
+		return fmt.Errorf("连接串口失败: %w", err)
+	}
+
+	// 记录设备身份，串口名变化后仍能找到同一台设备
+	d.bindDevice(selectedPort, baudRate)
+
+	// 设置连接状态和串口名称
+	d.setPortName(selectedPort)
+	d.setConnected(true)
+
+	// 重置 backoff（连接成功）
+	resetBackoff()
+
+	d.logger.Info("串口连接成功", zap.String("port", selectedPort), zap.Int("baud_rate", baudRate))
+
+	// 为本次连接创建独立的 context，用于管理连接的生命周期
+	connCtx, connCancel := context.WithCancel(context.Background())
+	defer connCancel() // 确保退出时取消 context
+
+	// 启动监听 goroutine
+	d.wg.Add(1)
+	go d.listenSerialData(connCtx, connCancel)
+
+	// 启动定时更新缓存的 goroutine
+	d.wg.Add(1)
+	go d.periodicCacheUpdate(connCtx)
+
+	// 启动定时校时的 goroutine
+	d.wg.Add(1)
+	go d.periodicClockSync(connCtx)
+
+	// 首次立即发送缓存更新请求
+	go d.RequestCacheUpdate()
+
+	// 对账断开期间提交的短信
+	go d.reconcileOutbox()
+
+	// 等待连接断开
+	d.wg.Wait()
+
+	// 连接已断开，更新状态
+	d.setConnected(false)
+
+	return nil
+}
+
+// connectSerial 连接串口
+func (d *serialDevice) connectSerial(portName string, baudRate int) error {
+	port, err := serial.Open(portName, serialMode(d.config, baudRate))
+	if err != nil {
+		return err
+	}
+
+	d.port = port
+	return nil
+}
+
+// autoDetectPort 自动检测可用串口，返回串口和探测到的波特率
+func (d *serialDevice) autoDetectPort(ports []string) (string, int, error) {
+	now := time.Now()
+	for _, portName := range ports {
+		if failure, ok := d.probeFailures[portName]; ok && now.Before(failure.skipUntil) {
+			d.logger.Debug("串口多次探测失败，暂时跳过",
+				zap.String("port", portName),
+				zap.Time("skip_until", failure.skipUntil))
+			continue
+		}
+
+		d.logger.Debug("测试串口", zap.String("port", portName))
+
+		baudRate, err := d.detectBaudRate(portName)
+		if err != nil {
+			d.recordProbeFailure(portName)
+			continue
+		}
+		delete(d.probeFailures, portName)
+		d.logger.Debug("检测到可用串口", zap.String("port", portName), zap.Int("baud_rate", baudRate))
+		return portName, baudRate, nil
+	}
+
+	return "", 0, fmt.Errorf("未检测到可用串口")
+}
+
+// recordProbeFailure 记录一次探测失败，连续失败达到阈值后在冷却时间内跳过该串口
+func (d *serialDevice) recordProbeFailure(portName string) {
+	failure, ok := d.probeFailures[portName]
+	if !ok {
+		failure = &probeFailure{}
+		d.probeFailures[portName] = failure
+	}
+	failure.count++
+	if failure.count >= probeFailureThreshold {
+		failure.count = 0
+		failure.skipUntil = time.Now().Add(probeFailureCooldown)
+		d.logger.Info("串口连续探测失败，加入冷却",
+			zap.String("port", portName),
+			zap.Duration("cooldown", probeFailureCooldown))
+	}
+}
+
+// detectBaudRate 依次尝试常用波特率（优先使用上次成功的），返回能得到有效响应的波特率
+func (d *serialDevice) detectBaudRate(portName string) (int, error) {
+	if d.config.BaudRate > 0 {
+		// 配置了波特率时只按该波特率探测设备是否响应
+		ok, err := d.probePort(portName, d.config.BaudRate)
+		if err != nil {
+			return 0, err
+		}
+		if !ok {
+			return 0, fmt.Errorf("串口 %s 在波特率 %d 下无有效响应", portName, d.config.BaudRate)
+		}
+		return d.config.BaudRate, nil
+	}
+
+	remembered := d.rememberedBaudRate(portName)
+	candidates := []int{remembered}
+	for _, rate := range commonBaudRates {
+		if rate != remembered {
+			candidates = append(candidates, rate)
+		}
+	}
+
+	for _, baudRate := range candidates {
+		ok, err := d.probePort(portName, baudRate)
+		if err != nil {
+			// 串口无法打开，换波特率也没有意义
+			d.logger.Debug("打开串口失败", zap.String("port", portName), zap.Error(err))
+			return 0, err
+		}
+		if ok {
+			if baudRate != remembered {
+				d.rememberBaudRate(portName, baudRate)
+			}
+			return baudRate, nil
+		}
+	}
+	return 0, fmt.Errorf("串口 %s 在所有波特率下均无有效响应", portName)
+}
+
+// probePort 以指定波特率打开串口并发送 get_status，检查是否收到有效响应
+func (d *serialDevice) probePort(portName string, baudRate int) (bool, error) {
+	port, err := serial.Open(portName, serialMode(d.config, baudRate))
+	if err != nil {
+		return false, err
+	}
+	defer port.Close()
+
+	// 设置读取超时
+	port.SetReadTimeout(1 * time.Second)
+
+	// 发送测试命令（使用正确的协议格式）
+	testCmd := map[string]string{"action": "get_status"}
+	jsonData, _ := json.Marshal(testCmd)
+	// 添加协议包围标志
+	message := fmt.Sprintf("CMD_START:%s:CMD_END\r\n", string(jsonData))
+
+	if _, err = port.Write([]byte(message)); err != nil {
+		return false, nil
+	}
+
+	// 等待响应
+	time.Sleep(500 * time.Millisecond)
+
+	buffer := make([]byte, 4096)
+	n, err := port.Read(buffer)
+	if err == nil && n > 0 && isValidResponse(string(buffer[:n])) {
+		return true, nil
+	}
+	return false, nil
+}
+
+// rememberedBaudRate 获取该串口上次探测成功的波特率，配置了波特率时返回配置值，没有记录时返回默认值
+func (d *serialDevice) rememberedBaudRate(portName string) int {
+	if d.config.BaudRate > 0 {
+		return d.config.BaudRate
+	}
+	rates := make(map[string]int)
+	if err := d.service.propertyService.GetValue(context.Background(), PropertyIDSerialBaudRates, &rates); err != nil {
+		return DefaultBaudRate
+	}
+	if rate, ok := rates[portName]; ok && rate > 0 {
+		return rate
+	}
+	return DefaultBaudRate
+}
+
+// rememberBaudRate 记录串口探测成功的波特率
+func (d *serialDevice) rememberBaudRate(portName string, baudRate int) {
+	ctx := context.Background()
+	rates := make(map[string]int)
+	_ = d.service.propertyService.GetValue(ctx, PropertyIDSerialBaudRates, &rates)
+	rates[portName] = baudRate
+	if err := d.service.propertyService.Set(ctx, PropertyIDSerialBaudRates, "串口波特率", rates); err != nil {
+		d.logger.Warn("保存串口波特率失败", zap.String("port", portName), zap.Error(err))
+		return
+	}
+	d.logger.Info("记住串口波特率", zap.String("port", portName), zap.Int("baud_rate", baudRate))
+}
+
+// listenSerialData 监听串口数据（在独立 goroutine 中运行）
+func (d *serialDevice) listenSerialData(connCtx context.Context, connCancel context.CancelFunc) {
+	defer d.wg.Done()
+	defer func() {
+		if r := recover(); r != nil {
+			d.logger.Error("串口监听 goroutine panic", zap.Any("recover", r))
+		}
+		// 关闭串口
+		if d.port != nil {
+			d.port.Close()
+			d.port = nil
+		}
+		// 取消连接 context，通知其他 goroutine 连接已断开
+		connCancel()
+	}()
+
+	readTimeout := time.Duration(d.config.ReadTimeout) * time.Second
+	if readTimeout <= 0 {
+		readTimeout = DefaultSerialReadTimeout * time.Second
+	}
+	// 短超时轮询读取，以便及时响应 context 取消和空闲检测
+	if err := d.port.SetReadTimeout(time.Second); err != nil {
+		d.logger.Error("设置串口读取超时失败", zap.Error(err))
+		return
+	}
+
+	lines := newLineAccumulator(d.config.MaxLineLength)
+	buffer := make([]byte, 4096)
+	lastData := time.Now()
+
+	for {
+		select {
+		case <-connCtx.Done():
+			d.logger.Info("串口监听停止")
+			return
+		default:
+			n, err := d.port.Read(buffer)
+			if err != nil {
+				if err == io.EOF {
+					// EOF 可能表示设备断开
+					d.logger.Warn("串口读取 EOF，设备可能已断开")
+					return
+				}
+				// 检查 context 是否已取消
+				if connCtx.Err() != nil {
+					return
+				}
+				// 其他错误，可能是设备断开或硬件错误
+				d.logger.Error("读取串口数据错误，退出监听", zap.Error(err))
+				return
+			}
+
+			if n == 0 {
+				// 读取超时，设备长时间无输出视为失联
+				if time.Since(lastData) > readTimeout {
+					d.logger.Warn("串口长时间无数据，设备可能已失联", zap.Duration("timeout", readTimeout))
+					return
+				}
+				continue
+			}
+			lastData = time.Now()
+
+			for _, line := range lines.feed(buffer[:n]) {
+				d.service.capture.Record(CaptureInbound, line)
+				d.processReceivedData(strings.TrimSpace(line))
+			}
+			if lines.dropped > 0 {
+				d.logger.Warn("串口数据行超过最大长度，已丢弃", zap.Int("bytes", lines.dropped))
+				lines.dropped = 0
+			}
+		}
+	}
+}
+
+// periodicCacheUpdate 定时更新缓存
+func (d *serialDevice) periodicCacheUpdate(connCtx context.Context) {
+	defer d.wg.Done()
+	defer func() {
+		if r := recover(); r != nil {
+			d.logger.Error("定时更新缓存 goroutine panic", zap.Any("recover", r))
+		}
+	}()
+
+	ticker := time.NewTicker(CacheRefreshInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-connCtx.Done():
+			d.logger.Info("停止定时更新缓存")
+			return
+		case <-ticker.C:
+			d.RequestCacheUpdate()
+		}
+	}
+}
+
+// RequestCacheUpdate 请求更新缓存（只发送命令，不等待响应）
+func (d *serialDevice) RequestCacheUpdate() {
+	d.logger.Debug("发送缓存更新请求")
+
+	// 发送获取设备状态命令（包含移动网络信息）
+	if err := d.sendJSONCommand(map[string]any{"action": "get_status"}); err != nil {
+		d.logger.Error("发送设备状态请求失败", zap.Error(err))
+	}
+}
+
+// processReceivedData 处理接收到的数据
+func (d *serialDevice) processReceivedData(data string) {
+	d.logger.Sugar().Debugf("received data: %s", data)
+	msg, err := parseSMSFrame(data)
+	if err != nil {
+		if errors.Is(err, errNotSMSFrame) {
+			return
+		}
+		if errors.Is(err, errMissingType) {
+			d.logger.Warn("消息类型缺失", zap.String("data", data))
+		} else {
+			d.logger.Error("解析串口消息失败", zap.Error(err), zap.String("data", data))
+		}
+		if d.service.deadLetterService != nil {
+			d.service.deadLetterService.Record(context.Background(), data, err)
+		}
+		return
+	}
+
+	d.emit(msg)
+}
+
+// GetStatus 获取设备状态（从缓存读取，包含 mobile 信息和串口连接状态）
+func (d *serialDevice) GetStatus() (*StatusData, error) {
+	// 获取连接信息
+	portName, connected := d.getConnectionInfo()
+
+	// 从缓存读取
+	if status, ok := d.deviceCache.Get(CacheKeyDeviceStatus); ok {
+		// 更新串口连接信息
+		status.DeviceID = d.id
+		status.PortName = portName
+		status.Connected = connected
+
+		// 更新飞行模式状态
+		status.Flymode = d.FlyMode()
+		return status, nil
+	}
+
+	// 缓存未命中时返回最后一次已知的状态，标记为旧状态
+	if status := d.lastKnownStatus(); status != nil {
+		status.DeviceID = d.id
+		status.PortName = portName
+		status.Connected = connected
+		status.Flymode = d.FlyMode()
+		status.Stale = true
+		return status, nil
+	}
+
+	// 从未收到过状态，仍然返回连接状态
+	status := &StatusData{
+		DeviceID:  d.id,
+		PortName:  portName,
+		Connected: connected,
+	}
+	return status, nil
+}
+
+// BackendName 获取设备后端名称
+func (d *serialDevice) BackendName() string {
+	if d.adapter != nil {
+		return d.adapter.Name()
+	}
+	return "lua"
+}
+
+func (d *serialDevice) FlyMode() bool {
+	// 返回当前飞行模式状态
+	return d.flyMode.Load()
+}
+
+// SetFlymode 设置飞行模式
+// enabled: true 表示启用飞行模式，false 表示禁用飞行模式
+func (d *serialDevice) SetFlymode(enabled bool) error {
+	cmd := map[string]any{
+		"action":  "set_flymode",
+		"enabled": enabled,
+	}
+	if err := d.sendJSONCommand(cmd); err != nil {
+		return err
+	}
+	// 更新飞行模式状态
+	d.flyMode.Store(enabled)
+	return nil
+}
+
+// RebootMcu 重启模块
+func (d *serialDevice) RebootMcu() error {
+	cmd := map[string]any{"action": "reboot_mcu"}
+	if err := d.sendJSONCommand(cmd); err != nil {
+		return err
+	}
+	// 重启后，飞行模式默认关闭
+	d.flyMode.Store(false)
+	return nil
+}
+
+// ResetStack 重启蜂窝协议栈，不重启模块，HiLink 和 Android 后端不支持
+func (d *serialDevice) ResetStack() error {
+	return d.sendJSONCommand(map[string]any{"action": "reset_stack"})
+}
+
+// HangupCall 挂断当前来电
+func (d *serialDevice) HangupCall() error {
+	return d.sendJSONCommand(map[string]any{"action": "hangup_call"})
+}
+
+// sendJSONCommand 发送JSON命令到设备
+func (d *serialDevice) sendJSONCommand(cmd map[string]any) error {
+	if d.adapter != nil {
+		return d.adapter.SendCommand(cmd)
+	}
+
+	if d.port == nil {
+		return fmt.Errorf("串口未连接")
+	}
+
+	message, jsonData, err := buildCommandMessage(cmd)
+	if err != nil {
+		return err
+	}
+
+	d.service.capture.Record(CaptureOutbound, string(message))
+	_, err = d.port.Write(message)
+	if err != nil {
+		return fmt.Errorf("串口写入失败: %w", err)
+	}
+	d.logger.Sugar().Debugf("send command: %s", jsonData)
+
+	return nil
+}
@@ -3,7 +3,9 @@ package service
 import (
 	"context"
 	"encoding/json"
+	"time"
 
+	"github.com/dushixiang/uart_sms_forwarder/internal/models"
 	"go.uber.org/zap"
 )
 
@@ -14,7 +16,7 @@ type IncomingCall struct {
 	Type      string `json:"type"`
 }
 
-// handleIncomingCall 处理来电通知
+// handleIncomingCall 处理来电通知，按来电规则通知、忽略或挂断
 func (s *SerialService) handleIncomingCall(msg *ParsedMessage) {
 	var call IncomingCall
 	if err := json.Unmarshal([]byte(msg.JSON), &call); err != nil {
@@ -22,9 +24,23 @@ func (s *SerialService) handleIncomingCall(msg *ParsedMessage) {
 		return
 	}
 
-	s.logger.Info("收到来电",
+	device := s.deviceOf(msg.DeviceID)
+	ctx := context.Background()
+	action, rule := s.decideCall(ctx, call.From)
+	device.logger.Info("收到来电",
 		zap.String("from", call.From),
-		zap.Int64("timestamp", call.Timestamp))
+		zap.Int64("timestamp", call.Timestamp),
+		zap.String("action", string(action)))
+
+	if action == models.CallActionHangup {
+		if err := device.HangupCall(); err != nil {
+			device.logger.Error("挂断来电失败", zap.String("from", call.From), zap.Error(err))
+		}
+	}
+	s.recordCall(ctx, &models.CallLog{From: call.From, DeviceID: msg.DeviceID, Action: action}, rule)
+	if action != models.CallActionNotify {
+		return
+	}
 
 	// 转换为通用通知消息并发送
 	notifMsg := NotificationMessage{
@@ -32,15 +48,61 @@ func (s *SerialService) handleIncomingCall(msg *ParsedMessage) {
 		From:      call.From,
 		Content:   "", // 来电无内容
 		Timestamp: call.Timestamp,
+		DeviceID:  msg.DeviceID,
 	}
 
+	s.publishCallEvent(call.From)
 	go s.sendNotificationMessage(context.Background(), notifMsg)
 }
 
+// ReceiveExternalCall 处理其他设备推送的来电，按来电规则发送来电通知，无法挂断其他设备的来电
+func (s *SerialService) ReceiveExternalCall(ctx context.Context, from string, timestamp int64, source string) {
+	action, rule := s.decideCall(ctx, from)
+	s.logger.Info("收到外部来电",
+		zap.String("source", source),
+		zap.String("from", from),
+		zap.String("action", string(action)))
+
+	s.recordCall(ctx, &models.CallLog{From: from, Source: source, Action: action}, rule)
+	if action != models.CallActionNotify {
+		return
+	}
+
+	s.publishCallEvent(from)
+	go s.sendNotificationMessage(context.WithoutCancel(ctx), NotificationMessage{
+		Type:      "call",
+		From:      from,
+		Timestamp: timestamp,
+	})
+}
+
 // handleCallDisconnected 处理通话结束通知
 func (s *SerialService) handleCallDisconnected(msg *ParsedMessage) {
 	timestamp, _ := msg.Payload["timestamp"].(float64)
 
 	s.logger.Info("通话已结束",
 		zap.Int64("timestamp", int64(timestamp)))
+
+	if s.callService != nil {
+		s.callService.End(context.Background(), msg.DeviceID, time.Now().UnixMilli())
+	}
+}
+
+// decideCall 决定来电的处理方式，未设置来电处理服务时发送通知
+func (s *SerialService) decideCall(ctx context.Context, from string) (models.CallAction, *models.CallRule) {
+	if s.callService == nil {
+		return models.CallActionNotify, nil
+	}
+	return s.callService.Decide(ctx, from)
+}
+
+// recordCall 保存来电记录
+func (s *SerialService) recordCall(ctx context.Context, log *models.CallLog, rule *models.CallRule) {
+	if s.callService == nil {
+		return
+	}
+	if rule != nil {
+		log.RuleID = rule.ID
+	}
+	s.callService.Record(ctx, log)
 }
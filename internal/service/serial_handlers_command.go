@@ -0,0 +1,226 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dushixiang/uart_sms_forwarder/internal/models"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+const (
+	// PropertyIDSMSCommand 短信指令配置
+	PropertyIDSMSCommand = "sms_command"
+	// balanceQueryTimeout 等待运营商话费回复的时间
+	balanceQueryTimeout = 10 * time.Minute
+)
+
+// operatorBalanceQuery 运营商话费查询号码和指令，按运营商名称匹配
+var operatorBalanceQuery = []struct {
+	keyword string
+	number  string
+	command string
+}{
+	{"移动", "10086", "CXYE"},
+	{"联通", "10010", "YE"},
+	{"电信", "10001", "102"},
+}
+
+// balanceQueries 等待运营商回复的话费查询：设备和运营商号码 -> 请求者
+type balanceQueries struct {
+	mu      sync.Mutex
+	pending map[balanceQueryKey]balanceQuery
+}
+
+// balanceQueryKey 话费查询按设备区分，运营商回复到达发出查询的 SIM 卡
+type balanceQueryKey struct {
+	deviceID       string
+	operatorNumber string
+}
+
+type balanceQuery struct {
+	requester string
+	expiresAt time.Time
+}
+
+// add 记录话费查询
+func (q *balanceQueries) add(deviceID, operatorNumber, requester string) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.pending == nil {
+		q.pending = make(map[balanceQueryKey]balanceQuery)
+	}
+	q.pending[balanceQueryKey{deviceID, operatorNumber}] = balanceQuery{requester: requester, expiresAt: time.Now().Add(balanceQueryTimeout)}
+}
+
+// take 取出设备上运营商号码对应的未过期查询
+func (q *balanceQueries) take(deviceID, operatorNumber string) (string, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	key := balanceQueryKey{deviceID, operatorNumber}
+	query, ok := q.pending[key]
+	if !ok {
+		return "", false
+	}
+	delete(q.pending, key)
+	if time.Now().After(query.expiresAt) {
+		return "", false
+	}
+	return query.requester, true
+}
+
+// getSMSCommandConfig 获取短信指令配置，未配置时返回禁用状态
+func (s *SerialService) getSMSCommandConfig(ctx context.Context) (models.SMSCommandConfig, error) {
+	var config models.SMSCommandConfig
+	if err := s.propertyService.GetValue(ctx, PropertyIDSMSCommand, &config); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return config, nil
+		}
+		return config, err
+	}
+	return config, nil
+}
+
+// normalizePhone 统一号码格式用于比较：去掉空格、横线和 +86 / 86 前缀
+func normalizePhone(number string) string {
+	number = strings.NewReplacer(" ", "", "-", "").Replace(number)
+	number = strings.TrimPrefix(number, "+")
+	if len(number) == 13 && strings.HasPrefix(number, "86") {
+		number = number[2:]
+	}
+	return number
+}
+
+// forwardBalanceReply 运营商回复话费查询时转发给请求者
+func (s *SerialService) forwardBalanceReply(deviceID, from, content string) {
+	requester, ok := s.balanceQueries.take(deviceID, normalizePhone(from))
+	if !ok {
+		return
+	}
+	s.replySMS(deviceID, requester, content)
+}
+
+// handleSMSCommand 处理白名单号码发来的指令，返回 true 表示短信是指令（不再发送通知）。
+// 指令和回复都通过收到指令的设备执行
+func (s *SerialService) handleSMSCommand(ctx context.Context, deviceID, from, content string) bool {
+	config, err := s.getSMSCommandConfig(ctx)
+	if err != nil {
+		s.logger.Error("获取短信指令配置失败", zap.Error(err))
+		return false
+	}
+	if !config.Enabled {
+		return false
+	}
+
+	sender := normalizePhone(from)
+	allowed := slices.ContainsFunc(config.AllowedNumbers, func(n string) bool {
+		return normalizePhone(n) == sender
+	})
+	if !allowed {
+		return false
+	}
+
+	text := strings.TrimSpace(content)
+	if config.Pin != "" {
+		rest, ok := strings.CutPrefix(text, config.Pin)
+		if !ok || (rest != "" && rest[0] != ' ') {
+			return false
+		}
+		text = strings.TrimSpace(rest)
+	}
+
+	fields := strings.Fields(text)
+	if len(fields) == 0 {
+		return false
+	}
+
+	command := strings.ToUpper(fields[0])
+	switch command {
+	case "STATUS", "状态":
+		s.replySMS(deviceID, from, s.statusReply(ctx, deviceID))
+	case "BALANCE", "话费":
+		s.queryBalance(deviceID, from, config)
+	case "SEND", "发送":
+		// SEND <号码> <内容>，内容保留原始空白
+		if len(fields) < 3 {
+			s.replySMS(deviceID, from, "格式错误，应为：SEND 号码 内容")
+			break
+		}
+		rest := strings.TrimSpace(text[len(fields[0]):])
+		to := fields[1]
+		body := strings.TrimSpace(rest[len(to):])
+		if _, err := s.SendSMS(deviceID, to, body); err != nil {
+			s.replySMS(deviceID, from, fmt.Sprintf("发送到 %s 失败：%v", to, err))
+		} else {
+			s.replySMS(deviceID, from, fmt.Sprintf("已提交发送到 %s", to))
+		}
+	case "HELP", "帮助":
+		s.replySMS(deviceID, from, "可用指令：\nSTATUS 设备状态\nBALANCE 话费查询\nSEND 号码 内容 发送短信")
+	default:
+		return false
+	}
+
+	s.logger.Info("已执行短信指令", zap.String("from", from), zap.String("command", command))
+	return true
+}
+
+// statusReply 生成设备状态回复
+func (s *SerialService) statusReply(ctx context.Context, deviceID string) string {
+	var b strings.Builder
+	status, _ := s.GetStatus(deviceID)
+	if status != nil {
+		mobile := status.Mobile
+		fmt.Fprintf(&b, "运营商：%s\n", mobile.Operator)
+		fmt.Fprintf(&b, "信号：%s（CSQ %d，RSRP %d）\n", mobile.SignalDesc, mobile.Csq, mobile.Rsrp)
+		fmt.Fprintf(&b, "注册：%t，漫游：%t\n", mobile.IsRegistered, mobile.IsRoaming)
+		fmt.Fprintf(&b, "飞行模式：%t\n", status.Flymode)
+	}
+	if stats, err := s.textMsgService.GetStats(ctx); err == nil {
+		fmt.Fprintf(&b, "今日短信：%d，总计：%d", stats.TodayCount, stats.TotalCount)
+	}
+	return strings.TrimSpace(b.String())
+}
+
+// queryBalance 向运营商发送话费查询，回复到达后转发给请求者
+func (s *SerialService) queryBalance(deviceID, requester string, config models.SMSCommandConfig) {
+	number, command := config.BalanceNumber, config.BalanceCommand
+	if number == "" || command == "" {
+		operator := ""
+		if status, _ := s.GetStatus(deviceID); status != nil {
+			operator = status.Mobile.Operator
+		}
+		for _, q := range operatorBalanceQuery {
+			if strings.Contains(operator, q.keyword) {
+				if number == "" {
+					number = q.number
+				}
+				if command == "" {
+					command = q.command
+				}
+				break
+			}
+		}
+	}
+	if number == "" || command == "" {
+		s.replySMS(deviceID, requester, "无法识别运营商，请在短信指令配置中设置话费查询号码和指令")
+		return
+	}
+
+	s.balanceQueries.add(deviceID, normalizePhone(number), requester)
+	if _, err := s.SendSMS(deviceID, number, command); err != nil {
+		s.replySMS(deviceID, requester, fmt.Sprintf("话费查询发送失败：%v", err))
+	}
+}
+
+// replySMS 通过收到指令的设备回复指令结果
+func (s *SerialService) replySMS(deviceID, to, content string) {
+	if _, err := s.SendSMS(deviceID, to, content); err != nil {
+		s.logger.Error("回复短信指令失败", zap.String("to", to), zap.Error(err))
+	}
+}
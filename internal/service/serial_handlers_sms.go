@@ -17,6 +17,10 @@ type IncomingSMS struct {
 	From      string `json:"from"`
 	Content   string `json:"content"`
 	Type      string `json:"type"`
+	// 长短信分片信息，设备未合并长短信时上报，ConcatTotal 大于 1 时为分片
+	ConcatRef   int `json:"concat_ref,omitempty"`
+	ConcatTotal int `json:"concat_total,omitempty"`
+	ConcatSeq   int `json:"concat_seq,omitempty"`
 }
 
 func (r IncomingSMS) String() string {
@@ -41,13 +45,105 @@ func (s *SerialService) handleIncomingSMS(msg *ParsedMessage) {
 		return
 	}
 
-	s.logger.Info("收到新短信",
+	device := s.deviceOf(msg.DeviceID)
+	device.logger.Info("收到新短信",
 		zap.String("from", sms.From),
 		zap.String("content", sms.Content),
 		zap.Int64("timestamp", sms.Timestamp))
 
+	// 长短信分片收齐后再按一条短信处理
+	if sms.ConcatTotal > 1 {
+		device.concat.add(sms, time.Now().UnixMilli(), s.concatTimeout(), func(sms IncomingSMS, receivedAt int64) {
+			s.receiveSMS(context.Background(), device.id, sms, "", receivedAt)
+		})
+		return
+	}
+	s.receiveSMS(context.Background(), device.id, sms, "", time.Now().UnixMilli())
+}
+
+// ReceiveExternalSMS 处理其他设备（如 Android 手机上的转发 App）推送过来的短信，
+// 与本机收到的短信走相同的脚本、分类、保存和通知流程。
+// source 记录短信来源，receivedAt 为对方设备收到短信的时间（时间戳毫秒）。
+func (s *SerialService) ReceiveExternalSMS(ctx context.Context, sms IncomingSMS, source string, receivedAt int64) {
+	s.logger.Info("收到外部短信",
+		zap.String("source", source),
+		zap.String("from", sms.From),
+		zap.String("content", sms.Content))
+
+	s.receiveSMS(context.WithoutCancel(ctx), "", sms, source, receivedAt)
+}
+
+// receiveSMS 收到短信后的处理流程，source 为空表示本机 deviceID 设备收到的短信
+func (s *SerialService) receiveSMS(ctx context.Context, deviceID string, sms IncomingSMS, source string, receivedAt int64) {
+	s.metrics.smsReceived.Add(1)
+
+	// 执行短信处理脚本
+	processed := ScriptMessage{
+		From:      sms.From,
+		Content:   sms.Content,
+		Timestamp: sms.Timestamp,
+		Notify:    true,
+	}
+	if s.scriptService != nil {
+		processed = s.scriptService.Process(ctx, processed)
+	}
+	// 交给外部插件处理
+	if s.pluginService != nil && !processed.Drop {
+		processed = s.pluginService.Process(ctx, processed)
+	}
+	if processed.Drop {
+		s.logger.Info("短信已被丢弃", zap.String("from", sms.From))
+		return
+	}
+	sms.From = processed.From
+	sms.Content = processed.Content
+
+	// 按号码分类，脚本未指定渠道时使用会话设置或规则的通知路由，会话设置优先
+	var category string
+	var mute bool
+	var channels []string
+	var priority models.NotificationPriority
+	var escalation *models.NumberRule
+	if s.numberRuleService != nil {
+		if rule := s.numberRuleService.Match(ctx, sms.From); rule != nil {
+			category = rule.Category
+			mute = rule.Mute
+			channels = rule.Channels
+			priority = rule.Priority
+			if len(rule.Escalation) > 0 {
+				escalation = rule
+			}
+		}
+	}
+	if s.conversationSettingService != nil {
+		if setting := s.conversationSettingService.Match(ctx, sms.From); setting != nil {
+			mute = setting.Mute
+			channels = setting.Channels
+			if setting.Priority != "" {
+				priority = setting.Priority
+			}
+		}
+	}
+	if mute {
+		processed.Notify = false
+	}
+	if len(processed.Channels) == 0 {
+		processed.Channels = channels
+	}
+
+	// 按规则提取结构化字段
+	var fields map[string]string
+	if s.extractionService != nil {
+		fields = s.extractionService.Extract(ctx, sms.From, sms.Content)
+	}
+
+	// 垃圾短信识别
+	var spam SpamResult
+	if s.spamService != nil {
+		spam = s.spamService.Classify(ctx, sms.From, sms.Content)
+	}
+
 	// 保存短信记录
-	ctx := context.Background()
 	record := &models.TextMessage{
 		ID:        uuid.NewString(),
 		From:      sms.From,
@@ -55,72 +151,72 @@ func (s *SerialService) handleIncomingSMS(msg *ParsedMessage) {
 		Content:   sms.Content,
 		Type:      models.MessageTypeIncoming,
 		Status:    models.MessageStatusReceived,
-		CreatedAt: time.Now().UnixMilli(),
+		Tags:      processed.Tags,
+		Fields:    fields,
+		Spam:      spam.Spam,
+		SpamScore: spam.Score,
+		Category:  category,
+		Source:    source,
+		DeviceID:  deviceID,
+		CreatedAt: receivedAt,
 	}
 
 	if err := s.textMsgService.Save(ctx, record); err != nil {
 		s.logger.Error("保存短信记录失败", zap.Error(err))
+		s.spoolMessage(record)
+	} else {
+		s.afterMessageSaved(ctx, record)
 	}
 
-	// 异步发送通知
-	go s.sendNotification(ctx, sms)
-}
+	// 话费查询和短信指令只处理本机收到的短信，回复需要从同一张 SIM 卡发出
+	if source == "" {
+		// 运营商回复话费查询时转发给指令发送者
+		s.forwardBalanceReply(deviceID, sms.From, sms.Content)
+		// 白名单号码发来的指令直接执行并回复，不再通知
+		if s.handleSMSCommand(ctx, deviceID, sms.From, sms.Content) {
+			return
+		}
+	}
+
+	// 垃圾短信保存到垃圾箱，不发送通知
+	if spam.Spam {
+		s.logger.Info("短信判定为垃圾短信",
+			zap.String("from", sms.From),
+			zap.String("reason", spam.Reason),
+			zap.Float64("score", spam.Score))
+		return
+	}
+
+	if !processed.Notify {
+		return
+	}
 
-// sendNotification 发送通知
-func (s *SerialService) sendNotification(ctx context.Context, sms IncomingSMS) {
-	// 转换为通用通知消息
-	msg := NotificationMessage{
+	// 异步发送通知，channels 不为空时只发送到指定类型的渠道
+	notification := NotificationMessage{
+		ID:        record.ID,
 		Type:      "sms",
 		From:      sms.From,
 		Content:   sms.Content,
 		Timestamp: sms.Timestamp,
+		Fields:    fields,
+		Priority:  priority,
+		DeviceID:  deviceID,
+		Channels:  processed.Channels,
 	}
+	go func() {
+		// 外语短信附加译文，翻译需要访问外部服务，放在发送通知的协程中
+		s.sendNotificationMessage(ctx, s.translateNotification(ctx, notification))
+	}()
 
-	s.sendNotificationMessage(ctx, msg)
-}
-
-// sendNotificationMessage 发送通用通知消息
-func (s *SerialService) sendNotificationMessage(ctx context.Context, msg NotificationMessage) {
-	// 获取通知渠道配置
-	channels, err := s.propertyService.GetNotificationChannelConfigs(ctx)
-	if err != nil {
-		s.logger.Error("获取通知渠道配置失败", zap.Error(err))
-		return
+	// 高优先级短信未及时确认时按升级链改发其他渠道
+	if escalation != nil {
+		s.scheduleEscalation(notification, escalation.Escalation, escalateAfter(escalation.EscalateAfter))
 	}
+}
 
-	// 格式化消息
-	message := msg.String()
-
-	// 发送到所有启用的渠道
-	for _, channel := range channels {
-		if !channel.Enabled {
-			continue
-		}
-
-		var sendErr error
-		switch channel.Type {
-		case "dingtalk":
-			sendErr = s.notifier.SendDingTalkByConfig(ctx, channel.Config, message)
-		case "wecom":
-			sendErr = s.notifier.SendWeComByConfig(ctx, channel.Config, message)
-		case "feishu":
-			sendErr = s.notifier.SendFeishuByConfig(ctx, channel.Config, message)
-		case "webhook":
-			sendErr = s.notifier.SendWebhookByConfig(ctx, channel.Config, msg)
-		case "email":
-			sendErr = s.notifier.SendEmail(ctx, channel.Config, msg)
-		case "telegram":
-			sendErr = s.notifier.sendTelegramByConfig(ctx, channel.Config, message)
-		}
-
-		if sendErr != nil {
-			s.logger.Error("发送通知失败",
-				zap.String("type", channel.Type),
-				zap.Error(sendErr))
-		} else {
-			s.logger.Info("通知发送成功", zap.String("type", channel.Type))
-		}
-	}
+// SendSystemNotification 以转发器自身的名义推送系统通知（发送失败、存储告警等）
+func (s *SerialService) SendSystemNotification(ctx context.Context, content string) {
+	s.SendEventNotification(ctx, EventSystem, content)
 }
 
 // handleSMSSendResult 处理短信发送结果
@@ -134,9 +230,15 @@ func (s *SerialService) handleSMSSendResult(msg *ParsedMessage) {
 		return
 	}
 
+	if !s.sendAcks.resolve(requestID) {
+		// 超时后才到达的结果以设备为准，覆盖超时标记的失败状态
+		s.logger.Warn("收到未在等待中的短信发送结果", zap.String("request_id", requestID))
+	}
+
 	ctx := context.Background()
 	var status models.MessageStatus
 	var lastRunStatus models.LastRunStatus
+	var failure sendFailure
 	if success {
 		status = models.MessageStatusSent
 		lastRunStatus = models.LastRunStatusSuccess
@@ -146,24 +248,77 @@ func (s *SerialService) handleSMSSendResult(msg *ParsedMessage) {
 	} else {
 		status = models.MessageStatusFailed
 		lastRunStatus = models.LastRunStatusFailed
+		errText, _ := msg.Payload["error"].(string)
+		failure = parseSendFailure(msg.Payload["code"], errText)
 		s.logger.Warn("短信发送失败",
 			zap.String("to", to),
-			zap.String("request_id", requestID))
-		go s.sendNotificationMessage(context.Background(), NotificationMessage{
-			Type:      "sms",
-			From:      "UART 短信转发器",
-			Content:   fmt.Sprintf("短信发送失败: %s", to),
-			Timestamp: time.Now().Unix(),
-		})
+			zap.String("request_id", requestID),
+			zap.String("code", failure.Code),
+			zap.String("reason", failure.Reason))
+		notice := fmt.Sprintf("短信发送失败: %s", to)
+		if failure.Reason != "" {
+			notice += "，" + failure.Reason
+			if failure.Code != "" {
+				notice += "（" + failure.Code + "）"
+			}
+		}
+		go s.SendSystemNotification(context.Background(), notice)
+	}
+
+	s.updateSendStatus(ctx, requestID, status, failure)
+
+	s.updateScheduledTaskStatus(ctx, requestID, lastRunStatus)
+}
+
+// handleDeliveryReport 处理运营商的送达报告，按 request_id 更新发送短信的状态
+func (s *SerialService) handleDeliveryReport(msg *ParsedMessage) {
+	requestID, _ := msg.Payload["request_id"].(string)
+	delivered, _ := msg.Payload["delivered"].(bool)
+	if requestID == "" {
+		s.logger.Warn("收到送达报告但缺少 request_id", zap.Any("msg", msg.Payload))
+		return
+	}
+
+	ctx := context.Background()
+	sms, err := s.textMsgService.Get(ctx, requestID)
+	if err != nil {
+		s.logger.Warn("送达报告对应的短信不存在", zap.String("request_id", requestID), zap.Error(err))
+		return
+	}
+	if sms.Type != models.MessageTypeOutgoing {
+		return
 	}
 
-	if err := s.textMsgService.UpdateStatusById(ctx, requestID, status); err != nil {
-		s.logger.Error("更新短信状态失败",
+	var failure sendFailure
+	status := models.MessageStatusDelivered
+	if delivered {
+		s.logger.Info("短信已送达", zap.String("to", sms.To), zap.String("request_id", requestID))
+	} else {
+		status = models.MessageStatusDeliveryFailed
+		errText, _ := msg.Payload["error"].(string)
+		failure = parseSendFailure(msg.Payload["code"], errText)
+		if failure.Reason == "" {
+			failure.Reason = "运营商报告短信未能送达"
+		}
+		s.logger.Warn("短信未能送达",
+			zap.String("to", sms.To),
 			zap.String("request_id", requestID),
-			zap.Error(err))
+			zap.String("code", failure.Code),
+			zap.String("reason", failure.Reason))
+		go s.SendSystemNotification(context.Background(), fmt.Sprintf("短信未能送达: %s，%s", sms.To, failure.Reason))
 	}
 
-	s.updateScheduledTaskStatus(ctx, requestID, lastRunStatus)
+	if err := s.textMsgService.UpdateDeliveryById(ctx, requestID, delivered, failure.Code, failure.Reason); err != nil {
+		s.logger.Error("更新短信送达状态失败", zap.String("request_id", requestID), zap.Error(err))
+		return
+	}
+	s.sendStatus.publish(SendStatusEvent{
+		MessageID: requestID,
+		Status:    string(status),
+		Error:     failure.Reason,
+		Code:      failure.Code,
+		Timestamp: time.Now().UnixMilli(),
+	})
 }
 
 func (s *SerialService) updateScheduledTaskStatus(ctx context.Context, msgID string, status models.LastRunStatus) {
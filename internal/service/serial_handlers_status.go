@@ -1,11 +1,48 @@
 package service
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
 
 	"go.uber.org/zap"
 )
 
+// statusRefreshTimeout 强制刷新时等待设备状态响应的时间
+const statusRefreshTimeout = 10 * time.Second
+
+// ErrStatusRefreshTimeout 等待设备状态响应超时
+var ErrStatusRefreshTimeout = errors.New("等待设备状态响应超时")
+
+// statusNotifier 通知等待下一次设备状态响应的请求，每次响应后关闭当前通道并换新
+type statusNotifier struct {
+	mu      sync.Mutex
+	updated chan struct{}
+}
+
+// next 返回在下一次状态响应时关闭的通道
+func (n *statusNotifier) next() <-chan struct{} {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if n.updated == nil {
+		n.updated = make(chan struct{})
+	}
+	return n.updated
+}
+
+// notify 唤醒所有等待者
+func (n *statusNotifier) notify() {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if n.updated != nil {
+		close(n.updated)
+	}
+	n.updated = make(chan struct{})
+}
+
 type StatusData struct {
 	Flymode bool   `json:"flymode"` // 设备当前是否为飞行模式
 	Type    string `json:"type"`    // 消息类型
@@ -28,8 +65,11 @@ type StatusData struct {
 	} `json:"mobile"`
 	Timestamp int    `json:"timestamp"`
 	MemKb     int    `json:"mem_kb"`
-	PortName  string `json:"port_name"` // 串口名称
-	Connected bool   `json:"connected"` // 连接状态
+	DeviceID  string `json:"device_id"`  // 设备 ID
+	PortName  string `json:"port_name"`  // 串口名称
+	Connected bool   `json:"connected"`  // 连接状态
+	UpdatedAt int64  `json:"updated_at"` // 收到该状态的时间（时间戳毫秒）
+	Stale     bool   `json:"stale"`      // 是否为服务重启或断开前保存的旧状态
 }
 
 func (s *SerialService) handleStatusResponse(msg *ParsedMessage) {
@@ -48,13 +88,59 @@ func (s *SerialService) handleStatusResponse(msg *ParsedMessage) {
 			return plmn
 		}()
 	}
-	s.deviceCache.Set(CacheKeyDeviceStatus, &statusData, CacheTTL)
-	s.logger.Debug("设备状态缓存已更新")
+	statusData.UpdatedAt = time.Now().UnixMilli()
+	device := s.deviceOf(msg.DeviceID)
+	statusData.DeviceID = device.id
+	device.deviceCache.Set(CacheKeyDeviceStatus, &statusData, CacheTTL)
+	device.persistStatus(&statusData)
+	device.bindICCID(statusData.Mobile.Iccid)
+	device.checkSignal(&statusData)
+	device.statusUpdates.notify()
+	device.logger.Debug("设备状态缓存已更新")
+}
+
+// checkSignal 信号变为弱或无信号时发送通知，恢复后才会再次通知。
+// 只按设备上报的信号描述判断，未上报信号描述的后端不通知
+func (d *serialDevice) checkSignal(status *StatusData) {
+	desc := status.Mobile.SignalDesc
+	low := !status.Flymode && (desc == "弱" || desc == "无信号")
+	if d.lowSignal.Swap(low) || !low {
+		return
+	}
+
+	d.logger.Warn("设备信号弱", zap.String("signal", desc), zap.Int("csq", status.Mobile.Csq))
+	portName, _ := d.getConnectionInfo()
+	go d.service.SendEventNotification(context.Background(), EventLowSignal,
+		fmt.Sprintf("设备信号弱: %s（CSQ %d，%s），短信可能无法及时收发", desc, status.Mobile.Csq, d.label(portName)))
+}
+
+// RefreshStatus 立即向设备查询状态并等待响应，不使用缓存
+func (d *serialDevice) RefreshStatus(ctx context.Context) (*StatusData, error) {
+	if _, connected := d.getConnectionInfo(); !connected {
+		return nil, fmt.Errorf("设备未连接")
+	}
+
+	// 先取通道再发送命令，避免响应在等待前到达
+	updated := d.statusUpdates.next()
+	if err := d.sendJSONCommand(map[string]any{"action": "get_status"}); err != nil {
+		return nil, fmt.Errorf("发送设备状态请求失败: %w", err)
+	}
+
+	timer := time.NewTimer(statusRefreshTimeout)
+	defer timer.Stop()
+	select {
+	case <-updated:
+		return d.GetStatus()
+	case <-timer.C:
+		return nil, ErrStatusRefreshTimeout
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
 }
 
 func (s *SerialService) handleSystemReady(msg *ParsedMessage) {
 	if message, ok := msg.Payload["message"].(string); ok {
-		s.logger.Info("系统就绪", zap.String("message", message))
+		s.deviceOf(msg.DeviceID).logger.Info("系统就绪", zap.String("message", message))
 	}
 }
 
@@ -63,39 +149,45 @@ func (s *SerialService) handleHeartbeat(msg *ParsedMessage) {
 	memoryUsage, _ := msg.Payload["memory_usage"].(float64)
 	bufferSize, _ := msg.Payload["buffer_size"].(float64)
 
-	s.logger.Debug("设备心跳",
+	s.deviceOf(msg.DeviceID).logger.Debug("设备心跳",
 		zap.Int64("timestamp", int64(timestamp)),
 		zap.Float64("memory_usage", memoryUsage),
 		zap.Int("buffer_size", int(bufferSize)))
 }
 
 func (s *SerialService) handleCellularControlResponse(msg *ParsedMessage) {
-	s.logger.Debug("收到蜂窝网络控制响应", zap.Any("data", msg.Payload))
+	s.deviceOf(msg.DeviceID).logger.Debug("收到蜂窝网络控制响应", zap.Any("data", msg.Payload))
 }
 
 func (s *SerialService) handlePhoneNumberResponse(msg *ParsedMessage) {
-	s.logger.Debug("收到电话号码响应", zap.Any("data", msg.Payload))
+	s.deviceOf(msg.DeviceID).logger.Debug("收到电话号码响应", zap.Any("data", msg.Payload))
 }
 
 func (s *SerialService) handleCommandResponse(msg *ParsedMessage) {
-	if action, ok := msg.Payload["action"].(string); ok {
-		s.logger.Info("命令响应", zap.String("action", action), zap.Any("result", msg.Payload["result"]))
+	action, ok := msg.Payload["action"].(string)
+	if !ok {
+		return
+	}
+	if action == "set_time" {
+		s.handleClockSyncResponse(msg)
+		return
 	}
+	s.deviceOf(msg.DeviceID).logger.Info("命令响应", zap.String("action", action), zap.Any("result", msg.Payload["result"]))
 }
 
 func (s *SerialService) handleSIMEvent(msg *ParsedMessage) {
 	status, _ := msg.Payload["status"].(string)
-	s.logger.Info("SIM卡事件", zap.String("status", status))
+	s.deviceOf(msg.DeviceID).logger.Info("SIM卡事件", zap.String("status", status))
 }
 
 func (s *SerialService) handleWarningMessage(msg *ParsedMessage) {
 	if warnMsg, ok := msg.Payload["msg"].(string); ok {
-		s.logger.Warn("设备警告", zap.String("message", warnMsg))
+		s.deviceOf(msg.DeviceID).logger.Warn("设备警告", zap.String("message", warnMsg))
 	}
 }
 
 func (s *SerialService) handleErrorMessage(msg *ParsedMessage) {
 	if errMsg, ok := msg.Payload["msg"].(string); ok {
-		s.logger.Error("设备错误", zap.String("message", errMsg))
+		s.deviceOf(msg.DeviceID).logger.Error("设备错误", zap.String("message", errMsg))
 	}
 }
@@ -0,0 +1,189 @@
+package service
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// PropertyIDSerialDeviceBinding 上次连接成功的设备身份
+const PropertyIDSerialDeviceBinding = "serial_device_binding"
+
+// DeviceBinding 设备身份绑定。
+// 串口名在重启或重新插拔后可能变化（/dev/ttyUSB0 → /dev/ttyUSB1），
+// 通过 USB VID/PID/序列号和 SIM 卡 ICCID 识别同一台设备。
+type DeviceBinding struct {
+	Port         string `json:"port"`         // 上次使用的串口名
+	BaudRate     int    `json:"baudRate"`     // 上次使用的波特率
+	VID          string `json:"vid"`          // USB 厂商 ID
+	PID          string `json:"pid"`          // USB 产品 ID
+	SerialNumber string `json:"serialNumber"` // USB 序列号
+	Product      string `json:"product"`      // USB 产品描述
+	ICCID        string `json:"iccid"`        // SIM 卡 ICCID，握手后由状态响应填充
+	UpdatedAt    int64  `json:"updatedAt"`    // 更新时间（时间戳毫秒）
+}
+
+// portDetails 串口的 USB 信息
+type portDetails struct {
+	Name         string
+	IsUSB        bool
+	VID          string
+	PID          string
+	SerialNumber string
+	Product      string
+}
+
+// matches 判断串口是否为绑定的 USB 设备
+func (b *DeviceBinding) matches(details *portDetails) bool {
+	if b.VID == "" || !details.IsUSB {
+		return false
+	}
+	if !strings.EqualFold(b.VID, details.VID) || !strings.EqualFold(b.PID, details.PID) {
+		return false
+	}
+	// 没有序列号的设备只能按 VID/PID 匹配
+	return b.SerialNumber == "" || b.SerialNumber == details.SerialNumber
+}
+
+// loadDeviceBinding 读取设备绑定，不存在时返回 nil
+func (d *serialDevice) loadDeviceBinding() *DeviceBinding {
+	var binding DeviceBinding
+	if err := d.service.propertyService.GetValue(context.Background(), d.propertyKey(PropertyIDSerialDeviceBinding), &binding); err != nil {
+		return nil
+	}
+	if binding.Port == "" && binding.VID == "" {
+		return nil
+	}
+	return &binding
+}
+
+// saveDeviceBinding 保存设备绑定
+func (d *serialDevice) saveDeviceBinding(binding *DeviceBinding) {
+	binding.UpdatedAt = time.Now().UnixMilli()
+	if err := d.service.propertyService.Set(context.Background(), d.propertyKey(PropertyIDSerialDeviceBinding), "串口设备绑定", binding); err != nil {
+		d.logger.Warn("保存设备绑定失败", zap.Error(err))
+	}
+}
+
+// bindDevice 连接成功后记录串口对应的 USB 身份，保留已知的 ICCID
+func (d *serialDevice) bindDevice(portName string, baudRate int) {
+	binding := d.loadDeviceBinding()
+	if binding == nil {
+		binding = &DeviceBinding{}
+	}
+
+	details := findPortDetails(portName)
+	if details != nil && details.IsUSB {
+		if binding.VID != "" && !binding.matches(details) {
+			// 换了一台设备，之前的 SIM 信息不再适用
+			binding.ICCID = ""
+		}
+		binding.VID = details.VID
+		binding.PID = details.PID
+		binding.SerialNumber = details.SerialNumber
+		binding.Product = details.Product
+	}
+
+	if binding.Port != portName && binding.Port != "" {
+		d.logger.Info("设备串口已变化", zap.String("old_port", binding.Port), zap.String("new_port", portName))
+	}
+	binding.Port = portName
+	binding.BaudRate = baudRate
+	d.saveDeviceBinding(binding)
+}
+
+// bindICCID 握手得到 ICCID 后写入绑定，SIM 卡变化时记录日志
+func (d *serialDevice) bindICCID(iccid string) {
+	if iccid == "" || d.adapter != nil {
+		return
+	}
+	binding := d.loadDeviceBinding()
+	if binding == nil || binding.ICCID == iccid {
+		return
+	}
+	if binding.ICCID != "" {
+		d.logger.Warn("SIM 卡已更换", zap.String("old_iccid", binding.ICCID), zap.String("new_iccid", iccid))
+	}
+	binding.ICCID = iccid
+	d.saveDeviceBinding(binding)
+}
+
+// orderPortsByBinding 将绑定设备对应的串口排在最前，上次使用的串口名优先
+func (d *serialDevice) orderPortsByBinding(ports []string) []string {
+	binding := d.loadDeviceBinding()
+	if binding == nil {
+		return ports
+	}
+
+	matched := make(map[string]bool)
+	if binding.VID != "" {
+		if list, err := listPortDetails(); err == nil {
+			for _, details := range list {
+				if binding.matches(details) {
+					matched[details.Name] = true
+				}
+			}
+		}
+	}
+
+	rank := func(port string) int {
+		switch {
+		case matched[port] && port == binding.Port:
+			return 0
+		case matched[port]:
+			return 1
+		case port == binding.Port && binding.VID == "":
+			// 非 USB 设备只能依赖串口名
+			return 2
+		default:
+			return 3
+		}
+	}
+
+	ordered := slices.Clone(ports)
+	slices.SortStableFunc(ordered, func(a, b string) int {
+		return rank(a) - rank(b)
+	})
+	return ordered
+}
+
+// resolveBoundPort 配置的串口不存在时，查找绑定设备当前的串口名和波特率
+func (d *serialDevice) resolveBoundPort() (string, int) {
+	binding := d.loadDeviceBinding()
+	if binding == nil || binding.VID == "" {
+		return "", 0
+	}
+	list, err := listPortDetails()
+	if err != nil {
+		return "", 0
+	}
+	var candidates []string
+	for _, details := range list {
+		if binding.matches(details) {
+			candidates = append(candidates, details.Name)
+		}
+	}
+	for _, port := range d.orderPortsByBinding(candidates) {
+		if baudRate, err := d.detectBaudRate(port); err == nil {
+			return port, baudRate
+		}
+	}
+	return "", 0
+}
+
+// findPortDetails 获取串口的 USB 信息，不支持时返回 nil
+func findPortDetails(portName string) *portDetails {
+	list, err := listPortDetails()
+	if err != nil {
+		return nil
+	}
+	for _, details := range list {
+		if details.Name == portName {
+			return details
+		}
+	}
+	return nil
+}
@@ -0,0 +1,67 @@
+package service
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/dushixiang/uart_sms_forwarder/config"
+	"go.bug.st/serial"
+)
+
+const (
+	// DefaultDataBits 默认数据位
+	DefaultDataBits = 8
+)
+
+// serialParities 配置中的校验方式
+var serialParities = map[string]serial.Parity{
+	"":      serial.NoParity,
+	"none":  serial.NoParity,
+	"odd":   serial.OddParity,
+	"even":  serial.EvenParity,
+	"mark":  serial.MarkParity,
+	"space": serial.SpaceParity,
+}
+
+// serialStopBits 配置中的停止位
+var serialStopBits = map[float64]serial.StopBits{
+	0:   serial.OneStopBit,
+	1:   serial.OneStopBit,
+	1.5: serial.OnePointFiveStopBits,
+	2:   serial.TwoStopBits,
+}
+
+// ValidateSerialConfig 校验串口参数配置
+func ValidateSerialConfig(cfg config.SerialConfig) error {
+	if cfg.BaudRate < 0 {
+		return fmt.Errorf("波特率无效: %d", cfg.BaudRate)
+	}
+	if cfg.DataBits != 0 && !slices.Contains([]int{5, 6, 7, 8}, cfg.DataBits) {
+		return fmt.Errorf("数据位无效: %d，可选 5、6、7、8", cfg.DataBits)
+	}
+	if _, ok := serialStopBits[cfg.StopBits]; !ok {
+		return fmt.Errorf("停止位无效: %v，可选 1、1.5、2", cfg.StopBits)
+	}
+	if _, ok := serialParities[strings.ToLower(cfg.Parity)]; !ok {
+		return fmt.Errorf("校验方式无效: %s，可选 none、odd、even、mark、space", cfg.Parity)
+	}
+	if cfg.ReadTimeout < 0 {
+		return fmt.Errorf("空闲超时无效: %d", cfg.ReadTimeout)
+	}
+	return nil
+}
+
+// serialMode 按配置的数据位、停止位和校验方式生成串口参数，配置已通过 ValidateSerialConfig 校验
+func serialMode(cfg config.SerialConfig, baudRate int) *serial.Mode {
+	dataBits := cfg.DataBits
+	if dataBits == 0 {
+		dataBits = DefaultDataBits
+	}
+	return &serial.Mode{
+		BaudRate: baudRate,
+		DataBits: dataBits,
+		StopBits: serialStopBits[cfg.StopBits],
+		Parity:   serialParities[strings.ToLower(cfg.Parity)],
+	}
+}
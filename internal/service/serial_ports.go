@@ -0,0 +1,25 @@
+//go:build !darwin || cgo
+
+package service
+
+import "go.bug.st/serial/enumerator"
+
+// listPortDetails 获取串口列表及 USB 信息
+func listPortDetails() ([]*portDetails, error) {
+	list, err := enumerator.GetDetailedPortsList()
+	if err != nil {
+		return nil, err
+	}
+	result := make([]*portDetails, 0, len(list))
+	for _, d := range list {
+		result = append(result, &portDetails{
+			Name:         d.Name,
+			IsUSB:        d.IsUSB,
+			VID:          d.VID,
+			PID:          d.PID,
+			SerialNumber: d.SerialNumber,
+			Product:      d.Product,
+		})
+	}
+	return result, nil
+}
@@ -0,0 +1,10 @@
+//go:build darwin && !cgo
+
+package service
+
+import "fmt"
+
+// listPortDetails macOS 读取 USB 信息依赖 IOKit（cgo），未启用 cgo 时不支持设备绑定
+func listPortDetails() ([]*portDetails, error) {
+	return nil, fmt.Errorf("未启用 cgo，无法获取 USB 设备信息")
+}
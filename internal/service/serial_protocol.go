@@ -1,15 +1,23 @@
 package service
 
 import (
+	"encoding/base64"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"strings"
+	"unicode/utf8"
 )
 
 const (
 	smsPrefix = "SMS_START:"
 	smsSuffix = ":SMS_END"
+	cmdPrefix = "CMD_START:"
+	cmdSuffix = ":CMD_END"
+
+	// base64Suffix 以该后缀结尾的字段为 base64 编码，解析时还原为去掉后缀的字段，
+	// 用于传输含控制字符、非 UTF-8 或帧标志的内容
+	base64Suffix = "_b64"
 )
 
 var (
@@ -18,9 +26,10 @@ var (
 )
 
 type ParsedMessage struct {
-	JSON    string
-	Type    string
-	Payload map[string]interface{}
+	JSON     string
+	Type     string
+	Payload  map[string]interface{}
+	DeviceID string // 上报该消息的设备
 }
 
 func parseSMSFrame(data string) (*ParsedMessage, error) {
@@ -33,6 +42,9 @@ func parseSMSFrame(data string) (*ParsedMessage, error) {
 	if err := json.Unmarshal([]byte(jsonData), &payload); err != nil {
 		return nil, fmt.Errorf("JSON解析失败: %w", err)
 	}
+	if err := decodeBase64Fields(payload); err != nil {
+		return nil, err
+	}
 
 	msgType, ok := payload["type"].(string)
 	if !ok || msgType == "" {
@@ -46,13 +58,88 @@ func parseSMSFrame(data string) (*ParsedMessage, error) {
 	}, nil
 }
 
+// newParsedMessage 由 payload 构造消息帧，用于非 Lua 后端上报事件
+func newParsedMessage(payload map[string]any) (*ParsedMessage, error) {
+	msgType, ok := payload["type"].(string)
+	if !ok || msgType == "" {
+		return nil, errMissingType
+	}
+
+	jsonData, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("JSON编码失败: %w", err)
+	}
+
+	// 与串口帧保持一致：数字统一为 float64
+	normalized := make(map[string]interface{})
+	if err := json.Unmarshal(jsonData, &normalized); err != nil {
+		return nil, fmt.Errorf("JSON解析失败: %w", err)
+	}
+
+	return &ParsedMessage{
+		JSON:    string(jsonData),
+		Type:    msgType,
+		Payload: normalized,
+	}, nil
+}
+
+// decodeBase64Fields 将 xxx_b64 字段解码为 xxx 字段
+func decodeBase64Fields(payload map[string]interface{}) error {
+	for key, value := range payload {
+		if !strings.HasSuffix(key, base64Suffix) {
+			continue
+		}
+		encoded, ok := value.(string)
+		if !ok {
+			continue
+		}
+		decoded, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return fmt.Errorf("字段 %s base64 解码失败: %w", key, err)
+		}
+		// 无法表示为 UTF-8 的字节替换为 U+FFFD，保证入库和通知时内容合法
+		payload[strings.TrimSuffix(key, base64Suffix)] = strings.ToValidUTF8(string(decoded), "\uFFFD")
+		delete(payload, key)
+	}
+	return nil
+}
+
+// needsBase64 判断内容是否无法安全地以 JSON 文本经行协议传输
+func needsBase64(s string) bool {
+	if !utf8.ValidString(s) {
+		return true
+	}
+	if strings.Contains(s, cmdPrefix) || strings.Contains(s, cmdSuffix) {
+		return true
+	}
+	for _, r := range s {
+		if r < 0x20 && r != '\n' && r != '\r' && r != '\t' {
+			return true
+		}
+	}
+	return false
+}
+
 func buildCommandMessage(cmd any) ([]byte, string, error) {
+	// content 含特殊字符时改用 content_b64 传输，避免破坏帧结构
+	if m, ok := cmd.(map[string]any); ok {
+		if content, ok := m["content"].(string); ok && needsBase64(content) {
+			encoded := make(map[string]any, len(m))
+			for k, v := range m {
+				encoded[k] = v
+			}
+			delete(encoded, "content")
+			encoded["content"+base64Suffix] = base64.StdEncoding.EncodeToString([]byte(content))
+			cmd = encoded
+		}
+	}
+
 	jsonData, err := json.Marshal(cmd)
 	if err != nil {
 		return nil, "", fmt.Errorf("JSON编码失败: %w", err)
 	}
 
-	message := fmt.Sprintf("CMD_START:%s:CMD_END\r\n", string(jsonData))
+	message := fmt.Sprintf("%s%s%s\r\n", cmdPrefix, string(jsonData), cmdSuffix)
 	return []byte(message), string(jsonData), nil
 }
 
@@ -0,0 +1,63 @@
+package service
+
+import "bytes"
+
+const (
+	// DefaultSerialReadTimeout 默认空闲超时（秒），超过该时间未收到任何数据视为设备失联
+	DefaultSerialReadTimeout = 120
+	// DefaultSerialMaxLineLength 默认单行最大长度（字节）
+	DefaultSerialMaxLineLength = 64 * 1024
+)
+
+// lineAccumulator 将串口数据按行切分，限制单行最大长度。
+// 设备持续输出无换行的二进制噪声时，超长部分直接丢弃，直到下一个换行符为止。
+type lineAccumulator struct {
+	maxLen     int
+	buf        []byte
+	discarding bool
+	// dropped 因超长被丢弃的字节数，由调用方读取后清零
+	dropped int
+}
+
+func newLineAccumulator(maxLen int) *lineAccumulator {
+	if maxLen <= 0 {
+		maxLen = DefaultSerialMaxLineLength
+	}
+	return &lineAccumulator{maxLen: maxLen}
+}
+
+// feed 追加数据并返回已完整的行（不含换行符）
+func (a *lineAccumulator) feed(data []byte) []string {
+	var lines []string
+	for len(data) > 0 {
+		idx := bytes.IndexByte(data, '\n')
+		if idx < 0 {
+			a.append(data)
+			break
+		}
+
+		a.append(data[:idx])
+		if a.discarding {
+			a.discarding = false
+		} else {
+			lines = append(lines, string(a.buf))
+		}
+		a.buf = a.buf[:0]
+		data = data[idx+1:]
+	}
+	return lines
+}
+
+func (a *lineAccumulator) append(data []byte) {
+	if a.discarding {
+		a.dropped += len(data)
+		return
+	}
+	if len(a.buf)+len(data) > a.maxLen {
+		a.dropped += len(a.buf) + len(data)
+		a.buf = a.buf[:0]
+		a.discarding = true
+		return
+	}
+	a.buf = append(a.buf, data...)
+}
@@ -14,6 +14,9 @@ func (s *SerialService) initMessageHandlers() {
 		"phone_number_response":     s.handlePhoneNumberResponse,
 		"cmd_response":              s.handleCommandResponse,
 		"sms_send_result":           s.handleSMSSendResult,
+		"send_results":              s.handleSendResults,
+		"sms_delivery_report":       s.handleDeliveryReport,
+		"ussd_response":             s.handleUSSDResponse,
 		"sim_event":                 s.handleSIMEvent,
 		"warning":                   s.handleWarningMessage,
 		"error":                     s.handleErrorMessage,
@@ -1,23 +1,15 @@
 package service
 
 import (
-	"bufio"
 	"context"
-	"encoding/json"
-	"errors"
 	"fmt"
-	"io"
-	"strings"
+	"slices"
 	"sync"
-	"sync/atomic"
 	"time"
 
 	"github.com/dushixiang/uart_sms_forwarder/config"
 	"github.com/dushixiang/uart_sms_forwarder/internal/models"
-	"github.com/go-orz/cache"
 	"github.com/google/uuid"
-	"github.com/jpillora/backoff"
-	"go.bug.st/serial"
 	"go.uber.org/zap"
 )
 
@@ -28,33 +20,48 @@ const (
 	CacheRefreshInterval = 10 * time.Second
 	// 缓存过期时间
 	CacheTTL = 5 * time.Minute
+	// DefaultBaudRate 默认波特率
+	DefaultBaudRate = 115200
 )
 
 type ScheduledTaskStatusUpdater func(ctx context.Context, msgID string, status models.LastRunStatus) error
 
-// SerialService 串口管理服务
+// SerialService 串口管理服务，管理一个或多个串口设备，按设备 ID 路由消息和发送短信
 type SerialService struct {
 	logger                     *zap.Logger
 	config                     config.SerialConfig
-	port                       serial.Port
+	devices                    []*serialDevice // 配置的设备，第一个为主设备
 	textMsgService             *TextMessageService
 	notifier                   *Notifier
 	propertyService            *PropertyService
 	handlers                   map[string]messageHandler
 	scheduledTaskStatusUpdater ScheduledTaskStatusUpdater
-	wg                         sync.WaitGroup
-	// 设备信息缓存
-	deviceCache cache.Cache[string, *StatusData]
-	// 连接状态管理
-	mu        sync.RWMutex
-	portName  string // 当前使用的串口名称
-	connected bool   // 连接状态
-
-	// 设备的飞行模式查询永远返回 false，无奈只能在应用层处理
-	flyMode atomic.Bool
+	scriptService              *ScriptService
+	pluginService              *PluginService
+	extractionService          *ExtractionService
+	transactionService         *TransactionService
+	spamService                *SpamService
+	numberRuleService          *NumberRuleService
+	callService                *CallService
+	conversationSettingService *ConversationSettingService
+	deadLetterService          *DeadLetterService
+	notificationLogService     *NotificationLogService
+	healthcheckService         *HealthcheckService
+	spool                      *MessageSpool
+	capture                    *SerialCapture
+	balanceQueries             balanceQueries    // 短信指令发起的话费查询
+	sendStatus                 sendStatusHub     // 短信发送进度订阅
+	messageEvents              messageEventHub   // 新消息订阅
+	sendAcks                   sendAckTracker    // 等待设备返回发送结果的短信
+	ussdRequests               ussdRequests      // 等待设备返回结果的 USSD 请求
+	idempotencyMu              sync.Mutex        // 串行化带幂等键的发送请求
+	policyMu                   sync.Mutex        // 串行化发送安全策略检查和发送记录保存
+	escalations                sendAckTracker    // 等待确认的升级通知
+	escalated                  escalatedChannels // 已发送过升级提醒的渠道
+	metrics                    metricsCounters   // Prometheus 指标计数
 }
 
-// NewSerialService 创建串口服务实例
+// NewSerialService 创建串口服务实例，config 为主设备，config.Devices 为其他设备
 func NewSerialService(
 	logger *zap.Logger,
 	config config.SerialConfig,
@@ -68,317 +75,326 @@ func NewSerialService(
 		textMsgService:  textMsgService,
 		notifier:        notifier,
 		propertyService: propertyService,
-		deviceCache:     cache.New[string, *StatusData](CacheTTL),
+	}
+	configs, err := deviceConfigs(config)
+	if err != nil {
+		logger.Fatal("串口参数配置错误", zap.Error(err))
+	}
+	for i, cfg := range configs {
+		device, err := newSerialDevice(service, cfg, i == 0)
+		if err != nil {
+			logger.Fatal("初始化设备后端失败", zap.String("device", cfg.ID), zap.Error(err))
+		}
+		service.devices = append(service.devices, device)
 	}
 	service.initMessageHandlers()
 	return service
 }
 
-func (s *SerialService) SetScheduledTaskStatusUpdater(updater ScheduledTaskStatusUpdater) {
-	s.scheduledTaskStatusUpdater = updater
+// deviceConfigs 展开主设备和其他设备的配置并校验，设备 ID 不能重复
+func deviceConfigs(cfg config.SerialConfig) ([]config.SerialConfig, error) {
+	primary := cfg
+	primary.Devices = nil
+	if primary.ID == "" {
+		primary.ID = DefaultDeviceID
+	}
+	configs := append([]config.SerialConfig{primary}, cfg.Devices...)
+
+	seen := make(map[string]bool)
+	for _, c := range configs {
+		if c.ID == "" {
+			return nil, fmt.Errorf("其他设备必须配置 ID")
+		}
+		if seen[c.ID] {
+			return nil, fmt.Errorf("设备 ID 重复: %s", c.ID)
+		}
+		seen[c.ID] = true
+		if len(c.Devices) > 0 {
+			return nil, fmt.Errorf("设备 %s 不能再嵌套配置 Devices", c.ID)
+		}
+		if err := ValidateSerialConfig(c); err != nil {
+			return nil, fmt.Errorf("设备 %s: %w", c.ID, err)
+		}
+	}
+	return configs, nil
 }
 
-// Start 启动串口服务（使用 backoff 重连机制）
+// Start 启动所有设备的连接循环（使用 backoff 重连机制），阻塞运行
 func (s *SerialService) Start() {
-
-	// 启动主循环
-	b := &backoff.Backoff{
-		Min:    5 * time.Second,
-		Max:    1 * time.Minute,
-		Factor: 2,
-		Jitter: true,
+	for _, device := range s.devices[1:] {
+		go device.run()
 	}
+	s.devices[0].run()
+}
 
-	for {
-		err := s.runOnce(b.Reset)
-
-		// 连接失败或断开，使用 backoff 重试
-		if err != nil {
-			s.setConnected(false)
-			retryAfter := b.Duration()
-			s.logger.Warn("串口连接异常，将重试",
-				zap.Error(err),
-				zap.Duration("retry_after", retryAfter))
-			s.deviceCache.Delete(CacheKeyDeviceStatus)
-
-			time.Sleep(retryAfter)
+// device 按 ID 查找设备，ID 为空时返回主设备
+func (s *SerialService) device(id string) (*serialDevice, error) {
+	if id == "" {
+		return s.devices[0], nil
+	}
+	for _, device := range s.devices {
+		if device.id == id {
+			return device, nil
 		}
 	}
+	return nil, fmt.Errorf("%w: %s", ErrDeviceNotFound, id)
 }
 
-// setConnected 设置连接状态
-func (s *SerialService) setConnected(connected bool) {
-	s.mu.Lock()
-	defer s.mu.Unlock()
-	s.connected = connected
+// deviceOf 获取消息来源设备，未知的设备 ID 按主设备处理
+func (s *SerialService) deviceOf(id string) *serialDevice {
+	if device, err := s.device(id); err == nil {
+		return device
+	}
+	return s.devices[0]
 }
 
-// setPortName 设置串口名称
-func (s *SerialService) setPortName(portName string) {
-	s.mu.Lock()
-	defer s.mu.Unlock()
-	s.portName = portName
+// DeviceIDs 获取所有设备的 ID，第一个为主设备
+func (s *SerialService) DeviceIDs() []string {
+	ids := make([]string, 0, len(s.devices))
+	for _, device := range s.devices {
+		ids = append(ids, device.id)
+	}
+	return ids
 }
 
-// getConnectionInfo 获取连接信息
-func (s *SerialService) getConnectionInfo() (portName string, connected bool) {
-	s.mu.RLock()
-	defer s.mu.RUnlock()
-	return s.portName, s.connected
+// unclaimedPorts 过滤掉其他设备配置或正在使用的串口，避免自动检测抢占其他设备
+func (s *SerialService) unclaimedPorts(self *serialDevice, ports []string) []string {
+	claimed := make(map[string]bool)
+	for _, device := range s.devices {
+		if device == self || device.adapter != nil {
+			continue
+		}
+		if device.config.Port != "" {
+			claimed[device.config.Port] = true
+		}
+		if portName, connected := device.getConnectionInfo(); connected {
+			claimed[portName] = true
+		}
+	}
+	return slices.DeleteFunc(slices.Clone(ports), func(port string) bool {
+		return claimed[port]
+	})
 }
 
-// runOnce 执行一次连接尝试
-func (s *SerialService) runOnce(resetBackoff func()) error {
-	// 获取串口列表
-	ports, err := serial.GetPortsList()
+// connected 设备是否已连接，设备不存在时返回 false
+func (s *SerialService) connected(deviceID string) bool {
+	device, err := s.device(deviceID)
 	if err != nil {
-		return fmt.Errorf("获取串口列表失败: %w", err)
+		return false
 	}
+	_, connected := device.getConnectionInfo()
+	return connected
+}
 
-	if len(ports) == 0 {
-		return fmt.Errorf("未发现可用串口")
+// GetStatus 获取设备状态（从缓存读取，包含 mobile 信息和串口连接状态），deviceID 为空时为主设备
+func (s *SerialService) GetStatus(deviceID string) (*StatusData, error) {
+	device, err := s.device(deviceID)
+	if err != nil {
+		return nil, err
 	}
+	return device.GetStatus()
+}
 
-	s.logger.Debug("发现可用串口", zap.Strings("ports", ports))
-
-	// 确定使用的串口
-	var selectedPort string
-	if s.config.Port != "" {
-		// 使用配置的串口
-		selectedPort = s.config.Port
-		s.logger.Info("使用配置的串口", zap.String("port", selectedPort))
-	} else {
-		// 自动检测
-		s.logger.Info("开始自动检测串口...")
-		selectedPort, err = s.autoDetectPort(ports)
-		if err != nil {
-			return fmt.Errorf("自动检测串口失败: %w", err)
-		}
-		s.logger.Info("自动检测到可用串口", zap.String("port", selectedPort))
+// GetAllStatus 获取所有设备的状态，第一个为主设备
+func (s *SerialService) GetAllStatus() []*StatusData {
+	statuses := make([]*StatusData, 0, len(s.devices))
+	for _, device := range s.devices {
+		status, _ := device.GetStatus()
+		statuses = append(statuses, status)
 	}
+	return statuses
+}
 
-	// 连接串口
-	if err := s.connectSerial(selectedPort); err != nil {
-		return fmt.Errorf("连接串口失败: %w", err)
+// RefreshStatus 立即向设备查询状态并等待响应，不使用缓存
+func (s *SerialService) RefreshStatus(ctx context.Context, deviceID string) (*StatusData, error) {
+	device, err := s.device(deviceID)
+	if err != nil {
+		return nil, err
 	}
+	return device.RefreshStatus(ctx)
+}
 
-	// 设置连接状态和串口名称
-	s.setPortName(selectedPort)
-	s.setConnected(true)
-
-	// 重置 backoff（连接成功）
-	resetBackoff()
-
-	s.logger.Info("串口连接成功", zap.String("port", selectedPort))
-
-	// 为本次连接创建独立的 context，用于管理连接的生命周期
-	connCtx, connCancel := context.WithCancel(context.Background())
-	defer connCancel() // 确保退出时取消 context
-
-	// 启动监听 goroutine
-	s.wg.Add(1)
-	go s.listenSerialData(connCtx, connCancel)
-
-	// 启动定时更新缓存的 goroutine
-	s.wg.Add(1)
-	go s.periodicCacheUpdate(connCtx)
-
-	// 首次立即发送缓存更新请求
-	go s.RequestCacheUpdate()
-
-	// 等待连接断开
-	s.wg.Wait()
+// RequestCacheUpdate 请求更新设备状态缓存（只发送命令，不等待响应）
+func (s *SerialService) RequestCacheUpdate(deviceID string) {
+	if device, err := s.device(deviceID); err == nil {
+		device.RequestCacheUpdate()
+	}
+}
 
-	// 连接已断开，更新状态
-	s.setConnected(false)
+// BackendName 获取设备后端名称
+func (s *SerialService) BackendName(deviceID string) string {
+	device, err := s.device(deviceID)
+	if err != nil {
+		return ""
+	}
+	return device.BackendName()
+}
 
-	return nil
+// FlyMode 设备当前是否为飞行模式
+func (s *SerialService) FlyMode(deviceID string) bool {
+	device, err := s.device(deviceID)
+	if err != nil {
+		return false
+	}
+	return device.FlyMode()
 }
 
-// connectSerial 连接串口
-func (s *SerialService) connectSerial(portName string) error {
-	mode := &serial.Mode{
-		BaudRate: 115200,
-		DataBits: 8,
-		StopBits: serial.OneStopBit,
-		Parity:   serial.NoParity,
+// SetFlymode 设置飞行模式
+// enabled: true 表示启用飞行模式，false 表示禁用飞行模式
+func (s *SerialService) SetFlymode(deviceID string, enabled bool) error {
+	device, err := s.device(deviceID)
+	if err != nil {
+		return err
 	}
+	return device.SetFlymode(enabled)
+}
 
-	port, err := serial.Open(portName, mode)
+// ResetStack 重启蜂窝协议栈
+func (s *SerialService) ResetStack(deviceID string) error {
+	device, err := s.device(deviceID)
 	if err != nil {
 		return err
 	}
+	return device.ResetStack()
+}
 
-	s.port = port
-	return nil
+// RebootMcu 重启模块
+func (s *SerialService) RebootMcu(deviceID string) error {
+	device, err := s.device(deviceID)
+	if err != nil {
+		return err
+	}
+	return device.RebootMcu()
 }
 
-// autoDetectPort 自动检测可用串口
-func (s *SerialService) autoDetectPort(ports []string) (string, error) {
-	for _, portName := range ports {
-		s.logger.Debug("测试串口", zap.String("port", portName))
+func (s *SerialService) SetScheduledTaskStatusUpdater(updater ScheduledTaskStatusUpdater) {
+	s.scheduledTaskStatusUpdater = updater
+}
 
-		mode := &serial.Mode{
-			BaudRate: 115200,
-			DataBits: 8,
-			StopBits: serial.OneStopBit,
-			Parity:   serial.NoParity,
-		}
+// SetScriptService 设置短信处理脚本服务
+func (s *SerialService) SetScriptService(scriptService *ScriptService) {
+	s.scriptService = scriptService
+}
 
-		port, err := serial.Open(portName, mode)
-		if err != nil {
-			s.logger.Debug("打开串口失败", zap.String("port", portName), zap.Error(err))
-			continue
-		}
+// SetExtractionService 设置字段提取服务
+func (s *SerialService) SetExtractionService(extractionService *ExtractionService) {
+	s.extractionService = extractionService
+}
 
-		// 设置读取超时
-		port.SetReadTimeout(1 * time.Second)
+// SetTransactionService 设置银行交易服务
+func (s *SerialService) SetTransactionService(transactionService *TransactionService) {
+	s.transactionService = transactionService
+}
 
-		// 发送测试命令（使用正确的协议格式）
-		testCmd := map[string]string{"action": "get_status"}
-		jsonData, _ := json.Marshal(testCmd)
-		// 添加协议包围标志
-		message := fmt.Sprintf("CMD_START:%s:CMD_END\r\n", string(jsonData))
+// SetSpamService 设置垃圾短信识别服务
+func (s *SerialService) SetSpamService(spamService *SpamService) {
+	s.spamService = spamService
+}
 
-		_, err = port.Write([]byte(message))
-		if err != nil {
-			port.Close()
-			continue
-		}
+// SetNumberRuleService 设置号码分类规则服务
+func (s *SerialService) SetNumberRuleService(numberRuleService *NumberRuleService) {
+	s.numberRuleService = numberRuleService
+}
 
-		// 等待响应
-		time.Sleep(500 * time.Millisecond)
+// SetCallService 设置来电处理服务，设置后来电按规则处理并保存来电记录
+func (s *SerialService) SetCallService(callService *CallService) {
+	s.callService = callService
+}
 
-		buffer := make([]byte, 4096)
-		n, err := port.Read(buffer)
-		port.Close()
+// SetConversationSettingService 设置会话设置服务
+func (s *SerialService) SetConversationSettingService(conversationSettingService *ConversationSettingService) {
+	s.conversationSettingService = conversationSettingService
+}
 
-		if err == nil && n > 0 {
-			response := string(buffer[:n])
-			if isValidResponse(response) {
-				s.logger.Debug("检测到可用串口", zap.String("port", portName))
-				return portName, nil
-			}
-		}
-	}
+// SetNotificationLogService 设置通知发送记录服务，设置后每次发送尝试都会被记录
+func (s *SerialService) SetNotificationLogService(notificationLogService *NotificationLogService) {
+	s.notificationLogService = notificationLogService
+}
 
-	return "", fmt.Errorf("未检测到可用串口")
+// SetMessageSpool 设置短信暂存，设置后数据库不可写时收到的短信暂存到本地文件
+func (s *SerialService) SetMessageSpool(spool *MessageSpool) {
+	s.spool = spool
 }
 
-// listenSerialData 监听串口数据（在独立 goroutine 中运行）
-func (s *SerialService) listenSerialData(connCtx context.Context, connCancel context.CancelFunc) {
-	defer s.wg.Done()
-	defer func() {
-		if r := recover(); r != nil {
-			s.logger.Error("串口监听 goroutine panic", zap.Any("recover", r))
-		}
-		// 关闭串口
-		if s.port != nil {
-			s.port.Close()
-			s.port = nil
-		}
-		// 取消连接 context，通知其他 goroutine 连接已断开
-		connCancel()
-	}()
-
-	reader := bufio.NewReader(s.port)
-
-	for {
-		select {
-		case <-connCtx.Done():
-			s.logger.Info("串口监听停止")
-			return
-		default:
-			line, err := reader.ReadString('\n')
-			if err != nil {
-				if err == io.EOF {
-					// EOF 可能表示设备断开
-					s.logger.Warn("串口读取 EOF，设备可能已断开")
-					return
-				}
-				// 检查 context 是否已取消
-				if connCtx.Err() != nil {
-					return
-				}
-				// 其他错误，可能是设备断开或硬件错误
-				s.logger.Error("读取串口数据错误，退出监听", zap.Error(err))
-				return
-			}
-
-			s.processReceivedData(strings.TrimSpace(line))
+// SetSerialCapture 设置串口抓包，非 Lua 后端的串口适配器（如 AT）同样记录收发的数据
+func (s *SerialService) SetSerialCapture(capture *SerialCapture) {
+	s.capture = capture
+	for _, device := range s.devices {
+		if adapter, ok := device.adapter.(interface{ setCapture(*SerialCapture) }); ok {
+			adapter.setCapture(capture)
 		}
 	}
 }
 
-// periodicCacheUpdate 定时更新缓存
-func (s *SerialService) periodicCacheUpdate(connCtx context.Context) {
-	defer s.wg.Done()
-	defer func() {
-		if r := recover(); r != nil {
-			s.logger.Error("定时更新缓存 goroutine panic", zap.Any("recover", r))
-		}
-	}()
-
-	ticker := time.NewTicker(CacheRefreshInterval)
-	defer ticker.Stop()
-
-	for {
-		select {
-		case <-connCtx.Done():
-			s.logger.Info("停止定时更新缓存")
-			return
-		case <-ticker.C:
-			s.RequestCacheUpdate()
-		}
-	}
+// SetDeadLetterService 设置无法解析帧服务，设置后解析失败的串口帧会被保存
+func (s *SerialService) SetDeadLetterService(deadLetterService *DeadLetterService) {
+	s.deadLetterService = deadLetterService
 }
 
-// RequestCacheUpdate 请求更新缓存（只发送命令，不等待响应）
-func (s *SerialService) RequestCacheUpdate() {
-	s.logger.Debug("发送缓存更新请求")
+// SetHealthcheckService 设置外部监控心跳服务，设置后设备连接时会发送一次心跳
+func (s *SerialService) SetHealthcheckService(healthcheckService *HealthcheckService) {
+	s.healthcheckService = healthcheckService
+}
 
-	// 发送获取设备状态命令（包含移动网络信息）
-	if err := s.sendJSONCommand(map[string]string{"action": "get_status"}); err != nil {
-		s.logger.Error("发送设备状态请求失败", zap.Error(err))
-	}
+// SetPluginService 设置外部插件服务
+func (s *SerialService) SetPluginService(pluginService *PluginService) {
+	s.pluginService = pluginService
 }
 
-// processReceivedData 处理接收到的数据
-func (s *SerialService) processReceivedData(data string) {
-	s.logger.Sugar().Debugf("received data: %s", data)
-	msg, err := parseSMSFrame(data)
+// SendSMS 通过指定设备发送短信，deviceID 为空时使用主设备
+func (s *SerialService) SendSMS(deviceID, to, content string) (string, error) {
+	return s.SendSMSContext(context.Background(), deviceID, to, content)
+}
+
+// SendSMSContext 以 ctx 中的用户身份发送短信，开启发送审批且用户不是管理员时只保存为待审批，不提交给设备
+func (s *SerialService) SendSMSContext(ctx context.Context, deviceID, to, content string) (string, error) {
+	// 客户端断开时仍需完成发送状态的更新
+	ctx = context.WithoutCancel(ctx)
+	device, err := s.device(deviceID)
 	if err != nil {
-		if errors.Is(err, errNotSMSFrame) {
-			return
-		}
-		if errors.Is(err, errMissingType) {
-			s.logger.Warn("消息类型缺失", zap.String("data", data))
-			return
-		}
-		s.logger.Error("解析串口消息失败", zap.Error(err), zap.String("data", data))
-		return
+		return "", err
 	}
-
-	s.routeMessage(msg)
+	msg := newOutgoingMessage(device.id, to, content)
+	if err := s.admitOutgoing(ctx, msg); err != nil {
+		return "", err
+	}
+	return s.submitSMS(ctx, msg)
 }
 
-// SendSMS 发送短信
-func (s *SerialService) SendSMS(to, content string) (string, error) {
-	// 先保存发送记录，状态为 "sending"
-	ctx := context.Background()
-	msgID := uuid.NewString()
-	msg := &models.TextMessage{
-		ID:        msgID,
+// newOutgoingMessage 创建发送记录，状态为发送中
+func newOutgoingMessage(deviceID, to, content string) *models.TextMessage {
+	return &models.TextMessage{
+		ID:        uuid.NewString(),
 		From:      "", // 发送方是本机
 		To:        to,
+		DeviceID:  deviceID,
 		Content:   content,
 		Type:      models.MessageTypeOutgoing,
 		Status:    models.MessageStatusSending, // 初始状态为发送中
 		CreatedAt: time.Now().UnixMilli(),
 	}
+}
 
+// saveOutgoing 保存发送记录
+func (s *SerialService) saveOutgoing(ctx context.Context, msg *models.TextMessage) error {
 	if err := s.textMsgService.Save(ctx, msg); err != nil {
 		s.logger.Error("保存短信发送记录失败", zap.Error(err))
+		return err
+	}
+	s.publishSendStage(msg.ID, SendStageQueued)
+	return nil
+}
+
+// submitSMS 将已保存的短信提交给设备，返回短信 ID，待审批的短信不提交
+func (s *SerialService) submitSMS(ctx context.Context, msg *models.TextMessage) (string, error) {
+	msgID, to, content := msg.ID, msg.To, msg.Content
+	if msg.Status == models.MessageStatusPendingApproval {
+		s.logger.Info("短信等待管理员审批", zap.String("to", to), zap.String("id", msgID), zap.String("submitted_by", msg.SubmittedBy))
+		return msgID, nil
+	}
+	device, err := s.device(msg.DeviceID)
+	if err != nil {
+		s.updateSendStatus(ctx, msgID, models.MessageStatusFailed, sendFailure{Reason: err.Error()})
 		return "", err
 	}
 
@@ -390,90 +406,40 @@ func (s *SerialService) SendSMS(to, content string) (string, error) {
 		"request_id": msgID,
 	}
 
-	if err := s.sendJSONCommand(cmd); err != nil {
+	// 提交前开始等待，部分设备后端在提交过程中就会返回发送结果
+	s.awaitSendResult(msgID, to)
+	if err := device.sendJSONCommand(cmd); err != nil {
+		s.sendAcks.resolve(msgID)
 		s.logger.Error("发送短信命令失败", zap.Error(err))
 		// 更新状态为失败
-		// 更新状态为失败
-		_ = s.textMsgService.UpdateStatusById(ctx, msgID, models.MessageStatusFailed)
+		s.updateSendStatus(ctx, msgID, models.MessageStatusFailed, sendFailure{Reason: err.Error()})
 		return "", err
 	}
 
-	s.logger.Info("发送短信命令成功", zap.String("to", to), zap.String("request_id", msgID))
+	device.logger.Info("发送短信命令成功", zap.String("to", to), zap.String("request_id", msgID))
+	s.publishSendStage(msgID, SendStageSubmitted)
 
 	return msgID, nil
 }
 
-// GetStatus 获取设备状态（从缓存读取，包含 mobile 信息和串口连接状态）
-func (s *SerialService) GetStatus() (*StatusData, error) {
-	// 获取连接信息
-	portName, connected := s.getConnectionInfo()
-
-	// 从缓存读取
-	if status, ok := s.deviceCache.Get(CacheKeyDeviceStatus); ok {
-		// 更新串口连接信息
-		status.PortName = portName
-		status.Connected = connected
-
-		// 更新飞行模式状态
-		status.Flymode = s.FlyMode()
-		return status, nil
-	}
-
-	// 缓存未命中，但仍然返回连接状态
-	status := &StatusData{
-		PortName:  portName,
-		Connected: connected,
-	}
-	return status, nil
-}
-
-func (s *SerialService) FlyMode() bool {
-	// 返回当前飞行模式状态
-	return s.flyMode.Load()
-}
-
-// SetFlymode 设置飞行模式
-// enabled: true 表示启用飞行模式，false 表示禁用飞行模式
-func (s *SerialService) SetFlymode(enabled bool) error {
-	cmd := map[string]any{
-		"action":  "set_flymode",
-		"enabled": enabled,
-	}
-	if err := s.sendJSONCommand(cmd); err != nil {
-		return err
-	}
-	// 更新飞行模式状态
-	s.flyMode.Store(enabled)
-	return nil
-}
-
-// RebootMcu 重启模块
-func (s *SerialService) RebootMcu() error {
-	cmd := map[string]string{"action": "reboot_mcu"}
-	if err := s.sendJSONCommand(cmd); err != nil {
-		return err
-	}
-	// 重启后，飞行模式默认关闭
-	s.flyMode.Store(false)
-	return nil
-}
-
-// sendJSONCommand 发送JSON命令到设备
-func (s *SerialService) sendJSONCommand(cmd any) error {
-	if s.port == nil {
-		return fmt.Errorf("串口未连接")
-	}
-
-	message, jsonData, err := buildCommandMessage(cmd)
+// ForwardMessage 将已保存的短信转发到其他号码，内容前附上原发送方和时间，返回新短信的 ID。
+// 通过收发原短信的设备发送，该设备已不在配置中时使用主设备。
+func (s *SerialService) ForwardMessage(ctx context.Context, id, to string) (string, error) {
+	msg, err := s.textMsgService.Get(ctx, id)
 	if err != nil {
-		return err
+		return "", err
 	}
 
-	_, err = s.port.Write(message)
-	if err != nil {
-		return fmt.Errorf("串口写入失败: %w", err)
+	sentAt := time.UnixMilli(msg.CreatedAt).Format("2006-01-02 15:04")
+	var header string
+	if msg.Type == models.MessageTypeOutgoing {
+		header = fmt.Sprintf("[转发] 发给 %s（%s）：", msg.To, sentAt)
+	} else {
+		header = fmt.Sprintf("[转发] 来自 %s（%s）：", msg.From, sentAt)
 	}
-	s.logger.Sugar().Debugf("send command: %s", jsonData)
-
-	return nil
+	deviceID := msg.DeviceID
+	if _, err := s.device(deviceID); err != nil {
+		deviceID = ""
+	}
+	return s.SendSMSContext(ctx, deviceID, to, header+"\n"+msg.Content)
 }
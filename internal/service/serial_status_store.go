@@ -0,0 +1,71 @@
+package service
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+const (
+	// PropertyIDLastDeviceStatus 最后一次收到的设备状态
+	PropertyIDLastDeviceStatus = "last_device_status"
+	// statusPersistInterval 设备状态没有关键变化时的最短保存间隔，避免频繁写入存储卡
+	statusPersistInterval = 5 * time.Minute
+)
+
+// statusStore 最后一次已知的设备状态，服务重启后在收到新状态前展示
+type statusStore struct {
+	once        sync.Once
+	mu          sync.Mutex
+	last        *StatusData
+	persistedAt time.Time
+}
+
+// lastKnownStatus 获取最后一次已知的设备状态，首次调用时从数据库读取
+func (d *serialDevice) lastKnownStatus() *StatusData {
+	d.lastStatus.once.Do(func() {
+		var status StatusData
+		if err := d.service.propertyService.GetValue(context.Background(), d.propertyKey(PropertyIDLastDeviceStatus), &status); err != nil {
+			return
+		}
+		d.lastStatus.mu.Lock()
+		if d.lastStatus.last == nil {
+			d.lastStatus.last = &status
+		}
+		d.lastStatus.mu.Unlock()
+	})
+
+	d.lastStatus.mu.Lock()
+	defer d.lastStatus.mu.Unlock()
+	if d.lastStatus.last == nil {
+		return nil
+	}
+	status := *d.lastStatus.last
+	return &status
+}
+
+// persistStatus 记录设备状态，SIM 卡、运营商或注册状态变化时立即保存，否则按间隔保存
+func (d *serialDevice) persistStatus(status *StatusData) {
+	d.lastStatus.mu.Lock()
+	previous := d.lastStatus.last
+	snapshot := *status
+	d.lastStatus.last = &snapshot
+	changed := previous == nil ||
+		previous.Mobile.Iccid != status.Mobile.Iccid ||
+		previous.Mobile.Operator != status.Mobile.Operator ||
+		previous.Mobile.Number != status.Mobile.Number ||
+		previous.Mobile.IsRegistered != status.Mobile.IsRegistered ||
+		previous.Mobile.SimReady != status.Mobile.SimReady
+	if !changed && time.Since(d.lastStatus.persistedAt) < statusPersistInterval {
+		d.lastStatus.mu.Unlock()
+		return
+	}
+	d.lastStatus.persistedAt = time.Now()
+	d.lastStatus.mu.Unlock()
+
+	if err := d.service.propertyService.Set(context.Background(), d.propertyKey(PropertyIDLastDeviceStatus), "最后一次设备状态", snapshot); err != nil {
+		d.logger.Warn("保存设备状态失败", zap.Error(err))
+	}
+}
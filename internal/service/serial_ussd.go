@@ -0,0 +1,134 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// ussdTimeout 等待运营商返回 USSD 结果的时间，网络较差时运营商可能在十几秒后才回复
+const ussdTimeout = 30 * time.Second
+
+var (
+	// ErrUSSDTimeout 等待 USSD 结果超时
+	ErrUSSDTimeout = errors.New("等待 USSD 结果超时")
+	// ErrInvalidUSSDCode USSD 代码格式错误
+	ErrInvalidUSSDCode = errors.New("USSD 代码格式错误，只能包含数字、*、#，如 *100#")
+
+	ussdCodePattern = regexp.MustCompile(`^[0-9*#+]{2,160}$`)
+)
+
+// ussdResult 设备返回的 USSD 结果
+type ussdResult struct {
+	text string
+	err  error
+}
+
+// ussdRequests 等待设备返回结果的 USSD 请求：request_id -> 结果通道
+type ussdRequests struct {
+	mu      sync.Mutex
+	pending map[string]chan ussdResult
+}
+
+// add 开始等待 request_id 对应的结果
+func (r *ussdRequests) add(requestID string) <-chan ussdResult {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.pending == nil {
+		r.pending = make(map[string]chan ussdResult)
+	}
+	ch := make(chan ussdResult, 1)
+	r.pending[requestID] = ch
+	return ch
+}
+
+// remove 放弃等待
+func (r *ussdRequests) remove(requestID string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.pending, requestID)
+}
+
+// resolve 将结果交给等待者，没有等待者（已超时）时返回 false
+func (r *ussdRequests) resolve(requestID string, result ussdResult) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	ch, ok := r.pending[requestID]
+	if !ok {
+		return false
+	}
+	delete(r.pending, requestID)
+	ch <- result
+	return true
+}
+
+// SendUSSD 通过指定设备发送 USSD 代码（如 *100# 查询话费），等待并返回运营商回复的文本。
+// deviceID 为空时使用主设备
+func (s *SerialService) SendUSSD(ctx context.Context, deviceID, code string) (string, error) {
+	if !ussdCodePattern.MatchString(code) {
+		return "", ErrInvalidUSSDCode
+	}
+	device, err := s.device(deviceID)
+	if err != nil {
+		return "", err
+	}
+	if _, connected := device.getConnectionInfo(); !connected {
+		return "", fmt.Errorf("设备未连接")
+	}
+
+	// 先登记再发送命令，部分设备后端在发送过程中就会返回结果
+	requestID := uuid.NewString()
+	result := s.ussdRequests.add(requestID)
+	defer s.ussdRequests.remove(requestID)
+
+	cmd := map[string]any{
+		"action":     "send_ussd",
+		"code":       code,
+		"request_id": requestID,
+	}
+	if err := device.sendJSONCommand(cmd); err != nil {
+		return "", fmt.Errorf("发送 USSD 命令失败: %w", err)
+	}
+	device.logger.Info("已发送 USSD 命令", zap.String("code", code), zap.String("request_id", requestID))
+
+	timer := time.NewTimer(ussdTimeout)
+	defer timer.Stop()
+	select {
+	case r := <-result:
+		return r.text, r.err
+	case <-timer.C:
+		return "", ErrUSSDTimeout
+	case <-ctx.Done():
+		return "", ctx.Err()
+	}
+}
+
+// handleUSSDResponse 处理设备返回的 USSD 结果，按 request_id 交给等待的请求
+func (s *SerialService) handleUSSDResponse(msg *ParsedMessage) {
+	requestID, _ := msg.Payload["request_id"].(string)
+	success, _ := msg.Payload["success"].(bool)
+	text, _ := msg.Payload["text"].(string)
+
+	var result ussdResult
+	if success {
+		result.text = text
+	} else {
+		reason, _ := msg.Payload["error"].(string)
+		if reason == "" {
+			reason = "未知错误"
+		}
+		result.err = fmt.Errorf("USSD 请求失败: %s", reason)
+	}
+
+	if !s.ussdRequests.resolve(requestID, result) {
+		s.deviceOf(msg.DeviceID).logger.Warn("收到无人等待的 USSD 结果，可能已超时",
+			zap.String("request_id", requestID),
+			zap.String("text", text))
+	}
+}
@@ -0,0 +1,284 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/dushixiang/uart_sms_forwarder/internal/models"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// settingsBundleVersion 设置导出包的格式版本
+const settingsBundleVersion = 1
+
+// settingsExcludedProperties 与当前设备绑定的运行状态，不随设置迁移
+var settingsExcludedProperties = []string{
+	PropertyIDSerialBaudRates,
+	PropertyIDSerialDeviceBinding,
+	PropertyIDLastDeviceStatus,
+}
+
+// SettingsBundle 完整的应用设置，用于迁移到新设备。
+// 配置中的敏感字段以明文导出，导入时按新设备的 App.SecretKey 重新加密。
+type SettingsBundle struct {
+	Version              int                          `json:"version"`
+	ExportedAt           int64                        `json:"exportedAt"` // 导出时间（时间戳毫秒）
+	Properties           []SettingsProperty           `json:"properties"` // 通知渠道、推送接口密钥等配置
+	NumberRules          []models.NumberRule          `json:"numberRules"`
+	CallRules            []models.CallRule            `json:"callRules"`
+	ConversationSettings []models.ConversationSetting `json:"conversationSettings"`
+	PeerAssignments      []models.PeerAssignment      `json:"peerAssignments"`
+	NumberAliases        []models.NumberAlias         `json:"numberAliases"`
+	MessageTemplates     []models.MessageTemplate     `json:"messageTemplates"`
+	ScheduledTasks       []models.ScheduledTask       `json:"scheduledTasks"`
+	PushDevices          []SettingsPushDevice         `json:"pushDevices"`
+}
+
+// SettingsProperty 导出的配置项，value 为解密后的 JSON
+type SettingsProperty struct {
+	ID    string          `json:"id"`
+	Name  string          `json:"name"`
+	Value json.RawMessage `json:"value"`
+}
+
+// SettingsPushDevice 导出的推送设备，包含 Web Push 订阅密钥
+type SettingsPushDevice struct {
+	models.PushDevice
+	P256dh string `json:"p256dh"`
+	Auth   string `json:"auth"`
+}
+
+// SettingsImportResult 导入结果，各类设置导入的数量
+type SettingsImportResult struct {
+	Properties           int `json:"properties"`
+	NumberRules          int `json:"numberRules"`
+	CallRules            int `json:"callRules"`
+	ConversationSettings int `json:"conversationSettings"`
+	PeerAssignments      int `json:"peerAssignments"`
+	NumberAliases        int `json:"numberAliases"`
+	MessageTemplates     int `json:"messageTemplates"`
+	ScheduledTasks       int `json:"scheduledTasks"`
+	PushDevices          int `json:"pushDevices"`
+}
+
+// SettingsService 设置导出导入服务，仅管理员可用
+type SettingsService struct {
+	logger          *zap.Logger
+	db              *gorm.DB
+	propertyService *PropertyService
+}
+
+// NewSettingsService 创建设置导出导入服务实例
+func NewSettingsService(logger *zap.Logger, db *gorm.DB, propertyService *PropertyService) *SettingsService {
+	return &SettingsService{
+		logger:          logger,
+		db:              db,
+		propertyService: propertyService,
+	}
+}
+
+// Export 导出完整的应用设置，不包含短信记录和交易记录
+func (s *SettingsService) Export(ctx context.Context) (*SettingsBundle, error) {
+	if err := requireAdmin(ctx); err != nil {
+		return nil, err
+	}
+
+	db := s.db.WithContext(ctx)
+	bundle := &SettingsBundle{
+		Version:    settingsBundleVersion,
+		ExportedAt: time.Now().UnixMilli(),
+	}
+
+	var properties []models.Property
+	if err := db.Order("id").Find(&properties).Error; err != nil {
+		return nil, fmt.Errorf("读取配置失败: %w", err)
+	}
+	for _, property := range properties {
+		if slices.Contains(settingsExcludedProperties, property.ID) || property.Value == "" {
+			continue
+		}
+		value, err := s.propertyService.openValue(property.Value)
+		if err != nil {
+			return nil, fmt.Errorf("解密配置 %s 失败: %w", property.ID, err)
+		}
+		if !json.Valid([]byte(value)) {
+			return nil, fmt.Errorf("配置 %s 不是有效的 JSON", property.ID)
+		}
+		bundle.Properties = append(bundle.Properties, SettingsProperty{
+			ID:    property.ID,
+			Name:  property.Name,
+			Value: json.RawMessage(value),
+		})
+	}
+
+	tables := []struct {
+		name   string
+		target any
+	}{
+		{"号码分类规则", &bundle.NumberRules},
+		{"来电规则", &bundle.CallRules},
+		{"会话设置", &bundle.ConversationSettings},
+		{"会话分配", &bundle.PeerAssignments},
+		{"号码别名", &bundle.NumberAliases},
+		{"短信模板", &bundle.MessageTemplates},
+		{"定时任务", &bundle.ScheduledTasks},
+	}
+	for _, table := range tables {
+		if err := db.Find(table.target).Error; err != nil {
+			return nil, fmt.Errorf("读取%s失败: %w", table.name, err)
+		}
+	}
+
+	var devices []models.PushDevice
+	if err := db.Find(&devices).Error; err != nil {
+		return nil, fmt.Errorf("读取推送设备失败: %w", err)
+	}
+	for _, device := range devices {
+		bundle.PushDevices = append(bundle.PushDevices, SettingsPushDevice{
+			PushDevice: device,
+			P256dh:     device.P256dh,
+			Auth:       device.Auth,
+		})
+	}
+
+	return bundle, nil
+}
+
+// Import 导入设置。相同 ID 的记录被覆盖；replace 为 true 时先清空现有设置，使结果与导出包完全一致。
+// 全部导入在一个事务中完成，任何一项失败都不会修改现有设置。
+func (s *SettingsService) Import(ctx context.Context, bundle *SettingsBundle, replace bool) (*SettingsImportResult, error) {
+	if err := requireAdmin(ctx); err != nil {
+		return nil, err
+	}
+	if bundle.Version != settingsBundleVersion {
+		return nil, fmt.Errorf("不支持的设置导出格式版本: %d", bundle.Version)
+	}
+
+	result := &SettingsImportResult{}
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if replace {
+			if err := s.clear(tx); err != nil {
+				return err
+			}
+		}
+
+		now := time.Now().UnixMilli()
+		for _, item := range bundle.Properties {
+			if item.ID == "" || slices.Contains(settingsExcludedProperties, item.ID) {
+				continue
+			}
+			if !json.Valid(item.Value) {
+				return fmt.Errorf("配置 %s 不是有效的 JSON", item.ID)
+			}
+			// 敏感字段按当前密钥重新加密
+			sealed, err := s.propertyService.sealValue(string(item.Value))
+			if err != nil {
+				return fmt.Errorf("加密配置 %s 失败: %w", item.ID, err)
+			}
+			property := models.Property{ID: item.ID, Name: item.Name, Value: sealed, CreatedAt: now, UpdatedAt: now}
+			if err := tx.Save(&property).Error; err != nil {
+				return fmt.Errorf("保存配置 %s 失败: %w", item.ID, err)
+			}
+			result.Properties++
+		}
+
+		for i := range bundle.NumberRules {
+			if err := ValidateNumberRule(&bundle.NumberRules[i]); err != nil {
+				return fmt.Errorf("号码分类规则 %s: %w", bundle.NumberRules[i].ID, err)
+			}
+		}
+		for i := range bundle.CallRules {
+			if err := ValidateCallRule(&bundle.CallRules[i]); err != nil {
+				return fmt.Errorf("来电规则 %s: %w", bundle.CallRules[i].ID, err)
+			}
+		}
+		for i := range bundle.MessageTemplates {
+			if err := ValidateMessageTemplate(&bundle.MessageTemplates[i]); err != nil {
+				return fmt.Errorf("短信模板 %s: %w", bundle.MessageTemplates[i].Name, err)
+			}
+		}
+
+		var err error
+		if result.NumberRules, err = saveAll(tx, "号码分类规则", bundle.NumberRules); err != nil {
+			return err
+		}
+		if result.CallRules, err = saveAll(tx, "来电规则", bundle.CallRules); err != nil {
+			return err
+		}
+		if result.ConversationSettings, err = saveAll(tx, "会话设置", bundle.ConversationSettings); err != nil {
+			return err
+		}
+		if result.PeerAssignments, err = saveAll(tx, "会话分配", bundle.PeerAssignments); err != nil {
+			return err
+		}
+		if result.NumberAliases, err = saveAll(tx, "号码别名", bundle.NumberAliases); err != nil {
+			return err
+		}
+		if result.MessageTemplates, err = saveAll(tx, "短信模板", bundle.MessageTemplates); err != nil {
+			return err
+		}
+		if result.ScheduledTasks, err = saveAll(tx, "定时任务", bundle.ScheduledTasks); err != nil {
+			return err
+		}
+
+		devices := make([]models.PushDevice, 0, len(bundle.PushDevices))
+		for _, item := range bundle.PushDevices {
+			device := item.PushDevice
+			device.P256dh = item.P256dh
+			device.Auth = item.Auth
+			// token 唯一，同一设备在本机以其他 ID 注册过时以导入的为准
+			if err := tx.Where("token = ? AND id <> ?", device.Token, device.ID).Delete(&models.PushDevice{}).Error; err != nil {
+				return fmt.Errorf("保存推送设备失败: %w", err)
+			}
+			devices = append(devices, device)
+		}
+		if result.PushDevices, err = saveAll(tx, "推送设备", devices); err != nil {
+			return err
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// 配置已直接写入数据库，清空缓存使其立即生效
+	s.propertyService.cache.Reset()
+	s.logger.Info("已导入设置", zap.Bool("replace", replace), zap.Any("result", result))
+	return result, nil
+}
+
+// clear 清空所有可导入的设置，保留与当前设备绑定的运行状态
+func (s *SettingsService) clear(tx *gorm.DB) error {
+	if err := tx.Where("id NOT IN ?", settingsExcludedProperties).Delete(&models.Property{}).Error; err != nil {
+		return fmt.Errorf("清空配置失败: %w", err)
+	}
+	for _, model := range []any{
+		&models.NumberRule{},
+		&models.CallRule{},
+		&models.ConversationSetting{},
+		&models.PeerAssignment{},
+		&models.NumberAlias{},
+		&models.MessageTemplate{},
+		&models.ScheduledTask{},
+		&models.PushDevice{},
+	} {
+		if err := tx.Session(&gorm.Session{AllowGlobalUpdate: true}).Delete(model).Error; err != nil {
+			return fmt.Errorf("清空设置失败: %w", err)
+		}
+	}
+	return nil
+}
+
+// saveAll 按主键保存记录，已存在时覆盖，返回保存的数量
+func saveAll[T any](tx *gorm.DB, name string, records []T) (int, error) {
+	for i := range records {
+		if err := tx.Save(&records[i]).Error; err != nil {
+			return 0, fmt.Errorf("保存%s失败: %w", name, err)
+		}
+	}
+	return len(records), nil
+}
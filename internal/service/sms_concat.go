@@ -0,0 +1,104 @@
+package service
+
+import (
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// DefaultConcatTimeout 默认等待长短信其余分片的超时（秒）
+const DefaultConcatTimeout = 60
+
+// concatKey 长短信分组，同一发送方的同一引用号为一条长短信
+type concatKey struct {
+	from       string
+	ref, total int
+}
+
+// concatGroup 已收到的长短信分片
+type concatGroup struct {
+	first      IncomingSMS    // 第一个到达的分片，合并后的短信使用其号码和时间
+	receivedAt int64          // 第一个分片的接收时间（时间戳毫秒）
+	parts      map[int]string // 分片序号 -> 内容
+	timer      *time.Timer
+}
+
+// concatBuffer 缓存长短信分片，收齐或超时后合并为一条短信
+type concatBuffer struct {
+	logger *zap.Logger
+	mu     sync.Mutex
+	groups map[concatKey]*concatGroup
+}
+
+// add 缓存分片，收齐所有分片或等待超时后以合并后的短信调用 complete。
+// 同一引用号可能被重复使用，分片序号重复时先按已有分片合并，再开始新的长短信。
+func (b *concatBuffer) add(sms IncomingSMS, receivedAt int64, timeout time.Duration, complete func(IncomingSMS, int64)) {
+	key := concatKey{from: sms.From, ref: sms.ConcatRef, total: sms.ConcatTotal}
+
+	b.mu.Lock()
+	if b.groups == nil {
+		b.groups = make(map[concatKey]*concatGroup)
+	}
+	var flushed []*concatGroup
+	group, ok := b.groups[key]
+	if ok {
+		if _, dup := group.parts[sms.ConcatSeq]; dup {
+			flushed = append(flushed, b.removeLocked(key))
+			ok = false
+		}
+	}
+	if !ok {
+		group = &concatGroup{first: sms, receivedAt: receivedAt, parts: make(map[int]string)}
+		group.timer = time.AfterFunc(timeout, func() {
+			b.mu.Lock()
+			expired := b.groups[key] == group
+			if expired {
+				b.removeLocked(key)
+			}
+			b.mu.Unlock()
+			if expired {
+				b.logger.Warn("长短信分片未收齐，按已收到的分片处理",
+					zap.String("from", key.from),
+					zap.Int("ref", key.ref),
+					zap.Int("total", key.total),
+					zap.Int("received", len(group.parts)))
+				complete(group.merged(), group.receivedAt)
+			}
+		})
+		b.groups[key] = group
+	}
+	group.parts[sms.ConcatSeq] = sms.Content
+	if len(group.parts) == key.total {
+		flushed = append(flushed, b.removeLocked(key))
+	}
+	b.mu.Unlock()
+
+	for _, group := range flushed {
+		complete(group.merged(), group.receivedAt)
+	}
+}
+
+// removeLocked 移除分组并停止超时计时，调用方需持有锁
+func (b *concatBuffer) removeLocked(key concatKey) *concatGroup {
+	group := b.groups[key]
+	group.timer.Stop()
+	delete(b.groups, key)
+	return group
+}
+
+// merged 按分片序号拼接为一条短信
+func (g *concatGroup) merged() IncomingSMS {
+	sms := g.first
+	sms.Content = joinParts(g.parts)
+	sms.ConcatRef, sms.ConcatTotal, sms.ConcatSeq = 0, 0, 0
+	return sms
+}
+
+// concatTimeout 等待长短信其余分片的超时
+func (s *SerialService) concatTimeout() time.Duration {
+	if s.config.ConcatTimeout > 0 {
+		return time.Duration(s.config.ConcatTimeout) * time.Second
+	}
+	return DefaultConcatTimeout * time.Second
+}
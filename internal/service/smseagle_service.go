@@ -0,0 +1,126 @@
+package service
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/dushixiang/uart_sms_forwarder/internal/models"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// PropertyIDSMSEagleAPI SMSEagle 兼容接口配置
+const PropertyIDSMSEagleAPI = "smseagle_api"
+
+var (
+	// ErrSMSEagleUnauthorized 账号密码或 access_token 错误，接口未启用时也返回该错误
+	ErrSMSEagleUnauthorized = errors.New("账号密码或令牌错误")
+	// ErrSMSEagleNoText 短信内容为空
+	ErrSMSEagleNoText = errors.New("短信内容不能为空")
+	// ErrSMSEagleNoRecipient 接收号码为空
+	ErrSMSEagleNoRecipient = errors.New("接收号码不能为空")
+)
+
+// SMSEagleSendRequest SMSEagle HTTP API send_sms 请求参数
+type SMSEagleSendRequest struct {
+	Login       string
+	Pass        string
+	AccessToken string
+	To          string // 多个号码用逗号分隔
+	Message     string
+}
+
+// SMSEagleService 模拟 SMSEagle 短信网关的 HTTP API，
+// 只支持 SMSEagle 方言的监控设备（Zabbix、PRTG、UPS 网卡等）无需修改即可通过本机发送短信
+type SMSEagleService struct {
+	logger          *zap.Logger
+	propertyService *PropertyService
+	accountService  *AccountService
+	serialService   *SerialService
+	visibility      *VisibilityService
+}
+
+// NewSMSEagleService 创建 SMSEagle 兼容接口服务实例
+func NewSMSEagleService(logger *zap.Logger, propertyService *PropertyService, accountService *AccountService, serialService *SerialService) *SMSEagleService {
+	return &SMSEagleService{
+		logger:          logger,
+		propertyService: propertyService,
+		accountService:  accountService,
+		serialService:   serialService,
+	}
+}
+
+// SetVisibilityService 设置会话可见性服务，用于判断 login 是否为管理员，开启发送审批时非管理员发送的短信需要审批
+func (s *SMSEagleService) SetVisibilityService(visibility *VisibilityService) {
+	s.visibility = visibility
+}
+
+// smsEagleTokenSubmitter 使用 access_token 发送时记录的提交者
+const smsEagleTokenSubmitter = "smseagle:access_token"
+
+// getConfig 获取 SMSEagle 兼容接口配置，未配置时视为未启用
+func (s *SMSEagleService) getConfig(ctx context.Context) (models.SMSEagleAPIConfig, error) {
+	var config models.SMSEagleAPIConfig
+	if err := s.propertyService.GetValue(ctx, PropertyIDSMSEagleAPI, &config); err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return config, err
+	}
+	return config, nil
+}
+
+// authenticate 校验 access_token 或 login/pass（使用本系统的登录账号），返回发送者身份。
+// access_token 不对应具体用户，按非管理员处理
+func (s *SMSEagleService) authenticate(ctx context.Context, req SMSEagleSendRequest) (Viewer, error) {
+	config, err := s.getConfig(ctx)
+	if err != nil {
+		return Viewer{}, fmt.Errorf("获取 SMSEagle 兼容接口配置失败: %w", err)
+	}
+	if !config.Enabled {
+		return Viewer{}, ErrSMSEagleUnauthorized
+	}
+	if req.AccessToken != "" {
+		if config.AccessToken != "" && subtle.ConstantTimeCompare([]byte(req.AccessToken), []byte(config.AccessToken)) == 1 {
+			return Viewer{Username: smsEagleTokenSubmitter}, nil
+		}
+		return Viewer{}, ErrSMSEagleUnauthorized
+	}
+	if req.Login == "" || s.accountService.ValidateCredentials(ctx, req.Login, req.Pass) != nil {
+		return Viewer{}, ErrSMSEagleUnauthorized
+	}
+	return Viewer{Username: req.Login, Admin: s.visibility == nil || s.visibility.IsAdmin(req.Login)}, nil
+}
+
+// Send 校验认证信息后向每个号码发送短信，返回各号码对应的短信记录 ID
+func (s *SMSEagleService) Send(ctx context.Context, req SMSEagleSendRequest) ([]string, error) {
+	viewer, err := s.authenticate(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	ctx = WithViewer(ctx, viewer)
+	if strings.TrimSpace(req.Message) == "" {
+		return nil, ErrSMSEagleNoText
+	}
+
+	var recipients []string
+	for _, to := range strings.Split(req.To, ",") {
+		if to = strings.TrimSpace(to); to != "" {
+			recipients = append(recipients, to)
+		}
+	}
+	if len(recipients) == 0 {
+		return nil, ErrSMSEagleNoRecipient
+	}
+
+	ids := make([]string, 0, len(recipients))
+	for _, to := range recipients {
+		id, err := s.serialService.SendSMSContext(ctx, "", to, req.Message)
+		if err != nil {
+			return ids, fmt.Errorf("发送短信到 %s 失败: %w", to, err)
+		}
+		ids = append(ids, id)
+	}
+	s.logger.Info("SMSEagle 兼容接口发送短信", zap.Strings("to", recipients), zap.String("login", req.Login))
+	return ids, nil
+}
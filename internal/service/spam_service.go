@@ -0,0 +1,276 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"unicode"
+
+	"github.com/dushixiang/uart_sms_forwarder/internal/models"
+	"github.com/dushixiang/uart_sms_forwarder/internal/repo"
+	"github.com/go-orz/orz"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+const (
+	// PropertyIDSpamFilter 垃圾短信识别配置
+	PropertyIDSpamFilter = "spam_filter"
+	// DefaultSpamThreshold 默认贝叶斯评分阈值
+	DefaultSpamThreshold = 0.9
+	// DefaultSpamMinTrained 默认启用贝叶斯评分所需的最少训练条数
+	DefaultSpamMinTrained = 10
+	// spamDocsToken 记录训练短信条数的保留分词，分词结果不含下划线，不会与其冲突
+	spamDocsToken = "__messages__"
+	// maxSpamTokens 单条短信参与分类的最大分词数
+	maxSpamTokens = 200
+
+	SpamLabelSpam = "spam"
+	SpamLabelHam  = "ham"
+)
+
+// DefaultSpamFilterConfig 默认垃圾短信识别配置（未启用，附带常见营销短信关键词）
+var DefaultSpamFilterConfig = models.SpamFilterConfig{
+	Enabled:         false,
+	AllowedPrefixes: []string{},
+	BlockedPrefixes: []string{},
+	Keywords:        []string{"回T退订", "回TD退订", "退订回T", "拒收请回复R"},
+	Threshold:       DefaultSpamThreshold,
+	MinTrained:      DefaultSpamMinTrained,
+}
+
+// SpamResult 垃圾短信分类结果
+type SpamResult struct {
+	Spam   bool    `json:"spam"`
+	Score  float64 `json:"score"`  // 评分（0-1），规则命中时为 0 或 1
+	Reason string  `json:"reason"` // 判定依据：allowed_prefix / blocked_prefix / keyword / bayes
+}
+
+// SpamService 垃圾短信识别服务。
+// 先按号码前缀和关键词规则判断，未命中时使用人工训练的贝叶斯模型评分，
+// 判为垃圾的短信照常保存但不发送通知，可在垃圾箱中查看并纠正。
+type SpamService struct {
+	logger          *zap.Logger
+	propertyService *PropertyService
+	tokenRepo       *repo.SpamTokenRepo
+	textMessageRepo *repo.TextMessageRepo
+}
+
+// NewSpamService 创建垃圾短信识别服务实例
+func NewSpamService(logger *zap.Logger, db *gorm.DB, propertyService *PropertyService, textMessageRepo *repo.TextMessageRepo) *SpamService {
+	return &SpamService{
+		logger:          logger,
+		propertyService: propertyService,
+		tokenRepo:       repo.NewSpamTokenRepo(db),
+		textMessageRepo: textMessageRepo,
+	}
+}
+
+// GetConfig 获取垃圾短信识别配置，未配置时返回禁用状态
+func (s *SpamService) GetConfig(ctx context.Context) (models.SpamFilterConfig, error) {
+	var config models.SpamFilterConfig
+	if err := s.propertyService.GetValue(ctx, PropertyIDSpamFilter, &config); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return config, nil
+		}
+		return config, err
+	}
+	if config.Threshold <= 0 || config.Threshold >= 1 {
+		config.Threshold = DefaultSpamThreshold
+	}
+	if config.MinTrained <= 0 {
+		config.MinTrained = DefaultSpamMinTrained
+	}
+	return config, nil
+}
+
+// Classify 判断收到的短信是否为垃圾短信，未启用或出错时视为正常短信
+func (s *SpamService) Classify(ctx context.Context, from, content string) SpamResult {
+	config, err := s.GetConfig(ctx)
+	if err != nil {
+		s.logger.Error("获取垃圾短信识别配置失败", zap.Error(err))
+		return SpamResult{}
+	}
+	if !config.Enabled {
+		return SpamResult{}
+	}
+	result, err := s.classify(ctx, config, from, content)
+	if err != nil {
+		s.logger.Error("垃圾短信评分失败", zap.Error(err))
+		return SpamResult{}
+	}
+	return result
+}
+
+// Test 使用当前模型和配置对短信评分（忽略启用开关），用于调整规则
+func (s *SpamService) Test(ctx context.Context, from, content string) (SpamResult, error) {
+	config, err := s.GetConfig(ctx)
+	if err != nil {
+		return SpamResult{}, err
+	}
+	return s.classify(ctx, config, from, content)
+}
+
+func (s *SpamService) classify(ctx context.Context, config models.SpamFilterConfig, from, content string) (SpamResult, error) {
+	sender := normalizePhone(from)
+	for _, prefix := range config.AllowedPrefixes {
+		if prefix != "" && strings.HasPrefix(sender, normalizePhone(prefix)) {
+			return SpamResult{Reason: "allowed_prefix"}, nil
+		}
+	}
+	for _, prefix := range config.BlockedPrefixes {
+		if prefix != "" && strings.HasPrefix(sender, normalizePhone(prefix)) {
+			return SpamResult{Spam: true, Score: 1, Reason: "blocked_prefix"}, nil
+		}
+	}
+	lower := strings.ToLower(content)
+	for _, keyword := range config.Keywords {
+		if keyword != "" && strings.Contains(lower, strings.ToLower(keyword)) {
+			return SpamResult{Spam: true, Score: 1, Reason: "keyword"}, nil
+		}
+	}
+
+	tokens := spamTokens(from, content)
+	counts, err := s.tokenRepo.FindByTokens(ctx, append(tokens, spamDocsToken))
+	if err != nil {
+		return SpamResult{}, err
+	}
+	docs := counts[spamDocsToken]
+	if docs.Spam < int64(config.MinTrained) || docs.Ham < int64(config.MinTrained) {
+		return SpamResult{}, nil
+	}
+
+	score := bayesScore(tokens, counts, docs)
+	return SpamResult{Spam: score >= config.Threshold, Score: score, Reason: "bayes"}, nil
+}
+
+// bayesScore 朴素贝叶斯评分（等先验，拉普拉斯平滑），只统计训练中出现过的分词
+func bayesScore(tokens []string, counts map[string]models.SpamToken, docs models.SpamToken) float64 {
+	var logOdds float64
+	for _, token := range tokens {
+		c, ok := counts[token]
+		if !ok {
+			continue
+		}
+		pSpam := (float64(c.Spam) + 1) / (float64(docs.Spam) + 2)
+		pHam := (float64(c.Ham) + 1) / (float64(docs.Ham) + 2)
+		logOdds += math.Log(pSpam / pHam)
+	}
+	return 1 / (1 + math.Exp(-logOdds))
+}
+
+// Train 将短信标记为垃圾或正常短信并训练模型，重复标记不会重复计数，改标时撤销原训练
+func (s *SpamService) Train(ctx context.Context, id string, spam bool) (*models.TextMessage, error) {
+	label := SpamLabelHam
+	if spam {
+		label = SpamLabelSpam
+	}
+
+	var msg *models.TextMessage
+	err := s.textMessageRepo.GetDB(ctx).Transaction(func(db *gorm.DB) error {
+		txCtx := orz.WithTx(ctx, db)
+		found, err := s.textMessageRepo.FindById(txCtx, id)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return fmt.Errorf("短信记录不存在")
+			}
+			return err
+		}
+		msg = &found
+
+		if msg.SpamLabel != label {
+			tokens := append(spamTokens(msg.From, msg.Content), spamDocsToken)
+			if msg.SpamLabel != "" {
+				spamDelta, hamDelta := labelDelta(msg.SpamLabel, -1)
+				if err := s.tokenRepo.Increment(txCtx, tokens, spamDelta, hamDelta); err != nil {
+					return err
+				}
+			}
+			spamDelta, hamDelta := labelDelta(label, 1)
+			if err := s.tokenRepo.Increment(txCtx, tokens, spamDelta, hamDelta); err != nil {
+				return err
+			}
+		}
+
+		msg.Spam = spam
+		msg.SpamLabel = label
+		return db.Model(&models.TextMessage{ID: id}).
+			Select("spam", "spam_label").
+			Updates(msg).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.logger.Info("已训练垃圾短信模型", zap.String("id", id), zap.String("label", label))
+	return msg, nil
+}
+
+// labelDelta 返回标签对应的 (spam, ham) 增量
+func labelDelta(label string, n int64) (int64, int64) {
+	if label == SpamLabelSpam {
+		return n, 0
+	}
+	return 0, n
+}
+
+// spamTokens 分词：中文按相邻两字切分，英文和数字按词切分，长数字按位数归类，
+// 另外加入发送方号码前 3 位，结果去重
+func spamTokens(from, content string) []string {
+	seen := make(map[string]bool)
+	var tokens []string
+	add := func(token string) {
+		if len(tokens) < maxSpamTokens && !seen[token] {
+			seen[token] = true
+			tokens = append(tokens, token)
+		}
+	}
+
+	if sender := normalizePhone(from); len(sender) >= 3 {
+		add("from:" + sender[:3])
+	}
+
+	var han, word []rune
+	flushHan := func() {
+		if len(han) == 1 {
+			add(string(han))
+		}
+		for i := 0; i+1 < len(han); i++ {
+			add(string(han[i : i+2]))
+		}
+		han = han[:0]
+	}
+	flushWord := func() {
+		if len(word) >= 2 {
+			w := string(word)
+			if strings.IndexFunc(w, func(r rune) bool { return !unicode.IsDigit(r) }) < 0 {
+				// 具体数字没有区分意义，按位数归类
+				if len(word) >= 4 {
+					add(fmt.Sprintf("num:%d", len(word)))
+				}
+			} else {
+				add(w)
+			}
+		}
+		word = word[:0]
+	}
+
+	for _, r := range strings.ToLower(content) {
+		switch {
+		case unicode.Is(unicode.Han, r):
+			flushWord()
+			han = append(han, r)
+		case r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)):
+			flushHan()
+			word = append(word, r)
+		default:
+			flushHan()
+			flushWord()
+		}
+	}
+	flushHan()
+	flushWord()
+	return tokens
+}
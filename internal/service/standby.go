@@ -0,0 +1,255 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/dushixiang/uart_sms_forwarder/internal/models"
+	"go.uber.org/zap"
+)
+
+// PropertyIDStandby 夜间待机配置
+const PropertyIDStandby = "standby"
+
+// StandbyCheckInterval 检查是否需要进入或退出待机的间隔
+const StandbyCheckInterval = time.Minute
+
+// DefaultStandbyConfig 默认夜间待机配置（未启用）
+var DefaultStandbyConfig = models.StandbyConfig{
+	Start: "00:00",
+	End:   "07:00",
+}
+
+// 手动覆盖计划的操作
+const (
+	StandbyActionSleep = "sleep" // 立即进入待机
+	StandbyActionWake  = "wake"  // 立即退出待机
+	StandbyActionAuto  = "auto"  // 取消手动覆盖，按计划执行
+)
+
+// ErrInvalidStandbyAction 无效的待机操作
+var ErrInvalidStandbyAction = errors.New("操作无效，可选 sleep、wake、auto")
+
+// StandbyStatus 夜间待机状态
+type StandbyStatus struct {
+	models.StandbyConfig
+	InWindow      bool   `json:"inWindow"`                // 当前是否处于计划的待机时段
+	Active        bool   `json:"active"`                  // 当前是否已由待机模式开启飞行模式
+	Override      string `json:"override,omitempty"`      // 手动覆盖: sleep 或 wake，为空表示按计划执行
+	OverrideUntil int64  `json:"overrideUntil,omitempty"` // 手动覆盖的结束时间（时间戳毫秒），为 0 表示直到取消
+}
+
+// StandbyService 夜间待机。
+// 按配置的时段开启飞行模式（关闭蜂窝网络），结束时恢复，用于电池或太阳能供电的部署降低功耗和发热。
+// 只在进入和退出时段时切换一次，期间定时任务临时退出飞行模式后不会被再次打断。
+// 可通过接口立即进入或退出待机，覆盖到下一次计划切换时为止。
+type StandbyService struct {
+	logger          *zap.Logger
+	propertyService *PropertyService
+	serialService   *SerialService
+
+	mu            sync.Mutex
+	active        bool      // 是否已由待机模式开启飞行模式
+	override      string    // 手动覆盖: sleep 或 wake
+	overrideUntil time.Time // 手动覆盖的结束时间，零值表示直到取消
+	stopChan      chan struct{}
+}
+
+// NewStandbyService 创建夜间待机实例
+func NewStandbyService(logger *zap.Logger, propertyService *PropertyService, serialService *SerialService) *StandbyService {
+	return &StandbyService{
+		logger:          logger,
+		propertyService: propertyService,
+		serialService:   serialService,
+		stopChan:        make(chan struct{}),
+	}
+}
+
+// Start 启动定期检查
+func (s *StandbyService) Start() {
+	go func() {
+		ticker := time.NewTicker(StandbyCheckInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				s.Check(context.Background())
+			case <-s.stopChan:
+				return
+			}
+		}
+	}()
+}
+
+// Stop 停止检查
+func (s *StandbyService) Stop() {
+	close(s.stopChan)
+}
+
+// Check 按计划和手动覆盖计算是否应处于待机，与当前状态不同时切换飞行模式
+func (s *StandbyService) Check(ctx context.Context) {
+	config := s.getConfig(ctx)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	if s.override != "" && !s.overrideUntil.IsZero() && !now.Before(s.overrideUntil) {
+		s.logger.Info("待机手动覆盖已到期，恢复按计划执行", zap.String("override", s.override))
+		s.override, s.overrideUntil = "", time.Time{}
+	}
+	s.applyLocked(s.desiredLocked(config, now))
+}
+
+// Status 获取夜间待机状态
+func (s *StandbyService) Status(ctx context.Context) *StandbyStatus {
+	config := s.getConfig(ctx)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	status := &StandbyStatus{
+		StandbyConfig: config,
+		InWindow:      inStandbyWindow(config, time.Now()),
+		Active:        s.active,
+		Override:      s.override,
+	}
+	if !s.overrideUntil.IsZero() {
+		status.OverrideUntil = s.overrideUntil.UnixMilli()
+	}
+	return status
+}
+
+// Override 手动进入（sleep）或退出（wake）待机，minutes 大于 0 时持续指定分钟数，
+// 否则持续到下一次计划切换（未启用计划时直到取消）；auto 取消手动覆盖并立即按计划执行
+func (s *StandbyService) Override(ctx context.Context, action string, minutes int) (*StandbyStatus, error) {
+	config := s.getConfig(ctx)
+	now := time.Now()
+
+	s.mu.Lock()
+	switch action {
+	case StandbyActionAuto:
+		s.override, s.overrideUntil = "", time.Time{}
+	case StandbyActionSleep, StandbyActionWake:
+		s.override = action
+		s.overrideUntil = time.Time{}
+		if minutes > 0 {
+			s.overrideUntil = now.Add(time.Duration(minutes) * time.Minute)
+		} else if config.Enabled {
+			s.overrideUntil = nextStandbyBoundary(config, now)
+		}
+	default:
+		s.mu.Unlock()
+		return nil, ErrInvalidStandbyAction
+	}
+	s.logger.Info("手动设置待机", zap.String("action", action), zap.Time("until", s.overrideUntil))
+	err := s.applyLocked(s.desiredLocked(config, now))
+	s.mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+	return s.Status(ctx), nil
+}
+
+// desiredLocked 当前是否应处于待机，调用方需持有锁
+func (s *StandbyService) desiredLocked(config models.StandbyConfig, now time.Time) bool {
+	switch s.override {
+	case StandbyActionSleep:
+		return true
+	case StandbyActionWake:
+		return false
+	}
+	return inStandbyWindow(config, now)
+}
+
+// applyLocked 所有设备切换到目标状态，有设备未连接时等待下一次检查，调用方需持有锁
+func (s *StandbyService) applyLocked(standby bool) error {
+	if standby == s.active {
+		return nil
+	}
+	deviceIDs := s.serialService.DeviceIDs()
+	for _, deviceID := range deviceIDs {
+		if !s.serialService.connected(deviceID) {
+			return fmt.Errorf("设备 %s 未连接", deviceID)
+		}
+	}
+	for _, deviceID := range deviceIDs {
+		if err := s.serialService.SetFlymode(deviceID, standby); err != nil {
+			s.logger.Error("切换待机状态失败", zap.String("device", deviceID), zap.Bool("standby", standby), zap.Error(err))
+			return err
+		}
+		go s.serialService.RequestCacheUpdate(deviceID)
+	}
+	s.active = standby
+	if standby {
+		s.logger.Info("进入待机，已关闭蜂窝网络")
+	} else {
+		s.logger.Info("退出待机，已恢复蜂窝网络")
+	}
+	return nil
+}
+
+// getConfig 获取夜间待机配置，读取失败时视为未启用
+func (s *StandbyService) getConfig(ctx context.Context) models.StandbyConfig {
+	var config models.StandbyConfig
+	if err := s.propertyService.GetValue(ctx, PropertyIDStandby, &config); err != nil {
+		return DefaultStandbyConfig
+	}
+	return config
+}
+
+// parseClock 解析 HH:MM，返回一天中的分钟数
+func parseClock(value string) (int, bool) {
+	t, err := time.Parse("15:04", value)
+	if err != nil {
+		return 0, false
+	}
+	return t.Hour()*60 + t.Minute(), true
+}
+
+// standbyWindow 解析待机时段，配置无效或未启用时 ok 为 false
+func standbyWindow(config models.StandbyConfig) (start, end int, ok bool) {
+	if !config.Enabled {
+		return 0, 0, false
+	}
+	start, okStart := parseClock(config.Start)
+	end, okEnd := parseClock(config.End)
+	if !okStart || !okEnd || start == end {
+		return 0, 0, false
+	}
+	return start, end, true
+}
+
+// inStandbyWindow 指定时间是否处于待机时段，支持跨过午夜的时段
+func inStandbyWindow(config models.StandbyConfig, now time.Time) bool {
+	start, end, ok := standbyWindow(config)
+	if !ok {
+		return false
+	}
+	minute := now.Hour()*60 + now.Minute()
+	if start < end {
+		return minute >= start && minute < end
+	}
+	return minute >= start || minute < end
+}
+
+// nextStandbyBoundary 下一次计划进入或退出待机的时间，配置无效时返回零值
+func nextStandbyBoundary(config models.StandbyConfig, now time.Time) time.Time {
+	start, end, ok := standbyWindow(config)
+	if !ok {
+		return time.Time{}
+	}
+	midnight := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	var next time.Time
+	for _, minute := range []int{start, end} {
+		at := midnight.Add(time.Duration(minute) * time.Minute)
+		if !at.After(now) {
+			at = at.AddDate(0, 0, 1)
+		}
+		if next.IsZero() || at.Before(next) {
+			next = at
+		}
+	}
+	return next
+}
@@ -0,0 +1,186 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dushixiang/uart_sms_forwarder/internal/models"
+	"go.uber.org/zap"
+)
+
+const (
+	// StorageCheckInterval 存储空间检查间隔
+	StorageCheckInterval = 10 * time.Minute
+	// StorageRealertInterval 告警持续期间的重复提醒间隔
+	StorageRealertInterval = 24 * time.Hour
+)
+
+// DefaultStorageAlertConfig 默认存储空间告警配置
+var DefaultStorageAlertConfig = models.StorageAlertConfig{
+	Enabled:            true,
+	MaxDatabaseSizeMB:  1024,
+	MinFreeDiskMB:      200,
+	MinFreeDiskPercent: 5,
+}
+
+// StorageAlert 单项存储告警
+type StorageAlert struct {
+	Key     string `json:"key"`     // database_size / disk_free / disk_free_percent
+	Message string `json:"message"` // 告警描述
+}
+
+// StorageMonitor 存储空间监控。
+// 数据库增长或磁盘写满会导致短信无法入库，定期检查并在接近阈值时推送通知，
+// 同一项告警在恢复前每 24 小时最多提醒一次。
+type StorageMonitor struct {
+	logger          *zap.Logger
+	systemService   *SystemService
+	propertyService *PropertyService
+	notify          func(ctx context.Context, content string)
+
+	mu       sync.Mutex
+	alerted  map[string]time.Time // 告警项 -> 上次通知时间
+	current  []StorageAlert
+	stopChan chan struct{}
+}
+
+// NewStorageMonitor 创建存储空间监控实例，notify 用于推送告警通知
+func NewStorageMonitor(logger *zap.Logger, systemService *SystemService, propertyService *PropertyService, notify func(ctx context.Context, content string)) *StorageMonitor {
+	return &StorageMonitor{
+		logger:          logger,
+		systemService:   systemService,
+		propertyService: propertyService,
+		notify:          notify,
+		alerted:         make(map[string]time.Time),
+		stopChan:        make(chan struct{}),
+	}
+}
+
+// Start 启动定期检查
+func (m *StorageMonitor) Start() {
+	go func() {
+		m.Check(context.Background())
+
+		ticker := time.NewTicker(StorageCheckInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				m.Check(context.Background())
+			case <-m.stopChan:
+				return
+			}
+		}
+	}()
+}
+
+// Stop 停止检查
+func (m *StorageMonitor) Stop() {
+	close(m.stopChan)
+}
+
+// Alerts 获取当前处于告警状态的项
+func (m *StorageMonitor) Alerts() []StorageAlert {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]StorageAlert{}, m.current...)
+}
+
+// Check 执行一次检查，对新出现或需要重复提醒的告警推送通知
+func (m *StorageMonitor) Check(ctx context.Context) {
+	config, err := m.propertyService.GetStorageAlertConfig(ctx)
+	if err != nil {
+		m.logger.Error("获取存储空间告警配置失败", zap.Error(err))
+		return
+	}
+
+	var alerts []StorageAlert
+	if config.Enabled {
+		alerts = m.evaluate(ctx, config)
+	}
+
+	now := time.Now()
+	var pending []string
+
+	m.mu.Lock()
+	active := make(map[string]bool, len(alerts))
+	for _, alert := range alerts {
+		active[alert.Key] = true
+		if last, ok := m.alerted[alert.Key]; ok && now.Sub(last) < StorageRealertInterval {
+			continue
+		}
+		m.alerted[alert.Key] = now
+		pending = append(pending, alert.Message)
+	}
+	// 已恢复的项清除记录，再次超限时重新通知
+	for key := range m.alerted {
+		if !active[key] {
+			delete(m.alerted, key)
+			m.logger.Info("存储空间告警已恢复", zap.String("key", key))
+		}
+	}
+	m.current = alerts
+	m.mu.Unlock()
+
+	if len(pending) == 0 {
+		return
+	}
+	m.logger.Warn("存储空间告警", zap.Strings("alerts", pending))
+	if m.notify != nil {
+		m.notify(ctx, "存储空间告警\n"+strings.Join(pending, "\n")+"\n请及时清理短信记录或扩容，避免短信无法保存")
+	}
+}
+
+// evaluate 按配置检查数据库大小和磁盘剩余空间
+func (m *StorageMonitor) evaluate(ctx context.Context, config models.StorageAlertConfig) []StorageAlert {
+	var alerts []StorageAlert
+
+	if config.MaxDatabaseSizeMB > 0 {
+		db := m.systemService.DatabaseInfo(ctx)
+		if db.Size > config.MaxDatabaseSizeMB*1024*1024 {
+			alerts = append(alerts, StorageAlert{
+				Key:     "database_size",
+				Message: fmt.Sprintf("数据库大小 %s 已超过阈值 %d MB", formatBytes(uint64(db.Size)), config.MaxDatabaseSizeMB),
+			})
+		}
+	}
+
+	if config.MinFreeDiskMB <= 0 && config.MinFreeDiskPercent <= 0 {
+		return alerts
+	}
+	usage := m.systemService.DiskUsage(ctx)
+	if usage == nil || usage.Total == 0 {
+		return alerts
+	}
+	if config.MinFreeDiskMB > 0 && usage.Free < uint64(config.MinFreeDiskMB)*1024*1024 {
+		alerts = append(alerts, StorageAlert{
+			Key:     "disk_free",
+			Message: fmt.Sprintf("磁盘 %s 剩余空间 %s 低于阈值 %d MB", usage.Path, formatBytes(usage.Free), config.MinFreeDiskMB),
+		})
+	}
+	freePercent := float64(usage.Free) / float64(usage.Total) * 100
+	if config.MinFreeDiskPercent > 0 && freePercent < config.MinFreeDiskPercent {
+		alerts = append(alerts, StorageAlert{
+			Key:     "disk_free_percent",
+			Message: fmt.Sprintf("磁盘 %s 剩余空间 %.1f%% 低于阈值 %.1f%%", usage.Path, freePercent, config.MinFreeDiskPercent),
+		})
+	}
+	return alerts
+}
+
+// formatBytes 格式化字节数
+func formatBytes(n uint64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := uint64(unit), 0
+	for v := n / unit; v >= unit; v /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %cB", float64(n)/float64(div), "KMGTPE"[exp])
+}
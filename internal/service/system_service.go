@@ -0,0 +1,236 @@
+package service
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"time"
+
+	"github.com/shirou/gopsutil/v4/cpu"
+	"github.com/shirou/gopsutil/v4/disk"
+	"github.com/shirou/gopsutil/v4/host"
+	"github.com/shirou/gopsutil/v4/load"
+	"github.com/shirou/gopsutil/v4/mem"
+	"github.com/shirou/gopsutil/v4/process"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// SystemInfo 系统资源信息
+type SystemInfo struct {
+	Host     HostInfo     `json:"host"`
+	CPU      CPUInfo      `json:"cpu"`
+	Memory   MemoryInfo   `json:"memory"`
+	Disk     *DiskInfo    `json:"disk"` // 数据库所在磁盘，获取失败时为空
+	Database DatabaseInfo `json:"database"`
+	Process  ProcessInfo  `json:"process"`
+}
+
+// HostInfo 主机信息
+type HostInfo struct {
+	Hostname string `json:"hostname"`
+	OS       string `json:"os"`
+	Platform string `json:"platform"`
+	Arch     string `json:"arch"`
+	Uptime   uint64 `json:"uptime"` // 主机开机时长，单位为秒
+}
+
+// CPUInfo CPU 信息
+type CPUInfo struct {
+	Cores   int     `json:"cores"`
+	Percent float64 `json:"percent"` // 整体使用率（%）
+	Load1   float64 `json:"load1"`   // 1 分钟平均负载，Windows 不支持时为 0
+	Load5   float64 `json:"load5"`
+	Load15  float64 `json:"load15"`
+}
+
+// MemoryInfo 内存信息（字节）
+type MemoryInfo struct {
+	Total       uint64  `json:"total"`
+	Used        uint64  `json:"used"`
+	Available   uint64  `json:"available"`
+	UsedPercent float64 `json:"usedPercent"`
+}
+
+// DiskInfo 磁盘信息（字节）
+type DiskInfo struct {
+	Path        string  `json:"path"`
+	Total       uint64  `json:"total"`
+	Used        uint64  `json:"used"`
+	Free        uint64  `json:"free"`
+	UsedPercent float64 `json:"usedPercent"`
+}
+
+// DatabaseInfo 数据库信息
+type DatabaseInfo struct {
+	Dialect string `json:"dialect"`
+	Path    string `json:"path"` // 数据库文件路径，仅 SQLite
+	Size    int64  `json:"size"` // 数据库文件大小（含 WAL），单位字节，仅 SQLite
+}
+
+// ProcessInfo 当前进程信息
+type ProcessInfo struct {
+	PID        int32   `json:"pid"`
+	CPUPercent float64 `json:"cpuPercent"`
+	RSS        uint64  `json:"rss"` // 常驻内存（字节）
+	HeapAlloc  uint64  `json:"heapAlloc"`
+	HeapSys    uint64  `json:"heapSys"`
+	Goroutines int     `json:"goroutines"`
+	NumGC      uint32  `json:"numGC"`
+	OpenFiles  int     `json:"openFiles"` // 打开的文件数，不支持的平台为 0
+	Uptime     int64   `json:"uptime"`    // 进程运行时长，单位为秒
+}
+
+// SystemService 系统资源服务
+type SystemService struct {
+	logger *zap.Logger
+	db     *gorm.DB
+}
+
+// NewSystemService 创建系统资源服务实例
+func NewSystemService(logger *zap.Logger, db *gorm.DB) *SystemService {
+	return &SystemService{
+		logger: logger,
+		db:     db,
+	}
+}
+
+// GetSystemInfo 获取系统资源信息，单项获取失败不影响其他项
+func (s *SystemService) GetSystemInfo(ctx context.Context) *SystemInfo {
+	info := &SystemInfo{
+		Host: HostInfo{
+			OS:   runtime.GOOS,
+			Arch: runtime.GOARCH,
+		},
+	}
+
+	if h, err := host.InfoWithContext(ctx); err == nil {
+		info.Host.Hostname = h.Hostname
+		info.Host.Platform = h.Platform
+		info.Host.Uptime = h.Uptime
+	}
+
+	info.CPU.Cores = runtime.NumCPU()
+	// 采样 200ms 计算 CPU 使用率
+	if percents, err := cpu.PercentWithContext(ctx, 200*time.Millisecond, false); err == nil && len(percents) > 0 {
+		info.CPU.Percent = percents[0]
+	}
+	if avg, err := load.AvgWithContext(ctx); err == nil {
+		info.CPU.Load1 = avg.Load1
+		info.CPU.Load5 = avg.Load5
+		info.CPU.Load15 = avg.Load15
+	}
+
+	if vm, err := mem.VirtualMemoryWithContext(ctx); err == nil {
+		info.Memory = MemoryInfo{
+			Total:       vm.Total,
+			Used:        vm.Used,
+			Available:   vm.Available,
+			UsedPercent: vm.UsedPercent,
+		}
+	}
+
+	info.Database = s.DatabaseInfo(ctx)
+	info.Disk = s.DiskUsage(ctx)
+	info.Process = s.processInfo(ctx)
+	return info
+}
+
+// DatabaseInfo 获取数据库类型、文件路径和大小
+func (s *SystemService) DatabaseInfo(ctx context.Context) DatabaseInfo {
+	db := s.db.WithContext(ctx)
+	result := DatabaseInfo{Dialect: db.Dialector.Name()}
+	if result.Dialect != "sqlite" {
+		return result
+	}
+
+	result.Path = sqliteFilePath(db)
+	if result.Path == "" {
+		return result
+	}
+	// WAL 模式下未合并的数据在 -wal 文件中，同样占用磁盘
+	for _, suffix := range []string{"", "-wal", "-shm"} {
+		if st, err := os.Stat(result.Path + suffix); err == nil {
+			result.Size += st.Size()
+		}
+	}
+	return result
+}
+
+// DiskUsage 获取数据库所在磁盘的使用情况，非 SQLite 时取工作目录所在磁盘
+func (s *SystemService) DiskUsage(ctx context.Context) *DiskInfo {
+	path := "."
+	if dbPath := s.DatabaseInfo(ctx).Path; dbPath != "" {
+		path = filepath.Dir(dbPath)
+	}
+	if abs, err := filepath.Abs(path); err == nil {
+		path = abs
+	}
+
+	usage, err := disk.UsageWithContext(ctx, path)
+	if err != nil {
+		s.logger.Debug("获取磁盘使用情况失败", zap.String("path", path), zap.Error(err))
+		return nil
+	}
+	return &DiskInfo{
+		Path:        path,
+		Total:       usage.Total,
+		Used:        usage.Used,
+		Free:        usage.Free,
+		UsedPercent: usage.UsedPercent,
+	}
+}
+
+// processInfo 获取当前进程信息
+func (s *SystemService) processInfo(ctx context.Context) ProcessInfo {
+	var ms runtime.MemStats
+	runtime.ReadMemStats(&ms)
+
+	info := ProcessInfo{
+		PID:        int32(os.Getpid()),
+		HeapAlloc:  ms.HeapAlloc,
+		HeapSys:    ms.HeapSys,
+		Goroutines: runtime.NumGoroutine(),
+		NumGC:      ms.NumGC,
+	}
+
+	p, err := process.NewProcessWithContext(ctx, info.PID)
+	if err != nil {
+		return info
+	}
+	if percent, err := p.CPUPercentWithContext(ctx); err == nil {
+		info.CPUPercent = percent
+	}
+	if memInfo, err := p.MemoryInfoWithContext(ctx); err == nil {
+		info.RSS = memInfo.RSS
+	}
+	if files, err := p.OpenFilesWithContext(ctx); err == nil {
+		info.OpenFiles = len(files)
+	}
+	if createTime, err := p.CreateTimeWithContext(ctx); err == nil {
+		info.Uptime = int64(time.Since(time.UnixMilli(createTime)).Seconds())
+	}
+	return info
+}
+
+// sqliteFilePath 获取 SQLite 主数据库文件路径，内存数据库返回空
+func sqliteFilePath(db *gorm.DB) string {
+	rows, err := db.Raw("PRAGMA database_list").Rows()
+	if err != nil {
+		return ""
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var seq int
+		var name, file string
+		if err := rows.Scan(&seq, &name, &file); err != nil {
+			return ""
+		}
+		if name == "main" {
+			return file
+		}
+	}
+	return ""
+}
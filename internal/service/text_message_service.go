@@ -1,30 +1,116 @@
 package service
 
 import (
+	"cmp"
 	"context"
+	"encoding/base64"
 	"errors"
 	"fmt"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/dushixiang/uart_sms_forwarder/internal/models"
 	"github.com/dushixiang/uart_sms_forwarder/internal/repo"
+	"github.com/go-orz/cache"
 
 	"go.uber.org/zap"
 	"gorm.io/gorm"
 )
 
+const (
+	// statsCacheKey 统计缓存键
+	statsCacheKey = "stats"
+	// statsCacheTTL 统计缓存有效期，写入时主动失效；有效期用于跨天后刷新今日数量
+	statsCacheTTL = time.Minute
+	// MaxDailyStatsDays 每日统计最多查询天数
+	MaxDailyStatsDays = 365
+)
+
 // TextMessageService 短信服务
 type TextMessageService struct {
 	repo   *repo.TextMessageRepo
 	logger *zap.Logger
+	// 统计缓存，仪表盘频繁轮询时避免重复 COUNT
+	statsCache cache.Cache[string, *Stats]
+	dailyCache cache.Cache[int, []DailyStat]
+	// 会话可见性，为空时不限制
+	visibility *VisibilityService
+	// 号码别名，为空时不合并会话
+	aliases *AliasService
 }
 
 // NewTextMessageService 创建短信服务实例
 func NewTextMessageService(logger *zap.Logger, repo *repo.TextMessageRepo) *TextMessageService {
 	return &TextMessageService{
-		repo:   repo,
-		logger: logger,
+		repo:       repo,
+		logger:     logger,
+		statsCache: cache.New[string, *Stats](time.Minute),
+		dailyCache: cache.New[int, []DailyStat](time.Minute),
+	}
+}
+
+// SetVisibilityService 设置会话可见性服务，设置后查询按当前用户的可见范围过滤
+func (s *TextMessageService) SetVisibilityService(visibility *VisibilityService) {
+	s.visibility = visibility
+}
+
+// SetAliasService 设置号码别名服务，设置后别名号码的短信合并到主号码的会话中
+func (s *TextMessageService) SetAliasService(aliases *AliasService) {
+	s.aliases = aliases
+}
+
+// aliasMap 别名到主号码的映射，未设置别名服务时返回 nil
+func (s *TextMessageService) aliasMap(ctx context.Context) (map[string]string, error) {
+	if s.aliases == nil {
+		return nil, nil
+	}
+	return s.aliases.aliasMap(ctx)
+}
+
+// conversationScope 会话消息的查询条件，包含主号码及其所有别名的短信
+func (s *TextMessageService) conversationScope(ctx context.Context, peer string) (func(db *gorm.DB) *gorm.DB, error) {
+	aliases, err := s.aliasMap(ctx)
+	if err != nil {
+		return nil, err
+	}
+	primary := resolveAlias(aliases, peer)
+	peers := append([]string{primary}, aliasesOf(aliases, primary)...)
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where(peerMessagesCondition,
+			models.MessageTypeIncoming, peers,
+			models.MessageTypeOutgoing, peers)
+	}, nil
+}
+
+// visibleScope 当前用户可见短信的查询条件，restricted 为 false 时不受限制
+func (s *TextMessageService) visibleScope(ctx context.Context) (scope func(db *gorm.DB) *gorm.DB, restricted bool, err error) {
+	if s.visibility == nil {
+		return noScope, false, nil
 	}
+	return s.visibility.MessageScope(ctx)
+}
+
+// DailyStat 每日统计
+type DailyStat struct {
+	Date          string `json:"date"` // 日期，如 2024-01-02
+	IncomingCount int64  `json:"incomingCount"`
+	OutgoingCount int64  `json:"outgoingCount"`
+}
+
+// HeatmapStats 按星期和小时分组的收发数量，第一维为星期（0 为周日），第二维为小时（0-23）
+type HeatmapStats struct {
+	Days     int          `json:"days"`     // 统计的天数
+	Incoming [7][24]int64 `json:"incoming"` // 接收数量
+	Outgoing [7][24]int64 `json:"outgoing"` // 发送数量
+}
+
+// invalidateStats 短信写入或删除后清除统计缓存
+func (s *TextMessageService) invalidateStats() {
+	s.statsCache.Reset()
+	s.dailyCache.Reset()
 }
 
 // Stats 统计信息
@@ -37,27 +123,297 @@ type Stats struct {
 
 // Conversation 会话信息
 type Conversation struct {
-	Peer         string              `json:"peer"`         // 对方号码
-	LastMessage  *models.TextMessage `json:"lastMessage"`  // 最后一条消息
-	MessageCount int64               `json:"messageCount"` // 消息总数
-	UnreadCount  int64               `json:"unreadCount"`  // 未读数量（暂时为0）
+	Peer         string              `json:"peer"`              // 对方号码，合并的会话为主号码
+	Aliases      []string            `json:"aliases,omitempty"` // 合并到该会话的别名号码
+	LastMessage  *models.TextMessage `json:"lastMessage"`       // 最后一条消息
+	MessageCount int64               `json:"messageCount"`      // 消息总数
+	UnreadCount  int64               `json:"unreadCount"`       // 未读数量
+}
+
+const (
+	// DefaultPageLimit 分页默认条数
+	DefaultPageLimit = 50
+	// MaxPageLimit 分页最大条数
+	MaxPageLimit = 200
+)
+
+// MessagePage 键集分页结果
+type MessagePage struct {
+	Items      []models.TextMessage `json:"items"`
+	NextCursor string               `json:"nextCursor"` // 下一页（更早的消息）游标，没有更多时为空
+	HasMore    bool                 `json:"hasMore"`
+	Total      *int64               `json:"total,omitempty"`  // 符合条件的总数，仅按页码翻页时返回
+	Pinned     []models.TextMessage `json:"pinned,omitempty"` // 会话中置顶的消息，仅在会话的第一页返回，按置顶时间倒序
+}
+
+// encodeCursor 将游标编码为不透明字符串
+func encodeCursor(msg *models.TextMessage) string {
+	return encodeCursorAt(msg.CreatedAt, msg.ID)
+}
+
+// encodeCursorAt 按创建时间和 ID 编码游标
+func encodeCursorAt(createdAt int64, id string) string {
+	raw := strconv.FormatInt(createdAt, 10) + ":" + id
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// decodeCursor 解析游标，空字符串返回 nil
+func decodeCursor(cursor string) (*repo.MessageCursor, error) {
+	if cursor == "" {
+		return nil, nil
+	}
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, fmt.Errorf("无效的游标")
+	}
+	createdAt, id, ok := strings.Cut(string(raw), ":")
+	if !ok {
+		return nil, fmt.Errorf("无效的游标")
+	}
+	ts, err := strconv.ParseInt(createdAt, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("无效的游标")
+	}
+	return &repo.MessageCursor{CreatedAt: ts, ID: id}, nil
+}
+
+// normalizeLimit 限制分页条数范围
+func normalizeLimit(limit int) int {
+	if limit <= 0 {
+		return DefaultPageLimit
+	}
+	return min(limit, MaxPageLimit)
+}
+
+// findPage 查询一页，多取一条用于判断是否还有更多
+func (s *TextMessageService) findPage(ctx context.Context, scope func(db *gorm.DB) *gorm.DB, cursor string, limit int) (*MessagePage, error) {
+	c, err := decodeCursor(cursor)
+	if err != nil {
+		return nil, err
+	}
+	limit = normalizeLimit(limit)
+	visible, _, err := s.visibleScope(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	messages, err := s.repo.FindBefore(ctx, func(db *gorm.DB) *gorm.DB {
+		return scope(db.Scopes(visible))
+	}, c, limit+1)
+	if err != nil {
+		s.logger.Error("分页查询短信失败", zap.Error(err))
+		return nil, fmt.Errorf("分页查询短信失败: %w", err)
+	}
+
+	page := &MessagePage{Items: messages}
+	if len(messages) > limit {
+		page.Items = messages[:limit]
+		page.HasMore = true
+		page.NextCursor = encodeCursor(&page.Items[limit-1])
+	}
+	return page, nil
+}
+
+// ErrContentSearchEncrypted 短信内容加密存储时无法在数据库中搜索
+var ErrContentSearchEncrypted = errors.New("短信内容已加密存储，不支持按内容搜索")
+
+// ErrMessageNotFound 短信不存在或当前用户不可见，包装 gorm.ErrRecordNotFound
+var ErrMessageNotFound = fmt.Errorf("短信记录不存在: %w", gorm.ErrRecordNotFound)
+
+// MessageFilter 短信列表筛选条件
+type MessageFilter struct {
+	Junk     bool                 // true 时查询垃圾箱，否则查询收件箱
+	Category string               // 号码分类，为空时不筛选
+	Query    string               // 内容关键词，为空时不筛选
+	From     string               // 发送方号码，为空时不筛选
+	Type     models.MessageType   // 消息类型，为空时不筛选
+	Status   models.MessageStatus // 状态，为空时不筛选
+	DeviceID string               // 收发短信的设备 ID，为空时不筛选
+	Since    int64                // 起始时间（时间戳毫秒，包含），0 表示不限制
+	Until    int64                // 截止时间（时间戳毫秒，不包含），0 表示不限制
+}
+
+// Validate 检查筛选条件能否执行
+func (f MessageFilter) Validate() error {
+	if f.Query != "" && messageEncryptionEnabled() {
+		return ErrContentSearchEncrypted
+	}
+	return nil
+}
+
+// scope 将筛选条件转换为查询条件
+func (f MessageFilter) scope() (func(db *gorm.DB) *gorm.DB, error) {
+	if err := f.Validate(); err != nil {
+		return nil, err
+	}
+	return func(db *gorm.DB) *gorm.DB {
+		db = db.Where("spam = ?", f.Junk)
+		if f.Category != "" {
+			db = db.Where("category = ?", f.Category)
+		}
+		if f.Query != "" {
+			db = db.Scopes(repo.ContentContains(f.Query))
+		}
+		if f.From != "" {
+			db = db.Where(`"from" = ?`, f.From)
+		}
+		if f.Type != "" {
+			db = db.Where("type = ?", f.Type)
+		}
+		if f.Status != "" {
+			db = db.Where("status = ?", f.Status)
+		}
+		if f.DeviceID != "" {
+			db = db.Where("device_id = ?", f.DeviceID)
+		}
+		if f.Since > 0 {
+			db = db.Where("created_at >= ?", f.Since)
+		}
+		if f.Until > 0 {
+			db = db.Where("created_at < ?", f.Until)
+		}
+		return db
+	}, nil
+}
+
+// ListMessages 按时间倒序分页获取短信
+func (s *TextMessageService) ListMessages(ctx context.Context, filter MessageFilter, cursor string, limit int) (*MessagePage, error) {
+	scope, err := filter.scope()
+	if err != nil {
+		return nil, err
+	}
+	return s.findPage(ctx, scope, cursor, limit)
+}
+
+// exportBatchSize 导出短信时每批读取的条数
+const exportBatchSize = 500
+
+// ExportMessages 按时间倒序逐条读取符合条件的所有短信，分批查询，不会一次加载全部记录。
+// fn 返回错误时停止导出。
+func (s *TextMessageService) ExportMessages(ctx context.Context, filter MessageFilter, fn func(msg *models.TextMessage) error) error {
+	scope, err := filter.scope()
+	if err != nil {
+		return err
+	}
+	visible, _, err := s.visibleScope(ctx)
+	if err != nil {
+		return err
+	}
+
+	var cursor *repo.MessageCursor
+	for {
+		messages, err := s.repo.FindBefore(ctx, func(db *gorm.DB) *gorm.DB {
+			return scope(db.Scopes(visible))
+		}, cursor, exportBatchSize)
+		if err != nil {
+			return fmt.Errorf("查询短信失败: %w", err)
+		}
+		for i := range messages {
+			if err := fn(&messages[i]); err != nil {
+				return err
+			}
+		}
+		if len(messages) < exportBatchSize {
+			return nil
+		}
+		last := messages[len(messages)-1]
+		cursor = &repo.MessageCursor{CreatedAt: last.CreatedAt, ID: last.ID}
+	}
+}
+
+// ListMessagesByPage 按页码分页获取短信，page 从 1 开始，返回符合条件的总数
+func (s *TextMessageService) ListMessagesByPage(ctx context.Context, filter MessageFilter, page, size int) (*MessagePage, error) {
+	scope, err := filter.scope()
+	if err != nil {
+		return nil, err
+	}
+	size = normalizeLimit(size)
+	page = max(page, 1)
+	visible, _, err := s.visibleScope(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	messages, total, err := s.repo.FindPage(ctx, func(db *gorm.DB) *gorm.DB {
+		return scope(db.Scopes(visible))
+	}, (page-1)*size, size)
+	if err != nil {
+		s.logger.Error("分页查询短信失败", zap.Error(err))
+		return nil, fmt.Errorf("分页查询短信失败: %w", err)
+	}
+	return &MessagePage{
+		Items:   messages,
+		HasMore: int64(page*size) < total,
+		Total:   &total,
+	}, nil
+}
+
+// ListConversationMessages 分页获取会话消息，从最新开始向前翻页，页内按时间正序返回
+func (s *TextMessageService) ListConversationMessages(ctx context.Context, peer, cursor string, limit int) (*MessagePage, error) {
+	scope, err := s.conversationScope(ctx, peer)
+	if err != nil {
+		return nil, err
+	}
+	page, err := s.findPage(ctx, scope, cursor, limit)
+	if err != nil {
+		return nil, err
+	}
+	slices.Reverse(page.Items)
+	if cursor == "" {
+		visible, _, err := s.visibleScope(ctx)
+		if err != nil {
+			return nil, err
+		}
+		err = s.repo.GetDB(ctx).Scopes(visible, scope).
+			Where("pinned_at > 0").
+			Order("pinned_at DESC").
+			Find(&page.Pinned).Error
+		if err != nil {
+			return nil, fmt.Errorf("获取置顶消息失败: %w", err)
+		}
+	}
+	return page, nil
 }
 
 // Save 保存短信记录
 func (s *TextMessageService) Save(ctx context.Context, msg *models.TextMessage) error {
+	// 发出的短信无需阅读
+	if msg.Type == models.MessageTypeOutgoing && msg.ReadAt == 0 {
+		msg.ReadAt = time.Now().UnixMilli()
+	}
 	if err := s.repo.Save(ctx, msg); err != nil {
 		s.logger.Error("保存短信记录失败", zap.Error(err), zap.String("id", msg.ID))
 		return fmt.Errorf("保存短信记录失败: %w", err)
 	}
+	s.invalidateStats()
 	return nil
 }
 
+// importBatchSize 导入历史短信时每批写入的条数
+const importBatchSize = 200
+
+// Import 导入历史短信，ID 已存在的记录跳过，返回实际导入的数量
+func (s *TextMessageService) Import(ctx context.Context, messages []models.TextMessage) (int64, error) {
+	imported, err := s.repo.CreateIgnoreDuplicates(ctx, messages, importBatchSize)
+	if err != nil {
+		s.logger.Error("导入短信记录失败", zap.Error(err))
+		return imported, fmt.Errorf("导入短信记录失败: %w", err)
+	}
+	s.invalidateStats()
+	return imported, nil
+}
+
 // Get 获取单条短信记录
 func (s *TextMessageService) Get(ctx context.Context, id string) (*models.TextMessage, error) {
-	msg, err := s.repo.FindById(ctx, id)
+	visible, _, err := s.visibleScope(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var msg models.TextMessage
+	err = s.repo.GetDB(ctx).Scopes(visible).Where("id = ?", id).First(&msg).Error
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, fmt.Errorf("短信记录不存在")
+			return nil, ErrMessageNotFound
 		}
 		s.logger.Error("获取短信记录失败", zap.Error(err), zap.String("id", id))
 		return nil, fmt.Errorf("获取短信记录失败: %w", err)
@@ -67,28 +423,64 @@ func (s *TextMessageService) Get(ctx context.Context, id string) (*models.TextMe
 
 // Delete 删除单条短信记录
 func (s *TextMessageService) Delete(ctx context.Context, id string) error {
-	if err := s.repo.DeleteById(ctx, id); err != nil {
+	visible, _, err := s.visibleScope(ctx)
+	if err != nil {
+		return err
+	}
+	if err := s.repo.GetDB(ctx).Scopes(visible).Where("id = ?", id).Delete(&models.TextMessage{}).Error; err != nil {
 		s.logger.Error("删除短信记录失败", zap.Error(err), zap.String("id", id))
 		return fmt.Errorf("删除短信记录失败: %w", err)
 	}
+	s.invalidateStats()
 	s.logger.Info("删除短信记录成功", zap.String("id", id))
 	return nil
 }
 
-// Clear 清空所有短信记录
+// Clear 清空当前用户可见的所有短信记录
 func (s *TextMessageService) Clear(ctx context.Context) error {
+	visible, _, err := s.visibleScope(ctx)
+	if err != nil {
+		return err
+	}
 	db := s.repo.GetDB(ctx)
-	if err := db.Where("1 = 1").Delete(&models.TextMessage{}).Error; err != nil {
+	if err := db.Scopes(visible).Where("1 = 1").Delete(&models.TextMessage{}).Error; err != nil {
 		s.logger.Error("清空短信记录失败", zap.Error(err))
 		return fmt.Errorf("清空短信记录失败: %w", err)
 	}
+	s.invalidateStats()
 	s.logger.Info("清空短信记录成功")
 	return nil
 }
 
-// GetStats 获取统计信息
+// GetStats 获取统计信息（带缓存），可见范围受限的用户不使用缓存
 func (s *TextMessageService) GetStats(ctx context.Context) (*Stats, error) {
-	db := s.repo.GetDB(ctx)
+	visible, restricted, err := s.visibleScope(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if restricted {
+		return s.queryStats(ctx, visible)
+	}
+
+	if stats, ok := s.statsCache.Get(statsCacheKey); ok {
+		copied := *stats
+		return &copied, nil
+	}
+
+	stats, err := s.queryStats(ctx, visible)
+	if err != nil {
+		return nil, err
+	}
+	s.statsCache.Set(statsCacheKey, stats, statsCacheTTL)
+
+	copied := *stats
+	return &copied, nil
+}
+
+// queryStats 查询统计信息
+// 只有收、发两种类型，接收数量由总数减去发送数量得出，避免扫描占绝大多数的接收记录
+func (s *TextMessageService) queryStats(ctx context.Context, scope func(db *gorm.DB) *gorm.DB) (*Stats, error) {
+	db := s.repo.GetDB(ctx).Scopes(scope)
 
 	stats := &Stats{}
 
@@ -97,18 +489,17 @@ func (s *TextMessageService) GetStats(ctx context.Context) (*Stats, error) {
 		return nil, fmt.Errorf("统计总数失败: %w", err)
 	}
 
-	// 接收数量
-	if err := db.Model(&models.TextMessage{}).Where("type = ?", "incoming").Count(&stats.IncomingCount).Error; err != nil {
-		return nil, fmt.Errorf("统计接收数量失败: %w", err)
-	}
-
 	// 发送数量
-	if err := db.Model(&models.TextMessage{}).Where("type = ?", "outgoing").Count(&stats.OutgoingCount).Error; err != nil {
+	if err := db.Model(&models.TextMessage{}).Where("type = ?", models.MessageTypeOutgoing).Count(&stats.OutgoingCount).Error; err != nil {
 		return nil, fmt.Errorf("统计发送数量失败: %w", err)
 	}
 
-	// 今日数量（按 created_at 字段）
-	todayStart := time.Now().Truncate(24 * time.Hour).UnixMilli()
+	// 接收数量
+	stats.IncomingCount = stats.TotalCount - stats.OutgoingCount
+
+	// 今日数量（按 created_at 字段，本地时区零点起）
+	now := time.Now()
+	todayStart := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location()).UnixMilli()
 	if err := db.Model(&models.TextMessage{}).Where("created_at >= ?", todayStart).Count(&stats.TodayCount).Error; err != nil {
 		return nil, fmt.Errorf("统计今日数量失败: %w", err)
 	}
@@ -116,15 +507,526 @@ func (s *TextMessageService) GetStats(ctx context.Context) (*Stats, error) {
 	return stats, nil
 }
 
+// GetDailyStats 获取最近 days 天（含今天）的每日收发数量（带缓存），没有消息的日期数量为 0
+func (s *TextMessageService) GetDailyStats(ctx context.Context, days int) ([]DailyStat, error) {
+	if days <= 0 {
+		days = 30
+	}
+	days = min(days, MaxDailyStatsDays)
+
+	visible, restricted, err := s.visibleScope(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if !restricted {
+		if stats, ok := s.dailyCache.Get(days); ok {
+			return slices.Clone(stats), nil
+		}
+	}
+
+	now := time.Now()
+	todayStart := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	start := todayStart.AddDate(0, 0, -(days - 1))
+
+	rows, err := s.repo.CountByDay(ctx, visible, start.UnixMilli(), now)
+	if err != nil {
+		s.logger.Error("查询每日统计失败", zap.Error(err))
+		return nil, fmt.Errorf("查询每日统计失败: %w", err)
+	}
+
+	index := make(map[string]int, days)
+	stats := make([]DailyStat, 0, days)
+	for d := start; !d.After(todayStart); d = d.AddDate(0, 0, 1) {
+		date := d.Format(time.DateOnly)
+		index[date] = len(stats)
+		stats = append(stats, DailyStat{Date: date})
+	}
+	for _, row := range rows {
+		date := time.UnixMilli(row.Day * 86400000).UTC().Format(time.DateOnly)
+		i, ok := index[date]
+		if !ok {
+			continue
+		}
+		switch models.MessageType(row.Type) {
+		case models.MessageTypeIncoming:
+			stats[i].IncomingCount += row.Count
+		case models.MessageTypeOutgoing:
+			stats[i].OutgoingCount += row.Count
+		}
+	}
+
+	if restricted {
+		return stats, nil
+	}
+	s.dailyCache.Set(days, stats, statsCacheTTL)
+	return slices.Clone(stats), nil
+}
+
+// GetHeatmapStats 获取最近 days 天（含今天）按星期和小时分组的收发数量，用于查看短信集中的时段
+func (s *TextMessageService) GetHeatmapStats(ctx context.Context, days int) (*HeatmapStats, error) {
+	if days <= 0 {
+		days = 90
+	}
+	days = min(days, MaxDailyStatsDays)
+
+	visible, _, err := s.visibleScope(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	todayStart := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	start := todayStart.AddDate(0, 0, -(days - 1))
+
+	rows, err := s.repo.CountByHour(ctx, visible, start.UnixMilli(), now)
+	if err != nil {
+		s.logger.Error("查询时段统计失败", zap.Error(err))
+		return nil, fmt.Errorf("查询时段统计失败: %w", err)
+	}
+
+	stats := &HeatmapStats{Days: days}
+	for _, row := range rows {
+		// 1970-01-01 为周四
+		weekday := (row.Hour/24 + int64(time.Thursday)) % 7
+		hour := row.Hour % 24
+		switch models.MessageType(row.Type) {
+		case models.MessageTypeIncoming:
+			stats.Incoming[weekday][hour] += row.Count
+		case models.MessageTypeOutgoing:
+			stats.Outgoing[weekday][hour] += row.Count
+		}
+	}
+	return stats, nil
+}
+
+// busiestHoursLimit 会话统计中返回的最活跃时段数量
+const busiestHoursLimit = 3
+
+// ConversationStats 单个会话的统计信息，用于联系人详情面板
+type ConversationStats struct {
+	Peer            string    `json:"peer"`              // 对方号码，合并的会话为主号码
+	Aliases         []string  `json:"aliases,omitempty"` // 合并到该会话的别名号码
+	TotalCount      int64     `json:"totalCount"`
+	IncomingCount   int64     `json:"incomingCount"`
+	OutgoingCount   int64     `json:"outgoingCount"`
+	FirstAt         int64     `json:"firstAt"`         // 第一条消息的时间（时间戳毫秒），没有消息时为 0
+	LastAt          int64     `json:"lastAt"`          // 最近一条消息的时间（时间戳毫秒），没有消息时为 0
+	AveragePerMonth float64   `json:"averagePerMonth"` // 从第一条到最近一条消息期间平均每月的数量，不足一个月按一个月计算
+	HourCounts      [24]int64 `json:"hourCounts"`      // 按小时（0-23，服务器时区）分组的收发数量
+	BusiestHours    []int     `json:"busiestHours"`    // 消息最多的几个小时，按数量倒序
+}
+
+// GetConversationStats 获取与某个号码的会话统计：收发数量、首次和最近联系时间、月均数量和最活跃的时段
+func (s *TextMessageService) GetConversationStats(ctx context.Context, peer string) (*ConversationStats, error) {
+	visible, _, err := s.visibleScope(ctx)
+	if err != nil {
+		return nil, err
+	}
+	aliases, err := s.aliasMap(ctx)
+	if err != nil {
+		return nil, err
+	}
+	scope, err := s.conversationScope(ctx, peer)
+	if err != nil {
+		return nil, err
+	}
+	primary := resolveAlias(aliases, peer)
+	stats := &ConversationStats{
+		Peer:         primary,
+		Aliases:      aliasesOf(aliases, primary),
+		BusiestHours: []int{},
+	}
+
+	summaries, err := s.repo.SummarizeByType(ctx, func(db *gorm.DB) *gorm.DB {
+		return db.Scopes(visible, scope)
+	})
+	if err != nil {
+		s.logger.Error("查询会话统计失败", zap.Error(err), zap.String("peer", peer))
+		return nil, fmt.Errorf("查询会话统计失败: %w", err)
+	}
+	for _, row := range summaries {
+		switch models.MessageType(row.Type) {
+		case models.MessageTypeIncoming:
+			stats.IncomingCount += row.Count
+		case models.MessageTypeOutgoing:
+			stats.OutgoingCount += row.Count
+		}
+		if stats.FirstAt == 0 || row.FirstAt < stats.FirstAt {
+			stats.FirstAt = row.FirstAt
+		}
+		stats.LastAt = max(stats.LastAt, row.LastAt)
+	}
+	stats.TotalCount = stats.IncomingCount + stats.OutgoingCount
+	if stats.TotalCount == 0 {
+		return stats, nil
+	}
+
+	// 按平均每月 30.44 天计算
+	months := float64(stats.LastAt-stats.FirstAt) / (30.44 * 24 * 3600 * 1000)
+	stats.AveragePerMonth = math.Round(float64(stats.TotalCount)/max(months, 1)*100) / 100
+
+	rows, err := s.repo.CountByHour(ctx, func(db *gorm.DB) *gorm.DB {
+		return db.Scopes(visible, scope)
+	}, 0, time.Now())
+	if err != nil {
+		s.logger.Error("查询会话时段统计失败", zap.Error(err), zap.String("peer", peer))
+		return nil, fmt.Errorf("查询会话时段统计失败: %w", err)
+	}
+	for _, row := range rows {
+		stats.HourCounts[row.Hour%24] += row.Count
+	}
+	hours := make([]int, 0, 24)
+	for hour, count := range stats.HourCounts {
+		if count > 0 {
+			hours = append(hours, hour)
+		}
+	}
+	slices.SortStableFunc(hours, func(a, b int) int {
+		return cmp.Compare(stats.HourCounts[b], stats.HourCounts[a])
+	})
+	stats.BusiestHours = hours[:min(len(hours), busiestHoursLimit)]
+	return stats, nil
+}
+
+// 批量操作类型
+const (
+	BatchOperationDelete   = "delete"
+	BatchOperationMarkRead = "markRead"
+	BatchOperationTag      = "tag"
+	// MaxBatchSize 单次批量操作最多处理的短信数量
+	MaxBatchSize = 1000
+)
+
+// BatchRequest 批量操作请求
+type BatchRequest struct {
+	IDs       []string `json:"ids"`
+	Operation string   `json:"operation"` // delete / markRead / tag
+	Tags      []string `json:"tags"`      // tag 操作时追加的标签
+}
+
+// Batch 在一个事务中对多条短信执行同一操作，返回受影响的数量
+func (s *TextMessageService) Batch(ctx context.Context, req BatchRequest) (int64, error) {
+	if len(req.IDs) == 0 {
+		return 0, fmt.Errorf("ids 不能为空")
+	}
+	if len(req.IDs) > MaxBatchSize {
+		return 0, fmt.Errorf("单次最多操作 %d 条短信", MaxBatchSize)
+	}
+
+	var tags []string
+	if req.Operation == BatchOperationTag {
+		tags = normalizeTags(req.Tags)
+		if len(tags) == 0 {
+			return 0, fmt.Errorf("tags 不能为空")
+		}
+	}
+
+	visible, _, err := s.visibleScope(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	var affected int64
+	err = s.repo.GetDB(ctx).Transaction(func(tx *gorm.DB) error {
+		switch req.Operation {
+		case BatchOperationDelete:
+			result := tx.Scopes(visible).Where("id IN ?", req.IDs).Delete(&models.TextMessage{})
+			affected = result.RowsAffected
+			return result.Error
+		case BatchOperationMarkRead:
+			result := tx.Model(&models.TextMessage{}).
+				Scopes(visible).
+				Where("id IN ? AND read_at = 0", req.IDs).
+				Update("read_at", time.Now().UnixMilli())
+			affected = result.RowsAffected
+			return result.Error
+		case BatchOperationTag:
+			var messages []models.TextMessage
+			if err := tx.Scopes(visible).Select("id", "tags").Where("id IN ?", req.IDs).Find(&messages).Error; err != nil {
+				return err
+			}
+			for _, msg := range messages {
+				merged := normalizeTags(append(msg.Tags, tags...))
+				if len(merged) == len(msg.Tags) {
+					continue
+				}
+				if err := tx.Model(&models.TextMessage{ID: msg.ID}).
+					Select("tags").
+					Updates(&models.TextMessage{Tags: merged}).Error; err != nil {
+					return err
+				}
+				affected++
+			}
+			return nil
+		default:
+			return fmt.Errorf("不支持的操作: %s", req.Operation)
+		}
+	})
+	if err != nil {
+		s.logger.Error("批量操作短信失败", zap.Error(err), zap.String("operation", req.Operation))
+		return 0, err
+	}
+
+	s.invalidateStats()
+	s.logger.Info("批量操作短信成功",
+		zap.String("operation", req.Operation),
+		zap.Int("requested", len(req.IDs)),
+		zap.Int64("affected", affected))
+	return affected, nil
+}
+
+// normalizeTags 去除空白和重复的标签，保持原有顺序
+func normalizeTags(tags []string) []string {
+	result := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		tag = strings.TrimSpace(tag)
+		if tag != "" && !slices.Contains(result, tag) {
+			result = append(result, tag)
+		}
+	}
+	return result
+}
+
+// SuggestLimit 每类搜索建议的最大数量
+const SuggestLimit = 10
+
+// Suggestions 搜索建议
+type Suggestions struct {
+	Senders  []string `json:"senders"`  // 发来过短信的号码
+	Contacts []string `json:"contacts"` // 发送过短信的号码
+	Tags     []string `json:"tags"`     // 标签
+}
+
+// Suggest 根据部分输入返回匹配的号码和标签，用于搜索框自动补全
+func (s *TextMessageService) Suggest(ctx context.Context, query string) (*Suggestions, error) {
+	query = strings.TrimSpace(query)
+	result := &Suggestions{Senders: []string{}, Contacts: []string{}, Tags: []string{}}
+	if query == "" {
+		return result, nil
+	}
+
+	visible, _, err := s.visibleScope(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if result.Senders, err = s.repo.FindDistinctPeers(ctx, visible, models.MessageTypeIncoming, query, SuggestLimit); err != nil {
+		return nil, fmt.Errorf("查询发送方失败: %w", err)
+	}
+	if result.Contacts, err = s.repo.FindDistinctPeers(ctx, visible, models.MessageTypeOutgoing, query, SuggestLimit); err != nil {
+		return nil, fmt.Errorf("查询联系人失败: %w", err)
+	}
+	// 别名号码按主号码显示，选择后打开合并的会话
+	aliases, err := s.aliasMap(ctx)
+	if err != nil {
+		return nil, err
+	}
+	result.Senders = resolvePeers(aliases, result.Senders)
+	result.Contacts = resolvePeers(aliases, result.Contacts)
+
+	tagLists, err := s.repo.FindTagsLike(ctx, visible, query, 500)
+	if err != nil {
+		return nil, fmt.Errorf("查询标签失败: %w", err)
+	}
+	lowerQuery := strings.ToLower(query)
+	for _, tags := range tagLists {
+		for _, tag := range tags {
+			if len(result.Tags) >= SuggestLimit {
+				break
+			}
+			if strings.Contains(strings.ToLower(tag), lowerQuery) && !slices.Contains(result.Tags, tag) {
+				result.Tags = append(result.Tags, tag)
+			}
+		}
+	}
+
+	return result, nil
+}
+
 func (s *TextMessageService) UpdateStatusById(ctx context.Context, id string, status models.MessageStatus) error {
 	return s.repo.UpdateColumnsById(ctx, id, map[string]interface{}{
 		"status": status,
 	})
 }
 
-// GetConversations 获取会话列表（按对方号码分组）
+// FindByIdempotencyKey 获取创建时间不早于 since、使用该幂等键的发送短信，不存在时返回 nil
+func (s *TextMessageService) FindByIdempotencyKey(ctx context.Context, key string, since int64) (*models.TextMessage, error) {
+	var messages []models.TextMessage
+	err := s.repo.GetDB(ctx).
+		Where("idempotency_key = ? AND type = ? AND created_at >= ?", key, models.MessageTypeOutgoing, since).
+		Order("created_at DESC").
+		Limit(1).
+		Find(&messages).Error
+	if err != nil {
+		return nil, fmt.Errorf("查询幂等键失败: %w", err)
+	}
+	if len(messages) == 0 {
+		return nil, nil
+	}
+	return &messages[0], nil
+}
+
+// FindRecipientsSince 查询 since（时间戳毫秒）之后发送过短信的不重复号码，包括发送失败的记录，不含被拒绝发送的记录
+func (s *TextMessageService) FindRecipientsSince(ctx context.Context, since int64) ([]string, error) {
+	var recipients []string
+	err := s.repo.GetDB(ctx).Model(&models.TextMessage{}).
+		Where("type = ? AND status <> ? AND created_at >= ?", models.MessageTypeOutgoing, models.MessageStatusRejected, since).
+		Distinct(`"to"`).
+		Pluck(`"to"`, &recipients).Error
+	if err != nil {
+		return nil, fmt.Errorf("查询发送号码失败: %w", err)
+	}
+	return recipients, nil
+}
+
+// FindOutgoingSince 查询 since（时间戳毫秒）之后发送的短信内容和时间，不含发送失败和被拒绝发送的短信
+func (s *TextMessageService) FindOutgoingSince(ctx context.Context, since int64) ([]models.TextMessage, error) {
+	var messages []models.TextMessage
+	err := s.repo.GetDB(ctx).
+		Select("content", "created_at").
+		Where("type = ? AND status NOT IN ? AND created_at >= ?", models.MessageTypeOutgoing,
+			[]models.MessageStatus{models.MessageStatusFailed, models.MessageStatusRejected}, since).
+		Find(&messages).Error
+	if err != nil {
+		return nil, fmt.Errorf("查询已发送短信失败: %w", err)
+	}
+	return messages, nil
+}
+
+// ClearIdempotencyKey 清除短信的幂等键
+func (s *TextMessageService) ClearIdempotencyKey(ctx context.Context, id string) error {
+	return s.repo.UpdateColumnsById(ctx, id, map[string]interface{}{
+		"idempotency_key": "",
+	})
+}
+
+// Ack 确认已处理短信，记录确认人和备注。只有首次确认生效，已被其他人确认时返回该确认记录，acked 为 false
+func (s *TextMessageService) Ack(ctx context.Context, id, by, note string) (msg *models.TextMessage, acked bool, err error) {
+	msg, err = s.Get(ctx, id)
+	if err != nil {
+		return nil, false, err
+	}
+	if msg.AckedAt > 0 {
+		return msg, false, nil
+	}
+
+	ackedAt := time.Now().UnixMilli()
+	// 多人同时确认时只有一个更新生效
+	result := s.repo.GetDB(ctx).Model(&models.TextMessage{}).
+		Where("id = ? AND acked_at = 0", id).
+		Updates(map[string]interface{}{
+			"acked_at": ackedAt,
+			"acked_by": by,
+			"ack_note": note,
+		})
+	if result.Error != nil {
+		return nil, false, fmt.Errorf("确认短信失败: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		msg, err = s.Get(ctx, id)
+		return msg, false, err
+	}
+	msg.AckedAt, msg.AckedBy, msg.AckNote = ackedAt, by, note
+	return msg, true, nil
+}
+
+// UpdateSendResultById 更新发送状态及失败的错误码和原因，发送成功时 code 和 reason 为空即清除之前的失败信息
+func (s *TextMessageService) UpdateSendResultById(ctx context.Context, id string, status models.MessageStatus, code, reason string) error {
+	// 送达报告可能早于发送结果到达，已有送达状态时不再覆盖
+	return s.repo.GetDB(ctx).Model(&models.TextMessage{}).
+		Where("id = ? AND status NOT IN ?", id, []models.MessageStatus{models.MessageStatusDelivered, models.MessageStatusDeliveryFailed}).
+		Updates(map[string]interface{}{
+			"status":         status,
+			"failure_code":   code,
+			"failure_reason": reason,
+		}).Error
+}
+
+// UpdateDeliveryById 记录短信的送达报告，未送达时 code、reason 为运营商返回的状态码和原因
+func (s *TextMessageService) UpdateDeliveryById(ctx context.Context, id string, delivered bool, code, reason string) error {
+	status := models.MessageStatusDelivered
+	if !delivered {
+		status = models.MessageStatusDeliveryFailed
+	}
+	return s.repo.UpdateColumnsById(ctx, id, map[string]interface{}{
+		"status":         status,
+		"failure_code":   code,
+		"failure_reason": reason,
+		"delivered_at":   time.Now().UnixMilli(),
+	})
+}
+
+// FindPendingApproval 获取所有待审批的发送短信，按创建时间排序
+func (s *TextMessageService) FindPendingApproval(ctx context.Context) ([]models.TextMessage, error) {
+	var messages []models.TextMessage
+	err := s.repo.GetDB(ctx).
+		Where("type = ? AND status = ?", models.MessageTypeOutgoing, models.MessageStatusPendingApproval).
+		Order("created_at").
+		Find(&messages).Error
+	if err != nil {
+		return nil, fmt.Errorf("查询待审批短信失败: %w", err)
+	}
+	return messages, nil
+}
+
+// ReviewPending 将待审批的短信更新为 status 并记录审批人，reason 为拒绝原因。
+// 只更新仍在待审批的短信，reviewed 为 false 表示短信不存在或已被审批
+func (s *TextMessageService) ReviewPending(ctx context.Context, id string, status models.MessageStatus, reviewer, reason string) (reviewed bool, err error) {
+	result := s.repo.GetDB(ctx).Model(&models.TextMessage{}).
+		Where("id = ? AND status = ?", id, models.MessageStatusPendingApproval).
+		Updates(map[string]interface{}{
+			"status":         status,
+			"failure_reason": reason,
+			"reviewed_by":    reviewer,
+			"reviewed_at":    time.Now().UnixMilli(),
+		})
+	if result.Error != nil {
+		return false, fmt.Errorf("更新审批结果失败: %w", result.Error)
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// FailStuckSending 将创建时间早于 before 且仍在发送中的短信标记为发送失败，返回更新的数量
+func (s *TextMessageService) FailStuckSending(ctx context.Context, before int64) (int64, error) {
+	result := s.repo.GetDB(ctx).
+		Model(&models.TextMessage{}).
+		Where("type = ? AND status = ? AND created_at < ?", models.MessageTypeOutgoing, models.MessageStatusSending, before).
+		Updates(map[string]interface{}{
+			"status":         models.MessageStatusFailed,
+			"failure_reason": "程序重启，未收到设备返回的发送结果",
+		})
+	if result.Error != nil {
+		return 0, fmt.Errorf("更新发送中的短信失败: %w", result.Error)
+	}
+	if result.RowsAffected > 0 {
+		s.invalidateStats()
+	}
+	return result.RowsAffected, nil
+}
+
+// FindUnconfirmedOutgoing 获取设备在 since 之后提交、仍在发送中或已标记失败的发送短信，按创建时间倒序。
+// deviceIDs 为设备 ID，主设备同时包含多设备支持之前没有设备 ID 的记录
+func (s *TextMessageService) FindUnconfirmedOutgoing(ctx context.Context, deviceIDs []string, since int64, limit int) ([]models.TextMessage, error) {
+	var messages []models.TextMessage
+	err := s.repo.GetDB(ctx).
+		Where("type = ? AND status IN ? AND created_at > ? AND device_id IN ?", models.MessageTypeOutgoing,
+			[]models.MessageStatus{models.MessageStatusSending, models.MessageStatusFailed}, since, deviceIDs).
+		Order("created_at DESC").
+		Limit(limit).
+		Find(&messages).Error
+	return messages, err
+}
+
+// GetConversations 获取会话列表（按对方号码分组，别名号码合并到主号码的会话）
 func (s *TextMessageService) GetConversations(ctx context.Context) ([]*Conversation, error) {
-	db := s.repo.GetDB(ctx)
+	visible, _, err := s.visibleScope(ctx)
+	if err != nil {
+		return nil, err
+	}
+	aliases, err := s.aliasMap(ctx)
+	if err != nil {
+		return nil, err
+	}
+	db := s.repo.GetDB(ctx).Scopes(visible)
 
 	// 获取所有短信记录，按创建时间倒序
 	var messages []models.TextMessage
@@ -149,11 +1051,13 @@ func (s *TextMessageService) GetConversations(ctx context.Context) ([]*Conversat
 		if peer == "" {
 			continue
 		}
+		peer = resolveAlias(aliases, peer)
 
 		// 如果会话不存在，创建新会话
 		if _, exists := conversationMap[peer]; !exists {
 			conversationMap[peer] = &Conversation{
 				Peer:         peer,
+				Aliases:      aliasesOf(aliases, peer),
 				LastMessage:  msg,
 				MessageCount: 0,
 				UnreadCount:  0,
@@ -162,6 +1066,9 @@ func (s *TextMessageService) GetConversations(ctx context.Context) ([]*Conversat
 
 		// 更新消息数量
 		conversationMap[peer].MessageCount++
+		if msg.Type == models.MessageTypeIncoming && msg.ReadAt == 0 {
+			conversationMap[peer].UnreadCount++
+		}
 
 		// 更新最后一条消息（取最新的）
 		if msg.CreatedAt > conversationMap[peer].LastMessage.CreatedAt {
@@ -189,15 +1096,19 @@ func (s *TextMessageService) GetConversations(ctx context.Context) ([]*Conversat
 
 // GetConversationMessages 获取指定会话的所有消息
 func (s *TextMessageService) GetConversationMessages(ctx context.Context, peer string) ([]models.TextMessage, error) {
-	db := s.repo.GetDB(ctx)
+	visible, _, err := s.visibleScope(ctx)
+	if err != nil {
+		return nil, err
+	}
+	scope, err := s.conversationScope(ctx, peer)
+	if err != nil {
+		return nil, err
+	}
+	db := s.repo.GetDB(ctx).Scopes(visible, scope)
 
 	var messages []models.TextMessage
 
-	// 查询条件：(type=incoming AND from=peer) OR (type=outgoing AND to=peer)
-	if err := db.Where("(type = ? AND \"from\" = ?) OR (type = ? AND \"to\" = ?)",
-		models.MessageTypeIncoming, peer,
-		models.MessageTypeOutgoing, peer,
-	).Order("created_at ASC").Find(&messages).Error; err != nil {
+	if err := db.Order("created_at ASC").Order("id ASC").Find(&messages).Error; err != nil {
 		s.logger.Error("获取会话消息失败", zap.Error(err), zap.String("peer", peer))
 		return nil, fmt.Errorf("获取会话消息失败: %w", err)
 	}
@@ -205,20 +1116,107 @@ func (s *TextMessageService) GetConversationMessages(ctx context.Context, peer s
 	return messages, nil
 }
 
-// DeleteConversation 删除整个会话（与某个联系人的所有消息）
+// GetConversationMessagesPinnedFirst 获取会话的所有消息，置顶的消息按置顶时间倒序排在最前，其余按时间正序
+func (s *TextMessageService) GetConversationMessagesPinnedFirst(ctx context.Context, peer string) ([]models.TextMessage, error) {
+	messages, err := s.GetConversationMessages(ctx, peer)
+	if err != nil {
+		return nil, err
+	}
+	slices.SortStableFunc(messages, func(a, b models.TextMessage) int {
+		// 未置顶的 PinnedAt 为 0，排在所有置顶消息之后并保持原有顺序
+		return cmp.Compare(b.PinnedAt, a.PinnedAt)
+	})
+	return messages, nil
+}
+
+// SetPinned 在会话中置顶或取消置顶短信，重复置顶保留首次置顶的时间
+func (s *TextMessageService) SetPinned(ctx context.Context, id string, pinned bool) (*models.TextMessage, error) {
+	msg, err := s.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if pinned == (msg.PinnedAt > 0) {
+		return msg, nil
+	}
+	var pinnedAt int64
+	if pinned {
+		pinnedAt = time.Now().UnixMilli()
+	}
+	if err := s.repo.UpdateColumnsById(ctx, id, map[string]interface{}{
+		"pinned_at": pinnedAt,
+	}); err != nil {
+		return nil, fmt.Errorf("更新置顶状态失败: %w", err)
+	}
+	msg.PinnedAt = pinnedAt
+	return msg, nil
+}
+
+// ExportConversation 将会话导出为可读的文本记录
+func (s *TextMessageService) ExportConversation(ctx context.Context, peer string) (string, error) {
+	messages, err := s.GetConversationMessages(ctx, peer)
+	if err != nil {
+		return "", err
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "与 %s 的短信记录\n", peer)
+	fmt.Fprintf(&b, "导出时间：%s\n", time.Now().Format(time.DateTime))
+	fmt.Fprintf(&b, "共 %d 条\n", len(messages))
+
+	for _, msg := range messages {
+		// 合并的会话中显示实际收发的号码
+		var direction string
+		if msg.Type == models.MessageTypeIncoming {
+			direction = msg.From + " → 我"
+		} else {
+			direction = "我 → " + msg.To
+			switch msg.Status {
+			case models.MessageStatusFailed:
+				direction += "（发送失败）"
+			case models.MessageStatusSending:
+				direction += "（发送中）"
+			case models.MessageStatusDelivered:
+				direction += "（已送达）"
+			case models.MessageStatusDeliveryFailed:
+				direction += "（未送达）"
+			case models.MessageStatusPendingApproval:
+				direction += "（待审批）"
+			case models.MessageStatusRejected:
+				direction += "（已拒绝）"
+			}
+		}
+
+		fmt.Fprintf(&b, "\n[%s] %s\n", time.UnixMilli(msg.CreatedAt).Format(time.DateTime), direction)
+		for _, line := range strings.Split(strings.ReplaceAll(msg.Content, "\r\n", "\n"), "\n") {
+			b.WriteString("  ")
+			b.WriteString(line)
+			b.WriteString("\n")
+		}
+	}
+
+	return b.String(), nil
+}
+
+// DeleteConversation 删除整个会话（与某个联系人的所有消息，包括合并的别名号码）
 func (s *TextMessageService) DeleteConversation(ctx context.Context, peer string) error {
-	db := s.repo.GetDB(ctx)
+	visible, _, err := s.visibleScope(ctx)
+	if err != nil {
+		return err
+	}
+	scope, err := s.conversationScope(ctx, peer)
+	if err != nil {
+		return err
+	}
+	db := s.repo.GetDB(ctx).Scopes(visible, scope)
 
-	// 删除条件：(type=incoming AND from=peer) OR (type=outgoing AND to=peer)
-	result := db.Where("(type = ? AND \"from\" = ?) OR (type = ? AND \"to\" = ?)",
-		models.MessageTypeIncoming, peer,
-		models.MessageTypeOutgoing, peer,
-	).Delete(&models.TextMessage{})
+	// 合并的会话同时删除所有别名号码的短信
+	result := db.Delete(&models.TextMessage{})
 
 	if result.Error != nil {
 		s.logger.Error("删除会话失败", zap.Error(result.Error), zap.String("peer", peer))
 		return fmt.Errorf("删除会话失败: %w", result.Error)
 	}
+	s.invalidateStats()
 
 	s.logger.Info("删除会话成功", zap.String("peer", peer), zap.Int64("deleted_count", result.RowsAffected))
 	return nil
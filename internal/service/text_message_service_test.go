@@ -0,0 +1,94 @@
+package service
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/dushixiang/uart_sms_forwarder/internal/migration"
+	"github.com/dushixiang/uart_sms_forwarder/internal/repo"
+	"github.com/glebarez/sqlite"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+const (
+	// benchmarkMessages 基准测试的短信数量
+	benchmarkMessages = 500000
+	// benchmarkPeers 基准测试的号码数量
+	benchmarkPeers = 2000
+)
+
+// newBenchmarkMessageService 创建临时 SQLite 数据库，执行迁移后写入 500k 条短信：
+// 2000 个号码，80% 为接收，时间分布在最近 100 天
+func newBenchmarkMessageService(b *testing.B) *TextMessageService {
+	b.Helper()
+	db, err := gorm.Open(sqlite.Open(filepath.Join(b.TempDir(), "bench.db")), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		b.Fatalf("打开数据库失败: %v", err)
+	}
+	if err := migration.NewRunner(zap.NewNop(), db).Up(); err != nil {
+		b.Fatalf("执行迁移失败: %v", err)
+	}
+
+	now := time.Now().UnixMilli()
+	step := int64(100*24*time.Hour/time.Millisecond) / benchmarkMessages
+	err = db.Exec(`
+		WITH RECURSIVE seq(n) AS (SELECT 1 UNION ALL SELECT n + 1 FROM seq WHERE n < ?)
+		INSERT INTO text_messages (id, "from", "to", content, type, status, spam, created_at, updated_at)
+		SELECT
+			printf('msg-%07d', n),
+			CASE WHEN n % 5 = 0 THEN 'self' ELSE printf('1380000%04d', n % ?) END,
+			CASE WHEN n % 5 = 0 THEN printf('1380000%04d', n % ?) ELSE 'self' END,
+			'您的验证码是 123456，5 分钟内有效',
+			CASE WHEN n % 5 = 0 THEN 'outgoing' ELSE 'incoming' END,
+			CASE WHEN n % 5 = 0 THEN 'sent' ELSE 'received' END,
+			false,
+			? - (? - n) * ?,
+			? - (? - n) * ?
+		FROM seq`,
+		benchmarkMessages, benchmarkPeers, benchmarkPeers,
+		now, benchmarkMessages, step,
+		now, benchmarkMessages, step,
+	).Error
+	if err != nil {
+		b.Fatalf("写入测试数据失败: %v", err)
+	}
+	if err := db.Exec("ANALYZE").Error; err != nil {
+		b.Fatalf("更新统计信息失败: %v", err)
+	}
+	return NewTextMessageService(zap.NewNop(), repo.NewTextMessageRepo(db))
+}
+
+// BenchmarkGetStats 统计查询，每次清除缓存以测量实际查询
+func BenchmarkGetStats(b *testing.B) {
+	s := newBenchmarkMessageService(b)
+	ctx := context.Background()
+	b.Run("500k", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			s.invalidateStats()
+			if _, err := s.GetStats(ctx); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+// BenchmarkGetConversationMessages 单个会话（约 250 条）的消息查询
+func BenchmarkGetConversationMessages(b *testing.B) {
+	s := newBenchmarkMessageService(b)
+	ctx := context.Background()
+	b.Run("500k", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			messages, err := s.GetConversationMessages(ctx, "13800000042")
+			if err != nil {
+				b.Fatal(err)
+			}
+			if len(messages) == 0 {
+				b.Fatal("会话没有消息")
+			}
+		}
+	})
+}
@@ -0,0 +1,230 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/dushixiang/uart_sms_forwarder/internal/models"
+	"github.com/dushixiang/uart_sms_forwarder/internal/repo"
+	"github.com/go-orz/orz"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+const (
+	// transactionRebuildBatch 重新解析时每批读取的短信数
+	transactionRebuildBatch = 500
+	// MaxSummaryMonths 月度汇总最多返回的月数
+	MaxSummaryMonths = 36
+	// monthLayout 月份参数格式
+	monthLayout = "2006-01"
+)
+
+// MonthlySummary 月度收支汇总（金额单位：分）
+type MonthlySummary struct {
+	Month        string `json:"month"` // 2006-01
+	Expense      int64  `json:"expense"`
+	Income       int64  `json:"income"`
+	ExpenseCount int64  `json:"expenseCount"`
+	IncomeCount  int64  `json:"incomeCount"`
+}
+
+// MerchantSummary 商户支出汇总（金额单位：分）
+type MerchantSummary struct {
+	Merchant string `json:"merchant"`
+	Amount   int64  `json:"amount"`
+	Count    int64  `json:"count"`
+}
+
+// TransactionService 银行交易服务，从银行通知短信中解析金额、余额和商户
+type TransactionService struct {
+	logger          *zap.Logger
+	repo            *repo.TransactionRepo
+	textMessageRepo *repo.TextMessageRepo
+	// 会话可见性，为空时不限制
+	visibility *VisibilityService
+}
+
+// NewTransactionService 创建银行交易服务实例
+func NewTransactionService(logger *zap.Logger, db *gorm.DB, textMessageRepo *repo.TextMessageRepo) *TransactionService {
+	return &TransactionService{
+		logger:          logger,
+		repo:            repo.NewTransactionRepo(db),
+		textMessageRepo: textMessageRepo,
+	}
+}
+
+// SetVisibilityService 设置会话可见性服务，隐藏会话中的银行短信解析出的交易对该用户不可见
+func (s *TransactionService) SetVisibilityService(visibility *VisibilityService) {
+	s.visibility = visibility
+}
+
+// findBetween 查询当前用户可见的 [start, end) 时间范围内的交易
+func (s *TransactionService) findBetween(ctx context.Context, start, end int64) ([]models.Transaction, error) {
+	scope := noScope
+	if s.visibility != nil {
+		var err error
+		if scope, err = s.visibility.TransactionScope(ctx); err != nil {
+			return nil, err
+		}
+	}
+	return s.repo.FindBetween(ctx, scope, start, end)
+}
+
+// Record 解析收到的短信，是银行动账通知时保存交易记录
+func (s *TransactionService) Record(ctx context.Context, msg *models.TextMessage) {
+	if msg.Type != models.MessageTypeIncoming {
+		return
+	}
+	tx := parseBankSMS(msg.From, msg.Content)
+	if tx == nil {
+		return
+	}
+	tx.ID = uuid.NewString()
+	tx.MessageID = msg.ID
+	tx.OccurredAt = msg.CreatedAt
+	if err := s.repo.CreateIgnoreDuplicate(ctx, tx); err != nil {
+		s.logger.Error("保存交易记录失败", zap.Error(err), zap.String("message_id", msg.ID))
+		return
+	}
+	s.logger.Info("已解析银行交易",
+		zap.String("bank", tx.Bank),
+		zap.String("direction", string(tx.Direction)),
+		zap.Int64("amount", tx.Amount))
+}
+
+// Rebuild 清空交易记录并重新解析所有收到的短信，返回解析出的交易数。
+// 已删除短信对应的交易记录不会保留。
+func (s *TransactionService) Rebuild(ctx context.Context) (int, error) {
+	incoming := func(db *gorm.DB) *gorm.DB {
+		return db.Where("type = ?", models.MessageTypeIncoming)
+	}
+
+	count := 0
+	err := s.repo.GetDB(ctx).Transaction(func(db *gorm.DB) error {
+		txCtx := orz.WithTx(ctx, db)
+		if err := s.repo.DeleteAll(txCtx); err != nil {
+			return fmt.Errorf("清空交易记录失败: %w", err)
+		}
+
+		var cursor *repo.MessageCursor
+		for {
+			messages, err := s.textMessageRepo.FindBefore(txCtx, incoming, cursor, transactionRebuildBatch)
+			if err != nil {
+				return err
+			}
+			for i := range messages {
+				tx := parseBankSMS(messages[i].From, messages[i].Content)
+				if tx == nil {
+					continue
+				}
+				tx.ID = uuid.NewString()
+				tx.MessageID = messages[i].ID
+				tx.OccurredAt = messages[i].CreatedAt
+				if err := s.repo.CreateIgnoreDuplicate(txCtx, tx); err != nil {
+					return err
+				}
+				count++
+			}
+			if len(messages) < transactionRebuildBatch {
+				return nil
+			}
+			last := messages[len(messages)-1]
+			cursor = &repo.MessageCursor{CreatedAt: last.CreatedAt, ID: last.ID}
+		}
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	s.logger.Info("交易记录重新解析完成", zap.Int("count", count))
+	return count, nil
+}
+
+// ListByMonth 查询指定月份（2006-01，本地时区）的交易
+func (s *TransactionService) ListByMonth(ctx context.Context, month string) ([]models.Transaction, error) {
+	start, err := time.ParseInLocation(monthLayout, month, time.Local)
+	if err != nil {
+		return nil, fmt.Errorf("月份格式错误，应为 YYYY-MM")
+	}
+	return s.findBetween(ctx, start.UnixMilli(), start.AddDate(0, 1, 0).UnixMilli())
+}
+
+// MonthlySummaries 最近 months 个月（含本月）的收支汇总，按月份倒序
+func (s *TransactionService) MonthlySummaries(ctx context.Context, months int) ([]MonthlySummary, error) {
+	if months <= 0 {
+		months = 12
+	}
+	if months > MaxSummaryMonths {
+		months = MaxSummaryMonths
+	}
+
+	now := time.Now()
+	thisMonth := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.Local)
+	start := thisMonth.AddDate(0, -(months - 1), 0)
+
+	transactions, err := s.findBetween(ctx, start.UnixMilli(), thisMonth.AddDate(0, 1, 0).UnixMilli())
+	if err != nil {
+		return nil, err
+	}
+
+	summaries := make([]MonthlySummary, months)
+	index := make(map[string]int, months)
+	for i := range summaries {
+		month := thisMonth.AddDate(0, -i, 0).Format(monthLayout)
+		summaries[i].Month = month
+		index[month] = i
+	}
+	for _, tx := range transactions {
+		i, ok := index[time.UnixMilli(tx.OccurredAt).Format(monthLayout)]
+		if !ok {
+			continue
+		}
+		if tx.Direction == models.TransactionExpense {
+			summaries[i].Expense += tx.Amount
+			summaries[i].ExpenseCount++
+		} else {
+			summaries[i].Income += tx.Amount
+			summaries[i].IncomeCount++
+		}
+	}
+	return summaries, nil
+}
+
+// MerchantSummaries 指定月份按商户汇总的支出，按金额倒序
+func (s *TransactionService) MerchantSummaries(ctx context.Context, month string) ([]MerchantSummary, error) {
+	transactions, err := s.ListByMonth(ctx, month)
+	if err != nil {
+		return nil, err
+	}
+
+	byMerchant := make(map[string]*MerchantSummary)
+	for _, tx := range transactions {
+		if tx.Direction != models.TransactionExpense {
+			continue
+		}
+		merchant := tx.Merchant
+		if merchant == "" {
+			merchant = "未知"
+		}
+		summary, ok := byMerchant[merchant]
+		if !ok {
+			summary = &MerchantSummary{Merchant: merchant}
+			byMerchant[merchant] = summary
+		}
+		summary.Amount += tx.Amount
+		summary.Count++
+	}
+
+	result := make([]MerchantSummary, 0, len(byMerchant))
+	for _, summary := range byMerchant {
+		result = append(result, *summary)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Amount > result[j].Amount
+	})
+	return result, nil
+}
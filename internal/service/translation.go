@@ -0,0 +1,202 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/dushixiang/uart_sms_forwarder/internal/models"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// PropertyIDTranslation 短信翻译配置
+const PropertyIDTranslation = "translation"
+
+const (
+	translationProviderLibre = "libretranslate"
+	translationProviderDeepL = "deepl"
+	// deeplDefaultURL DeepL 免费版接口地址，专业版为 https://api.deepl.com
+	deeplDefaultURL           = "https://api-free.deepl.com"
+	defaultTranslationTarget  = "zh"
+	defaultTranslationTimeout = 10 * time.Second
+)
+
+// translateNotification 按翻译配置为外语短信的通知附加译文，译文同时写入字段 translation 供 Webhook 模板使用。
+// 翻译失败或短信已是目标语言时原样返回，不影响通知发送。
+func (s *SerialService) translateNotification(ctx context.Context, msg NotificationMessage) NotificationMessage {
+	var config models.TranslationConfig
+	if err := s.propertyService.GetValue(ctx, PropertyIDTranslation, &config); err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			s.logger.Error("获取短信翻译配置失败", zap.Error(err))
+		}
+		return msg
+	}
+	if !config.Enabled || strings.TrimSpace(msg.Content) == "" {
+		return msg
+	}
+	target := config.TargetLang
+	if target == "" {
+		target = defaultTranslationTarget
+	}
+	if likelyInLanguage(msg.Content, target) {
+		return msg
+	}
+
+	timeout := defaultTranslationTimeout
+	if config.Timeout > 0 {
+		timeout = time.Duration(config.Timeout) * time.Second
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	translated, source, err := translateText(ctx, config, msg.Content, target)
+	if err != nil {
+		s.logger.Warn("翻译短信失败，发送原文", zap.String("provider", config.Provider), zap.Error(err))
+		return msg
+	}
+	translated = strings.TrimSpace(translated)
+	if translated == "" || translated == strings.TrimSpace(msg.Content) || sameLanguage(source, target) {
+		return msg
+	}
+
+	fields := maps.Clone(msg.Fields)
+	if fields == nil {
+		fields = make(map[string]string)
+	}
+	fields["translation"] = translated
+	msg.Fields = fields
+	msg.Content = fmt.Sprintf("%s\n\n[译文 %s→%s]\n%s", msg.Content, strings.ToLower(source), target, translated)
+	return msg
+}
+
+// translateText 调用翻译服务，返回译文和检测到的源语言
+func translateText(ctx context.Context, config models.TranslationConfig, text, target string) (string, string, error) {
+	switch config.Provider {
+	case "", translationProviderLibre:
+		if config.URL == "" {
+			return "", "", errors.New("未配置 LibreTranslate 地址")
+		}
+		var result struct {
+			TranslatedText   string `json:"translatedText"`
+			DetectedLanguage struct {
+				Language string `json:"language"`
+			} `json:"detectedLanguage"`
+		}
+		body := map[string]string{
+			"q":       text,
+			"source":  "auto",
+			"target":  target,
+			"format":  "text",
+			"api_key": config.APIKey,
+		}
+		if err := postTranslation(ctx, strings.TrimRight(config.URL, "/")+"/translate", nil, body, &result); err != nil {
+			return "", "", err
+		}
+		return result.TranslatedText, result.DetectedLanguage.Language, nil
+	case translationProviderDeepL:
+		if config.APIKey == "" {
+			return "", "", errors.New("未配置 DeepL 接口密钥")
+		}
+		url := config.URL
+		if url == "" {
+			url = deeplDefaultURL
+		}
+		var result struct {
+			Translations []struct {
+				DetectedSourceLanguage string `json:"detected_source_language"`
+				Text                   string `json:"text"`
+			} `json:"translations"`
+		}
+		body := map[string]interface{}{
+			"text":        []string{text},
+			"target_lang": strings.ToUpper(target),
+		}
+		headers := map[string]string{"Authorization": "DeepL-Auth-Key " + config.APIKey}
+		if err := postTranslation(ctx, strings.TrimRight(url, "/")+"/v2/translate", headers, body, &result); err != nil {
+			return "", "", err
+		}
+		if len(result.Translations) == 0 {
+			return "", "", errors.New("DeepL 未返回译文")
+		}
+		return result.Translations[0].Text, result.Translations[0].DetectedSourceLanguage, nil
+	default:
+		return "", "", fmt.Errorf("不支持的翻译服务: %s", config.Provider)
+	}
+}
+
+// postTranslation 发送 JSON 请求并解析响应
+func postTranslation(ctx context.Context, url string, headers map[string]string, body, result interface{}) error {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("请求翻译服务失败: %w", err)
+	}
+	defer resp.Body.Close()
+	respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("翻译服务返回状态码 %d: %s", resp.StatusCode, string(respBody))
+	}
+	return json.Unmarshal(respBody, result)
+}
+
+// sameLanguage 比较语言代码的主语言部分，如 zh 与 ZH-HANS 视为相同
+func sameLanguage(a, b string) bool {
+	primary := func(lang string) string {
+		lang, _, _ = strings.Cut(strings.ToLower(lang), "-")
+		return lang
+	}
+	return a != "" && primary(a) == primary(b)
+}
+
+// likelyInLanguage 粗略判断文本是否已是目标语言，避免为本地短信调用翻译服务。
+// 目前只识别中文（汉字为主且没有假名、谚文）和英文（只有拉丁字母），其他目标语言总是调用翻译服务。
+func likelyInLanguage(text, target string) bool {
+	var han, latin, other, letters int
+	for _, r := range text {
+		switch {
+		case unicode.Is(unicode.Han, r):
+			han++
+		case unicode.In(r, unicode.Hiragana, unicode.Katakana, unicode.Hangul):
+			other++
+		case r < unicode.MaxASCII && unicode.IsLetter(r):
+			latin++
+		case unicode.IsLetter(r):
+			other++
+		default:
+			continue
+		}
+		letters++
+	}
+	if letters == 0 {
+		// 只有数字和符号，无需翻译
+		return true
+	}
+	switch {
+	case sameLanguage(target, "zh"):
+		return other == 0 && han*2 >= letters
+	case sameLanguage(target, "en"):
+		return latin == letters
+	}
+	return false
+}
@@ -0,0 +1,201 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dushixiang/uart_sms_forwarder/config"
+	"github.com/dushixiang/uart_sms_forwarder/internal/version"
+	"go.uber.org/zap"
+)
+
+const (
+	// defaultUpdateRepository 默认检查的 GitHub 仓库
+	defaultUpdateRepository = "dushixiang/uart_sms_forwarder"
+	// defaultUpdateInterval 默认检查间隔
+	defaultUpdateInterval = 24 * time.Hour
+	// updateCheckTimeout 单次查询超时
+	updateCheckTimeout = 15 * time.Second
+)
+
+// UpdateInfo 最新版本信息
+type UpdateInfo struct {
+	LatestVersion   string `json:"latestVersion"`   // 最新发布的版本号
+	ReleaseURL      string `json:"releaseUrl"`      // 发布页面地址
+	PublishedAt     string `json:"publishedAt"`     // 发布时间
+	UpdateAvailable bool   `json:"updateAvailable"` // 是否有比当前运行版本更新的版本
+	CheckedAt       int64  `json:"checkedAt"`       // 最后一次成功检查的时间（时间戳毫秒）
+}
+
+// githubRelease GitHub Releases API 响应中用到的字段
+type githubRelease struct {
+	TagName     string `json:"tag_name"`
+	HTMLURL     string `json:"html_url"`
+	PublishedAt string `json:"published_at"`
+}
+
+// UpdateService 新版本检查服务，定期查询 GitHub Releases 并缓存最新版本
+type UpdateService struct {
+	logger     *zap.Logger
+	config     config.UpdateCheckConfig
+	httpClient *http.Client
+
+	mu     sync.RWMutex
+	latest *UpdateInfo
+}
+
+// NewUpdateService 创建新版本检查服务实例
+func NewUpdateService(logger *zap.Logger, config config.UpdateCheckConfig) *UpdateService {
+	if config.Repository == "" {
+		config.Repository = defaultUpdateRepository
+	}
+	return &UpdateService{
+		logger:     logger,
+		config:     config,
+		httpClient: &http.Client{Timeout: updateCheckTimeout},
+	}
+}
+
+// Start 启动定期检查，配置中关闭时不访问网络
+func (s *UpdateService) Start(ctx context.Context) {
+	if s.config.Disabled {
+		s.logger.Info("新版本检查已关闭")
+		return
+	}
+
+	interval := defaultUpdateInterval
+	if s.config.IntervalHours > 0 {
+		interval = time.Duration(s.config.IntervalHours) * time.Hour
+	}
+
+	go func() {
+		s.check(ctx)
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				s.check(ctx)
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+}
+
+// Latest 获取缓存的最新版本信息，未检查或检查失败时返回 nil
+func (s *UpdateService) Latest() *UpdateInfo {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.latest == nil {
+		return nil
+	}
+	info := *s.latest
+	return &info
+}
+
+// check 查询一次最新版本，失败时保留上次的结果
+func (s *UpdateService) check(ctx context.Context) {
+	release, err := s.fetchLatest(ctx)
+	if err != nil {
+		s.logger.Warn("检查新版本失败", zap.Error(err))
+		return
+	}
+
+	info := &UpdateInfo{
+		LatestVersion:   release.TagName,
+		ReleaseURL:      release.HTMLURL,
+		PublishedAt:     release.PublishedAt,
+		UpdateAvailable: isNewerVersion(release.TagName, version.GetVersion()),
+		CheckedAt:       time.Now().UnixMilli(),
+	}
+	if info.UpdateAvailable {
+		s.logger.Info("发现新版本", zap.String("current", version.GetVersion()), zap.String("latest", release.TagName))
+	}
+
+	s.mu.Lock()
+	s.latest = info
+	s.mu.Unlock()
+}
+
+// fetchLatest 查询 GitHub 上最新发布的版本
+func (s *UpdateService) fetchLatest(ctx context.Context) (*githubRelease, error) {
+	url := fmt.Sprintf("https://api.github.com/repos/%s/releases/latest", s.config.Repository)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("User-Agent", "uart_sms_forwarder/"+version.GetVersion())
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("GitHub 返回状态码 %d", resp.StatusCode)
+	}
+
+	var release githubRelease
+	if err := json.NewDecoder(resp.Body).Decode(&release); err != nil {
+		return nil, fmt.Errorf("解析发布信息失败: %w", err)
+	}
+	if release.TagName == "" {
+		return nil, fmt.Errorf("发布信息缺少版本号")
+	}
+	return &release, nil
+}
+
+// isNewerVersion 判断 latest 是否比 current 新，按点分隔的数字逐段比较，忽略 v 前缀和预发布后缀。
+// 开发版本（无法解析的版本号）不提示更新
+func isNewerVersion(latest, current string) bool {
+	latestParts, ok := parseVersion(latest)
+	if !ok {
+		return false
+	}
+	currentParts, ok := parseVersion(current)
+	if !ok {
+		return false
+	}
+	for i := 0; i < max(len(latestParts), len(currentParts)); i++ {
+		var l, c int
+		if i < len(latestParts) {
+			l = latestParts[i]
+		}
+		if i < len(currentParts) {
+			c = currentParts[i]
+		}
+		if l != c {
+			return l > c
+		}
+	}
+	return false
+}
+
+// parseVersion 解析 v1.2.3 / 1.2.3-beta 形式的版本号
+func parseVersion(v string) ([]int, bool) {
+	v = strings.TrimPrefix(strings.TrimSpace(v), "v")
+	if i := strings.IndexAny(v, "-+"); i >= 0 {
+		v = v[:i]
+	}
+	if v == "" {
+		return nil, false
+	}
+	var parts []int
+	for _, part := range strings.Split(v, ".") {
+		n, err := strconv.Atoi(part)
+		if err != nil {
+			return nil, false
+		}
+		parts = append(parts, n)
+	}
+	return parts, true
+}
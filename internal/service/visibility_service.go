@@ -0,0 +1,202 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/dushixiang/uart_sms_forwarder/internal/models"
+	"github.com/dushixiang/uart_sms_forwarder/internal/repo"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// ErrAdminRequired 操作需要管理员权限
+var ErrAdminRequired = errors.New("需要管理员权限")
+
+// Viewer 当前请求的用户
+type Viewer struct {
+	Username string
+	Admin    bool
+}
+
+type viewerKey struct{}
+
+// WithViewer 将当前用户存入 context，短信查询按该用户的可见范围过滤
+func WithViewer(ctx context.Context, viewer Viewer) context.Context {
+	return context.WithValue(ctx, viewerKey{}, viewer)
+}
+
+// ViewerFrom 获取 context 中的用户。
+// 后台任务（收信、定时任务等）没有用户，不受可见性限制。
+func ViewerFrom(ctx context.Context) (Viewer, bool) {
+	viewer, ok := ctx.Value(viewerKey{}).(Viewer)
+	return viewer, ok
+}
+
+// peerMessagesCondition 对方号码在列表中的短信
+const peerMessagesCondition = `(type = ? AND "from" IN ?) OR (type = ? AND "to" IN ?)`
+
+// VisibilityService 会话可见性服务。
+// 管理员可将号码（会话）分配给指定用户，分配后只有这些用户和管理员能看到该会话的短信，
+// 未分配的会话所有用户可见。
+type VisibilityService struct {
+	logger *zap.Logger
+	repo   *repo.PeerAssignmentRepo
+	admins []string
+}
+
+// NewVisibilityService 创建会话可见性服务实例，admins 为空时所有用户都是管理员
+func NewVisibilityService(logger *zap.Logger, db *gorm.DB, admins []string) *VisibilityService {
+	return &VisibilityService{
+		logger: logger,
+		repo:   repo.NewPeerAssignmentRepo(db),
+		admins: admins,
+	}
+}
+
+// IsAdmin 判断用户是否为管理员
+func (s *VisibilityService) IsAdmin(username string) bool {
+	return len(s.admins) == 0 || slices.Contains(s.admins, username)
+}
+
+// List 获取所有会话分配
+func (s *VisibilityService) List(ctx context.Context) ([]models.PeerAssignment, error) {
+	if err := requireAdmin(ctx); err != nil {
+		return nil, err
+	}
+	return s.repo.FindAll(ctx)
+}
+
+// Assign 将会话分配给指定用户，覆盖原有分配
+func (s *VisibilityService) Assign(ctx context.Context, peer string, users []string) (*models.PeerAssignment, error) {
+	if err := requireAdmin(ctx); err != nil {
+		return nil, err
+	}
+	peer = strings.TrimSpace(peer)
+	if peer == "" {
+		return nil, fmt.Errorf("号码不能为空")
+	}
+	users = normalizeTags(users)
+	if len(users) == 0 {
+		return nil, fmt.Errorf("用户不能为空，取消分配请删除")
+	}
+
+	now := time.Now().UnixMilli()
+	assignment := &models.PeerAssignment{
+		Peer:      peer,
+		Users:     users,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	if existing, err := s.repo.FindById(ctx, peer); err == nil {
+		assignment.CreatedAt = existing.CreatedAt
+	}
+	if err := s.repo.Save(ctx, assignment); err != nil {
+		return nil, err
+	}
+	s.logger.Info("会话已分配", zap.String("peer", peer), zap.Strings("users", users))
+	return assignment, nil
+}
+
+// Unassign 取消会话分配，会话恢复为所有用户可见
+func (s *VisibilityService) Unassign(ctx context.Context, peer string) error {
+	if err := requireAdmin(ctx); err != nil {
+		return err
+	}
+	return s.repo.DeleteById(ctx, peer)
+}
+
+// requireAdmin 请求来自非管理员用户时返回 ErrAdminRequired
+func requireAdmin(ctx context.Context) error {
+	if viewer, ok := ViewerFrom(ctx); ok && !viewer.Admin {
+		return ErrAdminRequired
+	}
+	return nil
+}
+
+// hiddenPeers 当前用户看不到的号码，不受限制时返回 nil
+func (s *VisibilityService) hiddenPeers(ctx context.Context) ([]string, error) {
+	viewer, ok := ViewerFrom(ctx)
+	if !ok || viewer.Admin {
+		return nil, nil
+	}
+	assignments, err := s.repo.FindAll(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("获取会话分配失败: %w", err)
+	}
+	var hidden []string
+	for _, assignment := range assignments {
+		if !slices.Contains(assignment.Users, viewer.Username) {
+			hidden = append(hidden, assignment.Peer)
+		}
+	}
+	return hidden, nil
+}
+
+// CanView 当前用户能否看到与该号码的会话
+func (s *VisibilityService) CanView(ctx context.Context, peer string) (bool, error) {
+	hidden, err := s.hiddenPeers(ctx)
+	if err != nil {
+		return false, err
+	}
+	return !slices.Contains(hidden, peer), nil
+}
+
+// MessageScope 当前用户可见短信的查询条件，restricted 为 false 时不受限制
+func (s *VisibilityService) MessageScope(ctx context.Context) (scope func(db *gorm.DB) *gorm.DB, restricted bool, err error) {
+	hidden, err := s.hiddenPeers(ctx)
+	if err != nil {
+		return nil, false, err
+	}
+	if len(hidden) == 0 {
+		return noScope, false, nil
+	}
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where("NOT ("+peerMessagesCondition+")",
+			models.MessageTypeIncoming, hidden,
+			models.MessageTypeOutgoing, hidden)
+	}, true, nil
+}
+
+// TransactionScope 当前用户可见交易记录的查询条件，隐藏会话中的银行短信解析出的交易不可见
+func (s *VisibilityService) TransactionScope(ctx context.Context) (func(db *gorm.DB) *gorm.DB, error) {
+	hidden, err := s.hiddenPeers(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if len(hidden) == 0 {
+		return noScope, nil
+	}
+	return func(db *gorm.DB) *gorm.DB {
+		hiddenIDs := db.Session(&gorm.Session{NewDB: true}).
+			Model(&models.TextMessage{}).
+			Select("id").
+			Where(peerMessagesCondition,
+				models.MessageTypeIncoming, hidden,
+				models.MessageTypeOutgoing, hidden)
+		return db.Where("message_id NOT IN (?)", hiddenIDs)
+	}, nil
+}
+
+// CallScope 当前用户可见来电记录的查询条件，隐藏会话号码的来电不可见
+func (s *VisibilityService) CallScope(ctx context.Context) (func(db *gorm.DB) *gorm.DB, error) {
+	hidden, err := s.hiddenPeers(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if len(hidden) == 0 {
+		return noScope, nil
+	}
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where(`"from" NOT IN ?`, hidden)
+	}, nil
+}
+
+// noScope 不附加任何条件
+func noScope(db *gorm.DB) *gorm.DB {
+	return db
+}
@@ -0,0 +1,138 @@
+package util
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	PasswordAlgorithmBcrypt   = "bcrypt"
+	PasswordAlgorithmArgon2id = "argon2id"
+
+	DefaultBcryptCost        = 12
+	DefaultArgon2Memory      = 64 * 1024
+	DefaultArgon2Iterations  = 3
+	DefaultArgon2Parallelism = 2
+
+	argon2SaltLength = 16
+	argon2KeyLength  = 32
+)
+
+// PasswordHasher 密码哈希，支持 bcrypt 和 argon2id（PHC 字符串格式）
+type PasswordHasher struct {
+	Algorithm         string
+	BcryptCost        int
+	Argon2Memory      uint32 // KiB
+	Argon2Iterations  uint32
+	Argon2Parallelism uint8
+}
+
+// Normalize 校验算法并为未设置的参数填充默认值
+func (h *PasswordHasher) Normalize() error {
+	switch h.Algorithm {
+	case "":
+		h.Algorithm = PasswordAlgorithmBcrypt
+	case PasswordAlgorithmBcrypt, PasswordAlgorithmArgon2id:
+	default:
+		return fmt.Errorf("不支持的密码哈希算法: %s", h.Algorithm)
+	}
+	if h.BcryptCost == 0 {
+		h.BcryptCost = DefaultBcryptCost
+	}
+	if h.BcryptCost < bcrypt.MinCost || h.BcryptCost > bcrypt.MaxCost {
+		return fmt.Errorf("bcrypt 成本应在 %d-%d 之间", bcrypt.MinCost, bcrypt.MaxCost)
+	}
+	if h.Argon2Memory == 0 {
+		h.Argon2Memory = DefaultArgon2Memory
+	}
+	if h.Argon2Iterations == 0 {
+		h.Argon2Iterations = DefaultArgon2Iterations
+	}
+	if h.Argon2Parallelism == 0 {
+		h.Argon2Parallelism = DefaultArgon2Parallelism
+	}
+	return nil
+}
+
+// Hash 按当前算法和参数计算密码哈希
+func (h *PasswordHasher) Hash(password string) (string, error) {
+	if h.Algorithm == PasswordAlgorithmArgon2id {
+		salt := make([]byte, argon2SaltLength)
+		if _, err := rand.Read(salt); err != nil {
+			return "", err
+		}
+		key := argon2.IDKey([]byte(password), salt, h.Argon2Iterations, h.Argon2Memory, h.Argon2Parallelism, argon2KeyLength)
+		return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s",
+			argon2.Version, h.Argon2Memory, h.Argon2Iterations, h.Argon2Parallelism,
+			base64.RawStdEncoding.EncodeToString(salt),
+			base64.RawStdEncoding.EncodeToString(key)), nil
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), h.BcryptCost)
+	return string(hash), err
+}
+
+// Verify 校验密码，根据哈希前缀自动识别算法
+func (h *PasswordHasher) Verify(hash, password string) bool {
+	if strings.HasPrefix(hash, "$argon2id$") {
+		params, salt, key, err := parseArgon2id(hash)
+		if err != nil {
+			return false
+		}
+		actual := argon2.IDKey([]byte(password), salt, params.Argon2Iterations, params.Argon2Memory, params.Argon2Parallelism, uint32(len(key)))
+		return subtle.ConstantTimeCompare(actual, key) == 1
+	}
+	return bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)) == nil
+}
+
+// NeedsRehash 判断哈希的算法或参数是否弱于当前配置
+func (h *PasswordHasher) NeedsRehash(hash string) bool {
+	if strings.HasPrefix(hash, "$argon2id$") {
+		if h.Algorithm != PasswordAlgorithmArgon2id {
+			return true
+		}
+		params, _, _, err := parseArgon2id(hash)
+		if err != nil {
+			return true
+		}
+		return params.Argon2Memory < h.Argon2Memory ||
+			params.Argon2Iterations < h.Argon2Iterations ||
+			params.Argon2Parallelism < h.Argon2Parallelism
+	}
+	if h.Algorithm != PasswordAlgorithmBcrypt {
+		return true
+	}
+	cost, err := bcrypt.Cost([]byte(hash))
+	return err != nil || cost < h.BcryptCost
+}
+
+// parseArgon2id 解析 $argon2id$v=19$m=65536,t=3,p=2$salt$key
+func parseArgon2id(hash string) (*PasswordHasher, []byte, []byte, error) {
+	parts := strings.Split(hash, "$")
+	if len(parts) != 6 {
+		return nil, nil, nil, errors.New("argon2id 哈希格式错误")
+	}
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return nil, nil, nil, errors.New("不支持的 argon2id 版本")
+	}
+	params := &PasswordHasher{Algorithm: PasswordAlgorithmArgon2id}
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &params.Argon2Memory, &params.Argon2Iterations, &params.Argon2Parallelism); err != nil {
+		return nil, nil, nil, errors.New("argon2id 参数格式错误")
+	}
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return nil, nil, nil, errors.New("argon2id 盐格式错误")
+	}
+	key, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil || len(key) == 0 {
+		return nil, nil, nil, errors.New("argon2id 哈希格式错误")
+	}
+	return params, salt, key, nil
+}
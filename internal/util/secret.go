@@ -0,0 +1,86 @@
+package util
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"strings"
+)
+
+// EncryptedPrefix 加密值前缀，带版本号便于以后更换算法
+const EncryptedPrefix = "enc:v1:"
+
+// SecretBox 使用 AES-256-GCM 加解密配置中的敏感字段
+type SecretBox struct {
+	aead cipher.AEAD
+}
+
+// NewSecretBox 创建加解密实例，key 可以是任意长度的字符串，经 SHA-256 派生为 32 字节密钥
+func NewSecretBox(key string) (*SecretBox, error) {
+	if key == "" {
+		return nil, errors.New("密钥不能为空")
+	}
+	sum := sha256.Sum256([]byte(key))
+	block, err := aes.NewCipher(sum[:])
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	return &SecretBox{aead: aead}, nil
+}
+
+// NewDerivedSecretBox 从 key 派生指定用途的加解密实例，不同用途的密钥互相独立
+func NewDerivedSecretBox(key, purpose string) (*SecretBox, error) {
+	if key == "" {
+		return nil, errors.New("密钥不能为空")
+	}
+	mac := hmac.New(sha256.New, []byte(key))
+	mac.Write([]byte(purpose))
+	return NewSecretBox(hex.EncodeToString(mac.Sum(nil)))
+}
+
+// IsEncrypted 判断字符串是否为加密值
+func IsEncrypted(s string) bool {
+	return strings.HasPrefix(s, EncryptedPrefix)
+}
+
+// Encrypt 加密字符串，已加密的值原样返回
+func (b *SecretBox) Encrypt(plaintext string) (string, error) {
+	if IsEncrypted(plaintext) {
+		return plaintext, nil
+	}
+	nonce := make([]byte, b.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	sealed := b.aead.Seal(nonce, nonce, []byte(plaintext), nil)
+	return EncryptedPrefix + base64.RawStdEncoding.EncodeToString(sealed), nil
+}
+
+// Decrypt 解密字符串，未加密的值原样返回
+func (b *SecretBox) Decrypt(value string) (string, error) {
+	if !IsEncrypted(value) {
+		return value, nil
+	}
+	sealed, err := base64.RawStdEncoding.DecodeString(strings.TrimPrefix(value, EncryptedPrefix))
+	if err != nil {
+		return "", errors.New("加密值格式错误")
+	}
+	size := b.aead.NonceSize()
+	if len(sealed) < size {
+		return "", errors.New("加密值格式错误")
+	}
+	plaintext, err := b.aead.Open(nil, sealed[:size], sealed[size:], nil)
+	if err != nil {
+		return "", errors.New("解密失败，密钥可能不正确")
+	}
+	return string(plaintext), nil
+}
@@ -1,8 +1,23 @@
 package version
 
+import (
+	"runtime"
+	"runtime/debug"
+	"time"
+)
+
 // Version 服务端版本号（通过 -ldflags 注入）
 var Version = "dev"
 
+// BuildTime 构建时间（通过 -ldflags 注入）
+var BuildTime = ""
+
+// GitCommit 构建时的 git 提交（通过 -ldflags 注入，未注入时从构建信息读取）
+var GitCommit = ""
+
+// startTime 进程启动时间
+var startTime = time.Now()
+
 // GetVersion 获取服务端版本号
 func GetVersion() string {
 	if Version == "" {
@@ -10,3 +25,46 @@ func GetVersion() string {
 	}
 	return Version
 }
+
+// Info 服务端版本与运行信息
+type Info struct {
+	Version   string `json:"version"`
+	BuildTime string `json:"buildTime"`
+	GitCommit string `json:"gitCommit"`
+	GoVersion string `json:"goVersion"`
+	OS        string `json:"os"`
+	Arch      string `json:"arch"`
+	StartedAt int64  `json:"startedAt"` // 启动时间（时间戳毫秒）
+	Uptime    int64  `json:"uptime"`    // 运行时长，单位为秒
+}
+
+// GetInfo 获取服务端版本与运行信息
+func GetInfo() Info {
+	info := Info{
+		Version:   GetVersion(),
+		BuildTime: BuildTime,
+		GitCommit: GitCommit,
+		GoVersion: runtime.Version(),
+		OS:        runtime.GOOS,
+		Arch:      runtime.GOARCH,
+		StartedAt: startTime.UnixMilli(),
+		Uptime:    int64(time.Since(startTime).Seconds()),
+	}
+
+	// go build 会在构建信息中记录 VCS 信息，未通过 ldflags 注入时使用
+	if bi, ok := debug.ReadBuildInfo(); ok {
+		for _, setting := range bi.Settings {
+			switch setting.Key {
+			case "vcs.revision":
+				if info.GitCommit == "" {
+					info.GitCommit = setting.Value
+				}
+			case "vcs.time":
+				if info.BuildTime == "" {
+					info.BuildTime = setting.Value
+				}
+			}
+		}
+	}
+	return info
+}
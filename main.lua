@@ -8,7 +8,7 @@
 -- =================================================================================
 
 PROJECT = "uart_sms_forwarder"
-VERSION = "1.0.3"
+VERSION = "1.0.6"
 
 log.info("main", PROJECT, VERSION)
 
@@ -22,6 +22,10 @@ local max_buffer_size = 50
 local msg_buffer = {}
 local uart_recv_buffer = ""
 local call_ring_count = 0  -- 来电响铃计数
+-- 最近的短信发送结果，串口断开期间上报的结果会丢失，重连后由 MCU 查询对账
+local max_send_results = 50
+local send_results = {}
+local send_result_ids = {}
 
 -- ========== 关键：禁用自动数据连接 ==========
 mobile.setAuto(0)
@@ -78,6 +82,17 @@ function get_mobile_info()
     return info
 end
 
+-- 内容含控制字符、非 UTF-8 或帧标志时改用 base64 传输，避免破坏行协议
+function needs_base64(s)
+    if utf8.len(s) == nil then
+        return true
+    end
+    if s:find("[%z\1-\8\11\12\14-\31]") then
+        return true
+    end
+    return s:find("SMS_START:", 1, true) ~= nil or s:find(":SMS_END", 1, true) ~= nil
+end
+
 function send_to_uart(data)
     local ok, json_str = pcall(json.encode, data)
     if ok and json_str then
@@ -89,20 +104,38 @@ function send_to_uart(data)
     end
 end
 
+function record_send_result(request_id, status)
+    if send_results[request_id] == nil then
+        table.insert(send_result_ids, request_id)
+        if #send_result_ids > max_send_results then
+            send_results[table.remove(send_result_ids, 1)] = nil
+        end
+    end
+    send_results[request_id] = status
+end
+
 function process_uart_command(cmd_data)
     if not cmd_data.action then
         send_to_uart({type = "error", msg = "missing action"})
         return
     end
 
+    -- xxx_b64 字段为 base64 编码的 xxx
+    if cmd_data.content_b64 then
+        cmd_data.content = cmd_data.content_b64:fromBase64()
+        cmd_data.content_b64 = nil
+    end
+
     if cmd_data.action == "send_sms" and cmd_data.to and cmd_data.content then
         local request_id = cmd_data.request_id or os.time()
         local to = cmd_data.to
         local content = cmd_data.content
+        record_send_result(request_id, "sending")
         -- 在协程中同步发送短信
         sys.taskInit(function()
             log.info("CMD", "发送短信 ->", to)
             local result = sms.sendLong(to, content).wait()
+            record_send_result(request_id, result == true and "sent" or "failed")
             send_to_uart({
                 type = "sms_send_result",
                 success = result == true,
@@ -112,6 +145,31 @@ function process_uart_command(cmd_data)
             })
         end)
 
+    elseif cmd_data.action == "query_send_results" and cmd_data.request_ids then
+        -- 只返回仍保留结果的 request_id
+        local results = {}
+        for _, request_id in ipairs(cmd_data.request_ids) do
+            if send_results[request_id] then
+                table.insert(results, {request_id = request_id, status = send_results[request_id]})
+            end
+        end
+        send_to_uart({type = "send_results", results = results})
+
+    elseif cmd_data.action == "send_ussd" then
+        -- 固件未提供 USSD 接口，直接返回失败，避免 MCU 等待超时
+        send_to_uart({
+            type = "ussd_response",
+            success = false,
+            request_id = cmd_data.request_id,
+            error = "firmware does not support USSD"
+        })
+
+    elseif cmd_data.action == "set_time" and cmd_data.timestamp then
+        -- 用 MCU 时间校准 RTC，返回校准前的偏差（秒）
+        local drift = os.time() - cmd_data.timestamp
+        rtc.set(os.date("!*t", cmd_data.timestamp))
+        send_to_uart({type = "cmd_response", action = "set_time", result = "ok", drift = drift})
+
     elseif cmd_data.action == "get_status" then
         send_to_uart({
             type = "status_response",
@@ -149,6 +207,11 @@ function process_uart_command(cmd_data)
         mobile.setAuto(0)
         send_to_uart({type = "cmd_response", action = "reset_stack", result = "ok"})
 
+    elseif cmd_data.action == "hangup_call" then
+        log.info("Call", "按来电规则挂断来电")
+        cc.hangUp()
+        send_to_uart({type = "cmd_response", action = "hangup_call", result = "ok"})
+
     elseif cmd_data.action == "reboot_mcu" then
         log.info("CMD", "重启模块")
         pm.reboot()
@@ -167,9 +230,13 @@ sys.subscribe("SMS_INC", function(phone, content)
     local msg = {
         type = "incoming_sms",
         timestamp = os.time(),
-        from = phone,
-        content = content
+        from = phone
     }
+    if needs_base64(content) then
+        msg.content_b64 = content:toBase64()
+    else
+        msg.content = content
+    end
     table.insert(msg_buffer, msg)
     if #msg_buffer > max_buffer_size then
         table.remove(msg_buffer, 1) -- 移除旧的
@@ -0,0 +1,39 @@
+// Web Push Service Worker：页面关闭后仍可显示新短信通知
+
+self.addEventListener('push', (event) => {
+    if (!event.data) {
+        return;
+    }
+    let data;
+    try {
+        data = event.data.json();
+    } catch {
+        data = {title: 'UART 短信转发器', body: event.data.text()};
+    }
+    event.waitUntil(
+        self.registration.showNotification(data.title || 'UART 短信转发器', {
+            body: data.body,
+            icon: '/logo.png',
+            tag: `${data.type || 'sms'}-${data.from || ''}-${data.timestamp || ''}`,
+            data: {url: data.url || '/'},
+            silent: data.priority === 'low',
+            requireInteraction: data.priority === 'high' || data.priority === 'critical',
+        })
+    );
+});
+
+self.addEventListener('notificationclick', (event) => {
+    event.notification.close();
+    const url = new URL(event.notification.data?.url || '/', self.location.origin).href;
+    event.waitUntil(
+        self.clients.matchAll({type: 'window', includeUncontrolled: true}).then((clients) => {
+            for (const client of clients) {
+                if (client.url.startsWith(self.location.origin) && 'focus' in client) {
+                    client.navigate(url);
+                    return client.focus();
+                }
+            }
+            return self.clients.openWindow(url);
+        })
+    );
+});
@@ -63,10 +63,16 @@ class ApiClient {
                 throw new Error('未授权，请重新登录');
             }
 
-            // 处理错误响应
+            // 处理错误响应，服务端统一返回 {code, message, requestId}
             if (!response.ok) {
                 const errorText = await response.text();
-                throw new Error(errorText || `HTTP ${response.status}: ${response.statusText}`);
+                let message = errorText;
+                try {
+                    message = JSON.parse(errorText).message || errorText;
+                } catch {
+                    // 非 JSON 响应时使用原始文本
+                }
+                throw new Error(message || `HTTP ${response.status}: ${response.statusText}`);
             }
 
             // 解析 JSON 响应
@@ -0,0 +1,52 @@
+import type { TextMessage } from './types';
+
+// 新收到的短信、来电或短信被确认
+export interface MessageStreamEvent {
+  type: 'sms' | 'call' | 'ack';
+  from: string;
+  message?: TextMessage; // 短信记录，来电时为空
+  timestamp: number;
+}
+
+// 订阅新消息推送（WebSocket），断开后自动重连，返回取消订阅的函数
+export const subscribeMessageEvents = (
+  onEvent: (event: MessageStreamEvent) => void,
+  onConnectionChange?: (connected: boolean) => void,
+) => {
+  let socket: WebSocket | null = null;
+  let retryTimer: ReturnType<typeof setTimeout> | undefined;
+  let retryDelay = 1000;
+  let stopped = false;
+
+  const connect = () => {
+    const token = localStorage.getItem('token');
+    if (!token) return;
+    const protocol = window.location.protocol === 'https:' ? 'wss:' : 'ws:';
+    socket = new WebSocket(`${protocol}//${window.location.host}/api/ws?token=${encodeURIComponent(token)}`);
+    socket.onopen = () => {
+      retryDelay = 1000;
+      onConnectionChange?.(true);
+    };
+    socket.onmessage = (e) => {
+      try {
+        onEvent(JSON.parse(e.data) as MessageStreamEvent);
+      } catch (err) {
+        console.error('解析消息推送失败:', err);
+      }
+    };
+    socket.onclose = () => {
+      onConnectionChange?.(false);
+      if (stopped) return;
+      // 断开后逐步延长重连间隔，最长 30 秒
+      retryTimer = setTimeout(connect, retryDelay);
+      retryDelay = Math.min(retryDelay * 2, 30000);
+    };
+  };
+
+  connect();
+  return () => {
+    stopped = true;
+    clearTimeout(retryTimer);
+    socket?.close();
+  };
+};
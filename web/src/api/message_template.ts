@@ -0,0 +1,36 @@
+// 短信模板
+import apiClient from "@/api/client.ts";
+
+export interface MessageTemplate {
+    id: string;
+    name: string;
+    content: string;       // 内容中可使用 {{变量名}}，内置变量：to、date、time、datetime
+    variables?: string[];  // 需要填写的变量名，不含内置变量
+    createdAt?: number;
+    updatedAt?: number;
+}
+
+// 获取所有模板
+export const getMessageTemplates = () => {
+    return apiClient.get<MessageTemplate[]>('/message-templates');
+};
+
+// 创建模板
+export const createMessageTemplate = (template: Pick<MessageTemplate, 'name' | 'content'>) => {
+    return apiClient.post<MessageTemplate>('/message-templates', template);
+};
+
+// 更新模板
+export const updateMessageTemplate = (id: string, template: Pick<MessageTemplate, 'name' | 'content'>) => {
+    return apiClient.put<MessageTemplate>(`/message-templates/${id}`, template);
+};
+
+// 删除模板
+export const deleteMessageTemplate = (id: string) => {
+    return apiClient.delete(`/message-templates/${id}`);
+};
+
+// 预览替换变量后的内容
+export const renderMessageTemplate = (id: string, to: string, variables: Record<string, string>) => {
+    return apiClient.post<{ content: string }>(`/message-templates/${id}/render`, {to, variables});
+};
@@ -1,11 +1,30 @@
 import apiClient from './client';
-import type {ListResult, Stats, Conversation, TextMessage} from './types';
+import type {ListResult, Stats, Conversation, ConversationStats, TextMessage} from './types';
 
 // 获取统计信息
 export const getStats = (): Promise<Stats> => {
     return apiClient.get('/messages/stats');
 };
 
+// 短信列表筛选条件
+export interface MessageQuery {
+    page?: number;
+    size?: number;
+    q?: string;       // 内容关键词
+    from?: string;    // 发送方号码
+    type?: TextMessage['type'];
+    status?: TextMessage['status'];
+    since?: number;   // 起始时间（毫秒）
+    until?: number;   // 截止时间（毫秒）
+    folder?: 'junk';
+    category?: string;
+}
+
+// 按页码分页查询短信
+export const listMessages = (query: MessageQuery): Promise<ListResult & { hasMore: boolean }> => {
+    return apiClient.get('/messages', {params: {page: 1, ...query}});
+};
+
 // 获取会话列表（按对方号码分组）
 export const getConversations = (): Promise<Conversation[]> => {
     return apiClient.get('/messages/conversations');
@@ -16,16 +35,47 @@ export const getConversationMessages = (peer: string): Promise<TextMessage[]> =>
     return apiClient.get(`/messages/conversations/${encodeURIComponent(peer)}/messages`);
 };
 
+// 获取会话统计：收发数量、首次和最近联系时间、月均数量和最活跃的时段
+export const getConversationStats = (peer: string): Promise<ConversationStats> => {
+    return apiClient.get(`/messages/conversations/${encodeURIComponent(peer)}/stats`);
+};
+
 // 删除单条短信
 export const deleteMessage = (id: string) => {
     return apiClient.delete(`/messages/${id}`);
 };
 
+// 将短信转发到其他号码
+export const forwardMessage = (id: string, to: string) => {
+    return apiClient.post(`/messages/${id}/forward`, {to});
+};
+
+// 确认已处理短信，停止升级通知，可附带备注
+export const ackMessage = (id: string, note?: string) => {
+    return apiClient.post(`/ack/${id}`, note ? {note} : undefined);
+};
+
 // 删除整个会话（与某个联系人的所有消息）
 export const deleteConversation = (peer: string) => {
     return apiClient.delete(`/messages/conversations/${encodeURIComponent(peer)}`);
 };
 
+// 合并会话后的主号码和别名
+export interface AliasGroup {
+    peer: string;
+    aliases: string[];
+}
+
+// 将其他号码合并到会话中
+export const mergeConversation = (peer: string, numbers: string[]): Promise<AliasGroup> => {
+    return apiClient.post(`/messages/conversations/${encodeURIComponent(peer)}/merge`, {numbers});
+};
+
+// 将别名号码从会话中拆出
+export const unmergeConversation = (peer: string, numbers: string[]): Promise<AliasGroup> => {
+    return apiClient.post(`/messages/conversations/${encodeURIComponent(peer)}/unmerge`, {numbers});
+};
+
 // 清空所有短信
 export const clearMessages = () => {
     return apiClient.delete('/messages');
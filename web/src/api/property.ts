@@ -32,8 +32,27 @@ export interface NotificationChannel {
     type: 'dingtalk' | 'wecom' | 'feishu' | 'email' | 'webhook' | 'telegram'; // 渠道类型，作为唯一标识
     enabled: boolean; // 是否启用
     config: Record<string, any>; // JSON配置，根据type不同而不同
+    events?: string[]; // 接收的事件类型，为空时接收全部
+    activeHours?: ChannelActiveHours[]; // 工作时间，只在任一时段内发送，为空时总是发送
 }
 
+// 通知渠道的工作时段
+export interface ChannelActiveHours {
+    days?: number[]; // 星期几，0 为周日，为空时每天
+    start: string; // 开始时间 HH:MM
+    end: string; // 结束时间 HH:MM，小于开始时间时表示跨过午夜
+}
+
+// 通知事件类型
+export const NOTIFICATION_EVENTS = [
+    {value: 'sms', label: '短信'},
+    {value: 'call', label: '来电'},
+    {value: 'device-offline', label: '设备离线'},
+    {value: 'low-signal', label: '信号弱'},
+    {value: 'task-failure', label: '定时任务失败'},
+    {value: 'system', label: '其他系统通知'},
+];
+
 // 获取通知渠道列表
 export const getNotificationChannels = async (): Promise<NotificationChannel[]> => {
     const channels = await getProperty<NotificationChannel[]>(PROPERTY_ID_NOTIFICATION_CHANNELS);
@@ -52,6 +71,11 @@ export const testNotificationChannel = async (type: string): Promise<{ message:
 
 export interface Version {
     version: string;
+    update?: {
+        latestVersion: string; // 最新发布的版本号
+        releaseUrl: string; // 发布页面地址
+        updateAvailable: boolean; // 是否有新版本
+    };
 }
 
 export const getVersion = () => {
@@ -0,0 +1,54 @@
+import apiClient from './client';
+
+// 获取 Web Push 服务器公钥
+export const getVAPIDPublicKey = () => {
+  return apiClient.get<{ publicKey: string }>('/push/webpush/public-key');
+};
+
+const urlBase64ToUint8Array = (base64: string) => {
+  const padding = '='.repeat((4 - (base64.length % 4)) % 4);
+  const raw = atob((base64 + padding).replace(/-/g, '+').replace(/_/g, '/'));
+  return Uint8Array.from(raw, (c) => c.charCodeAt(0));
+};
+
+// 当前浏览器是否支持 Web Push
+export const isWebPushSupported = () => {
+  return 'serviceWorker' in navigator && 'PushManager' in window && 'Notification' in window;
+};
+
+// 订阅 Web Push，页面关闭后仍可收到新短信通知
+export const subscribeWebPush = async (name?: string) => {
+  if (!isWebPushSupported()) {
+    throw new Error('当前浏览器不支持 Web Push');
+  }
+  const permission = await Notification.requestPermission();
+  if (permission !== 'granted') {
+    throw new Error('未授予通知权限');
+  }
+
+  const registration = await navigator.serviceWorker.register('/sw.js');
+  await navigator.serviceWorker.ready;
+  const {publicKey} = await getVAPIDPublicKey();
+  const subscription = await registration.pushManager.subscribe({
+    userVisibleOnly: true,
+    applicationServerKey: urlBase64ToUint8Array(publicKey),
+  });
+
+  return apiClient.post('/push/webpush/subscriptions', {
+    ...subscription.toJSON(),
+    name: name || navigator.userAgent,
+  });
+};
+
+// 取消当前浏览器的 Web Push 订阅
+export const unsubscribeWebPush = async () => {
+  const registration = await navigator.serviceWorker.getRegistration('/sw.js');
+  const subscription = await registration?.pushManager.getSubscription();
+  if (!subscription) {
+    return;
+  }
+  await apiClient.delete('/push/webpush/subscriptions', {
+    body: JSON.stringify({endpoint: subscription.endpoint}),
+  });
+  await subscription.unsubscribe();
+};
@@ -3,13 +3,18 @@ import apiClient from "@/api/client.ts";
 
 export type LastRunStatus = 'unknown' | 'success' | 'failed';
 
+// 服务停止期间错过执行时间的处理方式：下一次检查时补执行、启动后立即补执行、跳过
+export type MissedRunPolicy = 'once' | 'immediate' | 'skip';
+
 export interface ScheduledTask {
     id: string;
     name: string;
     enabled: boolean;
     intervalDays: number;
+    cronSpec?: string; // Cron 表达式（分 时 日 月 周），设置后忽略 intervalDays
     phoneNumber: string;
     content: string;
+    missedRun?: MissedRunPolicy;
     createdAt?: number;
     lastRunAt?: number;
     lastMsgId?: string;
@@ -42,6 +47,19 @@ export const deleteScheduledTask = (id: string) => {
     return apiClient.delete<{ message: string }>(`/scheduled-tasks/${id}`);
 };
 
+// 手动执行任务的结果
+export interface TaskRunResult {
+    success: boolean;
+    msgId?: string;
+    error?: string;
+    task: ScheduledTask; // 执行后的任务，包含 lastRunAt、lastRunStatus
+}
+
+// 立即执行定时任务，等待发送结果
+export const runScheduledTask = (id: string) => {
+    return apiClient.post<TaskRunResult>(`/scheduled-tasks/${id}/run`, {});
+};
+
 // 立即触发定时任务
 export const triggerScheduledTask = (id: string) => {
     return apiClient.post<{ message: string }>(`/scheduled-tasks/${id}/trigger`, {});
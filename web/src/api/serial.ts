@@ -11,6 +11,11 @@ export const getStatus = () => {
   return apiClient.get('/serial/status');
 };
 
+// 立即查询设备状态并等待设备响应（不使用缓存）
+export const refreshStatus = () => {
+  return apiClient.post('/serial/status/refresh');
+};
+
 // 设置飞行模式
 export const setFlymode = (enabled: boolean) => {
   return apiClient.post('/serial/flymode', { enabled });
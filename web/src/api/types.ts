@@ -5,7 +5,15 @@ export interface TextMessage {
     to: string;
     content: string;
     type: 'incoming' | 'outgoing';
-    status: 'received' | 'sending' | 'sent' | 'failed';
+    status: 'received' | 'sending' | 'sent' | 'failed' | 'delivered' | 'delivery_failed' | 'pending_approval' | 'rejected';
+    failureCode?: string;   // 发送失败时模组返回的错误码，如 CMS 21
+    failureReason?: string; // 发送失败原因
+    deliveredAt?: number;   // 送达报告的时间，0 表示未收到送达报告
+    submittedBy?: string;   // 需要审批的短信的提交者
+    reviewedBy?: string;    // 审批的管理员
+    ackedAt?: number;       // 确认处理时间，0 表示未确认
+    ackedBy?: string;       // 确认的用户
+    ackNote?: string;       // 确认时的备注或回应
     timestamp: number;
     createdAt: number;
     updatedAt: number;
@@ -29,6 +37,8 @@ export interface Stats {
 export interface SendSMSRequest {
     to: string;
     content: string;
+    templateId?: string;                  // 使用短信模板时的模板 ID，此时忽略 content
+    variables?: Record<string, string>;   // 模板变量值
 }
 
 // 设置飞行模式请求
@@ -64,6 +74,8 @@ export interface DeviceStatus {
     port_name: string;           // 串口名称
     connected: boolean;          // 串口连接状态
     version: string;             // Lua 版本
+    updated_at?: number;         // 收到该状态的时间（时间戳毫秒）
+    stale?: boolean;             // 是否为服务重启或断开前保存的旧状态
 }
 
 // 手机号码响应
@@ -75,8 +87,23 @@ export interface PhoneNumberResponse {
 
 // 会话信息
 export interface Conversation {
-    peer: string;              // 对方号码
+    peer: string;              // 对方号码，合并的会话为主号码
+    aliases?: string[];        // 合并到该会话的别名号码
     lastMessage: TextMessage;  // 最后一条消息
     messageCount: number;      // 消息总数
     unreadCount: number;       // 未读数量
 }
+
+// 会话统计
+export interface ConversationStats {
+    peer: string;
+    aliases?: string[];
+    totalCount: number;
+    incomingCount: number;
+    outgoingCount: number;
+    firstAt: number;           // 第一条消息的时间（毫秒），没有消息时为 0
+    lastAt: number;            // 最近一条消息的时间（毫秒），没有消息时为 0
+    averagePerMonth: number;   // 平均每月消息数
+    hourCounts: number[];      // 按小时（0-23）分组的消息数
+    busiestHours: number[];    // 消息最多的几个小时，按数量倒序
+}
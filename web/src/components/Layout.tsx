@@ -196,7 +196,19 @@ export default function Layout() {
             <footer className="bg-white/80 backdrop-blur-sm border-t border-gray-200 mt-auto">
                 <div className="max-w-7xl mx-auto px-4 sm:px-6 lg:px-8 py-4">
                     <div className="text-center text-xs text-gray-500">
-                        <p>UART 短信转发器 © 2025 版权所有 dushixiang · 版本 {versionQuery.data?.version}</p>
+                        <p>
+                            UART 短信转发器 © 2025 版权所有 dushixiang · 版本 {versionQuery.data?.version}
+                            {versionQuery.data?.update?.updateAvailable && (
+                                <a
+                                    href={versionQuery.data.update.releaseUrl}
+                                    target="_blank"
+                                    rel="noopener noreferrer"
+                                    className="ml-2 text-blue-600 hover:text-blue-700 hover:underline"
+                                >
+                                    新版本 {versionQuery.data.update.latestVersion} 可用
+                                </a>
+                            )}
+                        </p>
                     </div>
                 </div>
             </footer>
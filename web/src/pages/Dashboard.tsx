@@ -70,6 +70,12 @@ export default function Dashboard() {
         <div>
             <h1 className="text-2xl font-bold text-gray-900 mb-6">统计面板</h1>
 
+            {deviceStatus?.stale && (
+                <div className="mb-6 rounded-lg bg-yellow-50 px-4 py-3 text-sm text-yellow-800">
+                    设备状态为上次保存的数据{deviceStatus.updated_at ? `（${new Date(deviceStatus.updated_at).toLocaleString()}）` : ''}，等待设备响应后更新
+                </div>
+            )}
+
             <div className="grid grid-cols-1 md:grid-cols-2 lg:grid-cols-4 gap-6">
                 <StatCard label="信号强度" value={getSignalPercentage()} unit="%" icon={Signal}
                           colorClass="bg-green-100 text-green-600"
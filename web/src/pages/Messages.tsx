@@ -1,8 +1,17 @@
 import {useEffect, useRef, useState} from 'react';
 import {MoreVertical, RefreshCw, Search, Send, Trash2, User, X} from 'lucide-react';
 import {toast} from 'sonner';
-import {clearMessages, getConversations, getConversationMessages, deleteConversation, deleteMessage} from '../api/messages';
+import {
+    ackMessage,
+    clearMessages,
+    getConversations,
+    getConversationMessages,
+    getConversationStats,
+    deleteConversation,
+    deleteMessage
+} from '../api/messages';
 import {sendSMS} from '../api/serial';
+import {subscribeMessageEvents} from '../api/events';
 import {Input} from '@/components/ui/input';
 import {Button} from '@/components/ui/button';
 import {
@@ -12,7 +21,7 @@ import {
     DropdownMenuTrigger,
 } from '@/components/ui/dropdown-menu';
 import {useMutation, useQuery, useQueryClient} from '@tanstack/react-query';
-import type {Conversation, TextMessage} from '@/api/types';
+import type {Conversation, ConversationStats, TextMessage} from '@/api/types';
 
 export default function Messages() {
     const queryClient = useQueryClient();
@@ -26,6 +35,8 @@ export default function Messages() {
     const [searchQuery, setSearchQuery] = useState('');
     // 是否为移动设备
     const [isMobile, setIsMobile] = useState(typeof window !== 'undefined' && window.innerWidth < 768);
+    // 新消息推送是否已连接，连接时降低轮询频率
+    const [liveConnected, setLiveConnected] = useState(false);
 
     // 根据手机号生成头像颜色
     const getAvatarColor = (phoneNumber: string) => {
@@ -48,7 +59,7 @@ export default function Messages() {
     const {data: conversations = [], isLoading, refetch} = useQuery<Conversation[]>({
         queryKey: ['conversations'],
         queryFn: getConversations,
-        refetchInterval: 5000, // 每 5 秒自动刷新
+        refetchInterval: liveConnected ? 30000 : 5000, // 推送断开时每 5 秒自动刷新，连接时只为更新发送状态低频刷新
     });
 
     // 获取指定会话的所有消息
@@ -59,7 +70,47 @@ export default function Messages() {
             return getConversationMessages(selectedPeer);
         },
         enabled: !!selectedPeer,
-        refetchInterval: 5000,
+        refetchInterval: liveConnected ? 30000 : 5000,
+    });
+
+    // 获取当前会话的统计信息，显示在会话标题下方
+    const {data: conversationStats} = useQuery<ConversationStats>({
+        queryKey: ['conversation-stats', selectedPeer],
+        queryFn: () => getConversationStats(selectedPeer!),
+        enabled: !!selectedPeer,
+        staleTime: 60000,
+    });
+
+    // 收到新短信时刷新会话列表和当前会话消息
+    useEffect(() => {
+        return subscribeMessageEvents((event) => {
+            // 其他用户确认短信时只刷新当前会话消息
+            if (event.type === 'ack') {
+                queryClient.invalidateQueries({queryKey: ['conversation-messages']});
+                return;
+            }
+            if (event.type !== 'sms') return;
+            queryClient.invalidateQueries({queryKey: ['conversations']});
+            queryClient.invalidateQueries({queryKey: ['conversation-messages']});
+        }, (connected) => {
+            setLiveConnected(connected);
+            // 重连后补上断开期间的短信
+            if (connected) {
+                queryClient.invalidateQueries({queryKey: ['conversations']});
+                queryClient.invalidateQueries({queryKey: ['conversation-messages']});
+            }
+        });
+    }, [queryClient]);
+
+    // 确认已处理短信 Mutation
+    const ackMutation = useMutation({
+        mutationFn: (id: string) => ackMessage(id),
+        onSuccess: () => {
+            queryClient.invalidateQueries({queryKey: ['conversation-messages']});
+        },
+        onError: () => {
+            toast.error('确认失败');
+        },
     });
 
     // 发送短信 Mutation
@@ -201,14 +252,34 @@ export default function Messages() {
             date.toLocaleTimeString('zh-CN', {hour: '2-digit', minute: '2-digit'});
     };
 
-    const getStatusBadge = (status: string) => {
-        switch (status) {
+    const getStatusBadge = (msg: TextMessage) => {
+        switch (msg.status) {
             case 'sent':
                 return <span className="text-[10px] text-green-600">✓ 已发送</span>;
             case 'failed':
-                return <span className="text-[10px] text-red-600">✗ 失败</span>;
+                return (
+                    <span className="text-[10px] text-red-600" title={msg.failureCode}>
+                        ✗ 失败{msg.failureReason ? `：${msg.failureReason}` : ''}
+                    </span>
+                );
             case 'sending':
                 return <span className="text-[10px] text-gray-400">发送中...</span>;
+            case 'delivered':
+                return <span className="text-[10px] text-green-600">✓✓ 已送达</span>;
+            case 'delivery_failed':
+                return (
+                    <span className="text-[10px] text-red-600" title={msg.failureCode}>
+                        ✗ 未送达{msg.failureReason ? `：${msg.failureReason}` : ''}
+                    </span>
+                );
+            case 'pending_approval':
+                return <span className="text-[10px] text-amber-600">待审批</span>;
+            case 'rejected':
+                return (
+                    <span className="text-[10px] text-red-600">
+                        ✗ 已拒绝{msg.failureReason ? `：${msg.failureReason}` : ''}
+                    </span>
+                );
             default:
                 return null;
         }
@@ -344,8 +415,20 @@ export default function Messages() {
                                     </div>
                                     <div>
                                         <h3 className="text-sm font-bold text-gray-900">{selectedPeer}</h3>
-                                        <span className="text-xs text-gray-500">
+                                        <span
+                                            className="text-xs text-gray-500"
+                                            title={conversationStats && conversationStats.firstAt > 0
+                                                ? `首次联系 ${new Date(conversationStats.firstAt).toLocaleString('zh-CN')}，最近联系 ${new Date(conversationStats.lastAt).toLocaleString('zh-CN')}`
+                                                : undefined}
+                                        >
                                             共 {activeConversation?.messageCount || 0} 条消息
+                                            {conversationStats && conversationStats.totalCount > 0 && (
+                                                <>
+                                                    {` · 收 ${conversationStats.incomingCount} / 发 ${conversationStats.outgoingCount}`}
+                                                    {` · 月均 ${conversationStats.averagePerMonth}`}
+                                                    {conversationStats.busiestHours.length > 0 && ` · 常在 ${conversationStats.busiestHours.map(h => `${h}时`).join('、')}`}
+                                                </>
+                                            )}
                                         </span>
                                     </div>
                                 </div>
@@ -410,7 +493,21 @@ export default function Messages() {
                                                     className={`text-[10px] ${msg.type === 'outgoing' ? 'text-blue-600' : 'text-gray-400'}`}>
                                                     {formatTime(msg.createdAt)}
                                                 </span>
-                                                {msg.type === 'outgoing' && getStatusBadge(msg.status)}
+                                                {msg.type === 'outgoing' && getStatusBadge(msg)}
+                                                {msg.type === 'incoming' && (msg.ackedAt ? (
+                                                    <span className="text-[10px] text-green-600" title={msg.ackNote}>
+                                                        ✓ {msg.ackedBy || ''} 已确认{msg.ackNote ? `：${msg.ackNote}` : ''}
+                                                    </span>
+                                                ) : (
+                                                    <button
+                                                        onClick={() => ackMutation.mutate(msg.id)}
+                                                        disabled={ackMutation.isPending}
+                                                        className="text-[10px] text-gray-400 hover:text-blue-600 opacity-0 group-hover:opacity-100 transition-opacity"
+                                                        title="确认已处理，停止升级提醒"
+                                                    >
+                                                        确认处理
+                                                    </button>
+                                                ))}
                                             </div>
                                         </div>
                                     </div>
@@ -9,6 +9,7 @@ import {Select, SelectContent, SelectItem, SelectTrigger, SelectValue,} from '@/
 import {Textarea} from '@/components/ui/textarea';
 import {
     getNotificationChannels,
+    NOTIFICATION_EVENTS,
     type NotificationChannel,
     saveNotificationChannels,
     testNotificationChannel
@@ -57,6 +58,40 @@ interface FormValues {
     telegramProxyPassword: string
 }
 
+interface EventTogglesProps {
+    events: string[];
+    onChange: (events: string[]) => void;
+}
+
+// 渠道接收的事件类型，全部选中时保存为空（接收全部）
+function EventToggles({events, onChange}: EventTogglesProps) {
+    const selected = events.length > 0 ? events : NOTIFICATION_EVENTS.map((event) => event.value);
+    const toggle = (value: string, checked: boolean) => {
+        const next = checked ? [...selected, value] : selected.filter((event) => event !== value);
+        onChange(next.length === NOTIFICATION_EVENTS.length ? [] : next);
+    };
+
+    return (
+        <div>
+            <label className="block text-xs font-semibold text-gray-600 mb-2 uppercase tracking-wide">
+                接收的通知类型
+            </label>
+            <div className="flex flex-wrap gap-4">
+                {NOTIFICATION_EVENTS.map((event) => (
+                    <label key={event.value} className="inline-flex items-center gap-1.5 text-sm text-gray-700 cursor-pointer">
+                        <input
+                            type="checkbox"
+                            checked={selected.includes(event.value)}
+                            onChange={(e) => toggle(event.value, e.target.checked)}
+                        />
+                        {event.label}
+                    </label>
+                ))}
+            </div>
+        </div>
+    );
+}
+
 export default function NotificationChannels() {
     const queryClient = useQueryClient();
     const [formValues, setFormValues] = useState<FormValues>({
@@ -91,6 +126,9 @@ export default function NotificationChannels() {
         telegramProxyPassword: '',
     });
 
+    // 各渠道接收的事件类型
+    const [channelEvents, setChannelEvents] = useState<Record<string, string[]>>({});
+
     // 获取通知渠道列表
     const {data: channels = [], isLoading} = useQuery({
         queryKey: ['notificationChannels'],
@@ -160,8 +198,8 @@ export default function NotificationChannels() {
                     newFormValues.emailSubject = (channel.config?.subject as string) || '收到新短信 - {{from}}';
                 } else if (channel.type === 'telegram') {
                     newFormValues.telegramlEnabled = channel.enabled;
-                    newFormValues.telegramApiToken = (channel.config?.apiToken as string) || '';
-                    newFormValues.telegramUserid = (channel.config?.userid as string) || '';
+                    newFormValues.telegramApiToken = (channel.config?.botToken as string) || (channel.config?.apiToken as string) || '';
+                    newFormValues.telegramUserid = String(channel.config?.chatId ?? channel.config?.userid ?? '');
                     newFormValues.telegramProxyEnabled = (channel.config?.proxyEnabled as boolean)||false;
                     newFormValues.telegramProxyUrl = (channel.config?.proxyUrl as string) || '';
                     newFormValues.telegramProxyUsername = (channel.config?.proxyUsername as string) || '';
@@ -170,6 +208,7 @@ export default function NotificationChannels() {
             });
 
             setFormValues(newFormValues);
+            setChannelEvents(Object.fromEntries(channels.map((channel) => [channel.type, channel.events || []])));
         }
     }, [channels]);
 
@@ -178,6 +217,30 @@ export default function NotificationChannels() {
         setFormValues((prev) => ({...prev, [field]: value}));
     };
 
+    // 保留已有配置中的超时和重试策略（timeout、retries、retryBackoff），页面上未提供编辑
+    const policyOf = (type: string) => {
+        const config = channels.find((channel) => channel.type === type)?.config || {};
+        const policy: Record<string, unknown> = {};
+        for (const key of ['timeout', 'retries', 'retryBackoff']) {
+            if (config[key] !== undefined) {
+                policy[key] = config[key];
+            }
+        }
+        return policy;
+    };
+
+    const eventsOf = (type: string) => {
+        const events = channelEvents[type] || [];
+        return events.length > 0 ? events : undefined;
+    };
+
+    const eventToggles = (type: string) => (
+        <EventToggles
+            events={channelEvents[type] || []}
+            onChange={(events) => setChannelEvents((prev) => ({...prev, [type]: events}))}
+        />
+    );
+
     // 保存配置
     const handleSave = async () => {
         const newChannels: NotificationChannel[] = [];
@@ -187,7 +250,9 @@ export default function NotificationChannels() {
             newChannels.push({
                 type: 'dingtalk',
                 enabled: formValues.dingtalkEnabled,
+                events: eventsOf('dingtalk'),
                 config: {
+                    ...policyOf('dingtalk'),
                     secretKey: formValues.dingtalkSecretKey,
                     signSecret: formValues.dingtalkSignSecret,
                 },
@@ -199,7 +264,9 @@ export default function NotificationChannels() {
             newChannels.push({
                 type: 'wecom',
                 enabled: formValues.wecomEnabled,
+                events: eventsOf('wecom'),
                 config: {
+                    ...policyOf('wecom'),
                     secretKey: formValues.wecomSecretKey,
                 },
             });
@@ -210,7 +277,9 @@ export default function NotificationChannels() {
             newChannels.push({
                 type: 'feishu',
                 enabled: formValues.feishuEnabled,
+                events: eventsOf('feishu'),
                 config: {
+                    ...policyOf('feishu'),
                     secretKey: formValues.feishuSecretKey,
                     signSecret: formValues.feishuSignSecret,
                 },
@@ -232,7 +301,9 @@ export default function NotificationChannels() {
             newChannels.push({
                 type: 'webhook',
                 enabled: formValues.webhookEnabled,
+                events: eventsOf('webhook'),
                 config: {
+                    ...policyOf('webhook'),
                     url: formValues.webhookUrl,
                     method: formValues.webhookMethod,
                     contentType: formValues.webhookContentType,
@@ -247,7 +318,9 @@ export default function NotificationChannels() {
             newChannels.push({
                 type: 'email',
                 enabled: formValues.emailEnabled,
+                events: eventsOf('email'),
                 config: {
+                    ...policyOf('email'),
                     smtpHost: formValues.emailSmtpHost,
                     smtpPort: formValues.emailSmtpPort,
                     username: formValues.emailUsername,
@@ -268,9 +341,11 @@ export default function NotificationChannels() {
             newChannels.push({
                 type:'telegram',
                 enabled:formValues.telegramlEnabled,
+                events: eventsOf('telegram'),
                 config: {
-                    apiToken: formValues.telegramApiToken,
-                    userid: formValues.telegramUserid,
+                    ...policyOf('telegram'),
+                    botToken: formValues.telegramApiToken,
+                    chatId: formValues.telegramUserid,
                     proxyEnabled: formValues.telegramProxyEnabled,
                     proxyUrl: formValues.telegramProxyUrl,
                     proxyUsername: formValues.telegramProxyUsername,
@@ -279,7 +354,11 @@ export default function NotificationChannels() {
             })
         }
 
-        saveMutation.mutate(newChannels);
+        // 保留已有配置中的工作时间（activeHours），页面上未提供编辑
+        saveMutation.mutate(newChannels.map((channel) => ({
+            ...channel,
+            activeHours: channels.find((existing) => existing.type === channel.type)?.activeHours,
+        })));
     };
 
     if (isLoading) {
@@ -390,6 +469,7 @@ export default function NotificationChannels() {
                                 </div>
                                 <p className="text-xs text-gray-400 mt-1.5">如果启用了加签，请填写 SEC 开头的密钥</p>
                             </div>
+                            {eventToggles('dingtalk')}
                         </CardContent>
                     )}
                 </Card>
@@ -466,6 +546,7 @@ export default function NotificationChannels() {
                                     className="bg-gray-50 border-gray-200 focus:bg-white focus:border-green-500 focus:ring-1 focus:ring-green-500 transition-all font-mono text-sm"
                                 />
                             </div>
+                            {eventToggles('wecom')}
                         </CardContent>
                     )}
                 </Card>
@@ -559,6 +640,7 @@ export default function NotificationChannels() {
                                             className="absolute right-3 top-1/2 -translate-y-1/2 text-gray-400"/>
                                 </div>
                             </div>
+                            {eventToggles('feishu')}
                         </CardContent>
                     )}
                 </Card>
@@ -680,7 +762,8 @@ export default function NotificationChannels() {
                                 <p className="text-xs text-gray-400 mt-1.5">
                                     支持模板变量：<code className="bg-gray-200 px-1 py-0.5 rounded">{'{{from}}'}</code>（发送方）、
                                     <code className="bg-gray-200 px-1 py-0.5 rounded">{'{{content}}'}</code>（短信内容）、
-                                    <code className="bg-gray-200 px-1 py-0.5 rounded">{'{{timestamp}}'}</code>（时间戳）
+                                    <code className="bg-gray-200 px-1 py-0.5 rounded">{'{{timestamp}}'}</code>（时间戳）、
+                                    <code className="bg-gray-200 px-1 py-0.5 rounded">{'{{fields.code}}'}</code>（提取的字段）
                                 </p>
                             </div>
 
@@ -712,6 +795,7 @@ export default function NotificationChannels() {
                                         <li><code className="bg-white px-1.5 py-0.5 rounded border border-blue-200">{'{{from}}'}</code> - 短信发送方手机号</li>
                                         <li><code className="bg-white px-1.5 py-0.5 rounded border border-blue-200">{'{{content}}'}</code> - 短信内容</li>
                                         <li><code className="bg-white px-1.5 py-0.5 rounded border border-blue-200">{'{{timestamp}}'}</code> - 接收时间（格式：2006-01-02 15:04:05）</li>
+                                        <li><code className="bg-white px-1.5 py-0.5 rounded border border-blue-200">{'{{fields.字段名}}'}</code> - 字段提取规则中命名分组提取的值，如验证码 {'{{fields.code}}'}</li>
                                     </ul>
                                     <p className="mt-2">示例模板：</p>
                                     <pre
@@ -724,6 +808,7 @@ export default function NotificationChannels() {
                     </pre>
                                 </div>
                             </div>
+                            {eventToggles('webhook')}
                         </CardContent>
                     )}
                 </Card>
@@ -879,7 +964,8 @@ export default function NotificationChannels() {
                                 <p className="text-xs text-gray-400 mt-1.5">
                                     支持模板变量：<code className="bg-gray-200 px-1 py-0.5 rounded">{'{{from}}'}</code>（发送方）、
                                     <code className="bg-gray-200 px-1 py-0.5 rounded">{'{{content}}'}</code>（短信内容）、
-                                    <code className="bg-gray-200 px-1 py-0.5 rounded">{'{{timestamp}}'}</code>（时间戳）
+                                    <code className="bg-gray-200 px-1 py-0.5 rounded">{'{{timestamp}}'}</code>（时间戳）、
+                                    <code className="bg-gray-200 px-1 py-0.5 rounded">{'{{fields.code}}'}</code>（提取的字段）
                                 </p>
                             </div>
 
@@ -900,6 +986,7 @@ export default function NotificationChannels() {
                                     </p>
                                 </div>
                             </div>
+                            {eventToggles('email')}
                         </CardContent>
                     )}
                 </Card>
@@ -984,7 +1071,7 @@ export default function NotificationChannels() {
                         <div className="space-y-3 rounded-lg border border-blue-100 bg-blue-50/40 p-3 animate-in fade-in duration-200">
                             <div>
                                 <label className="block text-xs font-semibold text-gray-600 mb-1 uppercase tracking-wide">
-                                    HTTP代理地址
+                                    代理地址（http / socks5）
                                 </label>
                                 <Input
                                     value={formValues.telegramProxyUrl}
@@ -1029,12 +1116,12 @@ export default function NotificationChannels() {
                             <div>
                                 <label
                                     className="block text-xs font-semibold text-gray-600 mb-2 uppercase tracking-wide">
-                                    apiToken <span className="text-red-500">*</span>
+                                    Bot Token <span className="text-red-500">*</span>
                                 </label>
                                 <Input
                                     value={formValues.telegramApiToken}
                                     onChange={(e) => updateField('telegramApiToken', e.target.value)}
-                                    placeholder="apioken"
+                                    placeholder="123456:ABC-DEF..."
                                     className="bg-gray-50 border-gray-200 focus:bg-white focus:border-blue-500 focus:ring-1 focus:ring-blue-500 transition-all font-mono text-sm"
                                 />
                             </div>
@@ -1042,20 +1129,21 @@ export default function NotificationChannels() {
                             <div>
                                 <label
                                     className="block text-xs font-semibold text-gray-600 mb-2 uppercase tracking-wide">
-                                    用户id
+                                    Chat ID <span className="text-red-500">*</span>
                                 </label>
                                 <div className="relative">
                                     <Input
                                         value={formValues.telegramUserid}
                                         onChange={(e) => updateField('telegramUserid', e.target.value)}
-                                        placeholder="userid"
+                                        placeholder="chat_id"
                                         className="bg-gray-50 border-gray-200 focus:bg-white focus:border-blue-500 focus:ring-1 focus:ring-blue-500 transition-all font-mono text-sm pr-10"
                                     />
                                     <Shield size={14}
                                             className="absolute right-3 top-1/2 -translate-y-1/2 text-gray-400"/>
                                 </div>
-                                <p className="text-xs text-gray-400 mt-1.5">使用@userinfobot机器人获取</p>
+                                <p className="text-xs text-gray-400 mt-1.5">个人使用 @userinfobot 获取，群组或频道以 -100 开头</p>
                             </div>
+                            {eventToggles('telegram')}
                         </CardContent>
                     )}
                 </Card>
@@ -5,6 +5,7 @@ import {toast} from 'sonner';
 import {Button} from '@/components/ui/button';
 import {Input} from '@/components/ui/input';
 import {Card, CardContent, CardHeader, CardTitle} from '@/components/ui/card';
+import {Select, SelectContent, SelectItem, SelectTrigger, SelectValue,} from '@/components/ui/select';
 import {
     Dialog,
     DialogContent,
@@ -19,7 +20,8 @@ import {
     getScheduledTasks,
     type ScheduledTask,
     type LastRunStatus,
-    triggerScheduledTask,
+    type MissedRunPolicy,
+    runScheduledTask,
     updateScheduledTask,
 } from '../api/scheduled_task';
 
@@ -27,8 +29,10 @@ interface TaskFormData {
     name: string;
     enabled: boolean;
     intervalDays: number;
+    cronSpec: string;
     phoneNumber: string;
     content: string;
+    missedRun: MissedRunPolicy;
 }
 
 export default function ScheduledTasksConfig() {
@@ -39,8 +43,10 @@ export default function ScheduledTasksConfig() {
         name: '',
         enabled: false,
         intervalDays: 90,
+        cronSpec: '',
         phoneNumber: '',
         content: '',
+        missedRun: 'once',
     });
 
     // 获取状态显示信息
@@ -124,10 +130,14 @@ export default function ScheduledTasksConfig() {
 
     // 触发任务 mutation
     const triggerMutation = useMutation({
-        mutationFn: triggerScheduledTask,
-        onSuccess: () => {
+        mutationFn: runScheduledTask,
+        onSuccess: (result) => {
             queryClient.invalidateQueries({queryKey: ['scheduledTasks']});
-            toast.success('任务已触发执行');
+            if (result.success) {
+                toast.success('任务已执行，短信已发送');
+            } else {
+                toast.error(`任务执行失败: ${result.error || '未知错误'}`);
+            }
         },
         onError: (error: any) => {
             console.error('触发任务失败:', error);
@@ -141,8 +151,10 @@ export default function ScheduledTasksConfig() {
             name: '',
             enabled: false,
             intervalDays: 90,
+            cronSpec: '',
             phoneNumber: '',
             content: '',
+            missedRun: 'once',
         });
     };
 
@@ -160,8 +172,10 @@ export default function ScheduledTasksConfig() {
             name: task.name,
             enabled: task.enabled,
             intervalDays: task.intervalDays,
+            cronSpec: task.cronSpec || '',
             phoneNumber: task.phoneNumber,
             content: task.content,
+            missedRun: task.missedRun || 'once',
         });
         setDialogOpen(true);
     };
@@ -181,7 +195,7 @@ export default function ScheduledTasksConfig() {
             toast.warning('请输入任务名称');
             return;
         }
-        if (!formData.intervalDays || formData.intervalDays <= 0) {
+        if (!formData.cronSpec.trim() && (!formData.intervalDays || formData.intervalDays <= 0)) {
             toast.warning('请输入有效的执行间隔天数（必须大于0）');
             return;
         }
@@ -288,8 +302,13 @@ export default function ScheduledTasksConfig() {
                                         <div className="flex-1 min-w-0">
                                             <span
                                                 className="text-xs text-gray-400 font-medium block mb-0.5">执行间隔</span>
-                                            <span
-                                                className="text-sm text-gray-700 font-semibold">每 {task.intervalDays} 天</span>
+                                            {task.cronSpec ? (
+                                                <span
+                                                    className="text-sm text-gray-700 font-mono font-semibold">{task.cronSpec}</span>
+                                            ) : (
+                                                <span
+                                                    className="text-sm text-gray-700 font-semibold">每 {task.intervalDays} 天</span>
+                                            )}
                                         </div>
                                     </div>
 
@@ -441,6 +460,7 @@ export default function ScheduledTasksConfig() {
                                     <Input
                                         type="number"
                                         min="1"
+                                        disabled={!!formData.cronSpec.trim()}
                                         value={formData.intervalDays}
                                         onChange={(e) => updateFormField('intervalDays', parseInt(e.target.value) || 0)}
                                         placeholder="90"
@@ -450,7 +470,7 @@ export default function ScheduledTasksConfig() {
                                         className="absolute right-3 top-1/2 -translate-y-1/2 text-xs text-gray-400 font-medium">天</span>
                                 </div>
                                 <p className="text-xs text-gray-400 mt-1.5">
-                                    任务执行的时间间隔
+                                    每天 8 点检查是否到期
                                 </p>
                             </div>
 
@@ -473,6 +493,50 @@ export default function ScheduledTasksConfig() {
                             </div>
                         </div>
 
+                        {/* Cron 表达式 */}
+                        <div>
+                            <label
+                                className="block text-xs font-semibold text-gray-600 mb-2 uppercase tracking-wide flex items-center gap-1.5">
+                                <Clock size={12} className="text-gray-400"/>
+                                Cron 表达式
+                            </label>
+                            <Input
+                                value={formData.cronSpec}
+                                onChange={(e) => updateFormField('cronSpec', e.target.value)}
+                                placeholder="例如：0 10 1 * *（每月 1 日 10 点）"
+                                className="bg-gray-50 border-gray-200 focus:bg-white focus:border-blue-500 focus:ring-1 focus:ring-blue-500 transition-all font-mono"
+                            />
+                            <p className="text-xs text-gray-400 mt-1.5">
+                                可选，格式为“分 时 日 月 周”，填写后按表达式执行并忽略执行间隔
+                            </p>
+                        </div>
+
+                        {/* 错过执行时间的处理方式 */}
+                        <div>
+                            <label
+                                className="block text-xs font-semibold text-gray-600 mb-2 uppercase tracking-wide flex items-center gap-1.5">
+                                <Calendar size={12} className="text-gray-400"/>
+                                错过执行时
+                            </label>
+                            <Select
+                                value={formData.missedRun}
+                                onValueChange={(value) => updateFormField('missedRun', value)}
+                            >
+                                <SelectTrigger
+                                    className="bg-gray-50 border-gray-200 focus:bg-white focus:border-blue-500 focus:ring-1 focus:ring-blue-500 transition-all">
+                                    <SelectValue/>
+                                </SelectTrigger>
+                                <SelectContent>
+                                    <SelectItem value="once">下一次检查时补执行</SelectItem>
+                                    <SelectItem value="immediate">服务启动后立即补执行</SelectItem>
+                                    <SelectItem value="skip">跳过，重新计算间隔</SelectItem>
+                                </SelectContent>
+                            </Select>
+                            <p className="text-xs text-gray-400 mt-1.5">
+                                服务停止期间错过执行时间时的处理方式
+                            </p>
+                        </div>
+
                         {/* 短信内容 */}
                         <div>
                             <label